@@ -0,0 +1,93 @@
+// Package main 提供文件加密解密命令行工具.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/agent"
+	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentKeyring string
+	agentSocket  string
+)
+
+func newAgentCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: i18n.T("agent.short"),
+		Long:  i18n.T("agent.long"),
+		RunE:  runAgent,
+	}
+
+	cmd.Flags().StringVarP(&agentKeyring, "keyring", "k", "", i18n.T("agent.flags.keyring"))
+	cmd.Flags().StringVar(&agentSocket, "socket", "", i18n.T("agent.flags.socket"))
+
+	_ = cmd.MarkFlagRequired("keyring")
+
+	return cmd
+}
+
+func runAgent(_ *cobra.Command, _ []string) error {
+	keyring, err := zjcrypto.LoadKeyring(agentKeyring)
+	if err != nil {
+		return fmt.Errorf("load keyring: %w", i18n.TranslateError("error.keyring_failed", err))
+	}
+	a, err := agent.New(keyring)
+	if err != nil {
+		return fmt.Errorf("create agent: %w", i18n.TranslateError("error.agent_failed", err))
+	}
+
+	sock := agentSocket
+	if sock == "" {
+		sock = defaultAgentSocket()
+	}
+	listener, err := agent.Listen(sock)
+	if err != nil {
+		return fmt.Errorf("listen: %w", i18n.TranslateError("error.agent_failed", err))
+	}
+	defer func() {
+		_ = os.Remove(sock)
+	}()
+
+	for _, entry := range keyring.Entries() {
+		fmt.Printf(i18n.T("serve.key")+"\n", entry.Fingerprint[:16], entry.Name)
+	}
+	// 套接字路径以 shell 语句输出，可直接粘贴到其他终端
+	fmt.Printf("%s=%s; export %s;\n", agent.SockEnv, sock, agent.SockEnv)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.Serve(listener)
+	}()
+
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = a.Close()
+		fmt.Fprintln(os.Stderr, i18n.T("agent.stopped"))
+	}
+	if err != nil {
+		return fmt.Errorf("agent: %w", i18n.TranslateError("error.agent_failed", err))
+	}
+	return nil
+}
+
+// defaultAgentSocket 返回默认套接字路径，优先使用 $XDG_RUNTIME_DIR.
+func defaultAgentSocket() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "fzjjyz-agent.sock")
+	}
+	return filepath.Join(os.TempDir(), "fzjjyz-"+strconv.Itoa(os.Getuid()), "agent.sock")
+}
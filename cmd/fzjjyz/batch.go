@@ -0,0 +1,195 @@
+// Package main 提供文件加密解密命令行工具.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"codeberg.org/jiangfire/fzjjyz/cmd/fzjjyz/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
+	"codeberg.org/jiangfire/fzjjyz/internal/storage"
+)
+
+// batchJob 描述批量处理中的单个文件.
+type batchJob struct {
+	input  string
+	output string
+	err    error // 准备阶段的错误，非空时直接记为失败
+}
+
+// collectInputs 合并 -i 参数和位置参数，并判断是否进入批量模式.
+// 多个输入或使用通配符时进入批量模式，单个普通路径保持原有的单文件流程.
+func collectInputs(flagInputs, args []string) ([]string, bool, error) {
+	patterns := make([]string, 0, len(flagInputs)+len(args))
+	patterns = append(patterns, flagInputs...)
+	patterns = append(patterns, args...)
+	if len(patterns) == 0 {
+		return nil, false, errors.New(i18n.T("error.no_input_files"))
+	}
+
+	files, glob, err := expandInputPatterns(patterns)
+	if err != nil {
+		return nil, false, err
+	}
+	return files, glob || len(files) > 1, nil
+}
+
+// expandInputPatterns 展开通配符，保持参数顺序并去除重复路径.
+// 通配符只匹配普通文件；没有任何匹配的通配符视为错误.
+func expandInputPatterns(patterns []string) ([]string, bool, error) {
+	var files []string
+	seen := make(map[string]bool)
+	glob := false
+
+	add := func(path string) {
+		clean := filepath.Clean(path)
+		if !seen[clean] {
+			seen[clean] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, pattern := range patterns {
+		// 存储 URL 不做通配符展开（查询参数中的 ? 不是通配符）
+		if !strings.ContainsAny(pattern, "*?[") || storage.IsRemote(pattern) {
+			add(pattern)
+			continue
+		}
+		glob = true
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, false, fmt.Errorf(i18n.T("error.invalid_input_pattern"), pattern, err)
+		}
+		matched := false
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.Mode().IsRegular() {
+				add(match)
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, false, fmt.Errorf(i18n.T("error.no_input_match"), pattern)
+		}
+	}
+	return files, glob, nil
+}
+
+// prepareBatchOutputDir 检查并创建批量模式的输出目录.
+// 未指定 -o 时返回空字符串，输出文件写在各自输入文件旁边.
+func prepareBatchOutputDir(output string) (string, error) {
+	if output == "" {
+		return "", nil
+	}
+	if err := rejectRemote("--output", output); err != nil {
+		return "", err
+	}
+	info, err := os.Stat(output)
+	if err == nil {
+		if !info.IsDir() {
+			return "", fmt.Errorf(i18n.T("error.batch_output_not_dir"), output)
+		}
+		return output, nil
+	}
+	if err := os.MkdirAll(output, 0750); err != nil {
+		return "", fmt.Errorf(i18n.T("error.cannot_create_dir"), output, err)
+	}
+	return output, nil
+}
+
+// batchOutputPath 返回输出文件路径，outputDir 为空时与输入文件位于同一目录.
+func batchOutputPath(input, outputDir, name string) string {
+	if outputDir == "" {
+		outputDir = filepath.Dir(input)
+	}
+	return filepath.Join(outputDir, name)
+}
+
+// checkBatchOutputs 检查输出冲突：已存在的文件（未使用 --force）和多个输入写入同一输出.
+func checkBatchOutputs(jobs []batchJob, force bool) {
+	owners := make(map[string]string)
+	for i := range jobs {
+		job := &jobs[i]
+		if job.err != nil {
+			continue
+		}
+		key := filepath.Clean(job.output)
+		if owner, ok := owners[key]; ok {
+			job.err = fmt.Errorf(i18n.T("error.batch_duplicate_output"), job.output, owner)
+			continue
+		}
+		owners[key] = job.input
+		//nolint:wrapcheck
+		job.err = utils.CheckOutputConflict(job.output, force)
+	}
+}
+
+// batchWorkers 计算并发数：0 表示使用 CPU 核数，且不超过任务数.
+func batchWorkers(jobs, total int) (int, error) {
+	if jobs < 0 {
+		return 0, fmt.Errorf(i18n.T("error.invalid_jobs"), jobs)
+	}
+	if jobs == 0 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs > total {
+		jobs = total
+	}
+	return max(jobs, 1), nil
+}
+
+// runBatch 以固定数量的 worker 并发处理任务，按完成顺序逐行输出结果，返回失败数量.
+func runBatch(jobs []batchJob, workers int, process func(job batchJob) error) int {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		done   int
+		failed int
+	)
+
+	report := func(job batchJob, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		if err != nil {
+			failed++
+			fmt.Printf("  [%d/%d] ❌ %s: %v\n", done, len(jobs), job.input, err)
+			return
+		}
+		fmt.Printf("  [%d/%d] ✅ %s -> %s\n", done, len(jobs), job.input, job.output)
+	}
+
+	queue := make(chan batchJob)
+	for range workers {
+		wg.Go(func() {
+			for job := range queue {
+				err := job.err
+				if err == nil {
+					err = process(job)
+				}
+				report(job, err)
+			}
+		})
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	return failed
+}
+
+// finishBatch 输出批量处理汇总，存在失败时返回错误以使用非零状态退出.
+func finishBatch(total, failed int) error {
+	fmt.Printf("\n"+i18n.T("batch.summary")+"\n", total-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf(i18n.T("error.batch_failed"), failed, total)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+func TestExpandInputPatterns(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0600); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "d.txt"), 0750); err != nil {
+		t.Fatalf("创建测试目录失败: %v", err)
+	}
+
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	c := filepath.Join(dir, "c.log")
+
+	files, glob, err := expandInputPatterns([]string{c, filepath.Join(dir, "*.txt"), a})
+	if err != nil {
+		t.Fatalf("展开通配符失败: %v", err)
+	}
+	if !glob {
+		t.Error("应识别出通配符")
+	}
+	if want := []string{c, a, b}; !slices.Equal(files, want) {
+		t.Errorf("期望 %v，实际 %v", want, files)
+	}
+
+	files, glob, err = expandInputPatterns([]string{filepath.Join(dir, "missing.txt")})
+	if err != nil || glob || len(files) != 1 {
+		t.Errorf("普通路径应原样保留: %v %v %v", files, glob, err)
+	}
+
+	if _, _, err := expandInputPatterns([]string{filepath.Join(dir, "*.bin")}); err == nil {
+		t.Error("没有匹配的通配符应返回错误")
+	}
+}
+
+func TestCollectInputs(t *testing.T) {
+	if _, _, err := collectInputs(nil, nil); err == nil {
+		t.Error("没有输入时应返回错误")
+	}
+
+	files, batch, err := collectInputs([]string{"one.txt"}, nil)
+	if err != nil || batch || len(files) != 1 {
+		t.Errorf("单个输入不应进入批量模式: %v %v %v", files, batch, err)
+	}
+
+	files, batch, err = collectInputs([]string{"one.txt"}, []string{"two.txt", "one.txt"})
+	if err != nil || !batch || !slices.Equal(files, []string{"one.txt", "two.txt"}) {
+		t.Errorf("多个输入应进入批量模式并去重: %v %v %v", files, batch, err)
+	}
+}
+
+func TestCheckBatchOutputs(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "exists.fzj")
+	if err := os.WriteFile(existing, nil, 0600); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	jobs := []batchJob{
+		{input: "x/a.txt", output: filepath.Join(dir, "a.txt.fzj")},
+		{input: "y/a.txt", output: filepath.Join(dir, "a.txt.fzj")},
+		{input: "exists", output: existing},
+	}
+	checkBatchOutputs(jobs, false)
+	if jobs[0].err != nil {
+		t.Errorf("第一个输出不应冲突: %v", jobs[0].err)
+	}
+	if jobs[1].err == nil {
+		t.Error("重复的输出应被拒绝")
+	}
+	if jobs[2].err == nil {
+		t.Error("已存在的输出在未使用 --force 时应被拒绝")
+	}
+
+	jobs[2].err = nil
+	checkBatchOutputs(jobs[2:], true)
+	if jobs[2].err != nil {
+		t.Errorf("使用 --force 时应允许覆盖: %v", jobs[2].err)
+	}
+}
+
+func TestBatchWorkers(t *testing.T) {
+	if _, err := batchWorkers(-1, 3); err == nil {
+		t.Error("负数并发应返回错误")
+	}
+	if n, _ := batchWorkers(8, 3); n != 3 {
+		t.Errorf("并发数不应超过任务数: %d", n)
+	}
+	if n, _ := batchWorkers(0, 100); n < 1 {
+		t.Errorf("默认并发数应至少为 1: %d", n)
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	jobs := []batchJob{
+		{input: "ok1"},
+		{input: "fail"},
+		{input: "ok2"},
+		{input: "prepared", err: errors.New("prepare failed")},
+	}
+
+	var calls atomic.Int32
+	failed := runBatch(jobs, 2, func(job batchJob) error {
+		calls.Add(1)
+		if job.input == "fail" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if failed != 2 {
+		t.Errorf("期望 2 个失败，实际 %d", failed)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("准备阶段失败的任务不应被处理，实际调用 %d 次", calls.Load())
+	}
+	if err := finishBatch(len(jobs), failed); err == nil {
+		t.Error("存在失败时应返回错误")
+	}
+	if err := finishBatch(len(jobs), 0); err != nil {
+		t.Errorf("全部成功时不应返回错误: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+// Package main 提供文件加密解密命令行工具.
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"codeberg.org/jiangfire/fzjjyz/cmd/fzjjyz/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkTreeDir       string
+	checkTreeVerifyKey string
+	checkTreeOnly      []string
+)
+
+func newCheckTreeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check-tree",
+		Short: i18n.T("check-tree.short"),
+		Long:  i18n.T("check-tree.long"),
+		RunE:  runCheckTree,
+	}
+
+	cmd.Flags().StringVarP(&checkTreeDir, "dir", "d", "", i18n.T("check-tree.flags.dir"))
+	cmd.Flags().StringVarP(&checkTreeVerifyKey, "verify-key", "s", "", i18n.T("check-tree.flags.verify-key"))
+	cmd.Flags().StringArrayVar(&checkTreeOnly, "only", nil, i18n.T("check-tree.flags.only"))
+
+	_ = cmd.MarkFlagRequired("dir")
+	_ = cmd.MarkFlagRequired("verify-key")
+
+	return cmd
+}
+
+func runCheckTree(_ *cobra.Command, _ []string) error {
+	//nolint:wrapcheck
+	if err := utils.ValidateInputDir(checkTreeDir); err != nil {
+		return err
+	}
+	if err := zjcrypto.ValidateZipPatterns(checkTreeOnly); err != nil {
+		return fmt.Errorf("--only: %w", i18n.TranslateError("error.invalid_pattern", err))
+	}
+
+	dilithiumPub, err := utils.LoadDilithiumVerifyKey(checkTreeVerifyKey)
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	manifest, err := zjcrypto.ReadTreeManifest(checkTreeDir, dilithiumPub)
+	if err != nil {
+		return fmt.Errorf("manifest: %w", i18n.TranslateError("error.manifest_invalid", err))
+	}
+
+	report, err := zjcrypto.CheckTreeAgainstManifest(manifest, checkTreeDir, checkTreeOnly)
+	if err != nil {
+		return fmt.Errorf("check failed: %w", i18n.TranslateError("error.check_tree_failed", err))
+	}
+
+	printTreeDiffs(report.Diffs)
+	fmt.Printf(i18n.T("check-tree.summary")+"\n", report.Entries, report.Verified, len(report.Diffs))
+
+	if report.HasDifferences() {
+		return errors.New(i18n.T("error.tree_differs_manifest"))
+	}
+	fmt.Println(i18n.T("check-tree.intact"))
+	return nil
+}
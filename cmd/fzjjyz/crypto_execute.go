@@ -1,9 +1,12 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"codeberg.org/jiangfire/fzjjyz/cmd/fzjjyz/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
 	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
-	"github.com/cloudflare/circl/sign/dilithium/mode3"
 )
 
 func calculateBufferSizeFromFile(path string, overrideKB int) int {
@@ -27,29 +30,37 @@ func calculateBufferSizeFromLength(size int64, overrideKB int) int {
 func runEncryptWithMode(
 	inputPath, outputPath string,
 	hybridPub *zjcrypto.HybridPublicKey,
-	dilithiumPriv *mode3.PrivateKey,
+	dilithiumPriv zjcrypto.SigningKey,
 	streaming bool,
 	bufferSize int,
+	compression zjcrypto.CompressionOptions,
 ) error {
 	if streaming {
-		return zjcrypto.EncryptFileStreaming(
-			inputPath, outputPath,
+		encryptor, err := zjcrypto.NewStreamingEncryptor(
 			hybridPub.Kyber, hybridPub.ECDH,
 			dilithiumPriv,
 			bufferSize,
 		)
+		if err != nil {
+			//nolint:wrapcheck
+			return err
+		}
+		encryptor.SetCompression(compression)
+		//nolint:wrapcheck
+		return encryptor.EncryptFile(inputPath, outputPath)
 	}
-	return zjcrypto.EncryptFile(
+	return zjcrypto.EncryptFileWithCompression(
 		inputPath, outputPath,
 		hybridPub.Kyber, hybridPub.ECDH,
 		dilithiumPriv,
+		compression,
 	)
 }
 
 func runDecryptWithMode(
 	inputPath, outputPath string,
 	hybridPriv *zjcrypto.HybridPrivateKey,
-	dilithiumPub *mode3.PublicKey,
+	dilithiumPub zjcrypto.VerifyingKey,
 	streaming bool,
 	bufferSize int,
 ) error {
@@ -67,3 +78,46 @@ func runDecryptWithMode(
 		dilithiumPub,
 	)
 }
+
+// decryptArchiveToMemory 将加密的目录存档解密为 ZIP 数据.
+// 使用随机临时文件中转，读取后立即删除，减少明文驻留时间窗口.
+func decryptArchiveToMemory(
+	inputPath string,
+	hybridPriv *zjcrypto.HybridPrivateKey,
+	dilithiumPub zjcrypto.VerifyingKey,
+	streaming bool,
+	bufferSize int,
+) ([]byte, error) {
+	// 使用随机临时文件，避免固定路径带来的覆盖和竞争风险
+	tempZipFile, err := os.CreateTemp("", "fzjjyz-decrypt-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("error.cannot_open_temp"), err)
+	}
+	tempZipPath := tempZipFile.Name()
+	defer func() {
+		_ = os.Remove(tempZipPath) // #nosec G703 - 忽略清理错误，不影响主流程
+	}()
+	if closeErr := tempZipFile.Close(); closeErr != nil {
+		return nil, fmt.Errorf(i18n.T("error.cannot_open_temp"), closeErr)
+	}
+
+	if err := runDecryptWithMode(
+		inputPath,
+		tempZipPath,
+		hybridPriv,
+		dilithiumPub,
+		streaming,
+		bufferSize,
+	); err != nil {
+		return nil, fmt.Errorf("decrypt failed: %w",
+			i18n.TranslateError("error.decrypt_failed", err))
+	}
+
+	// 读取解密的ZIP数据
+	zipData, err := os.ReadFile(tempZipPath) // #nosec G703,G304
+	if err != nil {
+		return nil, fmt.Errorf("cannot read data: %w",
+			i18n.TranslateError("error.cannot_read_data", err))
+	}
+	return zipData, nil
+}
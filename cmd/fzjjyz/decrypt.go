@@ -12,11 +12,11 @@ import (
 	"codeberg.org/jiangfire/fzjjyz/internal/format"
 	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
 	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
-	"github.com/cloudflare/circl/sign/dilithium/mode3"
 	"github.com/spf13/cobra"
 )
 
 var (
+	decryptInputs     []string
 	decryptInput      string
 	decryptOutput     string
 	decryptPrivKey    string
@@ -24,43 +24,67 @@ var (
 	decryptForce      bool
 	decryptBufferSize int
 	decryptStreaming  bool
+	decryptJobs       int
 )
 
 func newDecryptCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "decrypt",
+		Use:   "decrypt [files...]",
 		Short: i18n.T("decrypt.short"),
 		Long:  i18n.T("decrypt.long"),
+		Args:  cobra.ArbitraryArgs,
 		RunE:  runDecrypt,
 	}
 
-	cmd.Flags().StringVarP(&decryptInput, "input", "i", "", i18n.T("decrypt.flags.input"))
+	cmd.Flags().StringArrayVarP(&decryptInputs, "input", "i", nil, i18n.T("decrypt.flags.input"))
 	cmd.Flags().StringVarP(&decryptOutput, "output", "o", "", i18n.T("decrypt.flags.output"))
 	cmd.Flags().StringVarP(&decryptPrivKey, "private-key", "p", "", i18n.T("decrypt.flags.private-key"))
 	cmd.Flags().StringVarP(&decryptVerifyKey, "verify-key", "s", "", i18n.T("decrypt.flags.verify-key"))
 	cmd.Flags().BoolVarP(&decryptForce, "force", "f", false, i18n.T("decrypt.flags.force"))
 	cmd.Flags().IntVar(&decryptBufferSize, "buffer-size", 0, i18n.T("decrypt.flags.buffer-size"))
 	cmd.Flags().BoolVar(&decryptStreaming, "streaming", true, i18n.T("decrypt.flags.streaming"))
+	cmd.Flags().IntVarP(&decryptJobs, "jobs", "j", 0, i18n.T("decrypt.flags.jobs"))
 
-	_ = cmd.MarkFlagRequired("input")
 	_ = cmd.MarkFlagRequired("private-key")
 
 	return cmd
 }
 
-func runDecrypt(_ *cobra.Command, _ []string) error {
+func runDecrypt(_ *cobra.Command, args []string) error {
+	inputs, batch, err := collectInputs(decryptInputs, args)
+	if err != nil {
+		return err
+	}
+	if batch {
+		return executeDecryptBatch(inputs)
+	}
+	decryptInput = inputs[0]
 	return executeDecryptCommand()
 }
 
 func executeDecryptCommand() error {
-	// 步骤1: 验证输入
+	// 步骤1: 验证输入（远程输入先下载到本地中转文件）
+	if err := rejectRemote("--output", decryptOutput); err != nil {
+		return err
+	}
+	stage, err := stageRemoteInput(&decryptInput)
+	if err != nil {
+		return err
+	}
+	defer stage.cleanup()
 	//nolint:wrapcheck
 	if err := utils.ValidateInputFile(decryptInput); err != nil {
 		return err
 	}
+	// 分卷输入校验分卷头后直接从分卷解密
+	volumes, err := openVolumeInput(decryptInput, decryptVerifyKey)
+	if err != nil {
+		return err
+	}
+	defer closeVolumeInput(volumes)
 
 	// 步骤2: 解析文件头
-	header, err := parseDecryptHeader()
+	header, err := parseDecryptHeader(volumes)
 	if err != nil {
 		return err
 	}
@@ -77,16 +101,16 @@ func executeDecryptCommand() error {
 	}
 
 	// 步骤4: 执行解密
-	if err := executeDecrypt(reporter, hybridPriv, dilithiumPub, header); err != nil {
+	if err := executeDecrypt(reporter, hybridPriv, dilithiumPub, header, volumes); err != nil {
 		return err
 	}
 
 	// 步骤5: 显示结果
-	return showDecryptResult(header)
+	return showDecryptResult(header, volumes)
 }
 
-func parseDecryptHeader() (*format.FileHeader, error) {
-	headerFile, err := os.Open(decryptInput) // #nosec G304 - decryptInput 来自用户输入，已通过前置校验
+func parseDecryptHeader(volumes *zjcrypto.VolumeReader) (*format.FileHeader, error) {
+	headerFile, err := openEncryptedInput(decryptInput, volumes)
 	if err != nil {
 		return nil, fmt.Errorf(i18n.T("error.cannot_open_file"), err)
 	}
@@ -128,7 +152,7 @@ func safeDefaultOutputFromHeader(headerFilename string) (string, error) {
 	return base, nil
 }
 
-func loadDecryptKeys(reporter *utils.ProgressReporter) (*zjcrypto.HybridPrivateKey, *mode3.PublicKey, error) {
+func loadDecryptKeys(reporter *utils.ProgressReporter) (*zjcrypto.HybridPrivateKey, zjcrypto.VerifyingKey, error) {
 	reporter.Step("progress.loading_keys")
 
 	// 加载私钥
@@ -159,8 +183,9 @@ func loadDecryptKeys(reporter *utils.ProgressReporter) (*zjcrypto.HybridPrivateK
 func executeDecrypt(
 	reporter *utils.ProgressReporter,
 	hybridPriv *zjcrypto.HybridPrivateKey,
-	dilithiumPub *mode3.PublicKey,
+	dilithiumPub zjcrypto.VerifyingKey,
 	header *format.FileHeader,
+	volumes *zjcrypto.VolumeReader,
 ) error {
 	// 显示详细信息
 	reporter.InfoString("file_info.encrypted_file", decryptInput)
@@ -178,14 +203,20 @@ func executeDecrypt(
 
 	// 执行解密
 	reporter.Step("progress.decrypting")
-	if err := runDecryptWithMode(
-		decryptInput,
-		decryptOutput,
-		hybridPriv,
-		dilithiumPub,
-		decryptStreaming,
-		bufSize,
-	); err != nil {
+	var err error
+	if volumes != nil {
+		err = zjcrypto.DecryptVolumes(volumes, decryptOutput, hybridPriv.Kyber, hybridPriv.ECDH, dilithiumPub)
+	} else {
+		err = runDecryptWithMode(
+			decryptInput,
+			decryptOutput,
+			hybridPriv,
+			dilithiumPub,
+			decryptStreaming,
+			bufSize,
+		)
+	}
+	if err != nil {
 		reporter.Failed()
 		return fmt.Errorf("decrypt failed: %w",
 			i18n.TranslateError("error.decrypt_failed", err))
@@ -199,14 +230,19 @@ func executeDecrypt(
 	return nil
 }
 
-func showDecryptResult(header *format.FileHeader) error {
+func showDecryptResult(header *format.FileHeader, volumes *zjcrypto.VolumeReader) error {
 	decryptedInfo, err := os.Stat(decryptOutput)
 	if err != nil {
 		fmt.Println("\n" + i18n.T("status.failed"))
 		return nil //nolint:nilerr
 	}
 
-	encryptedInfo, _ := os.Stat(decryptInput)
+	var encryptedSize int64
+	if volumes != nil {
+		encryptedSize = volumes.Size()
+	} else if encryptedInfo, err := os.Stat(decryptInput); err == nil {
+		encryptedSize = encryptedInfo.Size()
+	}
 
 	reporter := utils.NewProgressReporter(1, true)
 	reporter.Summary("status.success_decrypt")
@@ -214,10 +250,77 @@ func showDecryptResult(header *format.FileHeader) error {
 	summary := i18n.T("file_info.decrypt_summary")
 	fmt.Printf("%s\\n",
 		fmt.Sprintf(summary,
-			filepath.Base(decryptInput), encryptedInfo.Size(),
+			filepath.Base(decryptInput), encryptedSize,
 			filepath.Base(decryptOutput), decryptedInfo.Size(),
 			header.Filename,
 			format.UnixTime(header.Timestamp)))
 
 	return nil
 }
+
+// executeDecryptBatch 加载一次密钥后并发解密多个文件.
+// 输出文件名取自各文件头中的原始文件名，指定 -o 时将其作为输出目录.
+func executeDecryptBatch(inputs []string) error {
+	workers, err := batchWorkers(decryptJobs, len(inputs))
+	if err != nil {
+		return err
+	}
+	outputDir, err := prepareBatchOutputDir(decryptOutput)
+	if err != nil {
+		return err
+	}
+
+	reporter := utils.NewProgressReporter(2, verbose)
+	hybridPriv, dilithiumPub, err := loadDecryptKeys(reporter)
+	if err != nil {
+		return err
+	}
+
+	jobs := make([]batchJob, len(inputs))
+	for i, input := range inputs {
+		jobs[i] = batchJob{input: input}
+		name, err := batchDecryptName(input)
+		if err != nil {
+			jobs[i].err = err
+			continue
+		}
+		jobs[i].output = batchOutputPath(input, outputDir, name)
+	}
+	checkBatchOutputs(jobs, decryptForce)
+
+	reporter.Step("progress.decrypting")
+	fmt.Printf(i18n.T("batch.files")+"\n", len(jobs), workers)
+	failed := runBatch(jobs, workers, func(job batchJob) error {
+		return runDecryptWithMode(
+			job.input,
+			job.output,
+			hybridPriv,
+			dilithiumPub,
+			decryptStreaming,
+			calculateBufferSizeFromFile(job.input, decryptBufferSize),
+		)
+	})
+
+	return finishBatch(len(jobs), failed)
+}
+
+// batchDecryptName 读取文件头并返回安全的原始文件名.
+func batchDecryptName(input string) (string, error) {
+	//nolint:wrapcheck
+	if err := utils.ValidateInputFile(input); err != nil {
+		return "", err
+	}
+	headerFile, err := os.Open(input) // #nosec G304 - 文件路径来自用户输入，已通过参数验证
+	if err != nil {
+		return "", fmt.Errorf(i18n.T("error.cannot_open_file"), err)
+	}
+	defer func() {
+		_ = headerFile.Close()
+	}()
+
+	header, err := format.ParseFileHeader(headerFile)
+	if err != nil {
+		return "", fmt.Errorf(i18n.T("error.parse_header_failed"), err)
+	}
+	return safeDefaultOutputFromHeader(header.Filename)
+}
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,7 +11,6 @@ import (
 	"codeberg.org/jiangfire/fzjjyz/internal/format"
 	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
 	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
-	"github.com/cloudflare/circl/sign/dilithium/mode3"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +22,9 @@ var (
 	decryptDirForce      bool
 	decryptDirBufferSize int
 	decryptDirStreaming  bool
+	decryptDirLimits     extractLimitFlags
+	decryptDirOnly       []string
+	decryptDirMirror     bool
 )
 
 func newDecryptDirCmd() *cobra.Command {
@@ -39,6 +42,9 @@ func newDecryptDirCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(&decryptDirForce, "force", "f", false, i18n.T("decrypt-dir.flags.force"))
 	cmd.Flags().IntVar(&decryptDirBufferSize, "buffer-size", 0, i18n.T("decrypt-dir.flags.buffer-size"))
 	cmd.Flags().BoolVar(&decryptDirStreaming, "streaming", true, i18n.T("decrypt-dir.flags.streaming"))
+	decryptDirLimits.register(cmd)
+	cmd.Flags().StringArrayVar(&decryptDirOnly, "only", nil, i18n.T("decrypt-dir.flags.only"))
+	cmd.Flags().BoolVar(&decryptDirMirror, "mirror", false, i18n.T("decrypt-dir.flags.mirror"))
 
 	_ = cmd.MarkFlagRequired("input")
 	_ = cmd.MarkFlagRequired("output")
@@ -49,11 +55,41 @@ func newDecryptDirCmd() *cobra.Command {
 
 //nolint:gocognit,funlen
 func runDecryptDir(_ *cobra.Command, _ []string) error {
-	// 验证输入文件
-	//nolint:wrapcheck
-	if err := utils.ValidateInputFile(decryptDirInput); err != nil {
+	// 验证输入文件（镜像模式为目录，远程存档先下载到本地中转文件，分卷校验分卷头后直接读取）
+	if err := rejectRemote("--output", decryptDirOutput); err != nil {
 		return err
 	}
+	var volumes *zjcrypto.VolumeReader
+	if decryptDirMirror {
+		if err := rejectRemote("--input", decryptDirInput); err != nil {
+			return err
+		}
+		//nolint:wrapcheck
+		if err := utils.ValidateInputDir(decryptDirInput); err != nil {
+			return err
+		}
+	} else {
+		stage, err := stageRemoteInput(&decryptDirInput)
+		if err != nil {
+			return err
+		}
+		defer stage.cleanup()
+		//nolint:wrapcheck
+		if err := utils.ValidateInputFile(decryptDirInput); err != nil {
+			return err
+		}
+		if volumes, err = openVolumeInput(decryptDirInput, decryptDirVerifyKey); err != nil {
+			return err
+		}
+		defer closeVolumeInput(volumes)
+	}
+
+	// 解析解压限制
+	extractOpts, err := buildExtractOptions()
+	if err != nil {
+		return fmt.Errorf("invalid extract limits: %w",
+			i18n.TranslateError("error.invalid_extract_limit", err))
+	}
 
 	// 验证输出目录（如果已存在）
 	outputInfo, err := os.Stat(decryptDirOutput)
@@ -70,8 +106,18 @@ func runDecryptDir(_ *cobra.Command, _ []string) error {
 		}
 	}
 
+	if decryptDirMirror {
+		return runDecryptDirMirror()
+	}
+
+	// --force 时输出目录中原有的文件不属于存档，校验清单时不视为新增
+	existing, err := existingTreePaths(decryptDirOutput)
+	if err != nil {
+		return fmt.Errorf(i18n.T("error.cannot_read_dir"), decryptDirOutput, err)
+	}
+
 	// 读取文件头以获取信息
-	headerFile, err := os.Open(decryptDirInput) // #nosec G304 - 文件路径来自用户输入，已通过参数验证
+	headerFile, err := openEncryptedInput(decryptDirInput, volumes)
 	if err != nil {
 		return fmt.Errorf(i18n.T("error.cannot_open_file"), err)
 	}
@@ -94,6 +140,7 @@ func runDecryptDir(_ *cobra.Command, _ []string) error {
 			fmt.Printf("  %s: %s\n", i18n.T("status.verify_key"), decryptDirVerifyKey)
 		}
 		fmt.Printf("  %s: %s\n", i18n.T("file_info.original_filename"), header.Filename)
+		fmt.Printf("  %s: %s\n", i18n.T("file_info.extract_limits"), describeExtractOptions(extractOpts))
 	}
 
 	// [1/4] 加载密钥
@@ -105,7 +152,7 @@ func runDecryptDir(_ *cobra.Command, _ []string) error {
 		return err
 	}
 
-	var dilithiumPub = (*mode3.PublicKey)(nil)
+	var dilithiumPub = zjcrypto.VerifyingKey(nil)
 	if decryptDirVerifyKey != "" {
 		dilithiumPub, err = utils.LoadDilithiumVerifyKey(decryptDirVerifyKey)
 		if err != nil {
@@ -118,6 +165,10 @@ func runDecryptDir(_ *cobra.Command, _ []string) error {
 	}
 	fmt.Println(i18n.T("status.done"))
 
+	if header.IsIndexedArchive() {
+		return extractIndexedArchive(header, hybridPriv, dilithiumPub, extractOpts, volumes)
+	}
+
 	// [2/4] 解密数据
 	fmt.Printf("[2/4] %s ", i18n.T("progress.decrypting"))
 
@@ -128,64 +179,44 @@ func runDecryptDir(_ *cobra.Command, _ []string) error {
 		fmt.Printf(i18n.T("file_info.buffer_size")+"\n", bufSize/1024)
 	}
 
-	// 使用随机临时文件，避免固定路径带来的覆盖和竞争风险
-	tempZipFile, err := os.CreateTemp("", "fzjjyz-decrypt-*.zip")
-	if err != nil {
-		fmt.Println(i18n.T("status.failed"))
-		return fmt.Errorf(i18n.T("error.cannot_open_temp"), err)
-	}
-	tempZipPath := tempZipFile.Name()
-	if closeErr := tempZipFile.Close(); closeErr != nil {
-		fmt.Println(i18n.T("status.failed"))
-		return fmt.Errorf(i18n.T("error.cannot_open_temp"), closeErr)
-	}
-
-	if err := runDecryptWithMode(
-		decryptDirInput,
-		tempZipPath,
-		hybridPriv,
-		dilithiumPub,
-		decryptDirStreaming,
-		bufSize,
-	); err != nil {
-		fmt.Println(i18n.T("status.failed"))
-		return fmt.Errorf("decrypt failed: %w",
-			i18n.TranslateError("error.decrypt_failed", err))
-	}
-	defer func() {
-		if removeErr := os.Remove(tempZipPath); removeErr != nil {
-			_ = removeErr // 忽略清理错误，不影响主流程
+	var zipData []byte
+	if volumes != nil {
+		zipData, err = zjcrypto.DecryptVolumesCore(volumes, hybridPriv.Kyber, hybridPriv.ECDH, dilithiumPub)
+		if err != nil {
+			err = fmt.Errorf("decrypt failed: %w", i18n.TranslateError("error.decrypt_failed", err))
 		}
-	}() // 清理临时文件
-
-	// 读取解密的ZIP数据
-	zipData, err := os.ReadFile(tempZipPath) // #nosec G703,G304
+	} else {
+		zipData, err = decryptArchiveToMemory(
+			decryptDirInput,
+			hybridPriv,
+			dilithiumPub,
+			decryptDirStreaming,
+			bufSize,
+		)
+	}
 	if err != nil {
 		fmt.Println(i18n.T("status.failed"))
-		return fmt.Errorf("cannot read data: %w",
-			i18n.TranslateError("error.cannot_read_data", err))
-	}
-	// 读取后尽快删除明文临时文件，减少明文驻留时间窗口
-	if removeErr := os.Remove(tempZipPath); removeErr == nil { // #nosec G703
-		tempZipPath = ""
+		return err
 	}
 
 	zipSize := len(zipData)
-	fileCount, _ := zjcrypto.CountZipFiles(zipData)
+	fileCount := countSelectedEntries(zipData, extractOpts.Only)
 	fmt.Printf(i18n.T("archive.decrypted")+"\n", zipSize)
 
 	// [3/4] 解压ZIP
 	fmt.Printf("[3/4] %s ", i18n.T("progress.extracting"))
-	if err := zjcrypto.ExtractZipToDirectory(zipData, decryptDirOutput); err != nil {
+	if err := zjcrypto.ExtractZipToDirectoryWithOptions(zipData, decryptDirOutput, extractOpts); err != nil {
 		fmt.Println(i18n.T("status.failed"))
 		return fmt.Errorf("extract failed: %w",
 			i18n.TranslateError("error.extract_failed", err))
 	}
 	fmt.Println(i18n.T("status.done"))
 
-	// [4/4] 验证结果
+	// [4/4] 按签名清单校验解压的文件
 	fmt.Printf("[4/4] %s ", i18n.T("progress.verifying"))
-	fmt.Println(i18n.T("status.done"))
+	if err := verifyExtractedManifest(dilithiumPub, extractOpts.Only, existing); err != nil {
+		return err
+	}
 
 	// 显示结果
 	fmt.Printf("\n%s\n\n", i18n.T("status.success_decrypt"))
@@ -201,3 +232,259 @@ func runDecryptDir(_ *cobra.Command, _ []string) error {
 
 	return nil
 }
+
+// extractIndexedArchive 解压索引存档：只解密索引和选中的条目.
+func extractIndexedArchive(
+	header *format.FileHeader,
+	hybridPriv *zjcrypto.HybridPrivateKey,
+	dilithiumPub zjcrypto.VerifyingKey,
+	extractOpts zjcrypto.ExtractOptions,
+	volumes *zjcrypto.VolumeReader,
+) error {
+	// [2/4] 解密并验证索引
+	fmt.Printf("[2/4] %s ", i18n.T("progress.decrypting"))
+	var archive *zjcrypto.IndexedArchive
+	var err error
+	if volumes != nil {
+		archive, err = zjcrypto.OpenIndexedArchiveAt(volumes, volumes.Size(), hybridPriv.Kyber, hybridPriv.ECDH, dilithiumPub)
+	} else {
+		archive, err = zjcrypto.OpenIndexedArchive(decryptDirInput, hybridPriv.Kyber, hybridPriv.ECDH, dilithiumPub)
+	}
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		return fmt.Errorf("decrypt failed: %w",
+			i18n.TranslateError("error.decrypt_failed", err))
+	}
+	defer func() {
+		_ = archive.Close()
+	}()
+	fmt.Println(i18n.T("status.done"))
+
+	// [3/4] 解压选中的条目
+	fmt.Printf("[3/4] %s ", i18n.T("progress.extracting"))
+	if err := archive.ExtractTo(decryptDirOutput, extractOpts); err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		return fmt.Errorf("extract failed: %w",
+			i18n.TranslateError("error.extract_failed", err))
+	}
+	fmt.Println(i18n.T("status.done"))
+
+	// [4/4] 验证结果（各条目哈希已在解压时校验）
+	fmt.Printf("[4/4] %s ", i18n.T("progress.verifying"))
+	fmt.Println(i18n.T("status.done"))
+
+	fileCount := 0
+	for _, entry := range archive.Entries() {
+		if zjcrypto.MatchZipPatterns(extractOpts.Only, entry.Name) {
+			fileCount++
+		}
+	}
+	encryptedSize, _ := utils.GetFileSize(decryptDirInput)
+
+	fmt.Printf("\n%s\n\n", i18n.T("status.success_decrypt"))
+	fmt.Printf(i18n.T("dir_info.decrypt_summary")+"\n",
+		filepath.Base(decryptDirInput), encryptedSize,
+		header.FileSize,
+		fileCount,
+		decryptDirOutput,
+		header.Filename,
+		format.UnixTime(header.Timestamp))
+
+	return nil
+}
+
+// runDecryptDirMirror 将镜像目录中的每个 .fzj 文件解密到输出目录.
+func runDecryptDirMirror() error {
+	fmt.Printf(i18n.T("status.decrypting_dir")+"\n", filepath.Base(decryptDirInput))
+
+	// [1/2] 加载密钥
+	fmt.Printf("\n[1/2] %s ", i18n.T("progress.loading_keys"))
+	hybridPriv, err := utils.LoadHybridPrivateKey(decryptDirPrivKey)
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		//nolint:wrapcheck
+		return err
+	}
+
+	var dilithiumPub = zjcrypto.VerifyingKey(nil)
+	if decryptDirVerifyKey != "" {
+		dilithiumPub, err = utils.LoadDilithiumVerifyKey(decryptDirVerifyKey)
+		if err != nil {
+			fmt.Println(i18n.T("status.failed"))
+			//nolint:wrapcheck
+			return err
+		}
+	} else {
+		fmt.Println(i18n.T("status.warning_no_sign_verify"))
+	}
+	fmt.Println(i18n.T("status.done"))
+
+	// [2/2] 逐文件解密
+	fmt.Printf("[2/2] %s ", i18n.T("progress.decrypting"))
+	result, err := zjcrypto.DecryptDirectoryMirror(
+		decryptDirInput,
+		decryptDirOutput,
+		hybridPriv.Kyber,
+		hybridPriv.ECDH,
+		dilithiumPub,
+	)
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		return fmt.Errorf("decrypt failed: %w",
+			i18n.TranslateError("error.decrypt_failed", err))
+	}
+	fmt.Println(i18n.T("status.done"))
+
+	fmt.Printf("\n%s\n\n", i18n.T("status.success_decrypt"))
+	fmt.Printf(i18n.T("dir_info.mirror_decrypt_summary")+"\n",
+		decryptDirInput, decryptDirOutput, result.Processed)
+	return nil
+}
+
+// verifyExtractedManifest 按存档内的签名清单重新计算解压文件的哈希.
+// 旧版本存档没有清单时仅给出提示；existing 为解压前输出目录中已有的路径，不视为差异.
+func verifyExtractedManifest(dilithiumPub zjcrypto.VerifyingKey, only []string, existing map[string]bool) error {
+	manifest, err := zjcrypto.ReadTreeManifest(decryptDirOutput, dilithiumPub)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Println(i18n.T("status.done"))
+		fmt.Println(i18n.T("status.warning_no_manifest"))
+		return nil
+	}
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		return fmt.Errorf("manifest: %w", i18n.TranslateError("error.manifest_invalid", err))
+	}
+
+	report, err := zjcrypto.CheckTreeAgainstManifest(manifest, decryptDirOutput, only)
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		return fmt.Errorf("check failed: %w", i18n.TranslateError("error.check_tree_failed", err))
+	}
+
+	var mismatches []zjcrypto.TreeDiff
+	for _, diff := range report.Diffs {
+		if diff.Kind != zjcrypto.DiffAdded || !existing[diff.Name] {
+			mismatches = append(mismatches, diff)
+		}
+	}
+	if len(mismatches) > 0 {
+		fmt.Println(i18n.T("status.failed"))
+		printTreeDiffs(mismatches)
+		return errors.New(i18n.T("error.tree_differs_manifest"))
+	}
+
+	fmt.Printf(i18n.T("archive.manifest_verified")+"\n", report.Verified)
+	return nil
+}
+
+// existingTreePaths 返回目录中已有的全部路径（以 / 分隔的相对路径），目录不存在时返回 nil.
+func existingTreePaths(dir string) (map[string]bool, error) {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	paths := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, _ os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != "." {
+			paths[filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", dir, err)
+	}
+	return paths, nil
+}
+
+// extractLimitFlags 解压限制相关的命令行参数，decrypt-dir 与 verify-dir 共用.
+type extractLimitFlags struct {
+	maxTotal   string
+	maxFile    string
+	maxEntries int
+	maxDepth   int
+	maxRatio   int64
+}
+
+// register 在 cmd 上注册 --max-* 参数.
+func (f *extractLimitFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.maxTotal, "max-total-size", "1G", i18n.T("decrypt-dir.flags.max-total-size"))
+	cmd.Flags().StringVar(&f.maxFile, "max-file-size", "1G", i18n.T("decrypt-dir.flags.max-file-size"))
+	cmd.Flags().IntVar(&f.maxEntries, "max-entries", zjcrypto.DefaultMaxEntries,
+		i18n.T("decrypt-dir.flags.max-entries"))
+	cmd.Flags().IntVar(&f.maxDepth, "max-depth", zjcrypto.DefaultMaxPathDepth,
+		i18n.T("decrypt-dir.flags.max-depth"))
+	cmd.Flags().Int64Var(&f.maxRatio, "max-ratio", zjcrypto.DefaultMaxCompressionRatio,
+		i18n.T("decrypt-dir.flags.max-ratio"))
+}
+
+// options 根据命令行参数构建解压限制，0 表示不限制.
+func (f *extractLimitFlags) options() (zjcrypto.ExtractOptions, error) {
+	maxTotal, err := utils.ParseByteSize(f.maxTotal)
+	if err != nil {
+		return zjcrypto.ExtractOptions{}, fmt.Errorf("--max-total-size: %w", err)
+	}
+	maxFile, err := utils.ParseByteSize(f.maxFile)
+	if err != nil {
+		return zjcrypto.ExtractOptions{}, fmt.Errorf("--max-file-size: %w", err)
+	}
+	if f.maxEntries < 0 || f.maxDepth < 0 || f.maxRatio < 0 {
+		return zjcrypto.ExtractOptions{}, fmt.Errorf("limits must not be negative")
+	}
+
+	return zjcrypto.ExtractOptions{
+		MaxTotalSize:        maxTotal,
+		MaxFileSize:         maxFile,
+		MaxEntries:          f.maxEntries,
+		MaxPathDepth:        f.maxDepth,
+		MaxCompressionRatio: f.maxRatio,
+	}, nil
+}
+
+// buildExtractOptions 根据 decrypt-dir 的命令行参数构建解压选项.
+func buildExtractOptions() (zjcrypto.ExtractOptions, error) {
+	opts, err := decryptDirLimits.options()
+	if err != nil {
+		return zjcrypto.ExtractOptions{}, err
+	}
+	if err := zjcrypto.ValidateZipPatterns(decryptDirOnly); err != nil {
+		return zjcrypto.ExtractOptions{}, fmt.Errorf("--only: %w", err)
+	}
+	opts.Only = decryptDirOnly
+	return opts, nil
+}
+
+// countSelectedEntries 统计匹配 --only 模式的条目数量（未指定模式时为全部条目）.
+func countSelectedEntries(zipData []byte, patterns []string) int {
+	entries, err := zjcrypto.ListZipEntries(zipData)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, entry := range entries {
+		if zjcrypto.MatchZipPatterns(patterns, entry.Name) {
+			count++
+		}
+	}
+	return count
+}
+
+// describeExtractOptions 生成解压限制的简要描述.
+func describeExtractOptions(opts zjcrypto.ExtractOptions) string {
+	size := func(n int64) string {
+		if n == 0 {
+			return i18n.T("file_info.unlimited")
+		}
+		return utils.FormatByteSize(n)
+	}
+	count := func(n int64) string {
+		if n == 0 {
+			return i18n.T("file_info.unlimited")
+		}
+		return fmt.Sprintf("%d", n)
+	}
+	return fmt.Sprintf("total=%s, file=%s, entries=%s, depth=%s, ratio=%s",
+		size(opts.MaxTotalSize), size(opts.MaxFileSize),
+		count(int64(opts.MaxEntries)), count(int64(opts.MaxPathDepth)), count(opts.MaxCompressionRatio))
+}
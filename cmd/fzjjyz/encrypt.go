@@ -7,58 +7,116 @@ import (
 	"path/filepath"
 
 	"codeberg.org/jiangfire/fzjjyz/cmd/fzjjyz/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
 	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
 	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
-	"github.com/cloudflare/circl/sign/dilithium/mode3"
 	"github.com/spf13/cobra"
 )
 
 var (
+	encryptInputs     []string
 	encryptInput      string
 	encryptOutput     string
 	encryptPubKey     string
+	encryptRecipient  string
 	encryptSignKey    string
 	encryptForce      bool
 	encryptBufferSize int
 	encryptStreaming  bool
+	encryptCompress   string
+	encryptCompLevel  int
+	encryptJobs       int
+	encryptVolumeSize string
+	encryptRandom     bool
 )
 
 func newEncryptCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "encrypt",
+		Use:   "encrypt [files...]",
 		Short: i18n.T("encrypt.short"),
 		Long:  i18n.T("encrypt.long"),
+		Args:  cobra.ArbitraryArgs,
 		RunE:  runEncrypt,
 	}
 
-	cmd.Flags().StringVarP(&encryptInput, "input", "i", "", i18n.T("encrypt.flags.input"))
+	cmd.Flags().StringArrayVarP(&encryptInputs, "input", "i", nil, i18n.T("encrypt.flags.input"))
 	cmd.Flags().StringVarP(&encryptOutput, "output", "o", "", i18n.T("encrypt.flags.output"))
 	cmd.Flags().StringVarP(&encryptPubKey, "public-key", "p", "", i18n.T("encrypt.flags.public-key"))
+	cmd.Flags().StringVarP(&encryptRecipient, "recipient", "r", "", i18n.T("encrypt.flags.recipient"))
 	cmd.Flags().StringVarP(&encryptSignKey, "sign-key", "s", "", i18n.T("encrypt.flags.sign-key"))
 	cmd.Flags().BoolVarP(&encryptForce, "force", "f", false, i18n.T("encrypt.flags.force"))
 	cmd.Flags().IntVar(&encryptBufferSize, "buffer-size", 0, i18n.T("encrypt.flags.buffer-size"))
 	cmd.Flags().BoolVar(&encryptStreaming, "streaming", true, i18n.T("encrypt.flags.streaming"))
+	cmd.Flags().StringVar(&encryptCompress, "compress", "none", i18n.T("encrypt.flags.compress"))
+	cmd.Flags().IntVar(&encryptCompLevel, "compress-level", 0, i18n.T("encrypt.flags.compress-level"))
+	cmd.Flags().IntVarP(&encryptJobs, "jobs", "j", 0, i18n.T("encrypt.flags.jobs"))
+	cmd.Flags().StringVar(&encryptVolumeSize, "volume-size", "", i18n.T("encrypt.flags.volume-size"))
+	cmd.Flags().BoolVar(&encryptRandom, "random-access", false, i18n.T("encrypt.flags.random-access"))
 
-	_ = cmd.MarkFlagRequired("input")
-	_ = cmd.MarkFlagRequired("public-key")
 	_ = cmd.MarkFlagRequired("sign-key")
+	cmd.MarkFlagsOneRequired("public-key", "recipient")
+	cmd.MarkFlagsMutuallyExclusive("public-key", "recipient")
 
 	return cmd
 }
 
-func runEncrypt(_ *cobra.Command, _ []string) error {
-	return executeEncryptCommand()
+func runEncrypt(_ *cobra.Command, args []string) error {
+	// -r 与 -p 含义相同，都接受 PEM 路径、身份证书或 fzj1... 收件人字符串
+	if encryptRecipient != "" {
+		encryptPubKey = encryptRecipient
+	}
+
+	inputs, batch, err := collectInputs(encryptInputs, args)
+	if err != nil {
+		return err
+	}
+
+	compression, err := zjcrypto.ParseCompressionOptions(encryptCompress, encryptCompLevel)
+	if err != nil {
+		return fmt.Errorf("invalid compression: %w",
+			i18n.TranslateError("error.invalid_compression", err))
+	}
+
+	if encryptRandom && compression.Codec != format.CompressionNone {
+		return fmt.Errorf("%s", i18n.T("error.random_access_compression"))
+	}
+
+	volumeSize, err := parseVolumeSize(encryptVolumeSize)
+	if err != nil {
+		return err
+	}
+
+	if batch {
+		if volumeSize > 0 {
+			return fmt.Errorf("%s", i18n.T("error.volume_batch_unsupported"))
+		}
+		return executeEncryptBatch(inputs, compression)
+	}
+	encryptInput = inputs[0]
+	return executeEncryptCommand(compression, volumeSize)
 }
 
-func executeEncryptCommand() error {
+func executeEncryptCommand(compression zjcrypto.CompressionOptions, volumeSize int64) (err error) {
 	// 步骤1: 验证输入
 	//nolint:wrapcheck
 	if err := utils.ValidateInputFile(encryptInput); err != nil {
 		return err
 	}
 
-	// 步骤2: 准备输出路径
+	// 步骤2: 准备输出路径（远程输出先写入本地中转文件，成功后上传）
 	prepareEncryptOutput()
+	if volumeSize > 0 {
+		if err := checkVolumeOutput(encryptOutput, encryptForce); err != nil {
+			return err
+		}
+	}
+	stage, err := stageRemoteOutput(&encryptOutput, encryptForce)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = stage.finishOutput(err)
+	}()
 	//nolint:wrapcheck
 	if err := utils.CheckOutputConflict(encryptOutput, encryptForce); err != nil {
 		return err
@@ -71,13 +129,31 @@ func executeEncryptCommand() error {
 		return err
 	}
 
-	// 步骤4: 执行加密
-	if err := executeEncrypt(reporter, hybridPub, dilithiumPriv); err != nil {
+	// 步骤4: 执行加密（分卷输出时直接写入分卷，成功后回填分卷头）
+	var volumes *zjcrypto.VolumeWriter
+	if volumeSize > 0 {
+		if volumes, err = createVolumeOutput(encryptOutput, volumeSize, dilithiumPriv); err != nil {
+			return err
+		}
+		defer func() {
+			err = finishVolumeOutput(volumes, err)
+		}()
+	}
+	if err := executeEncrypt(reporter, hybridPub, dilithiumPriv, compression, volumes); err != nil {
 		return err
 	}
 
 	// 步骤5: 显示结果
-	return showEncryptResult()
+	return showEncryptResult(volumes)
+}
+
+// displayKeyRef 缩短收件人字符串用于显示，文件路径原样返回.
+func displayKeyRef(ref string) string {
+	const keep = 16
+	if zjcrypto.IsRecipient(ref) && len(ref) > 2*keep {
+		return ref[:keep] + "..." + ref[len(ref)-keep:]
+	}
+	return ref
 }
 
 func prepareEncryptOutput() {
@@ -86,7 +162,7 @@ func prepareEncryptOutput() {
 	}
 }
 
-func loadEncryptKeys(reporter *utils.ProgressReporter) (*zjcrypto.HybridPublicKey, *mode3.PrivateKey, error) {
+func loadEncryptKeys(reporter *utils.ProgressReporter) (*zjcrypto.HybridPublicKey, zjcrypto.SigningKey, error) {
 	reporter.Step("progress.loading_keys")
 
 	// 加载公钥
@@ -112,14 +188,18 @@ func loadEncryptKeys(reporter *utils.ProgressReporter) (*zjcrypto.HybridPublicKe
 func executeEncrypt(
 	reporter *utils.ProgressReporter,
 	hybridPub *zjcrypto.HybridPublicKey,
-	dilithiumPriv *mode3.PrivateKey,
+	dilithiumPriv zjcrypto.SigningKey,
+	compression zjcrypto.CompressionOptions,
+	volumes *zjcrypto.VolumeWriter,
 ) error {
 	// 显示详细信息
 	reporter.InfoString("file_info.original_file", encryptInput)
 	reporter.InfoString("file_info.encrypted_file", encryptOutput)
-	reporter.InfoString("status.public_key", encryptPubKey)
+	reporter.InfoString("status.public_key", displayKeyRef(encryptPubKey))
 	reporter.InfoString("status.sign_key", encryptSignKey)
 	reporter.InfoBool("status.streaming_mode", encryptStreaming)
+	reporter.InfoString("status.compression", format.CompressionName(compression.Codec))
+	reporter.InfoBool("status.random_access", encryptRandom)
 
 	// 计算缓冲区大小
 	bufSize := calculateBufferSizeFromFile(encryptInput, encryptBufferSize)
@@ -127,14 +207,13 @@ func executeEncrypt(
 
 	// 执行加密
 	reporter.Step("progress.encrypting")
-	if err := runEncryptWithMode(
-		encryptInput,
-		encryptOutput,
-		hybridPub,
-		dilithiumPriv,
-		encryptStreaming,
-		bufSize,
-	); err != nil {
+	var err error
+	if volumes != nil {
+		err = encryptFileToVolumes(encryptInput, volumes, hybridPub, dilithiumPriv, compression)
+	} else {
+		err = encryptFile(encryptInput, encryptOutput, hybridPub, dilithiumPriv, bufSize, compression)
+	}
+	if err != nil {
 		reporter.Failed()
 		return fmt.Errorf("encrypt failed: %w",
 			i18n.TranslateError("error.encrypt_failed", err))
@@ -148,8 +227,8 @@ func executeEncrypt(
 	return nil
 }
 
-func showEncryptResult() error {
-	encryptedInfo, _ := os.Stat(encryptOutput)
+func showEncryptResult(volumes *zjcrypto.VolumeWriter) error {
+	encryptedSize, _ := encryptedOutputSize(encryptOutput, volumes)
 	originalInfo, _ := os.Stat(encryptInput)
 
 	reporter := utils.NewProgressReporter(1, true)
@@ -159,8 +238,96 @@ func showEncryptResult() error {
 	fmt.Printf("%s\\n",
 		fmt.Sprintf(summary,
 			filepath.Base(encryptInput), originalInfo.Size(),
-			filepath.Base(encryptOutput), encryptedInfo.Size(),
-			float64(encryptedInfo.Size())/float64(originalInfo.Size())*100))
+			filepath.Base(encryptOutput), encryptedSize,
+			float64(encryptedSize)/float64(originalInfo.Size())*100))
 
 	return nil
 }
+
+// executeEncryptBatch 加载一次密钥后并发加密多个文件.
+// 指定 -o 时将其作为输出目录，否则密文写在各自输入文件旁边.
+func executeEncryptBatch(inputs []string, compression zjcrypto.CompressionOptions) error {
+	workers, err := batchWorkers(encryptJobs, len(inputs))
+	if err != nil {
+		return err
+	}
+	outputDir, err := prepareBatchOutputDir(encryptOutput)
+	if err != nil {
+		return err
+	}
+
+	reporter := utils.NewProgressReporter(2, verbose)
+	hybridPub, dilithiumPriv, err := loadEncryptKeys(reporter)
+	if err != nil {
+		return err
+	}
+
+	jobs := make([]batchJob, len(inputs))
+	for i, input := range inputs {
+		jobs[i] = batchJob{
+			input:  input,
+			output: batchOutputPath(input, outputDir, filepath.Base(input)+".fzj"),
+			err:    utils.ValidateInputFile(input),
+		}
+	}
+	checkBatchOutputs(jobs, encryptForce)
+
+	reporter.Step("progress.encrypting")
+	fmt.Printf(i18n.T("batch.files")+"\n", len(jobs), workers)
+	failed := runBatch(jobs, workers, func(job batchJob) error {
+		return encryptFile(
+			job.input,
+			job.output,
+			hybridPub,
+			dilithiumPriv,
+			calculateBufferSizeFromFile(job.input, encryptBufferSize),
+			compression,
+		)
+	})
+
+	return finishBatch(len(jobs), failed)
+}
+
+// encryptFile 按 --random-access 选择分块加密或常规加密.
+func encryptFile(
+	inputPath, outputPath string,
+	hybridPub *zjcrypto.HybridPublicKey,
+	dilithiumPriv zjcrypto.SigningKey,
+	bufferSize int,
+	compression zjcrypto.CompressionOptions,
+) error {
+	if encryptRandom {
+		//nolint:wrapcheck
+		return zjcrypto.EncryptFileChunked(
+			inputPath, outputPath,
+			hybridPub.Kyber, hybridPub.ECDH,
+			dilithiumPriv,
+			0,
+		)
+	}
+	return runEncryptWithMode(
+		inputPath,
+		outputPath,
+		hybridPub,
+		dilithiumPriv,
+		encryptStreaming,
+		bufferSize,
+		compression,
+	)
+}
+
+// encryptFileToVolumes 与 encryptFile 相同，但加密结果直接写入分卷.
+func encryptFileToVolumes(
+	inputPath string,
+	volumes *zjcrypto.VolumeWriter,
+	hybridPub *zjcrypto.HybridPublicKey,
+	dilithiumPriv zjcrypto.SigningKey,
+	compression zjcrypto.CompressionOptions,
+) error {
+	if encryptRandom {
+		//nolint:wrapcheck
+		return zjcrypto.EncryptFileChunkedTo(inputPath, volumes, hybridPub.Kyber, hybridPub.ECDH, dilithiumPriv, 0)
+	}
+	//nolint:wrapcheck
+	return zjcrypto.EncryptFileTo(inputPath, volumes, hybridPub.Kyber, hybridPub.ECDH, dilithiumPriv, compression)
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 
 	"codeberg.org/jiangfire/fzjjyz/cmd/fzjjyz/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
 	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
 	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
 	"github.com/spf13/cobra"
@@ -21,6 +22,11 @@ var (
 	encryptDirForce      bool
 	encryptDirBufferSize int
 	encryptDirStreaming  bool
+	encryptDirIndexed    bool
+	encryptDirMirror     bool
+	encryptDirNames      bool
+	encryptDirState      string
+	encryptDirVolumeSize string
 )
 
 func newEncryptDirCmd() *cobra.Command {
@@ -38,6 +44,11 @@ func newEncryptDirCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(&encryptDirForce, "force", "f", false, i18n.T("encrypt-dir.flags.force"))
 	cmd.Flags().IntVar(&encryptDirBufferSize, "buffer-size", 0, i18n.T("encrypt-dir.flags.buffer-size"))
 	cmd.Flags().BoolVar(&encryptDirStreaming, "streaming", true, i18n.T("encrypt-dir.flags.streaming"))
+	cmd.Flags().BoolVar(&encryptDirIndexed, "indexed", false, i18n.T("encrypt-dir.flags.indexed"))
+	cmd.Flags().BoolVar(&encryptDirMirror, "mirror", false, i18n.T("encrypt-dir.flags.mirror"))
+	cmd.Flags().BoolVar(&encryptDirNames, "encrypt-names", false, i18n.T("encrypt-dir.flags.encrypt-names"))
+	cmd.Flags().StringVar(&encryptDirState, "state", "", i18n.T("encrypt-dir.flags.state"))
+	cmd.Flags().StringVar(&encryptDirVolumeSize, "volume-size", "", i18n.T("encrypt-dir.flags.volume-size"))
 
 	_ = cmd.MarkFlagRequired("input")
 	_ = cmd.MarkFlagRequired("output")
@@ -48,13 +59,45 @@ func newEncryptDirCmd() *cobra.Command {
 }
 
 //nolint:funlen
-func runEncryptDir(_ *cobra.Command, _ []string) error {
+func runEncryptDir(_ *cobra.Command, _ []string) (err error) {
 	// 验证源目录
 	//nolint:wrapcheck
 	if err := utils.ValidateInputDir(encryptDirInput); err != nil {
 		return err
 	}
 
+	volumeSize, err := parseVolumeSize(encryptDirVolumeSize)
+	if err != nil {
+		return err
+	}
+
+	// 镜像模式的输出为目录，重复运行时增量更新
+	if encryptDirMirror {
+		if volumeSize > 0 {
+			return fmt.Errorf("%s", i18n.T("error.volume_mirror_conflict"))
+		}
+		return runEncryptDirMirror()
+	}
+	if encryptDirNames || encryptDirState != "" {
+		return fmt.Errorf("%s", i18n.T("error.mirror_only_flag"))
+	}
+
+	// 分卷输出时存档直接写入分卷
+	if volumeSize > 0 {
+		if err := checkVolumeOutput(encryptDirOutput, encryptDirForce); err != nil {
+			return err
+		}
+	}
+
+	// 远程输出先写入本地中转文件，成功后上传
+	stage, err := stageRemoteOutput(&encryptDirOutput, encryptDirForce)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = stage.finishOutput(err)
+	}()
+
 	// 检查输出文件是否已存在
 	//nolint:wrapcheck
 	if err := utils.CheckOutputConflict(encryptDirOutput, encryptDirForce); err != nil {
@@ -70,24 +113,12 @@ func runEncryptDir(_ *cobra.Command, _ []string) error {
 		fmt.Printf("  %s: %s\n", i18n.T("status.sign_key"), encryptDirSignKey)
 	}
 
-	// [1/4] 打包成ZIP
-	fmt.Printf("\n")
-	fmt.Printf("[1/4] %s ", i18n.T("progress.packing"))
-	var zipBuffer bytes.Buffer
-	if err := zjcrypto.CreateZipFromDirectory(encryptDirInput, &zipBuffer, zjcrypto.DefaultArchiveOptions); err != nil {
-		fmt.Println(i18n.T("status.failed"))
-		return fmt.Errorf("pack failed: %w",
-			i18n.TranslateError("error.pack_failed", err))
+	if encryptDirIndexed {
+		return runEncryptDirIndexed(volumeSize)
 	}
-	zipData := zipBuffer.Bytes()
-
-	// 获取ZIP信息
-	zipSize := len(zipData)
-	fileCount, _ := zjcrypto.CountZipFiles(zipData)
-	fmt.Printf(i18n.T("archive.packed")+"\n", zipSize, fileCount)
 
-	// [2/4] 加载密钥
-	fmt.Printf("[2/4] %s ", i18n.T("progress.loading_keys"))
+	// [1/4] 加载密钥（签名密钥同时用于签名清单）
+	fmt.Printf("\n[1/4] %s ", i18n.T("progress.loading_keys"))
 	hybridPub, err := utils.LoadHybridPublicKey(encryptDirPubKey)
 	if err != nil {
 		fmt.Println(i18n.T("status.failed"))
@@ -101,8 +132,35 @@ func runEncryptDir(_ *cobra.Command, _ []string) error {
 		//nolint:wrapcheck
 		return err
 	}
+	var volumes *zjcrypto.VolumeWriter
+	if volumeSize > 0 {
+		if volumes, err = createVolumeOutput(encryptDirOutput, volumeSize, dilithiumPriv); err != nil {
+			fmt.Println(i18n.T("status.failed"))
+			return err
+		}
+		defer func() {
+			err = finishVolumeOutput(volumes, err)
+		}()
+	}
 	fmt.Println(i18n.T("status.done"))
 
+	// [2/4] 打包成ZIP，并写入签名清单
+	fmt.Printf("[2/4] %s ", i18n.T("progress.packing"))
+	archiveOpts := zjcrypto.DefaultArchiveOptions
+	archiveOpts.ManifestKey = dilithiumPriv
+	var zipBuffer bytes.Buffer
+	if err := zjcrypto.CreateZipFromDirectory(encryptDirInput, &zipBuffer, archiveOpts); err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		return fmt.Errorf("pack failed: %w",
+			i18n.TranslateError("error.pack_failed", err))
+	}
+	zipData := zipBuffer.Bytes()
+
+	// 获取ZIP信息
+	zipSize := len(zipData)
+	fileCount, _ := zjcrypto.CountZipFiles(zipData)
+	fmt.Printf(i18n.T("archive.packed")+"\n", zipSize, fileCount)
+
 	// [3/4] 加密ZIP数据
 	fmt.Printf("[3/4] %s ", i18n.T("progress.encrypting"))
 
@@ -136,14 +194,26 @@ func runEncryptDir(_ *cobra.Command, _ []string) error {
 		fmt.Printf(i18n.T("file_info.buffer_size")+"\n", bufSize/1024)
 	}
 
-	if err := runEncryptWithMode(
-		tempZipPath,
-		encryptDirOutput,
-		hybridPub,
-		dilithiumPriv,
-		encryptDirStreaming,
-		bufSize,
-	); err != nil {
+	// ZIP 条目已使用 Deflate 压缩，加密时不再压缩
+	if volumes != nil {
+		err = zjcrypto.EncryptFileTo(
+			tempZipPath, volumes,
+			hybridPub.Kyber, hybridPub.ECDH,
+			dilithiumPriv,
+			zjcrypto.NoCompression,
+		)
+	} else {
+		err = runEncryptWithMode(
+			tempZipPath,
+			encryptDirOutput,
+			hybridPub,
+			dilithiumPriv,
+			encryptDirStreaming,
+			bufSize,
+			zjcrypto.NoCompression,
+		)
+	}
+	if err != nil {
 		fmt.Println(i18n.T("status.failed"))
 		return fmt.Errorf("encrypt failed: %w",
 			i18n.TranslateError("error.encrypt_failed", err))
@@ -152,7 +222,7 @@ func runEncryptDir(_ *cobra.Command, _ []string) error {
 
 	// [4/4] 验证结果
 	fmt.Printf("[4/4] %s ", i18n.T("progress.verifying"))
-	encryptedInfo, _ := os.Stat(encryptDirOutput)
+	encryptedSize, _ := encryptedOutputSize(encryptDirOutput, volumes)
 	fmt.Println(i18n.T("status.done"))
 
 	// 显示结果
@@ -162,8 +232,148 @@ func runEncryptDir(_ *cobra.Command, _ []string) error {
 		fmt.Sprintf(summary,
 			encryptDirInput, fileCount,
 			zipSize,
-			filepath.Base(encryptDirOutput), encryptedInfo.Size(),
-			float64(encryptedInfo.Size())/float64(zipSize)*100))
+			filepath.Base(encryptDirOutput), encryptedSize,
+			float64(encryptedSize)/float64(zipSize)*100))
+
+	return nil
+}
+
+// runEncryptDirIndexed 将目录加密为索引存档（逐条目加密，无需先打包 ZIP）.
+func runEncryptDirIndexed(volumeSize int64) (err error) {
+	// [1/3] 加载密钥
+	fmt.Printf("\n[1/3] %s ", i18n.T("progress.loading_keys"))
+	hybridPub, err := utils.LoadHybridPublicKey(encryptDirPubKey)
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		//nolint:wrapcheck
+		return err
+	}
 
+	dilithiumPriv, err := utils.LoadDilithiumPrivateKey(encryptDirSignKey)
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		//nolint:wrapcheck
+		return err
+	}
+	var volumes *zjcrypto.VolumeWriter
+	if volumeSize > 0 {
+		if volumes, err = createVolumeOutput(encryptDirOutput, volumeSize, dilithiumPriv); err != nil {
+			fmt.Println(i18n.T("status.failed"))
+			return err
+		}
+		defer func() {
+			err = finishVolumeOutput(volumes, err)
+		}()
+	}
+	fmt.Println(i18n.T("status.done"))
+
+	// [2/3] 逐条目加密
+	fmt.Printf("[2/3] %s ", i18n.T("progress.encrypting"))
+	var index *format.ArchiveIndex
+	if volumes != nil {
+		index, err = zjcrypto.EncryptDirectoryIndexedTo(
+			encryptDirInput,
+			volumes,
+			hybridPub.Kyber,
+			hybridPub.ECDH,
+			dilithiumPriv,
+			zjcrypto.DefaultArchiveOptions,
+		)
+	} else {
+		index, err = zjcrypto.EncryptDirectoryIndexed(
+			encryptDirInput,
+			encryptDirOutput,
+			hybridPub.Kyber,
+			hybridPub.ECDH,
+			dilithiumPriv,
+			zjcrypto.DefaultArchiveOptions,
+		)
+	}
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		return fmt.Errorf("encrypt failed: %w",
+			i18n.TranslateError("error.encrypt_failed", err))
+	}
+	fmt.Println(i18n.T("status.done"))
+
+	// [3/3] 验证结果
+	fmt.Printf("[3/3] %s ", i18n.T("progress.verifying"))
+	encryptedSize, err := encryptedOutputSize(encryptDirOutput, volumes)
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		return fmt.Errorf(i18n.T("error.cannot_open_file"), err)
+	}
+	fmt.Println(i18n.T("status.done"))
+
+	var fileCount int
+	var totalSize uint64
+	for i := range index.Entries {
+		if !index.Entries[i].IsDir() {
+			fileCount++
+			totalSize += index.Entries[i].Size
+		}
+	}
+
+	fmt.Printf("\n%s\n\n", i18n.T("status.success_encrypt"))
+	fmt.Printf(i18n.T("dir_info.indexed_encrypt_summary")+"\n",
+		encryptDirInput, fileCount,
+		totalSize,
+		filepath.Base(encryptDirOutput), encryptedSize)
+
+	return nil
+}
+
+// runEncryptDirMirror 将目录逐文件加密到输出目录，跳过未变化的文件.
+func runEncryptDirMirror() error {
+	if encryptDirIndexed {
+		return fmt.Errorf("%s", i18n.T("error.mirror_indexed_conflict"))
+	}
+	if err := rejectRemote("--output", encryptDirOutput); err != nil {
+		return err
+	}
+	if info, err := os.Stat(encryptDirOutput); err == nil && !info.IsDir() {
+		return fmt.Errorf(i18n.T("error.output_not_dir"), encryptDirOutput)
+	}
+
+	fmt.Printf(i18n.T("status.encrypting_dir")+"\n", filepath.Base(encryptDirInput))
+
+	// [1/2] 加载密钥
+	fmt.Printf("\n[1/2] %s ", i18n.T("progress.loading_keys"))
+	hybridPub, err := utils.LoadHybridPublicKey(encryptDirPubKey)
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		//nolint:wrapcheck
+		return err
+	}
+
+	dilithiumPriv, err := utils.LoadDilithiumPrivateKey(encryptDirSignKey)
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		//nolint:wrapcheck
+		return err
+	}
+	fmt.Println(i18n.T("status.done"))
+
+	// [2/2] 逐文件加密
+	fmt.Printf("[2/2] %s ", i18n.T("progress.encrypting"))
+	result, err := zjcrypto.EncryptDirectoryMirror(
+		encryptDirInput,
+		encryptDirOutput,
+		hybridPub.Kyber,
+		hybridPub.ECDH,
+		dilithiumPriv,
+		zjcrypto.MirrorOptions{EncryptNames: encryptDirNames, StatePath: encryptDirState},
+	)
+	if err != nil {
+		fmt.Println(i18n.T("status.failed"))
+		return fmt.Errorf("encrypt failed: %w",
+			i18n.TranslateError("error.encrypt_failed", err))
+	}
+	fmt.Println(i18n.T("status.done"))
+
+	fmt.Printf("\n%s\n\n", i18n.T("status.success_encrypt"))
+	fmt.Printf(i18n.T("dir_info.mirror_encrypt_summary")+"\n",
+		encryptDirInput, encryptDirOutput,
+		result.Processed, result.Skipped, result.Removed)
 	return nil
 }
@@ -98,6 +98,10 @@ func showFileInfo(header *format.FileHeader, data []byte) error {
 		algoName = "Kyber768 + ECDH + AES-256-GCM"
 	}
 	fmt.Printf("  "+i18n.T("file_info.algorithm")+"\n", algoName, header.Algorithm)
+	fmt.Printf("  "+i18n.T("file_info.compression")+"\n", format.CompressionName(header.Compression()))
+	if header.IsIndexedArchive() {
+		fmt.Printf("  %s\n", i18n.T("file_info.indexed_archive"))
+	}
 	fmt.Printf("  "+i18n.T("file_info.version")+"\n", header.Version)
 	fmt.Printf("  "+i18n.T("file_info.magic")+"\n", header.Magic[0], header.Magic[1], header.Magic[2], header.Magic[3])
 
@@ -107,6 +111,7 @@ func showFileInfo(header *format.FileHeader, data []byte) error {
 	fmt.Printf("  "+i18n.T("file_info.ecdh")+"\n", header.ECDHLen)
 	fmt.Printf("  "+i18n.T("file_info.iv")+"\n", header.IVLen)
 	fmt.Printf("  "+i18n.T("file_info.signature")+"\n", header.SigLen)
+	fmt.Printf("  "+i18n.T("file_info.signature_type")+"\n", format.SignatureTypeName(header.SignatureType()))
 
 	// 完整性信息
 	fmt.Println("\n" + i18n.T("file_info.integrity"))
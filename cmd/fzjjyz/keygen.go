@@ -2,8 +2,11 @@
 package main
 
 import (
+	"bufio"
 	"crypto/ecdh"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
@@ -12,7 +15,6 @@ import (
 	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
 	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
 	"github.com/cloudflare/circl/kem"
-	"github.com/cloudflare/circl/sign/dilithium/mode3"
 	"github.com/spf13/cobra"
 )
 
@@ -20,6 +22,9 @@ var (
 	keygenOutputDir string
 	keygenName      string
 	keygenForce     bool
+	keygenMnemonic  bool
+	keygenRecover   bool
+	keygenComposite bool
 )
 
 func newKeygenCmd() *cobra.Command {
@@ -33,6 +38,10 @@ func newKeygenCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&keygenOutputDir, "output-dir", "d", ".", i18n.T("keygen.flags.output-dir"))
 	cmd.Flags().StringVarP(&keygenName, "name", "n", "", i18n.T("keygen.flags.name"))
 	cmd.Flags().BoolVarP(&keygenForce, "force", "f", false, i18n.T("keygen.flags.force"))
+	cmd.Flags().BoolVar(&keygenMnemonic, "mnemonic", false, i18n.T("keygen.flags.mnemonic"))
+	cmd.Flags().BoolVar(&keygenRecover, "recover", false, i18n.T("keygen.flags.recover"))
+	cmd.Flags().BoolVar(&keygenComposite, "composite", false, i18n.T("keygen.flags.composite"))
+	cmd.MarkFlagsMutuallyExclusive("mnemonic", "recover")
 
 	return cmd
 }
@@ -48,9 +57,20 @@ func executeKeygenCommand() error {
 		return err
 	}
 
-	// 步骤2: 生成密钥
-	reporter := utils.NewProgressReporter(4, verbose)
-	keys, err := generateKeys(reporter)
+	// 步骤2: 生成密钥，--mnemonic / --recover 时由助记词派生
+	mnemonic, err := keygenMnemonicPhrase()
+	if err != nil {
+		return err
+	}
+	steps, generate := 4, generateKeys
+	if mnemonic != "" {
+		steps = 2
+		generate = func(reporter *utils.ProgressReporter) (*keyPair, error) {
+			return deriveKeys(reporter, mnemonic)
+		}
+	}
+	reporter := utils.NewProgressReporter(steps, verbose)
+	keys, err := generate(reporter)
 	if err != nil {
 		return err
 	}
@@ -61,7 +81,70 @@ func executeKeygenCommand() error {
 	}
 
 	// 步骤4: 显示结果
-	return showKeygenResult(paths)
+	if err := showKeygenResult(paths); err != nil {
+		return err
+	}
+	if keygenMnemonic {
+		fmt.Println("\n" + i18n.T("keygen_info.mnemonic"))
+		fmt.Println("  " + mnemonic)
+	}
+	return nil
+}
+
+// keygenMnemonicPhrase 返回 --mnemonic 新生成的或 --recover 从标准输入读取的助记词，
+// 两者都未指定时返回空字符串.
+func keygenMnemonicPhrase() (string, error) {
+	switch {
+	case keygenMnemonic:
+		mnemonic, err := zjcrypto.NewMnemonic()
+		if err != nil {
+			return "", fmt.Errorf("generate mnemonic failed: %w",
+				i18n.TranslateError("error.keygen_mnemonic_failed", err))
+		}
+		return mnemonic, nil
+	case keygenRecover:
+		// 助记词不从命令行参数读取，避免出现在进程列表和 shell 历史中
+		fmt.Fprint(os.Stderr, i18n.T("keygen.prompt_mnemonic"))
+		line, err := bufio.NewReader(io.LimitReader(os.Stdin, 4096)).ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			return "", fmt.Errorf("read mnemonic failed: %w",
+				i18n.TranslateError("error.invalid_mnemonic", err))
+		}
+		mnemonic, err := zjcrypto.NormalizeMnemonic(line)
+		if err != nil {
+			return "", fmt.Errorf("invalid mnemonic: %w",
+				i18n.TranslateError("error.invalid_mnemonic", err))
+		}
+		return mnemonic, nil
+	default:
+		return "", nil
+	}
+}
+
+func deriveKeys(reporter *utils.ProgressReporter, mnemonic string) (*keyPair, error) {
+	reporter.Step("progress.deriving_keys")
+	derived, err := zjcrypto.DeriveKeysFromMnemonic(mnemonic)
+	if err != nil {
+		reporter.Failed()
+		return nil, fmt.Errorf("derive keys failed: %w",
+			i18n.TranslateError("error.keygen_mnemonic_failed", err))
+	}
+	reporter.Done()
+
+	keys := &keyPair{
+		kyberPub: derived.KyberPub, kyberPriv: derived.KyberPriv, kyberSeed: derived.KyberSeed,
+		ecdhPub: derived.ECDHPub, ecdhPriv: derived.ECDHPriv,
+		dilithiumPub: derived.DilithiumPub, dilithiumPriv: derived.DilithiumPriv,
+	}
+	if keygenComposite {
+		keys.dilithiumPriv, err = zjcrypto.NewCompositeSigningKey(derived.DilithiumPriv, derived.Ed25519Priv)
+		if err != nil {
+			return nil, fmt.Errorf("composite key failed: %w",
+				i18n.TranslateError("error.keygen_dilithium_failed", err))
+		}
+		keys.dilithiumPub = zjcrypto.DilithiumPublicFromPrivate(keys.dilithiumPriv)
+	}
+	return keys, nil
 }
 
 func prepareKeygen() ([]string, error) {
@@ -105,16 +188,17 @@ func prepareKeygen() ([]string, error) {
 type keyPair struct {
 	kyberPub      kem.PublicKey
 	kyberPriv     kem.PrivateKey
+	kyberSeed     []byte
 	ecdhPub       *ecdh.PublicKey
 	ecdhPriv      *ecdh.PrivateKey
-	dilithiumPub  *mode3.PublicKey
-	dilithiumPriv *mode3.PrivateKey
+	dilithiumPub  zjcrypto.VerifyingKey
+	dilithiumPriv zjcrypto.SigningKey
 }
 
 func generateKeys(reporter *utils.ProgressReporter) (*keyPair, error) {
 	// 1. Kyber
 	reporter.Step("progress.generating_kyber")
-	kyberPub, kyberPriv, err := zjcrypto.GenerateKyberKeys()
+	kyberPub, kyberPriv, kyberSeed, err := zjcrypto.GenerateSeededKyberKeys()
 	if err != nil {
 		reporter.Failed()
 		return nil, fmt.Errorf("kyber key generation failed: %w",
@@ -132,9 +216,17 @@ func generateKeys(reporter *utils.ProgressReporter) (*keyPair, error) {
 	}
 	reporter.Done()
 
-	// 3. Dilithium
+	// 3. Dilithium（--composite 时附加 Ed25519）
 	reporter.Step("progress.generating_dilithium")
-	dilithiumPub, dilithiumPriv, err := zjcrypto.GenerateDilithiumKeyPair()
+	var (
+		dilithiumPub  zjcrypto.VerifyingKey
+		dilithiumPriv zjcrypto.SigningKey
+	)
+	if keygenComposite {
+		dilithiumPub, dilithiumPriv, err = zjcrypto.GenerateCompositeKeyPair()
+	} else {
+		dilithiumPub, dilithiumPriv, err = zjcrypto.GenerateDilithiumKeyPair()
+	}
 	if err != nil {
 		reporter.Failed()
 		return nil, fmt.Errorf("dilithium key generation failed: %w",
@@ -143,7 +235,7 @@ func generateKeys(reporter *utils.ProgressReporter) (*keyPair, error) {
 	reporter.Done()
 
 	return &keyPair{
-		kyberPub: kyberPub, kyberPriv: kyberPriv,
+		kyberPub: kyberPub, kyberPriv: kyberPriv, kyberSeed: kyberSeed,
 		ecdhPub: ecdhPub, ecdhPriv: ecdhPriv,
 		dilithiumPub: dilithiumPub, dilithiumPriv: dilithiumPriv,
 	}, nil
@@ -154,11 +246,9 @@ func saveKeys(reporter *utils.ProgressReporter, keys *keyPair, paths []string) e
 
 	pubPath, privPath, dilithiumPubPath, dilithiumPrivPath := paths[0], paths[1], paths[2], paths[3]
 
-	if err := zjcrypto.SaveKeyFiles(
-		keys.kyberPub,
-		keys.ecdhPub,
-		keys.kyberPriv,
-		keys.ecdhPriv,
+	if err := zjcrypto.SaveHybridKeyFiles(
+		&zjcrypto.HybridPublicKey{Kyber: keys.kyberPub, ECDH: keys.ecdhPub},
+		&zjcrypto.HybridPrivateKey{Kyber: keys.kyberPriv, ECDH: keys.ecdhPriv, KyberSeed: keys.kyberSeed},
 		pubPath,
 		privPath,
 	); err != nil {
@@ -190,6 +280,9 @@ func showKeygenResult(paths []string) error {
 		filepath.Base(privPath),
 		filepath.Base(dilithiumPubPath),
 		filepath.Base(dilithiumPrivPath))
+	if keygenComposite {
+		fmt.Println("\n" + i18n.T("keygen_info.composite"))
+	}
 
 	fmt.Println("\n" + i18n.T("security.warning"))
 	fmt.Println(i18n.T("security.protect_keys"))
@@ -3,21 +3,51 @@ package main
 
 import (
 	"bytes"
+	"crypto/x509/pkix"
 	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"codeberg.org/jiangfire/fzjjyz/cmd/fzjjyz/utils"
 	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
 	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+	"github.com/skip2/go-qrcode"
 	"github.com/spf13/cobra"
 )
 
 var (
-	keymanageAction    string
-	keymanagePubKey    string
-	keymanagePrivKey   string
-	keymanageOutput    string
-	keymanageOutputDir string
+	keymanageAction     string
+	keymanagePubKey     string
+	keymanagePrivKey    string
+	keymanageOutput     string
+	keymanageOutputDir  string
+	keymanageFormat     string
+	keymanageExportPriv bool
+	keymanageSubject    string
+	keymanageDays       int
+	keymanageCA         string
+	keymanageCSR        string
+	keymanageInput      string
+	keymanageCompare    string
+)
+
+// 纸质备份二维码：每个二维码容纳的数据行数（加上头部不超过 40-M 版本的 2331 字节）和每个模块的像素数.
+const (
+	paperQRLines      = 40
+	paperQRModulePx   = 4
+	paperQRFileSuffix = ".png"
+)
+
+// 导出格式.
+const (
+	keyFormatNative    = "native"
+	keyFormatPKCS8     = "pkcs8"
+	keyFormatRecipient = "recipient"
 )
 
 func newKeymanageCmd() *cobra.Command {
@@ -33,6 +63,14 @@ func newKeymanageCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&keymanagePrivKey, "private-key", "s", "", i18n.T("keymanage.flags.private-key"))
 	cmd.Flags().StringVarP(&keymanageOutput, "output", "o", "", i18n.T("keymanage.flags.output"))
 	cmd.Flags().StringVarP(&keymanageOutputDir, "output-dir", "d", ".", i18n.T("keymanage.flags.output-dir"))
+	cmd.Flags().StringVar(&keymanageFormat, "format", keyFormatNative, i18n.T("keymanage.flags.format"))
+	cmd.Flags().BoolVar(&keymanageExportPriv, "export-private", false, i18n.T("keymanage.flags.export-private"))
+	cmd.Flags().StringVar(&keymanageSubject, "subject", "", i18n.T("keymanage.flags.subject"))
+	cmd.Flags().IntVar(&keymanageDays, "days", 365, i18n.T("keymanage.flags.days"))
+	cmd.Flags().StringVar(&keymanageCA, "ca", "", i18n.T("keymanage.flags.ca"))
+	cmd.Flags().StringVar(&keymanageCSR, "csr", "", i18n.T("keymanage.flags.csr"))
+	cmd.Flags().StringVarP(&keymanageInput, "input", "i", "", i18n.T("keymanage.flags.input"))
+	cmd.Flags().StringVar(&keymanageCompare, "compare", "", i18n.T("keymanage.flags.compare"))
 
 	_ = cmd.MarkFlagRequired("action")
 
@@ -49,50 +87,142 @@ func runKeymanage(_ *cobra.Command, _ []string) error {
 		return runVerify()
 	case "cache-info":
 		return runCacheInfo()
+	case "csr":
+		return runCSR()
+	case "selfsign":
+		return runSelfSign()
+	case "issue":
+		return runIssue()
+	case "paperkey":
+		return runPaperKey()
+	case "restore-paper":
+		return runRestorePaper()
+	case "fingerprint":
+		return runFingerprint()
 	default:
 		return fmt.Errorf(i18n.T("error.unknown_action"), keymanageAction)
 	}
 }
 
-// export: 从私钥文件中提取并导出公钥
+// export: 从私钥文件中提取并导出公钥，或以 --export-private 导出私钥.
+// 私钥可以是混合私钥或 Dilithium 私钥，--format pkcs8 使用 SubjectPublicKeyInfo / PKCS#8 PEM，
+// --format recipient 输出 fzj1... 收件人字符串.
 func runExport() error {
+	if keymanageFormat == keyFormatRecipient {
+		return runExportRecipient()
+	}
 	if keymanagePrivKey == "" {
 		return fmt.Errorf(i18n.T("error.missing_required_flags"), "--private-key")
 	}
 	if keymanageOutput == "" {
 		return fmt.Errorf(i18n.T("error.missing_required_flags"), "--output")
 	}
+	if keymanageFormat != keyFormatNative && keymanageFormat != keyFormatPKCS8 {
+		return fmt.Errorf(i18n.T("error.invalid_key_format"), keymanageFormat)
+	}
 
 	fmt.Println(i18n.T("status.public_key") + "...")
 
-	// 加载私钥文件
-	hybridPriv, err := zjcrypto.LoadPrivateKey(keymanagePrivKey)
+	// 加载私钥（文件或私钥 URI），先按混合私钥、再按 Dilithium 私钥解析
+	hybridPriv, signKey, err := utils.LoadPrivateKeyMaterial(keymanagePrivKey)
 	if err != nil {
-		return fmt.Errorf("load private key failed: %w",
-			i18n.TranslateError("error.load_private_key_failed", err, keymanagePrivKey))
+		return err
+	}
+	var out []byte
+	if hybridPriv != nil {
+		out, err = exportHybridKey(hybridPriv)
+	} else {
+		out, err = exportDilithiumKey(signKey)
 	}
-
-	// 从私钥中提取公钥
-	kyberPub := hybridPriv.Kyber.Public()
-	ecdhPub := hybridPriv.ECDH.PublicKey()
-
-	// 导出公钥到 PEM
-	pubPEM, err := zjcrypto.ExportPublicKey(kyberPub, ecdhPub)
 	if err != nil {
 		return fmt.Errorf("export key failed: %w",
 			i18n.TranslateError("error.export_key_failed", err))
 	}
+	defer clear(out)
 
-	// 保存公钥文件
-	if err := os.WriteFile(keymanageOutput, pubPEM, 0600); err != nil {
+	// 保存导出文件
+	if err := os.WriteFile(keymanageOutput, out, 0600); err != nil {
 		return fmt.Errorf("save export failed: %w",
 			i18n.TranslateError("error.save_export_failed", err))
 	}
 
-	fmt.Printf(i18n.T("status.success_export")+"\n", keymanageOutput)
+	if keymanageExportPriv {
+		fmt.Printf(i18n.T("status.success_export_private")+"\n", keymanageOutput)
+	} else {
+		fmt.Printf(i18n.T("status.success_export")+"\n", keymanageOutput)
+	}
 	return nil
 }
 
+// runExportRecipient 将 -s 混合私钥或 -p 混合公钥导出为收件人字符串，未指定 -o 时输出到标准输出.
+func runExportRecipient() error {
+	if keymanageExportPriv {
+		return fmt.Errorf("%s", i18n.T("error.recipient_public_only"))
+	}
+	var pub *zjcrypto.HybridPublicKey
+	switch {
+	case keymanagePrivKey != "":
+		priv, err := utils.LoadHybridPrivateKey(keymanagePrivKey)
+		if err != nil {
+			return err
+		}
+		pub = &zjcrypto.HybridPublicKey{Kyber: priv.Kyber.Public(), ECDH: priv.ECDH.PublicKey()}
+	case keymanagePubKey != "":
+		var err error
+		if pub, err = utils.LoadHybridPublicKey(keymanagePubKey); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf(i18n.T("error.missing_required_flags"), "--private-key / --public-key")
+	}
+
+	recipient, err := zjcrypto.EncodeRecipient(pub)
+	if err != nil {
+		return fmt.Errorf("export key failed: %w",
+			i18n.TranslateError("error.export_key_failed", err))
+	}
+	if keymanageOutput == "" {
+		fmt.Println(recipient)
+		return nil
+	}
+	if err := os.WriteFile(keymanageOutput, []byte(recipient+"\n"), 0644); err != nil {
+		return fmt.Errorf("save export failed: %w",
+			i18n.TranslateError("error.save_export_failed", err))
+	}
+	fmt.Printf(i18n.T("status.success_export_recipient")+"\n", keymanageOutput)
+	return nil
+}
+
+func exportHybridKey(priv *zjcrypto.HybridPrivateKey) ([]byte, error) {
+	switch {
+	case keymanageExportPriv && keymanageFormat == keyFormatPKCS8:
+		return zjcrypto.ExportPrivateKeyPKCS8(priv.Kyber, priv.ECDH)
+	case keymanageExportPriv:
+		return zjcrypto.ExportHybridPrivateKey(priv)
+	case keymanageFormat == keyFormatPKCS8:
+		return zjcrypto.ExportPublicKeyPKIX(priv.Kyber.Public(), priv.ECDH.PublicKey())
+	default:
+		return zjcrypto.ExportPublicKey(priv.Kyber.Public(), priv.ECDH.PublicKey())
+	}
+}
+
+func exportDilithiumKey(priv zjcrypto.SigningKey) ([]byte, error) {
+	pub := zjcrypto.DilithiumPublicFromPrivate(priv)
+	export := zjcrypto.ExportDilithiumKeys
+	if keymanageFormat == keyFormatPKCS8 {
+		export = zjcrypto.ExportDilithiumKeysPKCS8
+	}
+	pair, err := export(pub, priv)
+	if err != nil {
+		return nil, err
+	}
+	if keymanageExportPriv {
+		return pair.Private, nil
+	}
+	clear(pair.Private)
+	return pair.Public, nil
+}
+
 // import: 导入密钥到指定目录
 func runImport() error {
 	if keymanagePubKey == "" || keymanagePrivKey == "" {
@@ -117,27 +247,29 @@ func runImport() error {
 			i18n.TranslateError("error.load_public_key_failed", err, keymanagePubKey))
 	}
 
-	hybridPriv, err := zjcrypto.LoadPrivateKey(keymanagePrivKey)
+	// 私钥可以是文件或私钥 URI；导入需要私钥本身，不经过密钥代理
+	provider, err := zjcrypto.ParseKeyURI(keymanagePrivKey)
 	if err != nil {
 		return fmt.Errorf("load private key failed: %w",
 			i18n.TranslateError("error.load_private_key_failed", err, keymanagePrivKey))
 	}
+	hybridPriv, err := zjcrypto.LoadPrivateKeyFrom(provider)
+	if err != nil {
+		return fmt.Errorf("load private key failed: %w",
+			i18n.TranslateError("error.load_private_key_failed", err, provider))
+	}
 
-	// 生成新路径
+	// 生成新路径，私钥来自 URI 时按公钥文件名命名
 	basePub := filepath.Base(keymanagePubKey)
-	basePriv := filepath.Base(keymanagePrivKey)
+	basePriv := strings.TrimSuffix(basePub, "_public.pem") + "_private.pem"
+	if file, ok := provider.(*zjcrypto.FileKeyProvider); ok {
+		basePriv = filepath.Base(file.Path)
+	}
 	newPubPath := filepath.Join(keymanageOutputDir, basePub)
 	newPrivPath := filepath.Join(keymanageOutputDir, basePriv)
 
 	// 保存到新位置
-	if err := zjcrypto.SaveKeyFiles(
-		hybridPub.Kyber,
-		hybridPub.ECDH,
-		hybridPriv.Kyber,
-		hybridPriv.ECDH,
-		newPubPath,
-		newPrivPath,
-	); err != nil {
+	if err := zjcrypto.SaveHybridKeyFiles(hybridPub, hybridPriv, newPubPath, newPrivPath); err != nil {
 		return fmt.Errorf("save keys failed: %w",
 			i18n.TranslateError("error.save_keys_failed", err))
 	}
@@ -166,11 +298,10 @@ func runVerify() error {
 	}
 
 	// 加载私钥
-	hybridPriv, err := zjcrypto.LoadPrivateKey(keymanagePrivKey)
+	hybridPriv, err := utils.LoadHybridPrivateKey(keymanagePrivKey)
 	if err != nil {
 		fmt.Println(i18n.T("status.failed"))
-		return fmt.Errorf("load private key failed: %w",
-			i18n.TranslateError("error.load_private_key_failed", err, keymanagePrivKey))
+		return err
 	}
 
 	// 验证密钥对是否匹配
@@ -214,3 +345,376 @@ func runCacheInfo() error {
 
 	return nil
 }
+
+// csr: 生成身份证书请求，-p 为混合公钥，-s 为用于签名请求的 Dilithium 私钥.
+func runCSR() error {
+	if keymanageOutput == "" {
+		return fmt.Errorf(i18n.T("error.missing_required_flags"), "--output")
+	}
+	subject, encKey, signKey, err := loadIdentityInputs()
+	if err != nil {
+		return err
+	}
+	out, err := zjcrypto.CreateIdentityRequest(subject, encKey, signKey)
+	if err != nil {
+		return fmt.Errorf("create certificate request failed: %w",
+			i18n.TranslateError("error.certificate_failed", err))
+	}
+	if err := writeCertificateFile(out); err != nil {
+		return err
+	}
+	fmt.Printf(i18n.T("status.success_csr")+"\n", keymanageOutput, subject)
+	return nil
+}
+
+// selfsign: 用 -s 指定的 Dilithium 私钥签发自签名身份证书，可作为本地 CA.
+func runSelfSign() error {
+	if keymanageOutput == "" {
+		return fmt.Errorf(i18n.T("error.missing_required_flags"), "--output")
+	}
+	notBefore, notAfter, err := certificateValidity()
+	if err != nil {
+		return err
+	}
+	subject, encKey, signKey, err := loadIdentityInputs()
+	if err != nil {
+		return err
+	}
+	out, err := zjcrypto.SelfSignIdentity(subject, encKey, signKey, notBefore, notAfter)
+	if err != nil {
+		return fmt.Errorf("self-sign certificate failed: %w",
+			i18n.TranslateError("error.certificate_failed", err))
+	}
+	if err := writeCertificateFile(out); err != nil {
+		return err
+	}
+	fmt.Printf(i18n.T("status.success_certificate")+"\n", keymanageOutput, subject, notAfter.Format(time.DateOnly))
+	return nil
+}
+
+// issue: 用 --ca 证书和 -s 指定的 CA Dilithium 私钥为 --csr 请求签发身份证书.
+func runIssue() error {
+	if keymanageCSR == "" || keymanageCA == "" || keymanagePrivKey == "" || keymanageOutput == "" {
+		return fmt.Errorf(i18n.T("error.missing_required_flags"), "--csr, --ca, --private-key, --output")
+	}
+	notBefore, notAfter, err := certificateValidity()
+	if err != nil {
+		return err
+	}
+	csrPEM, err := os.ReadFile(keymanageCSR)
+	if err != nil {
+		return fmt.Errorf(i18n.T("error.cannot_read_file"), err)
+	}
+	req, err := zjcrypto.ParseIdentityRequest(csrPEM)
+	if err != nil {
+		return fmt.Errorf("parse certificate request failed: %w",
+			i18n.TranslateError("error.certificate_failed", err))
+	}
+	ca, err := zjcrypto.LoadIdentityCertificate(keymanageCA)
+	if err != nil {
+		return fmt.Errorf("load CA certificate failed: %w",
+			i18n.TranslateError("error.certificate_failed", err))
+	}
+	caKey, err := utils.LoadDilithiumPrivateKey(keymanagePrivKey)
+	if err != nil {
+		return err
+	}
+	out, err := zjcrypto.IssueIdentityCertificate(req, ca, caKey, notBefore, notAfter)
+	if err != nil {
+		return fmt.Errorf("issue certificate failed: %w",
+			i18n.TranslateError("error.certificate_failed", err))
+	}
+	if err := writeCertificateFile(out); err != nil {
+		return err
+	}
+	fmt.Printf(i18n.T("status.success_certificate")+"\n", keymanageOutput, req.Subject, notAfter.Format(time.DateOnly))
+	return nil
+}
+
+// loadIdentityInputs 读取 --subject、-p 混合公钥和 -s Dilithium 私钥.
+func loadIdentityInputs() (pkix.Name, *zjcrypto.HybridPublicKey, zjcrypto.SigningKey, error) {
+	if keymanageSubject == "" || keymanagePubKey == "" || keymanagePrivKey == "" {
+		return pkix.Name{}, nil, nil, fmt.Errorf(i18n.T("error.missing_required_flags"),
+			"--subject, --public-key, --private-key")
+	}
+	subject, err := zjcrypto.ParseSubject(keymanageSubject)
+	if err != nil {
+		return pkix.Name{}, nil, nil, fmt.Errorf("parse subject failed: %w",
+			i18n.TranslateError("error.certificate_failed", err))
+	}
+	encKey, err := utils.LoadHybridPublicKey(keymanagePubKey)
+	if err != nil {
+		return pkix.Name{}, nil, nil, err
+	}
+	signKey, err := utils.LoadDilithiumPrivateKey(keymanagePrivKey)
+	if err != nil {
+		return pkix.Name{}, nil, nil, err
+	}
+	return subject, encKey, signKey, nil
+}
+
+// certificateValidity 由 --days 计算有效期，起始时间为当前时间.
+func certificateValidity() (notBefore, notAfter time.Time, err error) {
+	if keymanageDays <= 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf(i18n.T("error.invalid_days"), keymanageDays)
+	}
+	notBefore = time.Now()
+	return notBefore, notBefore.AddDate(0, 0, keymanageDays), nil
+}
+
+func writeCertificateFile(data []byte) error {
+	if err := os.WriteFile(keymanageOutput, data, 0644); err != nil {
+		return fmt.Errorf("save certificate failed: %w",
+			i18n.TranslateError("error.certificate_failed", err))
+	}
+	return nil
+}
+
+// paperkey: 将 -s 私钥或 -p 公钥输出为纸质备份，-o 以 .png 结尾时生成二维码，否则生成文本.
+func runPaperKey() error {
+	if keymanageOutput == "" {
+		return fmt.Errorf(i18n.T("error.missing_required_flags"), "--output")
+	}
+	var key any
+	var err error
+	switch {
+	case keymanagePrivKey != "":
+		hybridPriv, signKey, loadErr := utils.LoadPrivateKeyMaterial(keymanagePrivKey)
+		if loadErr != nil {
+			return loadErr
+		}
+		if key = signKey; hybridPriv != nil {
+			key = hybridPriv
+		}
+	case keymanagePubKey != "":
+		if key, err = zjcrypto.LoadPublicKey(keymanagePubKey); err != nil {
+			signKey, signErr := zjcrypto.LoadDilithiumPublicKey(keymanagePubKey)
+			if signErr != nil {
+				return fmt.Errorf("load public key failed: %w",
+					i18n.TranslateError("error.load_public_key_failed", err, keymanagePubKey))
+			}
+			key = signKey
+		}
+	default:
+		return fmt.Errorf(i18n.T("error.missing_required_flags"), "--private-key / --public-key")
+	}
+
+	paper, err := zjcrypto.NewPaperKey(key)
+	if err != nil {
+		return fmt.Errorf("create paper backup failed: %w", i18n.TranslateError("error.paperkey_failed", err))
+	}
+	defer paper.Wipe()
+
+	asQR := strings.EqualFold(filepath.Ext(keymanageOutput), paperQRFileSuffix)
+	var out []byte
+	var count int
+	if asQR {
+		parts := paper.Parts(paperQRLines)
+		if out, err = renderPaperQR(parts); err != nil {
+			return fmt.Errorf("render QR code failed: %w", i18n.TranslateError("error.paperkey_failed", err))
+		}
+		count = len(parts)
+	} else {
+		out = paper.Text()
+		count = len(paper.Lines())
+	}
+	defer clear(out)
+
+	perm := os.FileMode(0644)
+	if paper.Kind.IsPrivate() {
+		perm = 0600
+	}
+	if err := os.WriteFile(keymanageOutput, out, perm); err != nil {
+		return fmt.Errorf("save paper backup failed: %w", i18n.TranslateError("error.paperkey_failed", err))
+	}
+	if asQR {
+		fmt.Printf(i18n.T("status.success_paperkey_qr")+"\n", keymanageOutput, paper.Kind, count)
+	} else {
+		fmt.Printf(i18n.T("status.success_paperkey")+"\n", keymanageOutput, paper.Kind, count)
+	}
+	return nil
+}
+
+// renderPaperQR 为每份文本生成一个二维码，自上而下拼接为一张 PNG.
+func renderPaperQR(parts [][]byte) ([]byte, error) {
+	images := make([]image.Image, 0, len(parts))
+	width, height := 0, 0
+	for _, part := range parts {
+		code, err := qrcode.New(string(part), qrcode.Medium)
+		if err != nil {
+			return nil, err
+		}
+		img := code.Image(-paperQRModulePx)
+		images = append(images, img)
+		width = max(width, img.Bounds().Dx())
+		height += img.Bounds().Dy()
+	}
+
+	canvas := image.NewGray(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	y := 0
+	for _, img := range images {
+		r := image.Rect(0, y, img.Bounds().Dx(), y+img.Bounds().Dy())
+		draw.Draw(canvas, r, img, img.Bounds().Min, draw.Src)
+		y += img.Bounds().Dy()
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// restore-paper: 解析 -i 指定的纸质备份文本，还原为原生 PEM 密钥文件 -o.
+func runRestorePaper() error {
+	if keymanageInput == "" || keymanageOutput == "" {
+		return fmt.Errorf(i18n.T("error.missing_required_flags"), "--input, --output")
+	}
+	text, err := os.ReadFile(keymanageInput)
+	if err != nil {
+		return fmt.Errorf(i18n.T("error.cannot_read_file"), err)
+	}
+	defer clear(text)
+
+	paper, err := zjcrypto.ParsePaperKey(text)
+	if err != nil {
+		return fmt.Errorf("parse paper backup failed: %w", i18n.TranslateError("error.restore_paper_failed", err))
+	}
+	defer paper.Wipe()
+	out, err := paper.PEM()
+	if err != nil {
+		return fmt.Errorf("restore key failed: %w", i18n.TranslateError("error.restore_paper_failed", err))
+	}
+	defer clear(out)
+
+	perm := os.FileMode(0644)
+	if paper.Kind.IsPrivate() {
+		perm = 0600
+	}
+	if err := os.WriteFile(keymanageOutput, out, perm); err != nil {
+		return fmt.Errorf("save key failed: %w", i18n.TranslateError("error.restore_paper_failed", err))
+	}
+	fmt.Printf(i18n.T("status.success_restore_paper")+"\n", keymanageOutput, paper.Kind)
+	return nil
+}
+
+// fingerprint: 显示 -p / -s 中公钥的指纹；同时给出加密与签名公钥时显示身份指纹.
+// --compare 只比较不显示，不一致时返回错误，便于脚本使用.
+func runFingerprint() error {
+	enc, sign, err := loadFingerprintKeys()
+	if err != nil {
+		return err
+	}
+
+	var fp zjcrypto.Fingerprint
+	var title string
+	switch {
+	case enc != nil && sign != nil:
+		if fp, err = zjcrypto.IdentityFingerprint(enc, sign); err != nil {
+			return fmt.Errorf("compute fingerprint failed: %w", i18n.TranslateError("error.fingerprint_failed", err))
+		}
+		title = i18n.T("fingerprint.identity")
+	case enc != nil:
+		if fp, err = zjcrypto.HybridFingerprint(enc); err != nil {
+			return fmt.Errorf("compute fingerprint failed: %w", i18n.TranslateError("error.fingerprint_failed", err))
+		}
+		title = i18n.T("fingerprint.hybrid")
+	default:
+		fp = zjcrypto.DilithiumFingerprint(sign)
+		title = i18n.T("fingerprint.dilithium")
+	}
+
+	if keymanageCompare != "" {
+		if !fp.Matches(keymanageCompare) {
+			return fmt.Errorf(i18n.T("error.fingerprint_mismatch"), fp)
+		}
+		fmt.Println(i18n.T("status.fingerprint_match"))
+		return nil
+	}
+
+	groups := fp.HexGroups()
+	words := fp.Words()
+	sas := make([]string, 0, len(fp.SAS()))
+	for _, e := range fp.SAS() {
+		sas = append(sas, e.Emoji+" "+e.Name)
+	}
+	fmt.Println(title)
+	fmt.Printf("  SHA-256:\n    %s\n    %s\n", strings.Join(groups[:8], " "), strings.Join(groups[8:], " "))
+	fmt.Printf("  %s\n    %s\n    %s\n", i18n.T("fingerprint.words"),
+		strings.Join(words[:6], " "), strings.Join(words[6:], " "))
+	fmt.Printf("  %s\n    %s\n", i18n.T("fingerprint.sas"), strings.Join(sas, "   "))
+	if enc != nil && sign != nil {
+		encFP, _ := zjcrypto.HybridFingerprint(enc)
+		fmt.Printf("  %s %s\n", i18n.T("fingerprint.encryption_key"), encFP)
+		fmt.Printf("  %s %s\n", i18n.T("fingerprint.signing_key"), zjcrypto.DilithiumFingerprint(sign))
+	}
+	return nil
+}
+
+// loadFingerprintKeys 从 -p 和 -s 读取加密公钥和签名公钥. 每个参数可以是混合或 Dilithium 的公钥、私钥，
+// 身份证书（同时提供两个公钥，不检查签发者）或 fzj1... 收件人字符串.
+func loadFingerprintKeys() (*zjcrypto.HybridPublicKey, zjcrypto.VerifyingKey, error) {
+	if keymanagePubKey == "" && keymanagePrivKey == "" {
+		return nil, nil, fmt.Errorf(i18n.T("error.missing_required_flags"), "--public-key / --private-key")
+	}
+	var enc *zjcrypto.HybridPublicKey
+	var sign zjcrypto.VerifyingKey
+	for _, path := range []string{keymanagePubKey, keymanagePrivKey} {
+		if path == "" {
+			continue
+		}
+		e, s, err := loadFingerprintKey(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if (e != nil && enc != nil) || (s != nil && sign != nil) {
+			return nil, nil, fmt.Errorf(i18n.T("error.fingerprint_duplicate_key"), path)
+		}
+		if e != nil {
+			enc = e
+		}
+		if s != nil {
+			sign = s
+		}
+	}
+	return enc, sign, nil
+}
+
+func loadFingerprintKey(path string) (*zjcrypto.HybridPublicKey, zjcrypto.VerifyingKey, error) {
+	if _, statErr := os.Stat(path); statErr != nil && zjcrypto.IsRecipient(path) {
+		enc, err := zjcrypto.ParseRecipient(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse recipient failed: %w", i18n.TranslateError("error.invalid_recipient", err))
+		}
+		return enc, nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf(i18n.T("error.cannot_read_file"), err)
+	}
+	if zjcrypto.IsCertificatePEM(data) {
+		id, err := zjcrypto.ParseIdentityCertificate(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load certificate failed: %w",
+				i18n.TranslateError("error.certificate_failed", err))
+		}
+		return id.EncryptionKey, id.SigningKey, nil
+	}
+
+	if enc, err := zjcrypto.LoadPublicKey(path); err == nil {
+		return enc, nil, nil
+	}
+	if sign, err := zjcrypto.LoadDilithiumPublicKey(path); err == nil {
+		return nil, sign, nil
+	}
+	if priv, err := zjcrypto.LoadPrivateKey(path); err == nil {
+		return &zjcrypto.HybridPublicKey{Kyber: priv.Kyber.Public(), ECDH: priv.ECDH.PublicKey()}, nil, nil
+	}
+	priv, err := zjcrypto.LoadDilithiumPrivateKey(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load public key failed: %w",
+			i18n.TranslateError("error.load_public_key_failed", err, path))
+	}
+	return nil, zjcrypto.DilithiumPublicFromPrivate(priv), nil
+}
@@ -0,0 +1,142 @@
+// Package main 提供文件加密解密命令行工具.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"codeberg.org/jiangfire/fzjjyz/cmd/fzjjyz/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lsInput      string
+	lsPrivKey    string
+	lsVerifyKey  string
+	lsBufferSize int
+	lsStreaming  bool
+	lsOnly       []string
+)
+
+func newLsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: i18n.T("ls.short"),
+		Long:  i18n.T("ls.long"),
+		RunE:  runLs,
+	}
+
+	cmd.Flags().StringVarP(&lsInput, "input", "i", "", i18n.T("ls.flags.input"))
+	cmd.Flags().StringVarP(&lsPrivKey, "private-key", "p", "", i18n.T("ls.flags.private-key"))
+	cmd.Flags().StringVarP(&lsVerifyKey, "verify-key", "s", "", i18n.T("ls.flags.verify-key"))
+	cmd.Flags().IntVar(&lsBufferSize, "buffer-size", 0, i18n.T("ls.flags.buffer-size"))
+	cmd.Flags().BoolVar(&lsStreaming, "streaming", true, i18n.T("ls.flags.streaming"))
+	cmd.Flags().StringArrayVar(&lsOnly, "only", nil, i18n.T("ls.flags.only"))
+
+	_ = cmd.MarkFlagRequired("input")
+	_ = cmd.MarkFlagRequired("private-key")
+
+	return cmd
+}
+
+func runLs(_ *cobra.Command, _ []string) error {
+	//nolint:wrapcheck
+	if err := utils.ValidateInputFile(lsInput); err != nil {
+		return err
+	}
+	if err := zjcrypto.ValidateZipPatterns(lsOnly); err != nil {
+		return fmt.Errorf("--only: %w", i18n.TranslateError("error.invalid_pattern", err))
+	}
+
+	hybridPriv, err := utils.LoadHybridPrivateKey(lsPrivKey)
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	var dilithiumPub = zjcrypto.VerifyingKey(nil)
+	if lsVerifyKey != "" {
+		dilithiumPub, err = utils.LoadDilithiumVerifyKey(lsVerifyKey)
+		if err != nil {
+			//nolint:wrapcheck
+			return err
+		}
+	} else {
+		// 警告输出到 stderr，保持 stdout 列表便于脚本处理
+		fmt.Fprintln(os.Stderr, i18n.T("status.warning_no_sign_verify"))
+	}
+
+	entries, err := listArchiveEntries(hybridPriv, dilithiumPub)
+	if err != nil {
+		return err
+	}
+
+	printArchiveEntries(entries, lsOnly)
+	return nil
+}
+
+// listArchiveEntries 读取存档条目：索引存档只解密索引，ZIP 存档需解密整个存档.
+func listArchiveEntries(
+	hybridPriv *zjcrypto.HybridPrivateKey,
+	dilithiumPub zjcrypto.VerifyingKey,
+) ([]zjcrypto.ArchiveEntry, error) {
+	headerFile, err := os.Open(lsInput) // #nosec G304 - 文件路径来自用户输入，已通过参数验证
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("error.cannot_open_file"), err)
+	}
+	header, err := format.ParseFileHeader(headerFile)
+	_ = headerFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("error.parse_header_failed"), err)
+	}
+
+	if header.IsIndexedArchive() {
+		archive, err := zjcrypto.OpenIndexedArchive(lsInput, hybridPriv.Kyber, hybridPriv.ECDH, dilithiumPub)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt failed: %w",
+				i18n.TranslateError("error.decrypt_failed", err))
+		}
+		defer func() {
+			_ = archive.Close()
+		}()
+		return archive.ArchiveEntries(), nil
+	}
+
+	bufSize := calculateBufferSizeFromFile(lsInput, lsBufferSize)
+	zipData, err := decryptArchiveToMemory(lsInput, hybridPriv, dilithiumPub, lsStreaming, bufSize)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := zjcrypto.ListZipEntries(zipData)
+	if err != nil {
+		return nil, fmt.Errorf("list failed: %w", i18n.TranslateError("error.list_failed", err))
+	}
+	return entries, nil
+}
+
+// printArchiveEntries 按 "权限 大小 修改时间 路径" 的格式输出条目及汇总.
+func printArchiveEntries(entries []zjcrypto.ArchiveEntry, patterns []string) {
+	var files, dirs int
+	var totalSize uint64
+	for _, entry := range entries {
+		if !zjcrypto.MatchZipPatterns(patterns, entry.Name) {
+			continue
+		}
+		if entry.IsDir {
+			dirs++
+		} else {
+			files++
+			totalSize += entry.Size
+		}
+		fmt.Printf("%s %12d %s %s\n",
+			entry.Mode.String(),
+			entry.Size,
+			entry.Modified.Local().Format("2006-01-02 15:04:05"),
+			entry.Name)
+	}
+	fmt.Printf(i18n.T("ls.summary")+"\n", files, dirs, totalSize)
+}
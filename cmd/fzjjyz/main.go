@@ -55,9 +55,15 @@ func init() {
 		newDecryptCmd(),
 		newEncryptDirCmd(),
 		newDecryptDirCmd(),
+		newLsCmd(),
+		newVerifyDirCmd(),
+		newCheckTreeCmd(),
+		newRepoCmd(),
 		newKeygenCmd(),
 		newKeymanageCmd(),
 		newInfoCmd(),
+		newServeCmd(),
+		newAgentCmd(),
 		newVersionCmd(),
 	)
 }
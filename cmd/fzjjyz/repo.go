@@ -0,0 +1,357 @@
+// Package main 提供文件加密解密命令行工具.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"codeberg.org/jiangfire/fzjjyz/cmd/fzjjyz/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
+	"codeberg.org/jiangfire/fzjjyz/internal/repo"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	repoDir       string
+	repoPrivKey   string
+	repoVerifyKey string
+
+	repoInitPubKeys   []string
+	repoInitSignKey   string
+	repoInitChunkSize string
+
+	repoBackupInput   string
+	repoBackupSignKey string
+	repoBackupTags    []string
+
+	repoRestoreSnapshot string
+	repoRestoreOutput   string
+	repoRestoreOnly     []string
+	repoRestoreForce    bool
+
+	repoPruneKeepLast int
+	repoPruneForget   []string
+)
+
+func newRepoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repo",
+		Short: i18n.T("repo.short"),
+		Long:  i18n.T("repo.long"),
+	}
+
+	cmd.PersistentFlags().StringVarP(&repoDir, "repo", "r", "", i18n.T("repo.flags.repo"))
+	_ = cmd.MarkPersistentFlagRequired("repo")
+
+	cmd.AddCommand(
+		newRepoInitCmd(),
+		newRepoBackupCmd(),
+		newRepoSnapshotsCmd(),
+		newRepoRestoreCmd(),
+		newRepoPruneCmd(),
+	)
+	return cmd
+}
+
+func newRepoInitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: i18n.T("repo.init.short"),
+		Long:  i18n.T("repo.init.long"),
+		RunE:  runRepoInit,
+	}
+
+	cmd.Flags().StringArrayVarP(&repoInitPubKeys, "public-key", "p", nil, i18n.T("repo.init.flags.public-key"))
+	cmd.Flags().StringVarP(&repoInitSignKey, "sign-key", "s", "", i18n.T("repo.init.flags.sign-key"))
+	cmd.Flags().StringVar(&repoInitChunkSize, "chunk-size", "1M", i18n.T("repo.init.flags.chunk-size"))
+
+	_ = cmd.MarkFlagRequired("public-key")
+	_ = cmd.MarkFlagRequired("sign-key")
+
+	return cmd
+}
+
+func newRepoBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: i18n.T("repo.backup.short"),
+		Long:  i18n.T("repo.backup.long"),
+		RunE:  runRepoBackup,
+	}
+
+	cmd.Flags().StringVarP(&repoBackupInput, "input", "i", "", i18n.T("repo.backup.flags.input"))
+	cmd.Flags().StringVarP(&repoPrivKey, "private-key", "p", "", i18n.T("repo.flags.private-key"))
+	cmd.Flags().StringVarP(&repoBackupSignKey, "sign-key", "s", "", i18n.T("repo.backup.flags.sign-key"))
+	cmd.Flags().StringArrayVar(&repoBackupTags, "tag", nil, i18n.T("repo.backup.flags.tag"))
+
+	_ = cmd.MarkFlagRequired("input")
+	_ = cmd.MarkFlagRequired("private-key")
+	_ = cmd.MarkFlagRequired("sign-key")
+
+	return cmd
+}
+
+func newRepoSnapshotsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshots",
+		Short: i18n.T("repo.snapshots.short"),
+		Long:  i18n.T("repo.snapshots.long"),
+		RunE:  runRepoSnapshots,
+	}
+	addRepoReadFlags(cmd)
+	return cmd
+}
+
+func newRepoRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: i18n.T("repo.restore.short"),
+		Long:  i18n.T("repo.restore.long"),
+		RunE:  runRepoRestore,
+	}
+	addRepoReadFlags(cmd)
+
+	cmd.Flags().StringVar(&repoRestoreSnapshot, "snapshot", repo.LatestSnapshot, i18n.T("repo.restore.flags.snapshot"))
+	cmd.Flags().StringVarP(&repoRestoreOutput, "output", "o", "", i18n.T("repo.restore.flags.output"))
+	cmd.Flags().StringArrayVar(&repoRestoreOnly, "only", nil, i18n.T("repo.restore.flags.only"))
+	cmd.Flags().BoolVarP(&repoRestoreForce, "force", "f", false, i18n.T("repo.restore.flags.force"))
+
+	_ = cmd.MarkFlagRequired("output")
+
+	return cmd
+}
+
+func newRepoPruneCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: i18n.T("repo.prune.short"),
+		Long:  i18n.T("repo.prune.long"),
+		RunE:  runRepoPrune,
+	}
+	addRepoReadFlags(cmd)
+
+	cmd.Flags().IntVar(&repoPruneKeepLast, "keep-last", 0, i18n.T("repo.prune.flags.keep-last"))
+	cmd.Flags().StringArrayVar(&repoPruneForget, "forget", nil, i18n.T("repo.prune.flags.forget"))
+
+	return cmd
+}
+
+// addRepoReadFlags 添加读取仓库所需的私钥和验证公钥参数.
+func addRepoReadFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&repoPrivKey, "private-key", "p", "", i18n.T("repo.flags.private-key"))
+	cmd.Flags().StringVarP(&repoVerifyKey, "verify-key", "s", "", i18n.T("repo.flags.verify-key"))
+	_ = cmd.MarkFlagRequired("private-key")
+}
+
+func runRepoInit(_ *cobra.Command, _ []string) error {
+	avg, err := utils.ParseByteSize(repoInitChunkSize)
+	if err != nil || avg <= 0 || avg > 8<<20 {
+		return fmt.Errorf(i18n.T("error.repo_invalid_chunk_size"), repoInitChunkSize)
+	}
+	opts := repo.InitOptions{
+		MinChunkSize: int(avg / 4),
+		AvgChunkSize: int(avg),
+		MaxChunkSize: int(avg * 8),
+	}
+
+	reporter := utils.NewProgressReporter(2, verbose)
+	reporter.Step("progress.loading_keys")
+	recipients := make([]*zjcrypto.HybridPublicKey, 0, len(repoInitPubKeys))
+	for _, path := range repoInitPubKeys {
+		pub, err := utils.LoadHybridPublicKey(path)
+		if err != nil {
+			reporter.Failed()
+			//nolint:wrapcheck
+			return err
+		}
+		recipients = append(recipients, pub)
+	}
+	signKey, err := utils.LoadDilithiumPrivateKey(repoInitSignKey)
+	if err != nil {
+		reporter.Failed()
+		//nolint:wrapcheck
+		return err
+	}
+	reporter.Done()
+
+	reporter.Step("repo.progress.init")
+	config, err := repo.Init(repoDir, recipients, signKey, opts)
+	if err != nil {
+		reporter.Failed()
+		return fmt.Errorf("init failed: %w", i18n.TranslateError("error.repo_failed", err))
+	}
+	reporter.Done()
+
+	fmt.Printf(i18n.T("repo.init.summary")+"\n", repoDir, config.ID, len(recipients), config.AvgChunkSize/1024)
+	return nil
+}
+
+// openRepo 加载私钥和可选的验证公钥并打开仓库.
+func openRepo(reporter *utils.ProgressReporter, verifyKey zjcrypto.VerifyingKey) (*repo.Repository, error) {
+	reporter.Step("repo.progress.open")
+	hybridPriv, err := utils.LoadHybridPrivateKey(repoPrivKey)
+	if err != nil {
+		reporter.Failed()
+		//nolint:wrapcheck
+		return nil, err
+	}
+	r, err := repo.Open(repoDir, hybridPriv, verifyKey)
+	if err != nil {
+		reporter.Failed()
+		return nil, fmt.Errorf("open failed: %w", i18n.TranslateError("error.repo_failed", err))
+	}
+	reporter.Done()
+	return r, nil
+}
+
+// loadRepoVerifyKey 加载 -s 指定的验证公钥，未指定时输出警告.
+func loadRepoVerifyKey() (zjcrypto.VerifyingKey, error) {
+	if repoVerifyKey == "" {
+		fmt.Fprintln(os.Stderr, i18n.T("status.warning_no_sign_verify"))
+		return nil, nil
+	}
+	//nolint:wrapcheck
+	return utils.LoadDilithiumVerifyKey(repoVerifyKey)
+}
+
+func runRepoBackup(_ *cobra.Command, _ []string) error {
+	//nolint:wrapcheck
+	if err := utils.ValidateInputDir(repoBackupInput); err != nil {
+		return err
+	}
+	signKey, err := utils.LoadDilithiumPrivateKey(repoBackupSignKey)
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	reporter := utils.NewProgressReporter(2, verbose)
+	r, err := openRepo(reporter, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	reporter.Step("repo.progress.backup")
+	snap, stats, err := r.Backup(repoBackupInput, signKey, repo.BackupOptions{Tags: repoBackupTags})
+	if err != nil {
+		reporter.Failed()
+		return fmt.Errorf("backup failed: %w", i18n.TranslateError("error.repo_failed", err))
+	}
+	reporter.Done()
+
+	fmt.Printf(i18n.T("repo.backup.summary")+"\n",
+		snap.ShortID(), stats.Files, stats.Dirs, stats.Unchanged,
+		stats.NewChunks, stats.ReusedChunks, stats.BytesRead, stats.BytesAdded)
+	return nil
+}
+
+func runRepoSnapshots(_ *cobra.Command, _ []string) error {
+	verifyKey, err := loadRepoVerifyKey()
+	if err != nil {
+		return err
+	}
+	hybridPriv, err := utils.LoadHybridPrivateKey(repoPrivKey)
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+	r, err := repo.Open(repoDir, hybridPriv, verifyKey)
+	if err != nil {
+		return fmt.Errorf("open failed: %w", i18n.TranslateError("error.repo_failed", err))
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	snapshots, err := r.ListSnapshots()
+	if err != nil {
+		return fmt.Errorf("list failed: %w", i18n.TranslateError("error.repo_failed", err))
+	}
+	for _, snap := range snapshots {
+		tags := ""
+		if len(snap.Tags) > 0 {
+			tags = " [" + strings.Join(snap.Tags, ",") + "]"
+		}
+		fmt.Printf("%s  %s  %6d  %12d  %s%s\n",
+			snap.ShortID(), snap.Time.Local().Format("2006-01-02 15:04:05"),
+			snap.Files(), snap.Size(), snap.Source, tags)
+	}
+	fmt.Printf(i18n.T("repo.snapshots.total")+"\n", len(snapshots))
+	return nil
+}
+
+func runRepoRestore(_ *cobra.Command, _ []string) error {
+	if err := zjcrypto.ValidateZipPatterns(repoRestoreOnly); err != nil {
+		return fmt.Errorf("--only: %w", i18n.TranslateError("error.invalid_pattern", err))
+	}
+	if !repoRestoreForce {
+		if entries, _ := os.ReadDir(repoRestoreOutput); len(entries) > 0 {
+			return fmt.Errorf(i18n.T("error.output_dir_not_empty"), repoRestoreOutput)
+		}
+	}
+	verifyKey, err := loadRepoVerifyKey()
+	if err != nil {
+		return err
+	}
+
+	reporter := utils.NewProgressReporter(2, verbose)
+	r, err := openRepo(reporter, verifyKey)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	reporter.Step("repo.progress.restore")
+	snap, err := r.FindSnapshot(repoRestoreSnapshot)
+	if err != nil {
+		reporter.Failed()
+		return fmt.Errorf("restore failed: %w", i18n.TranslateError("error.repo_failed", err))
+	}
+	count, err := r.Restore(snap, repoRestoreOutput, repoRestoreOnly)
+	if err != nil {
+		reporter.Failed()
+		return fmt.Errorf("restore failed: %w", i18n.TranslateError("error.repo_failed", err))
+	}
+	reporter.Done()
+
+	fmt.Printf(i18n.T("repo.restore.summary")+"\n", snap.ShortID(), count, repoRestoreOutput)
+	return nil
+}
+
+func runRepoPrune(_ *cobra.Command, _ []string) error {
+	if repoPruneKeepLast <= 0 && len(repoPruneForget) == 0 {
+		return fmt.Errorf(i18n.T("error.missing_required_flags"), "--keep-last / --forget")
+	}
+	verifyKey, err := loadRepoVerifyKey()
+	if err != nil {
+		return err
+	}
+
+	reporter := utils.NewProgressReporter(2, verbose)
+	r, err := openRepo(reporter, verifyKey)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	reporter.Step("repo.progress.prune")
+	stats, err := r.Prune(repo.PruneOptions{KeepLast: repoPruneKeepLast, Forget: repoPruneForget})
+	if err != nil {
+		reporter.Failed()
+		return fmt.Errorf("prune failed: %w", i18n.TranslateError("error.repo_failed", err))
+	}
+	reporter.Done()
+
+	fmt.Printf(i18n.T("repo.prune.summary")+"\n",
+		stats.SnapshotsRemoved, stats.SnapshotsKept, stats.ChunksRemoved, stats.BytesFreed)
+	return nil
+}
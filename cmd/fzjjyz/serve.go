@@ -0,0 +1,177 @@
+// Package main 提供文件加密解密命令行工具.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/cmd/fzjjyz/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
+	"codeberg.org/jiangfire/fzjjyz/internal/server"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+	"github.com/spf13/cobra"
+)
+
+const serveShutdownTimeout = 10 * time.Second
+
+var (
+	serveListen      string
+	serveKeyring     string
+	serveTokenFile   string
+	serveTLSCert     string
+	serveTLSKey      string
+	serveClientCA    string
+	serveMaxBodySize string
+	serveAllowHosts  []string
+	serveInsecure    bool
+)
+
+func newServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: i18n.T("serve.short"),
+		Long:  i18n.T("serve.long"),
+		RunE:  runServe,
+	}
+
+	cmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:8443", i18n.T("serve.flags.listen"))
+	cmd.Flags().StringVarP(&serveKeyring, "keyring", "k", "", i18n.T("serve.flags.keyring"))
+	cmd.Flags().StringVar(&serveTokenFile, "token-file", "", i18n.T("serve.flags.token-file"))
+	cmd.Flags().StringVar(&serveTLSCert, "tls-cert", "", i18n.T("serve.flags.tls-cert"))
+	cmd.Flags().StringVar(&serveTLSKey, "tls-key", "", i18n.T("serve.flags.tls-key"))
+	cmd.Flags().StringVar(&serveClientCA, "client-ca", "", i18n.T("serve.flags.client-ca"))
+	cmd.Flags().StringVar(&serveMaxBodySize, "max-body-size", "1G", i18n.T("serve.flags.max-body-size"))
+	cmd.Flags().StringArrayVar(&serveAllowHosts, "allow-host", nil, i18n.T("serve.flags.allow-host"))
+	cmd.Flags().BoolVar(&serveInsecure, "insecure-no-auth", false, i18n.T("serve.flags.insecure-no-auth"))
+
+	_ = cmd.MarkFlagRequired("keyring")
+
+	return cmd
+}
+
+func runServe(_ *cobra.Command, _ []string) error {
+	if (serveTLSCert == "") != (serveTLSKey == "") || (serveClientCA != "" && serveTLSCert == "") {
+		return fmt.Errorf("%s", i18n.T("error.serve_tls_flags"))
+	}
+	maxBodySize, err := utils.ParseByteSize(serveMaxBodySize)
+	if err != nil {
+		return fmt.Errorf("--max-body-size: %w", err)
+	}
+	token, err := readServeToken()
+	if err != nil {
+		return err
+	}
+
+	keyring, err := zjcrypto.LoadKeyring(serveKeyring)
+	if err != nil {
+		return fmt.Errorf("load keyring: %w", i18n.TranslateError("error.keyring_failed", err))
+	}
+	// --listen 中的主机名（如 myhost:8443）总是允许出现在 Host 头中
+	allowHosts := serveAllowHosts
+	if host, _, err := net.SplitHostPort(serveListen); err == nil && host != "" {
+		allowHosts = append(allowHosts, host)
+	}
+	handler, err := server.New(server.Config{
+		Keyring:      keyring,
+		Token:        token,
+		MaxBodySize:  maxBodySize,
+		AllowedHosts: allowHosts,
+	})
+	if err != nil {
+		return fmt.Errorf("create server: %w", i18n.TranslateError("error.serve_failed", err))
+	}
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	scheme := "http"
+	if serveTLSCert != "" {
+		if srv.TLSConfig, err = server.TLSConfig(serveTLSCert, serveTLSKey, serveClientCA); err != nil {
+			return fmt.Errorf("tls: %w", i18n.TranslateError("error.serve_failed", err))
+		}
+		scheme = "https"
+	}
+
+	listener, err := net.Listen("tcp", serveListen)
+	if err != nil {
+		return fmt.Errorf("listen: %w", i18n.TranslateError("error.serve_failed", err))
+	}
+	if err := checkServeExposure(listener.Addr(), token); err != nil {
+		_ = listener.Close()
+		return err
+	}
+	for _, entry := range keyring.Entries() {
+		fmt.Printf(i18n.T("serve.key")+"\n", entry.Fingerprint[:16], entry.Name)
+	}
+	// 监听地址单独成行输出，便于脚本在 --listen 使用端口 0 时获取实际端口
+	fmt.Printf(i18n.T("serve.listening")+"\n", scheme+"://"+listener.Addr().String())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errCh := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			errCh <- srv.ServeTLS(listener, "", "")
+		} else {
+			errCh <- srv.Serve(listener)
+		}
+	}()
+
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+		defer cancel()
+		err = srv.Shutdown(shutdownCtx)
+		fmt.Println(i18n.T("serve.stopped"))
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve: %w", i18n.TranslateError("error.serve_failed", err))
+	}
+	return nil
+}
+
+// readServeToken 读取令牌文件，去除首尾空白.
+func readServeToken() (string, error) {
+	if serveTokenFile == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(serveTokenFile) // #nosec G304 - 路径来自命令行参数
+	if err != nil {
+		return "", fmt.Errorf(i18n.T("error.cannot_read_file"), err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf(i18n.T("error.serve_empty_token"), serveTokenFile)
+	}
+	return token, nil
+}
+
+// checkServeExposure 拒绝在非回环地址上无认证运行，或通过明文 HTTP 传输令牌.
+// 服务持有密钥环私钥，网络上的任何人都可以借此解密和签名.
+func checkServeExposure(addr net.Addr, token string) error {
+	if isLoopback(addr) {
+		return nil
+	}
+	if token != "" && serveTLSCert == "" {
+		return fmt.Errorf("%s", i18n.T("error.serve_token_plain_http"))
+	}
+	if token == "" && serveClientCA == "" {
+		if !serveInsecure {
+			return fmt.Errorf("%s", i18n.T("error.serve_no_auth"))
+		}
+		fmt.Fprintln(os.Stderr, i18n.T("serve.warning_no_auth"))
+	}
+	return nil
+}
+
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
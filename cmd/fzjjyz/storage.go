@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
+	"codeberg.org/jiangfire/fzjjyz/internal/storage"
+)
+
+// remoteStage 远程存储位置在本地的中转文件.
+// 加密命令先写入中转文件再上传，解密命令先下载到中转文件再处理.
+// nil 表示本地路径，所有方法均为空操作.
+type remoteStage struct {
+	target string // 远程存储位置
+	local  string // 本地中转文件
+	dir    string // 中转目录
+}
+
+// newRemoteStage 在临时目录中创建与远程对象同名的中转路径，便于结果输出显示原文件名.
+func newRemoteStage(target string) (*remoteStage, error) {
+	name := "data.fzj"
+	if u, err := url.Parse(target); err == nil {
+		if base := path.Base(u.Path); base != "." && base != "/" {
+			name = base
+		}
+	}
+	dir, err := os.MkdirTemp("", "fzjjyz-remote-*")
+	if err != nil {
+		return nil, fmt.Errorf("temp file failed: %w",
+			i18n.TranslateError("error.temp_file_failed", err))
+	}
+	return &remoteStage{target: target, local: filepath.Join(dir, name), dir: dir}, nil
+}
+
+// stageRemoteOutput 远程输出时将 *output 替换为本地中转路径，file:// URL 替换为本地路径.
+// 未使用 --force 时拒绝覆盖远程已有对象.
+func stageRemoteOutput(output *string, force bool) (*remoteStage, error) {
+	if local, ok := storage.LocalPath(*output); ok {
+		*output = local
+		return nil, nil
+	}
+	if !force {
+		exists, err := storage.Exists(*output)
+		if err != nil {
+			return nil, fmt.Errorf("storage failed: %w",
+				i18n.TranslateError("error.storage_failed", err))
+		}
+		if exists {
+			return nil, fmt.Errorf(i18n.T("error.output_file_exists"), *output)
+		}
+	}
+	stage, err := newRemoteStage(*output)
+	if err != nil {
+		return nil, err
+	}
+	*output = stage.local
+	return stage, nil
+}
+
+// stageRemoteInput 远程输入时下载到本地中转文件，并将 *input 替换为中转路径.
+// file:// URL 直接替换为本地路径.
+func stageRemoteInput(input *string) (*remoteStage, error) {
+	if local, ok := storage.LocalPath(*input); ok {
+		*input = local
+		return nil, nil
+	}
+	stage, err := newRemoteStage(*input)
+	if err != nil {
+		return nil, err
+	}
+	n, err := storage.Download(*input, stage.local)
+	if err != nil {
+		stage.cleanup()
+		return nil, fmt.Errorf("storage failed: %w",
+			i18n.TranslateError("error.storage_failed", err))
+	}
+	if verbose {
+		fmt.Printf(i18n.T("storage.downloaded")+"\n", *input, n)
+	}
+	*input = stage.local
+	return stage, nil
+}
+
+// finishOutput 命令成功时上传中转文件，之后总是清理中转目录.
+func (s *remoteStage) finishOutput(err error) error {
+	if s == nil {
+		return err
+	}
+	defer s.cleanup()
+	if err != nil {
+		return err
+	}
+	n, err := storage.Upload(s.local, s.target)
+	if err != nil {
+		return fmt.Errorf("storage failed: %w",
+			i18n.TranslateError("error.storage_failed", err))
+	}
+	fmt.Printf(i18n.T("storage.uploaded")+"\n", s.target, n)
+	return nil
+}
+
+func (s *remoteStage) cleanup() {
+	if s != nil {
+		_ = os.RemoveAll(s.dir)
+	}
+}
+
+// rejectRemote 对不支持远程存储的参数返回错误.
+func rejectRemote(flag, value string) error {
+	if storage.IsRemote(value) {
+		return fmt.Errorf(i18n.T("error.remote_unsupported"), flag, value)
+	}
+	return nil
+}
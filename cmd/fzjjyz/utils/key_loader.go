@@ -5,14 +5,43 @@ package utils
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
+	"codeberg.org/jiangfire/fzjjyz/internal/agent"
 	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
 	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
-	"github.com/cloudflare/circl/sign/dilithium/mode3"
 )
 
 // LoadHybridPrivateKey loads hybrid private key (eliminates 4 repetitions).
-func LoadHybridPrivateKey(path string) (*zjcrypto.HybridPrivateKey, error) {
+// uri 为文件路径或 zjcrypto.ParseKeyURI 支持的私钥 URI；文件路径经过缓存，
+// 设置 FZJJYZ_AGENT_SOCK 时优先使用密钥代理中匹配的密钥.
+func LoadHybridPrivateKey(uri string) (*zjcrypto.HybridPrivateKey, error) {
+	provider, err := zjcrypto.ParseKeyURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("load private key failed: %w",
+			i18n.TranslateError("error.load_private_key_failed", err, uri))
+	}
+	file, ok := provider.(*zjcrypto.FileKeyProvider)
+	if !ok {
+		key, err := zjcrypto.LoadPrivateKeyFrom(provider)
+		if err != nil {
+			return nil, fmt.Errorf("load private key failed: %w",
+				i18n.TranslateError("error.load_private_key_failed", err, provider))
+		}
+		return key, nil
+	}
+
+	path := file.Path
+	if client := agentClient(); client != nil {
+		if id := findAgentIdentity(client, path, "_private.pem", hybridFingerprint); id != nil && id.CanDecrypt {
+			if key, err := client.PrivateKey(id); err == nil {
+				return key, nil
+			}
+		}
+	}
 	key, err := zjcrypto.LoadPrivateKeyCached(path)
 	if err != nil {
 		return nil, fmt.Errorf("load private key failed: %w",
@@ -22,10 +51,18 @@ func LoadHybridPrivateKey(path string) (*zjcrypto.HybridPrivateKey, error) {
 }
 
 // LoadDilithiumVerifyKey loads signature verification public key (eliminates 3 repetitions).
-func LoadDilithiumVerifyKey(path string) (*mode3.PublicKey, error) {
+// path 也可以是身份证书，此时使用签名证书中的公钥.
+func LoadDilithiumVerifyKey(path string) (zjcrypto.VerifyingKey, error) {
 	if path == "" {
 		return nil, nil
 	}
+	if id, err := loadIdentityCertificate(path); id != nil || err != nil {
+		if err != nil {
+			return nil, fmt.Errorf("load verify key failed: %w",
+				i18n.TranslateError("error.load_verify_key_failed", err, path))
+		}
+		return id.SigningKey, nil
+	}
 	key, err := zjcrypto.LoadDilithiumPublicKeyCached(path)
 	if err != nil {
 		return nil, fmt.Errorf("load verify key failed: %w",
@@ -35,7 +72,25 @@ func LoadDilithiumVerifyKey(path string) (*mode3.PublicKey, error) {
 }
 
 // LoadHybridPublicKey loads hybrid public key.
+// path 也可以是身份证书，此时使用加密证书中的公钥；或者是 fzj1... 收件人字符串本身.
 func LoadHybridPublicKey(path string) (*zjcrypto.HybridPublicKey, error) {
+	if zjcrypto.IsRecipient(path) {
+		if _, err := os.Stat(path); err != nil {
+			key, err := zjcrypto.ParseRecipient(path)
+			if err != nil {
+				return nil, fmt.Errorf("parse recipient failed: %w",
+					i18n.TranslateError("error.invalid_recipient", err))
+			}
+			return key, nil
+		}
+	}
+	if id, err := loadIdentityCertificate(path); id != nil || err != nil {
+		if err != nil {
+			return nil, fmt.Errorf("load public key failed: %w",
+				i18n.TranslateError("error.load_public_key_failed", err, path))
+		}
+		return id.EncryptionKey, nil
+	}
 	key, err := zjcrypto.LoadPublicKeyCached(path)
 	if err != nil {
 		return nil, fmt.Errorf("load public key failed: %w",
@@ -45,11 +100,142 @@ func LoadHybridPublicKey(path string) (*zjcrypto.HybridPublicKey, error) {
 }
 
 // LoadDilithiumPrivateKey loads signature private key.
-func LoadDilithiumPrivateKey(path string) (*mode3.PrivateKey, error) {
+// uri 的含义与 LoadHybridPrivateKey 相同.
+func LoadDilithiumPrivateKey(uri string) (zjcrypto.SigningKey, error) {
+	provider, err := zjcrypto.ParseKeyURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("load dilithium private key failed: %w",
+			i18n.TranslateError("error.load_sign_key_failed", err, uri))
+	}
+	file, ok := provider.(*zjcrypto.FileKeyProvider)
+	if !ok {
+		key, err := zjcrypto.LoadDilithiumPrivateKeyFrom(provider)
+		if err != nil {
+			return nil, fmt.Errorf("load dilithium private key failed: %w",
+				i18n.TranslateError("error.load_sign_key_failed", err, provider))
+		}
+		return key, nil
+	}
+
+	path := file.Path
+	if client := agentClient(); client != nil {
+		if id := findAgentIdentity(client, path, "_dilithium_private.pem", signingFingerprint); id != nil &&
+			id.SigningFingerprint != "" {
+			if key, err := client.SigningKey(id); err == nil {
+				return key, nil
+			}
+		}
+	}
 	key, err := zjcrypto.LoadDilithiumPrivateKeyCached(path)
 	if err != nil {
 		return nil, fmt.Errorf("load dilithium private key failed: %w",
-			i18n.TranslateError("error.load_dilithium_private_key_failed", err, path))
+			i18n.TranslateError("error.load_sign_key_failed", err, path))
 	}
 	return key, nil
 }
+
+// LoadPrivateKeyMaterial 加载 uri 指向的混合私钥或 Dilithium 私钥，只返回其中一个.
+// 用于导出、纸质备份等需要私钥本身的场合，因此不经过密钥代理和缓存.
+func LoadPrivateKeyMaterial(uri string) (*zjcrypto.HybridPrivateKey, zjcrypto.SigningKey, error) {
+	provider, err := zjcrypto.ParseKeyURI(uri)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load private key failed: %w",
+			i18n.TranslateError("error.load_private_key_failed", err, uri))
+	}
+	hybrid, signKey, err := zjcrypto.LoadAnyPrivateKeyFrom(provider)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load private key failed: %w",
+			i18n.TranslateError("error.load_private_key_failed", err, provider))
+	}
+	return hybrid, signKey, nil
+}
+
+// loadIdentityCertificate 在 path 是身份证书时加载证书，并检查有效期和是否由 FZJJYZ_CA 中的 CA 签发.
+// path 不是证书（或无法读取，交由调用方报告）时返回 nil, nil.
+func loadIdentityCertificate(path string) (*zjcrypto.IdentityCertificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !zjcrypto.IsCertificatePEM(data) {
+		return nil, nil //nolint:nilnil // 不是证书
+	}
+	id, err := zjcrypto.ParseIdentityCertificate(data)
+	if err != nil {
+		return nil, err
+	}
+	caFile := os.Getenv(zjcrypto.CAFileEnv)
+	if caFile == "" {
+		return nil, fmt.Errorf(i18n.T("error.ca_not_configured"), zjcrypto.CAFileEnv)
+	}
+	roots, err := zjcrypto.LoadTrustedCAs(caFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := id.Verify(roots, time.Now()); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+var (
+	agentOnce sync.Once
+	agentConn *agent.Client
+)
+
+// agentClient 返回到 FZJJYZ_AGENT_SOCK 所指代理的连接，未设置或无法连接时返回 nil.
+// 连接在进程内只建立一次.
+func agentClient() *agent.Client {
+	agentOnce.Do(func() {
+		sock := os.Getenv(agent.SockEnv)
+		if sock == "" {
+			return
+		}
+		client, err := agent.Dial(sock)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("agent.fallback")+"\n", sock, err)
+			return
+		}
+		agentConn = client
+	})
+	return agentConn
+}
+
+// findAgentIdentity 在代理中查找与私钥参数对应的密钥.
+// path 不是现有文件时按名称或指纹查找；以 suffix 结尾时只接受指纹与同目录公钥文件相同的密钥.
+// 找不到时返回 nil，由调用方读取私钥文件，避免使用与参数同名但不同的代理密钥.
+func findAgentIdentity(client *agent.Client, path, suffix string,
+	siblingFingerprint func(base string) (string, error)) *agent.Identity {
+	if _, err := os.Stat(path); err != nil {
+		if id, err := client.Find(path); err == nil {
+			return id
+		}
+	}
+	base, ok := strings.CutSuffix(path, suffix)
+	if !ok {
+		return nil
+	}
+	fp, err := siblingFingerprint(base)
+	if err != nil {
+		return nil
+	}
+	id, err := client.Find(fp)
+	if err != nil || (id.Fingerprint != fp && id.SigningFingerprint != fp) {
+		return nil
+	}
+	return id
+}
+
+func hybridFingerprint(base string) (string, error) {
+	pub, err := zjcrypto.LoadPublicKeyCached(base + "_public.pem")
+	if err != nil {
+		return "", err
+	}
+	//nolint:wrapcheck
+	return zjcrypto.RecipientFingerprint(pub.Kyber, pub.ECDH)
+}
+
+func signingFingerprint(base string) (string, error) {
+	pub, err := zjcrypto.LoadDilithiumPublicKeyCached(base + "_dilithium_public.pem")
+	if err != nil {
+		return "", err
+	}
+	return zjcrypto.SigningFingerprint(pub), nil
+}
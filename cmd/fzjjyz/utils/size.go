@@ -0,0 +1,79 @@
+// Package utils provides byte size parsing for command line flags.
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// byteSizeUnits maps size suffixes to multipliers (binary units: 1K = 1024).
+var byteSizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"K":   1 << 10,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"M":   1 << 20,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"G":   1 << 30,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+	"T":   1 << 40,
+	"TB":  1 << 40,
+	"TIB": 1 << 40,
+}
+
+// ParseByteSize parses sizes like "512", "64K", "1.5G" or "2TB" into bytes.
+// "0" and "unlimited" return 0.
+func ParseByteSize(s string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	if text == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+	if text == "UNLIMITED" {
+		return 0, nil
+	}
+
+	split := len(text)
+	for split > 0 && !isSizeDigit(text[split-1]) {
+		split--
+	}
+	number, unit := strings.TrimSpace(text[:split]), strings.TrimSpace(text[split:])
+
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit: %q", s)
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 || math.IsNaN(value) {
+		return 0, fmt.Errorf("invalid size: %q", s)
+	}
+
+	bytes := value * float64(multiplier)
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("size too large: %q", s)
+	}
+	return int64(bytes), nil
+}
+
+// FormatByteSize formats bytes with binary units (e.g. 1.5 GiB).
+func FormatByteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func isSizeDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '.'
+}
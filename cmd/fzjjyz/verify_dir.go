@@ -0,0 +1,139 @@
+// Package main 提供文件加密解密命令行工具.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"codeberg.org/jiangfire/fzjjyz/cmd/fzjjyz/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyDirInput     string
+	verifyDirPrivKey   string
+	verifyDirVerifyKey string
+	verifyDirAgainst   string
+	verifyDirLimits    extractLimitFlags
+)
+
+func newVerifyDirCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-dir",
+		Short: i18n.T("verify-dir.short"),
+		Long:  i18n.T("verify-dir.long"),
+		RunE:  runVerifyDir,
+	}
+
+	cmd.Flags().StringVarP(&verifyDirInput, "input", "i", "", i18n.T("verify-dir.flags.input"))
+	cmd.Flags().StringVarP(&verifyDirPrivKey, "private-key", "p", "", i18n.T("verify-dir.flags.private-key"))
+	cmd.Flags().StringVarP(&verifyDirVerifyKey, "verify-key", "s", "", i18n.T("verify-dir.flags.verify-key"))
+	cmd.Flags().StringVar(&verifyDirAgainst, "against", "", i18n.T("verify-dir.flags.against"))
+	verifyDirLimits.register(cmd)
+
+	_ = cmd.MarkFlagRequired("input")
+	_ = cmd.MarkFlagRequired("private-key")
+	_ = cmd.MarkFlagRequired("against")
+
+	return cmd
+}
+
+func runVerifyDir(_ *cobra.Command, _ []string) error {
+	//nolint:wrapcheck
+	if err := utils.ValidateInputFile(verifyDirInput); err != nil {
+		return err
+	}
+	//nolint:wrapcheck
+	if err := utils.ValidateInputDir(verifyDirAgainst); err != nil {
+		return err
+	}
+
+	extractOpts, err := verifyDirLimits.options()
+	if err != nil {
+		return err
+	}
+
+	hybridPriv, err := utils.LoadHybridPrivateKey(verifyDirPrivKey)
+	if err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	var dilithiumPub = zjcrypto.VerifyingKey(nil)
+	if verifyDirVerifyKey != "" {
+		dilithiumPub, err = utils.LoadDilithiumVerifyKey(verifyDirVerifyKey)
+		if err != nil {
+			//nolint:wrapcheck
+			return err
+		}
+	} else {
+		fmt.Fprintln(os.Stderr, i18n.T("status.warning_no_sign_verify"))
+	}
+
+	report, err := compareArchiveWithDir(hybridPriv, dilithiumPub, extractOpts)
+	if err != nil {
+		return fmt.Errorf("verify failed: %w", i18n.TranslateError("error.verify_dir_failed", err))
+	}
+
+	printTreeDiffs(report.Diffs)
+	fmt.Printf(i18n.T("verify-dir.summary")+"\n", report.Entries, report.Verified, len(report.Diffs))
+
+	if report.HasDifferences() {
+		return errors.New(i18n.T("error.tree_differs"))
+	}
+	fmt.Println(i18n.T("verify-dir.identical"))
+	return nil
+}
+
+// compareArchiveWithDir 在内存中解密存档并与目录比较，不写入任何文件.
+func compareArchiveWithDir(
+	hybridPriv *zjcrypto.HybridPrivateKey,
+	dilithiumPub zjcrypto.VerifyingKey,
+	extractOpts zjcrypto.ExtractOptions,
+) (*zjcrypto.TreeDiffReport, error) {
+	headerFile, err := os.Open(verifyDirInput) // #nosec G304 - 文件路径来自用户输入，已通过参数验证
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("error.cannot_open_file"), err)
+	}
+	header, err := format.ParseFileHeader(headerFile)
+	_ = headerFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("error.parse_header_failed"), err)
+	}
+
+	if header.IsIndexedArchive() {
+		archive, err := zjcrypto.OpenIndexedArchive(verifyDirInput, hybridPriv.Kyber, hybridPriv.ECDH, dilithiumPub)
+		if err != nil {
+			//nolint:wrapcheck
+			return nil, err
+		}
+		defer func() {
+			_ = archive.Close()
+		}()
+		//nolint:wrapcheck
+		return archive.VerifyAgainstDirectory(verifyDirAgainst, extractOpts)
+	}
+
+	zipData, err := zjcrypto.DecryptFileCore(verifyDirInput, hybridPriv.Kyber, hybridPriv.ECDH, dilithiumPub)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	//nolint:wrapcheck
+	return zjcrypto.VerifyZipAgainstDirectory(zipData, verifyDirAgainst, extractOpts)
+}
+
+// printTreeDiffs 按 "标记 路径 (说明)" 的格式逐行输出差异.
+func printTreeDiffs(diffs []zjcrypto.TreeDiff) {
+	for _, diff := range diffs {
+		if diff.Detail != "" {
+			fmt.Printf("%s %s (%s)\n", diff.Kind, diff.Name, diff.Detail)
+		} else {
+			fmt.Printf("%s %s\n", diff.Kind, diff.Name)
+		}
+	}
+}
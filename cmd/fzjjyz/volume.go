@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"codeberg.org/jiangfire/fzjjyz/cmd/fzjjyz/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/i18n"
+	"codeberg.org/jiangfire/fzjjyz/internal/storage"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+)
+
+// parseVolumeSize 解析 --volume-size，空字符串表示不分卷.
+func parseVolumeSize(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	size, err := utils.ParseByteSize(value)
+	if err != nil || size < zjcrypto.MinVolumeSize {
+		return 0, fmt.Errorf(i18n.T("error.invalid_volume_size"), value, zjcrypto.MinVolumeSize)
+	}
+	return size, nil
+}
+
+// checkVolumeOutput 检查分卷输出：不支持远程存储，未使用 --force 时拒绝覆盖已有的第一个分卷.
+func checkVolumeOutput(output string, force bool) error {
+	local, ok := storage.LocalPath(output)
+	if !ok {
+		return fmt.Errorf(i18n.T("error.remote_unsupported"), "--volume-size", output)
+	}
+	//nolint:wrapcheck
+	return utils.CheckOutputConflict(format.VolumeName(local, 1), force)
+}
+
+// createVolumeOutput 创建分卷写入器，加密结果直接写入 output.001、output.002 ...
+func createVolumeOutput(output string, volumeSize int64, signKey zjcrypto.SigningKey) (*zjcrypto.VolumeWriter, error) {
+	volumes, err := zjcrypto.CreateVolumes(output, volumeSize, signKey)
+	if err != nil {
+		return nil, fmt.Errorf("create volumes failed: %w",
+			i18n.TranslateError("error.volume_failed", err))
+	}
+	return volumes, nil
+}
+
+// finishVolumeOutput 加密成功时签名并回填所有分卷头，失败时删除已写入的分卷.
+// volumes 为 nil 时原样返回 err.
+func finishVolumeOutput(volumes *zjcrypto.VolumeWriter, err error) error {
+	if volumes == nil {
+		return err
+	}
+	if err != nil {
+		volumes.Abort()
+		return err
+	}
+	if err := volumes.Close(); err != nil {
+		return fmt.Errorf("write volumes failed: %w",
+			i18n.TranslateError("error.volume_failed", err))
+	}
+	paths := volumes.Paths()
+	fmt.Printf(i18n.T("volume.split")+"\n", len(paths), filepath.Base(paths[0]), filepath.Base(paths[len(paths)-1]))
+	return nil
+}
+
+// encryptedOutputSize 返回加密输出的大小，分卷输出时为原加密文件大小.
+func encryptedOutputSize(output string, volumes *zjcrypto.VolumeWriter) (int64, error) {
+	if volumes != nil {
+		return volumes.Size(), nil
+	}
+	info, err := os.Stat(output)
+	if err != nil {
+		return 0, fmt.Errorf("stat %s: %w", output, err)
+	}
+	return info.Size(), nil
+}
+
+// openVolumeInput 输入为分卷时打开并校验分卷集，解密时直接从分卷读取；普通加密文件返回 nil.
+// 提供验证公钥时同时验证每个分卷头的签名.
+func openVolumeInput(input, verifyKeyPath string) (*zjcrypto.VolumeReader, error) {
+	if !zjcrypto.IsVolumeFile(input) {
+		return nil, nil
+	}
+	dilithiumPub, err := utils.LoadDilithiumVerifyKey(verifyKeyPath)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	volumes, err := zjcrypto.OpenVolumes(input, dilithiumPub)
+	if err != nil {
+		return nil, fmt.Errorf("open volumes failed: %w",
+			i18n.TranslateError("error.volume_failed", err))
+	}
+	if verbose {
+		fmt.Printf(i18n.T("volume.opened")+"\n", volumes.Count(), input, volumes.Size())
+	}
+	return volumes, nil
+}
+
+// closeVolumeInput 关闭 openVolumeInput 打开的分卷集，volumes 可以为 nil.
+func closeVolumeInput(volumes *zjcrypto.VolumeReader) {
+	if volumes != nil {
+		_ = volumes.Close()
+	}
+}
+
+// openEncryptedInput 打开加密输入用于读取文件头，分卷输入返回分卷集中原加密文件的字节流.
+func openEncryptedInput(path string, volumes *zjcrypto.VolumeReader) (io.ReadCloser, error) {
+	if volumes != nil {
+		return io.NopCloser(io.NewSectionReader(volumes, 0, volumes.Size())), nil
+	}
+	//nolint:wrapcheck
+	return os.Open(path) // #nosec G304 - 文件路径来自用户输入，已通过参数验证
+}
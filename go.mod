@@ -4,11 +4,18 @@ go 1.25.4
 
 require (
 	github.com/cloudflare/circl v1.6.3
+	github.com/klauspost/compress v1.18.0
+	github.com/miekg/pkcs11 v1.1.2
+	github.com/pkg/sftp v1.13.9
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/spf13/cobra v1.10.2
 	github.com/spf13/pflag v1.0.10
+	github.com/tyler-smith/go-bip39 v1.1.0
+	golang.org/x/crypto v0.54.0
+	golang.org/x/sys v0.47.0
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	golang.org/x/sys v0.39.0 // indirect
+	github.com/kr/fs v0.1.0 // indirect
 )
@@ -0,0 +1,330 @@
+// Package agent 实现密钥代理：在内存中持有已解锁的私钥，通过 Unix 套接字提供解封装和签名.
+//
+// 协议为逐行 JSON，每个请求对应一个响应，同一连接可发送多个请求:
+//
+//	{"op":"list"}                                              → {"identities":[...]}
+//	{"op":"decapsulate","key":"<指纹>","kyber":"..","ecdh":".."} → {"secret":".."}
+//	{"op":"sign","key":"<签名指纹>","message":".."}               → {"signature":".."}
+//
+// 二进制字段为 base64，失败时响应为 {"error":{"code":"..","message":".."}}.
+// 私钥从不离开代理，客户端只能得到公钥、单个文件的共享密钥和签名.
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+)
+
+// SockEnv 密钥代理套接字路径的环境变量.
+const SockEnv = "FZJJYZ_AGENT_SOCK"
+
+// 协议操作.
+const (
+	OpList        = "list"
+	OpDecapsulate = "decapsulate"
+	OpSign        = "sign"
+)
+
+const (
+	socketPerm    = 0600
+	socketDirPerm = 0700
+
+	// maxRequestSize 单个请求的最大字节数，签名消息可能是完整的清单或快照.
+	maxRequestSize = 64 << 20
+)
+
+// Identity 代理持有的一组密钥，只包含公开信息.
+type Identity struct {
+	Name string `json:"name"`
+	// Fingerprint 混合公钥指纹，Public 为其 PEM.
+	Fingerprint string `json:"fingerprint"`
+	Public      []byte `json:"public"`
+	CanDecrypt  bool   `json:"can_decrypt"`
+	// SigningFingerprint Dilithium 公钥指纹，SigningPublic 为其原始字节；没有签名私钥时为空.
+	SigningFingerprint string `json:"signing_fingerprint,omitempty"`
+	SigningPublic      []byte `json:"signing_public,omitempty"`
+	// SigningEd25519 复合签名密钥的 Ed25519 公钥；普通 Dilithium 签名密钥时为空.
+	SigningEd25519 []byte `json:"signing_ed25519,omitempty"`
+}
+
+// request 客户端请求.
+type request struct {
+	Op      string `json:"op"`
+	Key     string `json:"key,omitempty"`
+	Kyber   []byte `json:"kyber,omitempty"`
+	ECDH    []byte `json:"ecdh,omitempty"`
+	Message []byte `json:"message,omitempty"`
+}
+
+// response 代理响应.
+type response struct {
+	Identities []Identity  `json:"identities,omitempty"`
+	Secret     []byte      `json:"secret,omitempty"`
+	Signature  []byte      `json:"signature,omitempty"`
+	Error      *errorReply `json:"error,omitempty"`
+}
+
+type errorReply struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Agent 持有密钥环并响应客户端请求.
+type Agent struct {
+	identities []Identity
+	decrypt    map[string]*zjcrypto.KeyringEntry
+	sign       map[string]*zjcrypto.KeyringEntry
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+// New 由密钥环创建代理.
+func New(keyring *zjcrypto.Keyring) (*Agent, error) {
+	a := &Agent{
+		decrypt: make(map[string]*zjcrypto.KeyringEntry),
+		sign:    make(map[string]*zjcrypto.KeyringEntry),
+		conns:   make(map[net.Conn]struct{}),
+	}
+	for _, entry := range keyring.Entries() {
+		pubPEM, err := zjcrypto.ExportPublicKey(entry.Public.Kyber, entry.Public.ECDH)
+		if err != nil {
+			return nil, err
+		}
+		id := Identity{
+			Name:        entry.Name,
+			Fingerprint: entry.Fingerprint,
+			Public:      pubPEM,
+			CanDecrypt:  entry.Private != nil,
+		}
+		if entry.Private != nil {
+			a.decrypt[entry.Fingerprint] = entry
+		}
+		if entry.DilithiumPriv != nil {
+			pub := zjcrypto.DilithiumPublicFromPrivate(entry.DilithiumPriv)
+			id.SigningFingerprint = zjcrypto.SigningFingerprint(pub)
+			id.SigningPublic = pub.Bytes()
+			if composite, ok := pub.(*zjcrypto.CompositePublicKey); ok {
+				id.SigningPublic = composite.Dilithium.Bytes()
+				id.SigningEd25519 = composite.Ed25519
+			}
+			a.sign[id.SigningFingerprint] = entry
+		}
+		a.identities = append(a.identities, id)
+	}
+	return a, nil
+}
+
+// Listen 在 path 创建仅当前用户可访问的 Unix 套接字.
+// 父目录不存在时以 0700 创建；已存在时必须是当前用户所有、权限恰为 0700 的真实目录,
+// 否则其他用户可预先创建该目录并替换其中的套接字. 已存在的陈旧套接字会被替换.
+// 套接字先在新建的 0700 临时目录中创建并设置为 0600，再移动到 path，
+// 因此 path 上不会出现权限受 umask 影响的套接字；监听器关闭时删除 path.
+func Listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, socketDirPerm); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Failed to create socket directory: "+err.Error())
+	}
+	if err := checkSocketDir(dir); err != nil {
+		return nil, err
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Socket path exists and is not a socket: "+path)
+		}
+		// 仍有代理在监听时不替换
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Agent already running at "+path)
+		}
+		_ = os.Remove(path)
+	}
+
+	// MkdirTemp 以 0700 创建目录，其他用户无法在套接字设置权限前连接
+	tmpDir, err := os.MkdirTemp(dir, ".fzjjyz-agent-*")
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Failed to create socket directory: "+err.Error())
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	tmpPath := filepath.Join(tmpDir, "sock")
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpPath, Net: "unix"})
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Failed to listen: "+err.Error())
+	}
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmpPath, socketPerm); err != nil {
+		_ = listener.Close()
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Failed to set socket permissions: "+err.Error())
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = listener.Close()
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Failed to move socket into place: "+err.Error())
+	}
+	return &socketListener{UnixListener: listener, path: path}, nil
+}
+
+// checkSocketDir 确认套接字目录是当前用户独占的真实目录.
+func checkSocketDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return utils.NewCryptoError(utils.ErrIOError, "Failed to inspect socket directory: "+err.Error())
+	}
+	if !info.IsDir() {
+		return utils.NewCryptoError(utils.ErrInvalidParameter, "Socket directory is not a directory: "+dir)
+	}
+	return checkSocketDirAccess(dir, info)
+}
+
+// socketListener 关闭时删除移动后的套接字文件.
+type socketListener struct {
+	*net.UnixListener
+	path string
+	once sync.Once
+}
+
+// Close 关闭监听器并删除套接字文件.
+func (l *socketListener) Close() error {
+	err := l.UnixListener.Close()
+	l.once.Do(func() {
+		_ = os.Remove(l.path)
+	})
+	//nolint:wrapcheck
+	return err
+}
+
+// Serve 接受连接直到 Close 被调用，每个连接在独立的 goroutine 中处理.
+func (a *Agent) Serve(listener net.Listener) error {
+	a.mu.Lock()
+	a.listener = listener
+	a.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return utils.NewCryptoError(utils.ErrIOError, "Accept failed: "+err.Error())
+		}
+		a.mu.Lock()
+		a.conns[conn] = struct{}{}
+		a.mu.Unlock()
+		a.wg.Go(func() {
+			a.handle(conn)
+			a.mu.Lock()
+			delete(a.conns, conn)
+			a.mu.Unlock()
+		})
+	}
+}
+
+// Close 停止监听并断开所有连接.
+func (a *Agent) Close() error {
+	a.mu.Lock()
+	var err error
+	if a.listener != nil {
+		err = a.listener.Close()
+	}
+	for conn := range a.conns {
+		_ = conn.Close()
+	}
+	a.mu.Unlock()
+	a.wg.Wait()
+	//nolint:wrapcheck
+	return err
+}
+
+func (a *Agent) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+	reader := bufio.NewReader(conn)
+	encoder := json.NewEncoder(conn)
+	for {
+		line, err := readLine(reader, maxRequestSize)
+		if err != nil {
+			return
+		}
+
+		var req request
+		var resp response
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp = errorResponse(utils.NewCryptoError(utils.ErrInvalidParameter, "Invalid request: "+err.Error()))
+		} else {
+			resp = a.dispatch(&req)
+		}
+		err = encoder.Encode(resp)
+		clear(resp.Secret)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readLine 读取一行，超过 limit 字节时返回错误.
+func readLine(reader *bufio.Reader, limit int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > limit {
+			return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Message too large")
+		}
+		if err == nil {
+			return line, nil
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			//nolint:wrapcheck
+			return nil, err
+		}
+	}
+}
+
+func (a *Agent) dispatch(req *request) response {
+	switch req.Op {
+	case OpList:
+		return response{Identities: a.identities}
+	case OpDecapsulate:
+		entry, ok := a.decrypt[req.Key]
+		if !ok {
+			return errorResponse(utils.NewCryptoError(utils.ErrFileNotFound, "No decryption key: "+req.Key))
+		}
+		decryptor := zjcrypto.NewHybridDecryptor(entry.Private.Kyber, entry.Private.ECDH)
+		secret, err := decryptor.DecapsulateSecret(req.Kyber, req.ECDH)
+		if err != nil {
+			return errorResponse(err)
+		}
+		defer secret.Release()
+		return response{Secret: append([]byte(nil), secret.Bytes()...)}
+	case OpSign:
+		entry, ok := a.sign[req.Key]
+		if !ok {
+			return errorResponse(utils.NewCryptoError(utils.ErrFileNotFound, "No signing key: "+req.Key))
+		}
+		signature, err := zjcrypto.SignDataWithKey(req.Message, entry.DilithiumPriv)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return response{Signature: signature}
+	default:
+		return errorResponse(utils.NewCryptoError(utils.ErrInvalidParameter, "Unknown operation: "+req.Op))
+	}
+}
+
+func errorResponse(err error) response {
+	code, _ := utils.CodeOf(err)
+	return response{Error: &errorReply{Code: code.String(), Message: err.Error()}}
+}
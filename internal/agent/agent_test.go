@@ -0,0 +1,277 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+)
+
+// writeTestKey 以 keygen 的命名方式在 dir 中生成一组密钥.
+func writeTestKey(t *testing.T, dir, name string) {
+	t.Helper()
+	kyberPub, kyberPriv, err := zjcrypto.GenerateKyberKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdhPub, ecdhPriv, err := zjcrypto.GenerateECDHKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dilithiumPub, dilithiumPriv, err := zjcrypto.GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Join(dir, name)
+	if err := zjcrypto.SaveKeyFiles(kyberPub, ecdhPub, kyberPriv, ecdhPriv,
+		base+"_public.pem", base+"_private.pem"); err != nil {
+		t.Fatal(err)
+	}
+	if err := zjcrypto.SaveDilithiumKeys(dilithiumPub, dilithiumPriv,
+		base+"_dilithium_public.pem", base+"_dilithium_private.pem"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startAgent 启动持有 names 密钥的代理，返回密钥环和套接字路径.
+func startAgent(t *testing.T, names ...string) (*zjcrypto.Keyring, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		writeTestKey(t, dir, name)
+	}
+	return serveKeyring(t, dir)
+}
+
+// serveKeyring 启动持有 dir 中全部密钥的代理.
+func serveKeyring(t *testing.T, dir string) (*zjcrypto.Keyring, string) {
+	t.Helper()
+	keyring, err := zjcrypto.LoadKeyring(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent, err := New(keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sock := filepath.Join(dir, "agent", "sock")
+	listener, err := Listen(sock)
+	if err != nil {
+		t.Fatalf("Listen 失败: %v", err)
+	}
+	go func() {
+		_ = agent.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		_ = agent.Close()
+	})
+	return keyring, sock
+}
+
+func dialAgent(t *testing.T, sock string) *Client {
+	t.Helper()
+	client, err := Dial(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client
+}
+
+func TestAgentDecryptAndSign(t *testing.T) {
+	keyring, sock := startAgent(t, "alice", "bob")
+	alice, _ := keyring.Lookup("alice")
+	client := dialAgent(t, sock)
+
+	identities, err := client.List()
+	if err != nil || len(identities) != 2 {
+		t.Fatalf("List = %+v, %v", identities, err)
+	}
+	// 列表中只有公开信息
+	raw, _ := json.Marshal(identities)
+	if bytes.Contains(raw, []byte("PRIVATE")) {
+		t.Error("身份列表包含私钥")
+	}
+
+	id, err := client.Find(alice.Fingerprint[:10])
+	if err != nil || id.Name != "alice" {
+		t.Fatalf("Find = %+v, %v", id, err)
+	}
+	if signID, err := client.Find(id.SigningFingerprint[:10]); err != nil || signID.Name != "alice" {
+		t.Fatalf("按签名指纹查找失败: %v", err)
+	}
+	priv, err := client.PrivateKey(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signKey, err := client.SigningKey(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, _ := zjcrypto.HybridPublicFromPrivate(priv); pub != nil {
+		fp, _ := zjcrypto.RecipientFingerprint(pub.Kyber, pub.ECDH)
+		if fp != alice.Fingerprint {
+			t.Error("远程私钥对应的公钥不一致")
+		}
+	}
+	if !zjcrypto.DilithiumPublicFromPrivate(signKey).Equal(alice.DilithiumPub) {
+		t.Error("远程签名密钥对应的公钥不一致")
+	}
+
+	// 用代理签名加密，用代理解封装解密，本地公钥验证签名
+	plain := []byte("agent round trip")
+	data, err := zjcrypto.EncryptData(plain, "a.txt", alice.Public.Kyber, alice.Public.ECDH, signKey, zjcrypto.NoCompression)
+	if err != nil {
+		t.Fatalf("代理签名加密失败: %v", err)
+	}
+	got, err := zjcrypto.DecryptDataCore(data, priv.Kyber, priv.ECDH, alice.DilithiumPub)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("代理解密失败: %v", err)
+	}
+
+	// 并发请求共享同一连接
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Go(func() {
+			if _, err := zjcrypto.DecryptDataCore(data, priv.Kyber, priv.ECDH, alice.DilithiumPub); err != nil {
+				errs <- err
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("并发解密失败: %v", err)
+	}
+
+	// bob 的代理私钥无法解密给 alice 的文件
+	bobID, _ := client.Find("bob")
+	bobPriv, _ := client.PrivateKey(bobID)
+	if _, err := zjcrypto.DecryptDataCore(data, bobPriv.Kyber, bobPriv.ECDH, nil); err == nil {
+		t.Error("期望使用错误的代理私钥解密失败")
+	}
+	if _, err := client.Decapsulate("unknown", nil, nil); err == nil || !strings.Contains(err.Error(), "key agent") {
+		t.Errorf("期望未知密钥报错: %v", err)
+	}
+	if _, err := client.Find("carol"); err == nil {
+		t.Error("期望未知名称报错")
+	}
+}
+
+func TestAgentCompositeSign(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "alice")
+	signPub, signPriv, err := zjcrypto.GenerateCompositeKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Join(dir, "alice")
+	if err := zjcrypto.SaveDilithiumKeys(signPub, signPriv,
+		base+"_dilithium_public.pem", base+"_dilithium_private.pem"); err != nil {
+		t.Fatal(err)
+	}
+	keyring, sock := serveKeyring(t, dir)
+	alice, _ := keyring.Lookup("alice")
+	client := dialAgent(t, sock)
+
+	id, err := client.Find("alice")
+	if err != nil || len(id.SigningEd25519) == 0 {
+		t.Fatalf("Find = %+v, %v", id, err)
+	}
+	signKey, err := client.SigningKey(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 分块文件需要预先知道签名长度
+	if zjcrypto.SignatureSizeFor(signKey) != zjcrypto.CompositeSignatureSize {
+		t.Fatal("代理复合签名密钥的签名长度不正确")
+	}
+	// 代理产生的复合签名能用本地复合公钥验证
+	plain := []byte("composite via agent")
+	data, err := zjcrypto.EncryptData(plain, "a.txt", alice.Public.Kyber, alice.Public.ECDH, signKey, zjcrypto.NoCompression)
+	if err != nil {
+		t.Fatalf("代理复合签名加密失败: %v", err)
+	}
+	if _, err := zjcrypto.DecryptDataCore(data, alice.Private.Kyber, alice.Private.ECDH, signPub); err != nil {
+		t.Fatalf("复合签名验证失败: %v", err)
+	}
+}
+
+func TestAgentSocket(t *testing.T) {
+	_, sock := startAgent(t, "alice")
+
+	info, err := os.Stat(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != socketPerm {
+		t.Errorf("套接字权限 = %o", perm)
+	}
+	if _, err := Listen(sock); err == nil {
+		t.Error("期望代理运行时拒绝重复监听")
+	}
+
+	// 无效请求返回错误但不断开连接
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	_, _ = conn.Write([]byte("not json\n{\"op\":\"list\"}\n"))
+	decoder := json.NewDecoder(conn)
+	var first, second response
+	if err := decoder.Decode(&first); err != nil || first.Error == nil || first.Error.Code != "invalid_parameter" {
+		t.Errorf("无效请求响应 = %+v, %v", first, err)
+	}
+	if err := decoder.Decode(&second); err != nil || len(second.Identities) != 1 {
+		t.Errorf("后续请求响应 = %+v, %v", second, err)
+	}
+
+	// 陈旧的套接字文件被替换，普通文件不会被覆盖
+	staleDir := filepath.Join(t.TempDir(), "stale")
+	if err := os.Mkdir(staleDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	stale := filepath.Join(staleDir, "stale.sock")
+	listener, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = listener.Close()
+	if listener, err = Listen(stale); err != nil {
+		t.Fatalf("替换陈旧套接字失败: %v", err)
+	}
+	if info, err := os.Lstat(stale); err != nil || info.Mode().Perm() != socketPerm {
+		t.Errorf("替换后的套接字权限不正确: %v", err)
+	}
+	_ = listener.Close()
+
+	// 关闭后删除套接字，不残留临时目录
+	entries, err := os.ReadDir(filepath.Dir(stale))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("关闭后目录应为空，实际有 %d 项", len(entries))
+	}
+
+	regular := filepath.Join(staleDir, "file")
+	if err := os.WriteFile(regular, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(regular); err == nil {
+		t.Error("期望拒绝覆盖普通文件")
+	}
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenRejectsForeignSocketDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "fzjjyz-shared")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	// Chmod 不受 umask 影响，模拟其他用户预先创建的可写目录
+	if err := os.Chmod(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if listener, err := Listen(filepath.Join(dir, "agent.sock")); err == nil {
+		_ = listener.Close()
+		t.Fatal("期望拒绝权限不是 0700 的套接字目录")
+	}
+
+	// 指向私有目录的符号链接同样被拒绝
+	private := filepath.Join(t.TempDir(), "private")
+	if err := os.Mkdir(private, 0700); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(private, link); err != nil {
+		t.Fatal(err)
+	}
+	if listener, err := Listen(filepath.Join(link, "agent.sock")); err == nil {
+		_ = listener.Close()
+		t.Fatal("期望拒绝符号链接形式的套接字目录")
+	}
+}
@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+// Client 密钥代理客户端，可并发使用（请求在同一连接上串行发送）.
+type Client struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	encoder *json.Encoder
+}
+
+// Dial 连接 path 处的密钥代理.
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Cannot connect to key agent: "+err.Error())
+	}
+	return &Client{conn: conn, reader: bufio.NewReader(conn), encoder: json.NewEncoder(conn)}, nil
+}
+
+// Close 关闭连接.
+func (c *Client) Close() error {
+	//nolint:wrapcheck
+	return c.conn.Close()
+}
+
+func (c *Client) call(req *request) (*response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.encoder.Encode(req); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Key agent request failed: "+err.Error())
+	}
+	line, err := readLine(c.reader, maxRequestSize)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Key agent response failed: "+err.Error())
+	}
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid key agent response: "+err.Error())
+	}
+	if resp.Error != nil {
+		return nil, &utils.CryptoError{Code: parseCode(resp.Error.Code), Message: "key agent: " + resp.Error.Message}
+	}
+	return &resp, nil
+}
+
+// parseCode 将错误代码名称还原为 utils.ErrorCode，未知名称视为 ErrSystem.
+func parseCode(name string) utils.ErrorCode {
+	for code := utils.ErrSystem; code <= utils.ErrFileNotFound; code++ {
+		if code.String() == name {
+			return code
+		}
+	}
+	return utils.ErrSystem
+}
+
+// List 返回代理持有的密钥.
+func (c *Client) List() ([]Identity, error) {
+	resp, err := c.call(&request{Op: OpList})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Identities, nil
+}
+
+// Find 按名称、混合公钥指纹前缀或签名公钥指纹前缀查找密钥.
+func (c *Client) Find(ref string) (*Identity, error) {
+	identities, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	return findIdentity(identities, ref)
+}
+
+func findIdentity(identities []Identity, ref string) (*Identity, error) {
+	for i := range identities {
+		if identities[i].Name == ref {
+			return &identities[i], nil
+		}
+	}
+	ref = strings.ToLower(ref)
+	var found *Identity
+	if len(ref) >= zjcrypto.MinFingerprintPrefix {
+		for i := range identities {
+			id := &identities[i]
+			if !strings.HasPrefix(id.Fingerprint, ref) &&
+				(id.SigningFingerprint == "" || !strings.HasPrefix(id.SigningFingerprint, ref)) {
+				continue
+			}
+			if found != nil && found != id {
+				return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Ambiguous key fingerprint: "+ref)
+			}
+			found = id
+		}
+	}
+	if found == nil {
+		return nil, utils.NewCryptoError(utils.ErrFileNotFound, "Key not found in agent: "+ref)
+	}
+	return found, nil
+}
+
+// PrivateKey 返回由代理解封装的混合私钥.
+func (c *Client) PrivateKey(id *Identity) (*zjcrypto.HybridPrivateKey, error) {
+	if !id.CanDecrypt {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Agent holds no decryption key for "+id.Name)
+	}
+	pub, err := zjcrypto.ImportPublicKey(id.Public)
+	if err != nil {
+		return nil, fmt.Errorf("agent identity %s: %w", id.Name, err)
+	}
+	return zjcrypto.NewRemotePrivateKey(pub, &decapsulator{client: c, key: id.Fingerprint}), nil
+}
+
+// SigningKey 返回由代理签名的远程签名密钥，代理持有复合密钥时签名为复合签名.
+func (c *Client) SigningKey(id *Identity) (zjcrypto.SigningKey, error) {
+	if id.SigningFingerprint == "" {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Agent holds no signing key for "+id.Name)
+	}
+	dilithium := new(mode3.PublicKey)
+	if err := dilithium.UnmarshalBinary(id.SigningPublic); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Invalid signing key from agent: "+err.Error())
+	}
+	var pub zjcrypto.VerifyingKey = dilithium
+	if len(id.SigningEd25519) > 0 {
+		var err error
+		if pub, err = zjcrypto.NewCompositePublicKey(dilithium, id.SigningEd25519); err != nil {
+			return nil, fmt.Errorf("agent identity %s: %w", id.Name, err)
+		}
+	}
+	return zjcrypto.NewRemoteSigningKey(pub, &signer{client: c, key: id.SigningFingerprint}), nil
+}
+
+// Decapsulate 请求代理用指纹为 key 的私钥解封装.
+func (c *Client) Decapsulate(key string, encapsulated, ecdhPub []byte) ([]byte, error) {
+	resp, err := c.call(&request{Op: OpDecapsulate, Key: key, Kyber: encapsulated, ECDH: ecdhPub})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Secret, nil
+}
+
+// Sign 请求代理用签名指纹为 key 的私钥签名.
+func (c *Client) Sign(key string, message []byte) ([]byte, error) {
+	resp, err := c.call(&request{Op: OpSign, Key: key, Message: message})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+// decapsulator 实现 zjcrypto.RemoteDecapsulator.
+type decapsulator struct {
+	client *Client
+	key    string
+}
+
+func (d *decapsulator) Decapsulate(encapsulated, ecdhPub []byte) ([]byte, error) {
+	return d.client.Decapsulate(d.key, encapsulated, ecdhPub)
+}
+
+// signer 实现 zjcrypto.RemoteSigner.
+type signer struct {
+	client *Client
+	key    string
+}
+
+func (s *signer) Sign(message []byte) ([]byte, error) {
+	return s.client.Sign(s.key, message)
+}
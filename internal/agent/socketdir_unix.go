@@ -0,0 +1,24 @@
+//go:build unix
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// checkSocketDirAccess 确认目录属于当前用户且权限恰为 0700.
+func checkSocketDirAccess(dir string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || int(stat.Uid) != os.Getuid() {
+		return utils.NewCryptoError(utils.ErrInvalidParameter, "Socket directory is not owned by the current user: "+dir)
+	}
+	if perm := info.Mode().Perm(); perm != socketDirPerm {
+		return utils.NewCryptoError(utils.ErrInvalidParameter,
+			fmt.Sprintf("Socket directory %s has mode %o, want %o", dir, perm, socketDirPerm))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// 分块文件布局（FileHeader.Flags 含 FlagChunked）:
+//
+//	FileHeader | 加密块 0 | 加密块 1 | ... | 加密块表 | IndexTrailer
+//
+// 每块明文最多 ChunkSize 字节，独立 AES-GCM 加密，可以只解密覆盖读取范围的块.
+// 块表记录每块明文的 SHA256，头部 SHA256Hash 为块表明文的哈希（即一层 Merkle 树的根），
+// 签名覆盖该哈希，因此任意范围的读取都由文件签名覆盖.
+const (
+	// ChunkTableVersion 块表格式版本.
+	ChunkTableVersion uint16 = 0x0001
+	// DefaultChunkSize 分块文件的默认块大小（64KB）.
+	DefaultChunkSize uint32 = 64 * 1024
+	// chunkTableFixedSize 块表固定字段长度.
+	chunkTableFixedSize = 4 + 2 + 4 + 8
+)
+
+var chunkTableMagic = [4]byte{'F', 'Z', 'J', 'C'}
+
+// ChunkTable 分块文件的块表（加密前的明文结构）.
+type ChunkTable struct {
+	ChunkSize uint32     // 每块明文大小
+	FileSize  uint64     // 明文总大小
+	Hashes    [][32]byte // 每块明文的 SHA256
+}
+
+// ChunkCount 返回 fileSize 字节按 chunkSize 分块后的块数.
+func ChunkCount(fileSize uint64, chunkSize uint32) uint64 {
+	return (fileSize + uint64(chunkSize) - 1) / uint64(chunkSize)
+}
+
+// ChunkedDataSize 返回所有加密块的总长度.
+func ChunkedDataSize(fileSize uint64, chunkSize uint32) uint64 {
+	return fileSize + ChunkCount(fileSize, chunkSize)*IndexChunkOverhead
+}
+
+// MarshalBinary 序列化块表.
+func (t *ChunkTable) MarshalBinary() ([]byte, error) {
+	if t.ChunkSize == 0 || t.ChunkSize > MaxIndexChunkSize {
+		return nil, utils.NewCryptoError(
+			utils.ErrSerializationFailed,
+			fmt.Sprintf("Invalid chunk size: %d", t.ChunkSize),
+		)
+	}
+	if uint64(len(t.Hashes)) != ChunkCount(t.FileSize, t.ChunkSize) {
+		return nil, utils.NewCryptoError(utils.ErrSerializationFailed, "Chunk hash count does not match file size")
+	}
+
+	data := make([]byte, 0, chunkTableFixedSize+32*len(t.Hashes))
+	data = append(data, chunkTableMagic[:]...)
+	data = binary.BigEndian.AppendUint16(data, ChunkTableVersion)
+	data = binary.BigEndian.AppendUint32(data, t.ChunkSize)
+	data = binary.BigEndian.AppendUint64(data, t.FileSize)
+	for i := range t.Hashes {
+		data = append(data, t.Hashes[i][:]...)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary 反序列化块表.
+func (t *ChunkTable) UnmarshalBinary(data []byte) error {
+	if len(data) < chunkTableFixedSize || !bytes.Equal(data[:4], chunkTableMagic[:]) {
+		return utils.NewCryptoError(utils.ErrInvalidMagic, "Invalid chunk table magic")
+	}
+	if version := binary.BigEndian.Uint16(data[4:6]); version != ChunkTableVersion {
+		return utils.NewCryptoError(
+			utils.ErrInvalidVersion,
+			fmt.Sprintf("Unsupported chunk table version: 0x%04x", version),
+		)
+	}
+	t.ChunkSize = binary.BigEndian.Uint32(data[6:10])
+	t.FileSize = binary.BigEndian.Uint64(data[10:18])
+	if t.ChunkSize == 0 || t.ChunkSize > MaxIndexChunkSize {
+		return utils.NewCryptoError(
+			utils.ErrInvalidFormat,
+			fmt.Sprintf("Invalid chunk size: %d", t.ChunkSize),
+		)
+	}
+
+	hashes := data[chunkTableFixedSize:]
+	count := ChunkCount(t.FileSize, t.ChunkSize)
+	if uint64(len(hashes)) != count*32 {
+		return utils.NewCryptoError(utils.ErrInvalidFormat, "Chunk table size does not match file size")
+	}
+	t.Hashes = make([][32]byte, count)
+	for i := range t.Hashes {
+		copy(t.Hashes[i][:], hashes[i*32:])
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package format
+
+import (
+	"testing"
+)
+
+func TestChunkTableSerialization(t *testing.T) {
+	table := &ChunkTable{ChunkSize: 4096, FileSize: 10000, Hashes: make([][32]byte, 3)}
+	table.Hashes[2][0] = 0xCD
+
+	data, err := table.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	var parsed ChunkTable
+	if err := parsed.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if parsed.ChunkSize != table.ChunkSize || parsed.FileSize != table.FileSize || len(parsed.Hashes) != 3 ||
+		parsed.Hashes[2] != table.Hashes[2] {
+		t.Fatalf("Chunk table mismatch: %+v", parsed)
+	}
+	if got := ChunkedDataSize(10000, 4096); got != 10000+3*IndexChunkOverhead {
+		t.Errorf("ChunkedDataSize = %d", got)
+	}
+
+	if err := parsed.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("Expected error for truncated table")
+	}
+	if err := parsed.UnmarshalBinary(append(data, 0)); err == nil {
+		t.Error("Expected error for trailing data")
+	}
+	if _, err := (&ChunkTable{ChunkSize: 4096, FileSize: 10000}).MarshalBinary(); err == nil {
+		t.Error("Expected error for missing hashes")
+	}
+
+	empty := &ChunkTable{ChunkSize: 4096}
+	data, err = empty.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(empty) failed: %v", err)
+	}
+	if err := parsed.UnmarshalBinary(data); err != nil || len(parsed.Hashes) != 0 {
+		t.Errorf("Empty table round trip failed: %v", err)
+	}
+}
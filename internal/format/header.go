@@ -32,6 +32,112 @@ type FileHeader struct {
 	SHA256Hash  [32]byte // 文件内容校验和
 }
 
+// Flags 位定义.
+const (
+	// FlagCompressionMask 压缩算法占用 Flags 的低 2 位.
+	FlagCompressionMask byte = 0x03
+
+	// CompressionNone 未压缩.
+	CompressionNone byte = 0x00
+	// CompressionGzip 加密前使用 gzip 压缩.
+	CompressionGzip byte = 0x01
+	// CompressionZstd 加密前使用 zstd 压缩.
+	CompressionZstd byte = 0x02
+
+	// FlagIndexedArchive 文件为逐条目加密的索引存档（见 index.go）.
+	FlagIndexedArchive byte = 0x04
+
+	// FlagChunked 文件分块加密，支持随机访问（见 chunked.go）.
+	FlagChunked byte = 0x08
+
+	// FlagCompositeSignature 签名为 Ed25519 + Dilithium3 复合签名（见 zjcrypto/composite.go）.
+	FlagCompositeSignature byte = 0x10
+
+	// FlagFilenameBound 文件名作为 AES-GCM 附加认证数据，篡改文件名会导致解密失败（镜像模式使用）.
+	FlagFilenameBound byte = 0x20
+)
+
+// 签名类型.
+const (
+	// SignatureDilithium3 单一 Dilithium3 签名.
+	SignatureDilithium3 byte = 0x00
+	// SignatureComposite Ed25519 + Dilithium3 复合签名.
+	SignatureComposite byte = 0x01
+)
+
+// IsIndexedArchive 判断文件是否为索引存档.
+func (h *FileHeader) IsIndexedArchive() bool {
+	return h.Flags&FlagIndexedArchive != 0
+}
+
+// IsChunked 判断文件是否为分块加密.
+func (h *FileHeader) IsChunked() bool {
+	return h.Flags&FlagChunked != 0
+}
+
+// IsFilenameBound 判断文件名是否受 AES-GCM 认证.
+func (h *FileHeader) IsFilenameBound() bool {
+	return h.Flags&FlagFilenameBound != 0
+}
+
+// SignatureType 返回头部记录的签名类型.
+func (h *FileHeader) SignatureType() byte {
+	if h.Flags&FlagCompositeSignature != 0 {
+		return SignatureComposite
+	}
+	return SignatureDilithium3
+}
+
+// SetSignatureType 在 Flags 中记录签名类型，保留其他标志位.
+func (h *FileHeader) SetSignatureType(sigType byte) {
+	if sigType == SignatureComposite {
+		h.Flags |= FlagCompositeSignature
+	} else {
+		h.Flags &^= FlagCompositeSignature
+	}
+}
+
+// SignatureTypeName 返回签名类型名称.
+func SignatureTypeName(sigType byte) string {
+	switch sigType {
+	case SignatureDilithium3:
+		return "Dilithium3"
+	case SignatureComposite:
+		return "Ed25519+Dilithium3"
+	default:
+		return fmt.Sprintf("unknown(0x%02x)", sigType)
+	}
+}
+
+// Compression 返回头部记录的压缩算法.
+func (h *FileHeader) Compression() byte {
+	return h.Flags & FlagCompressionMask
+}
+
+// SetCompression 在 Flags 中记录压缩算法，保留其他标志位.
+func (h *FileHeader) SetCompression(codec byte) {
+	h.Flags = (h.Flags &^ FlagCompressionMask) | (codec & FlagCompressionMask)
+}
+
+// IsCompressionSupported 判断压缩算法是否受支持.
+func IsCompressionSupported(codec byte) bool {
+	return codec == CompressionNone || codec == CompressionGzip || codec == CompressionZstd
+}
+
+// CompressionName 返回压缩算法名称.
+func CompressionName(codec byte) string {
+	switch codec {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown(0x%02x)", codec)
+	}
+}
+
 // MarshalBinary 序列化为二进制（压缩格式）.
 //
 //nolint:funlen
@@ -343,6 +449,14 @@ func (h *FileHeader) Validate() error {
 		)
 	}
 
+	// 验证压缩标志
+	if !IsCompressionSupported(h.Compression()) {
+		return utils.NewCryptoError(
+			utils.ErrInvalidFormat,
+			fmt.Sprintf("Unsupported compression: 0x%02x", h.Compression()),
+		)
+	}
+
 	// 验证长度一致性
 	if int(h.FilenameLen) != len(h.Filename) {
 		return utils.NewCryptoError(
@@ -311,6 +311,46 @@ func TestValidateInvalidIVLength(t *testing.T) {
 	}
 }
 
+// TestCompressionFlags 测试压缩标志位的读写.
+func TestCompressionFlags(t *testing.T) {
+	header := NewFileHeader("a.txt", 1, nil, [32]byte{}, [12]byte{}, nil, [32]byte{})
+	if header.Compression() != CompressionNone {
+		t.Fatalf("新建头部默认应为未压缩，实际: 0x%02x", header.Compression())
+	}
+
+	header.Flags = 0x80
+	header.SetCompression(CompressionZstd)
+	if header.Compression() != CompressionZstd {
+		t.Errorf("期望 zstd，实际: %s", CompressionName(header.Compression()))
+	}
+	if header.Flags&0x80 == 0 {
+		t.Error("SetCompression 不应清除其他标志位")
+	}
+	if err := header.Validate(); err != nil {
+		t.Errorf("zstd 头部应通过验证: %v", err)
+	}
+
+	data, err := header.MarshalBinaryOptimized()
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	parsed, err := ParseFileHeaderFromBytes(data)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if parsed.Compression() != CompressionZstd {
+		t.Errorf("解析后压缩算法不一致: %s", CompressionName(parsed.Compression()))
+	}
+	if info := GetHeaderInfo(parsed); info.Compression != "zstd" {
+		t.Errorf("HeaderInfo 压缩算法错误: %s", info.Compression)
+	}
+
+	header.SetCompression(FlagCompressionMask)
+	if err := header.Validate(); err == nil {
+		t.Error("未知压缩算法应该返回错误")
+	}
+}
+
 // TestUnmarshalBinaryInvalidData 测试反序列化无效数据.
 func TestUnmarshalBinaryInvalidData(t *testing.T) {
 	tests := []struct {
@@ -589,3 +629,35 @@ func TestMarshalBinaryWithRealData(t *testing.T) {
 		t.Errorf("文件大小不匹配: %d vs %d", decoded.FileSize, header.FileSize)
 	}
 }
+
+// TestSignatureTypeFlags 测试签名类型标志位的读写.
+func TestSignatureTypeFlags(t *testing.T) {
+	header := NewFileHeader("a.txt", 1, nil, [32]byte{}, [12]byte{}, nil, [32]byte{})
+	if header.SignatureType() != SignatureDilithium3 {
+		t.Fatalf("新建头部默认应为 Dilithium3 签名，实际: %s", SignatureTypeName(header.SignatureType()))
+	}
+
+	header.SetCompression(CompressionGzip)
+	header.Flags |= FlagChunked
+	header.SetSignatureType(SignatureComposite)
+	if header.SignatureType() != SignatureComposite || header.Compression() != CompressionGzip || !header.IsChunked() {
+		t.Fatalf("SetSignatureType 不应影响其他标志位: 0x%02x", header.Flags)
+	}
+
+	data, err := header.MarshalBinaryOptimized()
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	parsed, err := ParseFileHeaderFromBytes(data)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if info := GetHeaderInfo(parsed); info.Signature != "Ed25519+Dilithium3" {
+		t.Errorf("HeaderInfo 签名类型错误: %s", info.Signature)
+	}
+
+	parsed.SetSignatureType(SignatureDilithium3)
+	if parsed.Flags&FlagCompositeSignature != 0 || parsed.Compression() != CompressionGzip {
+		t.Errorf("清除签名类型后 Flags = 0x%02x", parsed.Flags)
+	}
+}
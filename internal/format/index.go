@@ -0,0 +1,292 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// 索引存档布局（FileHeader.Flags 含 FlagIndexedArchive）:
+//
+//	FileHeader | 条目数据段 0 | 条目数据段 1 | ... | 加密索引 | IndexTrailer
+//
+// 每个条目数据段由若干加密块组成，每块明文最多 ChunkSize 字节，密文额外带 GCM 标签.
+// 头部 SHA256Hash 为索引明文的哈希，签名覆盖该哈希，从而间接覆盖所有条目的哈希.
+const (
+	// IndexVersion 索引格式版本.
+	IndexVersion uint16 = 0x0001
+	// DefaultIndexChunkSize 默认加密块大小（1MB）.
+	DefaultIndexChunkSize uint32 = 1024 * 1024
+	// MaxIndexChunkSize 加密块大小上限（64MB）.
+	MaxIndexChunkSize uint32 = 64 * 1024 * 1024
+	// IndexChunkOverhead 每个加密块的额外字节数（GCM 标签）.
+	IndexChunkOverhead = 16
+	// IndexTrailerSize 尾部固定长度.
+	IndexTrailerSize = 24
+
+	// IndexEntryFile 普通文件条目.
+	IndexEntryFile byte = 0x00
+	// IndexEntryDir 目录条目.
+	IndexEntryDir byte = 0x01
+)
+
+var (
+	indexMagic   = [4]byte{'F', 'Z', 'J', 'I'}
+	trailerMagic = [8]byte{'F', 'Z', 'J', 'T', 'R', 'L', 0x00, 0x01}
+)
+
+// IndexEntry 索引存档中的单个条目.
+type IndexEntry struct {
+	Type    byte        // IndexEntryFile / IndexEntryDir
+	Name    string      // 相对路径（使用 / 分隔）
+	Mode    os.FileMode // 权限位
+	ModTime int64       // 修改时间（Unix 秒）
+	Size    uint64      // 明文大小
+	Offset  uint64      // 数据段在存档中的起始偏移
+	SHA256  [32]byte    // 明文哈希
+}
+
+// IsDir 判断条目是否为目录.
+func (e *IndexEntry) IsDir() bool {
+	return e.Type == IndexEntryDir
+}
+
+// Modified 返回条目修改时间.
+func (e *IndexEntry) Modified() time.Time {
+	return time.Unix(e.ModTime, 0)
+}
+
+// ChunkCount 返回条目数据段的加密块数量.
+func (e *IndexEntry) ChunkCount(chunkSize uint32) uint64 {
+	if e.Size == 0 {
+		return 0
+	}
+	return (e.Size + uint64(chunkSize) - 1) / uint64(chunkSize)
+}
+
+// EncryptedSize 返回条目数据段的密文长度.
+func (e *IndexEntry) EncryptedSize(chunkSize uint32) uint64 {
+	return e.Size + e.ChunkCount(chunkSize)*IndexChunkOverhead
+}
+
+// ArchiveIndex 索引存档的条目索引（加密前的明文结构）.
+type ArchiveIndex struct {
+	ChunkSize uint32
+	Entries   []IndexEntry
+}
+
+// MarshalBinary 序列化索引.
+func (idx *ArchiveIndex) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(indexMagic[:])
+	_ = binary.Write(&buf, binary.BigEndian, IndexVersion)
+	_ = binary.Write(&buf, binary.BigEndian, idx.ChunkSize)
+	// #nosec G115 - 条目数量由调用方限制
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(idx.Entries)))
+
+	for i := range idx.Entries {
+		e := &idx.Entries[i]
+		if len(e.Name) == 0 || len(e.Name) > 0xFFFF {
+			return nil, utils.NewCryptoError(
+				utils.ErrSerializationFailed,
+				fmt.Sprintf("Invalid entry name length: %d", len(e.Name)),
+			)
+		}
+		buf.WriteByte(e.Type)
+		_ = binary.Write(&buf, binary.BigEndian, uint16(len(e.Name))) // #nosec G115 - 已检查长度
+		buf.WriteString(e.Name)
+		_ = binary.Write(&buf, binary.BigEndian, uint32(e.Mode.Perm()))
+		_ = binary.Write(&buf, binary.BigEndian, e.ModTime)
+		_ = binary.Write(&buf, binary.BigEndian, e.Size)
+		_ = binary.Write(&buf, binary.BigEndian, e.Offset)
+		buf.Write(e.SHA256[:])
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary 反序列化索引.
+//
+//nolint:funlen
+func (idx *ArchiveIndex) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	fail := func(what string, err error) error {
+		return utils.NewCryptoError(
+			utils.ErrInvalidFormat,
+			fmt.Sprintf("Failed to read index %s: %v", what, err),
+		)
+	}
+
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return fail("magic", err)
+	}
+	if magic != indexMagic {
+		return utils.NewCryptoError(utils.ErrInvalidMagic, "Invalid index magic")
+	}
+
+	var version uint16
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return fail("version", err)
+	}
+	if version != IndexVersion {
+		return utils.NewCryptoError(
+			utils.ErrInvalidVersion,
+			fmt.Sprintf("Unsupported index version: 0x%04x", version),
+		)
+	}
+
+	if err := binary.Read(r, binary.BigEndian, &idx.ChunkSize); err != nil {
+		return fail("chunk size", err)
+	}
+	if idx.ChunkSize == 0 || idx.ChunkSize > MaxIndexChunkSize {
+		return utils.NewCryptoError(
+			utils.ErrInvalidFormat,
+			fmt.Sprintf("Invalid chunk size: %d", idx.ChunkSize),
+		)
+	}
+
+	var count uint32
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return fail("entry count", err)
+	}
+	// 每个条目至少 64 字节，防止伪造的数量导致过量分配
+	if uint64(count)*64 > uint64(r.Len()) {
+		return utils.NewCryptoError(
+			utils.ErrInvalidFormat,
+			fmt.Sprintf("Index entry count too large: %d", count),
+		)
+	}
+
+	idx.Entries = make([]IndexEntry, count)
+	for i := range idx.Entries {
+		e := &idx.Entries[i]
+		var err error
+		if e.Type, err = r.ReadByte(); err != nil {
+			return fail("entry type", err)
+		}
+		if e.Type != IndexEntryFile && e.Type != IndexEntryDir {
+			return utils.NewCryptoError(
+				utils.ErrInvalidFormat,
+				fmt.Sprintf("Unknown entry type: 0x%02x", e.Type),
+			)
+		}
+		var nameLen uint16
+		if err := binary.Read(r, binary.BigEndian, &nameLen); err != nil {
+			return fail("name length", err)
+		}
+		name := make([]byte, nameLen)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return fail("name", err)
+		}
+		e.Name = string(name)
+		var mode uint32
+		if err := binary.Read(r, binary.BigEndian, &mode); err != nil {
+			return fail("mode", err)
+		}
+		e.Mode = os.FileMode(mode).Perm()
+		if err := binary.Read(r, binary.BigEndian, &e.ModTime); err != nil {
+			return fail("mod time", err)
+		}
+		if err := binary.Read(r, binary.BigEndian, &e.Size); err != nil {
+			return fail("size", err)
+		}
+		if err := binary.Read(r, binary.BigEndian, &e.Offset); err != nil {
+			return fail("offset", err)
+		}
+		if _, err := io.ReadFull(r, e.SHA256[:]); err != nil {
+			return fail("hash", err)
+		}
+		if e.IsDir() && e.Size != 0 {
+			return utils.NewCryptoError(
+				utils.ErrInvalidFormat,
+				"Directory entry with data: "+e.Name,
+			)
+		}
+	}
+
+	if r.Len() != 0 {
+		return utils.NewCryptoError(utils.ErrInvalidFormat, "Trailing data after index")
+	}
+	return nil
+}
+
+// ValidateLayout 检查条目名称安全、无重复，且数据段位于 [dataStart, dataEnd) 内互不重叠.
+func (idx *ArchiveIndex) ValidateLayout(dataStart, dataEnd uint64) error {
+	seen := make(map[string]struct{}, len(idx.Entries))
+	next := dataStart
+	for i := range idx.Entries {
+		e := &idx.Entries[i]
+		if !isSafeEntryName(e.Name) {
+			return utils.NewCryptoError(
+				utils.ErrInvalidParameter,
+				"Invalid entry name in index: "+e.Name,
+			)
+		}
+		if _, dup := seen[e.Name]; dup {
+			return utils.NewCryptoError(
+				utils.ErrInvalidFormat,
+				"Duplicate entry in index: "+e.Name,
+			)
+		}
+		seen[e.Name] = struct{}{}
+
+		if e.IsDir() {
+			continue
+		}
+		// 数据段按写入顺序排列，校验时同时检测溢出和重叠
+		encSize := e.EncryptedSize(idx.ChunkSize)
+		if e.Size > dataEnd || e.Offset < next || e.Offset > dataEnd || encSize > dataEnd-e.Offset {
+			return utils.NewCryptoError(
+				utils.ErrInvalidFormat,
+				"Entry data out of range: "+e.Name,
+			)
+		}
+		next = e.Offset + encSize
+	}
+	return nil
+}
+
+// isSafeEntryName 检查条目名称为不含 ".." 的相对路径.
+func isSafeEntryName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// IndexTrailer 存档末尾的定位信息.
+type IndexTrailer struct {
+	IndexOffset uint64 // 加密索引的起始偏移
+	IndexLen    uint64 // 加密索引的长度
+}
+
+// MarshalBinary 序列化尾部.
+func (t *IndexTrailer) MarshalBinary() ([]byte, error) {
+	data := make([]byte, IndexTrailerSize)
+	binary.BigEndian.PutUint64(data[0:8], t.IndexOffset)
+	binary.BigEndian.PutUint64(data[8:16], t.IndexLen)
+	copy(data[16:], trailerMagic[:])
+	return data, nil
+}
+
+// UnmarshalBinary 反序列化尾部.
+func (t *IndexTrailer) UnmarshalBinary(data []byte) error {
+	if len(data) != IndexTrailerSize || !bytes.Equal(data[16:], trailerMagic[:]) {
+		return utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid index trailer")
+	}
+	t.IndexOffset = binary.BigEndian.Uint64(data[0:8])
+	t.IndexLen = binary.BigEndian.Uint64(data[8:16])
+	return nil
+}
@@ -0,0 +1,93 @@
+package format
+
+import (
+	"testing"
+)
+
+func TestArchiveIndexSerialization(t *testing.T) {
+	idx := &ArchiveIndex{
+		ChunkSize: 4096,
+		Entries: []IndexEntry{
+			{Type: IndexEntryDir, Name: "docs", Mode: 0750, ModTime: 1700000000},
+			{Type: IndexEntryFile, Name: "docs/a.txt", Mode: 0640, ModTime: 1700000001, Size: 10000, Offset: 100},
+			{Type: IndexEntryFile, Name: "empty", Mode: 0600, Offset: 100 + 10000 + 3*IndexChunkOverhead},
+		},
+	}
+	idx.Entries[1].SHA256[0] = 0xAB
+
+	data, err := idx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	var parsed ArchiveIndex
+	if err := parsed.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if parsed.ChunkSize != idx.ChunkSize || len(parsed.Entries) != len(idx.Entries) {
+		t.Fatalf("Index mismatch: %+v", parsed)
+	}
+	for i := range idx.Entries {
+		if parsed.Entries[i] != idx.Entries[i] {
+			t.Errorf("Entry %d mismatch: %+v != %+v", i, parsed.Entries[i], idx.Entries[i])
+		}
+	}
+	if got := parsed.Entries[1].EncryptedSize(4096); got != 10000+3*IndexChunkOverhead {
+		t.Errorf("EncryptedSize = %d", got)
+	}
+	if err := parsed.ValidateLayout(100, 100+10000+3*IndexChunkOverhead); err != nil {
+		t.Errorf("ValidateLayout failed: %v", err)
+	}
+
+	// 截断和尾部多余数据都应被拒绝
+	if err := parsed.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("Expected error for truncated index")
+	}
+	if err := parsed.UnmarshalBinary(append(data, 0)); err == nil {
+		t.Error("Expected error for trailing data")
+	}
+}
+
+func TestArchiveIndexValidateLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []IndexEntry
+	}{
+		{"path traversal", []IndexEntry{{Name: "../etc/passwd"}}},
+		{"absolute path", []IndexEntry{{Name: "/etc/passwd"}}},
+		{"duplicate", []IndexEntry{{Name: "a", Offset: 10}, {Name: "a", Offset: 10}}},
+		{"out of range", []IndexEntry{{Name: "a", Size: 1000, Offset: 10}}},
+		{"overlap", []IndexEntry{{Name: "a", Size: 10, Offset: 10}, {Name: "b", Size: 10, Offset: 20}}},
+		{"before data start", []IndexEntry{{Name: "a", Size: 1, Offset: 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := &ArchiveIndex{ChunkSize: 4096, Entries: tt.entries}
+			if err := idx.ValidateLayout(10, 200); err == nil {
+				t.Error("Expected layout validation error")
+			}
+		})
+	}
+}
+
+func TestIndexTrailer(t *testing.T) {
+	trailer := IndexTrailer{IndexOffset: 1234, IndexLen: 56}
+	data, _ := trailer.MarshalBinary()
+	if len(data) != IndexTrailerSize {
+		t.Fatalf("Trailer size = %d", len(data))
+	}
+
+	var parsed IndexTrailer
+	if err := parsed.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if parsed != trailer {
+		t.Errorf("Trailer mismatch: %+v", parsed)
+	}
+
+	data[len(data)-1] ^= 0xFF
+	if err := parsed.UnmarshalBinary(data); err == nil {
+		t.Error("Expected error for bad trailer magic")
+	}
+}
@@ -0,0 +1,152 @@
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// 签名清单格式（文本，便于人工查看）:
+//
+//	fzjjyz-manifest v1
+//	<sha256 十六进制|-> <大小> <权限八进制> <带引号的路径>
+//	...
+//	-----BEGIN FZJJYZ MANIFEST SIGNATURE-----
+//	<Dilithium 签名，base64>
+//	-----END FZJJYZ MANIFEST SIGNATURE-----
+//
+// 目录条目的哈希列为 "-". 签名覆盖签名块之前的全部字节.
+const (
+	// ManifestName 清单在目录存档及解压目录中的文件名.
+	ManifestName = ".fzjjyz-manifest"
+	// ManifestSignatureType 签名 PEM 块类型.
+	ManifestSignatureType = "FZJJYZ MANIFEST SIGNATURE"
+
+	manifestHeader = "fzjjyz-manifest v1"
+)
+
+// ManifestEntry 清单中的单个条目.
+type ManifestEntry struct {
+	Name   string      // 相对路径（使用 / 分隔）
+	IsDir  bool        // 是否为目录
+	Size   uint64      // 文件大小
+	Mode   os.FileMode // 权限位
+	SHA256 [32]byte    // 文件内容哈希
+}
+
+// Manifest 目录存档的文件清单.
+type Manifest struct {
+	Entries []ManifestEntry
+}
+
+// MarshalText 序列化清单正文（不含签名）.
+func (m *Manifest) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(manifestHeader + "\n")
+	for i := range m.Entries {
+		e := &m.Entries[i]
+		if !isSafeEntryName(e.Name) {
+			return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Invalid manifest entry name: "+e.Name)
+		}
+		hash := "-"
+		if !e.IsDir {
+			hash = hex.EncodeToString(e.SHA256[:])
+		}
+		fmt.Fprintf(&buf, "%s %d %04o %s\n", hash, e.Size, uint32(e.Mode.Perm()), strconv.Quote(e.Name))
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalText 解析清单正文.
+func (m *Manifest) UnmarshalText(data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	if !scanner.Scan() || scanner.Text() != manifestHeader {
+		return utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid manifest header")
+	}
+
+	m.Entries = nil
+	seen := make(map[string]bool)
+	for line := 2; scanner.Scan(); line++ {
+		entry, err := parseManifestLine(scanner.Text())
+		if err != nil {
+			return utils.NewCryptoError(utils.ErrInvalidFormat,
+				fmt.Sprintf("Invalid manifest line %d: %v", line, err))
+		}
+		if seen[entry.Name] {
+			return utils.NewCryptoError(utils.ErrInvalidFormat, "Duplicate manifest entry: "+entry.Name)
+		}
+		seen[entry.Name] = true
+		m.Entries = append(m.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid manifest: "+err.Error())
+	}
+	return nil
+}
+
+// parseManifestLine 解析一行清单条目.
+func parseManifestLine(line string) (ManifestEntry, error) {
+	var entry ManifestEntry
+	fields := strings.SplitN(line, " ", 4)
+	if len(fields) != 4 {
+		return entry, fmt.Errorf("expected 4 fields, got %d", len(fields))
+	}
+
+	if fields[0] == "-" {
+		entry.IsDir = true
+	} else {
+		hash, err := hex.DecodeString(fields[0])
+		if err != nil || len(hash) != len(entry.SHA256) {
+			return entry, fmt.Errorf("invalid hash %q", fields[0])
+		}
+		copy(entry.SHA256[:], hash)
+	}
+
+	size, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return entry, fmt.Errorf("invalid size: %w", err)
+	}
+	entry.Size = size
+
+	mode, err := strconv.ParseUint(fields[2], 8, 32)
+	if err != nil {
+		return entry, fmt.Errorf("invalid mode: %w", err)
+	}
+	entry.Mode = os.FileMode(mode).Perm()
+
+	name, err := strconv.Unquote(fields[3])
+	if err != nil || !isSafeEntryName(name) {
+		return entry, fmt.Errorf("invalid name %s", fields[3])
+	}
+	entry.Name = name
+	return entry, nil
+}
+
+// EncodeSignedManifest 将清单正文与签名组合为清单文件内容.
+func EncodeSignedManifest(body, signature []byte) []byte {
+	out := make([]byte, 0, len(body)+len(signature)*2)
+	out = append(out, body...)
+	return append(out, pem.EncodeToMemory(&pem.Block{Type: ManifestSignatureType, Bytes: signature})...)
+}
+
+// DecodeSignedManifest 拆分清单文件内容，返回被签名的正文和签名.
+func DecodeSignedManifest(data []byte) (body, signature []byte, err error) {
+	marker := []byte("-----BEGIN " + ManifestSignatureType + "-----")
+	idx := bytes.LastIndex(data, marker)
+	if idx < 0 {
+		return nil, nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Manifest signature not found")
+	}
+	block, rest := pem.Decode(data[idx:])
+	if block == nil || block.Type != ManifestSignatureType || len(bytes.TrimSpace(rest)) != 0 {
+		return nil, nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid manifest signature block")
+	}
+	return data[:idx], block.Bytes, nil
+}
@@ -0,0 +1,70 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestManifestSerialization(t *testing.T) {
+	m := &Manifest{
+		Entries: []ManifestEntry{
+			{Name: "docs", IsDir: true, Mode: 0750},
+			{Name: "docs/a b.txt", Size: 11, Mode: 0640},
+			{Name: "odd \"name\"\n.txt", Size: 0, Mode: 0600},
+		},
+	}
+	m.Entries[1].SHA256[0] = 0xAB
+
+	body, err := m.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText failed: %v", err)
+	}
+
+	var parsed Manifest
+	if err := parsed.UnmarshalText(body); err != nil {
+		t.Fatalf("UnmarshalText failed: %v", err)
+	}
+	if len(parsed.Entries) != len(m.Entries) {
+		t.Fatalf("Entry count mismatch: %d", len(parsed.Entries))
+	}
+	for i := range m.Entries {
+		if parsed.Entries[i] != m.Entries[i] {
+			t.Errorf("Entry %d mismatch: %+v != %+v", i, parsed.Entries[i], m.Entries[i])
+		}
+	}
+
+	signed := EncodeSignedManifest(body, []byte("signature"))
+	gotBody, sig, err := DecodeSignedManifest(signed)
+	if err != nil {
+		t.Fatalf("DecodeSignedManifest failed: %v", err)
+	}
+	if !bytes.Equal(gotBody, body) || string(sig) != "signature" {
+		t.Error("Signed manifest round trip mismatch")
+	}
+	if _, _, err := DecodeSignedManifest(body); err == nil {
+		t.Error("Expected error for missing signature")
+	}
+	if _, _, err := DecodeSignedManifest(append(signed, "extra"...)); err == nil {
+		t.Error("Expected error for trailing data")
+	}
+}
+
+func TestManifestRejectsInvalidEntries(t *testing.T) {
+	if _, err := (&Manifest{Entries: []ManifestEntry{{Name: "../escape"}}}).MarshalText(); err == nil {
+		t.Error("Expected error for unsafe name")
+	}
+
+	cases := []string{
+		"not a manifest\n",
+		manifestHeader + "\nzz 1 0600 \"a\"\n",
+		manifestHeader + "\n- 0 0750 \"../x\"\n",
+		manifestHeader + "\n- 0 0750 \"a\"\n- 0 0750 \"a\"\n",
+		manifestHeader + "\n- 0 0750\n",
+	}
+	for _, input := range cases {
+		var m Manifest
+		if err := m.UnmarshalText([]byte(input)); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
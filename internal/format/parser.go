@@ -301,14 +301,16 @@ func IsValidEncryptedFile(data []byte) bool {
 
 // HeaderInfo contains basic file header information for quick preview.
 type HeaderInfo struct {
-	Filename  string
-	FileSize  uint64
-	Timestamp uint32
-	Algorithm string
-	HasKyber  bool
-	HasECDH   bool
-	HasIV     bool
-	HasSig    bool
+	Filename    string
+	FileSize    uint64
+	Timestamp   uint32
+	Algorithm   string
+	Compression string
+	Signature   string
+	HasKyber    bool
+	HasECDH     bool
+	HasIV       bool
+	HasSig      bool
 }
 
 // GetHeaderInfo extracts basic information from a file header for quick preview.
@@ -319,13 +321,15 @@ func GetHeaderInfo(header *FileHeader) *HeaderInfo {
 	}
 
 	return &HeaderInfo{
-		Filename:  header.Filename,
-		FileSize:  header.FileSize,
-		Timestamp: header.Timestamp,
-		Algorithm: algo,
-		HasKyber:  header.KyberEncLen > 0,
-		HasECDH:   header.ECDHLen > 0,
-		HasIV:     header.IVLen > 0,
-		HasSig:    header.SigLen > 0,
+		Filename:    header.Filename,
+		FileSize:    header.FileSize,
+		Timestamp:   header.Timestamp,
+		Algorithm:   algo,
+		Compression: CompressionName(header.Compression()),
+		Signature:   SignatureTypeName(header.SignatureType()),
+		HasKyber:    header.KyberEncLen > 0,
+		HasECDH:     header.ECDHLen > 0,
+		HasIV:       header.IVLen > 0,
+		HasSig:      header.SigLen > 0,
 	}
 }
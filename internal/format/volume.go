@@ -0,0 +1,181 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// 分卷布局：加密文件按固定大小切分为 name.fzj.001、name.fzj.002 ...，每个分卷为
+//
+//	VolumeHeader | 载荷（原加密文件的一段连续字节）
+//
+// 分卷头记录分卷集 ID、序号、总数、原文件大小和载荷哈希，并由 Dilithium 签名，
+// 从而可以发现缺失、乱序或混入其他分卷集的分卷.
+//
+// 版本 2 起签名可以是 Dilithium3 签名或 Ed25519 + Dilithium3 复合签名，长度由签名长度字段区分；
+// 版本 1 只有 Dilithium3 签名，仍可读取.
+const (
+	// VolumeVersion 分卷格式版本.
+	VolumeVersion uint16 = 0x0002
+	// VolumeVersionV1 只支持 Dilithium3 签名的旧版本.
+	VolumeVersionV1 uint16 = 0x0001
+	// VolumeSetIDSize 分卷集 ID 长度.
+	VolumeSetIDSize = 16
+	// VolumeSignatureSize 分卷头签名长度（Dilithium3）.
+	VolumeSignatureSize = 3293
+	// VolumeCompositeSignatureSize 分卷头复合签名长度（Ed25519 + Dilithium3）.
+	VolumeCompositeSignatureSize = 64 + VolumeSignatureSize
+	// volumeSignedSize 签名覆盖的固定字段长度.
+	volumeSignedSize = 4 + 2 + VolumeSetIDSize + 4 + 4 + 8 + 8 + 32
+	// VolumeHeaderPrefixSize 分卷头中签名之前的部分（含签名长度字段）的长度.
+	VolumeHeaderPrefixSize = volumeSignedSize + 2
+	// VolumeHeaderSize 使用 Dilithium3 签名的分卷头长度.
+	VolumeHeaderSize = VolumeHeaderPrefixSize + VolumeSignatureSize
+	// MaxVolumeCount 分卷数量上限.
+	MaxVolumeCount = 99999
+)
+
+var volumeMagic = [4]byte{'F', 'Z', 'J', 'V'}
+
+// VolumeHeader 分卷头.
+type VolumeHeader struct {
+	Version     uint16                // 格式版本，为 0 时按 VolumeVersion 写入
+	SetID       [VolumeSetIDSize]byte // 分卷集 ID（同一次切分的所有分卷相同）
+	Index       uint32                // 分卷序号，从 1 开始
+	Count       uint32                // 分卷总数
+	TotalSize   uint64                // 原加密文件大小
+	PayloadSize uint64                // 本分卷载荷大小
+	PayloadHash [32]byte              // 本分卷载荷的 SHA-256
+	Signature   []byte                // 对 SignedBytes 的签名
+}
+
+// VolumeHeaderSizeFor 返回签名长度为 sigLen 的分卷头长度.
+func VolumeHeaderSizeFor(sigLen int) int {
+	return VolumeHeaderPrefixSize + sigLen
+}
+
+// version 返回分卷头的格式版本.
+func (h *VolumeHeader) version() uint16 {
+	if h.Version == 0 {
+		return VolumeVersion
+	}
+	return h.Version
+}
+
+// Size 返回序列化后的分卷头长度.
+func (h *VolumeHeader) Size() int {
+	return VolumeHeaderSizeFor(len(h.Signature))
+}
+
+// validVolumeSignatureSize 检查 version 版本的分卷头是否允许 sigLen 长度的签名.
+func validVolumeSignatureSize(version uint16, sigLen int) bool {
+	switch version {
+	case VolumeVersionV1:
+		return sigLen == VolumeSignatureSize
+	case VolumeVersion:
+		return sigLen == VolumeSignatureSize || sigLen == VolumeCompositeSignatureSize
+	default:
+		return false
+	}
+}
+
+// SignedBytes 返回签名覆盖的字段.
+func (h *VolumeHeader) SignedBytes() []byte {
+	data := make([]byte, 0, volumeSignedSize)
+	data = append(data, volumeMagic[:]...)
+	data = binary.BigEndian.AppendUint16(data, h.version())
+	data = append(data, h.SetID[:]...)
+	data = binary.BigEndian.AppendUint32(data, h.Index)
+	data = binary.BigEndian.AppendUint32(data, h.Count)
+	data = binary.BigEndian.AppendUint64(data, h.TotalSize)
+	data = binary.BigEndian.AppendUint64(data, h.PayloadSize)
+	return append(data, h.PayloadHash[:]...)
+}
+
+// MarshalBinary 序列化分卷头.
+func (h *VolumeHeader) MarshalBinary() ([]byte, error) {
+	if !validVolumeSignatureSize(h.version(), len(h.Signature)) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid volume signature length")
+	}
+	data := h.SignedBytes()
+	data = binary.BigEndian.AppendUint16(data, uint16(len(h.Signature))) // #nosec G115 - 已检查长度
+	return append(data, h.Signature...), nil
+}
+
+// VolumeHeaderSizeFromPrefix 由分卷头前 VolumeHeaderPrefixSize 字节得到完整分卷头的长度.
+func VolumeHeaderSizeFromPrefix(prefix []byte) (int, error) {
+	if len(prefix) < VolumeHeaderPrefixSize || !IsVolumeHeader(prefix) {
+		return 0, utils.NewCryptoError(utils.ErrInvalidMagic, "Not a volume header")
+	}
+	version := binary.BigEndian.Uint16(prefix[4:6])
+	if version != VolumeVersion && version != VolumeVersionV1 {
+		return 0, utils.NewCryptoError(utils.ErrInvalidVersion, fmt.Sprintf("Unsupported volume version: 0x%04x", version))
+	}
+	sigLen := int(binary.BigEndian.Uint16(prefix[volumeSignedSize:]))
+	if !validVolumeSignatureSize(version, sigLen) {
+		return 0, utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid volume signature length")
+	}
+	return VolumeHeaderSizeFor(sigLen), nil
+}
+
+// UnmarshalBinary 反序列化分卷头并检查字段一致性.
+func (h *VolumeHeader) UnmarshalBinary(data []byte) error {
+	size, err := VolumeHeaderSizeFromPrefix(data)
+	if err != nil {
+		return err
+	}
+	if len(data) != size {
+		return utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid volume header length")
+	}
+	h.Version = binary.BigEndian.Uint16(data[4:6])
+	off := 6
+	copy(h.SetID[:], data[off:off+VolumeSetIDSize])
+	off += VolumeSetIDSize
+	h.Index = binary.BigEndian.Uint32(data[off:])
+	h.Count = binary.BigEndian.Uint32(data[off+4:])
+	h.TotalSize = binary.BigEndian.Uint64(data[off+8:])
+	h.PayloadSize = binary.BigEndian.Uint64(data[off+16:])
+	off += 24
+	copy(h.PayloadHash[:], data[off:off+32])
+	off += 32
+	h.Signature = bytes.Clone(data[off+2:])
+
+	if h.Count == 0 || h.Count > MaxVolumeCount || h.Index == 0 || h.Index > h.Count {
+		return utils.NewCryptoError(
+			utils.ErrInvalidFormat,
+			fmt.Sprintf("Invalid volume number %d of %d", h.Index, h.Count),
+		)
+	}
+	if h.PayloadSize > h.TotalSize {
+		return utils.NewCryptoError(utils.ErrInvalidFormat, "Volume payload larger than total size")
+	}
+	return nil
+}
+
+// IsVolumeHeader 判断数据是否以分卷头魔数开头.
+func IsVolumeHeader(data []byte) bool {
+	return len(data) >= len(volumeMagic) && bytes.Equal(data[:len(volumeMagic)], volumeMagic[:])
+}
+
+// VolumeName 返回第 index 个分卷的文件名（base.001、base.002 ...）.
+func VolumeName(base string, index int) string {
+	return fmt.Sprintf("%s.%03d", base, index)
+}
+
+// VolumeBase 从分卷文件名中去掉序号后缀，返回基础名和序号.
+func VolumeBase(name string) (string, int, bool) {
+	dot := strings.LastIndexByte(name, '.')
+	if dot <= 0 || len(name)-dot-1 < 3 {
+		return "", 0, false
+	}
+	index, err := strconv.Atoi(name[dot+1:])
+	if err != nil || index <= 0 || VolumeName(name[:dot], index) != name {
+		return "", 0, false
+	}
+	return name[:dot], index, true
+}
@@ -0,0 +1,140 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVolumeHeaderSerialization(t *testing.T) {
+	h := &VolumeHeader{
+		Index:       2,
+		Count:       3,
+		TotalSize:   1 << 20,
+		PayloadSize: 4096,
+		Signature:   bytes.Repeat([]byte{0x5A}, VolumeSignatureSize),
+	}
+	h.SetID[0] = 0x01
+	h.PayloadHash[31] = 0xFF
+
+	data, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	if len(data) != VolumeHeaderSize {
+		t.Fatalf("Header size = %d, want %d", len(data), VolumeHeaderSize)
+	}
+	if !IsVolumeHeader(data) {
+		t.Fatal("IsVolumeHeader returned false")
+	}
+
+	var parsed VolumeHeader
+	if err := parsed.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if !bytes.Equal(parsed.SignedBytes(), h.SignedBytes()) || !bytes.Equal(parsed.Signature, h.Signature) {
+		t.Error("Volume header round trip mismatch")
+	}
+
+	if _, err := (&VolumeHeader{Index: 1, Count: 1}).MarshalBinary(); err == nil {
+		t.Error("Expected error for missing signature")
+	}
+}
+
+func TestVolumeHeaderSignatureSizes(t *testing.T) {
+	tests := []struct {
+		version uint16
+		sigLen  int
+		ok      bool
+	}{
+		{VolumeVersion, VolumeSignatureSize, true},
+		{VolumeVersion, VolumeCompositeSignatureSize, true},
+		{VolumeVersion, VolumeSignatureSize + 1, false},
+		{VolumeVersionV1, VolumeSignatureSize, true},
+		{VolumeVersionV1, VolumeCompositeSignatureSize, false},
+	}
+	for _, tt := range tests {
+		h := &VolumeHeader{Version: tt.version, Index: 1, Count: 1, Signature: make([]byte, tt.sigLen)}
+		data, err := h.MarshalBinary()
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("version %d, signature %d: expected error", tt.version, tt.sigLen)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("version %d, signature %d: %v", tt.version, tt.sigLen, err)
+		}
+		if len(data) != h.Size() || len(data) != VolumeHeaderSizeFor(tt.sigLen) {
+			t.Errorf("version %d: header size = %d, want %d", tt.version, len(data), h.Size())
+		}
+		size, err := VolumeHeaderSizeFromPrefix(data[:VolumeHeaderPrefixSize])
+		if err != nil || size != len(data) {
+			t.Errorf("VolumeHeaderSizeFromPrefix = %d, %v; want %d", size, err, len(data))
+		}
+		var parsed VolumeHeader
+		if err := parsed.UnmarshalBinary(data); err != nil || parsed.Version != tt.version {
+			t.Errorf("version %d round trip: %d, %v", tt.version, parsed.Version, err)
+		}
+		if !bytes.Equal(parsed.SignedBytes(), h.SignedBytes()) {
+			t.Errorf("version %d: signed bytes mismatch", tt.version)
+		}
+	}
+}
+
+func TestVolumeHeaderRejectsInvalidNumbers(t *testing.T) {
+	cases := []struct{ index, count uint32 }{
+		{0, 1},
+		{2, 1},
+		{1, 0},
+		{1, MaxVolumeCount + 1},
+	}
+	for _, c := range cases {
+		h := &VolumeHeader{Index: c.index, Count: c.count, Signature: make([]byte, VolumeSignatureSize)}
+		data, err := h.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := new(VolumeHeader).UnmarshalBinary(data); err == nil {
+			t.Errorf("Expected error for volume %d of %d", c.index, c.count)
+		}
+	}
+
+	h := &VolumeHeader{Index: 1, Count: 1, TotalSize: 1, PayloadSize: 2, Signature: make([]byte, VolumeSignatureSize)}
+	data, _ := h.MarshalBinary()
+	if err := new(VolumeHeader).UnmarshalBinary(data); err == nil {
+		t.Error("Expected error for payload larger than total")
+	}
+	if err := new(VolumeHeader).UnmarshalBinary(data[:100]); err == nil {
+		t.Error("Expected error for truncated header")
+	}
+}
+
+func TestVolumeName(t *testing.T) {
+	if got := VolumeName("a.fzj", 7); got != "a.fzj.007" {
+		t.Errorf("VolumeName = %q", got)
+	}
+	if got := VolumeName("a.fzj", 1234); got != "a.fzj.1234" {
+		t.Errorf("VolumeName = %q", got)
+	}
+
+	tests := []struct {
+		name  string
+		base  string
+		index int
+		ok    bool
+	}{
+		{"dir/a.fzj.001", "dir/a.fzj", 1, true},
+		{"a.fzj.1234", "a.fzj", 1234, true},
+		{"a.fzj.01", "", 0, false},
+		{"a.fzj.0001", "", 0, false},
+		{"a.fzj.000", "", 0, false},
+		{"a.fzj", "", 0, false},
+		{".001", "", 0, false},
+	}
+	for _, tt := range tests {
+		base, index, ok := VolumeBase(tt.name)
+		if base != tt.base || index != tt.index || ok != tt.ok {
+			t.Errorf("VolumeBase(%q) = %q, %d, %v", tt.name, base, index, ok)
+		}
+	}
+}
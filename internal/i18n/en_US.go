@@ -58,19 +58,41 @@ Encryption process:
 
 Required parameters:
   --input, -i         Input file path
-  --public-key, -p    Kyber+ECDH public key file
+  --public-key, -p    Kyber+ECDH public key file (or --recipient, -r fzj1...)
   --sign-key, -s      Dilithium private key file
 
 Examples:
   fzj encrypt -i plaintext.txt -o encrypted.fzj -p public.pem -s dilithium_private.pem
-  fzj encrypt --input data.txt --public-key pub.pem --sign-key priv.pem --force`,
-	"encrypt.flags.input":       "Input file path (required)",
-	"encrypt.flags.output":      "Output file path (optional, default: input.fzj)",
-	"encrypt.flags.public-key":  "Kyber+ECDH public key file (required)",
-	"encrypt.flags.sign-key":    "Dilithium private key file (required)",
-	"encrypt.flags.force":       "Overwrite output file",
-	"encrypt.flags.buffer-size": "Buffer size (KB), 0=auto",
-	"encrypt.flags.streaming":   "Use streaming mode (recommended for large files)",
+  fzj encrypt --input data.txt --public-key pub.pem --sign-key priv.pem --force
+  fzj encrypt -i plaintext.txt -r fzj1qx... -s dilithium_private.pem
+  fzj encrypt -i app.log -p public.pem -s dilithium_private.pem --compress zstd --compress-level 19
+  fzj encrypt -p public.pem -s dilithium_private.pem -o out/ --jobs 4 'logs/*.log' report.pdf
+
+Batch mode:
+  Multiple inputs or globs are processed concurrently with keys loaded once; -o is the output directory.
+  Exits with a non-zero status if any file fails.
+
+Remote storage:
+  -o s3://bucket/key?endpoint=http://127.0.0.1:9000  credentials from AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY
+  -o sftp://user@host/path/file.fzj                  host key checked against ~/.ssh/known_hosts,
+                                                     auth via FZJJYZ_SFTP_PASSWORD, FZJJYZ_SFTP_KEY or ssh-agent
+
+Volumes:
+  --volume-size 4G writes out.fzj.001, out.fzj.002 ... with signed volume index and count.
+  Pass the first volume to decrypt / decrypt-dir; missing, reordered or foreign volumes are rejected.`,
+	"encrypt.flags.input":          "Input file path, repeatable, globs allowed (required)",
+	"encrypt.flags.output":         "Output file path or storage URL (s3://, sftp://) (optional, default: input.fzj)",
+	"encrypt.flags.public-key":     "Kyber+ECDH public key file, identity certificate or fzj1... recipient string (this or --recipient required)",
+	"encrypt.flags.recipient":      "Recipient: fzj1... string or public key file, same as --public-key",
+	"encrypt.flags.sign-key":       "Dilithium private key file or URI (required)",
+	"encrypt.flags.force":          "Overwrite output file",
+	"encrypt.flags.buffer-size":    "Buffer size (KB), 0=auto",
+	"encrypt.flags.streaming":      "Use streaming mode (recommended for large files)",
+	"encrypt.flags.compress":       "Compress before encryption: none/gzip/zstd",
+	"encrypt.flags.compress-level": "Compression level, 0=codec default (gzip 1-9, zstd 1-22)",
+	"encrypt.flags.jobs":           "Parallel workers in batch mode, 0=number of CPUs",
+	"encrypt.flags.volume-size":    "Split output into volumes of at most this size (e.g. 4G), named <output>.001, .002 ...",
+	"encrypt.flags.random-access":  "Encrypt in independently authenticated chunks so byte ranges can be read without decrypting everything (no compression)",
 
 	// decrypt 命令
 	"decrypt.short": "Decrypt file",
@@ -92,14 +114,20 @@ Required parameters:
 
 Examples:
   fzj decrypt -i encrypted.fzj -o decrypted.txt -p private.pem -s dilithium_public.pem
-  fzj decrypt --input data.fzj --private-key priv.pem --verify-key pub.pem --force`,
-	"decrypt.flags.input":       "Encrypted file path (required)",
+  fzj decrypt --input data.fzj --private-key priv.pem --verify-key pub.pem --force
+  fzj decrypt -p private.pem -s dilithium_public.pem -o restored/ 'out/*.fzj'
+
+Batch mode:
+  Multiple inputs or globs are processed concurrently; output names come from each file header and -o is the output directory.
+  Exits with a non-zero status if any file fails.`,
+	"decrypt.flags.input":       "Encrypted file path or storage URL (s3://, sftp://), repeatable, globs allowed (required)",
 	"decrypt.flags.output":      "Output file path (optional, default: original filename)",
-	"decrypt.flags.private-key": "Kyber+ECDH private key file (required)",
-	"decrypt.flags.verify-key":  "Dilithium public key file (optional)",
+	"decrypt.flags.private-key": "Kyber+ECDH private key file or URI (required)",
+	"decrypt.flags.verify-key":  "Dilithium public key or identity certificate file (optional)",
 	"decrypt.flags.force":       "Overwrite output file",
 	"decrypt.flags.buffer-size": "Buffer size (KB), 0=auto",
 	"decrypt.flags.streaming":   "Use streaming mode (recommended for large files)",
+	"decrypt.flags.jobs":        "Parallel workers in batch mode, 0=number of CPUs",
 
 	// encrypt-dir 命令
 	"encrypt-dir.short": "Encrypt directory",
@@ -123,14 +151,21 @@ Required parameters:
 
 Examples:
   fzj encrypt-dir -i ./sensitive_data -o secure.fzj -p public.pem -s dilithium_private.pem
-  fzj encrypt-dir --input ./confidential --output backup.fzj --public-key pub.pem --sign-key priv.pem --force`,
-	"encrypt-dir.flags.input":       "Source directory path (required)",
-	"encrypt-dir.flags.output":      "Output encrypted file path (required)",
-	"encrypt-dir.flags.public-key":  "Kyber+ECDH public key file (required)",
-	"encrypt-dir.flags.sign-key":    "Dilithium private key file (required)",
-	"encrypt-dir.flags.force":       "Overwrite output file",
-	"encrypt-dir.flags.buffer-size": "Buffer size (KB), 0=auto",
-	"encrypt-dir.flags.streaming":   "Use streaming mode",
+  fzj encrypt-dir --input ./confidential --output backup.fzj --public-key pub.pem --sign-key priv.pem --force
+  fzj encrypt-dir -i ./photos -o photos.fzj -p pub.pem -s priv.pem --indexed
+  fzj encrypt-dir -i ./photos -o ./photos.enc -p pub.pem -s priv.pem --mirror --encrypt-names`,
+	"encrypt-dir.flags.input":         "Source directory path (required)",
+	"encrypt-dir.flags.output":        "Output encrypted file path or storage URL (s3://, sftp://) (required)",
+	"encrypt-dir.flags.public-key":    "Kyber+ECDH public key file (required)",
+	"encrypt-dir.flags.sign-key":      "Dilithium private key file or URI (required)",
+	"encrypt-dir.flags.force":         "Overwrite output file",
+	"encrypt-dir.flags.buffer-size":   "Buffer size (KB), 0=auto",
+	"encrypt-dir.flags.streaming":     "Use streaming mode",
+	"encrypt-dir.flags.indexed":       "Use indexed per-entry archive format (random access, per-file integrity)",
+	"encrypt-dir.flags.mirror":        "Encrypt each file to its own .fzj under the output directory (incremental)",
+	"encrypt-dir.flags.encrypt-names": "Encrypt file and directory names deterministically (mirror mode)",
+	"encrypt-dir.flags.state":         "Mirror state file path, holds the name key; must be outside the output directory (default: under the user config directory)",
+	"encrypt-dir.flags.volume-size":   "Split the archive into volumes of at most this size (e.g. 4G)",
 
 	// decrypt-dir 命令
 	"decrypt-dir.short": "Decrypt directory",
@@ -154,14 +189,23 @@ Required parameters:
 
 Examples:
   fzj decrypt-dir -i secure.fzj -o ./restored -p private.pem -s dilithium_public.pem
-  fzj decrypt-dir --input backup.fzj --output ./recovered --private-key priv.pem --verify-key pub.pem --force`,
-	"decrypt-dir.flags.input":       "Encrypted file path (required)",
-	"decrypt-dir.flags.output":      "Output directory path (required)",
-	"decrypt-dir.flags.private-key": "Kyber+ECDH private key file (required)",
-	"decrypt-dir.flags.verify-key":  "Dilithium public key file (optional)",
-	"decrypt-dir.flags.force":       "Force overwrite existing files in output directory",
-	"decrypt-dir.flags.buffer-size": "Buffer size (KB), 0=auto",
-	"decrypt-dir.flags.streaming":   "Use streaming mode",
+  fzj decrypt-dir --input backup.fzj --output ./recovered --private-key priv.pem --verify-key pub.pem --force
+  fzj decrypt-dir -i backup.fzj -o ./restored -p priv.pem --max-total-size 50G --max-file-size 20G
+  fzj decrypt-dir -i backup.fzj -o ./restored -p priv.pem --only 'docs/**' --only README.md`,
+	"decrypt-dir.flags.input":          "Encrypted file path or storage URL (s3://, sftp://) (required)",
+	"decrypt-dir.flags.output":         "Output directory path (required)",
+	"decrypt-dir.flags.private-key":    "Kyber+ECDH private key file or URI (required)",
+	"decrypt-dir.flags.verify-key":     "Dilithium public key file (optional)",
+	"decrypt-dir.flags.force":          "Force overwrite existing files in output directory",
+	"decrypt-dir.flags.buffer-size":    "Buffer size (KB), 0=auto",
+	"decrypt-dir.flags.streaming":      "Use streaming mode",
+	"decrypt-dir.flags.max-total-size": "Max total bytes written during extraction (e.g. 50G), 0=unlimited",
+	"decrypt-dir.flags.max-file-size":  "Max bytes written per entry (e.g. 10G), 0=unlimited",
+	"decrypt-dir.flags.max-entries":    "Max number of entries in archive, 0=unlimited",
+	"decrypt-dir.flags.max-depth":      "Max path depth of entries, 0=unlimited",
+	"decrypt-dir.flags.max-ratio":      "Max compression ratio per entry, 0=unlimited",
+	"decrypt-dir.flags.only":           "Only extract entries matching pattern (repeatable, e.g. 'docs/**')",
+	"decrypt-dir.flags.mirror":         "Input is a mirror directory created by encrypt-dir --mirror",
 
 	// keygen 命令
 	"keygen.short": "Generate post-quantum key pair",
@@ -177,10 +221,23 @@ Generated files:
 
 Examples:
   fzj keygen -d ./keys -n mykey
-  fzj keygen --output-dir ./keys --name mykey --force`,
+  fzj keygen --output-dir ./keys --name mykey --force
+
+  # Derive all keys from a new 24-word mnemonic and print it for offline backup
+  fzj keygen -d ./keys -n mykey --mnemonic
+  # Rebuild byte-identical keys from the mnemonic (read from standard input)
+  fzj keygen -d ./restored -n mykey --recover < phrase.txt
+
+  # Composite signing key: files are signed with both Ed25519 and Dilithium3,
+  # and verification requires both signatures to be valid
+  fzj keygen -d ./keys -n mykey --composite`,
 	"keygen.flags.output-dir": "Output directory",
 	"keygen.flags.name":       "Key name prefix (default: timestamp)",
 	"keygen.flags.force":      "Overwrite existing files",
+	"keygen.flags.mnemonic":   "Derive keys from a new 24-word BIP39 mnemonic and print it",
+	"keygen.flags.recover":    "Recover keys from a mnemonic read from standard input",
+	"keygen.flags.composite":  "Generate an Ed25519 + Dilithium3 composite signing key (both signatures must verify)",
+	"keygen.prompt_mnemonic":  "Enter mnemonic: ",
 
 	// keymanage 命令
 	"keymanage.short": "Key management tool",
@@ -191,11 +248,25 @@ Examples:
 	  import    Import key files to specified directory
 	  verify    Verify key pair matching
 	  cache-info Show key cache statistics
+	  csr       Create an X.509 certificate request for an identity
+	  selfsign  Create a self-signed identity certificate (usable as a local CA)
+	  issue     Issue an identity certificate from a request with a local CA
+	  paperkey  Render a key as checksummed text (or QR codes with -o *.png) for offline backup
+	  restore-paper Restore a key file from the paper backup text
+	  fingerprint Show a public key or identity fingerprint as hex, words and emoji
 
 Examples:
   # Export public key
   fzj keymanage export --private-key private.pem --output public_extracted.pem
 
+  # Export as SubjectPublicKeyInfo / PKCS#8 (only the leading X25519 block is readable by OpenSSL;
+  # the post-quantum keys use private OIDs that only fzj reads)
+  fzj keymanage -a export -s private.pem -o public.spki.pem --format pkcs8
+  fzj keymanage -a export -s private.pem -o private.p8.pem --format pkcs8 --export-private
+
+  # Export a compact fzj1... recipient string for sharing (printed when -o is omitted)
+  fzj keymanage -a export -p public.pem --format recipient
+
   # Verify key pair
   fzj keymanage verify --public-key public.pem --private-key private.pem
 
@@ -203,12 +274,250 @@ Examples:
 	  fzj keymanage import --public-key pub.pem --private-key priv.pem --output-dir ./keys
 
 	  # Show cache info
-	  fzj keymanage -a cache-info`,
-	"keymanage.flags.action":      "Action type: export/import/verify/cache-info (required)",
-	"keymanage.flags.public-key":  "Public key file path",
-	"keymanage.flags.private-key": "Private key file path",
-	"keymanage.flags.output":      "Output file path (for export)",
-	"keymanage.flags.output-dir":  "Output directory (for import)",
+	  fzj keymanage -a cache-info
+
+  # Certificates: create a local CA, then issue a certificate for alice
+  fzj keymanage -a selfsign -p ca_public.pem -s ca_dilithium_private.pem --subject "CN=team-ca,O=team" --days 3650 -o ca.pem
+  fzj keymanage -a csr -p alice_public.pem -s alice_dilithium_private.pem --subject "CN=alice,O=team" -o alice.csr
+  fzj keymanage -a issue --csr alice.csr --ca ca.pem -s ca_dilithium_private.pem --days 365 -o alice.pem
+
+  # encrypt/decrypt accept certificates in place of public keys once FZJJYZ_CA points at the CA
+  FZJJYZ_CA=ca.pem fzj encrypt -i file.txt -o file.fzj -p alice.pem -s my_dilithium_private.pem
+
+  # Paper backup: checksummed text or QR codes, and restoring from the text
+  fzj keymanage -a paperkey -s private.pem -o backup.txt
+  fzj keymanage -a paperkey -p public.pem -o public.png
+  fzj keymanage -a restore-paper -i backup.txt -o private.pem
+
+  # Fingerprints: read out over the phone, or compare in scripts
+  fzj keymanage -a fingerprint -p alice_public.pem -s alice_dilithium_public.pem
+  fzj keymanage -a fingerprint -p alice.pem --compare "6883 50FB ..."`,
+	"keymanage.flags.action":         "Action type: export/import/verify/cache-info/csr/selfsign/issue/paperkey/restore-paper/fingerprint (required)",
+	"keymanage.flags.public-key":     "Public key file path",
+	"keymanage.flags.private-key":    "Private key file path",
+	"keymanage.flags.output":         "Output file path (for export)",
+	"keymanage.flags.output-dir":     "Output directory (for import)",
+	"keymanage.flags.format":         "Export format: native, pkcs8 (SubjectPublicKeyInfo/PKCS#8 PEM, post-quantum keys under private OIDs) or recipient (fzj1... string, hybrid public key only); all are detected on load",
+	"keymanage.flags.export-private": "Export the private key instead of the public key (for export)",
+	"keymanage.flags.subject":        `Certificate subject, e.g. "CN=alice,O=team" (for csr/selfsign)`,
+	"keymanage.flags.days":           "Certificate validity in days (for selfsign/issue)",
+	"keymanage.flags.ca":             "CA certificate file (for issue; -s is the CA Dilithium private key)",
+	"keymanage.flags.csr":            "Certificate request file (for issue)",
+	"keymanage.flags.input":          "Paper backup text file (for restore-paper)",
+	"keymanage.flags.compare":        "Fingerprint to compare against, hex or words (for fingerprint; exits non-zero on mismatch)",
+
+	"fingerprint.identity":       "Identity fingerprint (encryption + signing public key):",
+	"fingerprint.hybrid":         "Encryption public key fingerprint:",
+	"fingerprint.dilithium":      "Signing public key fingerprint:",
+	"fingerprint.words":          "Words:",
+	"fingerprint.sas":            "Short authentication string:",
+	"fingerprint.encryption_key": "Encryption key:",
+	"fingerprint.signing_key":    "Signing key:",
+
+	// ls 命令
+	"ls.short": "List contents of encrypted directory archive",
+	"ls.long": `Decrypt a directory archive in memory and list its entries with mode, size and modification time.
+Nothing is written to disk except a short-lived temporary file.
+
+Patterns (--only):
+  Segments are separated by /, "*" and "?" match within a segment,
+  "**" matches any number of directories. A pattern matching a
+  directory also matches everything below it.
+
+Examples:
+  fzj ls -i backup.fzj -p private.pem
+  fzj ls -i backup.fzj -p private.pem -s dilithium_public.pem --only 'docs/**'`,
+	"ls.flags.input":       "Encrypted directory archive path (required)",
+	"ls.flags.private-key": "Kyber+ECDH private key file or URI (required)",
+	"ls.flags.verify-key":  "Dilithium public key file (optional)",
+	"ls.flags.buffer-size": "Buffer size (KB), 0=auto",
+	"ls.flags.streaming":   "Use streaming mode",
+	"ls.flags.only":        "Only list entries matching pattern (repeatable)",
+	"ls.summary":           "%d files, %d directories, %d bytes",
+
+	// serve 命令
+	"serve.short": "Run a local HTTP encryption service",
+	"serve.long": `Serve encrypt, decrypt, verify and info endpoints over HTTP for programs
+that cannot link the Go library. Keys are loaded from a keyring directory
+created by keygen (<name>_public.pem, <name>_private.pem and the matching
+Dilithium key files).
+
+Endpoints (request and response bodies are raw bytes, errors are JSON):
+  GET  /v1/keys       list keyring entries and their fingerprints
+  POST /v1/encrypt    ?key=&signer=&filename=&compress=&level=
+  POST /v1/decrypt    ?key=&signer=&verify=
+  POST /v1/verify     ?key=&signer=
+  POST /v1/info       parse the file header only
+
+key and signer accept a key name or a fingerprint prefix of at least 8
+characters; they may be omitted when the keyring holds a single key.
+Decryption fails when the signer has no Dilithium public key unless
+verify=false is given; the X-Fzjjyz-Verified response header reports
+whether the signature was checked.
+
+Request bodies are limited by --max-body-size (default 1G). Uncompressed
+encryption is streamed chunk by chunk and only the ciphertext is written to
+a temporary file. Compressed encryption buffers the plaintext in a temporary
+file first, and decryption buffers the ciphertext there because the chunk
+table sits at the end of the file; chunked ciphertexts are then decrypted
+straight into the response, older formats through a temporary output file.
+Temporary files are removed when the request ends.
+
+Authenticate with --token-file (Authorization: Bearer <token>) and/or
+mutual TLS (--tls-cert, --tls-key, --client-ca). On a non-loopback address
+the service refuses to start without authentication unless --insecure-no-auth
+is given, and a bearer token requires TLS.
+
+The service is meant for local programs, not browsers: requests carrying an
+Origin header are rejected, and the Host header must be an IP address,
+localhost, the --listen host name or a name given with --allow-host. This
+blocks web pages from reaching the service through DNS rebinding.
+
+Examples:
+  fzj serve --keyring ./keys
+  fzj serve --keyring ./keys --token-file token.txt --max-body-size 256M
+  curl --data-binary @report.pdf 'http://127.0.0.1:8443/v1/encrypt?filename=report.pdf' -o report.pdf.fzj`,
+	"serve.flags.listen":           "Listen address",
+	"serve.flags.keyring":          "Keyring directory created by keygen (required)",
+	"serve.flags.token-file":       "File containing the bearer token required on every request",
+	"serve.flags.tls-cert":         "TLS certificate file",
+	"serve.flags.tls-key":          "TLS private key file",
+	"serve.flags.client-ca":        "Require client certificates signed by this CA (mutual TLS)",
+	"serve.flags.max-body-size":    "Maximum request body size (e.g. 512M, 4G)",
+	"serve.flags.allow-host":       "Additional host name accepted in the Host header (repeatable)",
+	"serve.flags.insecure-no-auth": "Allow a non-loopback listener without --token-file or --client-ca",
+	"serve.key":                    "Key %s  %s",
+	"serve.listening":              "Listening on %s",
+	"serve.stopped":                "Server stopped",
+	"serve.warning_no_auth":        "⚠️  Warning: listening on a non-loopback address without authentication (--insecure-no-auth)",
+
+	// agent 命令
+	"agent.short": "Hold unlocked keys in memory behind a Unix socket",
+	"agent.long": `Load a keyring directory and serve decapsulation and signing requests over
+a Unix socket that only the current user can access. Private keys never leave
+the agent; clients receive public keys, per-file shared secrets and signatures.
+
+When FZJJYZ_AGENT_SOCK is set, decrypt, sign and other commands ask the agent
+for keys matching the --private-key / --sign-key argument (by key name or
+fingerprint, or for a key file by the fingerprint of the public key next to it)
+and fall back to reading the file when it has none.
+
+Examples:
+  fzj agent --keyring ./keys
+  export FZJJYZ_AGENT_SOCK=/run/user/1000/fzjjyz-agent.sock
+  fzj decrypt -i report.pdf.fzj -p keys/alice_private.pem`,
+	"agent.flags.keyring": "Keyring directory created by keygen (required)",
+	"agent.flags.socket":  "Socket path (default $XDG_RUNTIME_DIR/fzjjyz-agent.sock)",
+	"agent.stopped":       "Agent stopped",
+	"agent.fallback":      "⚠️  Key agent unavailable, reading %s from disk: %v",
+
+	// verify-dir 命令
+	"verify-dir.short": "Compare an encrypted directory archive against a directory",
+	"verify-dir.long": `Decrypt a directory archive in memory, hash every entry and compare it
+with a live directory tree. Nothing is written to disk.
+
+Output markers:
+  A   present in the directory, missing from the archive
+  D   present in the archive, missing from the directory
+  M   content differs
+  m   content matches, mode or modification time differs
+  !   archive entry is corrupted
+
+Exits with a non-zero status when any difference is found.
+
+Entries are read under the same limits as decrypt-dir (--max-total-size,
+--max-file-size, ...); raise them to verify large archives.
+
+Examples:
+  fzj verify-dir -i backup.fzj -p private.pem --against ./src
+  fzj verify-dir -i backup.fzj -p private.pem -s dilithium_public.pem --against ./src
+  fzj verify-dir -i backup.fzj -p private.pem --against ./data --max-total-size 50G --max-file-size 10G`,
+	"verify-dir.flags.input":       "Encrypted directory archive path (required)",
+	"verify-dir.flags.private-key": "Kyber+ECDH private key file or URI (required)",
+	"verify-dir.flags.verify-key":  "Dilithium public key file (optional)",
+	"verify-dir.flags.against":     "Directory to compare against (required)",
+	"verify-dir.summary":           "%d archive entries, %d files verified, %d differences",
+	"verify-dir.identical":         "✅ Archive matches directory",
+
+	// check-tree 命令
+	"check-tree.short": "Re-verify an extracted directory against its signed manifest",
+	"check-tree.long": `Read the signed manifest (.fzjjyz-manifest) saved by decrypt-dir in the root
+of an extracted directory, verify its Dilithium signature and re-hash every file.
+
+Output markers:
+  A   file not listed in the manifest
+  D   file listed in the manifest is missing
+  M   content differs
+  m   content matches, mode differs
+
+Exits with a non-zero status when any difference is found.
+
+Examples:
+  fzj check-tree -d ./restored -s dilithium_public.pem
+  fzj check-tree -d ./restored -s dilithium_public.pem --only 'docs/**'`,
+	"check-tree.flags.dir":        "Extracted directory to check (required)",
+	"check-tree.flags.verify-key": "Dilithium public key file (required)",
+	"check-tree.flags.only":       "Only check entries matching pattern (repeatable)",
+	"check-tree.summary":          "%d manifest entries, %d files verified, %d differences",
+	"check-tree.intact":           "✅ Directory matches signed manifest",
+
+	// repo command
+	"repo.short": "Deduplicating encrypted backup repository",
+	"repo.long": `Back up directories into a deduplicating encrypted repository; each backup is a signed snapshot.
+
+Files are split at content-defined boundaries; each chunk is addressed by a keyed hash and
+encrypted separately, so identical content is stored once. The repository master key is
+wrapped with the hybrid KEM for every authorized public key.
+
+Subcommands:
+  init        Create a repository
+  backup      Back up a directory as a new snapshot
+  snapshots   List snapshots
+  restore     Restore a snapshot
+  prune       Remove old snapshots and unreferenced chunks
+
+Examples:
+  fzj repo init -r /backup/repo -p public.pem -p colleague_public.pem -s dilithium_private.pem
+  fzj repo backup -r /backup/repo -i ./project -p private.pem -s dilithium_private.pem --tag nightly
+  fzj repo snapshots -r /backup/repo -p private.pem -s dilithium_public.pem
+  fzj repo restore -r /backup/repo -p private.pem -s dilithium_public.pem --snapshot latest -o restored
+  fzj repo prune -r /backup/repo -p private.pem -s dilithium_public.pem --keep-last 7`,
+	"repo.flags.repo":        "Repository directory (required)",
+	"repo.flags.private-key": "Kyber+ECDH private key file or URI to unlock the repository (required)",
+	"repo.flags.verify-key":  "Dilithium public key file to verify master key and snapshot signatures (optional)",
+
+	"repo.init.short":             "Create a backup repository",
+	"repo.init.long":              "Create a new backup repository and wrap the master key for every --public-key.",
+	"repo.init.flags.public-key":  "Authorized Kyber+ECDH public key file, repeatable (required)",
+	"repo.init.flags.sign-key":    "Dilithium private key file or URI used to sign the master key (required)",
+	"repo.init.flags.chunk-size":  "Average chunk size, a power of two up to 8M",
+	"repo.init.summary":           "Repository: %s\nID: %s\nAuthorized keys: %d\nAverage chunk size: %d KB",
+	"repo.backup.short":           "Back up a directory as a new snapshot",
+	"repo.backup.long":            "Back up a directory as a new signed snapshot. Files unchanged since the previous snapshot are reused and existing chunks are never written twice.",
+	"repo.backup.flags.input":     "Directory to back up (required)",
+	"repo.backup.flags.sign-key":  "Dilithium private key file or URI used to sign the snapshot (required)",
+	"repo.backup.flags.tag":       "Snapshot tag (repeatable)",
+	"repo.backup.summary":         "Snapshot %s: %d files, %d directories, %d unchanged\n%d new chunks, %d reused, %d bytes read, %d bytes added",
+	"repo.snapshots.short":        "List snapshots",
+	"repo.snapshots.long":         "List repository snapshots in time order: ID, time, file count, size, source and tags.",
+	"repo.snapshots.total":        "%d snapshots",
+	"repo.restore.short":          "Restore a snapshot",
+	"repo.restore.long":           "Restore a snapshot into the output directory; every chunk is verified before it is written.",
+	"repo.restore.flags.snapshot": "Snapshot ID, unique prefix or latest",
+	"repo.restore.flags.output":   "Output directory (required)",
+	"repo.restore.flags.only":     "Only restore entries matching pattern (repeatable)",
+	"repo.restore.flags.force":    "Allow restoring into a non-empty directory",
+	"repo.restore.summary":        "Snapshot %s: restored %d files to %s",
+	"repo.prune.short":            "Remove old snapshots and reclaim chunks",
+	"repo.prune.long":             "Remove snapshots by retention policy or ID, then delete chunks no longer referenced by any snapshot.",
+	"repo.prune.flags.keep-last":  "Number of latest snapshots to keep per source directory",
+	"repo.prune.flags.forget":     "Snapshot ID or unique prefix to remove (repeatable)",
+	"repo.prune.summary":          "Removed %d snapshots, kept %d; deleted %d chunks, freed %d bytes",
+	"repo.progress.init":          "Creating repository...",
+	"repo.progress.open":          "Opening repository...",
+	"repo.progress.backup":        "Backing up directory...",
+	"repo.progress.restore":       "Restoring snapshot...",
+	"repo.progress.prune":         "Pruning repository...",
 
 	// info 命令
 	"info.short": "View encrypted file information",
@@ -243,28 +552,51 @@ Examples:
 	"progress.generating_ecdh":      "Generating ECDH X25519 keys...",
 	"progress.generating_dilithium": "Generating Dilithium3 signature keys...",
 	"progress.saving_keys":          "Saving key files...",
+	"progress.deriving_keys":        "Deriving keys from mnemonic...",
 
 	// Status messages
 	"status.done":   "Done",
 	"status.failed": "Failed",
 	"status.warning_no_sign_verify": "⚠️  Warning: No signature verification key " +
 		"provided, skipping signature verification",
-	"status.success_encrypt": "✅ Encryption successful!",
-	"status.success_decrypt": "✅ Decryption successful!",
-	"status.success_keygen":  "✅ Key pair generated successfully!",
-	"status.success_export":  "✅ Public key exported to: %s",
-	"status.success_import":  "✅ Keys imported to: %s",
-	"status.success_verify":  "✅ Key pair verified",
-	"status.cache_info":      "Cache information:",
-	"status.failed_verify":   "❌ Key pair mismatch",
-	"status.encrypting_file": "Encrypting file: %s",
-	"status.decrypting_file": "Decrypting file: %s",
-	"status.encrypting_dir":  "Encrypting directory: %s",
-	"status.decrypting_dir":  "Decrypting directory: %s",
-	"status.generating_keys": "Generating key pair...",
-	"status.public_key":      "Public key",
-	"status.sign_key":        "Sign key",
-	"status.streaming_mode":  "Streaming mode",
+	"status.success_encrypt":          "✅ Encryption successful!",
+	"status.success_decrypt":          "✅ Decryption successful!",
+	"status.success_keygen":           "✅ Key pair generated successfully!",
+	"status.success_export":           "✅ Public key exported to: %s",
+	"status.success_export_private":   "✅ Private key exported to: %s",
+	"status.success_export_recipient": "✅ Recipient exported to: %s",
+	"status.success_csr":              "✅ Certificate request saved to: %s (%s)",
+	"status.success_certificate":      "✅ Certificate saved to: %s (%s, valid until %s)",
+	"status.success_paperkey":         "✅ Paper backup saved to: %s (%s, %d lines)",
+	"status.success_paperkey_qr":      "✅ Paper backup QR codes saved to: %s (%s, %d QR codes)",
+	"status.success_restore_paper":    "✅ Key restored from paper backup to: %s (%s)",
+	"status.fingerprint_match":        "✅ Fingerprint matches",
+	"status.success_import":           "✅ Keys imported to: %s",
+	"status.success_verify":           "✅ Key pair verified",
+	"status.cache_info":               "Cache information:",
+	"status.failed_verify":            "❌ Key pair mismatch",
+	"status.encrypting_file":          "Encrypting file: %s",
+	"status.decrypting_file":          "Decrypting file: %s",
+	"status.encrypting_dir":           "Encrypting directory: %s",
+	"status.decrypting_dir":           "Decrypting directory: %s",
+	"status.generating_keys":          "Generating key pair...",
+	"status.public_key":               "Public key",
+	"status.sign_key":                 "Sign key",
+	"status.streaming_mode":           "Streaming mode",
+	"status.compression":              "Compression",
+	"status.random_access":            "Random access",
+
+	"status.warning_no_manifest": "⚠️  Archive has no signed manifest, per-file verification skipped",
+	"archive.manifest_verified":  "Done (%d files match signed manifest)",
+
+	"batch.files":   "%d files, %d workers",
+	"batch.summary": "Batch finished: %d succeeded, %d failed",
+
+	"storage.uploaded":   "Uploaded to %s (%d bytes)",
+	"storage.downloaded": "Downloaded %s (%d bytes)",
+
+	"volume.split":  "Split into %d volumes: %s ... %s",
+	"volume.opened": "Reading %d volumes starting at %s (%d bytes)",
 
 	// File info output
 	"file_info.header":            "📁 File info: %s",
@@ -279,12 +611,17 @@ Examples:
 	"file_info.compressed_rate":   "Compression rate: %.1f%%",
 	"file_info.timestamp":         "Timestamp: %s",
 	"file_info.algorithm":         "Algorithm: %s (0x%02x)",
+	"file_info.compression":       "Compression: %s",
+	"file_info.indexed_archive":   "Format: indexed directory archive (per-entry encryption)",
+	"file_info.extract_limits":    "Extraction limits",
+	"file_info.unlimited":         "unlimited",
 	"file_info.version":           "Version: 0x%04x",
 	"file_info.magic":             "Magic: %c%c%c\\x%02x",
 	"file_info.kyber":             "Kyber encapsulation: %d bytes",
 	"file_info.ecdh":              "ECDH public key: %d bytes",
 	"file_info.iv":                "IV/Nonce: %d bytes",
 	"file_info.signature":         "Signature: %d bytes",
+	"file_info.signature_type":    "Signature type: %s",
 	"file_info.hash":              "SHA256 hash: %x...",
 	"file_info.signature_status":  "Signature:",
 	"file_info.data_integrity":    "Data integrity:",
@@ -307,6 +644,20 @@ Examples:
   ZIP size: %d bytes
   Encrypted file: %s (%d bytes)
   Compression rate: %.1f%%`,
+	"dir_info.indexed_encrypt_summary": `File information:
+  Source directory: %s
+  File count: %d
+  Total size: %d bytes
+  Encrypted file: %s (%d bytes)
+  Format: indexed archive`,
+	"dir_info.mirror_encrypt_summary": `File information:
+  Source directory: %s
+  Mirror directory: %s
+  Encrypted: %d, unchanged: %d, removed: %d`,
+	"dir_info.mirror_decrypt_summary": `File information:
+  Mirror directory: %s
+  Output directory: %s
+  Decrypted files: %d`,
 	"dir_info.decrypt_summary": `File information:
   Encrypted file: %s (%d bytes)
   Decrypted size: %d bytes
@@ -327,6 +678,8 @@ Examples:
   Timestamp: %s`,
 
 	// Key generation info
+	"keygen_info.mnemonic":  "🔑 Recovery mnemonic (write it down and keep it offline; anyone who has it can rebuild all private keys):",
+	"keygen_info.composite": "Signing key type: Ed25519 + Dilithium3 composite",
 	"keygen_info.files": `Generated files:
   • %s (public key)
   • %s (private key - 0600 permissions)
@@ -360,6 +713,7 @@ Examples:
 	"error.output_file_exists":        "Output file already exists: %s (use --force to overwrite)",
 	"error.output_dir_not_empty":      "Output directory not empty: %s (use --force to overwrite)",
 	"error.cannot_create_dir":         "Cannot create directory %s: %v",
+	"error.cannot_read_dir":           "Cannot read directory %s: %v",
 	"error.cannot_open_file":          "Cannot open encrypted file: %v",
 	"error.cannot_read_file":          "Cannot read file: %v",
 	"error.cannot_read_data":          "Cannot read decrypted data: %v",
@@ -402,6 +756,8 @@ Tips:
 	"error.keygen_kyber_failed":     "Kyber key generation failed: %v",
 	"error.keygen_ecdh_failed":      "ECDH key generation failed: %v",
 	"error.keygen_dilithium_failed": "Dilithium key generation failed: %v",
+	"error.keygen_mnemonic_failed":  "Mnemonic key derivation failed: %v",
+	"error.invalid_mnemonic":        "Invalid mnemonic: %v",
 	"error.save_keys_failed":        "Failed to save key files: %v",
 	"error.save_dilithium_failed":   "Failed to save Dilithium keys: %v",
 	"error.export_key_failed":       "Failed to export public key: %v",
@@ -447,13 +803,63 @@ Possible causes:
   1. Insufficient output directory permissions
   2. Insufficient disk space
   3. ZIP file corrupted`,
-	"error.temp_file_failed":       "❌ Failed to create temporary file: %v",
-	"error.parse_header_failed":    "Failed to parse file header: %v",
-	"error.validate_header_failed": "Failed to validate file header: %v",
+	"error.temp_file_failed":        "❌ Failed to create temporary file: %v",
+	"error.parse_header_failed":     "Failed to parse file header: %v",
+	"error.validate_header_failed":  "Failed to validate file header: %v",
+	"error.invalid_compression":     "Invalid compression option: %v",
+	"error.invalid_extract_limit":   "Invalid extraction limit: %v",
+	"error.invalid_pattern":         "Invalid pattern: %v",
+	"error.list_failed":             "Failed to list archive: %v",
+	"error.verify_dir_failed":       "Failed to verify archive against directory: %v",
+	"error.tree_differs":            "archive and directory differ",
+	"error.manifest_invalid":        "Invalid or untrusted manifest: %v",
+	"error.check_tree_failed":       "Failed to check directory against manifest: %v",
+	"error.tree_differs_manifest":   "directory does not match signed manifest",
+	"error.mirror_only_flag":        "--encrypt-names and --state require --mirror",
+	"error.mirror_indexed_conflict": "--mirror cannot be combined with --indexed",
+
+	"error.no_input_files":         "At least one input file is required via -i or arguments",
+	"error.no_input_match":         "No input files match: %s",
+	"error.invalid_input_pattern":  "Invalid glob %s: %v",
+	"error.invalid_jobs":           "Invalid number of jobs: %d",
+	"error.batch_output_not_dir":   "Output path must be a directory in batch mode: %s",
+	"error.batch_duplicate_output": "Output file %s is also the output of %s",
+	"error.batch_failed":           "%d/%d files failed",
+
+	"error.repo_failed":             "Repository operation failed: %v",
+	"error.repo_invalid_chunk_size": "Invalid chunk size: %s",
+
+	"error.storage_failed":     "Storage operation failed: %v",
+	"error.remote_unsupported": "%s does not accept a storage URL here: %s",
+
+	"error.invalid_volume_size":       "Invalid volume size: %s (minimum %d bytes)",
+	"error.volume_batch_unsupported":  "--volume-size cannot be used with multiple input files",
+	"error.volume_mirror_conflict":    "--volume-size cannot be combined with --mirror",
+	"error.volume_failed":             "Volume operation failed: %v",
+	"error.random_access_compression": "--random-access cannot be combined with --compress",
+
+	"error.keyring_failed":         "Failed to load keyring: %v",
+	"error.serve_failed":           "Server failed: %v",
+	"error.serve_tls_flags":        "--tls-cert and --tls-key must be given together, and --client-ca requires them",
+	"error.serve_empty_token":      "Token file is empty: %s",
+	"error.serve_no_auth":          "Refusing to listen on a non-loopback address without --token-file or --client-ca; pass --insecure-no-auth to override",
+	"error.serve_token_plain_http": "Refusing to accept a bearer token over plain HTTP on a non-loopback address; use --tls-cert and --tls-key",
+	"error.agent_failed":           "Key agent failed: %v",
 
 	// Error messages - Other
-	"error.unknown_action":         "Unknown action: %s (supported: export, import, verify, cache-info)",
-	"error.missing_required_flags": "Must provide %s",
-	"error.missing_both_keys":      "Must provide --public-key and --private-key",
-	"error.nothing_to_do":          "Nothing to do",
+	"error.invalid_key_format":        "Invalid key format: %s (supported: native, pkcs8, recipient)",
+	"error.unknown_action":            "Unknown action: %s (supported: export, import, verify, cache-info, csr, selfsign, issue, paperkey, restore-paper, fingerprint)",
+	"error.certificate_failed":        "Certificate operation failed: %v",
+	"error.invalid_days":              "Invalid validity period: %d days",
+	"error.ca_not_configured":         "Public key is a certificate but no trusted CA is configured, set %s to the CA certificate file",
+	"error.paperkey_failed":           "Paper backup failed: %v",
+	"error.restore_paper_failed":      "Restoring paper backup failed: %v",
+	"error.invalid_recipient":         "Invalid recipient: %v",
+	"error.recipient_public_only":     "The recipient format only encodes public keys, --export-private is not supported",
+	"error.fingerprint_failed":        "Fingerprint calculation failed: %v",
+	"error.fingerprint_mismatch":      "Fingerprint does not match, the key's fingerprint is %s",
+	"error.fingerprint_duplicate_key": "%s contains a key of a type that was already given; pass one encryption and/or one signing key",
+	"error.missing_required_flags":    "Must provide %s",
+	"error.missing_both_keys":         "Must provide --public-key and --private-key",
+	"error.nothing_to_do":             "Nothing to do",
 }
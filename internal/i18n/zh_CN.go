@@ -58,19 +58,41 @@ var zhTranslations = map[string]string{
 
 必需参数：
   --input, -i         输入文件路径
-  --public-key, -p    Kyber+ECDH 公钥文件
+  --public-key, -p    Kyber+ECDH 公钥文件（或 --recipient, -r fzj1...）
   --sign-key, -s      Dilithium 私钥文件
 
 示例：
   fzj encrypt -i plaintext.txt -o encrypted.fzj -p public.pem -s dilithium_private.pem
-  fzj encrypt --input data.txt --public-key pub.pem --sign-key priv.pem --force`,
-	"encrypt.flags.input":       "输入文件路径 (必需)",
-	"encrypt.flags.output":      "输出文件路径 (可选，默认: input.fzj)",
-	"encrypt.flags.public-key":  "Kyber+ECDH 公钥文件 (必需)",
-	"encrypt.flags.sign-key":    "Dilithium 私钥文件 (必需)",
-	"encrypt.flags.force":       "覆盖输出文件",
-	"encrypt.flags.buffer-size": "缓冲区大小 (KB)，0=自动选择",
-	"encrypt.flags.streaming":   "使用流式处理（大文件推荐）",
+  fzj encrypt --input data.txt --public-key pub.pem --sign-key priv.pem --force
+  fzj encrypt -i plaintext.txt -r fzj1qx... -s dilithium_private.pem
+  fzj encrypt -i app.log -p public.pem -s dilithium_private.pem --compress zstd --compress-level 19
+  fzj encrypt -p public.pem -s dilithium_private.pem -o out/ --jobs 4 'logs/*.log' report.pdf
+
+批量模式：
+  多个输入或通配符时并发处理，密钥只加载一次；-o 作为输出目录。
+  任一文件失败时以非零状态退出。
+
+远程存储：
+  -o s3://bucket/key?endpoint=http://127.0.0.1:9000  凭证取自 AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY
+  -o sftp://user@host/path/file.fzj                  主机密钥按 ~/.ssh/known_hosts 校验，
+                                                     认证使用 FZJJYZ_SFTP_PASSWORD、FZJJYZ_SFTP_KEY 或 ssh-agent
+
+分卷：
+  --volume-size 4G 生成 out.fzj.001、out.fzj.002 ...，分卷头记录序号、总数并签名。
+  decrypt / decrypt-dir 传入第一个分卷即可，缺失、乱序或混入其他分卷集的分卷会被拒绝。`,
+	"encrypt.flags.input":          "输入文件路径，可重复并支持通配符 (必需)",
+	"encrypt.flags.output":         "输出文件路径或存储 URL (s3://、sftp://) (可选，默认: input.fzj)",
+	"encrypt.flags.public-key":     "Kyber+ECDH 公钥文件、身份证书或 fzj1... 收件人字符串 (与 --recipient 二选一，必需)",
+	"encrypt.flags.recipient":      "收件人: fzj1... 字符串或公钥文件，与 --public-key 相同",
+	"encrypt.flags.sign-key":       "Dilithium 私钥文件或 URI (必需)",
+	"encrypt.flags.force":          "覆盖输出文件",
+	"encrypt.flags.buffer-size":    "缓冲区大小 (KB)，0=自动选择",
+	"encrypt.flags.streaming":      "使用流式处理（大文件推荐）",
+	"encrypt.flags.compress":       "加密前压缩: none/gzip/zstd",
+	"encrypt.flags.compress-level": "压缩级别，0=算法默认 (gzip 1-9, zstd 1-22)",
+	"encrypt.flags.jobs":           "批量模式的并发数，0=CPU 核数",
+	"encrypt.flags.volume-size":    "将输出切分为不超过该大小的分卷 (如 4G)，命名为 <输出>.001、.002 ...",
+	"encrypt.flags.random-access":  "分块独立认证加密，可在不解密全部内容的情况下读取任意字节范围 (不支持压缩)",
 
 	// decrypt 命令
 	"decrypt.short": "解密文件",
@@ -92,14 +114,20 @@ var zhTranslations = map[string]string{
 
 示例：
   fzj decrypt -i encrypted.fzj -o decrypted.txt -p private.pem -s dilithium_public.pem
-  fzj decrypt --input data.fzj --private-key priv.pem --verify-key pub.pem --force`,
-	"decrypt.flags.input":       "加密文件路径 (必需)",
+  fzj decrypt --input data.fzj --private-key priv.pem --verify-key pub.pem --force
+  fzj decrypt -p private.pem -s dilithium_public.pem -o restored/ 'out/*.fzj'
+
+批量模式：
+  多个输入或通配符时并发处理，输出文件名取自各文件头，-o 作为输出目录。
+  任一文件失败时以非零状态退出。`,
+	"decrypt.flags.input":       "加密文件路径或存储 URL (s3://、sftp://)，可重复并支持通配符 (必需)",
 	"decrypt.flags.output":      "输出文件路径 (可选，默认: 原文件名)",
-	"decrypt.flags.private-key": "Kyber+ECDH 私钥文件 (必需)",
-	"decrypt.flags.verify-key":  "Dilithium 公钥文件 (可选)",
+	"decrypt.flags.private-key": "Kyber+ECDH 私钥文件或 URI (必需)",
+	"decrypt.flags.verify-key":  "Dilithium 公钥或身份证书文件 (可选)",
 	"decrypt.flags.force":       "覆盖输出文件",
 	"decrypt.flags.buffer-size": "缓冲区大小 (KB)，0=自动选择",
 	"decrypt.flags.streaming":   "使用流式处理（大文件推荐）",
+	"decrypt.flags.jobs":        "批量模式的并发数，0=CPU 核数",
 
 	// encrypt-dir 命令
 	"encrypt-dir.short": "加密文件夹",
@@ -118,19 +146,26 @@ var zhTranslations = map[string]string{
 必需参数：
   --input, -i         源目录路径
   --output, -o        输出加密文件路径
-  --public-key, -p    Kyber+ECDH 公钥文件
+  --public-key, -p    Kyber+ECDH 公钥文件（或 --recipient, -r fzj1...）
   --sign-key, -s      Dilithium 私钥文件
 
 示例：
   fzj encrypt-dir -i ./sensitive_data -o secure.fzj -p public.pem -s dilithium_private.pem
-  fzj encrypt-dir --input ./confidential --output backup.fzj --public-key pub.pem --sign-key priv.pem --force`,
-	"encrypt-dir.flags.input":       "源目录路径 (必需)",
-	"encrypt-dir.flags.output":      "输出加密文件路径 (必需)",
-	"encrypt-dir.flags.public-key":  "Kyber+ECDH 公钥文件 (必需)",
-	"encrypt-dir.flags.sign-key":    "Dilithium 私钥文件 (必需)",
-	"encrypt-dir.flags.force":       "覆盖输出文件",
-	"encrypt-dir.flags.buffer-size": "缓冲区大小 (KB)，0=自动选择",
-	"encrypt-dir.flags.streaming":   "使用流式处理",
+  fzj encrypt-dir --input ./confidential --output backup.fzj --public-key pub.pem --sign-key priv.pem --force
+  fzj encrypt-dir -i ./photos -o photos.fzj -p pub.pem -s priv.pem --indexed
+  fzj encrypt-dir -i ./photos -o ./photos.enc -p pub.pem -s priv.pem --mirror --encrypt-names`,
+	"encrypt-dir.flags.input":         "源目录路径 (必需)",
+	"encrypt-dir.flags.output":        "输出加密文件路径或存储 URL (s3://、sftp://) (必需)",
+	"encrypt-dir.flags.public-key":    "Kyber+ECDH 公钥文件 (必需)",
+	"encrypt-dir.flags.sign-key":      "Dilithium 私钥文件或 URI (必需)",
+	"encrypt-dir.flags.force":         "覆盖输出文件",
+	"encrypt-dir.flags.buffer-size":   "缓冲区大小 (KB)，0=自动选择",
+	"encrypt-dir.flags.streaming":     "使用流式处理",
+	"encrypt-dir.flags.indexed":       "使用逐条目加密的索引存档格式 (支持随机访问，单文件独立校验)",
+	"encrypt-dir.flags.mirror":        "将每个文件分别加密为输出目录中的 .fzj 文件 (增量)",
+	"encrypt-dir.flags.encrypt-names": "确定性加密文件和目录名称 (镜像模式)",
+	"encrypt-dir.flags.state":         "镜像状态文件路径，含名称密钥，不能位于输出目录中 (默认: 用户配置目录下)",
+	"encrypt-dir.flags.volume-size":   "将存档切分为不超过该大小的分卷 (如 4G)",
 
 	// decrypt-dir 命令
 	"decrypt-dir.short": "解密文件夹",
@@ -154,14 +189,23 @@ var zhTranslations = map[string]string{
 
 示例：
   fzj decrypt-dir -i secure.fzj -o ./restored -p private.pem -s dilithium_public.pem
-  fzj decrypt-dir --input backup.fzj --output ./recovered --private-key priv.pem --verify-key pub.pem --force`,
-	"decrypt-dir.flags.input":       "加密文件路径 (必需)",
-	"decrypt-dir.flags.output":      "输出目录路径 (必需)",
-	"decrypt-dir.flags.private-key": "Kyber+ECDH 私钥文件 (必需)",
-	"decrypt-dir.flags.verify-key":  "Dilithium 公钥文件 (可选)",
-	"decrypt-dir.flags.force":       "覆盖输出目录中的现有文件",
-	"decrypt-dir.flags.buffer-size": "缓冲区大小 (KB)，0=自动选择",
-	"decrypt-dir.flags.streaming":   "使用流式处理",
+  fzj decrypt-dir --input backup.fzj --output ./recovered --private-key priv.pem --verify-key pub.pem --force
+  fzj decrypt-dir -i backup.fzj -o ./restored -p priv.pem --max-total-size 50G --max-file-size 20G
+  fzj decrypt-dir -i backup.fzj -o ./restored -p priv.pem --only 'docs/**' --only README.md`,
+	"decrypt-dir.flags.input":          "加密文件路径或存储 URL (s3://、sftp://) (必需)",
+	"decrypt-dir.flags.output":         "输出目录路径 (必需)",
+	"decrypt-dir.flags.private-key":    "Kyber+ECDH 私钥文件或 URI (必需)",
+	"decrypt-dir.flags.verify-key":     "Dilithium 公钥文件 (可选)",
+	"decrypt-dir.flags.force":          "覆盖输出目录中的现有文件",
+	"decrypt-dir.flags.buffer-size":    "缓冲区大小 (KB)，0=自动选择",
+	"decrypt-dir.flags.streaming":      "使用流式处理",
+	"decrypt-dir.flags.max-total-size": "解压实际写入的总字节数上限 (如 50G)，0=不限制",
+	"decrypt-dir.flags.max-file-size":  "单个条目实际写入的字节数上限 (如 10G)，0=不限制",
+	"decrypt-dir.flags.max-entries":    "存档条目数量上限，0=不限制",
+	"decrypt-dir.flags.max-depth":      "条目路径深度上限，0=不限制",
+	"decrypt-dir.flags.max-ratio":      "单个条目压缩比上限，0=不限制",
+	"decrypt-dir.flags.only":           "仅解压匹配模式的条目 (可重复，如 'docs/**')",
+	"decrypt-dir.flags.mirror":         "输入为 encrypt-dir --mirror 生成的镜像目录",
 
 	// keygen 命令
 	"keygen.short": "生成后量子密钥对",
@@ -177,10 +221,22 @@ var zhTranslations = map[string]string{
 
 示例：
   fzj keygen -d ./keys -n mykey
-  fzj keygen --output-dir ./keys --name mykey --force`,
+  fzj keygen --output-dir ./keys --name mykey --force
+
+  # 由新生成的 24 词助记词派生全部密钥，并显示助记词以便离线备份
+  fzj keygen -d ./keys -n mykey --mnemonic
+  # 由助记词（从标准输入读取）恢复逐字节相同的密钥
+  fzj keygen -d ./restored -n mykey --recover < phrase.txt
+
+  # 复合签名密钥：文件同时带 Ed25519 与 Dilithium3 签名，验证时两者都必须有效
+  fzj keygen -d ./keys -n mykey --composite`,
 	"keygen.flags.output-dir": "输出目录",
 	"keygen.flags.name":       "密钥名称前缀 (默认: 时间戳)",
 	"keygen.flags.force":      "覆盖现有文件",
+	"keygen.flags.mnemonic":   "由新生成的 24 词 BIP39 助记词派生密钥并显示助记词",
+	"keygen.flags.recover":    "由从标准输入读取的助记词恢复密钥",
+	"keygen.flags.composite":  "生成 Ed25519 + Dilithium3 复合签名密钥（两个签名都必须验证通过）",
+	"keygen.prompt_mnemonic":  "请输入助记词: ",
 
 	// keymanage 命令
 	"keymanage.short": "密钥管理工具",
@@ -191,11 +247,25 @@ var zhTranslations = map[string]string{
 	  import    导入密钥文件到指定目录
 	  verify    验证密钥对是否匹配
 	  cache-info 查看密钥缓存统计信息
+	  csr       为身份生成 X.509 证书请求
+	  selfsign  生成自签名身份证书（可作为本地 CA）
+	  issue     用本地 CA 为证书请求签发身份证书
+	  paperkey  将密钥输出为带校验和的文本（-o 为 *.png 时输出二维码）用于离线备份
+	  restore-paper 从纸质备份文本还原密钥文件
+	  fingerprint 以十六进制、单词和表情符号显示公钥或身份指纹
 
 示例:
   # 导出公钥
   fzj keymanage export --private-key private.pem --output public_extracted.pem
 
+  # 以 SubjectPublicKeyInfo / PKCS#8 导出（仅在前的 X25519 块可由 OpenSSL 读取，
+  # 后量子分量使用私有 OID，只能由 fzj 读取）
+  fzj keymanage -a export -s private.pem -o public.spki.pem --format pkcs8
+  fzj keymanage -a export -s private.pem -o private.p8.pem --format pkcs8 --export-private
+
+  # 导出便于分享的 fzj1... 收件人字符串（未指定 -o 时直接输出）
+  fzj keymanage -a export -p public.pem --format recipient
+
   # 验证密钥对
   fzj keymanage verify --public-key public.pem --private-key private.pem
 
@@ -203,12 +273,235 @@ var zhTranslations = map[string]string{
 	  fzj keymanage import --public-key pub.pem --private-key priv.pem --output-dir ./keys
 
 	  # 查看缓存信息
-	  fzj keymanage -a cache-info`,
-	"keymanage.flags.action":      "操作类型: export/import/verify/cache-info (必需)",
-	"keymanage.flags.public-key":  "公钥文件路径",
-	"keymanage.flags.private-key": "私钥文件路径",
-	"keymanage.flags.output":      "输出文件路径 (用于export)",
-	"keymanage.flags.output-dir":  "输出目录 (用于import)",
+	  fzj keymanage -a cache-info
+
+  # 证书：创建本地 CA，再为 alice 签发证书
+  fzj keymanage -a selfsign -p ca_public.pem -s ca_dilithium_private.pem --subject "CN=team-ca,O=team" --days 3650 -o ca.pem
+  fzj keymanage -a csr -p alice_public.pem -s alice_dilithium_private.pem --subject "CN=alice,O=team" -o alice.csr
+  fzj keymanage -a issue --csr alice.csr --ca ca.pem -s ca_dilithium_private.pem --days 365 -o alice.pem
+
+  # 设置 FZJJYZ_CA 指向 CA 证书后，encrypt/decrypt 可以用证书代替公钥
+  FZJJYZ_CA=ca.pem fzj encrypt -i file.txt -o file.fzj -p alice.pem -s my_dilithium_private.pem
+
+  # 纸质备份：带校验和的文本或二维码，以及从文本还原
+  fzj keymanage -a paperkey -s private.pem -o backup.txt
+  fzj keymanage -a paperkey -p public.pem -o public.png
+  fzj keymanage -a restore-paper -i backup.txt -o private.pem
+
+  # 指纹：电话中读出核对，或在脚本中比较
+  fzj keymanage -a fingerprint -p alice_public.pem -s alice_dilithium_public.pem
+  fzj keymanage -a fingerprint -p alice.pem --compare "6883 50FB ..."`,
+	"keymanage.flags.action":         "操作类型: export/import/verify/cache-info/csr/selfsign/issue/paperkey/restore-paper/fingerprint (必需)",
+	"keymanage.flags.public-key":     "公钥文件路径",
+	"keymanage.flags.private-key":    "私钥文件路径",
+	"keymanage.flags.output":         "输出文件路径 (用于export)",
+	"keymanage.flags.output-dir":     "输出目录 (用于import)",
+	"keymanage.flags.format":         "导出格式: native、pkcs8 (SubjectPublicKeyInfo/PKCS#8 PEM，后量子分量使用私有 OID) 或 recipient (fzj1... 字符串，仅混合公钥)，加载时均可自动识别",
+	"keymanage.flags.export-private": "导出私钥而不是公钥 (用于 export)",
+	"keymanage.flags.subject":        `证书主题，如 "CN=alice,O=team" (用于 csr/selfsign)`,
+	"keymanage.flags.days":           "证书有效天数 (用于 selfsign/issue)",
+	"keymanage.flags.ca":             "CA 证书文件 (用于 issue，-s 为 CA 的 Dilithium 私钥)",
+	"keymanage.flags.csr":            "证书请求文件 (用于 issue)",
+	"keymanage.flags.input":          "纸质备份文本文件 (用于 restore-paper)",
+	"keymanage.flags.compare":        "要比较的指纹，十六进制或单词 (用于 fingerprint，不一致时返回非零状态)",
+
+	"fingerprint.identity":       "身份指纹（加密 + 签名公钥）:",
+	"fingerprint.hybrid":         "加密公钥指纹:",
+	"fingerprint.dilithium":      "签名公钥指纹:",
+	"fingerprint.words":          "单词:",
+	"fingerprint.sas":            "短认证串:",
+	"fingerprint.encryption_key": "加密公钥:",
+	"fingerprint.signing_key":    "签名公钥:",
+
+	// ls 命令
+	"ls.short": "列出加密文件夹存档的内容",
+	"ls.long": `在内存中解密文件夹存档，列出各条目的权限、大小和修改时间。
+除短暂存在的临时文件外不会写入磁盘。
+
+匹配模式 (--only)：
+  以 / 分段，"*" 和 "?" 在段内匹配，"**" 匹配任意层级目录。
+  匹配某个目录的模式同时匹配其下所有条目。
+
+示例：
+  fzj ls -i backup.fzj -p private.pem
+  fzj ls -i backup.fzj -p private.pem -s dilithium_public.pem --only 'docs/**'`,
+	"ls.flags.input":       "加密文件夹存档路径 (必需)",
+	"ls.flags.private-key": "Kyber+ECDH 私钥文件或 URI (必需)",
+	"ls.flags.verify-key":  "Dilithium 公钥文件 (可选)",
+	"ls.flags.buffer-size": "缓冲区大小 (KB)，0=自动选择",
+	"ls.flags.streaming":   "使用流式处理",
+	"ls.flags.only":        "仅列出匹配模式的条目 (可重复)",
+	"ls.summary":           "%d 个文件，%d 个目录，共 %d 字节",
+
+	// serve 命令
+	"serve.short": "运行本地 HTTP 加密服务",
+	"serve.long": `通过 HTTP 提供加密、解密、验证和信息查询接口，供无法直接调用 Go 库的程序使用。
+密钥从 keygen 生成的密钥环目录加载（<name>_public.pem、<name>_private.pem
+以及对应的 Dilithium 密钥文件）。
+
+接口（请求体和响应体为原始字节，错误以 JSON 返回）:
+  GET  /v1/keys       列出密钥环中的密钥及指纹
+  POST /v1/encrypt    ?key=&signer=&filename=&compress=&level=
+  POST /v1/decrypt    ?key=&signer=&verify=
+  POST /v1/verify     ?key=&signer=
+  POST /v1/info       只解析文件头
+
+key 和 signer 接受密钥名称或至少 8 个字符的指纹前缀，密钥环只有一个密钥时可省略。
+签名密钥没有 Dilithium 公钥时解密失败，除非指定 verify=false；
+响应头 X-Fzjjyz-Verified 表示签名是否已验证。
+
+请求体大小受 --max-body-size 限制（默认 1G）。未压缩加密按块流式处理，临时文件中只有密文；
+压缩加密先将明文写入临时文件，解密先将密文写入临时文件（分块格式的块表位于文件末尾），
+分块密文随后直接解密写入响应，其他格式经临时输出文件返回。请求结束时删除临时文件。
+
+可通过 --token-file（Authorization: Bearer <token>）和/或双向 TLS
+（--tls-cert、--tls-key、--client-ca）进行认证。在非回环地址上未启用认证时拒绝启动，
+除非指定 --insecure-no-auth；Bearer 令牌必须配合 TLS 使用。
+
+服务面向本机程序而非浏览器：带 Origin 头的请求会被拒绝，Host 头只能是 IP 地址、
+localhost、--listen 中的主机名或 --allow-host 指定的主机名，防止网页通过 DNS 重绑定访问服务。
+
+示例:
+  fzj serve --keyring ./keys
+  fzj serve --keyring ./keys --token-file token.txt --max-body-size 256M
+  curl --data-binary @report.pdf 'http://127.0.0.1:8443/v1/encrypt?filename=report.pdf' -o report.pdf.fzj`,
+	"serve.flags.listen":           "监听地址",
+	"serve.flags.keyring":          "keygen 生成的密钥环目录 (必需)",
+	"serve.flags.token-file":       "包含请求所需 Bearer 令牌的文件",
+	"serve.flags.tls-cert":         "TLS 证书文件",
+	"serve.flags.tls-key":          "TLS 私钥文件",
+	"serve.flags.client-ca":        "要求客户端出示由该 CA 签发的证书（双向 TLS）",
+	"serve.flags.max-body-size":    "请求体大小上限（如 512M、4G）",
+	"serve.flags.allow-host":       "Host 头中额外允许的主机名（可重复）",
+	"serve.flags.insecure-no-auth": "允许在非回环地址上不设置 --token-file 或 --client-ca 运行",
+	"serve.key":                    "密钥 %s  %s",
+	"serve.listening":              "正在监听 %s",
+	"serve.stopped":                "服务已停止",
+	"serve.warning_no_auth":        "⚠️  警告: 在非回环地址上监听且未启用认证 (--insecure-no-auth)",
+
+	// agent 命令
+	"agent.short": "在内存中持有已解锁的密钥，通过 Unix 套接字提供服务",
+	"agent.long": `加载密钥环目录，通过仅当前用户可访问的 Unix 套接字响应解封装和签名请求。
+私钥从不离开代理，客户端只能得到公钥、单个文件的共享密钥和签名。
+
+设置 FZJJYZ_AGENT_SOCK 后，decrypt、签名等命令会向代理请求与 --private-key / --sign-key
+参数匹配的密钥（按密钥名或指纹；参数为密钥文件时按同目录公钥的指纹），代理中没有时再读取文件。
+
+示例:
+  fzj agent --keyring ./keys
+  export FZJJYZ_AGENT_SOCK=/run/user/1000/fzjjyz-agent.sock
+  fzj decrypt -i report.pdf.fzj -p keys/alice_private.pem`,
+	"agent.flags.keyring": "keygen 生成的密钥环目录 (必需)",
+	"agent.flags.socket":  "套接字路径（默认 $XDG_RUNTIME_DIR/fzjjyz-agent.sock）",
+	"agent.stopped":       "代理已停止",
+	"agent.fallback":      "⚠️  密钥代理不可用，从磁盘读取 %s: %v",
+
+	// verify-dir 命令
+	"verify-dir.short": "将加密文件夹存档与目录进行比较",
+	"verify-dir.long": `在内存中解密文件夹存档，计算每个条目的哈希并与现有目录树比较。
+不会向磁盘写入任何内容。
+
+输出标记：
+  A   目录中存在，存档中缺失
+  D   存档中存在，目录中缺失
+  M   内容不同
+  m   内容相同，权限或修改时间不同
+  !   存档条目已损坏
+
+发现任何差异时以非零状态退出。
+
+条目读取受与 decrypt-dir 相同的限制 (--max-total-size、--max-file-size 等)，
+比较大型存档时可调高这些限制。
+
+示例：
+  fzj verify-dir -i backup.fzj -p private.pem --against ./src
+  fzj verify-dir -i backup.fzj -p private.pem -s dilithium_public.pem --against ./src
+  fzj verify-dir -i backup.fzj -p private.pem --against ./data --max-total-size 50G --max-file-size 10G`,
+	"verify-dir.flags.input":       "加密文件夹存档路径 (必需)",
+	"verify-dir.flags.private-key": "Kyber+ECDH 私钥文件或 URI (必需)",
+	"verify-dir.flags.verify-key":  "Dilithium 公钥文件 (可选)",
+	"verify-dir.flags.against":     "用于比较的目录 (必需)",
+	"verify-dir.summary":           "存档条目 %d 个，已校验文件 %d 个，差异 %d 处",
+	"verify-dir.identical":         "✅ 存档与目录一致",
+
+	// check-tree 命令
+	"check-tree.short": "按签名清单重新校验已解压的目录",
+	"check-tree.long": `读取 decrypt-dir 保存在解压目录根部的签名清单 (.fzjjyz-manifest)，
+验证其 Dilithium 签名并重新计算每个文件的哈希。
+
+输出标记：
+  A   文件未列在清单中
+  D   清单中的文件缺失
+  M   内容不同
+  m   内容相同，权限不同
+
+发现任何差异时以非零状态退出。
+
+示例：
+  fzj check-tree -d ./restored -s dilithium_public.pem
+  fzj check-tree -d ./restored -s dilithium_public.pem --only 'docs/**'`,
+	"check-tree.flags.dir":        "要校验的解压目录 (必需)",
+	"check-tree.flags.verify-key": "Dilithium 公钥文件 (必需)",
+	"check-tree.flags.only":       "仅校验匹配模式的条目 (可重复)",
+	"check-tree.summary":          "清单条目 %d 个，已校验文件 %d 个，差异 %d 处",
+	"check-tree.intact":           "✅ 目录与签名清单一致",
+
+	// repo 命令
+	"repo.short": "去重的加密备份仓库",
+	"repo.long": `将目录备份到去重的加密仓库，每次备份生成一个签名快照。
+
+文件按内容定义的边界切分成数据块，每个块以带密钥的哈希寻址并单独加密，
+相同内容只存储一次。仓库主密钥用混合 KEM 分别加密给每个授权公钥。
+
+子命令：
+  init        创建仓库
+  backup      备份目录为新快照
+  snapshots   列出快照
+  restore     恢复快照
+  prune       删除旧快照并回收不再引用的数据块
+
+示例：
+  fzj repo init -r /backup/repo -p public.pem -p colleague_public.pem -s dilithium_private.pem
+  fzj repo backup -r /backup/repo -i ./project -p private.pem -s dilithium_private.pem --tag nightly
+  fzj repo snapshots -r /backup/repo -p private.pem -s dilithium_public.pem
+  fzj repo restore -r /backup/repo -p private.pem -s dilithium_public.pem --snapshot latest -o restored
+  fzj repo prune -r /backup/repo -p private.pem -s dilithium_public.pem --keep-last 7`,
+	"repo.flags.repo":        "仓库目录 (必需)",
+	"repo.flags.private-key": "Kyber+ECDH 私钥文件或 URI，用于解锁仓库 (必需)",
+	"repo.flags.verify-key":  "Dilithium 公钥文件，验证主密钥和快照签名 (可选)",
+
+	"repo.init.short":             "创建备份仓库",
+	"repo.init.long":              "创建新的备份仓库，为每个 --public-key 封装一份仓库主密钥。",
+	"repo.init.flags.public-key":  "授权的 Kyber+ECDH 公钥文件，可重复 (必需)",
+	"repo.init.flags.sign-key":    "Dilithium 私钥文件或 URI，对主密钥签名 (必需)",
+	"repo.init.flags.chunk-size":  "平均块大小，必须为 2 的幂，最大 8M",
+	"repo.init.summary":           "仓库: %s\nID: %s\n授权公钥: %d 个\n平均块大小: %d KB",
+	"repo.backup.short":           "备份目录为新快照",
+	"repo.backup.long":            "备份目录为新的签名快照。与上一快照相比未变化的文件直接复用，已存在的数据块不会重复写入。",
+	"repo.backup.flags.input":     "要备份的目录 (必需)",
+	"repo.backup.flags.sign-key":  "Dilithium 私钥文件或 URI，对快照签名 (必需)",
+	"repo.backup.flags.tag":       "快照标签 (可重复)",
+	"repo.backup.summary":         "快照 %s: 文件 %d 个，目录 %d 个，未变化 %d 个\n新数据块 %d 个，复用 %d 个，读取 %d 字节，新增 %d 字节",
+	"repo.snapshots.short":        "列出快照",
+	"repo.snapshots.long":         "按时间顺序列出仓库中的快照：ID、时间、文件数、大小、源目录和标签。",
+	"repo.snapshots.total":        "共 %d 个快照",
+	"repo.restore.short":          "恢复快照",
+	"repo.restore.long":           "将快照恢复到输出目录，数据块在写出前逐一校验。",
+	"repo.restore.flags.snapshot": "快照 ID、唯一前缀或 latest",
+	"repo.restore.flags.output":   "输出目录 (必需)",
+	"repo.restore.flags.only":     "仅恢复匹配模式的条目 (可重复)",
+	"repo.restore.flags.force":    "允许恢复到非空目录",
+	"repo.restore.summary":        "已从快照 %s 恢复 %d 个文件到 %s",
+	"repo.prune.short":            "删除旧快照并回收数据块",
+	"repo.prune.long":             "按保留策略或指定 ID 删除快照，然后删除不再被任何快照引用的数据块。",
+	"repo.prune.flags.keep-last":  "每个源目录保留的最新快照数",
+	"repo.prune.flags.forget":     "要删除的快照 ID 或唯一前缀 (可重复)",
+	"repo.prune.summary":          "删除快照 %d 个，保留 %d 个；删除数据块 %d 个，释放 %d 字节",
+	"repo.progress.init":          "创建仓库...",
+	"repo.progress.open":          "打开仓库...",
+	"repo.progress.backup":        "备份目录...",
+	"repo.progress.restore":       "恢复快照...",
+	"repo.progress.prune":         "清理仓库...",
 
 	// info 命令
 	"info.short": "查看加密文件信息",
@@ -243,27 +536,50 @@ var zhTranslations = map[string]string{
 	"progress.generating_ecdh":      "生成 ECDH X25519 密钥...",
 	"progress.generating_dilithium": "生成 Dilithium3 签名密钥...",
 	"progress.saving_keys":          "保存密钥文件...",
+	"progress.deriving_keys":        "由助记词派生密钥...",
 
 	// 状态消息
-	"status.done":                   "完成",
-	"status.failed":                 "失败",
-	"status.warning_no_sign_verify": "⚠️  警告: 未提供签名验证密钥，将跳过签名验证",
-	"status.success_encrypt":        "✅ 加密成功！",
-	"status.success_decrypt":        "✅ 解密成功！",
-	"status.success_keygen":         "✅ 密钥对生成成功！",
-	"status.success_export":         "✅ 公钥已导出到: %s",
-	"status.success_import":         "✅ 密钥已导入到: %s",
-	"status.success_verify":         "✅ 密钥对验证通过",
-	"status.cache_info":             "缓存信息:",
-	"status.failed_verify":          "❌ 密钥对不匹配",
-	"status.encrypting_file":        "加密文件: %s",
-	"status.decrypting_file":        "解密文件: %s",
-	"status.encrypting_dir":         "加密文件夹: %s",
-	"status.decrypting_dir":         "解密文件夹: %s",
-	"status.generating_keys":        "生成密钥对...",
-	"status.public_key":             "公钥",
-	"status.sign_key":               "签名密钥",
-	"status.streaming_mode":         "流式处理",
+	"status.done":                     "完成",
+	"status.failed":                   "失败",
+	"status.warning_no_sign_verify":   "⚠️  警告: 未提供签名验证密钥，将跳过签名验证",
+	"status.success_encrypt":          "✅ 加密成功！",
+	"status.success_decrypt":          "✅ 解密成功！",
+	"status.success_keygen":           "✅ 密钥对生成成功！",
+	"status.success_export":           "✅ 公钥已导出到: %s",
+	"status.success_export_private":   "✅ 私钥已导出到: %s",
+	"status.success_export_recipient": "✅ 收件人字符串已导出到: %s",
+	"status.success_csr":              "✅ 证书请求已保存到: %s (%s)",
+	"status.success_certificate":      "✅ 证书已保存到: %s (%s，有效期至 %s)",
+	"status.success_paperkey":         "✅ 纸质备份已保存到: %s (%s，%d 行)",
+	"status.success_paperkey_qr":      "✅ 纸质备份二维码已保存到: %s (%s，%d 个二维码)",
+	"status.success_restore_paper":    "✅ 已从纸质备份还原密钥到: %s (%s)",
+	"status.fingerprint_match":        "✅ 指纹一致",
+	"status.success_import":           "✅ 密钥已导入到: %s",
+	"status.success_verify":           "✅ 密钥对验证通过",
+	"status.cache_info":               "缓存信息:",
+	"status.failed_verify":            "❌ 密钥对不匹配",
+	"status.encrypting_file":          "加密文件: %s",
+	"status.decrypting_file":          "解密文件: %s",
+	"status.encrypting_dir":           "加密文件夹: %s",
+	"status.decrypting_dir":           "解密文件夹: %s",
+	"status.generating_keys":          "生成密钥对...",
+	"status.public_key":               "公钥",
+	"status.sign_key":                 "签名密钥",
+	"status.streaming_mode":           "流式处理",
+	"status.compression":              "压缩算法",
+	"status.random_access":            "随机访问",
+
+	"status.warning_no_manifest": "⚠️  存档中没有签名清单，跳过逐文件校验",
+	"archive.manifest_verified":  "完成 (%d 个文件与签名清单一致)",
+
+	"batch.files":   "%d 个文件，并发 %d",
+	"batch.summary": "批量处理完成: 成功 %d，失败 %d",
+
+	"storage.uploaded":   "已上传到 %s (%d 字节)",
+	"storage.downloaded": "已下载 %s (%d 字节)",
+
+	"volume.split":  "已切分为 %d 个分卷: %s ... %s",
+	"volume.opened": "读取 %d 个分卷，从 %s 开始 (%d 字节)",
 
 	// 文件信息输出
 	"file_info.header":            "📁 文件信息: %s",
@@ -278,12 +594,17 @@ var zhTranslations = map[string]string{
 	"file_info.compressed_rate":   "压缩率: %.1f%%",
 	"file_info.timestamp":         "时间戳: %s",
 	"file_info.algorithm":         "算法: %s (0x%02x)",
+	"file_info.compression":       "压缩: %s",
+	"file_info.indexed_archive":   "格式: 索引目录存档 (逐条目加密)",
+	"file_info.extract_limits":    "解压限制",
+	"file_info.unlimited":         "不限制",
 	"file_info.version":           "版本: 0x%04x",
 	"file_info.magic":             "魔数: %c%c%c\\x%02x",
 	"file_info.kyber":             "Kyber封装: %d bytes",
 	"file_info.ecdh":              "ECDH公钥: %d bytes",
 	"file_info.iv":                "IV/Nonce: %d bytes",
 	"file_info.signature":         "签名: %d bytes",
+	"file_info.signature_type":    "签名类型: %s",
 	"file_info.hash":              "SHA256哈希: %x...",
 	"file_info.signature_status":  "签名:",
 	"file_info.data_integrity":    "数据完整性:",
@@ -306,6 +627,20 @@ var zhTranslations = map[string]string{
   ZIP大小: %d bytes
   加密文件: %s (%d bytes)
   压缩率: %.1f%%`,
+	"dir_info.indexed_encrypt_summary": `文件信息:
+  源目录: %s
+  文件数量: %d
+  总大小: %d 字节
+  加密文件: %s (%d 字节)
+  格式: 索引存档`,
+	"dir_info.mirror_encrypt_summary": `文件信息:
+  源目录: %s
+  镜像目录: %s
+  已加密: %d，未变化: %d，已移除: %d`,
+	"dir_info.mirror_decrypt_summary": `文件信息:
+  镜像目录: %s
+  输出目录: %s
+  已解密文件: %d`,
 	"dir_info.decrypt_summary": `文件信息:
   加密文件: %s (%d bytes)
   解密大小: %d bytes
@@ -326,6 +661,8 @@ var zhTranslations = map[string]string{
   时间戳: %s`,
 
 	// 密钥生成信息
+	"keygen_info.mnemonic":  "🔑 恢复助记词（请抄写并离线保存，任何得到它的人都能重建全部私钥）:",
+	"keygen_info.composite": "签名密钥类型: Ed25519 + Dilithium3 复合密钥",
 	"keygen_info.files": `生成的文件:
   • %s (公钥)
   • %s (私钥 - 0600权限)
@@ -359,6 +696,7 @@ var zhTranslations = map[string]string{
 	"error.output_file_exists":        "输出文件已存在: %s (使用 --force 覆盖)",
 	"error.output_dir_not_empty":      "输出目录非空: %s (使用 --force 覆盖)",
 	"error.cannot_create_dir":         "无法创建目录 %s: %v",
+	"error.cannot_read_dir":           "无法读取目录 %s: %v",
 	"error.cannot_open_file":          "无法打开加密文件: %v",
 	"error.cannot_read_file":          "无法读取文件: %v",
 	"error.cannot_read_data":          "无法读取解密数据: %v",
@@ -401,6 +739,8 @@ var zhTranslations = map[string]string{
 	"error.keygen_kyber_failed":     "Kyber密钥生成失败: %v",
 	"error.keygen_ecdh_failed":      "ECDH密钥生成失败: %v",
 	"error.keygen_dilithium_failed": "Dilithium密钥生成失败: %v",
+	"error.keygen_mnemonic_failed":  "助记词密钥派生失败: %v",
+	"error.invalid_mnemonic":        "无效的助记词: %v",
 	"error.save_keys_failed":        "保存密钥文件失败: %v",
 	"error.save_dilithium_failed":   "保存Dilithium密钥失败: %v",
 	"error.export_key_failed":       "导出公钥失败: %v",
@@ -446,13 +786,63 @@ var zhTranslations = map[string]string{
   1. 输出目录权限不足
   2. 磁盘空间不足
   3. ZIP文件损坏`,
-	"error.temp_file_failed":       "❌ 临时文件创建失败: %v",
-	"error.parse_header_failed":    "文件头解析失败: %v",
-	"error.validate_header_failed": "文件头验证失败: %v",
+	"error.temp_file_failed":        "❌ 临时文件创建失败: %v",
+	"error.parse_header_failed":     "文件头解析失败: %v",
+	"error.validate_header_failed":  "文件头验证失败: %v",
+	"error.invalid_compression":     "无效的压缩选项: %v",
+	"error.invalid_extract_limit":   "无效的解压限制: %v",
+	"error.invalid_pattern":         "无效的匹配模式: %v",
+	"error.list_failed":             "列出存档内容失败: %v",
+	"error.verify_dir_failed":       "存档与目录比较失败: %v",
+	"error.tree_differs":            "存档与目录存在差异",
+	"error.manifest_invalid":        "清单无效或不可信: %v",
+	"error.check_tree_failed":       "按清单校验目录失败: %v",
+	"error.tree_differs_manifest":   "目录与签名清单不一致",
+	"error.mirror_only_flag":        "--encrypt-names 和 --state 需要与 --mirror 一起使用",
+	"error.mirror_indexed_conflict": "--mirror 不能与 --indexed 同时使用",
+
+	"error.no_input_files":         "必须通过 -i 或参数提供至少一个输入文件",
+	"error.no_input_match":         "没有匹配的输入文件: %s",
+	"error.invalid_input_pattern":  "无效的通配符 %s: %v",
+	"error.invalid_jobs":           "无效的并发数: %d",
+	"error.batch_output_not_dir":   "批量模式下输出路径必须是目录: %s",
+	"error.batch_duplicate_output": "输出文件 %s 与 %s 的输出重复",
+	"error.batch_failed":           "%d/%d 个文件处理失败",
+
+	"error.repo_failed":             "仓库操作失败: %v",
+	"error.repo_invalid_chunk_size": "无效的块大小: %s",
+
+	"error.storage_failed":     "存储操作失败: %v",
+	"error.remote_unsupported": "%s 此处不支持存储 URL: %s",
+
+	"error.invalid_volume_size":       "无效的分卷大小: %s (最小 %d 字节)",
+	"error.volume_batch_unsupported":  "--volume-size 不能用于多个输入文件",
+	"error.volume_mirror_conflict":    "--volume-size 不能与 --mirror 同时使用",
+	"error.volume_failed":             "分卷操作失败: %v",
+	"error.random_access_compression": "--random-access 不能与 --compress 同时使用",
+
+	"error.keyring_failed":         "加载密钥环失败: %v",
+	"error.serve_failed":           "服务失败: %v",
+	"error.serve_tls_flags":        "--tls-cert 和 --tls-key 必须同时指定，--client-ca 需要二者",
+	"error.serve_empty_token":      "令牌文件为空: %s",
+	"error.serve_no_auth":          "拒绝在非回环地址上无 --token-file 或 --client-ca 监听；如确需如此请指定 --insecure-no-auth",
+	"error.serve_token_plain_http": "拒绝在非回环地址上通过明文 HTTP 接收 Bearer 令牌；请使用 --tls-cert 和 --tls-key",
+	"error.agent_failed":           "密钥代理失败: %v",
 
 	// 错误信息 - 其他
-	"error.unknown_action":         "未知操作: %s (支持: export, import, verify, cache-info)",
-	"error.missing_required_flags": "必须提供 %s",
-	"error.missing_both_keys":      "必须提供 --public-key 和 --private-key",
-	"error.nothing_to_do":          "没有可执行的操作",
+	"error.invalid_key_format":        "无效的密钥格式: %s (支持: native, pkcs8, recipient)",
+	"error.unknown_action":            "未知操作: %s (支持: export, import, verify, cache-info, csr, selfsign, issue, paperkey, restore-paper, fingerprint)",
+	"error.certificate_failed":        "证书操作失败: %v",
+	"error.invalid_days":              "无效的有效期: %d 天",
+	"error.ca_not_configured":         "公钥是证书，但未配置受信任的 CA，请将 %s 设置为 CA 证书文件",
+	"error.paperkey_failed":           "纸质备份失败: %v",
+	"error.restore_paper_failed":      "还原纸质备份失败: %v",
+	"error.invalid_recipient":         "无效的收件人字符串: %v",
+	"error.recipient_public_only":     "recipient 格式只能编码公钥，不支持 --export-private",
+	"error.fingerprint_failed":        "计算指纹失败: %v",
+	"error.fingerprint_mismatch":      "指纹不一致，该密钥的指纹为 %s",
+	"error.fingerprint_duplicate_key": "%s 中的密钥类型已经给出，请只提供一个加密密钥和/或一个签名密钥",
+	"error.missing_required_flags":    "必须提供 %s",
+	"error.missing_both_keys":         "必须提供 --public-key 和 --private-key",
+	"error.nothing_to_do":             "没有可执行的操作",
 }
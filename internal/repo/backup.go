@@ -0,0 +1,367 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+)
+
+// BackupOptions 备份选项.
+type BackupOptions struct {
+	Tags []string // 快照标签
+}
+
+// BackupStats 备份统计.
+type BackupStats struct {
+	Files        int   // 文件数量
+	Dirs         int   // 目录数量
+	Unchanged    int   // 与上一快照相同而直接复用的文件数量
+	NewChunks    int   // 新写入的数据块
+	ReusedChunks int   // 已存在而复用的数据块
+	BytesRead    int64 // 读取的明文字节数
+	BytesAdded   int64 // 写入仓库的密文字节数
+}
+
+// Backup 将 source 目录备份为新快照.
+// 与同一源目录的上一快照相比大小、权限和修改时间都未变化的文件直接复用其块列表.
+//
+//nolint:funlen,gocognit // 遍历、增量判断与分块写入需要完整处理
+func (r *Repository) Backup(source string, signKey zjcrypto.SigningKey, opts BackupOptions) (*Snapshot, *BackupStats, error) {
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolve source: %w", err)
+	}
+	info, err := os.Stat(absSource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat source: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Backup source is not a directory: "+source)
+	}
+
+	if err := r.Lock(); err != nil {
+		return nil, nil, err
+	}
+	defer r.Unlock()
+
+	snapshots, err := r.ListSnapshots()
+	if err != nil {
+		return nil, nil, err
+	}
+	parent := make(map[string]*Node)
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i].Source == absSource {
+			for j := range snapshots[i].Tree {
+				node := &snapshots[i].Tree[j]
+				parent[node.Name] = node
+			}
+			break
+		}
+	}
+
+	srcRoot, err := os.OpenRoot(absSource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open source: %w", err)
+	}
+	defer func() {
+		_ = srcRoot.Close()
+	}()
+
+	stats := &BackupStats{}
+	written := make(map[string]bool)
+	host, _ := os.Hostname()
+	snap := &Snapshot{
+		Time:   time.Now().UTC(),
+		Host:   host,
+		Source: absSource,
+		Tags:   opts.Tags,
+	}
+
+	err = fs.WalkDir(srcRoot.FS(), ".", func(name string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if name == "." {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			//nolint:wrapcheck
+			return err
+		}
+
+		node := Node{
+			Name:    name,
+			Mode:    info.Mode().Perm(),
+			ModTime: info.ModTime().UnixNano(),
+		}
+		switch {
+		case d.IsDir():
+			node.IsDir = true
+			stats.Dirs++
+		case info.Mode().IsRegular():
+			node.Size = info.Size()
+			if prev, ok := parent[name]; ok && r.unchanged(prev, &node) {
+				node.Chunks = prev.Chunks
+				stats.Unchanged++
+				stats.ReusedChunks += len(prev.Chunks)
+			} else if node.Chunks, err = r.storeFile(srcRoot, name, stats, written); err != nil {
+				return err
+			}
+			stats.Files++
+		default:
+			// 符号链接和特殊文件不纳入备份
+			return nil
+		}
+		snap.Tree = append(snap.Tree, node)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("backup %s: %w", source, err)
+	}
+
+	if err := r.saveSnapshot(snap, signKey); err != nil {
+		return nil, nil, err
+	}
+	return snap, stats, nil
+}
+
+// unchanged 判断文件是否与上一快照中的记录一致，且其数据块仍然存在.
+func (r *Repository) unchanged(prev, node *Node) bool {
+	if prev.IsDir || prev.Size != node.Size || prev.Mode != node.Mode || prev.ModTime != node.ModTime {
+		return false
+	}
+	for _, id := range prev.Chunks {
+		if !r.hasChunk(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// storeFile 分块读取文件，仅写入仓库中尚不存在的数据块，返回块 ID 列表.
+func (r *Repository) storeFile(srcRoot *os.Root, name string, stats *BackupStats, written map[string]bool) ([]string, error) {
+	f, err := srcRoot.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var chunks []string
+	err = r.chunker.split(f, func(chunk []byte) error {
+		stats.BytesRead += int64(len(chunk))
+		id := r.chunkID(chunk)
+		chunks = append(chunks, id)
+		if written[id] || r.hasChunk(id) {
+			stats.ReusedChunks++
+			return nil
+		}
+		n, err := r.putChunk(id, chunk)
+		if err != nil {
+			return err
+		}
+		written[id] = true
+		stats.NewChunks++
+		stats.BytesAdded += n
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	return chunks, nil
+}
+
+// Restore 将快照恢复到 outputDir，only 非空时只恢复匹配的条目.
+// 返回恢复的文件数量.
+func (r *Repository) Restore(snap *Snapshot, outputDir string, only []string) (int, error) {
+	if err := zjcrypto.ValidateZipPatterns(only); err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(outputDir, dirPerm); err != nil {
+		return 0, fmt.Errorf("create output directory: %w", err)
+	}
+	outRoot, err := os.OpenRoot(outputDir)
+	if err != nil {
+		return 0, fmt.Errorf("open output directory: %w", err)
+	}
+	defer func() {
+		_ = outRoot.Close()
+	}()
+
+	restored := 0
+	var dirs []*Node
+	for i := range snap.Tree {
+		node := &snap.Tree[i]
+		if !zjcrypto.MatchZipPatterns(only, node.Name) {
+			continue
+		}
+		if node.IsDir {
+			if err := outRoot.MkdirAll(node.Name, dirPerm); err != nil {
+				return restored, fmt.Errorf("create %s: %w", node.Name, err)
+			}
+			dirs = append(dirs, node)
+			continue
+		}
+		if dir := path.Dir(node.Name); dir != "." {
+			if err := outRoot.MkdirAll(dir, dirPerm); err != nil {
+				return restored, fmt.Errorf("create %s: %w", dir, err)
+			}
+		}
+		if err := r.restoreFile(outRoot, node); err != nil {
+			return restored, err
+		}
+		restored++
+	}
+
+	// 目录的权限和修改时间在其内容写完后再设置，由深到浅处理
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].Name > dirs[j].Name
+	})
+	for _, node := range dirs {
+		modTime := time.Unix(0, node.ModTime)
+		_ = outRoot.Chtimes(node.Name, modTime, modTime)
+		_ = outRoot.Chmod(node.Name, node.Mode|0700)
+	}
+	return restored, nil
+}
+
+// restoreFile 按块列表写出单个文件并恢复权限和修改时间.
+func (r *Repository) restoreFile(outRoot *os.Root, node *Node) (err error) {
+	f, err := outRoot.OpenFile(node.Name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, objectPerm)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", node.Name, err)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("close %s: %w", node.Name, closeErr)
+		}
+	}()
+
+	var size int64
+	for _, id := range node.Chunks {
+		chunk, err := r.getChunk(id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", node.Name, err)
+		}
+		if _, err := f.Write(chunk); err != nil {
+			return fmt.Errorf("write %s: %w", node.Name, err)
+		}
+		size += int64(len(chunk))
+	}
+	if size != node.Size {
+		return utils.NewCryptoError(
+			utils.ErrHashMismatch,
+			fmt.Sprintf("%s: restored %d bytes, snapshot records %d", node.Name, size, node.Size),
+		)
+	}
+
+	_ = f.Chmod(node.Mode)
+	modTime := time.Unix(0, node.ModTime)
+	_ = outRoot.Chtimes(node.Name, modTime, modTime)
+	return nil
+}
+
+// PruneOptions 清理选项.
+type PruneOptions struct {
+	KeepLast int      // 每个源目录保留的最新快照数，0 表示不按数量删除
+	Forget   []string // 额外删除的快照（ID 或唯一前缀）
+}
+
+// PruneStats 清理统计.
+type PruneStats struct {
+	SnapshotsRemoved int   // 删除的快照数量
+	SnapshotsKept    int   // 保留的快照数量
+	ChunksRemoved    int   // 删除的数据块数量
+	BytesFreed       int64 // 释放的字节数
+}
+
+// Prune 删除超出保留策略或被指定的快照，再删除不再被任何快照引用的数据块.
+// 任何快照无法读取时中止，避免误删仍被引用的数据.
+func (r *Repository) Prune(opts PruneOptions) (*PruneStats, error) {
+	if opts.KeepLast < 0 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "keep-last must not be negative")
+	}
+	if err := r.Lock(); err != nil {
+		return nil, err
+	}
+	defer r.Unlock()
+
+	snapshots, err := r.ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	remove := make(map[string]bool)
+	for _, ref := range opts.Forget {
+		snap, err := findSnapshot(snapshots, ref)
+		if err != nil {
+			return nil, err
+		}
+		remove[snap.ID] = true
+	}
+	if opts.KeepLast > 0 {
+		kept := make(map[string]int)
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			snap := snapshots[i]
+			if remove[snap.ID] {
+				continue
+			}
+			if kept[snap.Source] >= opts.KeepLast {
+				remove[snap.ID] = true
+				continue
+			}
+			kept[snap.Source]++
+		}
+	}
+
+	stats := &PruneStats{}
+	referenced := make(map[string]bool)
+	for _, snap := range snapshots {
+		if remove[snap.ID] {
+			if err := r.root.Remove(snapshotPath(snap.ID)); err != nil {
+				return stats, fmt.Errorf("remove snapshot %s: %w", snap.ShortID(), err)
+			}
+			stats.SnapshotsRemoved++
+			continue
+		}
+		stats.SnapshotsKept++
+		for i := range snap.Tree {
+			for _, id := range snap.Tree[i].Chunks {
+				referenced[id] = true
+			}
+		}
+	}
+
+	err = fs.WalkDir(r.root.FS(), DataDir, func(name string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil || d.IsDir() {
+			return walkErr
+		}
+		id := path.Base(name)
+		if referenced[id] {
+			return nil
+		}
+		info, err := d.Info()
+		if err == nil {
+			stats.BytesFreed += info.Size()
+		}
+		if err := r.root.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove %s: %w", name, err)
+		}
+		stats.ChunksRemoved++
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("prune data: %w", err)
+	}
+	return stats, nil
+}
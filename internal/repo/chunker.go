@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// chunker 基于 Gear 滚动哈希的内容定义分块（FastCDC 归一化分块）.
+// Gear 表由仓库主密钥派生，块边界不会泄露可用于识别已知文件的指纹.
+type chunker struct {
+	gear    [256]uint64
+	minSize int
+	avgSize int
+	maxSize int
+	maskS   uint64 // 未达到平均大小前使用的严格掩码
+	maskL   uint64 // 超过平均大小后使用的宽松掩码
+}
+
+// newChunker 由种子生成 Gear 表，avgSize 必须为 2 的幂.
+func newChunker(seed []byte, minSize, avgSize, maxSize int) *chunker {
+	c := &chunker{minSize: minSize, avgSize: avgSize, maxSize: maxSize}
+	for i := range c.gear {
+		h := sha256.Sum256(append(append([]byte{}, seed...), byte(i)))
+		c.gear[i] = binary.LittleEndian.Uint64(h[:8])
+	}
+
+	// 使用高位掩码：Gear 哈希的高位受最近 64 字节影响，低位只取决于最后几个字节
+	avgBits := bits.TrailingZeros(uint(avgSize))
+	c.maskS = ^uint64(0) << (64 - (avgBits + 1))
+	c.maskL = ^uint64(0) << (64 - (avgBits - 1))
+	return c
+}
+
+// cut 返回 data 中第一个块的长度.
+// 调用方需保证 data 至少包含 maxSize 字节，或已到达输入末尾.
+func (c *chunker) cut(data []byte) int {
+	n := len(data)
+	if n <= c.minSize {
+		return n
+	}
+	n = min(n, c.maxSize)
+	normal := min(c.avgSize, n)
+
+	var h uint64
+	i := c.minSize
+	for ; i < normal; i++ {
+		h = (h << 1) + c.gear[data[i]]
+		if h&c.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		h = (h << 1) + c.gear[data[i]]
+		if h&c.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// split 从 r 读取数据并按内容定义的边界依次交给 fn.
+// 传给 fn 的切片在下一次调用前有效，不应被保留.
+func (c *chunker) split(r io.Reader, fn func(chunk []byte) error) error {
+	buf := make([]byte, c.maxSize)
+	n := 0
+	eof := false
+	for {
+		if !eof && n < len(buf) {
+			m, err := io.ReadFull(r, buf[n:])
+			n += m
+			switch {
+			case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+				eof = true
+			case err != nil:
+				//nolint:wrapcheck
+				return err
+			}
+		}
+		if n == 0 {
+			return nil
+		}
+
+		size := c.cut(buf[:n])
+		if err := fn(buf[:size]); err != nil {
+			return err
+		}
+		n = copy(buf, buf[size:n])
+	}
+}
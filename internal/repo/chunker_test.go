@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func collectChunks(t *testing.T, c *chunker, data []byte) [][]byte {
+	t.Helper()
+	var chunks [][]byte
+	err := c.split(bytes.NewReader(data), func(chunk []byte) error {
+		chunks = append(chunks, append([]byte{}, chunk...))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("分块失败: %v", err)
+	}
+	return chunks
+}
+
+func TestChunkerBounds(t *testing.T) {
+	c := newChunker([]byte("seed"), 256, 1024, 4096)
+	data := make([]byte, 200*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	chunks := collectChunks(t, c, data)
+	if !bytes.Equal(bytes.Join(chunks, nil), data) {
+		t.Fatal("分块拼接后应与原数据一致")
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > 4096 {
+			t.Errorf("第 %d 块超过最大大小: %d", i, len(chunk))
+		}
+		if len(chunk) < 256 && i != len(chunks)-1 {
+			t.Errorf("第 %d 块小于最小大小: %d", i, len(chunk))
+		}
+	}
+	avg := len(data) / len(chunks)
+	if avg < 512 || avg > 2048 {
+		t.Errorf("平均块大小偏离预期: %d", avg)
+	}
+
+	if chunks := collectChunks(t, c, nil); len(chunks) != 0 {
+		t.Errorf("空输入不应产生数据块: %d", len(chunks))
+	}
+}
+
+// 在数据开头插入字节后，后续的块边界应重新对齐，大部分块保持不变.
+func TestChunkerShiftResistance(t *testing.T) {
+	c := newChunker([]byte("seed"), 256, 1024, 4096)
+	data := make([]byte, 100*1024)
+	rand.New(rand.NewSource(2)).Read(data)
+
+	before := make(map[string]bool)
+	for _, chunk := range collectChunks(t, c, data) {
+		before[string(chunk)] = true
+	}
+
+	shifted := append([]byte("inserted prefix"), data...)
+	after := collectChunks(t, c, shifted)
+	shared := 0
+	for _, chunk := range after {
+		if before[string(chunk)] {
+			shared++
+		}
+	}
+	if shared < len(after)*8/10 {
+		t.Errorf("插入数据后只有 %d/%d 个块保持不变", shared, len(after))
+	}
+}
+
+func TestChunkerSeedChangesBoundaries(t *testing.T) {
+	data := make([]byte, 64*1024)
+	rand.New(rand.NewSource(3)).Read(data)
+
+	a := collectChunks(t, newChunker([]byte("seed-a"), 256, 1024, 4096), data)
+	b := collectChunks(t, newChunker([]byte("seed-b"), 256, 1024, 4096), data)
+	if len(a) == len(b) {
+		same := true
+		for i := range a {
+			if len(a[i]) != len(b[i]) {
+				same = false
+				break
+			}
+		}
+		if same {
+			t.Error("不同种子应产生不同的块边界")
+		}
+	}
+}
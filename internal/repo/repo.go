@@ -0,0 +1,435 @@
+// Package repo 实现去重的加密备份仓库.
+//
+// 仓库布局:
+//
+//	config                     仓库配置（JSON，明文）
+//	keys/<公钥指纹>.fzj         加密给各授权公钥的主密钥
+//	data/<ID 前两位>/<块 ID>    加密的数据块
+//	snapshots/<快照 ID>         加密的签名快照
+//	lock                       写操作期间的排它锁
+//
+// 主密钥通过 HKDF 派生出块寻址密钥、对象加密密钥和分块种子.
+// 块 ID 为明文的 HMAC-SHA256，相同内容只存储一次，且不会泄露明文哈希.
+package repo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+)
+
+const (
+	// ConfigName 仓库配置文件名.
+	ConfigName = "config"
+	// KeysDir 主密钥目录.
+	KeysDir = "keys"
+	// DataDir 数据块目录.
+	DataDir = "data"
+	// SnapshotsDir 快照目录.
+	SnapshotsDir = "snapshots"
+	// LockName 排它锁文件名.
+	LockName = "lock"
+
+	// RepoVersion 仓库格式版本.
+	RepoVersion = 1
+
+	masterKeySize = 32
+	keyFileExt    = ".fzj"
+	objectPerm    = 0600
+	dirPerm       = 0700
+
+	labelChunkID = "fzjjyz repo chunk id"
+	labelEncrypt = "fzjjyz repo object encryption"
+	labelChunker = "fzjjyz repo chunker"
+)
+
+// InitOptions 仓库初始化选项.
+type InitOptions struct {
+	MinChunkSize int // 最小块大小
+	AvgChunkSize int // 平均块大小（2 的幂）
+	MaxChunkSize int // 最大块大小
+}
+
+// DefaultInitOptions 默认初始化选项（512KB / 1MB / 8MB）.
+var DefaultInitOptions = InitOptions{
+	MinChunkSize: 512 * 1024,
+	AvgChunkSize: 1024 * 1024,
+	MaxChunkSize: 8 * 1024 * 1024,
+}
+
+// Config 仓库配置.
+type Config struct {
+	Version      int       `json:"version"`
+	ID           string    `json:"id"`
+	Created      time.Time `json:"created"`
+	MinChunkSize int       `json:"min_chunk_size"`
+	AvgChunkSize int       `json:"avg_chunk_size"`
+	MaxChunkSize int       `json:"max_chunk_size"`
+}
+
+// Repository 已解锁的仓库.
+type Repository struct {
+	root      *os.Root
+	config    Config
+	idKey     []byte
+	aead      cipher.AEAD
+	chunker   *chunker
+	verifyKey zjcrypto.VerifyingKey
+}
+
+// validate 检查分块参数.
+func (c *Config) validate() error {
+	if c.Version != RepoVersion {
+		return utils.NewCryptoError(utils.ErrVersionMismatch, fmt.Sprintf("Unsupported repository version %d", c.Version))
+	}
+	if c.ID == "" {
+		return utils.NewCryptoError(utils.ErrInvalidFormat, "Repository ID is missing")
+	}
+	return validateChunkSizes(c.MinChunkSize, c.AvgChunkSize, c.MaxChunkSize)
+}
+
+func validateChunkSizes(minSize, avgSize, maxSize int) error {
+	if minSize < 64 || avgSize&(avgSize-1) != 0 || minSize >= avgSize || avgSize >= maxSize || maxSize > 64*1024*1024 {
+		return utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Invalid chunk sizes %d/%d/%d (need 64 <= min < avg < max <= 64MB, avg a power of two)",
+				minSize, avgSize, maxSize),
+		)
+	}
+	return nil
+}
+
+// Init 在 dir 创建新仓库，为每个授权公钥封装一份主密钥.
+// signKey 对封装后的主密钥签名，打开仓库时可用对应公钥验证.
+func Init(
+	dir string,
+	recipients []*zjcrypto.HybridPublicKey,
+	signKey zjcrypto.SigningKey,
+	opts InitOptions,
+) (*Config, error) {
+	if len(recipients) == 0 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "At least one public key is required")
+	}
+	if err := validateChunkSizes(opts.MinChunkSize, opts.AvgChunkSize, opts.MaxChunkSize); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, fmt.Errorf("create repository directory: %w", err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open repository directory: %w", err)
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+	if _, err := root.Stat(ConfigName); err == nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Repository already exists: "+dir)
+	}
+
+	id := make([]byte, 16)
+	master := make([]byte, masterKeySize)
+	if _, err := rand.Read(id); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrSystem, "Failed to generate repository ID: "+err.Error())
+	}
+	if _, err := rand.Read(master); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrKeyGenerationFailed, "Failed to generate master key: "+err.Error())
+	}
+
+	for _, sub := range []string{KeysDir, DataDir, SnapshotsDir} {
+		if err := root.MkdirAll(sub, dirPerm); err != nil {
+			return nil, fmt.Errorf("create %s: %w", sub, err)
+		}
+	}
+	for _, pub := range recipients {
+		if err := writeMasterKey(root, master, pub, signKey); err != nil {
+			return nil, err
+		}
+	}
+
+	config := &Config{
+		Version:      RepoVersion,
+		ID:           hex.EncodeToString(id),
+		Created:      time.Now().UTC(),
+		MinChunkSize: opts.MinChunkSize,
+		AvgChunkSize: opts.AvgChunkSize,
+		MaxChunkSize: opts.MaxChunkSize,
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+	// 配置最后写入，中途失败的目录不会被识别为仓库
+	if err := writeFileAtomic(root, ConfigName, data); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// writeMasterKey 将主密钥加密给 pub，文件名为公钥指纹.
+func writeMasterKey(root *os.Root, master []byte, pub *zjcrypto.HybridPublicKey, signKey zjcrypto.SigningKey) error {
+	fingerprint, err := zjcrypto.RecipientFingerprint(pub.Kyber, pub.ECDH)
+	if err != nil {
+		return err
+	}
+	data, err := zjcrypto.EncryptData(master, "master.key", pub.Kyber, pub.ECDH, signKey, zjcrypto.NoCompression)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(root, path.Join(KeysDir, fingerprint+keyFileExt), data)
+}
+
+// Open 打开仓库并用 priv 解锁主密钥.
+// verifyKey 非空时验证主密钥和快照的签名，防止仓库被替换为他人持有的密钥.
+func Open(dir string, priv *zjcrypto.HybridPrivateKey, verifyKey zjcrypto.VerifyingKey) (*Repository, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open repository: %w", err)
+	}
+	repo, err := openRoot(root, priv, verifyKey)
+	if err != nil {
+		_ = root.Close()
+		return nil, err
+	}
+	return repo, nil
+}
+
+func openRoot(root *os.Root, priv *zjcrypto.HybridPrivateKey, verifyKey zjcrypto.VerifyingKey) (*Repository, error) {
+	data, err := root.ReadFile(ConfigName)
+	if err != nil {
+		return nil, fmt.Errorf("read repository config: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid repository config: "+err.Error())
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	master, err := unlockMasterKey(root, priv, verifyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := []byte(config.ID)
+	idKey, err := hkdf.Key(sha256.New, master, salt, labelChunkID, 32)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrKeyGenerationFailed, "Key derivation failed: "+err.Error())
+	}
+	encKey, err := hkdf.Key(sha256.New, master, salt, labelEncrypt, 32)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrKeyGenerationFailed, "Key derivation failed: "+err.Error())
+	}
+	seed, err := hkdf.Key(sha256.New, master, salt, labelChunker, 32)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrKeyGenerationFailed, "Key derivation failed: "+err.Error())
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create GCM: %w", err)
+	}
+
+	return &Repository{
+		root:      root,
+		config:    config,
+		idKey:     idKey,
+		aead:      aead,
+		chunker:   newChunker(seed, config.MinChunkSize, config.AvgChunkSize, config.MaxChunkSize),
+		verifyKey: verifyKey,
+	}, nil
+}
+
+// unlockMasterKey 依次尝试 keys/ 下的主密钥文件，返回第一个能用 priv 解密的主密钥.
+func unlockMasterKey(root *os.Root, priv *zjcrypto.HybridPrivateKey, verifyKey zjcrypto.VerifyingKey) ([]byte, error) {
+	entries, err := fs.ReadDir(root.FS(), KeysDir)
+	if err != nil {
+		return nil, fmt.Errorf("read repository keys: %w", err)
+	}
+
+	// 优先尝试与私钥对应的指纹文件
+	var candidates []string
+	if pub, err := publicFromPrivate(priv); err == nil {
+		if fingerprint, err := zjcrypto.RecipientFingerprint(pub.Kyber, pub.ECDH); err == nil {
+			candidates = append(candidates, fingerprint+keyFileExt)
+		}
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), keyFileExt) {
+			candidates = append(candidates, entry.Name())
+		}
+	}
+
+	var lastErr error
+	for _, name := range candidates {
+		data, err := root.ReadFile(path.Join(KeysDir, name))
+		if err != nil {
+			continue
+		}
+		master, err := zjcrypto.DecryptDataCore(data, priv.Kyber, priv.ECDH, verifyKey)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(master) != masterKeySize {
+			lastErr = utils.NewCryptoError(utils.ErrInvalidKey, "Invalid master key length")
+			continue
+		}
+		return master, nil
+	}
+	// 能解密但签名不符时明确报告，而不是笼统地提示没有可用密钥
+	if ce := (*utils.CryptoError)(nil); errors.As(lastErr, &ce) && ce.Code == utils.ErrSignatureVerification {
+		return nil, lastErr
+	}
+	return nil, utils.NewCryptoError(utils.ErrInvalidKey, "No repository key can be unlocked with this private key")
+}
+
+// publicFromPrivate 由混合私钥推导公钥，用于定位主密钥文件.
+func publicFromPrivate(priv *zjcrypto.HybridPrivateKey) (*zjcrypto.HybridPublicKey, error) {
+	//nolint:wrapcheck
+	return zjcrypto.HybridPublicFromPrivate(priv)
+}
+
+// Config 返回仓库配置.
+func (r *Repository) Config() Config {
+	return r.config
+}
+
+// Close 关闭仓库.
+func (r *Repository) Close() error {
+	//nolint:wrapcheck
+	return r.root.Close()
+}
+
+// Lock 创建排它锁文件，防止备份与清理同时修改仓库.
+func (r *Repository) Lock() error {
+	f, err := r.root.OpenFile(LockName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, objectPerm)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return utils.NewCryptoError(
+				utils.ErrInvalidParameter,
+				"Repository is locked by another operation (remove the lock file if it is stale)",
+			)
+		}
+		return fmt.Errorf("create lock: %w", err)
+	}
+	host, _ := os.Hostname()
+	_, _ = fmt.Fprintf(f, "%s %d %s\n", host, os.Getpid(), time.Now().UTC().Format(time.RFC3339))
+	//nolint:wrapcheck
+	return f.Close()
+}
+
+// Unlock 删除排它锁文件.
+func (r *Repository) Unlock() {
+	_ = r.root.Remove(LockName)
+}
+
+// chunkID 计算数据块的寻址 ID.
+func (r *Repository) chunkID(data []byte) string {
+	mac := hmac.New(sha256.New, r.idKey)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// chunkPath 返回数据块在仓库中的相对路径.
+func chunkPath(id string) string {
+	return path.Join(DataDir, id[:2], id)
+}
+
+// seal 加密对象，name 作为附加数据，防止对象被调换位置.
+func (r *Repository) seal(name string, plain []byte) ([]byte, error) {
+	nonce := make([]byte, r.aead.NonceSize(), r.aead.NonceSize()+len(plain)+r.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrSystem, "Failed to generate nonce: "+err.Error())
+	}
+	return r.aead.Seal(nonce, nonce, plain, []byte(name)), nil
+}
+
+// open 解密对象.
+func (r *Repository) open(name string, data []byte) ([]byte, error) {
+	if len(data) < r.aead.NonceSize()+r.aead.Overhead() {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Object too short: "+name)
+	}
+	nonceSize := r.aead.NonceSize()
+	plain, err := r.aead.Open(nil, data[:nonceSize], data[nonceSize:], []byte(name))
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrAuthFailed, "Object authentication failed: "+name)
+	}
+	return plain, nil
+}
+
+// hasChunk 判断数据块是否已存在.
+func (r *Repository) hasChunk(id string) bool {
+	_, err := r.root.Stat(chunkPath(id))
+	return err == nil
+}
+
+// putChunk 加密并写入数据块，返回写入的字节数.
+func (r *Repository) putChunk(id string, data []byte) (int64, error) {
+	name := chunkPath(id)
+	sealed, err := r.seal(name, data)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.root.MkdirAll(path.Dir(name), dirPerm); err != nil {
+		return 0, fmt.Errorf("create chunk directory: %w", err)
+	}
+	if err := writeFileAtomic(r.root, name, sealed); err != nil {
+		return 0, err
+	}
+	return int64(len(sealed)), nil
+}
+
+// getChunk 读取并解密数据块，同时校验内容与 ID 一致.
+func (r *Repository) getChunk(id string) ([]byte, error) {
+	if len(id) != sha256.Size*2 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid chunk ID: "+id)
+	}
+	name := chunkPath(id)
+	data, err := r.root.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("read chunk %s: %w", id, err)
+	}
+	plain, err := r.open(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(r.chunkID(plain)), []byte(id)) {
+		return nil, utils.NewCryptoError(utils.ErrHashMismatch, "Chunk content does not match its ID: "+id)
+	}
+	return plain, nil
+}
+
+// writeFileAtomic 先写临时文件再重命名，避免中断时留下不完整的对象.
+func writeFileAtomic(root *os.Root, name string, data []byte) error {
+	tmpName := name + ".tmp"
+	if err := root.WriteFile(tmpName, data, objectPerm); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := root.Rename(tmpName, name); err != nil {
+		_ = root.Remove(tmpName)
+		return fmt.Errorf("rename %s: %w", name, err)
+	}
+	return nil
+}
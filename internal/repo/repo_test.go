@@ -0,0 +1,333 @@
+package repo
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+// testInitOptions 使用较小的块，便于在小文件上验证去重.
+var testInitOptions = InitOptions{MinChunkSize: 1024, AvgChunkSize: 4096, MaxChunkSize: 16384}
+
+type testIdentity struct {
+	pub      *zjcrypto.HybridPublicKey
+	priv     *zjcrypto.HybridPrivateKey
+	signPub  *mode3.PublicKey
+	signPriv *mode3.PrivateKey
+}
+
+func newTestIdentity(t *testing.T) testIdentity {
+	t.Helper()
+	kyberPub, kyberPriv, err := zjcrypto.GenerateKyberKeys()
+	if err != nil {
+		t.Fatalf("生成 Kyber 密钥失败: %v", err)
+	}
+	ecdhPub, ecdhPriv, err := zjcrypto.GenerateECDHKeys()
+	if err != nil {
+		t.Fatalf("生成 ECDH 密钥失败: %v", err)
+	}
+	signPub, signPriv, err := zjcrypto.GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatalf("生成 Dilithium 密钥失败: %v", err)
+	}
+	return testIdentity{
+		pub:      &zjcrypto.HybridPublicKey{Kyber: kyberPub, ECDH: ecdhPub},
+		priv:     &zjcrypto.HybridPrivateKey{Kyber: kyberPriv, ECDH: ecdhPriv},
+		signPub:  signPub,
+		signPriv: signPriv,
+	}
+}
+
+func createBackupSource(t *testing.T) (string, map[string][]byte) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "src")
+	large := make([]byte, 100*1024)
+	rand.New(rand.NewSource(7)).Read(large)
+	files := map[string][]byte{
+		"README.md":         []byte("# project"),
+		"data/large.bin":    large,
+		"data/empty.txt":    {},
+		"docs/guide/use.md": []byte("usage"),
+	}
+	for name, data := range files {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0750); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := os.WriteFile(full, data, 0640); err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+	}
+	return dir, files
+}
+
+func initTestRepo(t *testing.T, owner testIdentity, others ...testIdentity) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "repo")
+	recipients := []*zjcrypto.HybridPublicKey{owner.pub}
+	for _, other := range others {
+		recipients = append(recipients, other.pub)
+	}
+	if _, err := Init(dir, recipients, owner.signPriv, testInitOptions); err != nil {
+		t.Fatalf("初始化仓库失败: %v", err)
+	}
+	return dir
+}
+
+func openTestRepo(t *testing.T, dir string, id testIdentity) *Repository {
+	t.Helper()
+	r, err := Open(dir, id.priv, id.signPub)
+	if err != nil {
+		t.Fatalf("打开仓库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = r.Close()
+	})
+	return r
+}
+
+func TestInitAndOpen(t *testing.T) {
+	owner := newTestIdentity(t)
+	member := newTestIdentity(t)
+	stranger := newTestIdentity(t)
+	dir := initTestRepo(t, owner, member)
+
+	if _, err := Init(dir, []*zjcrypto.HybridPublicKey{owner.pub}, owner.signPriv, testInitOptions); err == nil {
+		t.Error("重复初始化应返回错误")
+	}
+
+	openTestRepo(t, dir, owner)
+	if r, err := Open(dir, member.priv, owner.signPub); err != nil {
+		t.Errorf("授权成员应能打开仓库: %v", err)
+	} else {
+		_ = r.Close()
+	}
+	if _, err := Open(dir, stranger.priv, nil); err == nil {
+		t.Error("未授权的私钥不应打开仓库")
+	}
+	if _, err := Open(dir, owner.priv, stranger.signPub); err == nil {
+		t.Error("主密钥签名与验证公钥不符时应拒绝打开")
+	}
+
+	bad := testInitOptions
+	bad.AvgChunkSize = 3000
+	if _, err := Init(filepath.Join(t.TempDir(), "bad"), []*zjcrypto.HybridPublicKey{owner.pub}, owner.signPriv, bad); err == nil {
+		t.Error("非 2 的幂的平均块大小应被拒绝")
+	}
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	owner := newTestIdentity(t)
+	r := openTestRepo(t, initTestRepo(t, owner), owner)
+	source, files := createBackupSource(t)
+
+	snap, stats, err := r.Backup(source, owner.signPriv, BackupOptions{Tags: []string{"nightly"}})
+	if err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+	if stats.Files != len(files) || stats.NewChunks == 0 {
+		t.Errorf("备份统计不正确: %+v", stats)
+	}
+
+	found, err := r.FindSnapshot(snap.ShortID())
+	if err != nil || found.ID != snap.ID || found.Tags[0] != "nightly" {
+		t.Fatalf("按前缀查找快照失败: %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "restore")
+	count, err := r.Restore(found, out, nil)
+	if err != nil {
+		t.Fatalf("恢复失败: %v", err)
+	}
+	if count != len(files) {
+		t.Errorf("期望恢复 %d 个文件，实际 %d", len(files), count)
+	}
+	for name, data := range files {
+		got, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(name))) // #nosec G304 - 测试环境使用临时文件路径
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%s 恢复内容不一致: %v", name, err)
+		}
+	}
+	info, err := os.Stat(filepath.Join(out, "README.md"))
+	if err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("应恢复文件权限: %v %v", info.Mode(), err)
+	}
+
+	partial := filepath.Join(t.TempDir(), "partial")
+	if count, err := r.Restore(found, partial, []string{"docs/**"}); err != nil || count != 1 {
+		t.Errorf("选择性恢复失败: %d %v", count, err)
+	}
+	if _, err := os.Stat(filepath.Join(partial, "README.md")); err == nil {
+		t.Error("未匹配的文件不应被恢复")
+	}
+}
+
+func TestBackupDeduplicates(t *testing.T) {
+	owner := newTestIdentity(t)
+	r := openTestRepo(t, initTestRepo(t, owner), owner)
+	source, _ := createBackupSource(t)
+
+	_, first, err := r.Backup(source, owner.signPriv, BackupOptions{})
+	if err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+
+	// 未变化的文件直接复用上一快照
+	_, second, err := r.Backup(source, owner.signPriv, BackupOptions{})
+	if err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+	if second.NewChunks != 0 || second.Unchanged != second.Files || second.BytesRead != 0 {
+		t.Errorf("未变化的目录不应写入新数据: %+v", second)
+	}
+
+	// 复制到另一目录：没有上一快照可复用，但内容相同的块不会重复写入
+	copyDir := filepath.Join(t.TempDir(), "copy")
+	if err := os.CopyFS(copyDir, os.DirFS(source)); err != nil {
+		t.Fatalf("复制目录失败: %v", err)
+	}
+	_, third, err := r.Backup(copyDir, owner.signPriv, BackupOptions{})
+	if err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+	if third.NewChunks != 0 || third.ReusedChunks != first.NewChunks+first.ReusedChunks {
+		t.Errorf("相同内容应全部去重: 首次 %+v，复制 %+v", first, third)
+	}
+
+	// 在大文件中间插入数据，只有受影响的块需要重新写入
+	large := filepath.Join(source, "data", "large.bin")
+	data, err := os.ReadFile(large) // #nosec G304 - 测试环境使用临时文件路径
+	if err != nil {
+		t.Fatalf("读取文件失败: %v", err)
+	}
+	edited := append(append(append([]byte{}, data[:50000]...), []byte("edit")...), data[50000:]...)
+	if err := os.WriteFile(large, edited, 0640); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	_, fourth, err := r.Backup(source, owner.signPriv, BackupOptions{})
+	if err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+	if fourth.NewChunks == 0 || fourth.NewChunks > 3 {
+		t.Errorf("局部修改只应产生少量新块: %+v", fourth)
+	}
+}
+
+func TestRestoreDetectsTampering(t *testing.T) {
+	owner := newTestIdentity(t)
+	dir := initTestRepo(t, owner)
+	r := openTestRepo(t, dir, owner)
+	source, _ := createBackupSource(t)
+
+	snap, _, err := r.Backup(source, owner.signPriv, BackupOptions{})
+	if err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+
+	var chunk string
+	for _, node := range snap.Tree {
+		if node.Name == "data/large.bin" {
+			chunk = node.Chunks[0]
+		}
+	}
+	chunkFile := filepath.Join(dir, filepath.FromSlash(chunkPath(chunk)))
+	data, err := os.ReadFile(chunkFile) // #nosec G304 - 测试环境使用临时文件路径
+	if err != nil {
+		t.Fatalf("读取数据块失败: %v", err)
+	}
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(chunkFile, data, 0600); err != nil {
+		t.Fatalf("写入数据块失败: %v", err)
+	}
+
+	if _, err := r.Restore(snap, filepath.Join(t.TempDir(), "out"), nil); err == nil {
+		t.Error("被篡改的数据块应导致恢复失败")
+	}
+
+	// 使用其他签名公钥打开时快照签名验证失败
+	other := newTestIdentity(t)
+	unverified, err := Open(dir, owner.priv, nil)
+	if err != nil {
+		t.Fatalf("打开仓库失败: %v", err)
+	}
+	defer func() {
+		_ = unverified.Close()
+	}()
+	unverified.verifyKey = other.signPub
+	if _, err := unverified.LoadSnapshot(snap.ID); err == nil {
+		t.Error("签名不符的快照应被拒绝")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	owner := newTestIdentity(t)
+	dir := initTestRepo(t, owner)
+	r := openTestRepo(t, dir, owner)
+	source, _ := createBackupSource(t)
+
+	first, _, err := r.Backup(source, owner.signPriv, BackupOptions{})
+	if err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+	if err := os.Remove(filepath.Join(source, "data", "large.bin")); err != nil {
+		t.Fatalf("删除文件失败: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	second, _, err := r.Backup(source, owner.signPriv, BackupOptions{})
+	if err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+
+	stats, err := r.Prune(PruneOptions{KeepLast: 1})
+	if err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+	if stats.SnapshotsRemoved != 1 || stats.SnapshotsKept != 1 || stats.ChunksRemoved == 0 {
+		t.Errorf("清理统计不正确: %+v", stats)
+	}
+	if _, err := r.LoadSnapshot(first.ID); err == nil {
+		t.Error("旧快照应被删除")
+	}
+	if _, err := r.Restore(second, filepath.Join(t.TempDir(), "out"), nil); err != nil {
+		t.Errorf("保留的快照应仍可恢复: %v", err)
+	}
+
+	stats, err = r.Prune(PruneOptions{Forget: []string{second.ShortID()}})
+	if err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+	snapshots, _ := r.ListSnapshots()
+	if stats.SnapshotsRemoved != 1 || len(snapshots) != 0 {
+		t.Errorf("指定的快照应被删除: %+v", stats)
+	}
+	entries, _ := filepath.Glob(filepath.Join(dir, DataDir, "*", "*"))
+	if len(entries) != 0 {
+		t.Errorf("没有快照时所有数据块都应被删除: %v", entries)
+	}
+}
+
+func TestLock(t *testing.T) {
+	owner := newTestIdentity(t)
+	r := openTestRepo(t, initTestRepo(t, owner), owner)
+	source, _ := createBackupSource(t)
+
+	if err := r.Lock(); err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+	if _, _, err := r.Backup(source, owner.signPriv, BackupOptions{}); err == nil {
+		t.Error("仓库被锁定时备份应失败")
+	}
+	r.Unlock()
+	if _, _, err := r.Backup(source, owner.signPriv, BackupOptions{}); err != nil {
+		t.Errorf("解锁后备份应成功: %v", err)
+	}
+	if _, err := r.FindSnapshot("ffffffff"); err == nil {
+		t.Error("不存在的快照应返回错误")
+	}
+}
@@ -0,0 +1,201 @@
+package repo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+)
+
+// LatestSnapshot 表示最新快照的引用名.
+const LatestSnapshot = "latest"
+
+// Node 快照树中的单个条目.
+type Node struct {
+	Name    string      `json:"name"` // 相对路径（使用 / 分隔）
+	IsDir   bool        `json:"dir,omitempty"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime int64       `json:"mtime"` // Unix 纳秒
+	Size    int64       `json:"size"`
+	Chunks  []string    `json:"chunks,omitempty"`
+}
+
+// Snapshot 一次备份的签名树清单.
+type Snapshot struct {
+	ID     string    `json:"-"`
+	Time   time.Time `json:"time"`
+	Host   string    `json:"host"`
+	Source string    `json:"source"`
+	Tags   []string  `json:"tags,omitempty"`
+	Tree   []Node    `json:"tree"`
+}
+
+// Files 返回快照中的文件数量.
+func (s *Snapshot) Files() int {
+	count := 0
+	for i := range s.Tree {
+		if !s.Tree[i].IsDir {
+			count++
+		}
+	}
+	return count
+}
+
+// Size 返回快照中文件的明文总大小.
+func (s *Snapshot) Size() int64 {
+	var total int64
+	for i := range s.Tree {
+		total += s.Tree[i].Size
+	}
+	return total
+}
+
+// ShortID 返回快照 ID 的前 8 位.
+func (s *Snapshot) ShortID() string {
+	return s.ID[:min(8, len(s.ID))]
+}
+
+func snapshotPath(id string) string {
+	return path.Join(SnapshotsDir, id)
+}
+
+// saveSnapshot 签名、加密并写入快照，并为其分配快照 ID.
+// 快照正文为 JSON，签名以清单签名块的形式附在正文之后.
+func (r *Repository) saveSnapshot(snap *Snapshot, signKey zjcrypto.SigningKey) error {
+	body, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+	body = append(body, '\n')
+	signature, err := zjcrypto.SignDataWithKey(body, signKey)
+	if err != nil {
+		return err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return utils.NewCryptoError(utils.ErrSystem, "Failed to generate snapshot ID: "+err.Error())
+	}
+	snap.ID = hex.EncodeToString(id)
+
+	name := snapshotPath(snap.ID)
+	sealed, err := r.seal(name, format.EncodeSignedManifest(body, signature))
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.root, name, sealed)
+}
+
+// LoadSnapshot 读取并解密快照；打开仓库时提供了验证公钥则同时验证签名.
+func (r *Repository) LoadSnapshot(id string) (*Snapshot, error) {
+	if !isObjectID(id) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Invalid snapshot ID: "+id)
+	}
+	name := snapshotPath(id)
+	data, err := r.root.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot %s: %w", id, err)
+	}
+	plain, err := r.open(name, data)
+	if err != nil {
+		return nil, err
+	}
+	body, signature, err := format.DecodeSignedManifest(plain)
+	if err != nil {
+		return nil, err
+	}
+	if r.verifyKey != nil {
+		valid, err := zjcrypto.VerifySignatureWithKey(body, signature, r.verifyKey)
+		if err != nil || !valid {
+			return nil, utils.NewCryptoError(utils.ErrSignatureVerification, "Snapshot signature verification failed: "+id)
+		}
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(body, &snap); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid snapshot: "+err.Error())
+	}
+	for i := range snap.Tree {
+		if !filepath.IsLocal(filepath.FromSlash(snap.Tree[i].Name)) || strings.Contains(snap.Tree[i].Name, `\`) {
+			return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Unsafe path in snapshot: "+snap.Tree[i].Name)
+		}
+	}
+	snap.ID = id
+	return &snap, nil
+}
+
+// ListSnapshots 返回所有快照，按时间升序排列.
+func (r *Repository) ListSnapshots() ([]*Snapshot, error) {
+	entries, err := fs.ReadDir(r.root.FS(), SnapshotsDir)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshots: %w", err)
+	}
+	snapshots := make([]*Snapshot, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !isObjectID(entry.Name()) {
+			continue
+		}
+		snap, err := r.LoadSnapshot(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snap)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// FindSnapshot 按完整 ID、唯一前缀或 "latest" 查找快照.
+func (r *Repository) FindSnapshot(ref string) (*Snapshot, error) {
+	snapshots, err := r.ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	return findSnapshot(snapshots, ref)
+}
+
+func findSnapshot(snapshots []*Snapshot, ref string) (*Snapshot, error) {
+	if ref == LatestSnapshot {
+		if len(snapshots) == 0 {
+			return nil, utils.NewCryptoError(utils.ErrFileNotFound, "Repository has no snapshots")
+		}
+		return snapshots[len(snapshots)-1], nil
+	}
+
+	var found *Snapshot
+	for _, snap := range snapshots {
+		if !strings.HasPrefix(snap.ID, ref) || ref == "" {
+			continue
+		}
+		if found != nil {
+			return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Ambiguous snapshot ID: "+ref)
+		}
+		found = snap
+	}
+	if found == nil {
+		return nil, utils.NewCryptoError(utils.ErrFileNotFound, "Snapshot not found: "+ref)
+	}
+	return found, nil
+}
+
+// isObjectID 判断名称是否为十六进制对象 ID（排除临时文件等）.
+func isObjectID(name string) bool {
+	if len(name) != 32 && len(name) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
@@ -0,0 +1,558 @@
+// Package server 提供本地 HTTP 加密服务，供非 Go 程序调用加密、解密、验证和信息查询.
+//
+// 接口（请求体为原始字节流，错误以 JSON 返回）:
+//
+//	GET  /v1/keys      列出密钥环中的密钥
+//	POST /v1/encrypt   加密请求体，返回 .fzj 密文
+//	POST /v1/decrypt   解密请求体，返回明文
+//	POST /v1/verify    解密并验证请求体，返回 JSON 结果
+//	POST /v1/info      解析请求体的文件头，返回 JSON
+//
+// 密钥通过查询参数 key（名称或指纹前缀）选择，签名密钥可通过 signer 单独指定，
+// 密钥环只有一个密钥时可省略. 请求体受 Config.MaxBodySize 限制：未压缩的加密请求体直接按块加密，
+// 只有密文写入临时文件；压缩加密的明文和待解密的密文先写入临时文件，分块密文逐块解密后直接写入响应.
+//
+// 服务面向本机程序而非浏览器：带 Origin 头的请求一律拒绝，Host 只接受 IP 地址、localhost
+// 和 Config.AllowedHosts，防止网页通过 DNS 重绑定访问本地服务.
+package server
+
+import (
+	"crypto/ecdh"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+	"github.com/cloudflare/circl/kem"
+)
+
+const (
+	// DefaultMaxBodySize 默认的请求体大小上限.
+	DefaultMaxBodySize int64 = 1 << 30
+
+	// FilenameHeader 加密时记录的文件名 / 解密后原始文件名的响应头.
+	FilenameHeader = "X-Fzjjyz-Filename"
+	// KeyHeader 响应中实际使用的密钥指纹.
+	KeyHeader = "X-Fzjjyz-Key"
+	// VerifiedHeader 解密响应中签名是否已验证（true / false）.
+	VerifiedHeader = "X-Fzjjyz-Verified"
+
+	defaultFilename = "data"
+	tempDirPattern  = "fzjjyz-serve-*"
+)
+
+// Config 服务配置.
+type Config struct {
+	// Keyring 可用的密钥.
+	Keyring *zjcrypto.Keyring
+	// Token 非空时要求请求携带 "Authorization: Bearer <Token>".
+	Token string
+	// MaxBodySize 请求体大小上限，0 表示使用 DefaultMaxBodySize.
+	MaxBodySize int64
+	// TempDir 临时文件目录，空字符串表示系统默认目录.
+	TempDir string
+	// AllowedHosts 除 IP 地址和 localhost 外允许出现在 Host 头中的主机名（不含端口）.
+	AllowedHosts []string
+}
+
+// Server HTTP 加密服务.
+type Server struct {
+	cfg Config
+	mux *http.ServeMux
+}
+
+// New 创建服务.
+func New(cfg Config) (*Server, error) {
+	if cfg.Keyring == nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Keyring is required")
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = DefaultMaxBodySize
+	}
+
+	s := &Server{cfg: cfg, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /v1/keys", s.handleKeys)
+	s.mux.HandleFunc("POST /v1/encrypt", s.handleEncrypt)
+	s.mux.HandleFunc("POST /v1/decrypt", s.handleDecrypt)
+	s.mux.HandleFunc("POST /v1/verify", s.handleVerify)
+	s.mux.HandleFunc("POST /v1/info", s.handleInfo)
+	return s, nil
+}
+
+// ServeHTTP 实现 http.Handler，在路由前检查 Host、Origin 和令牌并限制请求体大小.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.hostAllowed(r.Host) {
+		writeError(w, http.StatusMisdirectedRequest, "forbidden_host", "Host not allowed: "+r.Host)
+		return
+	}
+	if r.Header.Get("Origin") != "" {
+		writeError(w, http.StatusForbidden, "forbidden_origin", "Cross-origin requests are not allowed")
+		return
+	}
+	if s.cfg.Token != "" && !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "unauthorized", "Missing or invalid bearer token")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxBodySize)
+	s.mux.ServeHTTP(w, r)
+}
+
+// hostAllowed 判断 Host 头是否为 IP 地址、localhost 或 AllowedHosts 中的主机名.
+// 浏览器经 DNS 重绑定发出的请求带有攻击者的域名，会被拒绝.
+func (s *Server) hostAllowed(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") {
+		return true
+	}
+	for _, allowed := range s.cfg.AllowedHosts {
+		if strings.EqualFold(host, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Token)) == 1
+}
+
+// keyInfo /v1/keys 返回的密钥信息.
+type keyInfo struct {
+	Name        string `json:"name"`
+	Fingerprint string `json:"fingerprint"`
+	CanDecrypt  bool   `json:"can_decrypt"`
+	CanSign     bool   `json:"can_sign"`
+	CanVerify   bool   `json:"can_verify"`
+}
+
+func (s *Server) handleKeys(w http.ResponseWriter, _ *http.Request) {
+	entries := s.cfg.Keyring.Entries()
+	keys := make([]keyInfo, len(entries))
+	for i, entry := range entries {
+		keys[i] = keyInfo{
+			Name:        entry.Name,
+			Fingerprint: entry.Fingerprint,
+			CanDecrypt:  entry.Private != nil,
+			CanSign:     entry.DilithiumPriv != nil,
+			CanVerify:   entry.DilithiumPub != nil,
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"keys": keys})
+}
+
+// selectKeys 按查询参数选择接收方密钥和签名密钥，signer 缺省时使用接收方密钥.
+func (s *Server) selectKeys(r *http.Request) (recipient, signer *zjcrypto.KeyringEntry, err error) {
+	query := r.URL.Query()
+	recipient, err = s.cfg.Keyring.Lookup(query.Get("key"))
+	if err != nil {
+		return nil, nil, err
+	}
+	signer = recipient
+	if ref := query.Get("signer"); ref != "" {
+		if signer, err = s.cfg.Keyring.Lookup(ref); err != nil {
+			return nil, nil, err
+		}
+	}
+	return recipient, signer, nil
+}
+
+func (s *Server) handleEncrypt(w http.ResponseWriter, r *http.Request) {
+	recipient, signer, err := s.selectKeys(r)
+	if err != nil {
+		writeCryptoError(w, err)
+		return
+	}
+	if signer.DilithiumPriv == nil {
+		writeError(w, http.StatusBadRequest, utils.ErrInvalidKey.String(), "Signing key not available: "+signer.Name)
+		return
+	}
+
+	query := r.URL.Query()
+	filename := requestFilename(query.Get("filename"))
+	level := 0
+	if value := query.Get("level"); value != "" {
+		if level, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, utils.ErrInvalidParameter.String(), "Invalid compression level: "+value)
+			return
+		}
+	}
+	compression, err := zjcrypto.ParseCompressionOptions(query.Get("compress"), level)
+	if err != nil {
+		writeCryptoError(w, err)
+		return
+	}
+
+	work, err := s.newWorkspace()
+	if err != nil {
+		writeCryptoError(w, err)
+		return
+	}
+	defer work.cleanup()
+
+	// 未压缩时使用分块格式：请求体直接按块加密，只有密文写入临时文件（回填文件头需要随机写）；
+	// 压缩格式需要完整明文，请求体先写入临时文件
+	output := filepath.Join(work.dir, filename+".fzj")
+	pub := recipient.Public
+	if compression.Codec == format.CompressionNone {
+		err = encryptBody(r.Body, filename, output, recipient, signer)
+	} else if err = work.receive(r, filename); err == nil {
+		err = zjcrypto.EncryptFileWithCompression(work.input, output, pub.Kyber, pub.ECDH, signer.DilithiumPriv, compression)
+	}
+	if err != nil {
+		writeCryptoError(w, err)
+		return
+	}
+
+	w.Header().Set(FilenameHeader, filename+".fzj")
+	w.Header().Set(KeyHeader, recipient.Fingerprint)
+	sendFile(w, output)
+}
+
+func (s *Server) handleDecrypt(w http.ResponseWriter, r *http.Request) {
+	// 只有显式指定 verify=false 时才允许在没有签名公钥的情况下解密
+	work, header, recipient, signer, ok := s.decrypt(w, r, r.URL.Query().Get("verify") == "false")
+	if !ok {
+		return
+	}
+	defer work.cleanup()
+
+	w.Header().Set(FilenameHeader, header.Filename)
+	w.Header().Set(KeyHeader, recipient.Fingerprint)
+	w.Header().Set(VerifiedHeader, strconv.FormatBool(signer != nil))
+	if signer != nil {
+		w.Header().Set("X-Fzjjyz-Signer", signer.Fingerprint)
+	}
+	if work.plain != nil {
+		// 已在 decrypt 中完整校验过，从头重新解密并直接写入响应
+		if _, err := work.plain.Seek(0, io.SeekStart); err != nil {
+			writeCryptoError(w, err)
+			return
+		}
+		sendReader(w, work.plain, work.plain.Size())
+		return
+	}
+	sendFile(w, work.output)
+}
+
+// verifyResult /v1/verify 返回的结果.
+type verifyResult struct {
+	Valid    bool   `json:"valid"`
+	Filename string `json:"filename"`
+	Size     uint64 `json:"size"`
+	Key      string `json:"key"`
+	Signer   string `json:"signer"`
+}
+
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	work, header, recipient, signer, ok := s.decrypt(w, r, false)
+	if !ok {
+		return
+	}
+	defer work.cleanup()
+
+	writeJSON(w, http.StatusOK, verifyResult{
+		Valid:    true,
+		Filename: header.Filename,
+		Size:     header.FileSize,
+		Key:      recipient.Fingerprint,
+		Signer:   signer.Fingerprint,
+	})
+}
+
+// decrypt 解密请求体，成功时由调用方清理工作目录；失败时已写出错误响应.
+// 签名密钥没有 Dilithium 公钥时，在读取请求体之前拒绝请求；
+// 仅当 allowUnverified 为 true 时跳过签名验证，此时返回的 signer 为 nil.
+//
+// 临时目录中只保存密文：分块格式的块表位于文件末尾，需要随机读取. 分块密文在这里逐块解密
+// 校验一遍，明文不落盘，由调用方通过 work.plain 再次解密写入响应；其他格式解密到 work.output.
+func (s *Server) decrypt(w http.ResponseWriter, r *http.Request, allowUnverified bool) (
+	work *workspace, header *format.FileHeader, recipient, signer *zjcrypto.KeyringEntry, ok bool,
+) {
+	recipient, signer, err := s.selectKeys(r)
+	if err != nil {
+		writeCryptoError(w, err)
+		return nil, nil, nil, nil, false
+	}
+	if recipient.Private == nil {
+		writeError(w, http.StatusBadRequest, utils.ErrInvalidKey.String(), "Private key not available: "+recipient.Name)
+		return nil, nil, nil, nil, false
+	}
+	if signer.DilithiumPub == nil {
+		if !allowUnverified {
+			writeError(w, http.StatusBadRequest, utils.ErrInvalidKey.String(), "Verification key not available: "+signer.Name)
+			return nil, nil, nil, nil, false
+		}
+		signer = nil
+	}
+
+	work, err = s.newWorkspace()
+	if err != nil {
+		writeCryptoError(w, err)
+		return nil, nil, nil, nil, false
+	}
+	if err = work.receive(r, "input.fzj"); err == nil {
+		header, err = readHeader(work.input)
+	}
+	if err == nil {
+		var verifyKey zjcrypto.VerifyingKey
+		if signer != nil {
+			verifyKey = signer.DilithiumPub
+		}
+		priv := recipient.Private
+		if header.IsChunked() {
+			err = work.openChunked(priv.Kyber, priv.ECDH, verifyKey)
+		} else {
+			work.output = filepath.Join(work.dir, "output")
+			err = zjcrypto.DecryptFileStreamingAuto(work.input, work.output, priv.Kyber, priv.ECDH, verifyKey)
+		}
+	}
+	if err != nil {
+		work.cleanup()
+		writeCryptoError(w, err)
+		return nil, nil, nil, nil, false
+	}
+	return work, header, recipient, signer, true
+}
+
+// headerInfo /v1/info 返回的文件头信息.
+type headerInfo struct {
+	Filename    string `json:"filename"`
+	Size        uint64 `json:"size"`
+	Timestamp   string `json:"timestamp"`
+	Version     uint16 `json:"version"`
+	Algorithm   byte   `json:"algorithm"`
+	Compression string `json:"compression"`
+	Indexed     bool   `json:"indexed_archive"`
+	Chunked     bool   `json:"random_access"`
+	Signed      bool   `json:"signed"`
+	HeaderSize  int    `json:"header_size"`
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	// 只读取文件头，剩余的请求体由 net/http 丢弃
+	header, err := format.ParseFileHeader(r.Body)
+	if err == nil {
+		err = header.Validate()
+	}
+	if err != nil {
+		writeCryptoError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, headerInfo{
+		Filename:    header.Filename,
+		Size:        header.FileSize,
+		Timestamp:   time.Unix(int64(header.Timestamp), 0).UTC().Format(time.RFC3339),
+		Version:     header.Version,
+		Algorithm:   header.Algorithm,
+		Compression: format.CompressionName(header.Compression()),
+		Indexed:     header.IsIndexedArchive(),
+		Chunked:     header.IsChunked(),
+		Signed:      header.SigLen > 0,
+		HeaderSize:  header.GetHeaderSize(),
+	})
+}
+
+// workspace 单个请求的临时目录.
+type workspace struct {
+	dir    string
+	input  string
+	output string
+
+	// 分块密文的输入文件和已校验的明文读取器
+	inputFile *os.File
+	plain     *zjcrypto.ChunkedReader
+}
+
+func (s *Server) newWorkspace() (*workspace, error) {
+	dir, err := os.MkdirTemp(s.cfg.TempDir, tempDirPattern)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Failed to create temp dir: "+err.Error())
+	}
+	return &workspace{dir: dir}, nil
+}
+
+func (w *workspace) cleanup() {
+	if w.inputFile != nil {
+		_ = w.inputFile.Close()
+	}
+	_ = os.RemoveAll(w.dir)
+}
+
+// receive 将请求体写入临时目录中的 name 文件.
+func (w *workspace) receive(r *http.Request, name string) error {
+	w.input = filepath.Join(w.dir, name)
+	// #nosec G304 - 路径位于新建的临时目录中
+	file, err := os.OpenFile(w.input, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, r.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// openChunked 打开分块密文并逐块解密校验一遍，校验通过后 w.plain 可供重新读取.
+func (w *workspace) openChunked(kyberPriv kem.PrivateKey, ecdhPriv *ecdh.PrivateKey, verifyKey zjcrypto.VerifyingKey) error {
+	// #nosec G304 - 路径位于服务创建的临时目录中
+	file, err := os.Open(w.input)
+	if err != nil {
+		return utils.NewCryptoError(utils.ErrIOError, err.Error())
+	}
+	w.inputFile = file
+	info, err := file.Stat()
+	if err != nil {
+		return utils.NewCryptoError(utils.ErrIOError, err.Error())
+	}
+	plain, err := zjcrypto.OpenReaderAt(file, info.Size(), kyberPriv, ecdhPriv, verifyKey)
+	if err != nil {
+		return err
+	}
+	plain.SetCacheSize(1)
+	if _, err := io.Copy(io.Discard, plain); err != nil {
+		return err
+	}
+	w.plain = plain
+	return nil
+}
+
+// encryptBody 将请求体按块加密写入 output，明文只在内存中按块停留.
+func encryptBody(body io.Reader, filename, output string, recipient, signer *zjcrypto.KeyringEntry) (err error) {
+	// #nosec G304 - 路径位于服务创建的临时目录中
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return utils.NewCryptoError(utils.ErrIOError, err.Error())
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = utils.NewCryptoError(utils.ErrIOError, closeErr.Error())
+		}
+	}()
+	pub := recipient.Public
+	_, err = zjcrypto.EncryptReaderChunked(body, filename, file, pub.Kyber, pub.ECDH, signer.DilithiumPriv, 0)
+	return err
+}
+
+// readHeader 读取密文文件头，用于在响应中返回原始文件名.
+func readHeader(path string) (*format.FileHeader, error) {
+	// #nosec G304 - 路径位于服务创建的临时目录中
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrIOError, err.Error())
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	header, err := format.ParseFileHeader(file)
+	if err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// requestFilename 取文件名的最后一个路径元素，空或特殊名称时使用默认值.
+func requestFilename(name string) string {
+	name = filepath.Base(filepath.FromSlash(name))
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return defaultFilename
+	}
+	return name
+}
+
+// sendFile 以 application/octet-stream 流式发送文件.
+func sendFile(w http.ResponseWriter, path string) {
+	// #nosec G304 - 路径位于服务创建的临时目录中
+	file, err := os.Open(path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, utils.ErrIOError.String(), err.Error())
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	info, err := file.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, utils.ErrIOError.String(), err.Error())
+		return
+	}
+	sendReader(w, file, info.Size())
+}
+
+// sendReader 以 application/octet-stream 流式发送 size 字节.
+func sendReader(w http.ResponseWriter, r io.Reader, size int64) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, r)
+}
+
+// errorBody JSON 错误响应.
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// StatusForCode 返回错误代码对应的 HTTP 状态码.
+func StatusForCode(code utils.ErrorCode) int {
+	switch code {
+	case utils.ErrInvalidParameter:
+		return http.StatusBadRequest
+	case utils.ErrFileNotFound:
+		return http.StatusNotFound
+	case utils.ErrInvalidMagic, utils.ErrInvalidFormat, utils.ErrVersionMismatch, utils.ErrInvalidVersion,
+		utils.ErrInvalidAlgorithm, utils.ErrInvalidKey, utils.ErrInvalidData, utils.ErrDecryptionFailed,
+		utils.ErrAuthFailed, utils.ErrSignatureVerification, utils.ErrHashMismatch, utils.ErrVerificationFailed:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeCryptoError 按错误链中的 utils.ErrorCode 写出 JSON 错误.
+func writeCryptoError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "request_too_large",
+			fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit))
+		return
+	}
+	code, ok := utils.CodeOf(err)
+	if !ok {
+		// 非 CryptoError 的错误来自读取请求体或文件系统
+		writeError(w, http.StatusInternalServerError, utils.ErrSystem.String(), err.Error())
+		return
+	}
+	writeError(w, StatusForCode(code), code.String(), err.Error())
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, errorBody{Error: errorDetail{Code: code, Message: message}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
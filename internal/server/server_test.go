@@ -0,0 +1,483 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/zjcrypto"
+)
+
+// writeTestKey 以 keygen 的命名方式在 dir 中生成一组密钥.
+func writeTestKey(t *testing.T, dir, name string, withPrivate bool) {
+	t.Helper()
+	kyberPub, kyberPriv, err := zjcrypto.GenerateKyberKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdhPub, ecdhPriv, err := zjcrypto.GenerateECDHKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dilithiumPub, dilithiumPriv, err := zjcrypto.GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Join(dir, name)
+	if err := zjcrypto.SaveKeyFiles(kyberPub, ecdhPub, kyberPriv, ecdhPriv,
+		base+"_public.pem", base+"_private.pem"); err != nil {
+		t.Fatal(err)
+	}
+	if err := zjcrypto.SaveDilithiumKeys(dilithiumPub, dilithiumPriv,
+		base+"_dilithium_public.pem", base+"_dilithium_private.pem"); err != nil {
+		t.Fatal(err)
+	}
+	if !withPrivate {
+		_ = os.Remove(base + "_private.pem")
+		_ = os.Remove(base + "_dilithium_private.pem")
+	}
+}
+
+func newTestServer(t *testing.T, cfg Config, names ...string) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		writeTestKey(t, dir, name, true)
+	}
+	keyring, err := zjcrypto.LoadKeyring(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Keyring = keyring
+	cfg.TempDir = t.TempDir()
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// post 发送请求并返回状态码和响应体.
+func post(t *testing.T, client *http.Client, url string, body []byte, header http.Header) (int, http.Header, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, resp.Header, data
+}
+
+// errorCode 解析 JSON 错误响应中的错误代码.
+func errorCode(t *testing.T, body []byte) string {
+	t.Helper()
+	var e errorBody
+	if err := json.Unmarshal(body, &e); err != nil {
+		t.Fatalf("无效的错误响应 %q: %v", body, err)
+	}
+	return e.Error.Code
+}
+
+func TestServerRoundTrip(t *testing.T) {
+	ts := newTestServer(t, Config{}, "alice")
+	client := ts.Client()
+	plain := bytes.Repeat([]byte("fzjjyz serve "), 20000)
+
+	for _, query := range []string{"?filename=report.txt", "?filename=report.txt&compress=zstd&level=3"} {
+		status, header, ciphertext := post(t, client, ts.URL+"/v1/encrypt"+query, plain, nil)
+		if status != http.StatusOK || header.Get(FilenameHeader) != "report.txt.fzj" {
+			t.Fatalf("加密失败 (%s): %d %s", query, status, ciphertext)
+		}
+
+		status, _, body := post(t, client, ts.URL+"/v1/info", ciphertext, nil)
+		var info headerInfo
+		if status != http.StatusOK || json.Unmarshal(body, &info) != nil {
+			t.Fatalf("info 失败: %d %s", status, body)
+		}
+		if info.Filename != "report.txt" || info.Size != uint64(len(plain)) || !info.Signed ||
+			info.Chunked != !strings.Contains(query, "compress") {
+			t.Errorf("info = %+v", info)
+		}
+
+		status, header, decrypted := post(t, client, ts.URL+"/v1/decrypt", ciphertext, nil)
+		if status != http.StatusOK || !bytes.Equal(decrypted, plain) || header.Get(FilenameHeader) != "report.txt" {
+			t.Fatalf("解密失败 (%s): %d", query, status)
+		}
+
+		status, _, body = post(t, client, ts.URL+"/v1/verify", ciphertext, nil)
+		var result verifyResult
+		if status != http.StatusOK || json.Unmarshal(body, &result) != nil || !result.Valid {
+			t.Fatalf("验证失败: %d %s", status, body)
+		}
+	}
+}
+
+func TestServerKeySelection(t *testing.T) {
+	ts := newTestServer(t, Config{}, "alice", "bob")
+	client := ts.Client()
+
+	resp, err := client.Get(ts.URL + "/v1/keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys struct {
+		Keys []keyInfo `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil || len(keys.Keys) != 2 {
+		t.Fatalf("keys = %+v, %v", keys, err)
+	}
+	_ = resp.Body.Close()
+	alice, bob := keys.Keys[0].Fingerprint, keys.Keys[1].Fingerprint
+
+	// 多个密钥时必须选择
+	status, _, body := post(t, client, ts.URL+"/v1/encrypt", []byte("x"), nil)
+	if status != http.StatusBadRequest || errorCode(t, body) != "invalid_parameter" {
+		t.Errorf("未选择密钥: %d %s", status, body)
+	}
+	status, _, body = post(t, client, ts.URL+"/v1/encrypt?key=0000000000", []byte("x"), nil)
+	if status != http.StatusNotFound || errorCode(t, body) != "file_not_found" {
+		t.Errorf("未知密钥: %d %s", status, body)
+	}
+
+	// 加密给 alice、由 bob 签名
+	status, header, ciphertext := post(t, client, ts.URL+"/v1/encrypt?key="+alice[:12]+"&signer=bob", []byte("secret"), nil)
+	if status != http.StatusOK || header.Get(KeyHeader) != alice {
+		t.Fatalf("加密失败: %d %s", status, ciphertext)
+	}
+	status, _, body = post(t, client, ts.URL+"/v1/decrypt?key="+bob, ciphertext, nil)
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("错误私钥解密: %d %s", status, body)
+	}
+	status, _, body = post(t, client, ts.URL+"/v1/verify?key=alice", ciphertext, nil)
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("错误签名公钥验证: %d %s", status, body)
+	}
+	status, header, body = post(t, client, ts.URL+"/v1/decrypt?key=alice&signer="+bob, ciphertext, nil)
+	if status != http.StatusOK || string(body) != "secret" || header.Get("X-Fzjjyz-Signer") != bob {
+		t.Errorf("解密失败: %d %s", status, body)
+	}
+}
+
+func TestServerUnverifiedDecrypt(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "alice", true)
+	writeTestKey(t, dir, "carol", true)
+	// carol 没有 Dilithium 公钥，无法验证签名
+	_ = os.Remove(filepath.Join(dir, "carol_dilithium_public.pem"))
+	_ = os.Remove(filepath.Join(dir, "carol_dilithium_private.pem"))
+	keyring, err := zjcrypto.LoadKeyring(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(Config{Keyring: keyring, TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	client := ts.Client()
+
+	status, _, ciphertext := post(t, client, ts.URL+"/v1/encrypt?key=carol&signer=alice", []byte("secret"), nil)
+	if status != http.StatusOK {
+		t.Fatalf("加密失败: %d %s", status, ciphertext)
+	}
+	for _, path := range []string{"/v1/decrypt?key=carol", "/v1/verify?key=carol"} {
+		status, _, body := post(t, client, ts.URL+path, ciphertext, nil)
+		if status != http.StatusBadRequest || errorCode(t, body) != "invalid_key" {
+			t.Errorf("%s 缺少签名公钥: %d %s", path, status, body)
+		}
+	}
+
+	status, header, body := post(t, client, ts.URL+"/v1/decrypt?key=carol&verify=false", ciphertext, nil)
+	if status != http.StatusOK || string(body) != "secret" || header.Get(VerifiedHeader) != "false" {
+		t.Errorf("显式跳过验证: %d %s %v", status, body, header)
+	}
+	status, header, _ = post(t, client, ts.URL+"/v1/decrypt?key=carol&signer=alice", ciphertext, nil)
+	if status != http.StatusOK || header.Get(VerifiedHeader) != "true" {
+		t.Errorf("验证后解密: %d %v", status, header)
+	}
+}
+
+// scanReader 读到结尾时调用 atEOF，用于在请求体读完、响应写出之前检查临时目录.
+type scanReader struct {
+	r     io.Reader
+	atEOF func()
+}
+
+func (s *scanReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err == io.EOF && s.atEOF != nil {
+		s.atEOF()
+		s.atEOF = nil
+	}
+	return n, err
+}
+
+func TestServerEncryptStreamsBody(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "alice", true)
+	keyring, err := zjcrypto.LoadKeyring(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tempDir := t.TempDir()
+	srv, err := New(Config{Keyring: keyring, TempDir: tempDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	// 未压缩加密时临时目录中只有密文，明文不落盘；直接调用 ServeHTTP，读到请求体结尾时仍在处理请求
+	plain := bytes.Repeat([]byte("plaintext marker "), 10000)
+	var leaked []string
+	body := &scanReader{r: bytes.NewReader(plain), atEOF: func() {
+		_ = filepath.WalkDir(tempDir, func(path string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				data, _ := os.ReadFile(path) // #nosec G304 - 测试环境使用临时文件路径
+				if bytes.Contains(data, []byte("plaintext marker")) {
+					leaked = append(leaked, path)
+				}
+			}
+			return nil
+		})
+	}}
+	req := httptest.NewRequest(http.MethodPost, "/v1/encrypt?filename=a.txt", body)
+	req.Host = "127.0.0.1"
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	ciphertext := rec.Body.Bytes()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("加密失败: %d %s", rec.Code, ciphertext)
+	}
+	if len(leaked) > 0 {
+		t.Errorf("临时目录中出现明文: %v", leaked)
+	}
+
+	status, _, decrypted := post(t, ts.Client(), ts.URL+"/v1/decrypt", ciphertext, nil)
+	if status != http.StatusOK || !bytes.Equal(decrypted, plain) {
+		t.Fatalf("解密失败: %d", status)
+	}
+	entries, _ := os.ReadDir(tempDir)
+	if len(entries) != 0 {
+		t.Errorf("临时目录未清理: %v", entries)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	ts := newTestServer(t, Config{Token: "s3cret", MaxBodySize: 64 * 1024}, "alice")
+	client := ts.Client()
+	auth := http.Header{"Authorization": {"Bearer s3cret"}}
+
+	for _, header := range []http.Header{nil, {"Authorization": {"Bearer wrong"}}} {
+		status, _, body := post(t, client, ts.URL+"/v1/encrypt", []byte("x"), header)
+		if status != http.StatusUnauthorized || errorCode(t, body) != "unauthorized" {
+			t.Errorf("未授权请求: %d %s", status, body)
+		}
+	}
+
+	status, _, body := post(t, client, ts.URL+"/v1/encrypt", make([]byte, 64*1024+1), auth)
+	if status != http.StatusRequestEntityTooLarge || errorCode(t, body) != "request_too_large" {
+		t.Errorf("超大请求: %d %s", status, body)
+	}
+
+	status, _, body = post(t, client, ts.URL+"/v1/info", []byte("not an fzj file"), auth)
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("无效文件头: %d %s", status, body)
+	}
+
+	// 篡改密文：验证失败，临时文件被清理
+	status, _, ciphertext := post(t, client, ts.URL+"/v1/encrypt", bytes.Repeat([]byte{1}, 4096), auth)
+	if status != http.StatusOK {
+		t.Fatalf("加密失败: %d %s", status, ciphertext)
+	}
+	ciphertext[len(ciphertext)/2] ^= 0xFF
+	for _, path := range []string{"/v1/verify", "/v1/decrypt"} {
+		status, _, body = post(t, client, ts.URL+path, ciphertext, auth)
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("%s 篡改的密文: %d %s", path, status, body)
+		}
+	}
+
+	resp, err := client.Get(ts.URL + "/v1/decrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("未授权 GET: %d", resp.StatusCode)
+	}
+}
+
+func TestServerHostAndOrigin(t *testing.T) {
+	ts := newTestServer(t, Config{AllowedHosts: []string{"fzj.internal"}}, "alice")
+	handler := ts.Config.Handler
+
+	tests := []struct {
+		host, origin string
+		want         int
+	}{
+		{"127.0.0.1:8443", "", http.StatusOK},
+		{"[::1]:8443", "", http.StatusOK},
+		{"localhost:8443", "", http.StatusOK},
+		{"192.168.1.10", "", http.StatusOK},
+		{"FZJ.internal:8443", "", http.StatusOK},
+		{"attacker.example:8443", "", http.StatusMisdirectedRequest}, // DNS 重绑定
+		{"localhost.attacker.example", "", http.StatusMisdirectedRequest},
+		{"127.0.0.1:8443", "http://attacker.example", http.StatusForbidden},
+		{"127.0.0.1:8443", "null", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/v1/keys", nil)
+		req.Host = tt.host
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Host=%q Origin=%q: 状态码 %d，期望 %d", tt.host, tt.origin, rec.Code, tt.want)
+		}
+	}
+}
+
+// writeCert 生成证书并以 PEM 写入 dir，parent 为 nil 时生成自签名 CA.
+func writeCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey,
+	usage x509.ExtKeyUsage,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(filepath.Join(dir, name+".crt"), certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestServerMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCert(t, dir, "ca", nil, nil, x509.ExtKeyUsageAny)
+	writeCert(t, dir, "server", ca, caKey, x509.ExtKeyUsageServerAuth)
+	writeCert(t, dir, "client", ca, caKey, x509.ExtKeyUsageClientAuth)
+
+	tlsConfig, err := TLSConfig(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"),
+		filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		t.Fatalf("TLSConfig 失败: %v", err)
+	}
+	keyDir := t.TempDir()
+	writeTestKey(t, keyDir, "alice", false)
+	keyring, err := zjcrypto.LoadKeyring(keyDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(Config{Keyring: keyring})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewUnstartedServer(srv)
+	ts.TLS = tlsConfig
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	clientCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs: roots, Certificates: certs, MinVersion: tls.VersionTLS12,
+		}}}
+	}
+
+	if resp, err := newClient().Get(ts.URL + "/v1/keys"); err == nil {
+		_ = resp.Body.Close()
+		t.Error("期望没有客户端证书时连接失败")
+	}
+	resp, err := newClient(clientCert).Get(ts.URL + "/v1/keys")
+	if err != nil {
+		t.Fatalf("mTLS 请求失败: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("mTLS 请求状态 %d", resp.StatusCode)
+	}
+
+	// 只有公钥时不能解密
+	status, _, body := post(t, newClient(clientCert), ts.URL+"/v1/decrypt", []byte("x"), nil)
+	if status != http.StatusBadRequest || errorCode(t, body) != "invalid_key" {
+		t.Errorf("缺少私钥: %d %s", status, body)
+	}
+
+	if _, err := TLSConfig(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"),
+		filepath.Join(dir, "server.key")); err == nil {
+		t.Error("期望无效的 CA 文件报错")
+	}
+}
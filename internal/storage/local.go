@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+const localFilePerm = 0600
+
+// Local 本地文件系统后端，对象名称即文件路径.
+type Local struct{}
+
+// NewLocal 创建本地文件系统后端.
+func NewLocal() *Local {
+	return &Local{}
+}
+
+func newLocalFromURL(u *url.URL) (Backend, string, error) {
+	if u.Host != "" && u.Host != "localhost" {
+		return nil, "", utils.NewCryptoError(utils.ErrInvalidParameter, "file:// URL must not name a remote host: "+u.Host)
+	}
+	return NewLocal(), filepath.FromSlash(u.Path), nil
+}
+
+// Create 在目标文件旁创建临时文件写入，Close 时重命名为目标文件.
+func (l *Local) Create(name string) (Writer, error) {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("create %s: %w", name, err)
+	}
+	if err := f.Chmod(localFilePerm); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, fmt.Errorf("create %s: %w", name, err)
+	}
+	return &localWriter{f: f, name: name}, nil
+}
+
+// Open 打开本地文件.
+func (l *Local) Open(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name) // #nosec G304 - 路径由调用方提供
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	return f, nil
+}
+
+// Stat 返回本地文件信息.
+func (l *Local) Stat(name string) (Info, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		//nolint:wrapcheck
+		return Info{}, err
+	}
+	return Info{Name: name, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Remove 删除本地文件.
+func (l *Local) Remove(name string) error {
+	//nolint:wrapcheck
+	return os.Remove(name)
+}
+
+// Close 本地后端无需释放资源.
+func (l *Local) Close() error {
+	return nil
+}
+
+type localWriter struct {
+	f    *os.File
+	name string
+	done bool
+}
+
+func (w *localWriter) Write(p []byte) (int, error) {
+	//nolint:wrapcheck
+	return w.f.Write(p)
+}
+
+func (w *localWriter) Close() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	tmpName := w.f.Name()
+	if err := w.f.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("close %s: %w", w.name, err)
+	}
+	if err := os.Rename(tmpName, w.name); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("rename %s: %w", w.name, err)
+	}
+	return nil
+}
+
+func (w *localWriter) Abort() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	_ = w.f.Close()
+	//nolint:wrapcheck
+	return os.Remove(w.f.Name())
+}
@@ -0,0 +1,436 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+const (
+	// MinPartSize S3 分段上传除最后一段外的最小段大小.
+	MinPartSize = 5 * 1024 * 1024
+	// DefaultPartSize 默认分段大小.
+	DefaultPartSize = 16 * 1024 * 1024
+	// maxParts S3 单次分段上传允许的最大段数.
+	maxParts = 10000
+
+	defaultS3Region = "us-east-1"
+)
+
+// S3Config S3 兼容对象存储配置.
+type S3Config struct {
+	Endpoint     string       // 服务地址，如 http://127.0.0.1:9000；为空时使用 AWS 区域端点
+	Region       string       // 签名使用的区域，默认 us-east-1
+	AccessKey    string       // 访问密钥 ID，为空时发送匿名请求
+	SecretKey    string       // 访问密钥
+	SessionToken string       // 临时凭证的会话令牌
+	PathStyle    bool         // 使用 endpoint/bucket/key 形式的路径寻址
+	PartSize     int64        // 分段上传的段大小，默认 16MB
+	Client       *http.Client // HTTP 客户端，默认 http.DefaultClient
+}
+
+// S3 S3 兼容对象存储后端，对象名称即对象键.
+type S3 struct {
+	bucket    string
+	endpoint  *url.URL
+	region    string
+	creds     credentials
+	pathStyle bool
+	partSize  int64
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3 创建 S3 后端.
+func NewS3(bucket string, cfg S3Config) (*S3, error) {
+	if bucket == "" {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "S3 bucket name is empty")
+	}
+	region := cfg.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+	endpoint := cfg.Endpoint
+	pathStyle := cfg.PathStyle
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Invalid S3 endpoint: "+endpoint)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	partSize := cfg.PartSize
+	if partSize == 0 {
+		partSize = DefaultPartSize
+	}
+	if partSize < MinPartSize {
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("S3 part size must be at least %d bytes", MinPartSize),
+		)
+	}
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &S3{
+		bucket:   bucket,
+		endpoint: u,
+		region:   region,
+		creds: credentials{
+			accessKey:    cfg.AccessKey,
+			secretKey:    cfg.SecretKey,
+			sessionToken: cfg.SessionToken,
+		},
+		pathStyle: pathStyle,
+		partSize:  partSize,
+		client:    client,
+		now:       time.Now,
+	}, nil
+}
+
+func init() {
+	Register("s3", newS3FromURL)
+}
+
+// newS3FromURL 解析 s3://bucket/key?endpoint=...&region=...&path-style=true.
+// 凭证从 AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY 和 AWS_SESSION_TOKEN 读取；
+// 未在 URL 中指定时，端点和区域分别取自 AWS_ENDPOINT_URL_S3（或 AWS_ENDPOINT_URL）和 AWS_REGION.
+// 指定了自定义端点（如 MinIO）时默认使用路径寻址.
+func newS3FromURL(u *url.URL) (Backend, string, error) {
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return nil, "", utils.NewCryptoError(utils.ErrInvalidParameter, "S3 URL must be s3://bucket/key: "+u.String())
+	}
+	query := u.Query()
+	cfg := S3Config{
+		Endpoint:     firstNonEmpty(query.Get("endpoint"), os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")),
+		Region:       firstNonEmpty(query.Get("region"), os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")),
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	cfg.PathStyle = cfg.Endpoint != ""
+	if value := query.Get("path-style"); value != "" {
+		pathStyle, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, "", utils.NewCryptoError(utils.ErrInvalidParameter, "Invalid path-style value: "+value)
+		}
+		cfg.PathStyle = pathStyle
+	}
+	if value := query.Get("part-size"); value != "" {
+		partSize, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, "", utils.NewCryptoError(utils.ErrInvalidParameter, "Invalid part-size value: "+value)
+		}
+		cfg.PartSize = partSize
+	}
+
+	backend, err := NewS3(u.Host, cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	return backend, key, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// S3Error S3 服务返回的错误.
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3: %s: %s (HTTP %d)", e.Code, e.Message, e.StatusCode)
+}
+
+// Is 使对象不存在的错误满足 errors.Is(err, fs.ErrNotExist).
+func (e *S3Error) Is(target error) bool {
+	return target == fs.ErrNotExist && (e.StatusCode == http.StatusNotFound || e.Code == "NoSuchKey")
+}
+
+func (b *S3) objectURL(key string, query url.Values) *url.URL {
+	u := *b.endpoint
+	if b.pathStyle {
+		u.Path += "/" + b.bucket + "/" + key
+	} else {
+		u.Host = b.bucket + "." + u.Host
+		u.Path += "/" + key
+	}
+	u.RawPath = uriEscape(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// do 发送签名请求，非 2xx 响应转换为 *S3Error.
+func (b *S3) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		reader = bytes.NewReader(body)
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	req, err := http.NewRequest(method, b.objectURL(key, query).String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request: %w", err)
+	}
+	if b.creds.accessKey != "" {
+		signV4(req, b.creds, b.region, payloadHash, b.now())
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s %s: %w", method, key, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		return nil, parseS3Error(resp.StatusCode, resp.Body)
+	}
+	return resp, nil
+}
+
+func parseS3Error(status int, body io.Reader) error {
+	s3Err := &S3Error{StatusCode: status}
+	data, _ := io.ReadAll(io.LimitReader(body, 64*1024))
+	_ = xml.Unmarshal(data, s3Err)
+	return s3Err
+}
+
+// doXML 发送请求并把响应体解析到 out.
+// 部分操作（如完成分段上传）可能在 200 响应中返回错误，这里一并检查.
+func (b *S3) doXML(method, key string, query url.Values, body []byte, out any) error {
+	resp, err := b.do(method, key, query, body)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("s3 %s %s: %w", method, key, err)
+	}
+	if bytes.Contains(data, []byte("<Error>")) {
+		return parseS3Error(resp.StatusCode, bytes.NewReader(data))
+	}
+	if err := xml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("s3 %s %s: invalid response: %w", method, key, err)
+	}
+	return nil
+}
+
+// Create 创建对象写入器.
+// 数据不超过一个分段时使用单次 PUT，否则使用分段上传，内存中最多缓存一个分段.
+func (b *S3) Create(name string) (Writer, error) {
+	return &s3Writer{backend: b, key: name}, nil
+}
+
+// Open 以流式 GET 读取对象.
+func (b *S3) Open(name string) (io.ReadCloser, error) {
+	resp, err := b.do(http.MethodGet, name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Stat 通过 HEAD 请求获取对象大小和修改时间.
+func (b *S3) Stat(name string) (Info, error) {
+	resp, err := b.do(http.MethodHead, name, nil, nil)
+	if err != nil {
+		return Info{}, err
+	}
+	_ = resp.Body.Close()
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return Info{Name: name, Size: resp.ContentLength, ModTime: modTime}, nil
+}
+
+// Remove 删除对象.
+func (b *S3) Remove(name string) error {
+	resp, err := b.do(http.MethodDelete, name, nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Close S3 后端不持有连接以外的资源.
+func (b *S3) Close() error {
+	return nil
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3Writer struct {
+	backend  *S3
+	key      string
+	buf      []byte
+	uploadID string
+	parts    []completedPart
+	err      error
+	done     bool
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.done {
+		return 0, os.ErrClosed
+	}
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), int(w.backend.partSize)-len(w.buf))
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		written += n
+		if int64(len(w.buf)) == w.backend.partSize {
+			if err := w.flushPart(); err != nil {
+				w.err = err
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flushPart 上传缓存中的数据作为下一个分段，必要时先发起分段上传.
+func (w *s3Writer) flushPart() error {
+	if w.uploadID == "" {
+		var result struct {
+			UploadID string `xml:"UploadId"`
+		}
+		if err := w.backend.doXML(http.MethodPost, w.key, url.Values{"uploads": {""}}, []byte{}, &result); err != nil {
+			return err
+		}
+		if result.UploadID == "" {
+			return utils.NewCryptoError(utils.ErrIOError, "S3 did not return an upload ID")
+		}
+		w.uploadID = result.UploadID
+	}
+	if len(w.parts) >= maxParts {
+		return utils.NewCryptoError(utils.ErrIOError, "S3 upload exceeds the maximum number of parts")
+	}
+
+	number := len(w.parts) + 1
+	query := url.Values{
+		"partNumber": {strconv.Itoa(number)},
+		"uploadId":   {w.uploadID},
+	}
+	resp, err := w.backend.do(http.MethodPut, w.key, query, w.buf)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	w.parts = append(w.parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+	w.buf = w.buf[:0]
+	return nil
+}
+
+func (w *s3Writer) Close() error {
+	if w.done {
+		return w.err
+	}
+	w.done = true
+	if w.err != nil {
+		_ = w.abortUpload()
+		return w.err
+	}
+
+	if w.uploadID == "" {
+		resp, err := w.backend.do(http.MethodPut, w.key, nil, append([]byte{}, w.buf...))
+		if err != nil {
+			w.err = err
+			return err
+		}
+		w.buf = nil
+		return resp.Body.Close()
+	}
+
+	if len(w.buf) > 0 {
+		if err := w.flushPart(); err != nil {
+			w.err = err
+			_ = w.abortUpload()
+			return err
+		}
+	}
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: w.parts})
+	if err != nil {
+		w.err = fmt.Errorf("s3 complete upload: %w", err)
+		_ = w.abortUpload()
+		return w.err
+	}
+	var result struct {
+		ETag string `xml:"ETag"`
+	}
+	if err := w.backend.doXML(http.MethodPost, w.key, url.Values{"uploadId": {w.uploadID}}, body, &result); err != nil {
+		w.err = err
+		_ = w.abortUpload()
+		return err
+	}
+	w.buf = nil
+	return nil
+}
+
+func (w *s3Writer) Abort() error {
+	if w.done && w.err == nil {
+		return nil
+	}
+	w.done = true
+	if w.err == nil {
+		w.err = errors.New("s3 upload aborted")
+	}
+	return w.abortUpload()
+}
+
+// abortUpload 放弃未完成的分段上传，释放服务端已保存的分段.
+func (w *s3Writer) abortUpload() error {
+	w.buf = nil
+	if w.uploadID == "" {
+		return nil
+	}
+	uploadID := w.uploadID
+	w.uploadID = ""
+	resp, err := w.backend.do(http.MethodDelete, w.key, url.Values{"uploadId": {uploadID}}, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
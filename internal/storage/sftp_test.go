@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	testSFTPUser     = "tester"
+	testSFTPPassword = "secret"
+)
+
+// startSFTPServer 启动进程内 SSH 服务器，仅接受密码认证并提供 sftp 子系统.
+// 返回监听地址和服务器主机公钥.
+func startSFTPServer(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if meta.User() == testSFTPUser && string(password) == testSFTPPassword {
+				return &ssh.Permissions{}, nil
+			}
+			return nil, errors.New("access denied")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSHConn(conn, config)
+		}
+	}()
+	return listener.Addr().String(), hostSigner.PublicKey()
+}
+
+func serveSSHConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported channel")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+				server, err := sftp.NewServer(channel)
+				if err != nil {
+					_ = channel.Close()
+					return
+				}
+				_ = server.Serve()
+				_ = server.Close()
+				return
+			}
+		}()
+	}
+}
+
+func TestSFTPRoundTrip(t *testing.T) {
+	addr, hostKey := startSFTPServer(t)
+	backend, err := NewSFTP(SFTPConfig{
+		Addr:            addr,
+		User:            testSFTPUser,
+		Auth:            []ssh.AuthMethod{ssh.Password(testSFTPPassword)},
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = backend.Close()
+	}()
+
+	dir := t.TempDir()
+	name := filepath.ToSlash(filepath.Join(dir, "remote.fzj"))
+	data := randomBytes(t, 3*1024*1024+17)
+	writeObject(t, backend, name, data)
+
+	local, err := os.ReadFile(filepath.FromSlash(name))
+	if err != nil || !bytes.Equal(local, data) {
+		t.Fatalf("remote file mismatch: %v", err)
+	}
+	if !bytes.Equal(readObject(t, backend, name), data) {
+		t.Fatal("downloaded file mismatch")
+	}
+	info, err := backend.Stat(name)
+	if err != nil || info.Size != int64(len(data)) {
+		t.Fatalf("Stat = %+v, %v", info, err)
+	}
+
+	// 覆盖已有文件，中途放弃不影响原文件
+	w, err := backend.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("abort left %d entries", len(entries))
+	}
+	writeObject(t, backend, name, []byte("replaced"))
+	if got := readObject(t, backend, name); string(got) != "replaced" {
+		t.Fatalf("overwrite = %q", got)
+	}
+
+	if err := backend.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.Stat(name); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Stat after Remove = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestSFTPResolveURL(t *testing.T) {
+	addr, hostKey := startSFTPServer(t)
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	line := "[127.0.0.1]:" + addr[len("127.0.0.1:"):] + " " + string(ssh.MarshalAuthorizedKey(hostKey))
+	if err := os.WriteFile(knownHosts, []byte(line), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FZJJYZ_SFTP_KNOWN_HOSTS", knownHosts)
+	t.Setenv("FZJJYZ_SFTP_PASSWORD", testSFTPPassword)
+	t.Setenv("FZJJYZ_SFTP_KEY", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	src := writeTempFile(t, randomBytes(t, 64*1024))
+	remote := filepath.ToSlash(filepath.Join(t.TempDir(), "via-url.fzj"))
+	target := "sftp://" + testSFTPUser + "@" + addr + remote
+	if _, err := Upload(src, target); err != nil {
+		t.Fatal(err)
+	}
+	assertSameFile(t, src, filepath.FromSlash(remote))
+
+	dst := src + ".out"
+	if _, err := Download(target, dst); err != nil {
+		t.Fatal(err)
+	}
+	assertSameFile(t, src, dst)
+
+	// 主机密钥不在 known_hosts 中时拒绝连接
+	if err := os.WriteFile(knownHosts, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Exists(target); err == nil {
+		t.Fatal("expected host key verification failure")
+	}
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4 签名（仅实现 S3 所需的部分）.
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+	sigV4DateFormat = "20060102"
+	sigV4Service    = "s3"
+
+	headerDate          = "X-Amz-Date"
+	headerContentSHA256 = "X-Amz-Content-Sha256"
+	headerSecurityToken = "X-Amz-Security-Token"
+)
+
+// emptyPayloadHash 空请求体的 SHA-256.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+type credentials struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// signV4 为请求添加 SigV4 签名头.
+// 签名覆盖 Host 以及签名时请求中已有的全部头部，之后由传输层添加的头部不参与签名.
+func signV4(req *http.Request, creds credentials, region, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(sigV4TimeFormat)
+	req.Header.Set(headerDate, amzDate)
+	req.Header.Set(headerContentSHA256, payloadHash)
+	if creds.sessionToken != "" {
+		req.Header.Set(headerSecurityToken, creds.sessionToken)
+	}
+
+	signed := make([]string, 0, len(req.Header)+1)
+	signed = append(signed, "host")
+	for name := range req.Header {
+		if lower := strings.ToLower(name); lower != "authorization" {
+			signed = append(signed, lower)
+		}
+	}
+	sort.Strings(signed)
+
+	scope := credentialScope(amzDate, region)
+	canonical := canonicalRequest(req.Method, req.URL.Path, req.URL.Query(), req.Header, requestHost(req), signed, payloadHash)
+	signature := sigV4Signature(creds.secretKey, amzDate, region, canonical)
+	req.Header.Set("Authorization", sigV4Algorithm+
+		" Credential="+creds.accessKey+"/"+scope+
+		", SignedHeaders="+strings.Join(signed, ";")+
+		", Signature="+signature)
+}
+
+func requestHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+func credentialScope(amzDate, region string) string {
+	return amzDate[:len(sigV4DateFormat)] + "/" + region + "/" + sigV4Service + "/aws4_request"
+}
+
+// canonicalRequest 构造规范请求.
+// path 为未转义的路径，S3 只对路径做一次 URI 编码.
+func canonicalRequest(
+	method, path string,
+	query url.Values,
+	header http.Header,
+	host string,
+	signedHeaders []string,
+	payloadHash string,
+) string {
+	var b strings.Builder
+	b.WriteString(method)
+	b.WriteByte('\n')
+	if path == "" {
+		path = "/"
+	}
+	b.WriteString(uriEscape(path, false))
+	b.WriteByte('\n')
+	b.WriteString(canonicalQuery(query))
+	b.WriteByte('\n')
+	for _, name := range signedHeaders {
+		value := host
+		if name != "host" {
+			value = strings.Join(header.Values(name), ",")
+		}
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(strings.Fields(value), " "))
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	b.WriteString(strings.Join(signedHeaders, ";"))
+	b.WriteByte('\n')
+	b.WriteString(payloadHash)
+	return b.String()
+}
+
+// canonicalQuery 按键、值排序并编码查询参数，无值参数编码为 "key=".
+func canonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, uriEscape(key, true)+"="+uriEscape(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// sigV4Signature 对规范请求计算签名.
+func sigV4Signature(secretKey, amzDate, region, canonical string) string {
+	hash := sha256.Sum256([]byte(canonical))
+	stringToSign := sigV4Algorithm + "\n" +
+		amzDate + "\n" +
+		credentialScope(amzDate, region) + "\n" +
+		hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), amzDate[:len(sigV4DateFormat)])
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, sigV4Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEscape 按 RFC 3986 编码，仅保留非保留字符；escapeSlash 为 false 时保留 "/".
+func uriEscape(s string, escapeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9'),
+			c == '-', c == '_', c == '.', c == '~',
+			c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0f])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,235 @@
+// Package storage 提供读写加密数据的存储后端抽象.
+//
+// 存储位置以 URL 表示，按 scheme 选择后端:
+//
+//	/path/to/file.fzj 或 file:///path/to/file.fzj   本地文件系统
+//	s3://bucket/key?endpoint=http://127.0.0.1:9000  S3 兼容对象存储
+//	sftp://user@host:22/path/to/file.fzj            SFTP
+//
+// 写入均为流式：Writer 在 Close 时提交，失败或调用 Abort 时不留下不完整的对象.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// Backend 存储后端.
+type Backend interface {
+	// Create 创建对象写入器，Close 时提交，Abort 时丢弃已写入的数据.
+	Create(name string) (Writer, error)
+	// Open 打开对象进行流式读取.
+	Open(name string) (io.ReadCloser, error)
+	// Stat 返回对象信息，对象不存在时错误满足 errors.Is(err, fs.ErrNotExist).
+	Stat(name string) (Info, error)
+	// Remove 删除对象.
+	Remove(name string) error
+	// Close 释放后端持有的连接.
+	Close() error
+}
+
+// Writer 对象写入器.
+type Writer interface {
+	io.WriteCloser
+	// Abort 放弃写入并清理已上传的部分.
+	Abort() error
+}
+
+// Info 对象信息.
+type Info struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Factory 根据 URL 创建后端，并返回对象在该后端中的名称.
+type Factory func(u *url.URL) (Backend, string, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register 注册 scheme 对应的后端工厂，重复注册会覆盖之前的工厂.
+func Register(scheme string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(scheme)] = factory
+}
+
+// Schemes 返回已注册的 scheme 列表.
+func Schemes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	schemes := make([]string, 0, len(registry))
+	for scheme := range registry {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+func init() {
+	Register("file", newLocalFromURL)
+}
+
+// IsRemote 判断目标是否为非本地存储位置.
+// 没有 scheme 的路径（包括 Windows 盘符路径）和 file:// 都视为本地.
+func IsRemote(target string) bool {
+	scheme, ok := schemeOf(target)
+	return ok && scheme != "file"
+}
+
+// LocalPath 返回本地存储位置对应的文件路径（file:// URL 转换为路径）.
+// 目标为远程存储位置或无效的 file:// URL 时返回 false.
+func LocalPath(target string) (string, bool) {
+	scheme, ok := schemeOf(target)
+	if !ok {
+		return target, true
+	}
+	if scheme != "file" {
+		return "", false
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Host != "" && u.Host != "localhost") {
+		return "", false
+	}
+	return filepath.FromSlash(u.Path), true
+}
+
+func schemeOf(target string) (string, bool) {
+	scheme, _, found := strings.Cut(target, "://")
+	if !found || len(scheme) < 2 {
+		return "", false
+	}
+	for i, c := range scheme {
+		isAlpha := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !isAlpha && (i == 0 || !strings.ContainsRune("0123456789+-.", c)) {
+			return "", false
+		}
+	}
+	return strings.ToLower(scheme), true
+}
+
+// Resolve 解析存储位置，返回对应的后端和对象名称.
+// 调用方负责关闭返回的后端.
+func Resolve(target string) (Backend, string, error) {
+	scheme, ok := schemeOf(target)
+	if !ok {
+		return NewLocal(), target, nil
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, "", utils.NewCryptoError(utils.ErrInvalidParameter, "Invalid storage URL: "+err.Error())
+	}
+
+	registryMu.RLock()
+	factory := registry[scheme]
+	registryMu.RUnlock()
+	if factory == nil {
+		return nil, "", utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Unsupported storage scheme %q (supported: %s)", scheme, strings.Join(Schemes(), ", ")),
+		)
+	}
+	return factory(u)
+}
+
+// Exists 判断存储位置上是否已有对象.
+func Exists(target string) (bool, error) {
+	backend, name, err := Resolve(target)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = backend.Close()
+	}()
+	_, err = backend.Stat(name)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Upload 将本地文件流式写入存储位置，返回写入的字节数.
+func Upload(localPath, target string) (int64, error) {
+	backend, name, err := Resolve(target)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = backend.Close()
+	}()
+
+	src, err := os.Open(localPath) // #nosec G304 - 路径由调用方提供
+	if err != nil {
+		return 0, fmt.Errorf("open %s: %w", localPath, err)
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	w, err := backend.Create(name)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(w, src)
+	if err != nil {
+		_ = w.Abort()
+		return n, fmt.Errorf("upload %s: %w", target, err)
+	}
+	if err := w.Close(); err != nil {
+		return n, fmt.Errorf("upload %s: %w", target, err)
+	}
+	return n, nil
+}
+
+// Download 将存储位置上的对象流式读取到本地文件，返回读取的字节数.
+func Download(target, localPath string) (n int64, err error) {
+	backend, name, err := Resolve(target)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = backend.Close()
+	}()
+
+	src, err := backend.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	dst, err := os.OpenFile(localPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) // #nosec G304 - 路径由调用方提供
+	if err != nil {
+		return 0, fmt.Errorf("create %s: %w", localPath, err)
+	}
+	defer func() {
+		if closeErr := dst.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("close %s: %w", localPath, closeErr)
+		}
+	}()
+
+	n, err = io.Copy(dst, src)
+	if err != nil {
+		return n, fmt.Errorf("download %s: %w", target, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTempFile(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "input.bin")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func assertSameFile(t *testing.T, a, b string) {
+	t.Helper()
+	dataA, err := os.ReadFile(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataB, err := os.ReadFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dataA, dataB) {
+		t.Fatalf("%s and %s differ", a, b)
+	}
+}
+
+func TestIsRemote(t *testing.T) {
+	tests := map[string]bool{
+		"out.fzj":                    false,
+		"/tmp/out.fzj":               false,
+		`C:\data\out.fzj`:            false,
+		"file:///tmp/out.fzj":        false,
+		"s3://bucket/key":            true,
+		"SFTP://host/path":           true,
+		"dir/with://colon":           false,
+		"x+y://host/path":            true,
+		"1s3://bucket/key":           false,
+		"./s3://bucket/not-a-scheme": false,
+	}
+	for target, want := range tests {
+		if got := IsRemote(target); got != want {
+			t.Errorf("IsRemote(%q) = %v, want %v", target, got, want)
+		}
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		target string
+		path   string
+		ok     bool
+	}{
+		{"out.fzj", "out.fzj", true},
+		{"file:///tmp/out.fzj", filepath.FromSlash("/tmp/out.fzj"), true},
+		{"file://localhost/tmp/out.fzj", filepath.FromSlash("/tmp/out.fzj"), true},
+		{"file://remote/tmp/out.fzj", "", false},
+		{"s3://bucket/key", "", false},
+	}
+	for _, tt := range tests {
+		path, ok := LocalPath(tt.target)
+		if path != tt.path || ok != tt.ok {
+			t.Errorf("LocalPath(%q) = %q, %v; want %q, %v", tt.target, path, ok, tt.path, tt.ok)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	backend, name, err := Resolve("file:///tmp/a.fzj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := backend.(*Local); !ok || name != filepath.FromSlash("/tmp/a.fzj") {
+		t.Fatalf("Resolve(file://) = %T %q", backend, name)
+	}
+
+	if _, _, err := Resolve("gopher://host/x"); err == nil {
+		t.Fatal("expected error for unsupported scheme")
+	}
+	if _, _, err := Resolve("s3://bucket"); err == nil {
+		t.Fatal("expected error for S3 URL without key")
+	}
+	if _, _, err := Resolve("file://remote/tmp/a"); err == nil {
+		t.Fatal("expected error for file URL with remote host")
+	}
+}
+
+func TestLocalWriterCommitAndAbort(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewLocal()
+	target := filepath.Join(dir, "out.fzj")
+
+	w, err := backend.Create(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(target); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal("target visible before Close")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := backend.Stat(target)
+	if err != nil || info.Size != 5 {
+		t.Fatalf("Stat = %+v, %v", info, err)
+	}
+
+	w, err = backend.Create(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("abort changed target: %q, %v", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("abort left %d entries in directory", len(entries))
+	}
+}
+
+func TestUploadDownloadLocal(t *testing.T) {
+	src := writeTempFile(t, bytes.Repeat([]byte("fzjjyz"), 10000))
+	target := "file://" + filepath.ToSlash(filepath.Join(t.TempDir(), "copy.fzj"))
+	n, err := Upload(src, target)
+	if err != nil || n != 60000 {
+		t.Fatalf("Upload = %d, %v", n, err)
+	}
+	exists, err := Exists(target)
+	if err != nil || !exists {
+		t.Fatalf("Exists = %v, %v", exists, err)
+	}
+	dst := filepath.Join(t.TempDir(), "back.fzj")
+	if _, err := Download(target, dst); err != nil {
+		t.Fatal(err)
+	}
+	assertSameFile(t, src, dst)
+
+	exists, err = Exists(target + ".missing")
+	if err != nil || exists {
+		t.Fatalf("Exists(missing) = %v, %v", exists, err)
+	}
+}
@@ -93,3 +93,44 @@ func IsSecurityError(err error) bool {
 	}
 	return false
 }
+
+// errorCodeNames 错误代码的稳定名称，用于日志和 JSON 等机器可读输出.
+var errorCodeNames = map[ErrorCode]string{
+	ErrSystem:                "system",
+	ErrIOError:               "io_error",
+	ErrInvalidMagic:          "invalid_magic",
+	ErrInvalidFormat:         "invalid_format",
+	ErrVersionMismatch:       "version_mismatch",
+	ErrInvalidVersion:        "invalid_version",
+	ErrInvalidAlgorithm:      "invalid_algorithm",
+	ErrInvalidKey:            "invalid_key",
+	ErrKeyGenerationFailed:   "key_generation_failed",
+	ErrInvalidData:           "invalid_data",
+	ErrEncryptionFailed:      "encryption_failed",
+	ErrDecryptionFailed:      "decryption_failed",
+	ErrSerializationFailed:   "serialization_failed",
+	ErrAuthFailed:            "auth_failed",
+	ErrSignatureVerification: "signature_verification",
+	ErrHashMismatch:          "hash_mismatch",
+	ErrSigningFailed:         "signing_failed",
+	ErrVerificationFailed:    "verification_failed",
+	ErrInvalidParameter:      "invalid_parameter",
+	ErrFileNotFound:          "file_not_found",
+}
+
+// String 返回错误代码的稳定名称.
+func (c ErrorCode) String() string {
+	if name, ok := errorCodeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("error_%d", int(c))
+}
+
+// CodeOf 返回错误链中第一个 CryptoError 的代码，不存在时返回 false.
+func CodeOf(err error) (ErrorCode, bool) {
+	ce := &CryptoError{}
+	if errors.As(err, &ce) {
+		return ce.Code, true
+	}
+	return ErrSystem, false
+}
@@ -1,6 +1,7 @@
 package utils_test
 
 import (
+	"fmt"
 	"testing"
 
 	"codeberg.org/jiangfire/fzjjyz/internal/utils"
@@ -107,3 +108,21 @@ func TestErrorClassificationBoundaries(t *testing.T) {
 		t.Error("nil should not be format error")
 	}
 }
+
+// 测试8: 错误代码名称与提取.
+func TestErrorCodeString(t *testing.T) {
+	if got := utils.ErrDecryptionFailed.String(); got != "decryption_failed" {
+		t.Errorf("Expected decryption_failed, got %q", got)
+	}
+	if got := utils.ErrorCode(999).String(); got != "error_999" {
+		t.Errorf("Expected error_999, got %q", got)
+	}
+
+	wrapped := fmt.Errorf("decrypt: %w", utils.NewCryptoError(utils.ErrHashMismatch, "hash"))
+	if code, ok := utils.CodeOf(wrapped); !ok || code != utils.ErrHashMismatch {
+		t.Errorf("Expected ErrHashMismatch, got %v, %v", code, ok)
+	}
+	if _, ok := utils.CodeOf(fmt.Errorf("plain")); ok {
+		t.Error("Plain error should have no code")
+	}
+}
@@ -4,18 +4,31 @@ package zjcrypto
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
 	"codeberg.org/jiangfire/fzjjyz/internal/utils"
 )
 
 const (
-	// maxTotalSize 限制解压总大小，防止解压缩炸弹（1GB）。
-	maxTotalSize = 1024 * 1024 * 1024
+	// DefaultMaxTotalSize 默认解压总大小上限（1GB）.
+	DefaultMaxTotalSize = 1024 * 1024 * 1024
+	// DefaultMaxFileSize 默认单个条目大小上限（1GB）.
+	DefaultMaxFileSize = 1024 * 1024 * 1024
+	// DefaultMaxEntries 默认条目数量上限.
+	DefaultMaxEntries = 100000
+	// DefaultMaxPathDepth 默认路径深度上限.
+	DefaultMaxPathDepth = 64
+	// DefaultMaxCompressionRatio 默认单个条目压缩比上限.
+	DefaultMaxCompressionRatio = 1024
 	// dirPerm 使用更安全的目录权限。
 	dirPerm = 0750
 )
@@ -25,6 +38,9 @@ type ArchiveOptions struct {
 	IncludePatterns []string // 包含的文件模式（glob）
 	ExcludePatterns []string // 排除的文件模式（glob）
 	FollowSymlinks  bool     // 是否跟随符号链接
+
+	// ManifestKey 非空时为所有条目生成签名清单，作为最后一个条目写入存档
+	ManifestKey SigningKey
 }
 
 // DefaultArchiveOptions 默认打包选项.
@@ -34,6 +50,27 @@ var DefaultArchiveOptions = ArchiveOptions{
 	FollowSymlinks:  false,
 }
 
+// ExtractOptions 解压限制选项（防止解压缩炸弹），各字段为 0 表示不限制.
+type ExtractOptions struct {
+	MaxTotalSize        int64 // 实际写入的总字节数上限
+	MaxFileSize         int64 // 单个条目实际写入的字节数上限
+	MaxEntries          int   // 条目（文件和目录）数量上限
+	MaxPathDepth        int   // 条目路径深度上限
+	MaxCompressionRatio int64 // 单个条目解压后大小与压缩大小之比的上限
+
+	// Only 仅解压匹配这些模式的条目（语法见 MatchZipPatterns），为空时解压全部
+	Only []string
+}
+
+// DefaultExtractOptions 默认解压限制.
+var DefaultExtractOptions = ExtractOptions{
+	MaxTotalSize:        DefaultMaxTotalSize,
+	MaxFileSize:         DefaultMaxFileSize,
+	MaxEntries:          DefaultMaxEntries,
+	MaxPathDepth:        DefaultMaxPathDepth,
+	MaxCompressionRatio: DefaultMaxCompressionRatio,
+}
+
 // CreateZipFromDirectory 将目录打包成ZIP
 // 输入: 源目录路径, 输出缓冲区, 打包选项
 // 返回: 错误
@@ -78,9 +115,10 @@ func CreateZipFromDirectory(sourceDir string, output io.Writer, opts ArchiveOpti
 		_ = root.Close()
 	}()
 
+	var manifest format.Manifest
+
 	// 递归遍历目录
-	//nolint:wrapcheck // filepath.Walk 的错误已在回调中包装，此处直接返回
-	return filepath.Walk(absSource, func(path string, info os.FileInfo, walkErr error) error {
+	walkErr := filepath.Walk(absSource, func(path string, info os.FileInfo, walkErr error) error {
 		if walkErr != nil {
 			return fmt.Errorf("walk error at %s: %w", path, walkErr)
 		}
@@ -110,21 +148,29 @@ func CreateZipFromDirectory(sourceDir string, output io.Writer, opts ArchiveOpti
 		// 转换为ZIP路径格式（使用正斜杠）
 		zipPath := filepath.ToSlash(relPath)
 
+		// 旧清单（如重新打包已解压的目录）由新清单取代
+		if zipPath == format.ManifestName && opts.ManifestKey != nil {
+			return nil
+		}
+
 		// 处理目录
 		if info.IsDir() {
+			manifest.Entries = append(manifest.Entries, format.ManifestEntry{
+				Name: zipPath, IsDir: true, Mode: info.Mode().Perm(),
+			})
 			// ZIP中目录以斜杠结尾
 			if !strings.HasSuffix(zipPath, "/") {
 				zipPath += "/"
 			}
-			_, err := zipWriter.Create(zipPath)
+			_, err := zipWriter.CreateHeader(zipEntryHeader(zipPath, info, zip.Store))
 			if err != nil {
 				return fmt.Errorf("create zip dir entry: %w", err)
 			}
 			return nil
 		}
 
-		// 处理文件
-		header, err := zipWriter.Create(zipPath)
+		// 处理文件（记录权限和修改时间）
+		header, err := zipWriter.CreateHeader(zipEntryHeader(zipPath, info, zip.Deflate))
 		if err != nil {
 			return fmt.Errorf("create zip file entry: %w", err)
 		}
@@ -138,77 +184,125 @@ func CreateZipFromDirectory(sourceDir string, output io.Writer, opts ArchiveOpti
 			_ = file.Close()
 		}()
 
-		_, err = io.Copy(header, file)
+		hasher := sha256.New()
+		n, err := io.Copy(io.MultiWriter(header, hasher), file)
 		if err != nil {
 			return fmt.Errorf("copy file content: %w", err)
 		}
+
+		entry := format.ManifestEntry{Name: zipPath, Size: uint64(n), Mode: info.Mode().Perm()} //nolint:gosec
+		copy(entry.SHA256[:], hasher.Sum(nil))
+		manifest.Entries = append(manifest.Entries, entry)
 		return nil
 	})
+	if walkErr != nil {
+		return walkErr //nolint:wrapcheck // filepath.Walk 的错误已在回调中包装
+	}
+
+	if opts.ManifestKey != nil {
+		return writeZipManifest(zipWriter, &manifest, opts.ManifestKey)
+	}
+	return nil
 }
 
-// ExtractZipToDirectory 将ZIP解压到目录
-// 输入: ZIP数据, 目标目录路径
-// 返回: 错误
-//
-//nolint:funlen,gocognit // 解压逻辑需要完整处理路径验证、目录创建和文件写入，复杂度较高
-func ExtractZipToDirectory(zipData []byte, targetDir string) error {
-	// 检查ZIP数据大小，防止解压缩炸弹
-	if int64(len(zipData)) > maxTotalSize {
-		return utils.NewCryptoError(
-			utils.ErrInvalidParameter,
-			fmt.Sprintf("ZIP data too large: %d bytes (max: %d)", len(zipData), maxTotalSize),
-		)
+// writeZipManifest 签名清单并写入存档根目录.
+func writeZipManifest(zipWriter *zip.Writer, manifest *format.Manifest, key SigningKey) error {
+	data, err := SignManifest(manifest, key)
+	if err != nil {
+		return err
 	}
 
-	// 创建目标目录 - 使用更安全的权限
-	if err := os.MkdirAll(targetDir, dirPerm); err != nil {
-		return fmt.Errorf("create target directory: %w", err)
+	header := &zip.FileHeader{
+		Name:     format.ManifestName,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
 	}
+	header.SetMode(0600)
+	w, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("create manifest entry: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+// zipEntryHeader 构建记录权限和修改时间的 ZIP 条目头.
+func zipEntryHeader(zipPath string, info os.FileInfo, method uint16) *zip.FileHeader {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		header = &zip.FileHeader{}
+	}
+	header.Name = zipPath
+	header.Method = method
+	return header
+}
 
+// ExtractZipToDirectory 将ZIP解压到目录（使用默认解压限制）
+// 输入: ZIP数据, 目标目录路径
+// 返回: 错误.
+func ExtractZipToDirectory(zipData []byte, targetDir string) error {
+	return ExtractZipToDirectoryWithOptions(zipData, targetDir, DefaultExtractOptions)
+}
+
+// ExtractZipToDirectoryWithOptions 按指定限制将ZIP解压到目录
+// 所有大小限制都按实际写入的字节数执行，不信任ZIP目录中声明的大小.
+//
+//nolint:funlen,gocognit // 解压逻辑需要完整处理路径验证、限制检查、目录创建和文件写入，复杂度较高
+func ExtractZipToDirectoryWithOptions(zipData []byte, targetDir string, opts ExtractOptions) error {
 	// 创建ZIP读取器
 	reader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
 	if err != nil {
 		return fmt.Errorf("invalid ZIP format: %w", err)
 	}
 
-	// 计算总大小用于防炸弹
-	var totalSize int64
+	// 解压前检查条目数量、重名条目和重叠条目（防止重叠型解压缩炸弹）
+	if err := checkZipEntries(reader.File, opts); err != nil {
+		return err
+	}
+	if err := ValidateZipPatterns(opts.Only); err != nil {
+		return err
+	}
+
+	// 创建目标目录 - 使用更安全的权限
+	if err := os.MkdirAll(targetDir, dirPerm); err != nil {
+		return fmt.Errorf("create target directory: %w", err)
+	}
+
+	// 按实际写入字节累计总大小
+	var totalWritten int64
 
 	// 遍历ZIP中的所有文件
 	for _, file := range reader.File {
 		// 防止路径遍历攻击 - 检查原始ZIP路径
-		if strings.Contains(file.Name, "..") || strings.HasPrefix(file.Name, "/") || strings.HasPrefix(file.Name, "\\") {
+		if !isSafeZipName(file.Name) {
 			return utils.NewCryptoError(
 				utils.ErrInvalidParameter,
 				"Invalid file path in ZIP: "+file.Name,
 			)
 		}
 
-		// 构建完整的目标路径并验证安全性
-		// G305: 使用 validateAndExtractPath 防止路径遍历攻击
-		targetPath, err := validateAndExtractPath(file.Name, targetDir)
-		if err != nil {
-			return utils.NewCryptoError(
-				utils.ErrInvalidParameter,
-				"Invalid extraction path: "+err.Error(),
-			)
+		// 跳过未选中的条目（清单始终解压，供 check-tree 使用）
+		if file.Name != format.ManifestName && !MatchZipPatterns(opts.Only, file.Name) {
+			continue
 		}
 
-		// 累加大小检查（防止解压缩炸弹）
-		// G115: uint64 -> int64 转换，但有 maxTotalSize=1GB 限制，不会溢出
-		// 因为 maxTotalSize=1GB < math.MaxInt64，所以转换是安全的
-		size := file.UncompressedSize64
-		if size > uint64(maxTotalSize) {
+		// 限制路径深度
+		if opts.MaxPathDepth > 0 && zipPathDepth(file.Name) > opts.MaxPathDepth {
 			return utils.NewCryptoError(
 				utils.ErrInvalidParameter,
-				fmt.Sprintf("File size exceeds limit: %d bytes", size),
+				fmt.Sprintf("Path too deep in ZIP: %s (max depth: %d)", file.Name, opts.MaxPathDepth),
 			)
 		}
-		totalSize += int64(size)
-		if totalSize > maxTotalSize {
+
+		// 构建完整的目标路径并验证安全性
+		// G305: 使用 validateAndExtractPath 防止路径遍历攻击
+		targetPath, err := validateAndExtractPath(file.Name, targetDir)
+		if err != nil {
 			return utils.NewCryptoError(
 				utils.ErrInvalidParameter,
-				fmt.Sprintf("Total uncompressed size exceeds limit: %d bytes", totalSize),
+				"Invalid extraction path: "+err.Error(),
 			)
 		}
 
@@ -225,16 +319,19 @@ func ExtractZipToDirectory(zipData []byte, targetDir string) error {
 			return fmt.Errorf("create parent dir for %s: %w", targetPath, err)
 		}
 
-		// 打开ZIP中的文件
-		if err := extractZipFile(file, targetPath); err != nil {
+		// 解压单个文件，写入量受剩余配额约束
+		written, err := extractZipFile(file, targetPath, opts, totalWritten)
+		if err != nil {
 			return err
 		}
+		totalWritten += written
 	}
 
 	return nil
 }
 
-// GetZipSize 计算ZIP数据的总大小（用于进度条）.
+// GetZipSize 计算ZIP数据的总大小（用于进度条）
+// 注意：返回值来自ZIP目录声明，仅用于展示，不能作为安全限制依据.
 func GetZipSize(zipData []byte) (int64, error) {
 	reader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
 	if err != nil {
@@ -243,9 +340,8 @@ func GetZipSize(zipData []byte) (int64, error) {
 
 	var totalSize int64
 	for _, file := range reader.File {
-		// G115: uint64 -> int64 转换，但有 maxTotalSize=1GB 限制，不会溢出
-		// 因为 maxTotalSize=1GB < math.MaxInt64，所以转换是安全的
-		if file.UncompressedSize64 > uint64(maxTotalSize) {
+		// G115: uint64 -> int64 转换前检查溢出
+		if file.UncompressedSize64 > uint64(math.MaxInt64-totalSize) { //nolint:gosec
 			return 0, fmt.Errorf("file size exceeds maximum: %d bytes", file.UncompressedSize64)
 		}
 		totalSize += int64(file.UncompressedSize64) //nolint:gosec
@@ -255,10 +351,13 @@ func GetZipSize(zipData []byte) (int64, error) {
 }
 
 // extractZipFile 将单个 ZIP 条目解压到目标文件，确保每次循环及时释放文件句柄。
-func extractZipFile(file *zip.File, targetPath string) error {
+// 返回实际写入的字节数；超出任何限制时删除已写入的部分文件并返回错误。
+func extractZipFile(file *zip.File, targetPath string, opts ExtractOptions, totalWritten int64) (int64, error) {
+	limit, reason := entryWriteLimit(file, opts, totalWritten)
+
 	srcFile, err := file.Open()
 	if err != nil {
-		return fmt.Errorf("open zip entry %s: %w", file.Name, err)
+		return 0, fmt.Errorf("open zip entry %s: %w", file.Name, err)
 	}
 	defer func() {
 		_ = srcFile.Close()
@@ -272,21 +371,151 @@ func extractZipFile(file *zip.File, targetPath string) error {
 		file.Mode(),
 	) //nolint:gosec
 	if err != nil {
-		return fmt.Errorf("create output file %s: %w", targetPath, err)
+		return 0, fmt.Errorf("create output file %s: %w", targetPath, err)
 	}
 	defer func() {
 		_ = dstFile.Close()
 	}()
 
-	// G110: 已通过 totalSize 检查限制总大小（maxTotalSize=1GB），防止解压缩炸弹
-	// io.Copy 的安全性已通过前面的大小检查保证
-	if _, err := io.Copy(dstFile, srcFile); err != nil { // #nosec G110
-		return fmt.Errorf("copy content to %s: %w", targetPath, err)
+	// 多读 1 字节用于判断是否超限；限制基于实际解压出的字节，而非ZIP头声明
+	src := io.Reader(srcFile)
+	if limit >= 0 {
+		src = io.LimitReader(srcFile, limit+1)
+	}
+	written, err := io.Copy(dstFile, src)
+	if err != nil {
+		_ = dstFile.Close()
+		_ = os.Remove(targetPath)
+		return written, fmt.Errorf("copy content to %s: %w", targetPath, err)
+	}
+
+	if limit >= 0 && written > limit {
+		_ = dstFile.Close()
+		_ = os.Remove(targetPath)
+		return written, utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Extraction limit exceeded for %s: %s", file.Name, reason),
+		)
+	}
+
+	// OpenFile 的权限受 umask 影响，显式恢复存档记录的权限
+	if err := os.Chmod(targetPath, file.Mode().Perm()); err != nil {
+		return written, fmt.Errorf("chmod %s: %w", targetPath, err)
+	}
+
+	// 恢复修改时间（旧版本存档未记录时间则跳过）
+	if !file.Modified.IsZero() {
+		_ = os.Chtimes(targetPath, time.Time{}, file.Modified)
 	}
 
+	return written, nil
+}
+
+// entryWriteLimit 计算单个条目允许写入的最大字节数（-1 表示不限制）及对应的限制说明.
+func entryWriteLimit(file *zip.File, opts ExtractOptions, totalWritten int64) (int64, string) {
+	limit := int64(-1)
+	reason := ""
+	apply := func(candidate int64, why string) {
+		if candidate < 0 {
+			candidate = 0
+		}
+		if limit < 0 || candidate < limit {
+			limit = candidate
+			reason = why
+		}
+	}
+
+	if opts.MaxFileSize > 0 {
+		apply(opts.MaxFileSize, fmt.Sprintf("entry larger than %d bytes", opts.MaxFileSize))
+	}
+	if opts.MaxTotalSize > 0 {
+		apply(opts.MaxTotalSize-totalWritten, fmt.Sprintf("total size larger than %d bytes", opts.MaxTotalSize))
+	}
+	if opts.MaxCompressionRatio > 0 {
+		compressed := file.CompressedSize64
+		if compressed == 0 {
+			compressed = 1
+		}
+		// 避免乘法溢出
+		if compressed <= uint64(math.MaxInt64/opts.MaxCompressionRatio) { //nolint:gosec
+			apply(int64(compressed)*opts.MaxCompressionRatio, //nolint:gosec
+				fmt.Sprintf("compression ratio higher than %d:1", opts.MaxCompressionRatio))
+		}
+	}
+
+	return limit, reason
+}
+
+// checkZipEntries 在读取任何条目前检查条目数量、重名条目和数据区重叠.
+// 解压和校验共用这些检查，保证校验通过的存档一定能按相同内容解压.
+func checkZipEntries(files []*zip.File, opts ExtractOptions) error {
+	if opts.MaxEntries > 0 && len(files) > opts.MaxEntries {
+		return utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Too many entries in ZIP: %d (max: %d)", len(files), opts.MaxEntries),
+		)
+	}
+	// 同名条目解压时后者覆盖前者，清单或校验看到的内容可能与恢复结果不同
+	seen := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name, "/")
+		if _, ok := seen[name]; ok {
+			return utils.NewCryptoError(utils.ErrInvalidFormat, "Duplicate entry in ZIP: "+name)
+		}
+		seen[name] = struct{}{}
+	}
+	return checkOverlappingEntries(files)
+}
+
+// isSafeZipName 判断ZIP条目路径不含上级引用且不是绝对路径.
+func isSafeZipName(name string) bool {
+	return !strings.Contains(name, "..") && !strings.HasPrefix(name, "/") && !strings.HasPrefix(name, "\\")
+}
+
+// checkOverlappingEntries 检查ZIP条目的数据区是否重叠（重叠条目是常见的解压缩炸弹手法）.
+func checkOverlappingEntries(files []*zip.File) error {
+	type span struct {
+		start, end int64
+		name       string
+	}
+
+	spans := make([]span, 0, len(files))
+	for _, file := range files {
+		if file.CompressedSize64 == 0 {
+			continue
+		}
+		offset, err := file.DataOffset()
+		if err != nil {
+			return fmt.Errorf("locate zip entry %s: %w", file.Name, err)
+		}
+		spans = append(spans, span{
+			start: offset,
+			end:   offset + int64(file.CompressedSize64), //nolint:gosec
+			name:  file.Name,
+		})
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	for i := 1; i < len(spans); i++ {
+		if spans[i].start < spans[i-1].end {
+			return utils.NewCryptoError(
+				utils.ErrInvalidParameter,
+				fmt.Sprintf("Overlapping entries in ZIP: %s and %s", spans[i-1].name, spans[i].name),
+			)
+		}
+	}
 	return nil
 }
 
+// zipPathDepth 计算ZIP条目路径的层级数.
+func zipPathDepth(name string) int {
+	trimmed := strings.Trim(strings.ReplaceAll(name, "\\", "/"), "/")
+	if trimmed == "" {
+		return 0
+	}
+	return strings.Count(trimmed, "/") + 1
+}
+
 // CountZipFiles 统计ZIP中的文件数量.
 func CountZipFiles(zipData []byte) (int, error) {
 	reader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
@@ -0,0 +1,118 @@
+package zjcrypto
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// ArchiveEntry 存档条目信息（仅来自目录/索引，不解密数据）.
+type ArchiveEntry struct {
+	Name           string      // 条目路径（使用 / 分隔）
+	Size           uint64      // 声明的解压后大小
+	CompressedSize uint64      // 压缩后大小
+	Mode           os.FileMode // 文件权限和类型
+	Modified       time.Time   // 修改时间
+	IsDir          bool        // 是否为目录
+}
+
+// ListZipEntries 列出ZIP中的所有条目.
+func ListZipEntries(zipData []byte) ([]ArchiveEntry, error) {
+	reader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, fmt.Errorf("create ZIP reader: %w", err)
+	}
+
+	entries := make([]ArchiveEntry, 0, len(reader.File))
+	for _, file := range reader.File {
+		info := file.FileInfo()
+		entries = append(entries, ArchiveEntry{
+			Name:           file.Name,
+			Size:           file.UncompressedSize64,
+			CompressedSize: file.CompressedSize64,
+			Mode:           info.Mode(),
+			Modified:       file.Modified,
+			IsDir:          info.IsDir(),
+		})
+	}
+	return entries, nil
+}
+
+// ValidateZipPatterns 检查条目匹配模式的语法.
+func ValidateZipPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		normalized := normalizeZipPattern(pattern)
+		if normalized == "" {
+			return utils.NewCryptoError(utils.ErrInvalidParameter, "Empty pattern")
+		}
+		for _, segment := range strings.Split(normalized, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return utils.NewCryptoError(
+					utils.ErrInvalidParameter,
+					fmt.Sprintf("Invalid pattern %q: %v", pattern, err),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// MatchZipPatterns 判断条目是否匹配任意模式，模式为空时匹配所有条目.
+// 模式按 / 分段匹配，"**" 匹配任意层级；匹配某个目录的模式同时匹配其下所有条目.
+func MatchZipPatterns(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	nameSegments := strings.Split(strings.Trim(name, "/"), "/")
+	for _, pattern := range patterns {
+		normalized := normalizeZipPattern(pattern)
+		if normalized == "" {
+			continue
+		}
+		patternSegments := strings.Split(normalized, "/")
+		// 依次尝试条目自身及其各级父目录
+		for n := len(nameSegments); n > 0; n-- {
+			if matchSegments(patternSegments, nameSegments[:n]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeZipPattern 统一模式的分隔符并去除首尾多余部分.
+func normalizeZipPattern(pattern string) string {
+	normalized := strings.ReplaceAll(strings.TrimSpace(pattern), "\\", "/")
+	normalized = strings.TrimPrefix(normalized, "./")
+	return strings.Trim(normalized, "/")
+}
+
+// matchSegments 按路径段匹配，支持 "**" 匹配零个或多个段.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,372 @@
+package zjcrypto
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ecdh"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem"
+)
+
+// ArchiveFS 目录存档的只读 fs.FS 视图，实现 fs.ReadDirFS、fs.StatFS 和 fs.ReadFileFS.
+// 文件内容在打开时按需解密：索引存档只解密对应条目，ZIP 存档在打开存档时整体解密一次.
+// 打开的文件实现 io.Seeker 和 io.ReaderAt，可直接用于 http.FileServer(http.FS(...)).
+// 可并发使用.
+type ArchiveFS struct {
+	nodes  map[string]*archiveNode
+	read   func(node *archiveNode) ([]byte, error)
+	closer io.Closer
+}
+
+// archiveNode 存档中的文件或目录（包括隐含的上级目录）.
+type archiveNode struct {
+	name     string // 完整路径，根目录为 "."
+	size     int64
+	mode     fs.FileMode
+	modTime  time.Time
+	children []*archiveNode // 按名称排序
+	zipFile  *zip.File      // ZIP 存档中的条目
+}
+
+// OpenArchiveFS 打开加密的目录存档（ZIP 或索引存档）并返回文件系统视图.
+// dilithiumPub 为 nil 时跳过签名验证；ZIP 存档的条目读取受 opts 限制（见 NewZipFS）.
+func OpenArchiveFS(
+	archivePath string,
+	kyberPriv kem.PrivateKey,
+	ecdhPriv *ecdh.PrivateKey,
+	dilithiumPub VerifyingKey,
+	opts ExtractOptions,
+) (*ArchiveFS, error) {
+	// #nosec G304 - archivePath 应由调用方验证
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	header, err := format.ParseFileHeader(file)
+	_ = file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("parse file header: %w", err)
+	}
+
+	if header.IsIndexedArchive() {
+		archive, err := OpenIndexedArchive(archivePath, kyberPriv, ecdhPriv, dilithiumPub)
+		if err != nil {
+			return nil, err
+		}
+		fsys, err := archive.FS()
+		if err != nil {
+			_ = archive.Close()
+			return nil, err
+		}
+		return fsys, nil
+	}
+
+	zipData, err := DecryptFileCore(archivePath, kyberPriv, ecdhPriv, dilithiumPub)
+	if err != nil {
+		return nil, err
+	}
+	return NewZipFS(zipData, opts)
+}
+
+// NewZipFS 返回已解密 ZIP 存档的文件系统视图，签名清单条目不可见.
+// 条目数量、路径深度在打开时检查；每次读取条目时按实际解压的字节数执行 opts 中的单文件、
+// 总大小和压缩比限制（总大小限制按单个条目计算），opts.Only 不起作用.
+func NewZipFS(zipData []byte, opts ExtractOptions) (*ArchiveFS, error) {
+	reader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid ZIP archive: "+err.Error())
+	}
+	if opts.MaxEntries > 0 && len(reader.File) > opts.MaxEntries {
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Too many entries in ZIP: %d (max: %d)", len(reader.File), opts.MaxEntries),
+		)
+	}
+	if err := checkOverlappingEntries(reader.File); err != nil {
+		return nil, err
+	}
+
+	fsys := newArchiveFS()
+	for _, file := range reader.File {
+		name := strings.TrimSuffix(file.Name, "/")
+		if name == format.ManifestName {
+			continue
+		}
+		if opts.MaxPathDepth > 0 && zipPathDepth(file.Name) > opts.MaxPathDepth {
+			return nil, utils.NewCryptoError(
+				utils.ErrInvalidParameter,
+				fmt.Sprintf("Path too deep in ZIP: %s (max depth: %d)", file.Name, opts.MaxPathDepth),
+			)
+		}
+		info := file.FileInfo()
+		node := &archiveNode{
+			name:    name,
+			size:    int64(file.UncompressedSize64), // #nosec G115 - 由 ZIP 读取器校验
+			mode:    info.Mode(),
+			modTime: file.Modified,
+			zipFile: file,
+		}
+		if info.IsDir() {
+			node.size = 0
+			node.mode = fs.ModeDir | info.Mode().Perm()
+			node.zipFile = nil
+		}
+		if err := fsys.add(node); err != nil {
+			return nil, err
+		}
+	}
+	fsys.read = func(node *archiveNode) ([]byte, error) {
+		return readZipEntry(node.zipFile, opts)
+	}
+	fsys.finish()
+	return fsys, nil
+}
+
+// readZipEntry 解压单个 ZIP 条目，读取量受限制约束.
+func readZipEntry(file *zip.File, opts ExtractOptions) ([]byte, error) {
+	limit, reason := entryWriteLimit(file, opts, 0)
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", file.Name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	r := io.Reader(rc)
+	if limit >= 0 {
+		r = io.LimitReader(rc, limit+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", file.Name, err)
+	}
+	if limit >= 0 && int64(len(data)) > limit {
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			"Extraction limit exceeded: "+reason,
+		)
+	}
+	return data, nil
+}
+
+// FS 返回索引存档的文件系统视图，ArchiveFS.Close 会同时关闭存档.
+func (a *IndexedArchive) FS() (*ArchiveFS, error) {
+	fsys := newArchiveFS()
+	for i := range a.index.Entries {
+		e := &a.index.Entries[i]
+		node := &archiveNode{name: e.Name, modTime: e.Modified(), mode: e.Mode}
+		if e.IsDir() {
+			node.mode |= fs.ModeDir
+		} else {
+			node.size = int64(e.Size) // #nosec G115 - 已通过 ValidateLayout 验证
+		}
+		if err := fsys.add(node); err != nil {
+			return nil, err
+		}
+	}
+	fsys.read = func(node *archiveNode) ([]byte, error) {
+		var buf bytes.Buffer
+		buf.Grow(int(node.size))
+		if err := a.WriteEntry(&buf, node.name); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	fsys.closer = a
+	fsys.finish()
+	return fsys, nil
+}
+
+func newArchiveFS() *ArchiveFS {
+	root := &archiveNode{name: ".", mode: fs.ModeDir | 0755}
+	return &ArchiveFS{nodes: map[string]*archiveNode{".": root}}
+}
+
+// add 添加条目并补全隐含的上级目录.
+func (f *ArchiveFS) add(node *archiveNode) error {
+	if !fs.ValidPath(node.name) || node.name == "." {
+		return utils.NewCryptoError(utils.ErrInvalidParameter, "Invalid entry name in archive: "+node.name)
+	}
+	if existing, ok := f.nodes[node.name]; ok {
+		// 隐含目录可由之后的目录条目补充属性
+		if existing.mode.IsDir() && node.mode.IsDir() {
+			existing.mode, existing.modTime = node.mode, node.modTime
+			return nil
+		}
+		return utils.NewCryptoError(utils.ErrInvalidFormat, "Duplicate entry in archive: "+node.name)
+	}
+	f.nodes[node.name] = node
+
+	for child := node; ; {
+		parentName := path.Dir(child.name)
+		parent, ok := f.nodes[parentName]
+		if !ok {
+			parent = &archiveNode{name: parentName, mode: fs.ModeDir | 0755}
+			f.nodes[parentName] = parent
+		} else if !parent.mode.IsDir() {
+			return utils.NewCryptoError(utils.ErrInvalidFormat, "Entry is inside a file: "+node.name)
+		}
+		parent.children = append(parent.children, child)
+		if ok {
+			return nil
+		}
+		child = parent
+	}
+}
+
+// finish 对目录的子条目按名称排序.
+func (f *ArchiveFS) finish() {
+	for _, node := range f.nodes {
+		slices.SortFunc(node.children, func(a, b *archiveNode) int {
+			return strings.Compare(a.name, b.name)
+		})
+	}
+}
+
+// lookup 查找条目，名称不合法或不存在时返回 *fs.PathError.
+func (f *ArchiveFS) lookup(op, name string) (*archiveNode, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	node, ok := f.nodes[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return node, nil
+}
+
+// Open 实现 fs.FS，普通文件在此时解密.
+func (f *ArchiveFS) Open(name string) (fs.File, error) {
+	node, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if node.mode.IsDir() {
+		return &archiveDir{node: node}, nil
+	}
+	data, err := f.read(node)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &archiveFile{node: node, Reader: bytes.NewReader(data)}, nil
+}
+
+// ReadFile 实现 fs.ReadFileFS.
+func (f *ArchiveFS) ReadFile(name string) ([]byte, error) {
+	node, err := f.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	data, err := f.read(node)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+// Stat 实现 fs.StatFS，不解密文件内容.
+func (f *ArchiveFS) Stat(name string) (fs.FileInfo, error) {
+	node, err := f.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return node.info(), nil
+}
+
+// ReadDir 实现 fs.ReadDirFS，按名称排序.
+func (f *ArchiveFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	node, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return node.dirEntries(), nil
+}
+
+// Close 关闭底层存档文件.
+func (f *ArchiveFS) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	//nolint:wrapcheck
+	return f.closer.Close()
+}
+
+func (n *archiveNode) info() fs.FileInfo {
+	return archiveFileInfo{n}
+}
+
+func (n *archiveNode) dirEntries() []fs.DirEntry {
+	entries := make([]fs.DirEntry, len(n.children))
+	for i, child := range n.children {
+		entries[i] = fs.FileInfoToDirEntry(child.info())
+	}
+	return entries
+}
+
+// archiveFileInfo 实现 fs.FileInfo.
+type archiveFileInfo struct {
+	node *archiveNode
+}
+
+func (i archiveFileInfo) Name() string       { return path.Base(i.node.name) }
+func (i archiveFileInfo) Size() int64        { return i.node.size }
+func (i archiveFileInfo) Mode() fs.FileMode  { return i.node.mode }
+func (i archiveFileInfo) ModTime() time.Time { return i.node.modTime }
+func (i archiveFileInfo) IsDir() bool        { return i.node.mode.IsDir() }
+func (i archiveFileInfo) Sys() any           { return nil }
+
+// archiveFile 已解密的普通文件.
+type archiveFile struct {
+	node *archiveNode
+	*bytes.Reader
+}
+
+func (f *archiveFile) Stat() (fs.FileInfo, error) { return f.node.info(), nil }
+func (f *archiveFile) Close() error               { return nil }
+
+// archiveDir 目录，实现 fs.ReadDirFile.
+type archiveDir struct {
+	node   *archiveNode
+	offset int
+}
+
+func (d *archiveDir) Stat() (fs.FileInfo, error) { return d.node.info(), nil }
+func (d *archiveDir) Close() error               { return nil }
+
+func (d *archiveDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.node.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir 实现 fs.ReadDirFile.
+func (d *archiveDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries := d.node.dirEntries()[d.offset:]
+	if n <= 0 {
+		d.offset += len(entries)
+		return entries, nil
+	}
+	if len(entries) == 0 {
+		return nil, io.EOF
+	}
+	entries = entries[:min(n, len(entries))]
+	d.offset += len(entries)
+	return entries, nil
+}
@@ -0,0 +1,185 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"testing/fstest"
+)
+
+// encryptZipTestDir 以 ZIP 格式加密目录（与 encrypt-dir 命令相同，带签名清单）.
+func encryptZipTestDir(t *testing.T, keys testKeys, sourceDir string) string {
+	t.Helper()
+	opts := DefaultArchiveOptions
+	opts.ManifestKey = keys.dilithiumPriv
+	var zipBuffer bytes.Buffer
+	if err := CreateZipFromDirectory(sourceDir, &zipBuffer, opts); err != nil {
+		t.Fatalf("打包失败: %v", err)
+	}
+	data, err := EncryptData(zipBuffer.Bytes(), "project.zip", keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, NoCompression)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	archivePath := filepath.Join(t.TempDir(), "project.fzj")
+	if err := os.WriteFile(archivePath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return archivePath
+}
+
+func TestArchiveFS(t *testing.T) {
+	sourceDir, files := createIndexedTestDir(t)
+	keys := generateTestKeys(t)
+
+	indexedPath := filepath.Join(t.TempDir(), "indexed.fzj")
+	if _, err := EncryptDirectoryIndexed(sourceDir, indexedPath, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, DefaultArchiveOptions); err != nil {
+		t.Fatalf("索引存档加密失败: %v", err)
+	}
+	archives := map[string]string{
+		"zip":     encryptZipTestDir(t, keys, sourceDir),
+		"indexed": indexedPath,
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for kind, archivePath := range archives {
+		t.Run(kind, func(t *testing.T) {
+			fsys, err := OpenArchiveFS(archivePath, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub, DefaultExtractOptions)
+			if err != nil {
+				t.Fatalf("OpenArchiveFS 失败: %v", err)
+			}
+			defer func() {
+				_ = fsys.Close()
+			}()
+
+			if err := fstest.TestFS(fsys, names...); err != nil {
+				t.Fatalf("fstest.TestFS: %v", err)
+			}
+
+			// 内容与原文件一致，清单条目不可见
+			var walked []string
+			err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() {
+					walked = append(walked, name)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("WalkDir 失败: %v", err)
+			}
+			if len(walked) != len(names) {
+				t.Fatalf("WalkDir 文件 = %v", walked)
+			}
+			for _, name := range names {
+				got, err := fs.ReadFile(fsys, name)
+				if err != nil || !bytes.Equal(got, files[name]) {
+					t.Fatalf("ReadFile(%s) 内容不一致: %v", name, err)
+				}
+			}
+
+			info, err := fs.Stat(fsys, "docs/guide")
+			if err != nil || !info.IsDir() || info.Name() != "guide" {
+				t.Errorf("Stat(docs/guide) = %v, %v", info, err)
+			}
+			if _, err := fsys.Open("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("期望 fs.ErrNotExist，得到 %v", err)
+			}
+			if _, err := fsys.Open("../etc/passwd"); !errors.Is(err, fs.ErrInvalid) {
+				t.Errorf("期望 fs.ErrInvalid，得到 %v", err)
+			}
+
+			// http.FileServer 支持范围请求
+			server := httptest.NewServer(http.FileServer(http.FS(fsys)))
+			defer server.Close()
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/data/blob.bin", nil)
+			req.Header.Set("Range", "bytes=100-199")
+			resp, err := server.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusPartialContent || !bytes.Equal(body, files["data/blob.bin"][100:200]) {
+				t.Errorf("范围请求失败: %d", resp.StatusCode)
+			}
+		})
+	}
+
+	// 错误的私钥无法打开
+	other := generateTestKeys(t)
+	for kind, archivePath := range archives {
+		if _, err := OpenArchiveFS(archivePath, other.kyberPriv, other.ecdhPriv, nil, DefaultExtractOptions); err == nil {
+			t.Errorf("%s: 期望使用错误私钥时打开失败", kind)
+		}
+	}
+}
+
+func TestNewZipFSRejectsConflicts(t *testing.T) {
+	if _, err := NewZipFS([]byte("not a zip"), DefaultExtractOptions); err == nil {
+		t.Error("期望无效 ZIP 报错")
+	}
+	fsys := newArchiveFS()
+	if err := fsys.add(&archiveNode{name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.add(&archiveNode{name: "a/b"}); err == nil {
+		t.Error("期望文件下的条目报错")
+	}
+	if err := fsys.add(&archiveNode{name: "a"}); err == nil {
+		t.Error("期望重复条目报错")
+	}
+}
+
+func TestNewZipFSLimits(t *testing.T) {
+	zipData := buildTestZip(t, []struct {
+		name string
+		data []byte
+	}{
+		{"small.txt", []byte("small")},
+		{"bomb.bin", make([]byte, 4<<20)},
+	})
+
+	for name, opts := range map[string]ExtractOptions{
+		"file size": {MaxFileSize: 1 << 20},
+		"ratio":     {MaxCompressionRatio: 100},
+	} {
+		fsys, err := NewZipFS(zipData, opts)
+		if err != nil {
+			t.Fatalf("%s: NewZipFS 失败: %v", name, err)
+		}
+		if _, err := fsys.ReadFile("bomb.bin"); err == nil {
+			t.Errorf("%s: 超出限制的条目应读取失败", name)
+		}
+		if _, err := fsys.Open("bomb.bin"); err == nil {
+			t.Errorf("%s: 超出限制的条目应打开失败", name)
+		}
+		if data, err := fsys.ReadFile("small.txt"); err != nil || string(data) != "small" {
+			t.Errorf("%s: 未超限的条目应可读取: %v", name, err)
+		}
+	}
+
+	if _, err := NewZipFS(zipData, ExtractOptions{MaxEntries: 1}); err == nil {
+		t.Error("条目数量超限时应打开失败")
+	}
+	fsys, err := NewZipFS(zipData, ExtractOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := fsys.ReadFile("bomb.bin"); err != nil || len(data) != 4<<20 {
+		t.Errorf("不限制时应完整读取: %v", err)
+	}
+}
@@ -3,6 +3,8 @@ package zjcrypto
 import (
 	"archive/zip"
 	"bytes"
+	"compress/flate"
+	"hash/crc32"
 	"os"
 	"path/filepath"
 	"testing"
@@ -466,3 +468,245 @@ func TestExtractZipWithDirectories(t *testing.T) {
 		}
 	}
 }
+
+// buildTestZip 按顺序写入条目构建ZIP数据.
+func buildTestZip(t *testing.T, entries []struct {
+	name string
+	data []byte
+}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := writer.Create(e.name)
+		if err != nil {
+			t.Fatalf("Failed to create entry: %v", err)
+		}
+		if _, err := w.Write(e.data); err != nil {
+			t.Fatalf("Failed to write entry: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestExtractZipLimits 测试解压限制按实际写入字节执行.
+func TestExtractZipLimits(t *testing.T) {
+	random := make([]byte, 64*1024)
+	for i := range random {
+		random[i] = byte(i*7919 + i/13)
+	}
+	zeros := make([]byte, 4*1024*1024)
+
+	type entry = struct {
+		name string
+		data []byte
+	}
+
+	tests := []struct {
+		name    string
+		entries []entry
+		opts    ExtractOptions
+		wantErr bool
+	}{
+		{
+			name:    "file size limit",
+			entries: []entry{{"big.bin", random}},
+			opts:    ExtractOptions{MaxFileSize: 1024},
+			wantErr: true,
+		},
+		{
+			name:    "total size limit",
+			entries: []entry{{"a.bin", random}, {"b.bin", random}},
+			opts:    ExtractOptions{MaxTotalSize: int64(len(random)) + 1},
+			wantErr: true,
+		},
+		{
+			name:    "entry count limit",
+			entries: []entry{{"a.txt", []byte("a")}, {"b.txt", []byte("b")}, {"c.txt", []byte("c")}},
+			opts:    ExtractOptions{MaxEntries: 2},
+			wantErr: true,
+		},
+		{
+			name:    "path depth limit",
+			entries: []entry{{"a/b/c/d/e.txt", []byte("deep")}},
+			opts:    ExtractOptions{MaxPathDepth: 4},
+			wantErr: true,
+		},
+		{
+			name:    "compression ratio limit",
+			entries: []entry{{"zeros.bin", zeros}},
+			opts:    ExtractOptions{MaxCompressionRatio: 100},
+			wantErr: true,
+		},
+		{
+			name:    "raised limits allow large restore",
+			entries: []entry{{"a.bin", random}, {"zeros.bin", zeros}, {"a/b/c/d/e.txt", []byte("deep")}},
+			opts: ExtractOptions{
+				MaxTotalSize:        int64(len(random) + len(zeros) + 4),
+				MaxFileSize:         int64(len(zeros)),
+				MaxEntries:          3,
+				MaxPathDepth:        5,
+				MaxCompressionRatio: 10000,
+			},
+		},
+		{
+			name:    "zero means unlimited",
+			entries: []entry{{"a.bin", random}, {"zeros.bin", zeros}},
+			opts:    ExtractOptions{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zipData := buildTestZip(t, tt.entries)
+			targetDir := t.TempDir()
+
+			err := ExtractZipToDirectoryWithOptions(zipData, targetDir, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractZipToDirectoryWithOptions() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, e := range tt.entries {
+				got, err := os.ReadFile(filepath.Join(targetDir, filepath.FromSlash(e.name))) //nolint:gosec
+				if err != nil {
+					t.Fatalf("读取 %s 失败: %v", e.name, err)
+				}
+				if !bytes.Equal(got, e.data) {
+					t.Errorf("%s 内容不匹配", e.name)
+				}
+			}
+		})
+	}
+}
+
+// TestExtractZipLyingHeader 测试ZIP目录声明的大小与实际数据不符时被拒绝且不残留文件.
+func TestExtractZipLyingHeader(t *testing.T) {
+	payload := make([]byte, 1024*1024)
+
+	var compressed bytes.Buffer
+	fw, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		t.Fatalf("Failed to create flate writer: %v", err)
+	}
+	if _, err := fw.Write(payload); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("Failed to close flate writer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	w, err := writer.CreateRaw(&zip.FileHeader{
+		Name:               "liar.bin",
+		Method:             zip.Deflate,
+		CRC32:              crc32.ChecksumIEEE(payload),
+		CompressedSize64:   uint64(compressed.Len()),
+		UncompressedSize64: 16, // 声明仅 16 字节
+	})
+	if err != nil {
+		t.Fatalf("Failed to create raw entry: %v", err)
+	}
+	if _, err := w.Write(compressed.Bytes()); err != nil {
+		t.Fatalf("Failed to write raw entry: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+
+	targetDir := t.TempDir()
+	opts := ExtractOptions{MaxFileSize: 4096}
+	if err := ExtractZipToDirectoryWithOptions(buf.Bytes(), targetDir, opts); err == nil {
+		t.Fatal("声明大小与实际不符的条目应该被拒绝")
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "liar.bin")); !os.IsNotExist(err) {
+		t.Error("被拒绝的条目不应残留部分文件")
+	}
+}
+
+// TestMatchZipPatterns 测试条目匹配模式.
+func TestMatchZipPatterns(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		name     string
+		want     bool
+	}{
+		{nil, "any/file.txt", true},
+		{[]string{"docs/**"}, "docs/a.txt", true},
+		{[]string{"docs/**"}, "docs/guide/b.md", true},
+		{[]string{"docs/**"}, "src/docs.go", false},
+		{[]string{"docs"}, "docs/guide/b.md", true},
+		{[]string{"./docs/"}, "docs/", true},
+		{[]string{"README.md"}, "README.md", true},
+		{[]string{"README.md"}, "sub/README.md", false},
+		{[]string{"**/README.md"}, "sub/README.md", true},
+		{[]string{"*.go"}, "main.go", true},
+		{[]string{"*.go"}, "cmd/main.go", false},
+		{[]string{"src/**/*.go"}, "src/a/b/c.go", true},
+		{[]string{"src/**/*.go"}, "src/c.go", true},
+		{[]string{"a.txt", "b.txt"}, "b.txt", true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchZipPatterns(tt.patterns, tt.name); got != tt.want {
+			t.Errorf("MatchZipPatterns(%v, %q) = %v, want %v", tt.patterns, tt.name, got, tt.want)
+		}
+	}
+
+	if err := ValidateZipPatterns([]string{"docs/[a-"}); err == nil {
+		t.Error("无效模式应该返回错误")
+	}
+	if err := ValidateZipPatterns([]string{"docs/**", "*.md"}); err != nil {
+		t.Errorf("有效模式不应返回错误: %v", err)
+	}
+}
+
+// TestListZipEntriesAndExtractOnly 测试列出条目和选择性解压.
+func TestListZipEntriesAndExtractOnly(t *testing.T) {
+	zipData := buildTestZip(t, []struct {
+		name string
+		data []byte
+	}{
+		{"README.md", []byte("readme")},
+		{"docs/intro.txt", []byte("intro")},
+		{"docs/guide/advanced.md", []byte("advanced")},
+		{"src/main.go", []byte("package main")},
+	})
+
+	entries, err := ListZipEntries(zipData)
+	if err != nil {
+		t.Fatalf("列出条目失败: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("期望 4 个条目，实际得到 %d", len(entries))
+	}
+	if entries[1].Name != "docs/intro.txt" || entries[1].Size != 5 || entries[1].IsDir {
+		t.Errorf("条目信息不正确: %+v", entries[1])
+	}
+
+	targetDir := t.TempDir()
+	opts := DefaultExtractOptions
+	opts.Only = []string{"docs/**", "README.md"}
+	if err := ExtractZipToDirectoryWithOptions(zipData, targetDir, opts); err != nil {
+		t.Fatalf("选择性解压失败: %v", err)
+	}
+
+	for _, name := range []string{"README.md", "docs/intro.txt", "docs/guide/advanced.md"} {
+		if _, err := os.Stat(filepath.Join(targetDir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("匹配的 %s 应被解压: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "src")); !os.IsNotExist(err) {
+		t.Error("未匹配的条目不应被解压")
+	}
+
+	opts.Only = []string{"docs/[a-"}
+	if err := ExtractZipToDirectoryWithOptions(zipData, t.TempDir(), opts); err == nil {
+		t.Error("无效模式应该返回错误")
+	}
+}
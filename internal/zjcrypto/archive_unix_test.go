@@ -0,0 +1,59 @@
+//go:build unix
+
+package zjcrypto
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestExtractZipRestoresModeUnderUmask 验证解压后的文件权限不受 umask 影响，清单校验通过.
+func TestExtractZipRestoresModeUnderUmask(t *testing.T) {
+	old := unix.Umask(0o022)
+	defer unix.Umask(old)
+
+	sourceDir := t.TempDir()
+	target := filepath.Join(sourceDir, "b.txt")
+	if err := os.WriteFile(target, []byte("group writable"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(target, 0664); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := generateTestKeys(t)
+	opts := DefaultArchiveOptions
+	opts.ManifestKey = keys.dilithiumPriv
+	var buf bytes.Buffer
+	if err := CreateZipFromDirectory(sourceDir, &buf, opts); err != nil {
+		t.Fatalf("创建ZIP失败: %v", err)
+	}
+
+	targetDir := t.TempDir()
+	if err := ExtractZipToDirectory(buf.Bytes(), targetDir); err != nil {
+		t.Fatalf("解压失败: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(targetDir, "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0664 {
+		t.Errorf("解压后权限 = %o，期望 0664", info.Mode().Perm())
+	}
+
+	manifest, err := ReadTreeManifest(targetDir, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("读取清单失败: %v", err)
+	}
+	report, err := CheckTreeAgainstManifest(manifest, targetDir, nil)
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if report.HasDifferences() {
+		t.Errorf("解压目录应与清单一致: %+v", report.Diffs)
+	}
+}
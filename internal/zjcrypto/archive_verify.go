@@ -0,0 +1,322 @@
+package zjcrypto
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// DiffKind 存档与目录树之间的差异类型.
+type DiffKind int
+
+const (
+	// DiffAdded 目录中存在、存档中没有.
+	DiffAdded DiffKind = iota
+	// DiffRemoved 存档中存在、目录中没有.
+	DiffRemoved
+	// DiffModified 文件内容不同.
+	DiffModified
+	// DiffMetadata 内容相同，但权限或修改时间不同.
+	DiffMetadata
+	// DiffCorrupted 存档中的条目无法解密或校验失败.
+	DiffCorrupted
+)
+
+// String 返回差异类型的单字符标记.
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "A"
+	case DiffRemoved:
+		return "D"
+	case DiffModified:
+		return "M"
+	case DiffMetadata:
+		return "m"
+	case DiffCorrupted:
+		return "!"
+	default:
+		return "?"
+	}
+}
+
+// TreeDiff 单个路径的差异.
+type TreeDiff struct {
+	Name   string   // 相对路径（使用 / 分隔）
+	Kind   DiffKind // 差异类型
+	Detail string   // 补充说明（如权限变化）
+}
+
+// TreeDiffReport 存档与目录树的比较结果.
+type TreeDiffReport struct {
+	Diffs    []TreeDiff // 按路径排序的差异
+	Entries  int        // 存档中的条目数
+	Verified int        // 成功解密并计算哈希的文件数
+}
+
+// HasDifferences 判断是否存在任何差异.
+func (r *TreeDiffReport) HasDifferences() bool {
+	return len(r.Diffs) > 0
+}
+
+// verifyEntry 存档条目的比较信息.
+type verifyEntry struct {
+	isDir   bool
+	mode    os.FileMode
+	modTime time.Time // 零值表示存档未记录
+	hasMode bool      // 存档是否记录了权限
+	hash    [32]byte
+	err     error // 条目读取失败
+}
+
+// VerifyZipAgainstDirectory 在内存中逐条目计算 ZIP 内容哈希并与目录比较，不写入磁盘.
+// 条目数量、重名、重叠和路径检查与 ExtractZipToDirectoryWithOptions 相同.
+// 读取量受 opts 中的大小和压缩比限制约束，超出限制时返回错误而不是报告差异.
+func VerifyZipAgainstDirectory(zipData []byte, dir string, opts ExtractOptions) (*TreeDiffReport, error) {
+	reader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ZIP format: %w", err)
+	}
+	// 与解压相同的预检查：存档能通过校验就一定能被 decrypt-dir 接受
+	if err := checkZipEntries(reader.File, opts); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*verifyEntry, len(reader.File))
+	var totalRead int64
+	for _, file := range reader.File {
+		name := strings.TrimSuffix(file.Name, "/")
+		if name == format.ManifestName {
+			continue // 清单由打包过程生成，不属于源目录
+		}
+		if !isSafeZipName(file.Name) {
+			return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Invalid file path in ZIP: "+file.Name)
+		}
+		if opts.MaxPathDepth > 0 && zipPathDepth(file.Name) > opts.MaxPathDepth {
+			return nil, utils.NewCryptoError(
+				utils.ErrInvalidParameter,
+				fmt.Sprintf("Path too deep in ZIP: %s (max depth: %d)", file.Name, opts.MaxPathDepth),
+			)
+		}
+		info := file.FileInfo()
+		entry := &verifyEntry{
+			isDir:   info.IsDir(),
+			mode:    info.Mode().Perm(),
+			modTime: file.Modified,
+			// 旧版本存档未记录 Unix 权限
+			hasMode: file.CreatorVersion>>8 == 3 && file.ExternalAttrs != 0,
+		}
+		entries[name] = entry
+		if entry.isDir {
+			continue
+		}
+
+		limit, reason := entryWriteLimit(file, opts, totalRead)
+		hash, n, err := hashZipFile(file, limit)
+		totalRead += n
+		if limit >= 0 && n > limit {
+			return nil, utils.NewCryptoError(
+				utils.ErrInvalidParameter,
+				fmt.Sprintf("Extraction limit exceeded for %s: %s", name, reason),
+			)
+		}
+		entry.hash, entry.err = hash, err
+	}
+
+	return compareWithDirectory(entries, dir, nil)
+}
+
+// hashZipFile 计算单个 ZIP 条目的内容哈希，limit 非负时最多读取 limit+1 字节.
+// 返回的读取量大于 limit 时哈希无效，由调用方报告超限.
+func hashZipFile(file *zip.File, limit int64) ([32]byte, int64, error) {
+	src, err := file.Open()
+	if err != nil {
+		return [32]byte{}, 0, fmt.Errorf("open zip entry: %w", err)
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	r := io.Reader(src)
+	if limit >= 0 {
+		r = io.LimitReader(src, limit+1)
+	}
+	hasher := sha256.New()
+	n, err := io.Copy(hasher, r)
+	if err != nil {
+		return [32]byte{}, n, fmt.Errorf("read zip entry: %w", err)
+	}
+
+	var hash [32]byte
+	copy(hash[:], hasher.Sum(nil))
+	return hash, n, nil
+}
+
+// VerifyAgainstDirectory 解密索引存档的每个条目并与目录比较，不写入磁盘.
+// opts 中的限制与 ExtractTo 相同，在解密前根据索引检查.
+func (a *IndexedArchive) VerifyAgainstDirectory(dir string, opts ExtractOptions) (*TreeDiffReport, error) {
+	selected, err := a.selectEntries(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*verifyEntry, len(selected))
+	for _, i := range selected {
+		e := &a.index.Entries[i]
+		entry := &verifyEntry{
+			isDir:   e.IsDir(),
+			mode:    e.Mode.Perm(),
+			modTime: e.Modified(),
+			hasMode: true,
+			hash:    e.SHA256,
+		}
+		entries[e.Name] = entry
+		if entry.isDir {
+			continue
+		}
+		// 实际解密一遍，确认数据段可恢复（writeEntry 会校验哈希）
+		entry.err = a.writeEntry(io.Discard, i)
+	}
+
+	return compareWithDirectory(entries, dir, opts.Only)
+}
+
+// compareWithDirectory 将存档条目与目录树逐一比较；only 非空时目录中不匹配这些模式的路径不参与比较.
+//
+//nolint:funlen,gocognit
+func compareWithDirectory(entries map[string]*verifyEntry, dir string, only []string) (*TreeDiffReport, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Directory not found: "+err.Error())
+	}
+	if !info.IsDir() {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Path is not a directory: "+dir)
+	}
+
+	root, err := os.OpenRoot(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("open directory root: %w", err)
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+
+	report := &TreeDiffReport{Entries: len(entries)}
+	seen := make(map[string]bool, len(entries))
+
+	walkErr := filepath.Walk(absDir, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return fmt.Errorf("walk error at %s: %w", path, walkErr)
+		}
+		if path == absDir || info.Mode()&os.ModeSymlink != 0 {
+			return nil // 与打包时一致，跳过符号链接
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(absDir, path)
+		if err != nil {
+			return fmt.Errorf("get relative path: %w", err)
+		}
+		name := filepath.ToSlash(relPath)
+		if name == format.ManifestName || !MatchZipPatterns(only, name) {
+			return nil
+		}
+
+		entry, ok := entries[name]
+		if !ok {
+			report.Diffs = append(report.Diffs, TreeDiff{Name: name, Kind: DiffAdded})
+			return nil
+		}
+		seen[name] = true
+
+		if entry.isDir != info.IsDir() {
+			report.Diffs = append(report.Diffs, TreeDiff{Name: name, Kind: DiffModified, Detail: "type changed"})
+			return nil
+		}
+		if entry.isDir {
+			return nil
+		}
+		if entry.err != nil {
+			report.Diffs = append(report.Diffs, TreeDiff{Name: name, Kind: DiffCorrupted, Detail: entry.err.Error()})
+			return nil
+		}
+		report.Verified++
+
+		hash, err := hashRootFile(root, relPath)
+		if err != nil {
+			return err
+		}
+		if hash != entry.hash {
+			report.Diffs = append(report.Diffs, TreeDiff{Name: name, Kind: DiffModified})
+			return nil
+		}
+
+		var details []string
+		if entry.hasMode && entry.mode != info.Mode().Perm() {
+			details = append(details, fmt.Sprintf("mode %04o -> %04o", entry.mode, info.Mode().Perm()))
+		}
+		if !entry.modTime.IsZero() && entry.modTime.Unix() != info.ModTime().Unix() {
+			details = append(details, fmt.Sprintf("mtime %s -> %s",
+				entry.modTime.Format(time.DateTime), info.ModTime().Format(time.DateTime)))
+		}
+		if len(details) > 0 {
+			report.Diffs = append(report.Diffs, TreeDiff{Name: name, Kind: DiffMetadata, Detail: strings.Join(details, ", ")})
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr //nolint:wrapcheck // 回调中已包装
+	}
+
+	for name, entry := range entries {
+		if seen[name] {
+			continue
+		}
+		if entry.err != nil {
+			report.Diffs = append(report.Diffs, TreeDiff{Name: name, Kind: DiffCorrupted, Detail: entry.err.Error()})
+			continue
+		}
+		report.Diffs = append(report.Diffs, TreeDiff{Name: name, Kind: DiffRemoved})
+	}
+
+	sort.Slice(report.Diffs, func(i, j int) bool {
+		if report.Diffs[i].Name != report.Diffs[j].Name {
+			return report.Diffs[i].Name < report.Diffs[j].Name
+		}
+		return report.Diffs[i].Kind < report.Diffs[j].Kind
+	})
+	return report, nil
+}
+
+// hashRootFile 计算 root 下文件的 SHA256.
+func hashRootFile(root *os.Root, relPath string) ([32]byte, error) {
+	file, err := root.Open(relPath)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("open file %s: %w", relPath, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash, err := HashReader(file)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("hash file %s: %w", relPath, err)
+	}
+	return hash, nil
+}
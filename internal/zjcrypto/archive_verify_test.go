@@ -0,0 +1,241 @@
+package zjcrypto
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mutateTree 对目录做一组典型修改：新增、删除、改内容、仅改修改时间.
+func mutateTree(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "docs", "intro.txt")); err != nil {
+		t.Fatalf("删除文件失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# changed"), 0600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "empty.txt"), old, old); err != nil {
+		t.Fatalf("修改时间失败: %v", err)
+	}
+}
+
+// assertTreeDiffs 检查报告中的差异与期望一致.
+func assertTreeDiffs(t *testing.T, report *TreeDiffReport, want map[string]DiffKind) {
+	t.Helper()
+	got := make(map[string]DiffKind, len(report.Diffs))
+	for _, diff := range report.Diffs {
+		got[diff.Name] = diff.Kind
+	}
+	if len(got) != len(want) {
+		t.Errorf("差异数量不符: 期望 %v，实际 %+v", want, report.Diffs)
+	}
+	for name, kind := range want {
+		if got[name] != kind {
+			t.Errorf("%s: 期望差异 %s，实际 %s", name, kind, got[name])
+		}
+	}
+}
+
+func TestVerifyZipAgainstDirectory(t *testing.T) {
+	sourceDir, _ := createIndexedTestDir(t)
+
+	var buf bytes.Buffer
+	if err := CreateZipFromDirectory(sourceDir, &buf, DefaultArchiveOptions); err != nil {
+		t.Fatalf("创建ZIP失败: %v", err)
+	}
+
+	report, err := VerifyZipAgainstDirectory(buf.Bytes(), sourceDir, DefaultExtractOptions)
+	if err != nil {
+		t.Fatalf("比较失败: %v", err)
+	}
+	if report.HasDifferences() {
+		t.Fatalf("未修改的目录不应有差异: %+v", report.Diffs)
+	}
+	if report.Verified != 6 {
+		t.Errorf("期望校验 6 个文件，实际 %d", report.Verified)
+	}
+
+	mutateTree(t, sourceDir)
+	report, err = VerifyZipAgainstDirectory(buf.Bytes(), sourceDir, DefaultExtractOptions)
+	if err != nil {
+		t.Fatalf("比较失败: %v", err)
+	}
+	assertTreeDiffs(t, report, map[string]DiffKind{
+		"new.txt":        DiffAdded,
+		"docs/intro.txt": DiffRemoved,
+		"README.md":      DiffModified,
+		"empty.txt":      DiffMetadata,
+	})
+
+	if _, err := VerifyZipAgainstDirectory(buf.Bytes(), filepath.Join(sourceDir, "missing"), DefaultExtractOptions); err == nil {
+		t.Error("目录不存在时应返回错误")
+	}
+}
+
+func TestIndexedArchiveVerifyAgainstDirectory(t *testing.T) {
+	sourceDir, _ := createIndexedTestDir(t)
+	keys := generateTestKeys(t)
+
+	archivePath := filepath.Join(t.TempDir(), "project.fzj")
+	if _, err := EncryptDirectoryIndexed(sourceDir, archivePath, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, DefaultArchiveOptions); err != nil {
+		t.Fatalf("索引存档加密失败: %v", err)
+	}
+
+	archive, err := OpenIndexedArchive(archivePath, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("打开索引存档失败: %v", err)
+	}
+	defer func() {
+		_ = archive.Close()
+	}()
+
+	report, err := archive.VerifyAgainstDirectory(sourceDir, DefaultExtractOptions)
+	if err != nil {
+		t.Fatalf("比较失败: %v", err)
+	}
+	if report.HasDifferences() {
+		t.Fatalf("未修改的目录不应有差异: %+v", report.Diffs)
+	}
+
+	mutateTree(t, sourceDir)
+	if err := os.Chmod(filepath.Join(sourceDir, "docs", "guide", "usage.md"), 0640); err != nil {
+		t.Fatalf("修改权限失败: %v", err)
+	}
+	report, err = archive.VerifyAgainstDirectory(sourceDir, DefaultExtractOptions)
+	if err != nil {
+		t.Fatalf("比较失败: %v", err)
+	}
+	assertTreeDiffs(t, report, map[string]DiffKind{
+		"new.txt":             DiffAdded,
+		"docs/intro.txt":      DiffRemoved,
+		"README.md":           DiffModified,
+		"empty.txt":           DiffMetadata,
+		"docs/guide/usage.md": DiffMetadata,
+	})
+}
+
+func TestVerifyZipCorruptedEntryMissingOnDisk(t *testing.T) {
+	sourceDir, _ := createIndexedTestDir(t)
+
+	var buf bytes.Buffer
+	if err := CreateZipFromDirectory(sourceDir, &buf, DefaultArchiveOptions); err != nil {
+		t.Fatalf("创建ZIP失败: %v", err)
+	}
+	zipData := buf.Bytes()
+	corruptZipEntry(t, zipData, "docs/intro.txt")
+	if err := os.Remove(filepath.Join(sourceDir, "docs", "intro.txt")); err != nil {
+		t.Fatalf("删除文件失败: %v", err)
+	}
+
+	// 条目数据损坏且磁盘上已不存在：只应报告一次损坏
+	report, err := VerifyZipAgainstDirectory(zipData, sourceDir, DefaultExtractOptions)
+	if err != nil {
+		t.Fatalf("比较失败: %v", err)
+	}
+	var kinds []DiffKind
+	for _, diff := range report.Diffs {
+		if diff.Name == "docs/intro.txt" {
+			kinds = append(kinds, diff.Kind)
+		}
+	}
+	if len(kinds) != 1 || kinds[0] != DiffCorrupted {
+		t.Errorf("docs/intro.txt 应只报告一次损坏，实际 %v", kinds)
+	}
+}
+
+// corruptZipEntry 翻转 ZIP 中指定条目数据段的第一个字节.
+func corruptZipEntry(t *testing.T, zipData []byte, name string) {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		t.Fatalf("读取ZIP失败: %v", err)
+	}
+	for _, file := range reader.File {
+		if file.Name != name {
+			continue
+		}
+		offset, err := file.DataOffset()
+		if err != nil {
+			t.Fatalf("定位条目数据失败: %v", err)
+		}
+		zipData[offset] ^= 0xff
+		return
+	}
+	t.Fatalf("ZIP 中没有条目 %s", name)
+}
+
+func TestVerifyAgainstDirectoryLimits(t *testing.T) {
+	sourceDir, _ := createIndexedTestDir(t)
+	keys := generateTestKeys(t)
+
+	var buf bytes.Buffer
+	if err := CreateZipFromDirectory(sourceDir, &buf, DefaultArchiveOptions); err != nil {
+		t.Fatalf("创建ZIP失败: %v", err)
+	}
+	archivePath := filepath.Join(t.TempDir(), "project.fzj")
+	if _, err := EncryptDirectoryIndexed(sourceDir, archivePath, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, DefaultArchiveOptions); err != nil {
+		t.Fatalf("索引存档加密失败: %v", err)
+	}
+	archive, err := OpenIndexedArchive(archivePath, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("打开索引存档失败: %v", err)
+	}
+	defer func() {
+		_ = archive.Close()
+	}()
+
+	limits := map[string]func(*ExtractOptions){
+		"单文件大小": func(o *ExtractOptions) { o.MaxFileSize = 1 },
+		"总大小":   func(o *ExtractOptions) { o.MaxTotalSize = 1024 },
+		"条目数":   func(o *ExtractOptions) { o.MaxEntries = 2 },
+		"路径深度":  func(o *ExtractOptions) { o.MaxPathDepth = 1 },
+	}
+	for name, apply := range limits {
+		opts := DefaultExtractOptions
+		apply(&opts)
+		// 超出限制应返回错误，而不是把条目报告为损坏
+		if report, err := VerifyZipAgainstDirectory(buf.Bytes(), sourceDir, opts); err == nil {
+			t.Errorf("%s: ZIP 存档超出限制时应返回错误，实际报告 %+v", name, report.Diffs)
+		}
+		if report, err := archive.VerifyAgainstDirectory(sourceDir, opts); err == nil {
+			t.Errorf("%s: 索引存档超出限制时应返回错误，实际报告 %+v", name, report.Diffs)
+		}
+	}
+}
+
+func TestVerifyZipDuplicateEntry(t *testing.T) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, content := range []string{"old", "new"} {
+		w, err := writer.Create("a.txt")
+		if err != nil {
+			t.Fatalf("创建条目失败: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("写入条目失败: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("关闭ZIP失败: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("new"), 0600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	// 重名条目解压时会被拒绝，校验也不能报告为一致
+	if report, err := VerifyZipAgainstDirectory(buf.Bytes(), dir, DefaultExtractOptions); err == nil {
+		t.Errorf("重名条目应返回错误，实际报告 %+v", report.Diffs)
+	}
+	if err := ExtractZipToDirectoryWithOptions(buf.Bytes(), t.TempDir(), DefaultExtractOptions); err == nil {
+		t.Error("解压重名条目应返回错误")
+	}
+}
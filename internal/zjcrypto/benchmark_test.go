@@ -292,23 +292,6 @@ func TestPerformanceComparison(t *testing.T) {
 	}
 }
 
-// TestCacheTTL 测试缓存TTL功能.
-func TestCacheTTL(t *testing.T) {
-	t.Skip("SaveKeyFiles 需要非 nil 私钥，跳过完整测试")
-}
-
-// TestCacheSizeLimit 测试缓存大小限制.
-func TestCacheSizeLimit(t *testing.T) {
-	t.Skip("SaveKeyFiles 需要非 nil 私钥，跳过完整测试")
-}
-
-// TestCacheExpiration 测试缓存过期.
-func TestCacheExpiration(t *testing.T) {
-	// 注意: DefaultCacheTTL 是常量，无法直接修改
-	// 这里测试缓存的基本过期机制
-	t.Skip("TTL 是常量，需要重构为可配置变量才能测试")
-}
-
 // 辅助函数.
 func getFileSize(path string) int64 {
 	info, err := os.Stat(path)
@@ -0,0 +1,591 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"os"
+	"strings"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+// 身份证书由两张 X.509 证书组成，主题、有效期和签发者相同：
+//   - 签名证书：SubjectPublicKeyInfo 为 Dilithium3 公钥，keyUsage 为 digitalSignature，
+//     CA 证书另有 keyCertSign 和 basicConstraints；
+//   - 加密证书：SubjectPublicKeyInfo 为 X25519 公钥，Kyber768 公钥放在
+//     subjectAltPublicKeyInfo 扩展（X.509 2019，2.5.29.72）中，keyUsage 为 keyAgreement.
+//
+// 一张证书只有一个公钥位置，而混合身份有三个公钥；拆成两张证书后只需要标准的扩展和
+// pkix.go 中已有的算法 OID，不必为本工具另行分配 OID. 两张证书都用签发者的 Dilithium3 私钥签名.
+// 证书请求同样由两个 PKCS#10 请求组成，都用申请者的 Dilithium3 私钥签名，
+// 加密请求以 extensionRequest 属性携带 Kyber768 公钥.
+
+// 证书 PEM 类型.
+const (
+	pemTypeCertificate        = "CERTIFICATE"
+	pemTypeCertificateRequest = "CERTIFICATE REQUEST"
+)
+
+// CAFileEnv 指定验证身份证书时信任的本地 CA 证书文件.
+const CAFileEnv = "FZJJYZ_CA"
+
+var (
+	oidExtSubjectKeyID           = asn1.ObjectIdentifier{2, 5, 29, 14}
+	oidExtKeyUsage               = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtBasicConstraints       = asn1.ObjectIdentifier{2, 5, 29, 19}
+	oidExtAuthorityKeyID         = asn1.ObjectIdentifier{2, 5, 29, 35}
+	oidExtSubjectAltPublicKey    = asn1.ObjectIdentifier{2, 5, 29, 72}
+	oidAttributeExtensionRequest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 14}
+
+	// serialLimit 序列号取 [0, 2^127)，保证编码为正数且不超过 RFC 5280 的 20 字节上限.
+	serialLimit = new(big.Int).Lsh(big.NewInt(1), 127)
+)
+
+// tbsCertificate RFC 5280 TBSCertificate 中本工具使用的字段.
+type tbsCertificate struct {
+	Version            int `asn1:"optional,explicit,default:0,tag:0"`
+	SerialNumber       *big.Int
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Issuer             asn1.RawValue
+	Validity           certValidity
+	Subject            asn1.RawValue
+	PublicKey          asn1.RawValue
+	Extensions         []pkix.Extension `asn1:"optional,explicit,tag:3"`
+}
+
+type certValidity struct {
+	NotBefore, NotAfter time.Time
+}
+
+// certificationRequestInfo RFC 2986.
+type certificationRequestInfo struct {
+	Version    int
+	Subject    asn1.RawValue
+	PublicKey  asn1.RawValue
+	Attributes []extensionRequest `asn1:"tag:0"`
+}
+
+type extensionRequest struct {
+	Type   asn1.ObjectIdentifier
+	Values [][]pkix.Extension `asn1:"set"`
+}
+
+// signedData 是 Certificate 与 CertificationRequest 共同的外层结构.
+type signedData struct {
+	Data               asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+}
+
+type basicConstraints struct {
+	IsCA bool `asn1:"optional"`
+}
+
+type authorityKeyID struct {
+	ID []byte `asn1:"optional,tag:0"`
+}
+
+// IdentityRequest 是签名已验证的身份证书请求.
+type IdentityRequest struct {
+	RawSubject    []byte
+	Subject       pkix.Name
+	SigningKey    *mode3.PublicKey
+	EncryptionKey *HybridPublicKey
+}
+
+// IdentityCertificate 是解析后的身份证书.
+type IdentityCertificate struct {
+	Signing       *x509.Certificate
+	Encryption    *x509.Certificate
+	SigningKey    *mode3.PublicKey
+	EncryptionKey *HybridPublicKey
+}
+
+// ParseSubject 解析 "CN=alice,O=team" 形式的主题，支持 CN、O、OU、C、ST、L，属性可重复.
+func ParseSubject(s string) (pkix.Name, error) {
+	var name pkix.Name
+	for part := range strings.SplitSeq(s, ",") {
+		key, value, ok := strings.Cut(part, "=")
+		key, value = strings.ToUpper(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return pkix.Name{}, utils.NewCryptoError(utils.ErrInvalidParameter,
+				fmt.Sprintf("Invalid subject attribute %q, expected KEY=value", strings.TrimSpace(part)))
+		}
+		switch key {
+		case "CN":
+			if name.CommonName != "" {
+				return pkix.Name{}, utils.NewCryptoError(utils.ErrInvalidParameter, "Subject has more than one CN")
+			}
+			name.CommonName = value
+		case "O":
+			name.Organization = append(name.Organization, value)
+		case "OU":
+			name.OrganizationalUnit = append(name.OrganizationalUnit, value)
+		case "C":
+			name.Country = append(name.Country, value)
+		case "ST":
+			name.Province = append(name.Province, value)
+		case "L":
+			name.Locality = append(name.Locality, value)
+		default:
+			return pkix.Name{}, utils.NewCryptoError(utils.ErrInvalidParameter,
+				fmt.Sprintf("Unsupported subject attribute %q", key))
+		}
+	}
+	if name.CommonName == "" {
+		return pkix.Name{}, utils.NewCryptoError(utils.ErrInvalidParameter, "Subject must contain a CN")
+	}
+	return name, nil
+}
+
+// CreateIdentityRequest 生成身份证书请求（PEM），两个请求都用 signKey 签名.
+func CreateIdentityRequest(subject pkix.Name, encKey *HybridPublicKey, signKey SigningKey) ([]byte, error) {
+	signPub, err := certSigningPublic(signKey)
+	if err != nil {
+		return nil, err
+	}
+	rawSubject, err := asn1.Marshal(subject.ToRDNSequence())
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrSerializationFailed, fmt.Sprintf("Failed to marshal subject: %v", err))
+	}
+	signSPKI, encSPKI, altKey, err := identityPublicKeys(signPub, encKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	for _, info := range []certificationRequestInfo{
+		{Subject: asn1.RawValue{FullBytes: rawSubject}, PublicKey: asn1.RawValue{FullBytes: signSPKI}},
+		{
+			Subject:   asn1.RawValue{FullBytes: rawSubject},
+			PublicKey: asn1.RawValue{FullBytes: encSPKI},
+			Attributes: []extensionRequest{{
+				Type:   oidAttributeExtensionRequest,
+				Values: [][]pkix.Extension{{altKey}},
+			}},
+		},
+	} {
+		der, err := signASN1(info, signKey)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: pemTypeCertificateRequest, Bytes: der})...)
+	}
+	return out, nil
+}
+
+// ParseIdentityRequest 解析身份证书请求并验证两个请求都由请求中的 Dilithium3 公钥签名.
+func ParseIdentityRequest(pemData []byte) (*IdentityRequest, error) {
+	var reqs []*x509.CertificateRequest
+	for block, rest := pem.Decode(pemData); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != pemTypeCertificateRequest {
+			continue
+		}
+		req, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrInvalidFormat, fmt.Sprintf("Invalid certificate request: %v", err))
+		}
+		reqs = append(reqs, req)
+	}
+	if len(reqs) != 2 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat,
+			"Identity certificate request must contain a signing and an encryption request")
+	}
+
+	signing, encryption := reqs[0], reqs[1]
+	signKey, ok := parseSigningSPKI(signing.RawSubjectPublicKeyInfo)
+	if !ok {
+		signing, encryption = encryption, signing
+		if signKey, ok = parseSigningSPKI(signing.RawSubjectPublicKeyInfo); !ok {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Certificate request has no Dilithium3 signing key")
+		}
+	}
+	encKey, err := parseEncryptionKey(encryption.RawSubjectPublicKeyInfo, encryption.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(signing.RawSubject, encryption.RawSubject) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Signing and encryption requests have different subjects")
+	}
+	for _, req := range []*x509.CertificateRequest{signing, encryption} {
+		if err := checkSignature(req.Raw, signKey); err != nil {
+			return nil, err
+		}
+	}
+	return &IdentityRequest{
+		RawSubject:    signing.RawSubject,
+		Subject:       signing.Subject,
+		SigningKey:    signKey,
+		EncryptionKey: encKey,
+	}, nil
+}
+
+// SelfSignIdentity 用 signKey 为自身签发身份证书.
+// 签名证书同时标记为 CA，可以作为本地 CA 用 IssueIdentityCertificate 签发其他身份.
+func SelfSignIdentity(subject pkix.Name, encKey *HybridPublicKey, signKey SigningKey,
+	notBefore, notAfter time.Time) ([]byte, error) {
+	signPub, err := certSigningPublic(signKey)
+	if err != nil {
+		return nil, err
+	}
+	rawSubject, err := asn1.Marshal(subject.ToRDNSequence())
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrSerializationFailed, fmt.Sprintf("Failed to marshal subject: %v", err))
+	}
+	return issueIdentity(&IdentityRequest{RawSubject: rawSubject, SigningKey: signPub, EncryptionKey: encKey},
+		rawSubject, subjectKeyID(signPub.Bytes()), signKey, true, notBefore, notAfter)
+}
+
+// IssueIdentityCertificate 用 CA 证书及其 Dilithium3 私钥为已验证的请求签发身份证书.
+func IssueIdentityCertificate(req *IdentityRequest, ca *IdentityCertificate, caKey SigningKey,
+	notBefore, notAfter time.Time) ([]byte, error) {
+	if !isCA(ca.Signing) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter,
+			fmt.Sprintf("Certificate for %s is not a CA certificate", ca.Signing.Subject))
+	}
+	if !DilithiumPublicFromPrivate(caKey).Equal(ca.SigningKey) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "CA private key does not match the CA certificate")
+	}
+	return issueIdentity(req, ca.Signing.RawSubject, ca.Signing.SubjectKeyId, caKey, false, notBefore, notAfter)
+}
+
+func issueIdentity(req *IdentityRequest, rawIssuer, issuerKeyID []byte, issuerKey SigningKey, ca bool,
+	notBefore, notAfter time.Time) ([]byte, error) {
+	notBefore, notAfter = notBefore.UTC().Truncate(time.Second), notAfter.UTC().Truncate(time.Second)
+	if !notAfter.After(notBefore) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Certificate validity period is empty")
+	}
+	signSPKI, encSPKI, altKey, err := identityPublicKeys(req.SigningKey, req.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signUsage := x509.KeyUsageDigitalSignature
+	var signExts extensionList
+	if ca {
+		signUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		signExts.add(oidExtBasicConstraints, true, basicConstraints{IsCA: true})
+	}
+	signExts.add(oidExtKeyUsage, true, keyUsageBits(signUsage))
+	signExts.add(oidExtSubjectKeyID, false, subjectKeyID(req.SigningKey.Bytes()))
+	signExts.add(oidExtAuthorityKeyID, false, authorityKeyID{ID: issuerKeyID})
+
+	var encExts extensionList
+	encExts.add(oidExtKeyUsage, true, keyUsageBits(x509.KeyUsageKeyAgreement))
+	encExts.add(oidExtSubjectKeyID, false, subjectKeyID(req.EncryptionKey.ECDH.Bytes()))
+	encExts.add(oidExtAuthorityKeyID, false, authorityKeyID{ID: issuerKeyID})
+	encExts.exts = append(encExts.exts, altKey)
+	if signExts.err != nil || encExts.err != nil {
+		return nil, utils.NewCryptoError(utils.ErrSerializationFailed,
+			fmt.Sprintf("Failed to marshal certificate extensions: %v", errors.Join(signExts.err, encExts.err)))
+	}
+
+	var out []byte
+	for _, cert := range []struct {
+		spki []byte
+		exts []pkix.Extension
+	}{{signSPKI, signExts.exts}, {encSPKI, encExts.exts}} {
+		serial, err := rand.Int(rand.Reader, serialLimit)
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrSystem, fmt.Sprintf("Failed to generate serial number: %v", err))
+		}
+		der, err := signASN1(tbsCertificate{
+			Version:            2,
+			SerialNumber:       serial,
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidDilithium3},
+			Issuer:             asn1.RawValue{FullBytes: rawIssuer},
+			Validity:           certValidity{NotBefore: notBefore, NotAfter: notAfter},
+			Subject:            asn1.RawValue{FullBytes: req.RawSubject},
+			PublicKey:          asn1.RawValue{FullBytes: cert.spki},
+			Extensions:         cert.exts,
+		}, issuerKey)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: pemTypeCertificate, Bytes: der})...)
+	}
+	return out, nil
+}
+
+// ParseIdentityCertificate 解析身份证书（两个 "CERTIFICATE" PEM 块，顺序不限）.
+// 只检查两张证书结构一致，有效期和签发者由 Verify 检查.
+func ParseIdentityCertificate(pemData []byte) (*IdentityCertificate, error) {
+	certs, err := parseCertificates(pemData)
+	if err != nil {
+		return nil, err
+	}
+	id := &IdentityCertificate{}
+	var ecdhCert *x509.Certificate
+	for _, cert := range certs {
+		if key, ok := parseSigningSPKI(cert.RawSubjectPublicKeyInfo); ok && id.Signing == nil {
+			id.Signing, id.SigningKey = cert, key
+		} else if ecdhCert == nil {
+			ecdhCert = cert
+		}
+	}
+	if len(certs) != 2 || id.Signing == nil || ecdhCert == nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat,
+			"Identity certificate must contain a signing and an encryption certificate")
+	}
+	id.Encryption = ecdhCert
+	if id.EncryptionKey, err = parseEncryptionKey(ecdhCert.RawSubjectPublicKeyInfo, ecdhCert.Extensions); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !bytes.Equal(id.Signing.RawSubject, id.Encryption.RawSubject),
+		!bytes.Equal(id.Signing.RawIssuer, id.Encryption.RawIssuer):
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat,
+			"Signing and encryption certificates have different subjects or issuers")
+	case id.Signing.KeyUsage&x509.KeyUsageDigitalSignature == 0:
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Signing certificate does not allow digital signatures")
+	case id.Encryption.KeyUsage&x509.KeyUsageKeyAgreement == 0:
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Encryption certificate does not allow key agreement")
+	}
+	return id, nil
+}
+
+// LoadIdentityCertificate 从文件加载身份证书.
+func LoadIdentityCertificate(path string) (*IdentityCertificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read certificate: %w", err)
+	}
+	return ParseIdentityCertificate(data)
+}
+
+// IsCertificatePEM 判断数据是否包含 PEM 编码的证书.
+func IsCertificatePEM(data []byte) bool {
+	return bytes.Contains(data, []byte("-----BEGIN "+pemTypeCertificate+"-----"))
+}
+
+// LoadTrustedCAs 从文件加载本地 CA 证书，文件中不是 Dilithium3 CA 签名证书的块被忽略.
+// selfsign 生成的身份证书可以直接作为 CA 文件.
+func LoadTrustedCAs(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CA certificates: %w", err)
+	}
+	certs, err := parseCertificates(data)
+	if err != nil {
+		return nil, err
+	}
+	var roots []*x509.Certificate
+	for _, cert := range certs {
+		if _, ok := parseSigningSPKI(cert.RawSubjectPublicKeyInfo); ok && isCA(cert) {
+			roots = append(roots, cert)
+		}
+	}
+	if len(roots) == 0 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, fmt.Sprintf("No CA certificate found in %s", path))
+	}
+	return roots, nil
+}
+
+// Verify 检查身份证书在 now 时有效，并由 roots 中某个有效的 CA 直接签发.
+// 不支持中间 CA；自签名身份证书只有本身在 roots 中时才被信任.
+func (id *IdentityCertificate) Verify(roots []*x509.Certificate, now time.Time) error {
+	if err := checkValidity(id.Signing, now); err != nil {
+		return err
+	}
+	if err := checkValidity(id.Encryption, now); err != nil {
+		return err
+	}
+	for _, root := range roots {
+		if !isCA(root) || !bytes.Equal(root.RawSubject, id.Signing.RawIssuer) {
+			continue
+		}
+		if len(id.Signing.AuthorityKeyId) != 0 && !bytes.Equal(id.Signing.AuthorityKeyId, root.SubjectKeyId) {
+			continue
+		}
+		rootKey, ok := parseSigningSPKI(root.RawSubjectPublicKeyInfo)
+		if !ok || checkSignature(id.Signing.Raw, rootKey) != nil || checkSignature(id.Encryption.Raw, rootKey) != nil {
+			continue
+		}
+		return checkValidity(root, now)
+	}
+	return utils.NewCryptoError(utils.ErrVerificationFailed,
+		fmt.Sprintf("Certificate for %s is not issued by a trusted CA", id.Signing.Subject))
+}
+
+func parseCertificates(pemData []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(pemData); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != pemTypeCertificate {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrInvalidFormat, fmt.Sprintf("Invalid certificate: %v", err))
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "No certificate found")
+	}
+	return certs, nil
+}
+
+func checkValidity(cert *x509.Certificate, now time.Time) error {
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return utils.NewCryptoError(utils.ErrVerificationFailed,
+			fmt.Sprintf("Certificate for %s is not valid at %s (valid from %s to %s)", cert.Subject,
+				now.UTC().Format(time.RFC3339), cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339)))
+	}
+	return nil
+}
+
+func isCA(cert *x509.Certificate) bool {
+	return cert.BasicConstraintsValid && cert.IsCA && cert.KeyUsage&x509.KeyUsageCertSign != 0
+}
+
+// identityPublicKeys 编码签名公钥、X25519 公钥，以及携带 Kyber768 公钥的 subjectAltPublicKeyInfo 扩展.
+func identityPublicKeys(signKey *mode3.PublicKey, encKey *HybridPublicKey) (signSPKI, encSPKI []byte,
+	altKey pkix.Extension, err error) {
+	if signKey == nil || encKey == nil || encKey.Kyber == nil || encKey.ECDH == nil {
+		return nil, nil, pkix.Extension{}, utils.NewCryptoError(utils.ErrInvalidKey, "Incomplete identity keys")
+	}
+	if signSPKI, err = MarshalPKIXPublicKey(signKey); err != nil {
+		return nil, nil, pkix.Extension{}, err
+	}
+	if encSPKI, err = MarshalPKIXPublicKey(encKey.ECDH); err != nil {
+		return nil, nil, pkix.Extension{}, err
+	}
+	kyberSPKI, err := MarshalPKIXPublicKey(encKey.Kyber)
+	if err != nil {
+		return nil, nil, pkix.Extension{}, err
+	}
+	return signSPKI, encSPKI, pkix.Extension{Id: oidExtSubjectAltPublicKey, Value: kyberSPKI}, nil
+}
+
+func parseSigningSPKI(spki []byte) (*mode3.PublicKey, bool) {
+	key, err := ParsePKIXPublicKey(spki)
+	if err != nil {
+		return nil, false
+	}
+	pub, ok := key.(*mode3.PublicKey)
+	return pub, ok
+}
+
+// parseEncryptionKey 由 X25519 SubjectPublicKeyInfo 和 subjectAltPublicKeyInfo 扩展还原混合公钥.
+func parseEncryptionKey(spki []byte, exts []pkix.Extension) (*HybridPublicKey, error) {
+	key, err := ParsePKIXPublicKey(spki)
+	if err != nil {
+		return nil, err
+	}
+	ecdhPub, ok := key.(*ecdh.PublicKey)
+	if !ok {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Encryption certificate must contain an X25519 key")
+	}
+	for _, ext := range exts {
+		if !ext.Id.Equal(oidExtSubjectAltPublicKey) {
+			continue
+		}
+		alt, err := ParsePKIXPublicKey(ext.Value)
+		if err != nil {
+			return nil, err
+		}
+		kyberPub, ok := alt.(kem.PublicKey)
+		if !ok {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Encryption certificate must contain a Kyber768 key")
+		}
+		return &HybridPublicKey{Kyber: kyberPub, ECDH: ecdhPub}, nil
+	}
+	return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Encryption certificate has no Kyber768 key")
+}
+
+// signASN1 编码 tbs 并用 Dilithium3 私钥签名，返回 Certificate 或 CertificationRequest 的 DER.
+func signASN1(tbs any, key SigningKey) ([]byte, error) {
+	if _, err := certSigningPublic(key); err != nil {
+		return nil, err
+	}
+	data, err := asn1.Marshal(tbs)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrSerializationFailed, fmt.Sprintf("Failed to marshal certificate: %v", err))
+	}
+	sig, err := SignDataWithKey(data, key)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:wrapcheck
+	return asn1.Marshal(signedData{
+		Data:               asn1.RawValue{FullBytes: data},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidDilithium3},
+		Signature:          asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)},
+	})
+}
+
+// certSigningPublic 返回 key 的 Dilithium3 公钥.
+// 证书只能表示 Dilithium3 公钥，签名算法标识也是 Dilithium3，因此不接受复合密钥.
+func certSigningPublic(key SigningKey) (*mode3.PublicKey, error) {
+	switch pub := DilithiumPublicFromPrivate(key).(type) {
+	case *mode3.PublicKey:
+		return pub, nil
+	case *CompositePublicKey:
+		return nil, errCompositeUnsupported()
+	default:
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Invalid Dilithium3 private key")
+	}
+}
+
+// checkSignature 用 Dilithium3 公钥验证证书或证书请求的签名.
+func checkSignature(der []byte, key *mode3.PublicKey) error {
+	var sd signedData
+	if rest, err := asn1.Unmarshal(der, &sd); err != nil || len(rest) != 0 {
+		return utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid signed certificate structure")
+	}
+	if !sd.SignatureAlgorithm.Algorithm.Equal(oidDilithium3) || len(sd.SignatureAlgorithm.Parameters.FullBytes) != 0 {
+		return utils.NewCryptoError(utils.ErrInvalidAlgorithm,
+			fmt.Sprintf("Unsupported certificate signature algorithm %s", sd.SignatureAlgorithm.Algorithm))
+	}
+	if !mode3.Verify(key, sd.Data.FullBytes, sd.Signature.RightAlign()) {
+		return utils.NewCryptoError(utils.ErrSignatureVerification, "Certificate signature verification failed")
+	}
+	return nil
+}
+
+// subjectKeyID 按 RFC 7093 方法 1 取公钥 SHA-256 的前 160 位.
+func subjectKeyID(rawKey []byte) []byte {
+	sum := sha256.Sum256(rawKey)
+	return sum[:20]
+}
+
+// keyUsageBits 按 RFC 5280 编码 keyUsage：位 0 为 BIT STRING 的最高位，省略末尾的零位.
+func keyUsageBits(usage x509.KeyUsage) asn1.BitString {
+	b := []byte{bits.Reverse8(byte(usage)), bits.Reverse8(byte(usage >> 8))}
+	if b[1] == 0 {
+		b = b[:1]
+	}
+	return asn1.BitString{Bytes: b, BitLength: 8*len(b) - bits.TrailingZeros8(b[len(b)-1])}
+}
+
+// extensionList 依次编码证书扩展，保留第一个编码错误.
+type extensionList struct {
+	exts []pkix.Extension
+	err  error
+}
+
+func (l *extensionList) add(oid asn1.ObjectIdentifier, critical bool, value any) {
+	if l.err != nil {
+		return
+	}
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		l.err = fmt.Errorf("extension %s: %w", oid, err)
+		return
+	}
+	l.exts = append(l.exts, pkix.Extension{Id: oid, Critical: critical, Value: der})
+}
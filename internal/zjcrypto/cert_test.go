@@ -0,0 +1,195 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+type testIdentity struct {
+	enc  *HybridPublicKey
+	sign *mode3.PrivateKey
+}
+
+func newTestIdentity(t *testing.T) testIdentity {
+	t.Helper()
+	kyberPub, _, ecdhPub, _, err := GenerateHybridKeysParallel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, signKey, err := GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testIdentity{enc: &HybridPublicKey{Kyber: kyberPub, ECDH: ecdhPub}, sign: signKey}
+}
+
+func TestIdentityCertificateIssue(t *testing.T) {
+	now := time.Now()
+	ca := newTestIdentity(t)
+	caPEM, err := SelfSignIdentity(pkix.Name{CommonName: "team-ca"}, ca.enc, ca.sign, now, now.AddDate(10, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	caID, err := ParseIdentityCertificate(caPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isCA(caID.Signing) || isCA(caID.Encryption) {
+		t.Fatal("自签名证书的签名证书应为 CA，加密证书不应为 CA")
+	}
+
+	alice := newTestIdentity(t)
+	subject, err := ParseSubject("CN=alice, O=team, OU=dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := CreateIdentityRequest(subject, alice.enc, alice.sign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := ParseIdentityRequest(csr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Subject.CommonName != "alice" || !req.SigningKey.Equal(DilithiumPublicFromPrivate(alice.sign)) {
+		t.Fatalf("请求内容不正确: %+v", req.Subject)
+	}
+
+	certPEM, err := IssueIdentityCertificate(req, caID, ca.sign, now, now.AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ParseIdentityCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !id.EncryptionKey.ECDH.Equal(alice.enc.ECDH) || !id.EncryptionKey.Kyber.Equal(alice.enc.Kyber) ||
+		!id.SigningKey.Equal(DilithiumPublicFromPrivate(alice.sign)) {
+		t.Fatal("证书中的公钥与请求不一致")
+	}
+	if id.Signing.Subject.String() != "CN=alice,OU=dev,O=team" || id.Signing.Issuer.CommonName != "team-ca" {
+		t.Fatalf("主题或签发者不正确: %s / %s", id.Signing.Subject, id.Signing.Issuer)
+	}
+	if isCA(id.Signing) {
+		t.Fatal("签发的证书不应为 CA")
+	}
+
+	// 从文件加载 CA，自签名身份证书本身即可作为 CA 文件
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	roots, err := LoadTrustedCAs(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 {
+		t.Fatalf("应只加载签名证书作为 CA，实际 %d 个", len(roots))
+	}
+	if err := id.Verify(roots, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := caID.Verify(roots, now.Add(time.Hour)); err != nil {
+		t.Fatalf("自签名 CA 应信任自身: %v", err)
+	}
+
+	// 过期、不受信任的 CA
+	if err := id.Verify(roots, now.AddDate(2, 0, 0)); err == nil {
+		t.Fatal("过期证书应验证失败")
+	}
+	other := newTestIdentity(t)
+	otherPEM, err := SelfSignIdentity(pkix.Name{CommonName: "team-ca"}, other.enc, other.sign, now, now.AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherID, err := ParseIdentityCertificate(otherPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := id.Verify([]*x509.Certificate{otherID.Signing}, now); err == nil {
+		t.Fatal("同名但不同密钥的 CA 不应被信任")
+	}
+	if err := otherID.Verify(roots, now); err == nil {
+		t.Fatal("未列入 CA 文件的自签名证书不应被信任")
+	}
+
+	// 非 CA 证书和不匹配的 CA 私钥不能签发
+	if _, err := IssueIdentityCertificate(req, id, alice.sign, now, now.AddDate(1, 0, 0)); err == nil {
+		t.Fatal("非 CA 证书不应能签发证书")
+	}
+	if _, err := IssueIdentityCertificate(req, caID, other.sign, now, now.AddDate(1, 0, 0)); err == nil {
+		t.Fatal("CA 私钥与证书不匹配时应失败")
+	}
+}
+
+func TestIdentityCertificateTampered(t *testing.T) {
+	now := time.Now()
+	ca := newTestIdentity(t)
+	caPEM, err := SelfSignIdentity(pkix.Name{CommonName: "ca"}, ca.enc, ca.sign, now, now.AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	caID, err := ParseIdentityCertificate(caPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 把加密证书换成另一身份的自签名加密证书：签名证书仍然有效，但加密证书的签名不对
+	other := newTestIdentity(t)
+	otherPEM, err := SelfSignIdentity(pkix.Name{CommonName: "ca"}, other.enc, other.sign, now, now.AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signBlock, rest := pem.Decode(caPEM)
+	_, rest2 := pem.Decode(otherPEM)
+	encBlock, _ := pem.Decode(rest2)
+	if signBlock == nil || encBlock == nil || len(bytes.TrimSpace(rest)) == 0 {
+		t.Fatal("证书应包含两个 PEM 块")
+	}
+	mixed := append(pem.EncodeToMemory(signBlock), pem.EncodeToMemory(encBlock)...)
+	id, err := ParseIdentityCertificate(mixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := id.Verify([]*x509.Certificate{caID.Signing}, now); err == nil {
+		t.Fatal("替换加密证书后应验证失败")
+	}
+
+	// 篡改请求的签名
+	csr, err := CreateIdentityRequest(pkix.Name{CommonName: "alice"}, other.enc, other.sign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqBlock, reqRest := pem.Decode(csr)
+	reqBlock.Bytes[len(reqBlock.Bytes)-1] ^= 0xff
+	tampered := append(pem.EncodeToMemory(reqBlock), reqRest...)
+	if _, err := ParseIdentityRequest(tampered); err == nil {
+		t.Fatal("签名被篡改的请求应验证失败")
+	}
+	if _, err := ParseIdentityRequest(csr[:len(csr)/2]); err == nil {
+		t.Fatal("只有一个请求时应失败")
+	}
+}
+
+func TestParseSubject(t *testing.T) {
+	name, err := ParseSubject("CN=alice,O=team,O=ops,C=CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name.CommonName != "alice" || len(name.Organization) != 2 || name.Country[0] != "CN" {
+		t.Fatalf("解析结果不正确: %+v", name)
+	}
+	for _, bad := range []string{"", "O=team", "CN=a,CN=b", "CN=alice,X=1", "CN"} {
+		if _, err := ParseSubject(bad); err == nil {
+			t.Errorf("%q 应解析失败", bad)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package zjcrypto
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/pem"
+	"io"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+// 复合签名: Ed25519 与 Dilithium3 对同一消息签名，两个分量都通过才视为有效.
+//
+//	M'   = "fzjjyz composite signature v1" || 0x00 || M
+//	签名 = Ed25519(M') (64 字节) || Dilithium3(M') (3293 字节)
+//
+// 两个分量签名的都是带前缀的 M'，因此任一分量都不能单独当作普通 Dilithium3 签名使用；
+// 文件头用 format.FlagCompositeSignature 记录签名类型，验签方不会接受降级为单一算法的签名.
+
+const compositeSignatureLabel = "fzjjyz composite signature v1"
+
+// CompositeSignatureSize 复合签名的长度.
+const CompositeSignatureSize = ed25519.SignatureSize + mode3.SignatureSize
+
+// CompositePublicKeySize 与 CompositePrivateKeySize 为 MarshalBinary 输出的长度.
+const (
+	CompositePublicKeySize  = mode3.PublicKeySize + ed25519.PublicKeySize
+	CompositePrivateKeySize = mode3.PrivateKeySize + ed25519.SeedSize
+)
+
+// SigningKey 签名私钥.
+// 实现有 *mode3.PrivateKey（Dilithium3）、*CompositePrivateKey（Ed25519 + Dilithium3）
+// 和 NewRemoteSigningKey 返回的远程签名密钥；Public 返回对应的 VerifyingKey.
+type SigningKey interface {
+	crypto.Signer
+	Equal(other crypto.PrivateKey) bool
+}
+
+// isNilSigningKey 判断签名私钥为 nil，包括装在接口中的 nil 指针（如 (*mode3.PrivateKey)(nil)）.
+// 接口与 nil 比较无法发现这种情况，调用 Sign 或 Public 会解引用 nil.
+func isNilSigningKey(key SigningKey) bool {
+	switch k := key.(type) {
+	case nil:
+		return true
+	case *mode3.PrivateKey:
+		return k == nil
+	case *CompositePrivateKey:
+		return k == nil
+	case *remoteSigningKey:
+		return k == nil
+	default:
+		return false
+	}
+}
+
+// VerifyingKey 验签公钥，实现有 *mode3.PublicKey 和 *CompositePublicKey.
+// Bytes 返回公钥的完整编码，复合公钥包含两个分量.
+type VerifyingKey interface {
+	Bytes() []byte
+	Equal(other crypto.PublicKey) bool
+}
+
+// CompositePublicKey Ed25519 + Dilithium3 复合公钥.
+type CompositePublicKey struct {
+	Dilithium *mode3.PublicKey
+	Ed25519   ed25519.PublicKey
+}
+
+// CompositePrivateKey Ed25519 + Dilithium3 复合私钥.
+type CompositePrivateKey struct {
+	Dilithium *mode3.PrivateKey
+	Ed25519   ed25519.PrivateKey
+}
+
+// NewCompositeSigningKey 返回由 dilithium 与 ed 组成的复合私钥.
+// 返回值持有两个分量本身，调用方不应再单独使用它们.
+func NewCompositeSigningKey(dilithium *mode3.PrivateKey, ed ed25519.PrivateKey) (*CompositePrivateKey, error) {
+	if dilithium == nil || len(ed) != ed25519.PrivateKeySize {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Invalid composite signing key components")
+	}
+	return &CompositePrivateKey{Dilithium: dilithium, Ed25519: ed}, nil
+}
+
+// NewCompositePublicKey 返回由 dilithium 与 ed 组成的复合公钥.
+func NewCompositePublicKey(dilithium *mode3.PublicKey, ed ed25519.PublicKey) (*CompositePublicKey, error) {
+	if dilithium == nil || len(ed) != ed25519.PublicKeySize {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Invalid composite public key components")
+	}
+	return &CompositePublicKey{Dilithium: dilithium, Ed25519: ed}, nil
+}
+
+// GenerateCompositeKeyPair 生成 Ed25519 + Dilithium3 复合签名密钥对.
+func GenerateCompositeKeyPair() (*CompositePublicKey, *CompositePrivateKey, error) {
+	_, dilithium, err := GenerateDilithiumKeyPair()
+	if err != nil {
+		return nil, nil, err
+	}
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, utils.NewCryptoError(utils.ErrKeyGenerationFailed, "Ed25519 key generation failed")
+	}
+	priv, err := NewCompositeSigningKey(dilithium, ed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv.PublicKey(), priv, nil
+}
+
+// Bytes 返回 Dilithium3 公钥 || Ed25519 公钥.
+func (k *CompositePublicKey) Bytes() []byte {
+	data := make([]byte, 0, CompositePublicKeySize)
+	data = append(data, k.Dilithium.Bytes()...)
+	return append(data, k.Ed25519...)
+}
+
+// MarshalBinary 实现 encoding.BinaryMarshaler，编码同 Bytes.
+func (k *CompositePublicKey) MarshalBinary() ([]byte, error) {
+	return k.Bytes(), nil
+}
+
+// UnmarshalBinary 解析 Bytes 的输出.
+func (k *CompositePublicKey) UnmarshalBinary(data []byte) error {
+	if len(data) != CompositePublicKeySize {
+		return utils.NewCryptoError(utils.ErrInvalidKey, "Invalid composite public key size")
+	}
+	dilithium := new(mode3.PublicKey)
+	if err := dilithium.UnmarshalBinary(data[:mode3.PublicKeySize]); err != nil {
+		return utils.NewCryptoError(utils.ErrInvalidKey, "Invalid Dilithium3 public key: "+err.Error())
+	}
+	k.Dilithium = dilithium
+	k.Ed25519 = ed25519.PublicKey(append([]byte(nil), data[mode3.PublicKeySize:]...))
+	return nil
+}
+
+// Equal 判断 other 是否为两个分量都相同的复合公钥.
+func (k *CompositePublicKey) Equal(other crypto.PublicKey) bool {
+	o, ok := other.(*CompositePublicKey)
+	return ok && k.Dilithium.Equal(o.Dilithium) && k.Ed25519.Equal(o.Ed25519)
+}
+
+// PublicKey 返回对应的复合公钥.
+func (k *CompositePrivateKey) PublicKey() *CompositePublicKey {
+	//nolint:forcetypeassert // 两个分量的 Public 返回类型固定
+	return &CompositePublicKey{
+		Dilithium: k.Dilithium.Public().(*mode3.PublicKey),
+		Ed25519:   k.Ed25519.Public().(ed25519.PublicKey),
+	}
+}
+
+// Public 实现 crypto.Signer，返回 *CompositePublicKey.
+func (k *CompositePrivateKey) Public() crypto.PublicKey {
+	return k.PublicKey()
+}
+
+// Sign 实现 crypto.Signer，对 message 生成复合签名；与 mode3.PrivateKey 相同，opts 必须不带哈希.
+func (k *CompositePrivateKey) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != 0 {
+		return nil, utils.NewCryptoError(utils.ErrSigningFailed, "Composite signatures sign the message directly")
+	}
+	msg := compositeMessage(message)
+	signature := make([]byte, CompositeSignatureSize)
+	copy(signature, ed25519.Sign(k.Ed25519, msg))
+	mode3.SignTo(k.Dilithium, msg, signature[ed25519.SignatureSize:])
+	return signature, nil
+}
+
+// Bytes 返回 Dilithium3 私钥 || Ed25519 种子.
+func (k *CompositePrivateKey) Bytes() []byte {
+	data := make([]byte, 0, CompositePrivateKeySize)
+	data = append(data, k.Dilithium.Bytes()...)
+	return append(data, k.Ed25519.Seed()...)
+}
+
+// MarshalBinary 实现 encoding.BinaryMarshaler，编码同 Bytes.
+func (k *CompositePrivateKey) MarshalBinary() ([]byte, error) {
+	return k.Bytes(), nil
+}
+
+// UnmarshalBinary 解析 Bytes 的输出.
+func (k *CompositePrivateKey) UnmarshalBinary(data []byte) error {
+	if len(data) != CompositePrivateKeySize {
+		return utils.NewCryptoError(utils.ErrInvalidKey, "Invalid composite private key size")
+	}
+	dilithium := new(mode3.PrivateKey)
+	if err := dilithium.UnmarshalBinary(data[:mode3.PrivateKeySize]); err != nil {
+		return utils.NewCryptoError(utils.ErrInvalidKey, "Invalid Dilithium3 private key: "+err.Error())
+	}
+	k.Dilithium = dilithium
+	k.Ed25519 = ed25519.NewKeyFromSeed(data[mode3.PrivateKeySize:])
+	return nil
+}
+
+// Equal 判断 other 是否为两个分量都相同的复合私钥.
+func (k *CompositePrivateKey) Equal(other crypto.PrivateKey) bool {
+	o, ok := other.(*CompositePrivateKey)
+	return ok && k.Dilithium.Equal(o.Dilithium) &&
+		subtle.ConstantTimeCompare(k.Ed25519, o.Ed25519) == 1
+}
+
+// Wipe 清零复合私钥的两个分量.
+func (k *CompositePrivateKey) Wipe() {
+	*k.Dilithium = mode3.PrivateKey{}
+	clear(k.Ed25519)
+}
+
+// IsCompositeKey 判断 key 是否为复合公钥、复合私钥或公钥为复合公钥的远程签名密钥.
+func IsCompositeKey(key any) bool {
+	switch k := key.(type) {
+	case *CompositePublicKey, *CompositePrivateKey:
+		return true
+	case SigningKey:
+		_, ok := k.Public().(*CompositePublicKey)
+		return ok
+	}
+	return false
+}
+
+// SignatureSizeFor 返回 key（本地、复合或远程）产生的签名长度.
+func SignatureSizeFor(key SigningKey) int {
+	if IsCompositeKey(key) {
+		return CompositeSignatureSize
+	}
+	return mode3.SignatureSize
+}
+
+func compositeMessage(data []byte) []byte {
+	msg := make([]byte, 0, len(compositeSignatureLabel)+1+len(data))
+	msg = append(msg, compositeSignatureLabel...)
+	msg = append(msg, 0)
+	return append(msg, data...)
+}
+
+// verifyComposite 验证复合签名，两个分量都必须通过.
+func verifyComposite(pub *CompositePublicKey, data, signature []byte) bool {
+	if len(signature) != CompositeSignatureSize {
+		return false
+	}
+	msg := compositeMessage(data)
+	edOK := ed25519.Verify(pub.Ed25519, msg, signature[:ed25519.SignatureSize])
+	dilithiumOK := mode3.Verify(pub.Dilithium, msg, signature[ed25519.SignatureSize:])
+	return edOK && dilithiumOK
+}
+
+// errCompositeUnsupported 用于只能表示单一 Dilithium3 密钥的格式（PKCS#8、证书、纸质备份等）.
+func errCompositeUnsupported() error {
+	return utils.NewCryptoError(utils.ErrInvalidKey,
+		"Composite Ed25519 + Dilithium3 keys are not supported in this format")
+}
+
+// 复合密钥文件在 Dilithium3 PEM 块之后追加一个 Ed25519 PEM 块（公钥 32 字节，私钥为 32 字节种子）.
+// 只读取第一个 PEM 块的旧版本仍能加载其中的 Dilithium3 分量.
+const (
+	pemTypeEd25519Public  = "ED25519 PUBLIC KEY"
+	pemTypeEd25519Private = "ED25519 PRIVATE KEY"
+)
+
+// findPEMBlock 返回 data 中第一个类型为 typ 的 PEM 块.
+func findPEMBlock(data []byte, typ string) *pem.Block {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil
+		}
+		if block.Type == typ {
+			return block
+		}
+	}
+}
+
+// compositePublicFromPEM 在 data 含 Ed25519 公钥块时把 pub 组合为复合公钥.
+func compositePublicFromPEM(pub *mode3.PublicKey, data []byte) (VerifyingKey, error) {
+	block := findPEMBlock(data, pemTypeEd25519Public)
+	if block == nil {
+		return pub, nil
+	}
+	if len(block.Bytes) != ed25519.PublicKeySize {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Invalid Ed25519 public key size")
+	}
+	return NewCompositePublicKey(pub, ed25519.PublicKey(block.Bytes))
+}
+
+// compositePrivateFromPEM 在 data 含 Ed25519 私钥块时把 priv 组合为复合私钥.
+func compositePrivateFromPEM(priv *mode3.PrivateKey, data []byte) (SigningKey, error) {
+	block := findPEMBlock(data, pemTypeEd25519Private)
+	if block == nil {
+		return priv, nil
+	}
+	defer clear(block.Bytes)
+	if len(block.Bytes) != ed25519.SeedSize {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Invalid Ed25519 private key size")
+	}
+	return NewCompositeSigningKey(priv, ed25519.NewKeyFromSeed(block.Bytes))
+}
@@ -0,0 +1,320 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+// generateCompositeTestKeys 生成签名密钥为复合密钥的测试密钥.
+func generateCompositeTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	keys := generateTestKeys(t)
+	var err error
+	if keys.dilithiumPub, keys.dilithiumPriv, err = GenerateCompositeKeyPair(); err != nil {
+		t.Fatalf("生成复合密钥失败: %v", err)
+	}
+	return keys
+}
+
+// plainDilithiumPublic 返回复合公钥中 Dilithium3 分量的普通公钥.
+func plainDilithiumPublic(pub VerifyingKey) *mode3.PublicKey {
+	return pub.(*CompositePublicKey).Dilithium //nolint:forcetypeassert // 测试只传入复合公钥
+}
+
+func TestCompositeSignVerify(t *testing.T) {
+	pub, priv, err := GenerateCompositeKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsCompositeKey(priv) || !IsCompositeKey(pub) || SignatureSizeFor(priv) != CompositeSignatureSize {
+		t.Fatal("应为复合密钥")
+	}
+
+	data := []byte("composite message")
+	sig, err := SignDataWithKey(data, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig) != CompositeSignatureSize {
+		t.Fatalf("签名长度 = %d", len(sig))
+	}
+	if ok, _ := VerifySignatureWithKey(data, sig, pub); !ok {
+		t.Fatal("复合签名应验证通过")
+	}
+
+	// 任一分量被篡改都失败
+	for name, i := range map[string]int{"Ed25519": 10, "Dilithium3": ed25519.SignatureSize + 10} {
+		bad := bytes.Clone(sig)
+		bad[i] ^= 1
+		if ok, _ := VerifySignatureWithKey(data, bad, pub); ok {
+			t.Errorf("%s 分量被篡改时应验证失败", name)
+		}
+	}
+
+	// 普通 Dilithium3 签名不能冒充复合签名，复合签名的 Dilithium3 分量也不能单独使用
+	plainPub := plainDilithiumPublic(pub)
+	plainSig := make([]byte, mode3.SignatureSize)
+	mode3.SignTo(priv.Dilithium, data, plainSig)
+	if ok, _ := VerifySignatureWithKey(data, plainSig, pub); ok {
+		t.Error("复合公钥不应接受普通签名")
+	}
+	if ok, _ := VerifySignatureWithKey(data, sig, plainPub); ok {
+		t.Error("普通公钥不应接受复合签名")
+	}
+	if ok, _ := VerifySignatureWithKey(data, sig[ed25519.SignatureSize:], plainPub); ok {
+		t.Error("复合签名的 Dilithium3 分量不应是有效的普通签名")
+	}
+
+	if DilithiumFingerprint(pub) == DilithiumFingerprint(plainPub) {
+		t.Error("复合公钥的指纹应覆盖 Ed25519 分量")
+	}
+}
+
+func TestCompositeKeyFiles(t *testing.T) {
+	pub, priv, err := GenerateCompositeKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := ExportDilithiumKeys(pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(pair.Public), "ED25519 PUBLIC KEY") ||
+		!strings.Contains(string(pair.Private), "ED25519 PRIVATE KEY") {
+		t.Fatal("复合密钥文件应包含 Ed25519 块")
+	}
+	if _, err := ExportDilithiumKeys(plainDilithiumPublic(pub), priv); err == nil {
+		t.Error("公钥与私钥类型不一致时应导出失败")
+	}
+
+	gotPub, gotPriv, err := ImportDilithiumKeys(pair.Public, pair.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gotPub.Equal(pub) || !priv.Equal(gotPriv) {
+		t.Fatal("导入后应为相同的复合密钥")
+	}
+	data := []byte("reloaded")
+	sig, _ := SignDataWithKey(data, gotPriv)
+	if ok, _ := VerifySignatureWithKey(data, sig, pub); !ok {
+		t.Fatal("导入的私钥签名应能由原公钥验证")
+	}
+
+	// 缓存重建的私钥保留 Ed25519 分量
+	dir := t.TempDir()
+	pubPath, privPath := filepath.Join(dir, "c_public.pem"), filepath.Join(dir, "c_private.pem")
+	if err := SaveDilithiumKeys(pub, priv, pubPath, privPath); err != nil {
+		t.Fatal(err)
+	}
+	cache, _ := newTestKeyCache(t, KeyCacheConfig{})
+	for range 2 {
+		cached, err := cache.LoadDilithiumPrivateKey(privPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, _ := SignDataWithKey(data, cached)
+		if ok, _ := VerifySignatureWithKey(data, sig, pub); !ok {
+			t.Fatal("缓存加载的复合私钥签名应验证通过")
+		}
+	}
+
+	// 只能表示单一 Dilithium3 密钥的格式拒绝复合密钥
+	if _, err := MarshalPKCS8PrivateKey(priv); err == nil {
+		t.Error("PKCS#8 应拒绝复合私钥")
+	}
+	if _, err := MarshalPKIXPublicKey(pub); err == nil {
+		t.Error("PKIX 应拒绝复合公钥")
+	}
+	if _, err := NewPaperKey(pub); err == nil {
+		t.Error("纸质备份应拒绝复合公钥")
+	}
+}
+
+func TestCompositeKeyEncoding(t *testing.T) {
+	pub, priv, err := GenerateCompositeKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Bytes / UnmarshalBinary 保留两个分量
+	var gotPub CompositePublicKey
+	if err := gotPub.UnmarshalBinary(pub.Bytes()); err != nil || !gotPub.Equal(pub) {
+		t.Fatalf("复合公钥编码往返失败: %v", err)
+	}
+	var gotPriv CompositePrivateKey
+	if err := gotPriv.UnmarshalBinary(priv.Bytes()); err != nil || !gotPriv.Equal(priv) {
+		t.Fatalf("复合私钥编码往返失败: %v", err)
+	}
+	if len(pub.Bytes()) != CompositePublicKeySize || len(priv.Bytes()) != CompositePrivateKeySize {
+		t.Error("编码长度不正确")
+	}
+	if err := gotPub.UnmarshalBinary(pub.Dilithium.Bytes()); err == nil {
+		t.Error("只有 Dilithium3 分量的数据不应解析为复合公钥")
+	}
+
+	// 值拷贝仍是复合密钥
+	copied := *priv
+	data := []byte("copied")
+	sig, err := SignDataWithKey(data, &copied)
+	if err != nil || len(sig) != CompositeSignatureSize {
+		t.Fatalf("值拷贝签名失败: %v", err)
+	}
+	if ok, _ := VerifySignatureWithKey(data, sig, pub); !ok {
+		t.Error("值拷贝的签名应验证通过")
+	}
+
+	// Equal 比较两个分量，Dilithium3 分量相同但 Ed25519 分量不同时不相等
+	_, otherEd, _ := ed25519.GenerateKey(nil)
+	other, err := NewCompositeSigningKey(priv.Dilithium, otherEd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Equal(priv) || other.PublicKey().Equal(pub) {
+		t.Error("Ed25519 分量不同的复合密钥不应相等")
+	}
+	if pub.Equal(pub.Dilithium) || pub.Dilithium.Equal(pub) {
+		t.Error("复合公钥与普通公钥不应相等")
+	}
+	if DilithiumFingerprint(other.PublicKey()) == DilithiumFingerprint(pub) {
+		t.Error("Ed25519 分量不同的复合公钥指纹应不同")
+	}
+}
+
+func TestCompositeFileSignature(t *testing.T) {
+	composite := generateCompositeTestKeys(t)
+	plain := generateTestKeys(t)
+	plain.kyberPub, plain.kyberPriv = composite.kyberPub, composite.kyberPriv
+	plain.ecdhPub, plain.ecdhPriv = composite.ecdhPub, composite.ecdhPriv
+
+	data := []byte("signed with both algorithms")
+	sealed, err := EncryptData(data, "a.txt", composite.kyberPub, composite.ecdhPub, composite.dilithiumPriv, NoCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := format.ParseFileHeaderFromBytes(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.SignatureType() != format.SignatureComposite || int(header.SigLen) != CompositeSignatureSize {
+		t.Fatalf("签名类型 = %s, 长度 = %d", format.SignatureTypeName(header.SignatureType()), header.SigLen)
+	}
+
+	got, err := DecryptDataCore(sealed, composite.kyberPriv, composite.ecdhPriv, composite.dilithiumPub)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("复合签名文件解密失败: %v", err)
+	}
+	if _, err := DecryptDataCore(sealed, composite.kyberPriv, composite.ecdhPriv,
+		plainDilithiumPublic(composite.dilithiumPub)); err == nil {
+		t.Error("普通公钥不应验证复合签名文件")
+	}
+
+	// 降级：复合公钥不接受普通签名的文件
+	downgraded, err := EncryptData(data, "a.txt", plain.kyberPub, plain.ecdhPub, plain.dilithiumPriv, NoCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptDataCore(downgraded, composite.kyberPriv, composite.ecdhPriv, composite.dilithiumPub); err == nil {
+		t.Error("复合公钥应拒绝普通签名文件")
+	}
+
+	// 清除标志位或篡改任一分量都失败
+	tamper := map[string]func(h *format.FileHeader){
+		"flag":       func(h *format.FileHeader) { h.SetSignatureType(format.SignatureDilithium3) },
+		"ed25519":    func(h *format.FileHeader) { h.Signature[0] ^= 1 },
+		"dilithium3": func(h *format.FileHeader) { h.Signature[ed25519.SignatureSize] ^= 1 },
+	}
+	for name, fn := range tamper {
+		h, ciphertext, err := parseEncryptedData(sealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Signature = bytes.Clone(h.Signature)
+		fn(h)
+		bad, err := encodeEncryptedData(h, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecryptDataCore(bad, composite.kyberPriv, composite.ecdhPriv, composite.dilithiumPub); err == nil {
+			t.Errorf("%s: 应验证失败", name)
+		}
+	}
+}
+
+func TestCompositeChunkedFile(t *testing.T) {
+	keys := generateCompositeTestKeys(t)
+	plain, data := encryptChunkedTestFile(t, keys, 5000, 1024)
+	reader, err := OpenReaderAt(bytes.NewReader(data), int64(len(data)), keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("OpenReaderAt 失败: %v", err)
+	}
+	if reader.Header().SignatureType() != format.SignatureComposite {
+		t.Fatal("分块文件应记录复合签名类型")
+	}
+	got := make([]byte, len(plain))
+	if _, err := reader.ReadAt(got, 0); err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("读取失败: %v", err)
+	}
+	if _, err := OpenReaderAt(bytes.NewReader(data), int64(len(data)), keys.kyberPriv, keys.ecdhPriv,
+		plainDilithiumPublic(keys.dilithiumPub)); err == nil {
+		t.Error("普通公钥不应验证复合签名的分块文件")
+	}
+
+	// 分卷头使用复合签名，普通公钥不能验证
+	input := filepath.Join(t.TempDir(), "in.bin")
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	paths, err := SplitVolumes(input, input, MinVolumeSize, keys.dilithiumPriv)
+	if err != nil {
+		t.Fatalf("复合密钥分卷失败: %v", err)
+	}
+	joined := filepath.Join(t.TempDir(), "joined.bin")
+	if _, err := JoinVolumes(paths[0], joined, keys.dilithiumPub); err != nil {
+		t.Fatalf("复合签名分卷合并失败: %v", err)
+	}
+	if got, _ := os.ReadFile(joined); !bytes.Equal(got, data) { // #nosec G304 - 测试环境使用临时文件路径
+		t.Fatal("复合签名分卷合并结果不一致")
+	}
+	if _, err := JoinVolumes(paths[0], joined, plainDilithiumPublic(keys.dilithiumPub)); err == nil {
+		t.Error("普通公钥不应验证复合签名的分卷")
+	}
+
+	// 复合公钥不接受普通 Dilithium3 签名的分卷（防止降级）
+	plainKeys := generateTestKeys(t)
+	paths, err = SplitVolumes(input, filepath.Join(t.TempDir(), "plain"), MinVolumeSize, plainKeys.dilithiumPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	composite, err := NewCompositePublicKey(plainKeys.dilithiumPub.(*mode3.PublicKey), make(ed25519.PublicKey, ed25519.PublicKeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := JoinVolumes(paths[0], joined, composite); err == nil {
+		t.Error("复合公钥不应接受普通签名的分卷")
+	}
+}
+
+func TestCompositeMnemonic(t *testing.T) {
+	keys, err := DeriveKeysFromMnemonic(mnemonicVectors[0].mnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := NewCompositeSigningKey(keys.DilithiumPriv, keys.Ed25519Priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := priv.PublicKey()
+	if !pub.Ed25519.Equal(keys.Ed25519Priv.Public()) || !pub.Dilithium.Equal(keys.DilithiumPub) {
+		t.Fatal("复合公钥分量不一致")
+	}
+	if _, err := NewCompositeSigningKey(keys.DilithiumPriv, nil); err == nil {
+		t.Error("缺少 Ed25519 分量应失败")
+	}
+}
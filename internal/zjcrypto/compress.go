@@ -0,0 +1,183 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/klauspost/compress/zstd"
+)
+
+// MaxDecompressionRatio 解压比上限（原始大小 / 压缩数据大小），防止压缩炸弹.
+// 加密时若压缩比超过该上限会自动回退为不压缩，保证合法文件始终可解密.
+const MaxDecompressionRatio = 1024
+
+// decompressPreallocFactor 解压缓冲区按压缩数据大小预分配的倍数上限.
+const decompressPreallocFactor = 4
+
+// CompressionOptions 加密前压缩选项.
+type CompressionOptions struct {
+	Codec byte // 压缩算法: format.CompressionNone / CompressionGzip / CompressionZstd
+	Level int  // 压缩级别，0 表示使用算法默认级别
+}
+
+// NoCompression 不压缩（默认行为，输出与旧版本格式完全一致）.
+var NoCompression = CompressionOptions{Codec: format.CompressionNone}
+
+// ParseCompressionOptions 解析命令行形式的压缩算法名称和级别.
+// 支持 none、gzip（级别 1-9）、zstd（级别 1-22），级别 0 表示默认.
+func ParseCompressionOptions(name string, level int) (CompressionOptions, error) {
+	var codec byte
+	maxLevel := 0
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "none":
+		codec = format.CompressionNone
+	case "gzip":
+		codec = format.CompressionGzip
+		maxLevel = gzip.BestCompression
+	case "zstd":
+		codec = format.CompressionZstd
+		maxLevel = 22
+	default:
+		return NoCompression, utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Unsupported compression: %s (supported: none, gzip, zstd)", name),
+		)
+	}
+
+	if level < 0 || level > maxLevel {
+		return NoCompression, utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Compression level %d out of range for %s (0-%d)", level, format.CompressionName(codec), maxLevel),
+		)
+	}
+
+	return CompressionOptions{Codec: codec, Level: level}, nil
+}
+
+// compressPayload 按选项压缩明文，返回实际写入的数据和实际使用的压缩算法.
+// 压缩比超过 MaxDecompressionRatio 时回退为不压缩.
+func compressPayload(plaintext []byte, opts CompressionOptions) ([]byte, byte, error) {
+	if opts.Codec == format.CompressionNone {
+		return plaintext, format.CompressionNone, nil
+	}
+
+	compressed, err := compressData(plaintext, opts)
+	if err != nil {
+		return nil, format.CompressionNone, err
+	}
+
+	if uint64(len(plaintext)) > MaxDecompressionRatio*uint64(len(compressed)) {
+		return plaintext, format.CompressionNone, nil
+	}
+
+	return compressed, opts.Codec, nil
+}
+
+// compressData 使用指定算法压缩数据.
+func compressData(data []byte, opts CompressionOptions) ([]byte, error) {
+	switch opts.Codec {
+	case format.CompressionGzip:
+		level := opts.Level
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		var buf bytes.Buffer
+		zw, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Invalid gzip level: "+err.Error())
+		}
+		if _, err := zw.Write(data); err != nil {
+			return nil, fmt.Errorf("gzip compress: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("gzip close: %w", err)
+		}
+		return buf.Bytes(), nil
+
+	case format.CompressionZstd:
+		encOpts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if opts.Level > 0 {
+			encOpts = append(encOpts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(opts.Level)))
+		}
+		enc, err := zstd.NewWriter(nil, encOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("create zstd encoder: %w", err)
+		}
+		defer func() {
+			_ = enc.Close()
+		}()
+		return enc.EncodeAll(data, make([]byte, 0, len(data)/2)), nil
+
+	default:
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Unsupported compression: 0x%02x", opts.Codec),
+		)
+	}
+}
+
+// decompressData 解压数据，并确保输出恰好为 expectedSize 字节.
+// 解压前检查解压比，解压时限制读取量，防止压缩炸弹耗尽内存.
+func decompressData(data []byte, codec byte, expectedSize uint64) ([]byte, error) {
+	if codec == format.CompressionNone {
+		return data, nil
+	}
+
+	if expectedSize > MaxDecompressionRatio*uint64(len(data)) {
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidData,
+			fmt.Sprintf("Decompression ratio exceeds limit (%d bytes from %d bytes, max ratio %d)",
+				expectedSize, len(data), MaxDecompressionRatio),
+		)
+	}
+
+	var reader io.Reader
+	switch codec {
+	case format.CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrInvalidData, "Invalid gzip data: "+err.Error())
+		}
+		defer func() {
+			_ = zr.Close()
+		}()
+		reader = zr
+
+	case format.CompressionZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrInvalidData, "Invalid zstd data: "+err.Error())
+		}
+		defer zr.Close()
+		reader = zr
+
+	default:
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidFormat,
+			fmt.Sprintf("Unsupported compression: 0x%02x", codec),
+		)
+	}
+
+	// 解压比已限制 expectedSize 为密文大小的有限倍数，转换不会溢出
+	// 多读 1 字节，用于检测实际解压大小超过头部声明
+	limit := int64(expectedSize) + 1 // #nosec G115
+	// expectedSize 来自尚未验证签名的头部，预分配不超过压缩数据的若干倍，其余按实际输出增长
+	var buf bytes.Buffer
+	buf.Grow(int(min(expectedSize, uint64(len(data))*decompressPreallocFactor))) // #nosec G115
+	if _, err := io.Copy(&buf, io.LimitReader(reader, limit)); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidData, "Decompression failed: "+err.Error())
+	}
+	if uint64(buf.Len()) != expectedSize {
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidData,
+			fmt.Sprintf("Decompressed size mismatch: got %d bytes, expected %d", buf.Len(), expectedSize),
+		)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,205 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+)
+
+// TestEncryptWithCompressionRoundTrip 测试压缩加密后可透明解密.
+func TestEncryptWithCompressionRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	kyberPub, kyberPriv, _ := GenerateKyberKeys()
+	ecdhPub, ecdhPriv, _ := GenerateECDHKeys()
+	dilithiumPub, dilithiumPriv, _ := GenerateDilithiumKeys()
+
+	var logBuf bytes.Buffer
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&logBuf, "2026-01-01T00:%02d:%02d INFO request %d served in %dms\n", i/60%60, i%60, i, i*7%113)
+	}
+	originalData := logBuf.Bytes()
+	originalFile := filepath.Join(tmpDir, "app.log")
+	if err := os.WriteFile(originalFile, originalData, 0600); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		codec string
+		level int
+		want  byte
+	}{
+		{"gzip default", "gzip", 0, format.CompressionGzip},
+		{"gzip best", "gzip", 9, format.CompressionGzip},
+		{"zstd default", "zstd", 0, format.CompressionZstd},
+		{"zstd level 19", "zstd", 19, format.CompressionZstd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ParseCompressionOptions(tt.codec, tt.level)
+			if err != nil {
+				t.Fatalf("解析压缩选项失败: %v", err)
+			}
+
+			encryptedFile := filepath.Join(tmpDir, tt.codec+".fzj")
+			err = EncryptFileWithCompression(originalFile, encryptedFile, kyberPub, ecdhPub, dilithiumPriv, opts)
+			if err != nil {
+				t.Fatalf("压缩加密失败: %v", err)
+			}
+
+			data, err := os.ReadFile(encryptedFile) //nolint:gosec
+			if err != nil {
+				t.Fatalf("读取加密文件失败: %v", err)
+			}
+			header, err := format.ParseFileHeaderFromBytes(data)
+			if err != nil {
+				t.Fatalf("解析头部失败: %v", err)
+			}
+			if header.Compression() != tt.want {
+				t.Errorf("头部压缩算法错误: %s", format.CompressionName(header.Compression()))
+			}
+			if header.FileSize != uint64(len(originalData)) {
+				t.Errorf("FileSize 应记录原始大小: %d", header.FileSize)
+			}
+			if len(data) >= len(originalData)/2 {
+				t.Errorf("压缩后密文未明显变小: %d -> %d", len(originalData), len(data))
+			}
+
+			decryptedFile := filepath.Join(tmpDir, tt.codec+".out")
+			if err := DecryptFile(encryptedFile, decryptedFile, kyberPriv, ecdhPriv, dilithiumPub); err != nil {
+				t.Fatalf("解密失败: %v", err)
+			}
+			decrypted, err := os.ReadFile(decryptedFile) //nolint:gosec
+			if err != nil {
+				t.Fatalf("读取解密文件失败: %v", err)
+			}
+			if !bytes.Equal(decrypted, originalData) {
+				t.Error("解密数据与原始数据不一致")
+			}
+		})
+	}
+}
+
+// TestStreamingEncryptWithCompression 测试流式加密器的压缩设置.
+func TestStreamingEncryptWithCompression(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	kyberPub, kyberPriv, _ := GenerateKyberKeys()
+	ecdhPub, ecdhPriv, _ := GenerateECDHKeys()
+	dilithiumPub, dilithiumPriv, _ := GenerateDilithiumKeys()
+
+	var csvBuf bytes.Buffer
+	csvBuf.WriteString("id,name,value\n")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&csvBuf, "%d,item-%d,%d\n", i, i%37, i*i%1009)
+	}
+	originalData := csvBuf.Bytes()
+	originalFile := filepath.Join(tmpDir, "data.csv")
+	if err := os.WriteFile(originalFile, originalData, 0600); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	encryptor, err := NewStreamingEncryptor(kyberPub, ecdhPub, dilithiumPriv, DefaultBufferSize)
+	if err != nil {
+		t.Fatalf("创建流式加密器失败: %v", err)
+	}
+	encryptor.SetCompression(CompressionOptions{Codec: format.CompressionZstd})
+
+	encryptedFile := filepath.Join(tmpDir, "data.csv.fzj")
+	if err := encryptor.EncryptFile(originalFile, encryptedFile); err != nil {
+		t.Fatalf("流式压缩加密失败: %v", err)
+	}
+
+	decryptedFile := filepath.Join(tmpDir, "data.out")
+	err = DecryptFileStreamingAuto(encryptedFile, decryptedFile, kyberPriv, ecdhPriv, dilithiumPub)
+	if err != nil {
+		t.Fatalf("流式解密失败: %v", err)
+	}
+	decrypted, err := os.ReadFile(decryptedFile) //nolint:gosec
+	if err != nil {
+		t.Fatalf("读取解密文件失败: %v", err)
+	}
+	if !bytes.Equal(decrypted, originalData) {
+		t.Error("解密数据与原始数据不一致")
+	}
+}
+
+// TestCompressionFallbackOnExtremeRatio 测试压缩比超限时回退为不压缩.
+func TestCompressionFallbackOnExtremeRatio(t *testing.T) {
+	zeros := make([]byte, 8*1024*1024)
+
+	payload, codec, err := compressPayload(zeros, CompressionOptions{Codec: format.CompressionZstd})
+	if err != nil {
+		t.Fatalf("压缩失败: %v", err)
+	}
+	if codec != format.CompressionNone {
+		t.Errorf("极端压缩比应回退为不压缩，实际: %s", format.CompressionName(codec))
+	}
+	if len(payload) != len(zeros) {
+		t.Errorf("回退后应保留原始数据，长度: %d", len(payload))
+	}
+}
+
+// TestDecompressionBombRejected 测试解压比保护.
+func TestDecompressionBombRejected(t *testing.T) {
+	data := []byte(strings.Repeat("a", 64*1024))
+	compressed, err := compressData(data, CompressionOptions{Codec: format.CompressionGzip})
+	if err != nil {
+		t.Fatalf("压缩失败: %v", err)
+	}
+
+	// 头部声明的大小超过解压比上限
+	claimed := uint64(len(compressed))*MaxDecompressionRatio + 1
+	if _, err := decompressData(compressed, format.CompressionGzip, claimed); err == nil {
+		t.Error("超过解压比上限应该返回错误")
+	}
+
+	// 实际解压大小超过头部声明
+	if _, err := decompressData(compressed, format.CompressionGzip, uint64(len(data)-1)); err == nil {
+		t.Error("解压大小超过声明应该返回错误")
+	}
+
+	// 声明大小正确时可以解压
+	out, err := decompressData(compressed, format.CompressionGzip, uint64(len(data)))
+	if err != nil {
+		t.Fatalf("解压失败: %v", err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Error("解压数据不一致")
+	}
+}
+
+// TestParseCompressionOptions 测试压缩选项解析.
+func TestParseCompressionOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		codec   string
+		level   int
+		wantErr bool
+	}{
+		{"none", "none", 0, false},
+		{"empty", "", 0, false},
+		{"gzip upper", "GZIP", 6, false},
+		{"zstd max", "zstd", 22, false},
+		{"unknown codec", "brotli", 0, true},
+		{"gzip level too high", "gzip", 10, true},
+		{"negative level", "zstd", -1, true},
+		{"level without codec", "none", 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCompressionOptions(tt.codec, tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCompressionOptions(%q, %d) err = %v, wantErr %v", tt.codec, tt.level, err, tt.wantErr)
+			}
+		})
+	}
+}
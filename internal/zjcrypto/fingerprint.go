@@ -0,0 +1,149 @@
+package zjcrypto
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// 公钥指纹:
+//
+//	混合公钥     = SHA-256(Kyber768 公钥 || X25519 公钥)，与 RecipientFingerprint 相同
+//	Dilithium 公钥 = SHA-256(Dilithium3 公钥)，与 SigningFingerprint 相同
+//	复合公钥     = SHA-256(Dilithium3 公钥 || Ed25519 公钥)
+//	身份         = SHA-256("fzjjyz identity fingerprint v1" || 0x00 || 混合公钥指纹 || Dilithium 公钥指纹)
+//
+// 指纹可以显示为十六进制分组、12 个 BIP39 单词（前 132 位）和 7 个表情符号的短认证串（前 42 位）.
+// 短认证串只适合双方实时核对，长期保存或脚本比较应使用十六进制或单词.
+
+const identityFingerprintLabel = "fzjjyz identity fingerprint v1"
+
+const (
+	fingerprintWords    = 12
+	fingerprintWordBits = 11
+	sasLength           = 7
+	sasBits             = 6
+)
+
+// Fingerprint 公钥的 SHA-256 指纹.
+type Fingerprint [sha256.Size]byte
+
+// SASEmoji 短认证串中的一个表情符号及其名称，电话核对时读出名称.
+type SASEmoji struct {
+	Emoji string
+	Name  string
+}
+
+// sasEmojis 短认证串使用的 64 个表情符号，每个表示 6 位.
+var sasEmojis = [1 << sasBits]SASEmoji{
+	{"🐶", "Dog"}, {"🐱", "Cat"}, {"🦁", "Lion"}, {"🐎", "Horse"},
+	{"🦄", "Unicorn"}, {"🐷", "Pig"}, {"🐘", "Elephant"}, {"🐰", "Rabbit"},
+	{"🐼", "Panda"}, {"🐓", "Rooster"}, {"🐧", "Penguin"}, {"🐢", "Turtle"},
+	{"🐟", "Fish"}, {"🐙", "Octopus"}, {"🦋", "Butterfly"}, {"🌷", "Flower"},
+	{"🌳", "Tree"}, {"🌵", "Cactus"}, {"🍄", "Mushroom"}, {"🌏", "Globe"},
+	{"🌙", "Moon"}, {"☁️", "Cloud"}, {"🔥", "Fire"}, {"🍌", "Banana"},
+	{"🍎", "Apple"}, {"🍓", "Strawberry"}, {"🌽", "Corn"}, {"🍕", "Pizza"},
+	{"🎂", "Cake"}, {"❤️", "Heart"}, {"😀", "Smiley"}, {"🤖", "Robot"},
+	{"🎩", "Hat"}, {"👓", "Glasses"}, {"🔧", "Spanner"}, {"🎅", "Santa"},
+	{"👍", "Thumbs Up"}, {"☂️", "Umbrella"}, {"⌛", "Hourglass"}, {"⏰", "Clock"},
+	{"🎁", "Gift"}, {"💡", "Light Bulb"}, {"📕", "Book"}, {"✏️", "Pencil"},
+	{"📎", "Paperclip"}, {"✂️", "Scissors"}, {"🔒", "Lock"}, {"🔑", "Key"},
+	{"🔨", "Hammer"}, {"☎️", "Telephone"}, {"🏁", "Flag"}, {"🚂", "Train"},
+	{"🚲", "Bicycle"}, {"✈️", "Aeroplane"}, {"🚀", "Rocket"}, {"🏆", "Trophy"},
+	{"⚽", "Ball"}, {"🎸", "Guitar"}, {"🎺", "Trumpet"}, {"🔔", "Bell"},
+	{"⚓", "Anchor"}, {"🎧", "Headphones"}, {"📁", "Folder"}, {"📌", "Pin"},
+}
+
+// HybridFingerprint 计算混合公钥的指纹.
+func HybridFingerprint(pub *HybridPublicKey) (Fingerprint, error) {
+	s, err := RecipientFingerprint(pub.Kyber, pub.ECDH)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	var fp Fingerprint
+	_, err = hex.Decode(fp[:], []byte(s))
+	return fp, err
+}
+
+// DilithiumFingerprint 计算签名公钥的指纹，即 Bytes 的 SHA256；复合公钥的指纹同时覆盖 Ed25519 分量.
+func DilithiumFingerprint(pub VerifyingKey) Fingerprint {
+	return sha256.Sum256(pub.Bytes())
+}
+
+// IdentityFingerprint 计算由加密公钥和签名公钥组成的身份的指纹.
+func IdentityFingerprint(enc *HybridPublicKey, sign VerifyingKey) (Fingerprint, error) {
+	encFP, err := HybridFingerprint(enc)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	signFP := DilithiumFingerprint(sign)
+	h := sha256.New()
+	h.Write([]byte(identityFingerprintLabel))
+	h.Write([]byte{0})
+	h.Write(encFP[:])
+	h.Write(signFP[:])
+	var fp Fingerprint
+	h.Sum(fp[:0])
+	return fp, nil
+}
+
+// String 返回小写十六进制指纹.
+func (f Fingerprint) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+// HexGroups 返回以 4 个字符分组的大写十六进制指纹.
+func (f Fingerprint) HexGroups() []string {
+	s := strings.ToUpper(f.String())
+	groups := make([]string, 0, len(s)/4)
+	for i := 0; i < len(s); i += 4 {
+		groups = append(groups, s[i:i+4])
+	}
+	return groups
+}
+
+// Words 返回指纹前 132 位对应的 12 个 BIP39 英文单词.
+func (f Fingerprint) Words() []string {
+	list := bip39.GetWordList()
+	words := make([]string, fingerprintWords)
+	for i := range words {
+		words[i] = list[f.bits(i*fingerprintWordBits, fingerprintWordBits)]
+	}
+	return words
+}
+
+// SAS 返回指纹前 42 位对应的 7 个表情符号.
+func (f Fingerprint) SAS() []SASEmoji {
+	out := make([]SASEmoji, sasLength)
+	for i := range out {
+		out[i] = sasEmojis[f.bits(i*sasBits, sasBits)]
+	}
+	return out
+}
+
+// bits 按大端顺序读取从第 offset 位开始的 n 位.
+func (f Fingerprint) bits(offset, n int) int {
+	v := 0
+	for i := offset; i < offset+n; i++ {
+		v = v<<1 | int(f[i/8]>>(7-i%8)&1)
+	}
+	return v
+}
+
+// Matches 判断 s 是否表示同一指纹. s 可以是十六进制（忽略大小写、空白、冒号和连字符）
+// 或 12 个单词（忽略大小写和空白）；不接受前缀或短认证串.
+func (f Fingerprint) Matches(s string) bool {
+	hexStr := strings.Map(func(r rune) rune {
+		if r == ':' || r == '-' || r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(s))
+	if got, err := hex.DecodeString(hexStr); err == nil && len(got) == len(f) {
+		return subtle.ConstantTimeCompare(got, f[:]) == 1
+	}
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ") == strings.Join(f.Words(), " ")
+}
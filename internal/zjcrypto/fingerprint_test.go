@@ -0,0 +1,111 @@
+package zjcrypto
+
+import (
+	"strings"
+	"testing"
+)
+
+// 由 mnemonicVectors[0] 派生的身份的指纹. 改变指纹定义会使用户之前核对过的指纹失效.
+const (
+	vectorIdentityFingerprint = "688350fbe17bac16445659aa859760a1d9d933b83bde0925c666ba627517d74f"
+	vectorHybridFingerprint   = "40eb7bfe2eefa5b41b92e69580ffce28e0fb62636dd3e2d353c94088188e0d9b"
+	vectorWords               = "hammer box discover seed river arctic bacon sleep pride coach subway dry"
+	vectorSAS                 = "Corn Panda Octopus Tree Folder Folder Pig"
+)
+
+func TestFingerprintVectors(t *testing.T) {
+	keys, err := DeriveKeysFromMnemonic(mnemonicVectors[0].mnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := &HybridPublicKey{Kyber: keys.KyberPub, ECDH: keys.ECDHPub}
+
+	hybrid, err := HybridFingerprint(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, _ := RecipientFingerprint(enc.Kyber, enc.ECDH)
+	if hybrid.String() != vectorHybridFingerprint || hybrid.String() != recipient {
+		t.Fatalf("混合公钥指纹 = %s", hybrid)
+	}
+	if got := DilithiumFingerprint(keys.DilithiumPub).String(); got != mnemonicVectors[0].dilithiumPub ||
+		got != SigningFingerprint(keys.DilithiumPub) {
+		t.Fatalf("Dilithium 公钥指纹 = %s", got)
+	}
+
+	identity, err := IdentityFingerprint(enc, keys.DilithiumPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.String() != vectorIdentityFingerprint {
+		t.Fatalf("身份指纹 = %s", identity)
+	}
+	if got := strings.Join(identity.Words(), " "); got != vectorWords {
+		t.Fatalf("单词 = %s", got)
+	}
+	var names []string
+	for _, e := range identity.SAS() {
+		names = append(names, e.Name)
+	}
+	if got := strings.Join(names, " "); got != vectorSAS {
+		t.Fatalf("短认证串 = %s", got)
+	}
+	if got := strings.Join(identity.HexGroups(), ""); got != strings.ToUpper(vectorIdentityFingerprint) {
+		t.Fatalf("十六进制分组 = %s", got)
+	}
+}
+
+func TestFingerprintBits(t *testing.T) {
+	var zero, ones Fingerprint
+	for i := range ones {
+		ones[i] = 0xff
+	}
+	if w := zero.Words(); w[0] != "abandon" || w[11] != "abandon" {
+		t.Fatalf("全零指纹的单词 = %v", w)
+	}
+	if w := ones.Words(); w[0] != "zoo" || w[11] != "zoo" {
+		t.Fatalf("全一指纹的单词 = %v", w)
+	}
+	if s := zero.SAS(); s[0].Name != "Dog" || ones.SAS()[6].Name != "Pin" {
+		t.Fatalf("短认证串 = %v", s)
+	}
+	seen := map[string]bool{}
+	for _, e := range sasEmojis {
+		if e.Emoji == "" || e.Name == "" || seen[e.Name] {
+			t.Fatalf("表情符号表有空项或重复: %v", e)
+		}
+		seen[e.Name] = true
+	}
+}
+
+func TestFingerprintMatches(t *testing.T) {
+	keys, err := DeriveKeysFromMnemonic(mnemonicVectors[1].mnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp, err := IdentityFingerprint(&HybridPublicKey{Kyber: keys.KyberPub, ECDH: keys.ECDHPub}, keys.DilithiumPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		fp.String(),
+		strings.Join(fp.HexGroups(), " "),
+		strings.Join(fp.HexGroups(), ":"),
+		strings.ToUpper(strings.Join(fp.Words(), "  ")),
+	} {
+		if !fp.Matches(s) {
+			t.Errorf("应匹配: %q", s)
+		}
+	}
+	other := DilithiumFingerprint(keys.DilithiumPub)
+	for _, s := range []string{
+		other.String(),
+		fp.String()[:32],
+		strings.Join(fp.Words()[:11], " "),
+		"",
+	} {
+		if fp.Matches(s) {
+			t.Errorf("不应匹配: %q", s)
+		}
+	}
+}
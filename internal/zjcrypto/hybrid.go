@@ -44,12 +44,23 @@ func NewHybridDecryptor(kyberPriv kem.PrivateKey, ecdhPriv *ecdh.PrivateKey) *Hy
 
 // Encapsulate 执行混合密钥封装
 // 返回: Kyber 密文 (1088B), 临时 ECDH 公钥 (32B), 组合共享密钥 (32B)
+// 返回的共享密钥位于普通堆内存中，调用方用完后应清零；包内使用 EncapsulateSecret.
+func (e *HybridEncryptor) Encapsulate() (encapsulated []byte, ecdhPub []byte, sharedSecret []byte, err error) {
+	encapsulated, ecdhPub, secret, err := e.EncapsulateSecret()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer secret.Release()
+	return encapsulated, ecdhPub, append([]byte(nil), secret.Bytes()...), nil
+}
+
+// EncapsulateSecret 执行混合密钥封装，组合共享密钥保存在 SecretBuffer 中，由调用方 Release
 //
 // 加密流程:
 // 1. Kyber768 封装 → 1088B 密文 + 32B Kyber 共享密钥
 // 2. 生成临时 ECDH 密钥对 → 32B 临时公钥 + 32B ECDH 共享密钥
 // 3. SHA256(Kyber密钥 + ECDH密钥) → 32B 最终密钥.
-func (e *HybridEncryptor) Encapsulate() (encapsulated []byte, ecdhPub []byte, sharedSecret []byte, err error) {
+func (e *HybridEncryptor) EncapsulateSecret() (encapsulated []byte, ecdhPub []byte, sharedSecret *SecretBuffer, err error) {
 	// 步骤1: Kyber 封装
 	kyberScheme := kyber768.Scheme()
 	encapsulated, kyberSecret, err := kyberScheme.Encapsulate(e.kyberPub)
@@ -59,6 +70,7 @@ func (e *HybridEncryptor) Encapsulate() (encapsulated []byte, ecdhPub []byte, sh
 			"Kyber encapsulation failed",
 		)
 	}
+	defer clear(kyberSecret)
 
 	// 步骤2: ECDH 临时密钥对生成和交换
 	ecdhKey, err := ecdh.X25519().GenerateKey(rand.Reader)
@@ -75,24 +87,54 @@ func (e *HybridEncryptor) Encapsulate() (encapsulated []byte, ecdhPub []byte, sh
 			"ECDH key exchange failed",
 		)
 	}
+	defer clear(ecdhSecret)
 
 	// 返回临时 ECDH 公钥（需要存储在文件头中）
 	ecdhPubBytes := ecdhKey.PublicKey().Bytes()
 
 	// 步骤3: 组合共享密钥
-	combined := sha256.Sum256(append(kyberSecret, ecdhSecret...))
-	return encapsulated, ecdhPubBytes, combined[:], nil
+	return encapsulated, ecdhPubBytes, combineSecrets(kyberSecret, ecdhSecret), nil
+}
+
+// combineSecrets 计算 SHA256(kyberSecret || ecdhSecret)，中间数据不留在堆上.
+func combineSecrets(kyberSecret, ecdhSecret []byte) *SecretBuffer {
+	h := sha256.New()
+	h.Write(kyberSecret)
+	h.Write(ecdhSecret)
+	combined := NewSecretBuffer(sha256.Size)
+	h.Sum(combined.Bytes()[:0])
+	h.Reset()
+	return combined
 }
 
 // Decapsulate 执行混合密钥解封装
 // 输入: Kyber 密文 (1088B), 临时 ECDH 公钥 (32B)
-// 返回: 组合共享密钥 (32B)
+// 返回: 组合共享密钥 (32B)，位于普通堆内存中，调用方用完后应清零；包内使用 DecapsulateSecret.
+func (d *HybridDecryptor) Decapsulate(encapsulated []byte, ecdhPub []byte) ([]byte, error) {
+	secret, err := d.DecapsulateSecret(encapsulated, ecdhPub)
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Release()
+	return append([]byte(nil), secret.Bytes()...), nil
+}
+
+// DecapsulateSecret 执行混合密钥解封装，组合共享密钥保存在 SecretBuffer 中，由调用方 Release
 //
 // 解密流程:
 // 1. Kyber768 解封装 → 32B Kyber 共享密钥
 // 2. ECDH X25519 密钥交换（使用临时公钥）→ 32B ECDH 共享密钥
 // 3. SHA256(Kyber密钥 + ECDH密钥) → 32B 最终密钥.
-func (d *HybridDecryptor) Decapsulate(encapsulated []byte, ecdhPub []byte) ([]byte, error) {
+func (d *HybridDecryptor) DecapsulateSecret(encapsulated []byte, ecdhPub []byte) (*SecretBuffer, error) {
+	// 远程私钥由持有方完成整个解封装
+	if remote, ok := d.kyberPriv.(*remoteKyberKey); ok {
+		sharedSecret, err := remote.remote.Decapsulate(encapsulated, ecdhPub)
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrAuthFailed, "Remote decapsulation failed: "+err.Error())
+		}
+		return NewSecretBufferFrom(sharedSecret), nil
+	}
+
 	// 步骤1: Kyber 解封装
 	kyberScheme := kyber768.Scheme()
 	kyberSecret, err := kyberScheme.Decapsulate(d.kyberPriv, encapsulated)
@@ -102,6 +144,7 @@ func (d *HybridDecryptor) Decapsulate(encapsulated []byte, ecdhPub []byte) ([]by
 			"Kyber decapsulation failed",
 		)
 	}
+	defer clear(kyberSecret)
 
 	// 步骤2: ECDH 密钥交换
 	// 解析加密器的临时 ECDH 公钥
@@ -119,10 +162,10 @@ func (d *HybridDecryptor) Decapsulate(encapsulated []byte, ecdhPub []byte) ([]by
 			"ECDH key exchange failed",
 		)
 	}
+	defer clear(ecdhSecret)
 
 	// 步骤3: 组合共享密钥
-	combined := sha256.Sum256(append(kyberSecret, ecdhSecret...))
-	return combined[:], nil
+	return combineSecrets(kyberSecret, ecdhSecret), nil
 }
 
 // AESGCMEncrypt 使用 AES-256-GCM 加密数据
@@ -134,6 +177,11 @@ func (d *HybridDecryptor) Decapsulate(encapsulated []byte, ecdhPub []byte) ([]by
 // - 完整性: GCM 认证标签
 // - 防重放: 随机 Nonce.
 func AESGCMEncrypt(key []byte, plaintext []byte) (ciphertext []byte, nonce []byte, err error) {
+	return aesGCMSeal(key, plaintext, nil)
+}
+
+// aesGCMSeal 使用 AES-256-GCM 加密数据，aad 为附加认证数据（可为 nil）.
+func aesGCMSeal(key, plaintext, aad []byte) (ciphertext []byte, nonce []byte, err error) {
 	// 验证密钥长度 (必须是 32B 用于 AES-256)
 	if len(key) != 32 {
 		return nil, nil, utils.NewCryptoError(
@@ -170,7 +218,7 @@ func AESGCMEncrypt(key []byte, plaintext []byte) (ciphertext []byte, nonce []byt
 	}
 
 	// 加密
-	ciphertext = gcm.Seal(nil, nonce, plaintext, nil)
+	ciphertext = gcm.Seal(nil, nonce, plaintext, aad)
 	return ciphertext, nonce, nil
 }
 
@@ -180,6 +228,11 @@ func AESGCMEncrypt(key []byte, plaintext []byte) (ciphertext []byte, nonce []byt
 //
 // 自动验证数据完整性和真实性.
 func AESGCMDecrypt(key []byte, ciphertext []byte, nonce []byte) ([]byte, error) {
+	return aesGCMOpen(key, ciphertext, nonce, nil)
+}
+
+// aesGCMOpen 使用 AES-256-GCM 解密数据并验证 aad.
+func aesGCMOpen(key, ciphertext, nonce, aad []byte) ([]byte, error) {
 	// 验证密钥长度
 	if len(key) != 32 {
 		return nil, utils.NewCryptoError(
@@ -215,7 +268,7 @@ func AESGCMDecrypt(key []byte, ciphertext []byte, nonce []byte) ([]byte, error)
 	}
 
 	// 解密（自动验证认证标签）
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
 	if err != nil {
 		return nil, utils.NewCryptoError(
 			utils.ErrAuthFailed,
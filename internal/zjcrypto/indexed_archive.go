@@ -0,0 +1,707 @@
+package zjcrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem"
+)
+
+// 索引存档的密钥派生标签：每个条目和索引使用独立子密钥，
+// 由混合 KEM 共享密钥经 HKDF-SHA256 派生，盐为头部 IV.
+const (
+	indexKeyLabel = "fzjjyz archive index v1"
+	entryKeyLabel = "fzjjyz archive entry v1"
+)
+
+// indexAAD 索引密文的附加认证数据.
+var indexAAD = []byte("FZJI")
+
+// deriveArchiveKey 派生索引存档子密钥.
+func deriveArchiveKey(sharedSecret, salt []byte, label string) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, sharedSecret, salt, label, 32)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrKeyGenerationFailed, "Key derivation failed: "+err.Error())
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Invalid AES key")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrKeyGenerationFailed, "GCM mode failed")
+	}
+	return gcm, nil
+}
+
+// entryKeyLabelFor 返回第 id 个条目的密钥派生标签.
+func entryKeyLabelFor(id int) string {
+	return fmt.Sprintf("%s/%d", entryKeyLabel, id)
+}
+
+// chunkNonce 返回第 i 个加密块的 Nonce（子密钥唯一，计数器 Nonce 不会重复）.
+func chunkNonce(i uint64) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], i)
+	return nonce
+}
+
+// chunkAAD 返回第 i 个加密块的附加认证数据，最后一块带结束标记防止截断.
+func chunkAAD(i uint64, final bool) []byte {
+	aad := make([]byte, 9)
+	binary.BigEndian.PutUint64(aad, i)
+	if final {
+		aad[8] = 1
+	}
+	return aad
+}
+
+// EncryptDirectoryIndexed 将目录加密为索引存档，返回写入的索引.
+// 每个文件独立分块加密，末尾写入加密并签名的索引，支持按条目随机访问.
+func EncryptDirectoryIndexed(
+	sourceDir, outputPath string,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	opts ArchiveOptions,
+) (_ *format.ArchiveIndex, err error) {
+	// #nosec G304 - outputPath 应由调用方验证
+	out, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, encryptedFilePerm)
+	if err != nil {
+		return nil, fmt.Errorf("create output file: %w", err)
+	}
+	defer func() {
+		if closeErr := out.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close output file: %w", closeErr)
+		}
+		if err != nil {
+			_ = os.Remove(outputPath)
+		}
+	}()
+	return EncryptDirectoryIndexedTo(sourceDir, out, kyberPub, ecdhPub, dilithiumPriv, opts)
+}
+
+// EncryptDirectoryIndexedTo 与 EncryptDirectoryIndexed 相同，但写入 out（如分卷写入器），出错时由调用方清理输出.
+//
+//nolint:funlen,gocognit // 目录遍历、分块加密与头部回填需要完整处理
+func EncryptDirectoryIndexedTo(
+	sourceDir string,
+	out BackfillWriter,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	opts ArchiveOptions,
+) (*format.ArchiveIndex, error) {
+	info, err := os.Stat(sourceDir)
+	if err != nil {
+		return nil, utils.NewCryptoError(
+			utils.ErrIOError,
+			"Source directory not found: "+err.Error(),
+		)
+	}
+	if !info.IsDir() {
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			"Source path is not a directory",
+		)
+	}
+	if isNilSigningKey(dilithiumPriv) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Signing key is required")
+	}
+
+	absSource, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	// 1. 混合密钥封装，IV 字段保存 HKDF 盐
+	encapsulated, ecdhTempPub, secret, err := prepareEncryptionKeys(kyberPub, ecdhPub)
+	if err != nil {
+		return nil, utils.NewCryptoError(
+			utils.ErrKeyGenerationFailed,
+			"Hybrid encapsulation failed: "+err.Error(),
+		)
+	}
+	defer secret.Release()
+	sharedSecret := secret.Bytes()
+	salt := make([]byte, 12)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrKeyGenerationFailed, "Salt generation failed")
+	}
+
+	// 2. 写入占位头部（签名和哈希在写完索引后回填，长度不变）
+	header, err := buildFileHeader(
+		filepath.Base(absSource),
+		0,
+		encapsulated,
+		ecdhTempPub,
+		salt,
+		make([]byte, SignatureSizeFor(dilithiumPriv)),
+		[32]byte{},
+	)
+	if err != nil {
+		return nil, err
+	}
+	header.Flags |= format.FlagIndexedArchive
+	headerBytes, err := serializeHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := out.Write(headerBytes); err != nil {
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+	offset := uint64(len(headerBytes))
+
+	// 3. 遍历目录，逐条目分块加密
+	root, err := os.OpenRoot(absSource)
+	if err != nil {
+		return nil, fmt.Errorf("open source root: %w", err)
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+
+	index := format.ArchiveIndex{ChunkSize: format.DefaultIndexChunkSize}
+	plainBuf := make([]byte, index.ChunkSize)
+	sealBuf := make([]byte, 0, int(index.ChunkSize)+format.IndexChunkOverhead)
+	var totalSize uint64
+
+	walkErr := filepath.Walk(absSource, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return fmt.Errorf("walk error at %s: %w", path, walkErr)
+		}
+		if path == absSource {
+			return nil
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			info, err = handleSymlink(path, absSource, opts.FollowSymlinks)
+			if err != nil {
+				return err
+			}
+			if info == nil {
+				return nil // 跳过符号链接
+			}
+		}
+
+		relPath, err := filepath.Rel(absSource, path)
+		if err != nil {
+			return fmt.Errorf("get relative path: %w", err)
+		}
+
+		entry := format.IndexEntry{
+			Name:    filepath.ToSlash(relPath),
+			Mode:    info.Mode().Perm(),
+			ModTime: info.ModTime().Unix(),
+			Offset:  offset,
+		}
+		if info.IsDir() {
+			entry.Type = format.IndexEntryDir
+			entry.Offset = 0
+			index.Entries = append(index.Entries, entry)
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil // 跳过设备文件、管道等
+		}
+
+		gcm, err := deriveArchiveKey(sharedSecret, salt, entryKeyLabelFor(len(index.Entries)))
+		if err != nil {
+			return err
+		}
+
+		file, err := root.Open(relPath)
+		if err != nil {
+			return fmt.Errorf("open file %s: %w", path, err)
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+
+		size, hash, encSize, err := sealEntry(out, file, gcm, plainBuf, sealBuf)
+		if err != nil {
+			return fmt.Errorf("encrypt %s: %w", entry.Name, err)
+		}
+		entry.Size = size
+		entry.SHA256 = hash
+		offset += encSize
+		totalSize += size
+		index.Entries = append(index.Entries, entry)
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr //nolint:wrapcheck // 回调中已包装
+	}
+
+	// 4. 加密索引并写入尾部
+	indexPlain, err := index.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("marshal index: %w", err)
+	}
+	indexGCM, err := deriveArchiveKey(sharedSecret, salt, indexKeyLabel)
+	if err != nil {
+		return nil, err
+	}
+	indexCipher := indexGCM.Seal(nil, chunkNonce(0), indexPlain, indexAAD)
+	if _, err := out.Write(indexCipher); err != nil {
+		return nil, fmt.Errorf("write index: %w", err)
+	}
+	trailer := format.IndexTrailer{IndexOffset: offset, IndexLen: uint64(len(indexCipher))}
+	trailerBytes, _ := trailer.MarshalBinary()
+	if _, err := out.Write(trailerBytes); err != nil {
+		return nil, fmt.Errorf("write trailer: %w", err)
+	}
+
+	// 5. 对索引哈希签名并回填头部
+	hash := calculateHash(indexPlain)
+	signature, err := signHash(hash[:], dilithiumPriv)
+	if err != nil {
+		return nil, utils.NewCryptoError(
+			utils.ErrSigningFailed,
+			"Hash signing failed: "+err.Error(),
+		)
+	}
+	header.FileSize = totalSize
+	header.Signature = signature
+	header.SHA256Hash = hash
+	finalHeader, err := serializeHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	if len(finalHeader) != len(headerBytes) {
+		return nil, utils.NewCryptoError(utils.ErrSerializationFailed, "Header size changed while finalizing")
+	}
+	if _, err := out.WriteAt(finalHeader, 0); err != nil {
+		return nil, fmt.Errorf("finalize header: %w", err)
+	}
+
+	return &index, nil
+}
+
+// sealEntry 分块加密单个文件，返回明文大小、明文哈希和写入的密文长度.
+func sealEntry(w io.Writer, r io.Reader, gcm cipher.AEAD, plainBuf, sealBuf []byte) (uint64, [32]byte, uint64, error) {
+	hasher := sha256.New()
+	var size, written uint64
+
+	// 预读一块，以便判断当前块是否为最后一块
+	n, err := io.ReadFull(r, plainBuf)
+	pending := append([]byte(nil), plainBuf[:n]...)
+	eof := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	if err != nil && !eof {
+		return 0, [32]byte{}, 0, fmt.Errorf("read: %w", err)
+	}
+
+	for chunk := uint64(0); len(pending) > 0; chunk++ {
+		var next []byte
+		if !eof {
+			n, err = io.ReadFull(r, plainBuf)
+			eof = errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+			if err != nil && !eof {
+				return 0, [32]byte{}, 0, fmt.Errorf("read: %w", err)
+			}
+			next = plainBuf[:n]
+		}
+
+		final := len(next) == 0
+		sealed := gcm.Seal(sealBuf[:0], chunkNonce(chunk), pending, chunkAAD(chunk, final))
+		if _, err := w.Write(sealed); err != nil {
+			return 0, [32]byte{}, 0, fmt.Errorf("write: %w", err)
+		}
+		hasher.Write(pending)
+		size += uint64(len(pending))
+		written += uint64(len(sealed))
+
+		pending = append(pending[:0], next...)
+	}
+
+	var hash [32]byte
+	copy(hash[:], hasher.Sum(nil))
+	return size, hash, written, nil
+}
+
+// IndexedArchive 已打开的索引存档，条目数据按需解密.
+// 可并发读取不同条目.
+type IndexedArchive struct {
+	r            io.ReaderAt
+	closer       io.Closer // OpenIndexedArchive 打开的文件，OpenIndexedArchiveAt 时为 nil
+	header       *format.FileHeader
+	index        format.ArchiveIndex
+	sharedSecret *SecretBuffer
+	byName       map[string]int
+}
+
+// OpenIndexedArchive 打开索引存档：解封装密钥、解密并验证索引.
+// dilithiumPub 为 nil 时跳过签名验证（索引仍受 AEAD 保护）.
+func OpenIndexedArchive(
+	path string,
+	kyberPriv kem.PrivateKey,
+	ecdhPriv *ecdh.PrivateKey,
+	dilithiumPub VerifyingKey,
+) (_ *IndexedArchive, err error) {
+	// #nosec G304 - path 应由调用方验证
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+		}
+	}()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat archive: %w", err)
+	}
+
+	archive, err := OpenIndexedArchiveAt(file, stat.Size(), kyberPriv, ecdhPriv, dilithiumPub)
+	if err != nil {
+		return nil, err
+	}
+	archive.closer = file
+	return archive, nil
+}
+
+// OpenIndexedArchiveAt 打开 r 中长度为 size 的索引存档（如分卷集），r 由调用方关闭.
+//
+//nolint:funlen
+func OpenIndexedArchiveAt(
+	r io.ReaderAt,
+	size int64,
+	kyberPriv kem.PrivateKey,
+	ecdhPriv *ecdh.PrivateKey,
+	dilithiumPub VerifyingKey,
+) (_ *IndexedArchive, err error) {
+	if size < 0 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Archive too short")
+	}
+	header, err := format.ParseFileHeader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("parse file header: %w", err)
+	}
+	if err := header.Validate(); err != nil {
+		return nil, fmt.Errorf("header validation failed: %w", err)
+	}
+	if !header.IsIndexedArchive() {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Not an indexed archive")
+	}
+
+	fileSize := uint64(size) // #nosec G115 - 已检查非负
+	headerSize := uint64(header.GetHeaderSize())
+	if fileSize < headerSize+format.IndexTrailerSize {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Archive too short")
+	}
+
+	// 读取尾部定位索引
+	trailerBytes := make([]byte, format.IndexTrailerSize)
+	// #nosec G115 - 已检查文件长度
+	if _, err := r.ReadAt(trailerBytes, int64(fileSize-format.IndexTrailerSize)); err != nil {
+		return nil, fmt.Errorf("read trailer: %w", err)
+	}
+	var trailer format.IndexTrailer
+	if err := trailer.UnmarshalBinary(trailerBytes); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	indexEnd := fileSize - format.IndexTrailerSize
+	if trailer.IndexOffset < headerSize || trailer.IndexOffset > indexEnd ||
+		trailer.IndexLen != indexEnd-trailer.IndexOffset {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Index location out of range")
+	}
+
+	sharedSecret, err := decapsulateKeys(kyberPriv, ecdhPriv, header.KyberEnc, header.ECDHPub[:])
+	if err != nil {
+		return nil, utils.NewCryptoError(
+			utils.ErrDecryptionFailed,
+			"Key decapsulation failed: "+err.Error(),
+		)
+	}
+	defer func() {
+		if err != nil {
+			sharedSecret.Release()
+		}
+	}()
+
+	// 解密索引并验证哈希和签名
+	indexCipher := make([]byte, trailer.IndexLen)
+	// #nosec G115 - 已检查偏移范围
+	if _, err := r.ReadAt(indexCipher, int64(trailer.IndexOffset)); err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	indexGCM, err := deriveArchiveKey(sharedSecret.Bytes(), header.IV[:], indexKeyLabel)
+	if err != nil {
+		return nil, err
+	}
+	indexPlain, err := indexGCM.Open(nil, chunkNonce(0), indexCipher, indexAAD)
+	if err != nil {
+		return nil, utils.NewCryptoError(
+			utils.ErrAuthFailed,
+			"Index authentication failed - wrong key or corrupted archive",
+		)
+	}
+	if err := verifyDecryptionIntegrity(indexPlain, header, dilithiumPub); err != nil {
+		return nil, err
+	}
+
+	archive := &IndexedArchive{
+		r:            r,
+		header:       header,
+		sharedSecret: sharedSecret,
+	}
+	if err := archive.index.UnmarshalBinary(indexPlain); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if err := archive.index.ValidateLayout(headerSize, trailer.IndexOffset); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	archive.byName = make(map[string]int, len(archive.index.Entries))
+	for i := range archive.index.Entries {
+		archive.byName[archive.index.Entries[i].Name] = i
+	}
+	return archive, nil
+}
+
+// Close 清零共享密钥，并关闭 OpenIndexedArchive 打开的存档文件.
+func (a *IndexedArchive) Close() error {
+	a.sharedSecret.Release()
+	if a.closer == nil {
+		return nil
+	}
+	if err := a.closer.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+	return nil
+}
+
+// Header 返回存档文件头.
+func (a *IndexedArchive) Header() *format.FileHeader {
+	return a.header
+}
+
+// Entries 返回索引中的所有条目（按写入顺序）.
+func (a *IndexedArchive) Entries() []format.IndexEntry {
+	return a.index.Entries
+}
+
+// ArchiveEntries 以通用条目信息形式返回索引内容.
+func (a *IndexedArchive) ArchiveEntries() []ArchiveEntry {
+	entries := make([]ArchiveEntry, 0, len(a.index.Entries))
+	for i := range a.index.Entries {
+		e := &a.index.Entries[i]
+		mode := e.Mode
+		if e.IsDir() {
+			mode |= os.ModeDir
+		}
+		entries = append(entries, ArchiveEntry{
+			Name:           e.Name,
+			Size:           e.Size,
+			CompressedSize: e.EncryptedSize(a.index.ChunkSize),
+			Mode:           mode,
+			Modified:       e.Modified(),
+			IsDir:          e.IsDir(),
+		})
+	}
+	return entries
+}
+
+// Lookup 按名称查找条目.
+func (a *IndexedArchive) Lookup(name string) (*format.IndexEntry, bool) {
+	i, ok := a.byName[strings.Trim(name, "/")]
+	if !ok {
+		return nil, false
+	}
+	return &a.index.Entries[i], true
+}
+
+// WriteEntry 解密指定文件条目并写入 w，仅读取该条目的数据段.
+func (a *IndexedArchive) WriteEntry(w io.Writer, name string) error {
+	i, ok := a.byName[strings.Trim(name, "/")]
+	if !ok {
+		return utils.NewCryptoError(utils.ErrFileNotFound, "Entry not found: "+name)
+	}
+	return a.writeEntry(w, i)
+}
+
+// writeEntry 逐块解密第 i 个条目，最后校验明文哈希.
+func (a *IndexedArchive) writeEntry(w io.Writer, i int) error {
+	entry := &a.index.Entries[i]
+	if entry.IsDir() {
+		return utils.NewCryptoError(utils.ErrInvalidParameter, "Entry is a directory: "+entry.Name)
+	}
+
+	gcm, err := deriveArchiveKey(a.sharedSecret.Bytes(), a.header.IV[:], entryKeyLabelFor(i))
+	if err != nil {
+		return err
+	}
+
+	chunkSize := uint64(a.index.ChunkSize)
+	chunks := entry.ChunkCount(a.index.ChunkSize)
+	cipherBuf := make([]byte, chunkSize+format.IndexChunkOverhead)
+	plainBuf := make([]byte, 0, chunkSize)
+	hasher := sha256.New()
+	offset := entry.Offset
+
+	for chunk := uint64(0); chunk < chunks; chunk++ {
+		plainLen := min(chunkSize, entry.Size-chunk*chunkSize)
+		buf := cipherBuf[:plainLen+format.IndexChunkOverhead]
+		// #nosec G115 - 偏移已通过 ValidateLayout 验证
+		if _, err := a.r.ReadAt(buf, int64(offset)); err != nil {
+			return fmt.Errorf("read %s: %w", entry.Name, err)
+		}
+		plain, err := gcm.Open(plainBuf[:0], chunkNonce(chunk), buf, chunkAAD(chunk, chunk == chunks-1))
+		if err != nil {
+			return utils.NewCryptoError(
+				utils.ErrAuthFailed,
+				fmt.Sprintf("Entry %s is corrupted (chunk %d)", entry.Name, chunk),
+			)
+		}
+		hasher.Write(plain)
+		if _, err := w.Write(plain); err != nil {
+			return fmt.Errorf("write %s: %w", entry.Name, err)
+		}
+		offset += uint64(len(buf))
+	}
+
+	var hash [32]byte
+	copy(hash[:], hasher.Sum(nil))
+	if hash != entry.SHA256 {
+		return utils.NewCryptoError(
+			utils.ErrHashMismatch,
+			"SHA256 hash mismatch for entry "+entry.Name,
+		)
+	}
+	return nil
+}
+
+// ExtractTo 将存档解压到目录，遵循 opts 中的限制和条目选择.
+// 单个条目损坏不影响其他条目：其余条目照常解压，最后汇总返回失败的条目.
+//
+//nolint:funlen,gocognit
+func (a *IndexedArchive) ExtractTo(targetDir string, opts ExtractOptions) error {
+	selected, err := a.selectEntries(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(targetDir, dirPerm); err != nil {
+		return fmt.Errorf("create target directory: %w", err)
+	}
+
+	var failures []error
+	for _, i := range selected {
+		e := &a.index.Entries[i]
+		targetPath, err := validateAndExtractPath(e.Name, targetDir)
+		if err != nil {
+			return utils.NewCryptoError(
+				utils.ErrInvalidParameter,
+				"Invalid extraction path: "+err.Error(),
+			)
+		}
+
+		if e.IsDir() {
+			if err := os.MkdirAll(targetPath, dirPerm); err != nil {
+				return fmt.Errorf("create directory %s: %w", targetPath, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(targetPath), dirPerm); err != nil {
+			return fmt.Errorf("create parent dir for %s: %w", targetPath, err)
+		}
+
+		if err := a.extractEntry(i, targetPath); err != nil {
+			failures = append(failures, err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return utils.NewCryptoError(
+			utils.ErrDecryptionFailed,
+			fmt.Sprintf("%d entries failed: %v", len(failures), errors.Join(failures...)),
+		)
+	}
+	return nil
+}
+
+// selectEntries 返回匹配 opts.Only 的条目下标，并检查条目数、路径深度和大小限制.
+// 索引已认证，条目大小可信，无需读取数据段即可检查.
+func (a *IndexedArchive) selectEntries(opts ExtractOptions) ([]int, error) {
+	if opts.MaxEntries > 0 && len(a.index.Entries) > opts.MaxEntries {
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Too many entries in archive: %d (max: %d)", len(a.index.Entries), opts.MaxEntries),
+		)
+	}
+	if err := ValidateZipPatterns(opts.Only); err != nil {
+		return nil, err
+	}
+
+	selected := make([]int, 0, len(a.index.Entries))
+	var total uint64
+	for i := range a.index.Entries {
+		e := &a.index.Entries[i]
+		if !MatchZipPatterns(opts.Only, e.Name) {
+			continue
+		}
+		if opts.MaxPathDepth > 0 && zipPathDepth(e.Name) > opts.MaxPathDepth {
+			return nil, utils.NewCryptoError(
+				utils.ErrInvalidParameter,
+				fmt.Sprintf("Path too deep in archive: %s (max depth: %d)", e.Name, opts.MaxPathDepth),
+			)
+		}
+		// #nosec G115 - 限制值非负
+		if opts.MaxFileSize > 0 && e.Size > uint64(opts.MaxFileSize) {
+			return nil, utils.NewCryptoError(
+				utils.ErrInvalidParameter,
+				fmt.Sprintf("Extraction limit exceeded for %s: entry larger than %d bytes", e.Name, opts.MaxFileSize),
+			)
+		}
+		total += e.Size
+		selected = append(selected, i)
+	}
+	// #nosec G115 - 限制值非负
+	if opts.MaxTotalSize > 0 && total > uint64(opts.MaxTotalSize) {
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Extraction limit exceeded: total size larger than %d bytes", opts.MaxTotalSize),
+		)
+	}
+
+	return selected, nil
+}
+
+// extractEntry 解压单个条目到目标文件，失败时删除部分文件.
+func (a *IndexedArchive) extractEntry(i int, targetPath string) (err error) {
+	entry := &a.index.Entries[i]
+	// #nosec G304 - targetPath 已通过 validateAndExtractPath 验证
+	out, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, entry.Mode.Perm()|0o200)
+	if err != nil {
+		return fmt.Errorf("create output file %s: %w", targetPath, err)
+	}
+	defer func() {
+		if closeErr := out.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close %s: %w", targetPath, closeErr)
+		}
+		if err != nil {
+			_ = os.Remove(targetPath)
+			return
+		}
+		_ = os.Chmod(targetPath, entry.Mode.Perm())
+		_ = os.Chtimes(targetPath, time.Time{}, entry.Modified())
+	}()
+
+	return a.writeEntry(out, i)
+}
@@ -0,0 +1,268 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"github.com/cloudflare/circl/kem"
+)
+
+// testKeys 测试用的完整密钥集合.
+type testKeys struct {
+	kyberPub      kem.PublicKey
+	kyberPriv     kem.PrivateKey
+	ecdhPub       *ecdh.PublicKey
+	ecdhPriv      *ecdh.PrivateKey
+	dilithiumPub  VerifyingKey
+	dilithiumPriv SigningKey
+}
+
+// generateTestKeys 生成测试密钥.
+func generateTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	var k testKeys
+	var err error
+	if k.kyberPub, k.kyberPriv, err = GenerateKyberKeys(); err != nil {
+		t.Fatalf("生成 Kyber 密钥失败: %v", err)
+	}
+	if k.ecdhPub, k.ecdhPriv, err = GenerateECDHKeys(); err != nil {
+		t.Fatalf("生成 ECDH 密钥失败: %v", err)
+	}
+	if k.dilithiumPub, k.dilithiumPriv, err = GenerateDilithiumKeys(); err != nil {
+		t.Fatalf("生成 Dilithium 密钥失败: %v", err)
+	}
+	return k
+}
+
+// createIndexedTestDir 创建包含空文件、多块文件和子目录的测试目录.
+func createIndexedTestDir(t *testing.T) (string, map[string][]byte) {
+	t.Helper()
+	sourceDir := filepath.Join(t.TempDir(), "project")
+
+	large := make([]byte, int(format.DefaultIndexChunkSize)*2+12345)
+	for i := range large {
+		large[i] = byte(i * 31)
+	}
+	files := map[string][]byte{
+		"README.md":           []byte("# project"),
+		"empty.txt":           {},
+		"docs/intro.txt":      []byte("intro"),
+		"data/blob.bin":       large,
+		"data/exact/one.bin":  bytes.Repeat([]byte{7}, int(format.DefaultIndexChunkSize)),
+		"docs/guide/usage.md": []byte("usage"),
+	}
+	for name, data := range files {
+		path := filepath.Join(sourceDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+	}
+	return sourceDir, files
+}
+
+// TestIndexedArchiveRoundTrip 测试索引存档加密、列出、单条目读取和完整解压.
+func TestIndexedArchiveRoundTrip(t *testing.T) {
+	sourceDir, files := createIndexedTestDir(t)
+	keys := generateTestKeys(t)
+
+	archivePath := filepath.Join(t.TempDir(), "project.fzj")
+	_, err := EncryptDirectoryIndexed(sourceDir, archivePath, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, DefaultArchiveOptions)
+	if err != nil {
+		t.Fatalf("索引存档加密失败: %v", err)
+	}
+
+	archive, err := OpenIndexedArchive(archivePath, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("打开索引存档失败: %v", err)
+	}
+	defer func() {
+		_ = archive.Close()
+	}()
+
+	if !archive.Header().IsIndexedArchive() {
+		t.Error("头部应标记为索引存档")
+	}
+	var total uint64
+	for _, data := range files {
+		total += uint64(len(data))
+	}
+	if archive.Header().FileSize != total {
+		t.Errorf("头部 FileSize 应为明文总大小: %d != %d", archive.Header().FileSize, total)
+	}
+
+	fileCount := 0
+	for _, e := range archive.Entries() {
+		if !e.IsDir() {
+			fileCount++
+		}
+	}
+	if fileCount != len(files) {
+		t.Errorf("期望 %d 个文件条目，实际 %d", len(files), fileCount)
+	}
+
+	// 单条目读取
+	var buf bytes.Buffer
+	if err := archive.WriteEntry(&buf, "data/blob.bin"); err != nil {
+		t.Fatalf("读取单个条目失败: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), files["data/blob.bin"]) {
+		t.Error("单条目内容不一致")
+	}
+	if err := archive.WriteEntry(&buf, "missing.txt"); err == nil {
+		t.Error("不存在的条目应返回错误")
+	}
+
+	// 完整解压
+	targetDir := t.TempDir()
+	if err := archive.ExtractTo(targetDir, DefaultExtractOptions); err != nil {
+		t.Fatalf("解压失败: %v", err)
+	}
+	for name, data := range files {
+		got, err := os.ReadFile(filepath.Join(targetDir, filepath.FromSlash(name))) //nolint:gosec
+		if err != nil {
+			t.Fatalf("读取 %s 失败: %v", name, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s 内容不一致", name)
+		}
+	}
+}
+
+// TestIndexedArchiveCorruptedSegment 测试损坏的数据段只影响对应文件.
+func TestIndexedArchiveCorruptedSegment(t *testing.T) {
+	sourceDir, files := createIndexedTestDir(t)
+	keys := generateTestKeys(t)
+
+	archivePath := filepath.Join(t.TempDir(), "project.fzj")
+	_, err := EncryptDirectoryIndexed(sourceDir, archivePath, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, DefaultArchiveOptions)
+	if err != nil {
+		t.Fatalf("索引存档加密失败: %v", err)
+	}
+
+	archive, err := OpenIndexedArchive(archivePath, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("打开索引存档失败: %v", err)
+	}
+	entry, ok := archive.Lookup("docs/intro.txt")
+	if !ok {
+		t.Fatal("找不到条目 docs/intro.txt")
+	}
+	offset := int64(entry.Offset) //nolint:gosec
+	_ = archive.Close()
+
+	// 翻转该条目数据段中的一个字节
+	data, err := os.ReadFile(archivePath) //nolint:gosec
+	if err != nil {
+		t.Fatalf("读取存档失败: %v", err)
+	}
+	data[offset+2] ^= 0xFF
+	if err := os.WriteFile(archivePath, data, 0600); err != nil {
+		t.Fatalf("写回存档失败: %v", err)
+	}
+
+	archive, err = OpenIndexedArchive(archivePath, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("数据段损坏不应影响打开存档: %v", err)
+	}
+	defer func() {
+		_ = archive.Close()
+	}()
+
+	targetDir := t.TempDir()
+	if err := archive.ExtractTo(targetDir, DefaultExtractOptions); err == nil {
+		t.Fatal("损坏的条目应返回错误")
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "docs", "intro.txt")); !os.IsNotExist(err) {
+		t.Error("损坏的条目不应残留文件")
+	}
+	for name, want := range files {
+		if name == "docs/intro.txt" {
+			continue
+		}
+		got, err := os.ReadFile(filepath.Join(targetDir, filepath.FromSlash(name))) //nolint:gosec
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("未损坏的 %s 应正常解压: %v", name, err)
+		}
+	}
+}
+
+// TestIndexedArchiveTamperedIndex 测试索引被篡改或验签密钥错误时拒绝打开.
+func TestIndexedArchiveTamperedIndex(t *testing.T) {
+	sourceDir, _ := createIndexedTestDir(t)
+	keys := generateTestKeys(t)
+
+	archivePath := filepath.Join(t.TempDir(), "project.fzj")
+	_, err := EncryptDirectoryIndexed(sourceDir, archivePath, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, DefaultArchiveOptions)
+	if err != nil {
+		t.Fatalf("索引存档加密失败: %v", err)
+	}
+
+	otherPub, _, _ := GenerateDilithiumKeys()
+	if _, err := OpenIndexedArchive(archivePath, keys.kyberPriv, keys.ecdhPriv, otherPub); err == nil {
+		t.Error("错误的验签公钥应被拒绝")
+	}
+
+	data, err := os.ReadFile(archivePath) //nolint:gosec
+	if err != nil {
+		t.Fatalf("读取存档失败: %v", err)
+	}
+	// 篡改索引密文（位于尾部之前）
+	data[len(data)-format.IndexTrailerSize-5] ^= 0x01
+	if err := os.WriteFile(archivePath, data, 0600); err != nil {
+		t.Fatalf("写回存档失败: %v", err)
+	}
+	if _, err := OpenIndexedArchive(archivePath, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub); err == nil {
+		t.Error("篡改的索引应被拒绝")
+	}
+
+	// 普通解密接口应明确拒绝索引存档
+	if _, err := DecryptFileCore(archivePath, keys.kyberPriv, keys.ecdhPriv, nil); err == nil {
+		t.Error("DecryptFileCore 应拒绝索引存档")
+	}
+}
+
+// TestIndexedArchiveExtractOptions 测试索引存档的选择性解压和限制.
+func TestIndexedArchiveExtractOptions(t *testing.T) {
+	sourceDir, _ := createIndexedTestDir(t)
+	keys := generateTestKeys(t)
+
+	archivePath := filepath.Join(t.TempDir(), "project.fzj")
+	_, err := EncryptDirectoryIndexed(sourceDir, archivePath, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, DefaultArchiveOptions)
+	if err != nil {
+		t.Fatalf("索引存档加密失败: %v", err)
+	}
+	archive, err := OpenIndexedArchive(archivePath, keys.kyberPriv, keys.ecdhPriv, nil)
+	if err != nil {
+		t.Fatalf("打开索引存档失败: %v", err)
+	}
+	defer func() {
+		_ = archive.Close()
+	}()
+
+	targetDir := t.TempDir()
+	opts := DefaultExtractOptions
+	opts.Only = []string{"docs/**"}
+	if err := archive.ExtractTo(targetDir, opts); err != nil {
+		t.Fatalf("选择性解压失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "docs", "guide", "usage.md")); err != nil {
+		t.Errorf("匹配的条目应被解压: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "data")); !os.IsNotExist(err) {
+		t.Error("未匹配的条目不应被解压")
+	}
+
+	if err := archive.ExtractTo(t.TempDir(), ExtractOptions{MaxFileSize: 1024}); err == nil {
+		t.Error("超过单文件限制应返回错误")
+	}
+	if err := archive.ExtractTo(t.TempDir(), ExtractOptions{MaxTotalSize: 1024}); err == nil {
+		t.Error("超过总大小限制应返回错误")
+	}
+}
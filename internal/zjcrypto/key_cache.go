@@ -0,0 +1,526 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+// racyWindow 文件修改时间与加载时间相差小于该值时，仅凭 stat 信息无法判断文件是否被再次修改
+// （同一时间粒度内的改写不会改变 mtime），此时以内容哈希为准.
+const racyWindow = 2 * time.Second
+
+// 缓存条目类型.
+const (
+	cacheKindPublic          = "pub:"
+	cacheKindPrivate         = "priv:"
+	cacheKindDilithiumPublic = "dilithium_pub:"
+	cacheKindDilithiumPriv   = "dilithium_priv:"
+)
+
+// KeyCacheConfig 密钥缓存配置，零值字段使用默认值.
+type KeyCacheConfig struct {
+	// TTL 条目自加载起的有效期，默认 DefaultCacheTTL.
+	TTL time.Duration
+	// Capacity 最多缓存的密钥数量，超出时淘汰最久未使用的条目，默认 MaxCacheSize.
+	Capacity int
+	// CleanupInterval 后台清理过期条目的间隔，默认 CacheCleanupInterval；为负时不启动后台清理.
+	CleanupInterval time.Duration
+}
+
+// KeyCache 按文件路径缓存已解析的密钥，可并发使用.
+//
+// 文件的 inode、大小、修改时间或内容哈希变化时条目失效并重新加载.
+// 私钥以缓存独占的原始字节保存，每次加载返回新解析的密钥对象；
+// 条目被淘汰、过期、清空或 Close 时这些字节会被清零，调用方持有的密钥不受影响.
+type KeyCache struct {
+	ttl      time.Duration
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // 前端为最近使用
+	closed  bool
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	now func() time.Time
+}
+
+// keyCacheEntry 缓存条目.
+type keyCacheEntry struct {
+	cacheKey string
+	// public 已解析的公钥，private 为私钥原始字节（缓存独占）
+	public   any
+	private  *SecretBuffer
+	loadedAt time.Time
+
+	// 加载时的文件状态和内容哈希；verifiedAt 为最近一次确认文件未变化的时间
+	info       os.FileInfo
+	digest     [sha256.Size]byte
+	verifiedAt time.Time
+}
+
+// NewKeyCache 创建密钥缓存；CleanupInterval 非负时启动后台清理，使用完毕后应调用 Close.
+func NewKeyCache(cfg KeyCacheConfig) *KeyCache {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultCacheTTL
+	}
+	if cfg.Capacity <= 0 {
+		cfg.Capacity = MaxCacheSize
+	}
+	if cfg.CleanupInterval == 0 {
+		cfg.CleanupInterval = CacheCleanupInterval
+	}
+
+	c := &KeyCache{
+		ttl:      cfg.TTL,
+		capacity: cfg.Capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		now:      time.Now,
+	}
+	if cfg.CleanupInterval > 0 {
+		c.stop = make(chan struct{})
+		c.done = make(chan struct{})
+		go c.janitor(cfg.CleanupInterval)
+	}
+	return c
+}
+
+// janitor 定期清理过期条目，直到 Close 被调用.
+func (c *KeyCache) janitor(interval time.Duration) {
+	defer close(c.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.RemoveExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Close 停止后台清理并清空缓存；之后的加载直接读取文件，不再缓存.
+func (c *KeyCache) Close() error {
+	c.closeOnce.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+			<-c.done
+		}
+		c.mu.Lock()
+		c.closed = true
+		c.clearLocked()
+		c.mu.Unlock()
+	})
+	return nil
+}
+
+// Clear 清空缓存.
+func (c *KeyCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clearLocked()
+}
+
+func (c *KeyCache) clearLocked() {
+	for _, elem := range c.entries {
+		wipeEntry(elem.Value.(*keyCacheEntry)) //nolint:forcetypeassert // lru 只存储 *keyCacheEntry
+	}
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// Invalidate 移除 path 对应的所有条目.
+func (c *KeyCache) Invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, kind := range []string{cacheKindPublic, cacheKindPrivate, cacheKindDilithiumPublic, cacheKindDilithiumPriv} {
+		if elem, ok := c.entries[kind+path]; ok {
+			c.removeLocked(elem)
+		}
+	}
+}
+
+// RemoveExpired 移除所有过期条目.
+func (c *KeyCache) RemoveExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if c.expired(elem.Value.(*keyCacheEntry), now) { //nolint:forcetypeassert // lru 只存储 *keyCacheEntry
+			c.removeLocked(elem)
+		}
+		elem = next
+	}
+}
+
+// Len 返回当前缓存的密钥数量.
+func (c *KeyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Info 返回缓存详细信息：总条目数、已过期条目数、总大小（字节估算）.
+func (c *KeyCache) Info() (total int, expired int, estimatedSize int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*keyCacheEntry) //nolint:forcetypeassert // lru 只存储 *keyCacheEntry
+		total++
+		if c.expired(entry, now) {
+			expired++
+		}
+		// 估算大小：每个条目约 100 字节 + 私钥字节
+		estimatedSize += 100 + entry.private.Len()
+	}
+	return total, expired, estimatedSize
+}
+
+func (c *KeyCache) expired(entry *keyCacheEntry, now time.Time) bool {
+	return now.Sub(entry.loadedAt) >= c.ttl
+}
+
+func (c *KeyCache) removeLocked(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*keyCacheEntry) //nolint:forcetypeassert // lru 只存储 *keyCacheEntry
+	delete(c.entries, entry.cacheKey)
+	wipeEntry(entry)
+}
+
+// wipeEntry 清零条目持有的私钥字节.
+func wipeEntry(entry *keyCacheEntry) {
+	entry.private.Release()
+	entry.private = nil
+	entry.public = nil
+}
+
+// cachedValue 命中时返回的条目内容；private 为副本，由调用方负责 Release.
+type cachedValue struct {
+	public  any
+	private *SecretBuffer
+}
+
+// lookup 在 stat 信息与缓存一致时返回条目内容.
+// 修改时间落在 racyWindow 内的条目需要校验内容哈希，此时返回 needHash.
+func (c *KeyCache) lookup(cacheKey string, info os.FileInfo) (value cachedValue, hit, needHash bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[cacheKey]
+	if !ok {
+		return cachedValue{}, false, false
+	}
+	entry := elem.Value.(*keyCacheEntry) //nolint:forcetypeassert // lru 只存储 *keyCacheEntry
+	if c.expired(entry, c.now()) || !os.SameFile(entry.info, info) ||
+		entry.info.Size() != info.Size() || !entry.info.ModTime().Equal(info.ModTime()) {
+		c.removeLocked(elem)
+		return cachedValue{}, false, false
+	}
+	if !info.ModTime().Before(entry.verifiedAt.Add(-racyWindow)) {
+		return cachedValue{}, false, true
+	}
+	c.lru.MoveToFront(elem)
+	return entry.snapshot(), true, false
+}
+
+// lookupDigest 内容哈希与缓存一致时返回条目内容.
+func (c *KeyCache) lookupDigest(cacheKey string, digest [sha256.Size]byte) (cachedValue, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[cacheKey]
+	if !ok {
+		return cachedValue{}, false
+	}
+	entry := elem.Value.(*keyCacheEntry) //nolint:forcetypeassert // lru 只存储 *keyCacheEntry
+	if entry.digest != digest {
+		c.removeLocked(elem)
+		return cachedValue{}, false
+	}
+	entry.verifiedAt = c.now()
+	c.lru.MoveToFront(elem)
+	return entry.snapshot(), true
+}
+
+func (e *keyCacheEntry) snapshot() cachedValue {
+	value := cachedValue{public: e.public}
+	if e.private != nil {
+		value.private = NewSecretBuffer(e.private.Len())
+		copy(value.private.Bytes(), e.private.Bytes())
+	}
+	return value
+}
+
+// store 插入条目并按 LRU 淘汰超出容量的条目；缓存已关闭时清零 private.
+func (c *KeyCache) store(entry *keyCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		wipeEntry(entry)
+		return
+	}
+	if elem, ok := c.entries[entry.cacheKey]; ok {
+		c.removeLocked(elem)
+	}
+	c.entries[entry.cacheKey] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.capacity {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+// load 加载 path 处的密钥.
+// parse 解析文件内容，返回公钥对象或私钥原始字节；build 由缓存内容构造返回给调用方的密钥.
+func (c *KeyCache) load(
+	kind, path, readErr string,
+	parse func(data []byte) (cachedValue, error),
+	build func(value cachedValue) (any, error),
+) (any, error) {
+	cacheKey := kind + path
+	info, statErr := os.Stat(path)
+	if statErr == nil {
+		value, hit, needHash := c.lookup(cacheKey, info)
+		if hit {
+			return buildAndWipe(value, build)
+		}
+		if needHash {
+			data, err := readSecretFile(path)
+			if err == nil {
+				value, ok := c.lookupDigest(cacheKey, sha256.Sum256(data.Bytes()))
+				data.Release()
+				if ok {
+					return buildAndWipe(value, build)
+				}
+			}
+		}
+	} else {
+		c.Invalidate(path)
+	}
+
+	data, err := readSecretFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", readErr, err)
+	}
+	defer data.Release()
+	value, err := parse(data.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	key, err := build(value)
+	if err != nil {
+		value.private.Release()
+		return nil, err
+	}
+	if statErr == nil {
+		now := c.now()
+		c.store(&keyCacheEntry{
+			cacheKey:   cacheKey,
+			public:     value.public,
+			private:    value.private,
+			loadedAt:   now,
+			info:       info,
+			digest:     sha256.Sum256(data.Bytes()),
+			verifiedAt: now,
+		})
+	} else {
+		value.private.Release()
+	}
+	return key, nil
+}
+
+func buildAndWipe(value cachedValue, build func(cachedValue) (any, error)) (any, error) {
+	defer value.private.Release()
+	return build(value)
+}
+
+func buildPublic(value cachedValue) (any, error) {
+	return value.public, nil
+}
+
+// LoadPublicKey 带缓存的混合公钥加载.
+func (c *KeyCache) LoadPublicKey(path string) (*HybridPublicKey, error) {
+	key, err := c.load(cacheKindPublic, path, "read public key file",
+		func(data []byte) (cachedValue, error) {
+			kyberPub, ecdhPub, err := parsePublicKeys(data)
+			if err != nil {
+				return cachedValue{}, err
+			}
+			return cachedValue{public: &HybridPublicKey{Kyber: kyberPub, ECDH: ecdhPub}}, nil
+		}, buildPublic)
+	if err != nil {
+		return nil, err
+	}
+	return key.(*HybridPublicKey), nil //nolint:forcetypeassert // 由上面的 parse 构造
+}
+
+// LoadPrivateKey 带缓存的混合私钥加载，每次返回新的私钥对象.
+func (c *KeyCache) LoadPrivateKey(path string) (*HybridPrivateKey, error) {
+	key, err := c.load(cacheKindPrivate, path, "read private key file",
+		func(data []byte) (cachedValue, error) {
+			priv, err := parseHybridPrivateKey(data)
+			if err != nil {
+				return cachedValue{}, err
+			}
+			kyberBytes, err := priv.Kyber.MarshalBinary()
+			if err != nil {
+				return cachedValue{}, utils.NewCryptoError(utils.ErrInvalidKey, "Failed to marshal Kyber private key: "+err.Error())
+			}
+			ecdhBytes := priv.ECDH.Bytes()
+			private := NewSecretBuffer(len(kyberBytes) + len(ecdhBytes) + len(priv.KyberSeed))
+			copy(private.Bytes(), kyberBytes)
+			copy(private.Bytes()[len(kyberBytes):], ecdhBytes)
+			copy(private.Bytes()[len(kyberBytes)+len(ecdhBytes):], priv.KyberSeed)
+			clear(kyberBytes)
+			clear(ecdhBytes)
+			clear(priv.KyberSeed)
+			return cachedValue{private: private}, nil
+		},
+		func(value cachedValue) (any, error) {
+			// 缓存布局：Kyber 私钥 || X25519 私钥 || 可选的 Kyber 种子
+			private := value.private.Bytes()
+			const ecdhEnd = kyber768.PrivateKeySize + 32
+			if len(private) != ecdhEnd && len(private) != ecdhEnd+kyber768.KeySeedSize {
+				return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Incomplete private key data")
+			}
+			kyberPriv, err := kyber768.Scheme().UnmarshalBinaryPrivateKey(private[:kyber768.PrivateKeySize])
+			if err != nil {
+				return nil, utils.NewCryptoError(utils.ErrInvalidKey, fmt.Sprintf("Failed to parse Kyber private key: %v", err))
+			}
+			ecdhPriv, err := ecdh.X25519().NewPrivateKey(private[kyber768.PrivateKeySize:ecdhEnd])
+			if err != nil {
+				return nil, utils.NewCryptoError(utils.ErrInvalidKey, fmt.Sprintf("Failed to parse ECDH private key: %v", err))
+			}
+			var seed []byte
+			if len(private) > ecdhEnd {
+				seed = bytes.Clone(private[ecdhEnd:])
+			}
+			return &HybridPrivateKey{Kyber: kyberPriv, ECDH: ecdhPriv, KyberSeed: seed}, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return key.(*HybridPrivateKey), nil //nolint:forcetypeassert // 由上面的 build 构造
+}
+
+// LoadDilithiumPublicKey 带缓存的 Dilithium 公钥加载.
+func (c *KeyCache) LoadDilithiumPublicKey(path string) (VerifyingKey, error) {
+	key, err := c.load(cacheKindDilithiumPublic, path, "read Dilithium public key file",
+		func(data []byte) (cachedValue, error) {
+			pub, err := parseDilithiumPublicKey(data)
+			if err != nil {
+				return cachedValue{}, err
+			}
+			return cachedValue{public: pub}, nil
+		}, buildPublic)
+	if err != nil {
+		return nil, err
+	}
+	return key.(VerifyingKey), nil //nolint:forcetypeassert // 由上面的 parse 构造
+}
+
+// LoadDilithiumPrivateKey 带缓存的 Dilithium 私钥加载，每次返回新的私钥对象.
+// 保留种子的私钥只缓存种子（复合私钥再附加 Ed25519 种子），否则复合私钥以
+// CompositePrivateKey.Bytes 的编码缓存；各编码由长度区分.
+func (c *KeyCache) LoadDilithiumPrivateKey(path string) (SigningKey, error) {
+	key, err := c.load(cacheKindDilithiumPriv, path, "read Dilithium private key file",
+		func(data []byte) (cachedValue, error) {
+			priv, err := parseDilithiumPrivateKey(data)
+			if err != nil {
+				return cachedValue{}, err
+			}
+			switch k := priv.(type) {
+			case *mode3.PrivateKey:
+				encoded := k.Seed()
+				if encoded == nil {
+					encoded = k.Bytes()
+				}
+				private := NewSecretBufferFrom(encoded)
+				*k = mode3.PrivateKey{}
+				return cachedValue{private: private}, nil
+			case *CompositePrivateKey:
+				var private *SecretBuffer
+				if seed := k.Dilithium.Seed(); seed != nil {
+					edSeed := k.Ed25519.Seed()
+					private = NewSecretBuffer(len(seed) + len(edSeed))
+					copy(private.Bytes(), seed)
+					copy(private.Bytes()[len(seed):], edSeed)
+					clear(seed)
+					clear(edSeed)
+				} else {
+					private = NewSecretBufferFrom(k.Bytes())
+				}
+				k.Wipe()
+				return cachedValue{private: private}, nil
+			default:
+				return cachedValue{}, utils.NewCryptoError(utils.ErrInvalidKey, "Unsupported Dilithium private key type")
+			}
+		},
+		func(value cachedValue) (any, error) {
+			switch private := value.private.Bytes(); len(private) {
+			case mode3.SeedSize:
+				return dilithiumFromSeed(private)
+			case mode3.SeedSize + ed25519.SeedSize:
+				dilithium, err := dilithiumFromSeed(private[:mode3.SeedSize])
+				if err != nil {
+					return nil, err
+				}
+				return NewCompositeSigningKey(dilithium, ed25519.NewKeyFromSeed(private[mode3.SeedSize:]))
+			case CompositePrivateKeySize:
+				priv := new(CompositePrivateKey)
+				if err := priv.UnmarshalBinary(private); err != nil {
+					return nil, err
+				}
+				return priv, nil
+			default:
+				priv := new(mode3.PrivateKey)
+				if err := priv.UnmarshalBinary(private); err != nil {
+					return nil, utils.NewCryptoError(utils.ErrInvalidKey, fmt.Sprintf("Failed to parse Dilithium3 private key: %v", err))
+				}
+				return priv, nil
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	return key.(SigningKey), nil //nolint:forcetypeassert // 由上面的 build 构造
+}
+
+// defaultKeyCache 包级 Load*Cached 函数使用的缓存，首次使用时创建.
+var (
+	defaultKeyCacheMu sync.Mutex
+	defaultKeyCache   *KeyCache
+)
+
+// DefaultKeyCache 返回包级 Load*Cached 函数使用的缓存.
+func DefaultKeyCache() *KeyCache {
+	defaultKeyCacheMu.Lock()
+	defer defaultKeyCacheMu.Unlock()
+	if defaultKeyCache == nil {
+		defaultKeyCache = NewKeyCache(KeyCacheConfig{})
+	}
+	return defaultKeyCache
+}
+
+// SetDefaultKeyCache 替换包级 Load*Cached 函数使用的缓存并返回原缓存（可能为 nil），
+// 原缓存由调用方负责 Close.
+func SetDefaultKeyCache(c *KeyCache) *KeyCache {
+	defaultKeyCacheMu.Lock()
+	defer defaultKeyCacheMu.Unlock()
+	old := defaultKeyCache
+	defaultKeyCache = c
+	return old
+}
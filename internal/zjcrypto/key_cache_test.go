@@ -0,0 +1,279 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeCacheTestKeys 在 dir 中以 name 为前缀保存一组新的混合密钥与 Dilithium 密钥.
+func writeCacheTestKeys(t *testing.T, dir, name string) (pubPath, privPath, dilPubPath, dilPrivPath string) {
+	t.Helper()
+	kyberPub, kyberPriv, ecdhPub, ecdhPriv, err := GenerateHybridKeysParallel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dilithiumPub, dilithiumPriv, err := GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Join(dir, name)
+	pubPath, privPath = base+"_public.pem", base+"_private.pem"
+	dilPubPath, dilPrivPath = base+"_dilithium_public.pem", base+"_dilithium_private.pem"
+	if err := SaveKeyFiles(kyberPub, ecdhPub, kyberPriv, ecdhPriv, pubPath, privPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveDilithiumKeys(dilithiumPub, dilithiumPriv, dilPubPath, dilPrivPath); err != nil {
+		t.Fatal(err)
+	}
+	return pubPath, privPath, dilPubPath, dilPrivPath
+}
+
+// ageFile 把文件修改时间调到一小时前，使缓存只凭 stat 信息判断命中.
+func ageFile(t *testing.T, paths ...string) {
+	t.Helper()
+	old := time.Now().Add(-time.Hour)
+	for _, path := range paths {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// fakeClock 可手动推进的时钟.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func newTestKeyCache(t *testing.T, cfg KeyCacheConfig) (*KeyCache, *fakeClock) {
+	t.Helper()
+	if cfg.CleanupInterval == 0 {
+		cfg.CleanupInterval = -1
+	}
+	c := NewKeyCache(cfg)
+	clock := &fakeClock{now: time.Now()}
+	c.now = clock.Now
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+	return c, clock
+}
+
+// TestCacheTTL 测试缓存TTL功能.
+func TestCacheTTL(t *testing.T) {
+	dir := t.TempDir()
+	pubPath, _, _, _ := writeCacheTestKeys(t, dir, "ttl")
+	ageFile(t, pubPath)
+	c, clock := newTestKeyCache(t, KeyCacheConfig{TTL: time.Minute})
+
+	first, err := c.LoadPublicKey(pubPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := c.LoadPublicKey(pubPath); again != first {
+		t.Error("TTL 内应返回缓存的公钥")
+	}
+
+	clock.Advance(2 * time.Minute)
+	if total, expired, _ := c.Info(); total != 1 || expired != 1 {
+		t.Errorf("Info = %d, %d，期望 1 个已过期条目", total, expired)
+	}
+	if reloaded, _ := c.LoadPublicKey(pubPath); reloaded == first {
+		t.Error("过期后应重新加载")
+	}
+	if _, expired, _ := c.Info(); expired != 0 {
+		t.Error("重新加载后不应有过期条目")
+	}
+}
+
+// TestCacheSizeLimit 测试缓存大小限制（LRU 淘汰）.
+func TestCacheSizeLimit(t *testing.T) {
+	dir := t.TempDir()
+	a, _, _, _ := writeCacheTestKeys(t, dir, "a")
+	b, _, _, _ := writeCacheTestKeys(t, dir, "b")
+	d, _, _, _ := writeCacheTestKeys(t, dir, "d")
+	ageFile(t, a, b, d)
+	c, _ := newTestKeyCache(t, KeyCacheConfig{Capacity: 2})
+
+	keyA, _ := c.LoadPublicKey(a)
+	keyB, _ := c.LoadPublicKey(b)
+	// 访问 a 后 b 成为最久未使用的条目
+	if got, _ := c.LoadPublicKey(a); got != keyA {
+		t.Fatal("a 应命中缓存")
+	}
+	if _, err := c.LoadPublicKey(d); err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len = %d，期望 2", c.Len())
+	}
+	if got, _ := c.LoadPublicKey(a); got != keyA {
+		t.Error("最近使用的 a 不应被淘汰")
+	}
+	if got, _ := c.LoadPublicKey(b); got == keyB {
+		t.Error("最久未使用的 b 应被淘汰")
+	}
+}
+
+// TestCacheExpiration 测试后台清理与 Close.
+func TestCacheExpiration(t *testing.T) {
+	dir := t.TempDir()
+	pubPath, _, _, _ := writeCacheTestKeys(t, dir, "exp")
+	c := NewKeyCache(KeyCacheConfig{TTL: time.Millisecond, CleanupInterval: 5 * time.Millisecond})
+
+	if _, err := c.LoadPublicKey(pubPath); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for c.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("后台清理未移除过期条目")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 关闭后仍可加载，但不再缓存
+	if _, err := c.LoadPublicKey(pubPath); err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 0 {
+		t.Error("关闭后不应缓存")
+	}
+	if err := c.Close(); err != nil {
+		t.Error("重复 Close 应无副作用")
+	}
+}
+
+// TestKeyCacheInvalidation 测试文件变化后缓存失效.
+func TestKeyCacheInvalidation(t *testing.T) {
+	dir := t.TempDir()
+	pubPath, _, _, _ := writeCacheTestKeys(t, dir, "key")
+	otherPub, _, _, _ := writeCacheTestKeys(t, dir, "other")
+	ageFile(t, pubPath)
+	c, _ := newTestKeyCache(t, KeyCacheConfig{})
+
+	original, err := c.LoadPublicKey(pubPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("替换文件", func(t *testing.T) {
+		data, _ := os.ReadFile(otherPub) // #nosec G304 - 测试环境使用临时文件路径
+		tmp := pubPath + ".tmp"
+		if err := os.WriteFile(tmp, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, pubPath); err != nil {
+			t.Fatal(err)
+		}
+		want, _ := LoadPublicKey(otherPub)
+		got, err := c.LoadPublicKey(pubPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == original || !got.ECDH.Equal(want.ECDH) {
+			t.Error("替换文件后应加载新密钥")
+		}
+	})
+
+	t.Run("同一时间粒度内原地改写", func(t *testing.T) {
+		// 改写后恢复相同的修改时间，只能由内容哈希发现变化
+		third, _, _, _ := writeCacheTestKeys(t, t.TempDir(), "third")
+		before, err := c.LoadPublicKey(pubPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, _ := os.Stat(pubPath)
+		data, _ := os.ReadFile(third) // #nosec G304 - 测试环境使用临时文件路径
+		if err := os.WriteFile(pubPath, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(pubPath, info.ModTime(), info.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+		want, _ := LoadPublicKey(third)
+		got, err := c.LoadPublicKey(pubPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == before || !got.ECDH.Equal(want.ECDH) {
+			t.Error("内容变化后应加载新密钥")
+		}
+	})
+
+	t.Run("删除文件", func(t *testing.T) {
+		if err := os.Remove(pubPath); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.LoadPublicKey(pubPath); err == nil {
+			t.Error("文件删除后应返回错误")
+		}
+		if c.Len() != 0 {
+			t.Error("文件删除后条目应被移除")
+		}
+	})
+}
+
+// TestKeyCacheZeroization 测试私钥字节在淘汰时被清零，且不影响调用方持有的密钥.
+func TestKeyCacheZeroization(t *testing.T) {
+	dir := t.TempDir()
+	pubPath, privPath, _, dilPrivPath := writeCacheTestKeys(t, dir, "secret")
+	c, _ := newTestKeyCache(t, KeyCacheConfig{})
+
+	priv, err := c.LoadPrivateKey(privPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := c.LoadPrivateKey(privPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv == again {
+		t.Error("私钥每次加载应返回新对象")
+	}
+	signKey, err := c.LoadDilithiumPrivateKey(dilPrivPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, size := c.Info(); size <= 200 {
+		t.Fatalf("期望缓存 2 个私钥条目，估算大小 %d", size)
+	}
+
+	rec := recordReleases(t)
+	c.Clear()
+	if total, dirty, _ := rec.snapshot(); total != 2 || dirty != 0 {
+		t.Errorf("淘汰时释放 %d 个私钥缓冲区，其中 %d 个未清零", total, dirty)
+	}
+
+	// 调用方持有的密钥仍然可用
+	pub, _ := LoadPublicKey(pubPath)
+	plain := []byte("still usable")
+	data, err := EncryptData(plain, "z.txt", pub.Kyber, pub.ECDH, signKey, NoCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecryptDataCore(data, priv.Kyber, priv.ECDH, nil)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("清空缓存后密钥不可用: %v", err)
+	}
+}
@@ -0,0 +1,357 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// 私钥 URI 的 scheme.
+const (
+	schemeFile   = "file"
+	schemeEnv    = "env"
+	schemeBase64 = "base64"
+	schemeFD     = "fd"
+	schemeExec   = "exec"
+	schemePKCS11 = "pkcs11"
+)
+
+// KeyKind 请求的私钥类型，供需要区分的提供者（如外部命令）使用.
+type KeyKind int
+
+const (
+	// KeyKindHybrid Kyber768+ECDH 混合私钥.
+	KeyKindHybrid KeyKind = iota
+	// KeyKindDilithium Dilithium3 签名私钥.
+	KeyKindDilithium
+)
+
+func (k KeyKind) String() string {
+	if k == KeyKindDilithium {
+		return "dilithium"
+	}
+	return "hybrid"
+}
+
+// KeyProvider 私钥来源.
+//
+// FetchPrivateKey 返回 PEM 编码的私钥，调用方用完后必须 Release；
+// String 返回不含密钥内容的描述，用于错误信息.
+type KeyProvider interface {
+	FetchPrivateKey(kind KeyKind) (*SecretBuffer, error)
+	String() string
+}
+
+// ParseKeyURI 按 URI 选择私钥来源:
+//
+//	file:PATH 或不带 scheme 的路径    从文件读取
+//	env:NAME                         环境变量，内容为 PEM 或其 base64 编码
+//	base64:DATA                      内联的 base64 编码 PEM（会出现在进程列表中，仅供测试）
+//	fd:N                             从已打开的文件描述符读到 EOF
+//	exec:COMMAND [ARGS...]           外部命令，协议与 git credential helper 相同
+//	pkcs11:token=T;object=O?...      PKCS#11 令牌中的数据对象（RFC 7512）
+//
+// 未知的 scheme 视为文件路径，因此 Windows 盘符路径不受影响.
+func ParseKeyURI(uri string) (KeyProvider, error) {
+	scheme, rest, _ := strings.Cut(uri, ":")
+	switch scheme {
+	case schemeFile:
+		return &FileKeyProvider{Path: rest}, nil
+	case schemeEnv:
+		if rest == "" {
+			return nil, invalidKeyURI(uri, "missing variable name")
+		}
+		return &EnvKeyProvider{Name: rest}, nil
+	case schemeBase64:
+		return &Base64KeyProvider{Data: rest}, nil
+	case schemeFD:
+		fd, err := strconv.Atoi(rest)
+		if err != nil || fd < 0 {
+			return nil, invalidKeyURI(uri, "invalid file descriptor")
+		}
+		return &FDKeyProvider{FD: fd}, nil
+	case schemeExec:
+		args := strings.Fields(rest)
+		if len(args) == 0 {
+			return nil, invalidKeyURI(uri, "missing command")
+		}
+		return &ExecKeyProvider{Command: args}, nil
+	case schemePKCS11:
+		return parsePKCS11URI(uri, rest)
+	default:
+		return &FileKeyProvider{Path: uri}, nil
+	}
+}
+
+func invalidKeyURI(uri, reason string) error {
+	return utils.NewCryptoError(utils.ErrInvalidParameter,
+		fmt.Sprintf("Invalid key URI %q: %s", uri, reason))
+}
+
+// LoadPrivateKeyFrom 从提供者加载混合私钥.
+func LoadPrivateKeyFrom(p KeyProvider) (*HybridPrivateKey, error) {
+	privPEM, err := p.FetchPrivateKey(KeyKindHybrid)
+	if err != nil {
+		return nil, fmt.Errorf("read private key from %s: %w", p, err)
+	}
+	defer privPEM.Release()
+
+	return parseHybridPrivateKey(privPEM.Bytes())
+}
+
+// LoadDilithiumPrivateKeyFrom 从提供者加载 Dilithium 私钥.
+func LoadDilithiumPrivateKeyFrom(p KeyProvider) (SigningKey, error) {
+	privPEM, err := p.FetchPrivateKey(KeyKindDilithium)
+	if err != nil {
+		return nil, fmt.Errorf("read Dilithium private key from %s: %w", p, err)
+	}
+	defer privPEM.Release()
+	return parseDilithiumPrivateKey(privPEM.Bytes())
+}
+
+// LoadAnyPrivateKeyFrom 从提供者加载混合私钥或 Dilithium 私钥，先按混合私钥解析，只返回其中一个.
+// 提供者只读取一次（外部命令收到 type=hybrid），fd: 等一次性来源同样可用；
+// 外部命令返回的不是私钥时再以 type=dilithium 请求一次.
+func LoadAnyPrivateKeyFrom(p KeyProvider) (*HybridPrivateKey, SigningKey, error) {
+	privPEM, err := p.FetchPrivateKey(KeyKindHybrid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read private key from %s: %w", p, err)
+	}
+	defer privPEM.Release()
+
+	hybrid, err := parseHybridPrivateKey(privPEM.Bytes())
+	if err == nil {
+		return hybrid, nil, nil
+	}
+	if signKey, signErr := parseDilithiumPrivateKey(privPEM.Bytes()); signErr == nil {
+		return nil, signKey, nil
+	}
+	if _, ok := p.(*ExecKeyProvider); ok {
+		if signKey, signErr := LoadDilithiumPrivateKeyFrom(p); signErr == nil {
+			return nil, signKey, nil
+		}
+	}
+	return nil, nil, err
+}
+
+// FileKeyProvider 从文件读取私钥.
+type FileKeyProvider struct {
+	Path string
+}
+
+// FetchPrivateKey 实现 KeyProvider.
+func (p *FileKeyProvider) FetchPrivateKey(KeyKind) (*SecretBuffer, error) {
+	return readSecretFile(p.Path)
+}
+
+func (p *FileKeyProvider) String() string { return p.Path }
+
+// EnvKeyProvider 从环境变量读取私钥，变量内容为 PEM 或其 base64 编码.
+// 环境变量本身的副本无法清零，只有读出的 PEM 放在 SecretBuffer 中.
+type EnvKeyProvider struct {
+	Name string
+}
+
+// FetchPrivateKey 实现 KeyProvider.
+func (p *EnvKeyProvider) FetchPrivateKey(KeyKind) (*SecretBuffer, error) {
+	value, ok := os.LookupEnv(p.Name)
+	if !ok || value == "" {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+			fmt.Sprintf("Environment variable %s is not set", p.Name))
+	}
+	return decodeKeyData(NewSecretBufferFrom([]byte(value)))
+}
+
+func (p *EnvKeyProvider) String() string { return schemeEnv + ":" + p.Name }
+
+// Base64KeyProvider 内联的 base64 编码私钥.
+type Base64KeyProvider struct {
+	Data string
+}
+
+// FetchPrivateKey 实现 KeyProvider.
+func (p *Base64KeyProvider) FetchPrivateKey(KeyKind) (*SecretBuffer, error) {
+	return decodeKeyData(NewSecretBufferFrom([]byte(p.Data)))
+}
+
+func (p *Base64KeyProvider) String() string { return schemeBase64 + ":..." }
+
+// FDKeyProvider 从已打开的文件描述符读取私钥，常用于 `-p fd:3 3<key.pem` 或管道.
+// 描述符读到 EOF 后关闭（0 除外），因此同一描述符只能读取一次.
+type FDKeyProvider struct {
+	FD int
+}
+
+// FetchPrivateKey 实现 KeyProvider.
+func (p *FDKeyProvider) FetchPrivateKey(KeyKind) (*SecretBuffer, error) {
+	f := os.Stdin
+	if p.FD != 0 {
+		f = os.NewFile(uintptr(p.FD), p.String())
+		defer func() {
+			_ = f.Close()
+		}()
+	}
+	data, err := readSecretStream(f)
+	if err != nil {
+		return nil, err
+	}
+	return decodeKeyData(data)
+}
+
+func (p *FDKeyProvider) String() string { return schemeFD + ":" + strconv.Itoa(p.FD) }
+
+// ExecKeyProvider 通过外部命令获取私钥.
+//
+// 与 git credential helper 相同，命令以追加的 "get" 参数运行，标准输入收到
+// "type=hybrid" 或 "type=dilithium" 一行属性，标准输出返回 PEM 或其 base64 编码；
+// 标准错误直接传给用户，便于提示输入口令.
+type ExecKeyProvider struct {
+	Command []string
+}
+
+// FetchPrivateKey 实现 KeyProvider.
+func (p *ExecKeyProvider) FetchPrivateKey(kind KeyKind) (*SecretBuffer, error) {
+	// #nosec G204 - 命令由用户通过 -p 参数显式指定
+	cmd := exec.Command(p.Command[0], append(p.Command[1:], "get")...)
+	cmd.Stdin = strings.NewReader("type=" + kind.String() + "\n")
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("key helper: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start key helper: %w", err)
+	}
+
+	data, readErr := readSecretStream(stdout)
+	if readErr != nil {
+		_ = cmd.Process.Kill()
+	}
+	if err := cmd.Wait(); err != nil {
+		data.Release()
+		if readErr != nil {
+			return nil, readErr
+		}
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+			fmt.Sprintf("Key helper %s failed: %v", p.Command[0], err))
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return decodeKeyData(data)
+}
+
+func (p *ExecKeyProvider) String() string { return schemeExec + ":" + p.Command[0] }
+
+// PKCS11KeyProvider 从 PKCS#11 令牌读取以数据对象（CKO_DATA）保存的 PEM 私钥.
+//
+// 硬件令牌不支持 Kyber 与 Dilithium，私钥以 PEM 数据对象存放，受令牌 PIN 保护，
+// 可用 `pkcs11-tool --write-object key.pem --type data --label NAME --private` 写入.
+// 需要以 `-tags pkcs11` 并启用 cgo 构建.
+type PKCS11KeyProvider struct {
+	// ModulePath PKCS#11 模块路径，URI 中未指定时取 FZJJYZ_PKCS11_MODULE.
+	ModulePath string
+	// Token 令牌标签.
+	Token string
+	// Object 数据对象标签（CKA_LABEL）.
+	Object string
+	// PIN 用户 PIN，URI 中未指定时取 FZJJYZ_PKCS11_PIN.
+	PIN string
+}
+
+// PKCS11ModuleEnv 和 PKCS11PINEnv 是 PKCS#11 URI 缺省模块路径与 PIN 的环境变量.
+const (
+	PKCS11ModuleEnv = "FZJJYZ_PKCS11_MODULE"
+	PKCS11PINEnv    = "FZJJYZ_PKCS11_PIN"
+)
+
+func (p *PKCS11KeyProvider) String() string {
+	return schemePKCS11 + ":token=" + url.PathEscape(p.Token) + ";object=" + url.PathEscape(p.Object)
+}
+
+// parsePKCS11URI 解析 RFC 7512 URI 中本工具使用的属性:
+// 路径部分 token、object、type（只能为 data），查询部分 module-path、pin-value、pin-source.
+func parsePKCS11URI(uri, rest string) (*PKCS11KeyProvider, error) {
+	p := &PKCS11KeyProvider{}
+	path, query, _ := strings.Cut(rest, "?")
+	var pinSource string
+	for _, part := range []struct{ attrs, sep string }{{path, ";"}, {query, "&"}} {
+		if part.attrs == "" {
+			continue
+		}
+		for attr := range strings.SplitSeq(part.attrs, part.sep) {
+			name, raw, _ := strings.Cut(attr, "=")
+			value, err := url.PathUnescape(raw)
+			if err != nil {
+				return nil, invalidKeyURI(uri, err.Error())
+			}
+			switch name {
+			case "token":
+				p.Token = value
+			case "object":
+				p.Object = value
+			case "type":
+				if value != "data" {
+					return nil, invalidKeyURI(uri, "only type=data objects are supported")
+				}
+			case "module-path":
+				p.ModulePath = value
+			case "pin-value":
+				p.PIN = value
+			case "pin-source":
+				pinSource = strings.TrimPrefix(value, schemeFile+":")
+			default:
+				return nil, invalidKeyURI(uri, "unsupported attribute "+name)
+			}
+		}
+	}
+	if p.Object == "" {
+		return nil, invalidKeyURI(uri, "missing object")
+	}
+	if p.ModulePath == "" {
+		p.ModulePath = os.Getenv(PKCS11ModuleEnv)
+	}
+	if p.ModulePath == "" {
+		return nil, invalidKeyURI(uri, "missing module-path")
+	}
+	if p.PIN == "" && pinSource != "" {
+		pin, err := os.ReadFile(pinSource) // #nosec G304 - 路径由用户在 URI 中指定
+		if err != nil {
+			return nil, fmt.Errorf("read PKCS#11 PIN: %w", err)
+		}
+		p.PIN = strings.TrimRight(string(pin), "\r\n")
+		clear(pin)
+	}
+	if p.PIN == "" {
+		p.PIN = os.Getenv(PKCS11PINEnv)
+	}
+	return p, nil
+}
+
+// decodeKeyData 接受 PEM 文本或其 base64 编码，返回 PEM 并释放 data.
+func decodeKeyData(data *SecretBuffer) (*SecretBuffer, error) {
+	text := bytes.TrimSpace(data.Bytes())
+	if len(text) == 0 {
+		data.Release()
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Empty key data")
+	}
+	if bytes.HasPrefix(text, []byte("-----BEGIN ")) {
+		return data, nil
+	}
+	defer data.Release()
+	decoded := NewSecretBuffer(base64.StdEncoding.DecodedLen(len(text)))
+	n, err := base64.StdEncoding.Decode(decoded.data, text)
+	if err != nil {
+		decoded.Release()
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Key data is neither PEM nor base64")
+	}
+	decoded.data = decoded.data[:n:n]
+	return decoded, nil
+}
@@ -0,0 +1,16 @@
+//go:build !pkcs11 || !cgo
+
+package zjcrypto
+
+import (
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// pkcs11Enabled 报告本构建是否包含 PKCS#11 支持.
+const pkcs11Enabled = false
+
+// FetchPrivateKey 未启用 PKCS#11 支持的构建中始终返回错误.
+func (p *PKCS11KeyProvider) FetchPrivateKey(KeyKind) (*SecretBuffer, error) {
+	return nil, utils.NewCryptoError(utils.ErrInvalidParameter,
+		"PKCS#11 support is not compiled in, rebuild with cgo and -tags pkcs11")
+}
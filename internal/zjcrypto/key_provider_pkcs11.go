@@ -0,0 +1,111 @@
+//go:build pkcs11 && cgo
+
+package zjcrypto
+
+import (
+	"fmt"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/miekg/pkcs11"
+)
+
+// pkcs11Enabled 报告本构建是否包含 PKCS#11 支持.
+const pkcs11Enabled = true
+
+// FetchPrivateKey 实现 KeyProvider：登录令牌并读取数据对象的 CKA_VALUE.
+func (p *PKCS11KeyProvider) FetchPrivateKey(KeyKind) (*SecretBuffer, error) {
+	ctx := pkcs11.New(p.ModulePath)
+	if ctx == nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter,
+			fmt.Sprintf("Cannot load PKCS#11 module %s", p.ModulePath))
+	}
+	defer ctx.Destroy()
+	if err := ctx.Initialize(); err != nil {
+		return nil, fmt.Errorf("initialize PKCS#11 module: %w", err)
+	}
+	defer func() {
+		_ = ctx.Finalize()
+	}()
+
+	slot, err := p.findSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return nil, fmt.Errorf("open PKCS#11 session: %w", err)
+	}
+	defer func() {
+		_ = ctx.CloseSession(session)
+	}()
+	if p.PIN != "" {
+		if err := ctx.Login(session, pkcs11.CKU_USER, p.PIN); err != nil {
+			return nil, utils.NewCryptoError(utils.ErrAuthFailed,
+				fmt.Sprintf("PKCS#11 login failed: %v", err))
+		}
+		defer func() {
+			_ = ctx.Logout(session)
+		}()
+	}
+
+	object, err := p.findObject(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := ctx.GetAttributeValue(session, object, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil),
+	})
+	if err != nil || len(attrs) != 1 {
+		return nil, fmt.Errorf("read PKCS#11 object %s: %w", p.Object, err)
+	}
+	return decodeKeyData(NewSecretBufferFrom(attrs[0].Value))
+}
+
+// findSlot 返回标签为 Token 的令牌所在插槽；未指定 Token 时要求只有一个令牌.
+func (p *PKCS11KeyProvider) findSlot(ctx *pkcs11.Ctx) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("list PKCS#11 slots: %w", err)
+	}
+	if p.Token == "" {
+		if len(slots) != 1 {
+			return 0, utils.NewCryptoError(utils.ErrInvalidParameter,
+				fmt.Sprintf("Found %d PKCS#11 tokens, specify token= in the URI", len(slots)))
+		}
+		return slots[0], nil
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err == nil && info.Label == p.Token {
+			return slot, nil
+		}
+	}
+	return 0, utils.NewCryptoError(utils.ErrInvalidKey,
+		fmt.Sprintf("PKCS#11 token %q not found", p.Token))
+}
+
+// findObject 按标签查找唯一的数据对象.
+func (p *PKCS11KeyProvider) findObject(ctx *pkcs11.Ctx, session pkcs11.SessionHandle) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, p.Object),
+	}
+	if err := ctx.FindObjectsInit(session, template); err != nil {
+		return 0, fmt.Errorf("search PKCS#11 objects: %w", err)
+	}
+	objects, _, err := ctx.FindObjects(session, 2)
+	_ = ctx.FindObjectsFinal(session)
+	if err != nil {
+		return 0, fmt.Errorf("search PKCS#11 objects: %w", err)
+	}
+	switch len(objects) {
+	case 0:
+		return 0, utils.NewCryptoError(utils.ErrInvalidKey,
+			fmt.Sprintf("PKCS#11 object %q not found", p.Object))
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, utils.NewCryptoError(utils.ErrInvalidKey,
+			fmt.Sprintf("PKCS#11 object label %q is not unique", p.Object))
+	}
+}
@@ -0,0 +1,137 @@
+//go:build pkcs11 && cgo
+
+package zjcrypto
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+)
+
+// softHSMModule 返回 SoftHSM 模块路径，FZJJYZ_TEST_PKCS11_MODULE 优先.
+func softHSMModule(t *testing.T) string {
+	t.Helper()
+	candidates := []string{
+		os.Getenv("FZJJYZ_TEST_PKCS11_MODULE"),
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+		"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+	}
+	for _, path := range candidates {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	t.Skip("未找到 SoftHSM 模块")
+	return ""
+}
+
+// initSoftHSMToken 在临时目录中初始化令牌并以数据对象写入 value.
+func initSoftHSMToken(t *testing.T, module, token, pin, label string, value []byte) {
+	t.Helper()
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "softhsm2.conf")
+	tokens := filepath.Join(dir, "tokens")
+	if err := os.Mkdir(tokens, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(conf, []byte("directories.tokendir = "+tokens+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	ctx := pkcs11.New(module)
+	if ctx == nil {
+		t.Fatalf("无法加载 %s", module)
+	}
+	defer ctx.Destroy()
+	if err := ctx.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = ctx.Finalize()
+	}()
+	slots, err := ctx.GetSlotList(true)
+	if err != nil || len(slots) == 0 {
+		t.Fatalf("没有可用插槽: %v", err)
+	}
+	if err := ctx.InitToken(slots[0], "so-pin", token); err != nil {
+		t.Fatal(err)
+	}
+	// 初始化后令牌会换到新的插槽编号
+	slots, _ = ctx.GetSlotList(true)
+	var slot uint
+	for _, s := range slots {
+		if info, err := ctx.GetTokenInfo(s); err == nil && info.Label == token {
+			slot = s
+		}
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = ctx.CloseSession(session)
+	}()
+	if err := ctx.Login(session, pkcs11.CKU_SO, "so-pin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.InitPIN(session, pin); err != nil {
+		t.Fatal(err)
+	}
+	_ = ctx.Logout(session)
+	if err := ctx.Login(session, pkcs11.CKU_USER, pin); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = ctx.Logout(session)
+	}()
+	if _, err := ctx.CreateObject(session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_DATA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, value),
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPKCS11KeyProvider(t *testing.T) {
+	module := softHSMModule(t)
+	_, privPath, _, _ := writeCacheTestKeys(t, t.TempDir(), "hsm")
+	privPEM, _ := os.ReadFile(privPath) // #nosec G304 - 测试环境使用临时文件路径
+	initSoftHSMToken(t, module, "fzj", "1234", "fzj key", privPEM)
+
+	want, _ := LoadPrivateKey(privPath)
+	p, err := ParseKeyURI("pkcs11:token=fzj;object=fzj%20key?module-path=" + module + "&pin-value=1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadPrivateKeyFrom(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.ECDH.Equal(want.ECDH) {
+		t.Error("从令牌加载的私钥不一致")
+	}
+
+	for _, bad := range []*PKCS11KeyProvider{
+		{ModulePath: module, Token: "fzj", Object: "fzj key", PIN: "0000"},
+		{ModulePath: module, Token: "fzj", Object: "other", PIN: "1234"},
+		{ModulePath: module, Token: "none", Object: "fzj key", PIN: "1234"},
+		// 未登录时私有对象不可见
+		{ModulePath: module, Token: "fzj", Object: "fzj key"},
+		{ModulePath: filepath.Join(t.TempDir(), "missing.so"), Object: "fzj key"},
+	} {
+		if _, err := LoadPrivateKeyFrom(bad); err == nil {
+			t.Errorf("%+v: 期望返回错误", *bad)
+		}
+	}
+}
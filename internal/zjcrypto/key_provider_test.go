@@ -0,0 +1,162 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseKeyURI(t *testing.T) {
+	t.Setenv(PKCS11ModuleEnv, "/usr/lib/softhsm/libsofthsm2.so")
+	t.Setenv(PKCS11PINEnv, "")
+	pinFile := filepath.Join(t.TempDir(), "pin")
+	if err := os.WriteFile(pinFile, []byte("4321\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		uri  string
+		want KeyProvider
+	}{
+		{"keys/a_private.pem", &FileKeyProvider{Path: "keys/a_private.pem"}},
+		{"file:env:odd.pem", &FileKeyProvider{Path: "env:odd.pem"}},
+		{`C:\keys\a_private.pem`, &FileKeyProvider{Path: `C:\keys\a_private.pem`}},
+		{"env:FZJ_KEY", &EnvKeyProvider{Name: "FZJ_KEY"}},
+		{"base64:LS0t", &Base64KeyProvider{Data: "LS0t"}},
+		{"fd:3", &FDKeyProvider{FD: 3}},
+		{"exec:/usr/local/bin/vault-get --path secret/fzj", &ExecKeyProvider{
+			Command: []string{"/usr/local/bin/vault-get", "--path", "secret/fzj"}}},
+		{"pkcs11:token=fzj;object=my%20key;type=data?pin-value=1234", &PKCS11KeyProvider{
+			ModulePath: "/usr/lib/softhsm/libsofthsm2.so", Token: "fzj", Object: "my key", PIN: "1234"}},
+		{"pkcs11:object=k?module-path=/opt/p11.so&pin-source=file:" + pinFile, &PKCS11KeyProvider{
+			ModulePath: "/opt/p11.so", Object: "k", PIN: "4321"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseKeyURI(tt.uri)
+		if err != nil {
+			t.Errorf("ParseKeyURI(%q): %v", tt.uri, err)
+			continue
+		}
+		if got.String() != tt.want.String() || !equalProvider(got, tt.want) {
+			t.Errorf("ParseKeyURI(%q) = %#v, want %#v", tt.uri, got, tt.want)
+		}
+	}
+
+	for _, uri := range []string{"env:", "fd:x", "fd:-1", "exec:", "exec:  ",
+		"pkcs11:token=fzj", "pkcs11:object=k;type=private-key", "pkcs11:object=k;serial=1", "pkcs11:object=%zz"} {
+		if _, err := ParseKeyURI(uri); err == nil {
+			t.Errorf("ParseKeyURI(%q) 应返回错误", uri)
+		}
+	}
+}
+
+func equalProvider(a, b KeyProvider) bool {
+	switch a := a.(type) {
+	case *ExecKeyProvider:
+		b, ok := b.(*ExecKeyProvider)
+		return ok && strings.Join(a.Command, "\x00") == strings.Join(b.Command, "\x00")
+	case *PKCS11KeyProvider:
+		b, ok := b.(*PKCS11KeyProvider)
+		return ok && *a == *b
+	case *Base64KeyProvider:
+		b, ok := b.(*Base64KeyProvider)
+		return ok && a.Data == b.Data
+	default:
+		return a.String() == b.String()
+	}
+}
+
+func TestKeyProviders(t *testing.T) {
+	dir := t.TempDir()
+	_, privPath, _, dilPrivPath := writeCacheTestKeys(t, dir, "prov")
+	privPEM, _ := os.ReadFile(privPath)       // #nosec G304 - 测试环境使用临时文件路径
+	dilPrivPEM, _ := os.ReadFile(dilPrivPath) // #nosec G304 - 测试环境使用临时文件路径
+	want, err := LoadPrivateKey(privPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("FZJ_TEST_PEM", string(privPEM))
+	t.Setenv("FZJ_TEST_B64", base64.StdEncoding.EncodeToString(privPEM)+"\n")
+	for _, uri := range []string{
+		privPath,
+		"file:" + privPath,
+		"env:FZJ_TEST_PEM",
+		"env:FZJ_TEST_B64",
+		"base64:" + base64.StdEncoding.EncodeToString(privPEM),
+	} {
+		p, err := ParseKeyURI(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := LoadPrivateKeyFrom(p)
+		if err != nil {
+			t.Errorf("%s: %v", p, err)
+			continue
+		}
+		if !got.ECDH.Equal(want.ECDH) {
+			t.Errorf("%s: 加载的私钥不一致", p)
+		}
+	}
+
+	t.Setenv("FZJ_TEST_DIL", string(dilPrivPEM))
+	if _, err := LoadDilithiumPrivateKeyFrom(&EnvKeyProvider{Name: "FZJ_TEST_DIL"}); err != nil {
+		t.Error(err)
+	}
+
+	// 不区分类型时按内容识别，只返回其中一个
+	if hybrid, signKey, err := LoadAnyPrivateKeyFrom(&EnvKeyProvider{Name: "FZJ_TEST_PEM"}); err != nil ||
+		hybrid == nil || signKey != nil {
+		t.Errorf("混合私钥: %v", err)
+	}
+	if hybrid, signKey, err := LoadAnyPrivateKeyFrom(&EnvKeyProvider{Name: "FZJ_TEST_DIL"}); err != nil ||
+		hybrid != nil || signKey == nil {
+		t.Errorf("Dilithium 私钥: %v", err)
+	}
+
+	t.Setenv("FZJ_TEST_EMPTY", "  \n")
+	t.Setenv("FZJ_TEST_JUNK", "not a key!")
+	for _, p := range []KeyProvider{
+		&EnvKeyProvider{Name: "FZJ_TEST_UNSET_VARIABLE"},
+		&EnvKeyProvider{Name: "FZJ_TEST_EMPTY"},
+		&EnvKeyProvider{Name: "FZJ_TEST_JUNK"},
+		&FileKeyProvider{Path: filepath.Join(dir, "missing.pem")},
+	} {
+		if _, err := LoadPrivateKeyFrom(p); err == nil {
+			t.Errorf("%s: 期望返回错误", p)
+		}
+		if _, _, err := LoadAnyPrivateKeyFrom(p); err == nil {
+			t.Errorf("%s: 期望返回错误", p)
+		}
+	}
+	// 错误信息不泄露内联密钥
+	if _, err := LoadPrivateKeyFrom(&Base64KeyProvider{Data: "c2VjcmV0"}); err == nil ||
+		strings.Contains(err.Error(), "c2VjcmV0") {
+		t.Errorf("错误信息 = %v", err)
+	}
+}
+
+func TestReadSecretStream(t *testing.T) {
+	b, err := readSecretStream(strings.NewReader("stream data"))
+	if err != nil || string(b.Bytes()) != "stream data" {
+		t.Fatalf("readSecretStream = %q, %v", b.Bytes(), err)
+	}
+	b.Release()
+
+	if _, err := readSecretStream(bytes.NewReader(make([]byte, maxSecretFileSize+1))); err == nil {
+		t.Error("期望拒绝过大的数据")
+	}
+}
+
+func TestPKCS11Unavailable(t *testing.T) {
+	if pkcs11Enabled {
+		t.Skip("已启用 PKCS#11 支持")
+	}
+	p := &PKCS11KeyProvider{ModulePath: "/nonexistent.so", Object: "k"}
+	if _, err := LoadPrivateKeyFrom(p); err == nil || !strings.Contains(err.Error(), "-tags pkcs11") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+//go:build unix
+
+package zjcrypto
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestFDKeyProvider(t *testing.T) {
+	_, privPath, _, _ := writeCacheTestKeys(t, t.TempDir(), "fd")
+	f, err := os.Open(privPath) // #nosec G304 - 测试环境使用临时文件路径
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	// 提供者读完后关闭描述符，交给它一个副本
+	fd, err := unix.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &FDKeyProvider{FD: fd}
+	if _, err := LoadPrivateKeyFrom(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unix.FcntlInt(uintptr(fd), unix.F_GETFD, 0); err == nil {
+		t.Error("读取后描述符应已关闭")
+	}
+}
+
+func TestExecKeyProvider(t *testing.T) {
+	dir := t.TempDir()
+	_, privPath, _, dilPrivPath := writeCacheTestKeys(t, dir, "exec")
+	log := filepath.Join(dir, "helper.log")
+	helper := filepath.Join(dir, "key-helper")
+	script := `#!/bin/sh
+read attr
+echo "$* $attr" >> ` + log + `
+case "$attr" in
+type=hybrid) cat ` + privPath + ` ;;
+type=dilithium) base64 < ` + dilPrivPath + ` ;;
+esac
+`
+	if err := os.WriteFile(helper, []byte(script), 0700); err != nil { // #nosec G306 - 测试脚本需要可执行
+		t.Fatal(err)
+	}
+
+	p, err := ParseKeyURI("exec:" + helper + " --vault test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPrivateKeyFrom(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDilithiumPrivateKeyFrom(p); err != nil {
+		t.Fatal(err)
+	}
+	calls, _ := os.ReadFile(log) // #nosec G304 - 测试环境使用临时文件路径
+	if got := string(calls); got != "--vault test get type=hybrid\n--vault test get type=dilithium\n" {
+		t.Errorf("helper 调用记录:\n%s", got)
+	}
+
+	for _, cmd := range []string{"exit 3", "echo", "head -c 2000000 /dev/zero"} {
+		failing := filepath.Join(dir, "failing")
+		if err := os.WriteFile(failing, []byte("#!/bin/sh\n"+cmd+"\n"), 0700); err != nil { // #nosec G306 - 测试脚本需要可执行
+			t.Fatal(err)
+		}
+		if _, err := LoadPrivateKeyFrom(&ExecKeyProvider{Command: []string{failing}}); err == nil {
+			t.Errorf("%q: 期望返回错误", cmd)
+		}
+	}
+	if _, err := LoadPrivateKeyFrom(&ExecKeyProvider{Command: []string{filepath.Join(dir, "missing")}}); err == nil ||
+		!strings.Contains(err.Error(), "start key helper") {
+		t.Errorf("err = %v", err)
+	}
+}
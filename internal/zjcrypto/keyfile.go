@@ -2,11 +2,12 @@ package zjcrypto
 
 import (
 	"crypto/ecdh"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
 	"os"
 	"runtime"
-	"sync"
 	"time"
 
 	"codeberg.org/jiangfire/fzjjyz/internal/utils"
@@ -30,45 +31,6 @@ const (
 	pubKeyFilePerm = 0644
 )
 
-// keyCacheEntry 缓存条目，包含创建时间和TTL.
-type keyCacheEntry struct {
-	key       interface{}
-	createdAt time.Time
-	ttl       time.Duration
-}
-
-// keyCache 密钥缓存，使用 sync.Map 实现线程安全.
-var keyCache sync.Map
-
-// init 初始化缓存清理定时器
-//
-//nolint:gochecknoinits // init函数用于必要的包级初始化：启动后台缓存清理任务
-func init() {
-	startCacheCleanup()
-}
-
-// startCacheCleanup 启动后台缓存清理任务.
-func startCacheCleanup() {
-	// 使用匿名函数避免全局变量，定时器会自动重新调度
-	_ = time.AfterFunc(CacheCleanupInterval, func() {
-		cleanupExpiredKeys()
-		// 重新调度下一次清理
-		startCacheCleanup()
-	})
-}
-
-// cleanupExpiredKeys 清理过期的缓存条目.
-func cleanupExpiredKeys() {
-	now := time.Now()
-	keyCache.Range(func(key, value interface{}) bool {
-		entry := value.(*keyCacheEntry)
-		if now.Sub(entry.createdAt) >= entry.ttl {
-			keyCache.Delete(key)
-		}
-		return true
-	})
-}
-
 // SaveKeyFiles 保存密钥文件（遵循安全原则）.
 func SaveKeyFiles(
 	kyberPub kem.PublicKey,
@@ -77,14 +39,23 @@ func SaveKeyFiles(
 	ecdhPriv *ecdh.PrivateKey,
 	pubPath, privPath string,
 ) error {
+	return SaveHybridKeyFiles(
+		&HybridPublicKey{Kyber: kyberPub, ECDH: ecdhPub},
+		&HybridPrivateKey{Kyber: kyberPriv, ECDH: ecdhPriv},
+		pubPath, privPath,
+	)
+}
+
+// SaveHybridKeyFiles 与 SaveKeyFiles 相同，私钥含 KyberSeed 时一并保存种子.
+func SaveHybridKeyFiles(pub *HybridPublicKey, priv *HybridPrivateKey, pubPath, privPath string) error {
 	// 导出公钥
-	pubPEM, err := ExportPublicKey(kyberPub, ecdhPub)
+	pubPEM, err := ExportPublicKey(pub.Kyber, pub.ECDH)
 	if err != nil {
 		return err
 	}
 
 	// 导出私钥
-	privPEM, err := ExportPrivateKey(kyberPriv, ecdhPriv)
+	privPEM, err := ExportHybridPrivateKey(priv)
 	if err != nil {
 		return err
 	}
@@ -118,13 +89,13 @@ func LoadKeyFiles(pubPath, privPath string) (*HybridPublicKey, *HybridPrivateKey
 		return nil, nil, fmt.Errorf("read public key file: %w", err)
 	}
 
-	// #nosec G304 - 调用方应验证路径安全性
-	privPEM, err := os.ReadFile(privPath)
+	privPEM, err := readSecretFile(privPath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("read private key file: %w", err)
 	}
+	defer privPEM.Release()
 
-	return ImportKeys(pubPEM, privPEM)
+	return ImportKeys(pubPEM, privPEM.Bytes())
 }
 
 // LoadPublicKey 只加载公钥文件.
@@ -144,21 +115,9 @@ func LoadPublicKey(pubPath string) (*HybridPublicKey, error) {
 	return &HybridPublicKey{Kyber: pubKyber, ECDH: pubECDH}, nil
 }
 
-// LoadPrivateKey 只加载私钥文件.
+// LoadPrivateKey 只加载私钥文件；其他来源见 LoadPrivateKeyFrom.
 func LoadPrivateKey(privPath string) (*HybridPrivateKey, error) {
-	// #nosec G304 - 调用方应验证路径安全性
-	privPEM, err := os.ReadFile(privPath)
-	if err != nil {
-		return nil, fmt.Errorf("read private key file: %w", err)
-	}
-
-	// 只解析私钥部分
-	privKyber, privECDH, err := parsePrivateKeys(privPEM)
-	if err != nil {
-		return nil, err
-	}
-
-	return &HybridPrivateKey{Kyber: privKyber, ECDH: privECDH}, nil
+	return LoadPrivateKeyFrom(&FileKeyProvider{Path: privPath})
 }
 
 // DilithiumKeyPair 包含 Dilithium3 密钥对的 PEM 格式.
@@ -167,8 +126,9 @@ type DilithiumKeyPair struct {
 	Private []byte
 }
 
-// ExportDilithiumKeys 导出 Dilithium3 密钥对到 PEM 格式.
-func ExportDilithiumKeys(pub *mode3.PublicKey, priv *mode3.PrivateKey) (*DilithiumKeyPair, error) {
+// ExportDilithiumKeys 导出 Dilithium3 密钥对到 PEM 格式，复合密钥在 Dilithium3 PEM 块之后追加 Ed25519 PEM 块，
+// 保留种子的私钥再追加种子块.
+func ExportDilithiumKeys(pub VerifyingKey, priv SigningKey) (*DilithiumKeyPair, error) {
 	if pub == nil || priv == nil {
 		return nil, utils.NewCryptoError(
 			utils.ErrInvalidKey,
@@ -176,320 +136,211 @@ func ExportDilithiumKeys(pub *mode3.PublicKey, priv *mode3.PrivateKey) (*Dilithi
 		)
 	}
 
-	pubKey := pub
-	privKey := priv
-
-	// 导出公钥
-	pubBytes := pubKey.Bytes()
-	pubPEM := pem.Block{
-		Type:  "DILITHIUM3 PUBLIC KEY",
-		Bytes: pubBytes,
+	var pubBlocks, privBlocks []*pem.Block
+	switch k := priv.(type) {
+	case *mode3.PrivateKey:
+		p, ok := pub.(*mode3.PublicKey)
+		if !ok || !p.Equal(k.Public()) {
+			return nil, errDilithiumKeyMismatch()
+		}
+		pubBlocks = []*pem.Block{{Type: "DILITHIUM3 PUBLIC KEY", Bytes: p.Bytes()}}
+		privBlocks = []*pem.Block{{Type: "DILITHIUM3 PRIVATE KEY", Bytes: k.Bytes()}}
+		if seed := k.Seed(); seed != nil {
+			privBlocks = append(privBlocks, &pem.Block{Type: pemTypeDilithiumSeed, Bytes: seed})
+		}
+	case *CompositePrivateKey:
+		p, ok := pub.(*CompositePublicKey)
+		if !ok || !p.Equal(k.Public()) {
+			return nil, errDilithiumKeyMismatch()
+		}
+		pubBlocks = []*pem.Block{
+			{Type: "DILITHIUM3 PUBLIC KEY", Bytes: p.Dilithium.Bytes()},
+			{Type: pemTypeEd25519Public, Bytes: p.Ed25519},
+		}
+		privBlocks = []*pem.Block{
+			{Type: "DILITHIUM3 PRIVATE KEY", Bytes: k.Dilithium.Bytes()},
+			{Type: pemTypeEd25519Private, Bytes: k.Ed25519.Seed()},
+		}
+		if seed := k.Dilithium.Seed(); seed != nil {
+			privBlocks = append(privBlocks, &pem.Block{Type: pemTypeDilithiumSeed, Bytes: seed})
+		}
+	default:
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Signing key cannot be exported")
 	}
 
-	// 导出私钥
-	privBytes := privKey.Bytes()
-	privPEM := pem.Block{
-		Type:  "DILITHIUM3 PRIVATE KEY",
-		Bytes: privBytes,
+	pair := &DilithiumKeyPair{}
+	for _, block := range pubBlocks {
+		pair.Public = append(pair.Public, pem.EncodeToMemory(block)...)
+	}
+	for _, block := range privBlocks {
+		pair.Private = append(pair.Private, pem.EncodeToMemory(block)...)
+		clear(block.Bytes)
 	}
+	return pair, nil
+}
 
-	return &DilithiumKeyPair{
-		Public:  pem.EncodeToMemory(&pubPEM),
-		Private: pem.EncodeToMemory(&privPEM),
-	}, nil
+func errDilithiumKeyMismatch() error {
+	return utils.NewCryptoError(utils.ErrInvalidKey, "Dilithium public and private keys do not match")
 }
 
 // ImportDilithiumKeys 从 PEM 格式导入 Dilithium3 密钥对.
-func ImportDilithiumKeys(pubPEM, privPEM []byte) (*mode3.PublicKey, *mode3.PrivateKey, error) {
-	// 解析公钥
-	pubBlock, _ := pem.Decode(pubPEM)
-	if pubBlock == nil || pubBlock.Type != "DILITHIUM3 PUBLIC KEY" {
-		return nil, nil, utils.NewCryptoError(
-			utils.ErrInvalidKey,
-			"Invalid Dilithium3 public key PEM",
-		)
-	}
-	var pubKey mode3.PublicKey
-	if err := pubKey.UnmarshalBinary(pubBlock.Bytes); err != nil {
-		return nil, nil, utils.NewCryptoError(
-			utils.ErrInvalidKey,
-			fmt.Sprintf("Failed to parse Dilithium3 public key: %v", err),
-		)
-	}
-
-	// 解析私钥
-	privBlock, _ := pem.Decode(privPEM)
-	if privBlock == nil || privBlock.Type != "DILITHIUM3 PRIVATE KEY" {
-		return nil, nil, utils.NewCryptoError(
-			utils.ErrInvalidKey,
-			"Invalid Dilithium3 private key PEM",
-		)
+func ImportDilithiumKeys(pubPEM, privPEM []byte) (VerifyingKey, SigningKey, error) {
+	pubKey, err := parseDilithiumPublicKey(pubPEM)
+	if err != nil {
+		return nil, nil, err
 	}
-	var privKey mode3.PrivateKey
-	if err := privKey.UnmarshalBinary(privBlock.Bytes); err != nil {
-		return nil, nil, utils.NewCryptoError(
-			utils.ErrInvalidKey,
-			fmt.Sprintf("Failed to parse Dilithium3 private key: %v", err),
-		)
+	privKey, err := parseDilithiumPrivateKey(privPEM)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	return &pubKey, &privKey, nil
+	return pubKey, privKey, nil
 }
 
 // LoadDilithiumKeys 从文件加载 Dilithium3 密钥对.
-func LoadDilithiumKeys(pubPath, privPath string) (*mode3.PublicKey, *mode3.PrivateKey, error) {
+func LoadDilithiumKeys(pubPath, privPath string) (VerifyingKey, SigningKey, error) {
 	// #nosec G304 - 调用方应验证路径安全性
 	pubPEM, err := os.ReadFile(pubPath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("read Dilithium public key file: %w", err)
 	}
 
-	// #nosec G304 - 调用方应验证路径安全性
-	privPEM, err := os.ReadFile(privPath)
+	privPEM, err := readSecretFile(privPath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("read Dilithium private key file: %w", err)
 	}
+	defer privPEM.Release()
 
-	return ImportDilithiumKeys(pubPEM, privPEM)
+	return ImportDilithiumKeys(pubPEM, privPEM.Bytes())
 }
 
 // LoadDilithiumPublicKey 只加载 Dilithium 公钥.
-func LoadDilithiumPublicKey(pubPath string) (*mode3.PublicKey, error) {
+func LoadDilithiumPublicKey(pubPath string) (VerifyingKey, error) {
 	// #nosec G304 - 调用方应验证路径安全性
 	pubPEM, err := os.ReadFile(pubPath)
 	if err != nil {
 		return nil, fmt.Errorf("read Dilithium public key file: %w", err)
 	}
+	return parseDilithiumPublicKey(pubPEM)
+}
 
-	// 解析公钥
-	pubBlock, _ := pem.Decode(pubPEM)
-	if pubBlock == nil || pubBlock.Type != "DILITHIUM3 PUBLIC KEY" {
+// parseDilithiumPublicKey 解析 Dilithium3 公钥，接受本工具的 PEM 格式、
+// SubjectPublicKeyInfo PEM 或 DER；本工具格式含 Ed25519 块时返回复合公钥.
+func parseDilithiumPublicKey(pubPEM []byte) (VerifyingKey, error) {
+	raw, der, ok := decodeSingleKey(pubPEM, "DILITHIUM3 PUBLIC KEY", pemTypePublicKey)
+	if !ok {
 		return nil, utils.NewCryptoError(
 			utils.ErrInvalidKey,
 			"Invalid Dilithium3 public key PEM",
 		)
 	}
+	if der != nil {
+		key, err := ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, err
+		}
+		pub, ok := key.(*mode3.PublicKey)
+		if !ok {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Not a Dilithium3 public key")
+		}
+		return pub, nil
+	}
 	var pubKey mode3.PublicKey
-	if err := pubKey.UnmarshalBinary(pubBlock.Bytes); err != nil {
+	if err := pubKey.UnmarshalBinary(raw); err != nil {
 		return nil, utils.NewCryptoError(
 			utils.ErrInvalidKey,
 			fmt.Sprintf("Failed to parse Dilithium3 public key: %v", err),
 		)
 	}
 
-	return &pubKey, nil
+	return compositePublicFromPEM(&pubKey, pubPEM)
 }
 
-// LoadDilithiumPrivateKey 只加载 Dilithium 私钥.
-func LoadDilithiumPrivateKey(privPath string) (*mode3.PrivateKey, error) {
-	// #nosec G304 - 调用方应验证路径安全性
-	privPEM, err := os.ReadFile(privPath)
-	if err != nil {
-		return nil, fmt.Errorf("read Dilithium private key file: %w", err)
-	}
+// LoadDilithiumPrivateKey 只加载 Dilithium 私钥文件；其他来源见 LoadDilithiumPrivateKeyFrom.
+func LoadDilithiumPrivateKey(privPath string) (SigningKey, error) {
+	return LoadDilithiumPrivateKeyFrom(&FileKeyProvider{Path: privPath})
+}
 
-	// 解析私钥
-	privBlock, _ := pem.Decode(privPEM)
-	if privBlock == nil || privBlock.Type != "DILITHIUM3 PRIVATE KEY" {
+// parseDilithiumPrivateKey 解析 Dilithium3 私钥，接受本工具的 PEM 格式、PKCS#8 PEM 或 DER；
+// 本工具格式含种子块时返回保留种子的私钥，含 Ed25519 块时返回复合私钥.
+func parseDilithiumPrivateKey(privPEM []byte) (SigningKey, error) {
+	raw, der, ok := decodeSingleKey(privPEM, "DILITHIUM3 PRIVATE KEY", pemTypePrivateKey)
+	if !ok {
 		return nil, utils.NewCryptoError(
 			utils.ErrInvalidKey,
 			"Invalid Dilithium3 private key PEM",
 		)
 	}
+	if der != nil {
+		// der 可能是调用方的缓冲区，只清零 PEM 解码产生的副本
+		if len(der) > 0 && &der[0] != &privPEM[0] {
+			defer clear(der)
+		}
+		key, err := ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, err
+		}
+		priv, ok := key.(*mode3.PrivateKey)
+		if !ok {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Not a Dilithium3 private key")
+		}
+		return priv, nil
+	}
+	defer clear(raw)
 	var privKey mode3.PrivateKey
-	if err := privKey.UnmarshalBinary(privBlock.Bytes); err != nil {
+	if err := privKey.UnmarshalBinary(raw); err != nil {
 		return nil, utils.NewCryptoError(
 			utils.ErrInvalidKey,
 			fmt.Sprintf("Failed to parse Dilithium3 private key: %v", err),
 		)
 	}
 
-	return &privKey, nil
-}
-
-// checkCacheSize 检查缓存大小，如果超过限制则清理最旧的条目.
-func checkCacheSize() {
-	count := 0
-	keyCache.Range(func(_, _ interface{}) bool {
-		count++
-		return true
-	})
-
-	if count >= MaxCacheSize {
-		// 清理最旧的条目（简化策略：清理20%）
-		toDelete := count / 5
-		if toDelete == 0 {
-			toDelete = 1
-		}
-
-		deleted := 0
-		now := time.Now()
-		keyCache.Range(func(key, value interface{}) bool {
-			if deleted >= toDelete {
-				return false
-			}
-			entry := value.(*keyCacheEntry)
-			// 优先清理过期的，然后清理最旧的
-			if now.Sub(entry.createdAt) >= entry.ttl || deleted < toDelete {
-				keyCache.Delete(key)
-				deleted++
-			}
-			return true
-		})
-	}
-}
-
-// LoadPublicKeyCached 带缓存的公钥加载（支持TTL和大小限制）
-// 第一次加载时从文件读取并缓存，后续直接返回缓存结果.
-func LoadPublicKeyCached(path string) (*HybridPublicKey, error) {
-	cacheKey := "pub:" + path
-	if cached, ok := keyCache.Load(cacheKey); ok {
-		entry := cached.(*keyCacheEntry)
-		if time.Since(entry.createdAt) < entry.ttl {
-			return entry.key.(*HybridPublicKey), nil
-		}
-		// 已过期，删除
-		keyCache.Delete(cacheKey)
-	}
-
-	// 检查缓存大小
-	checkCacheSize()
-
-	key, err := LoadPublicKey(path)
+	seeded, err := dilithiumSeedFromPEM(&privKey, privPEM)
 	if err != nil {
 		return nil, err
 	}
+	return compositePrivateFromPEM(seeded, privPEM)
+}
 
-	keyCache.Store(cacheKey, &keyCacheEntry{
-		key:       key,
-		createdAt: time.Now(),
-		ttl:       DefaultCacheTTL,
-	})
-	return key, nil
+// LoadPublicKeyCached 通过 DefaultKeyCache 加载公钥.
+// 第一次加载时从文件读取并缓存，文件变化或过期后重新读取.
+func LoadPublicKeyCached(path string) (*HybridPublicKey, error) {
+	return DefaultKeyCache().LoadPublicKey(path)
 }
 
-// LoadPrivateKeyCached 带缓存的私钥加载（支持TTL和大小限制）.
+// LoadPrivateKeyCached 通过 DefaultKeyCache 加载私钥.
 func LoadPrivateKeyCached(path string) (*HybridPrivateKey, error) {
-	cacheKey := "priv:" + path
-	if cached, ok := keyCache.Load(cacheKey); ok {
-		entry := cached.(*keyCacheEntry)
-		if time.Since(entry.createdAt) < entry.ttl {
-			return entry.key.(*HybridPrivateKey), nil
-		}
-		keyCache.Delete(cacheKey)
-	}
-
-	checkCacheSize()
-
-	key, err := LoadPrivateKey(path)
-	if err != nil {
-		return nil, err
-	}
-
-	keyCache.Store(cacheKey, &keyCacheEntry{
-		key:       key,
-		createdAt: time.Now(),
-		ttl:       DefaultCacheTTL,
-	})
-	return key, nil
+	return DefaultKeyCache().LoadPrivateKey(path)
 }
 
-// LoadDilithiumPublicKeyCached 带缓存的 Dilithium 公钥加载（支持TTL和大小限制）.
-func LoadDilithiumPublicKeyCached(path string) (*mode3.PublicKey, error) {
-	cacheKey := "dilithium_pub:" + path
-	if cached, ok := keyCache.Load(cacheKey); ok {
-		entry := cached.(*keyCacheEntry)
-		if time.Since(entry.createdAt) < entry.ttl {
-			pubKey, ok := entry.key.(*mode3.PublicKey)
-			if ok {
-				return pubKey, nil
-			}
-		}
-		keyCache.Delete(cacheKey)
-	}
-
-	checkCacheSize()
-
-	key, err := LoadDilithiumPublicKey(path)
-	if err != nil {
-		return nil, err
-	}
-
-	keyCache.Store(cacheKey, &keyCacheEntry{
-		key:       key,
-		createdAt: time.Now(),
-		ttl:       DefaultCacheTTL,
-	})
-	return key, nil
+// LoadDilithiumPublicKeyCached 通过 DefaultKeyCache 加载 Dilithium 公钥.
+func LoadDilithiumPublicKeyCached(path string) (VerifyingKey, error) {
+	return DefaultKeyCache().LoadDilithiumPublicKey(path)
 }
 
-// LoadDilithiumPrivateKeyCached 带缓存的 Dilithium 私钥加载（支持TTL和大小限制）.
-func LoadDilithiumPrivateKeyCached(path string) (*mode3.PrivateKey, error) {
-	cacheKey := "dilithium_priv:" + path
-	if cached, ok := keyCache.Load(cacheKey); ok {
-		entry := cached.(*keyCacheEntry)
-		if time.Since(entry.createdAt) < entry.ttl {
-			privKey, ok := entry.key.(*mode3.PrivateKey)
-			if ok {
-				return privKey, nil
-			}
-		}
-		keyCache.Delete(cacheKey)
-	}
-
-	checkCacheSize()
-
-	key, err := LoadDilithiumPrivateKey(path)
-	if err != nil {
-		return nil, err
-	}
-
-	keyCache.Store(cacheKey, &keyCacheEntry{
-		key:       key,
-		createdAt: time.Now(),
-		ttl:       DefaultCacheTTL,
-	})
-	return key, nil
+// LoadDilithiumPrivateKeyCached 通过 DefaultKeyCache 加载 Dilithium 私钥.
+func LoadDilithiumPrivateKeyCached(path string) (SigningKey, error) {
+	return DefaultKeyCache().LoadDilithiumPrivateKey(path)
 }
 
-// ClearKeyCache 清空密钥缓存
+// ClearKeyCache 清空 DefaultKeyCache
 // 用于测试或手动清理缓存.
 func ClearKeyCache() {
-	keyCache.Range(func(key, _ interface{}) bool {
-		keyCache.Delete(key)
-		return true
-	})
+	DefaultKeyCache().Clear()
 }
 
-// GetCacheSize 获取当前缓存的密钥数量.
+// GetCacheSize 获取 DefaultKeyCache 中的密钥数量.
 func GetCacheSize() int {
-	count := 0
-	keyCache.Range(func(_, _ interface{}) bool {
-		count++
-		return true
-	})
-	return count
+	return DefaultKeyCache().Len()
 }
 
-// GetCacheInfo 获取缓存详细信息
+// GetCacheInfo 获取 DefaultKeyCache 详细信息
 // 返回：总条目数、已过期条目数、总大小（字节估算）.
 func GetCacheInfo() (total int, expired int, estimatedSize int) {
-	now := time.Now()
-	keyCache.Range(func(_, value interface{}) bool {
-		total++
-		entry := value.(*keyCacheEntry)
-		if now.Sub(entry.createdAt) >= entry.ttl {
-			expired++
-		}
-		// 估算大小：每个条目约 100 字节 + 密钥大小
-		estimatedSize += 100
-		return true
-	})
-	return total, expired, estimatedSize
+	return DefaultKeyCache().Info()
 }
 
 // SaveDilithiumKeys 保存 Dilithium3 密钥对到文件.
-func SaveDilithiumKeys(pub *mode3.PublicKey, priv *mode3.PrivateKey, pubPath, privPath string) error {
+func SaveDilithiumKeys(pub VerifyingKey, priv SigningKey, pubPath, privPath string) error {
 	keyPair, err := ExportDilithiumKeys(pub, priv)
 	if err != nil {
 		return err
@@ -514,3 +365,15 @@ func SaveDilithiumKeys(pub *mode3.PublicKey, priv *mode3.PrivateKey, pubPath, pr
 
 	return nil
 }
+
+// RecipientFingerprint 计算接收方混合公钥的 SHA256 指纹（十六进制）.
+func RecipientFingerprint(kyberPub kem.PublicKey, ecdhPub *ecdh.PublicKey) (string, error) {
+	kyberBytes, err := kyberPub.MarshalBinary()
+	if err != nil {
+		return "", fmt.Errorf("marshal kyber public key: %w", err)
+	}
+	h := sha256.New()
+	h.Write(kyberBytes)
+	h.Write(ecdhPub.Bytes())
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
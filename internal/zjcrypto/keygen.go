@@ -23,6 +23,8 @@ type HybridPublicKey struct {
 type HybridPrivateKey struct {
 	Kyber kem.PrivateKey
 	ECDH  *ecdh.PrivateKey
+	// KyberSeed 生成 Kyber 私钥的 64 字节种子，未知时为 nil（见 keyseed.go）.
+	KyberSeed []byte
 }
 
 // GenerateKyberKeys 生成Kyber密钥对.
@@ -38,6 +40,22 @@ func GenerateKyberKeys() (kem.PublicKey, kem.PrivateKey, error) {
 	return pub, priv, nil
 }
 
+// GenerateSeededKyberKeys 由随机种子生成Kyber密钥对，同时返回 64 字节种子.
+func GenerateSeededKyberKeys() (kem.PublicKey, kem.PrivateKey, []byte, error) {
+	seed := make([]byte, kyber768.KeySeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, nil, nil, utils.NewCryptoError(
+			utils.ErrKeyGenerationFailed,
+			fmt.Sprintf("Kyber key generation failed: %v", err),
+		)
+	}
+	pub, priv, err := kyberFromSeed(seed)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return pub, priv, seed, nil
+}
+
 // GenerateECDHKeys 生成ECDH密钥对.
 func GenerateECDHKeys() (*ecdh.PublicKey, *ecdh.PrivateKey, error) {
 	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
@@ -106,6 +124,15 @@ func ExportPrivateKey(kyberPriv kem.PrivateKey, ecdhPriv *ecdh.PrivateKey) ([]by
 	return combined, nil
 }
 
+// ExportHybridPrivateKey 导出混合私钥到PEM格式，KyberSeed 存在时追加种子块.
+func ExportHybridPrivateKey(priv *HybridPrivateKey) ([]byte, error) {
+	combined, err := ExportPrivateKey(priv.Kyber, priv.ECDH)
+	if err != nil || priv.KyberSeed == nil {
+		return combined, err
+	}
+	return append(combined, pem.EncodeToMemory(&pem.Block{Type: pemTypeKyberSeed, Bytes: priv.KyberSeed})...), nil
+}
+
 // ImportKeys 从PEM导入密钥.
 func ImportKeys(pubPEM, privPEM []byte) (*HybridPublicKey, *HybridPrivateKey, error) {
 	// 解析公钥
@@ -115,17 +142,24 @@ func ImportKeys(pubPEM, privPEM []byte) (*HybridPublicKey, *HybridPrivateKey, er
 	}
 
 	// 解析私钥
-	privKyber, privECDH, err := parsePrivateKeys(privPEM)
+	priv, err := parseHybridPrivateKey(privPEM)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return &HybridPublicKey{Kyber: pubKyber, ECDH: pubECDH},
-		&HybridPrivateKey{Kyber: privKyber, ECDH: privECDH},
-		nil
+	return &HybridPublicKey{Kyber: pubKyber, ECDH: pubECDH}, priv, nil
+}
+
+// ImportPublicKey 从PEM导入混合公钥.
+func ImportPublicKey(pubPEM []byte) (*HybridPublicKey, error) {
+	pubKyber, pubECDH, err := parsePublicKeys(pubPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &HybridPublicKey{Kyber: pubKyber, ECDH: pubECDH}, nil
 }
 
-// 辅助函数：解析公钥.
+// 辅助函数：解析公钥，接受本工具的 PEM 块或 "PUBLIC KEY"（SubjectPublicKeyInfo）块.
 func parsePublicKeys(pemData []byte) (kem.PublicKey, *ecdh.PublicKey, error) {
 	var kyberKey kem.PublicKey
 	var ecdhKey *ecdh.PublicKey
@@ -159,6 +193,20 @@ func parsePublicKeys(pemData []byte) (kem.PublicKey, *ecdh.PublicKey, error) {
 				)
 			}
 			ecdhKey = pub
+
+		case pemTypePublicKey:
+			key, err := ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch k := key.(type) {
+			case *ecdh.PublicKey:
+				ecdhKey = k
+			case kem.PublicKey:
+				kyberKey = k
+			default:
+				return nil, nil, utils.NewCryptoError(utils.ErrInvalidKey, "Not a hybrid public key component")
+			}
 		}
 
 		rest = next
@@ -174,7 +222,20 @@ func parsePublicKeys(pemData []byte) (kem.PublicKey, *ecdh.PublicKey, error) {
 	return kyberKey, ecdhKey, nil
 }
 
-// 辅助函数：解析私钥.
+// parseHybridPrivateKey 解析混合私钥及可选的 Kyber 种子块.
+func parseHybridPrivateKey(pemData []byte) (*HybridPrivateKey, error) {
+	kyberPriv, ecdhPriv, err := parsePrivateKeys(pemData)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := kyberSeedFromPEM(pemData, kyberPriv)
+	if err != nil {
+		return nil, err
+	}
+	return &HybridPrivateKey{Kyber: kyberPriv, ECDH: ecdhPriv, KyberSeed: seed}, nil
+}
+
+// 辅助函数：解析私钥，接受本工具的 PEM 块或 "PRIVATE KEY"（PKCS#8）块.
 func parsePrivateKeys(pemData []byte) (kem.PrivateKey, *ecdh.PrivateKey, error) {
 	var kyberKey kem.PrivateKey
 	var ecdhKey *ecdh.PrivateKey
@@ -198,6 +259,7 @@ func parsePrivateKeys(pemData []byte) (kem.PrivateKey, *ecdh.PrivateKey, error)
 				)
 			}
 			kyberKey = priv
+			clear(block.Bytes)
 
 		case "ECDH PRIVATE KEY":
 			priv, err := ecdh.X25519().NewPrivateKey(block.Bytes)
@@ -208,6 +270,22 @@ func parsePrivateKeys(pemData []byte) (kem.PrivateKey, *ecdh.PrivateKey, error)
 				)
 			}
 			ecdhKey = priv
+			clear(block.Bytes)
+
+		case pemTypePrivateKey:
+			key, err := ParsePKCS8PrivateKey(block.Bytes)
+			clear(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch k := key.(type) {
+			case *ecdh.PrivateKey:
+				ecdhKey = k
+			case kem.PrivateKey:
+				kyberKey = k
+			default:
+				return nil, nil, utils.NewCryptoError(utils.ErrInvalidKey, "Not a hybrid private key component")
+			}
 		}
 
 		rest = next
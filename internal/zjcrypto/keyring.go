@@ -0,0 +1,148 @@
+package zjcrypto
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// 密钥环目录中的文件名后缀，与 keygen 命令的输出一致.
+const (
+	keyringPublicSuffix           = "_public.pem"
+	keyringPrivateSuffix          = "_private.pem"
+	keyringDilithiumPublicSuffix  = "_dilithium_public.pem"
+	keyringDilithiumPrivateSuffix = "_dilithium_private.pem"
+
+	// MinFingerprintPrefix 按指纹前缀查找密钥时的最短前缀长度.
+	MinFingerprintPrefix = 8
+)
+
+// KeyringEntry 密钥环中以同一名称生成的一组密钥，缺失的私钥或签名密钥为 nil.
+type KeyringEntry struct {
+	Name          string
+	Fingerprint   string
+	Public        *HybridPublicKey
+	Private       *HybridPrivateKey
+	DilithiumPub  VerifyingKey
+	DilithiumPriv SigningKey
+}
+
+// Keyring 从目录加载的密钥集合，按混合公钥指纹索引.
+type Keyring struct {
+	entries []*KeyringEntry
+}
+
+// LoadKeyring 加载目录中由 keygen 生成的所有密钥.
+// 每个 <name>_public.pem 构成一个条目，同名的私钥和 Dilithium 密钥存在时一并加载.
+func LoadKeyring(dir string) (*Keyring, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read keyring: %w", err)
+	}
+
+	keyring := &Keyring{}
+	seen := make(map[string]string)
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), keyringPublicSuffix)
+		if !ok || file.IsDir() || strings.HasSuffix(name, "_dilithium") {
+			continue
+		}
+		entry, err := loadKeyringEntry(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[entry.Fingerprint]; ok {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+				fmt.Sprintf("Duplicate key in keyring: %s and %s", other, name))
+		}
+		seen[entry.Fingerprint] = name
+		keyring.entries = append(keyring.entries, entry)
+	}
+	if len(keyring.entries) == 0 {
+		return nil, utils.NewCryptoError(utils.ErrFileNotFound, "No keys found in keyring: "+dir)
+	}
+	sort.Slice(keyring.entries, func(i, j int) bool {
+		return keyring.entries[i].Name < keyring.entries[j].Name
+	})
+	return keyring, nil
+}
+
+func loadKeyringEntry(dir, name string) (*KeyringEntry, error) {
+	base := filepath.Join(dir, name)
+	pub, err := LoadPublicKey(base + keyringPublicSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", name, err)
+	}
+	fingerprint, err := RecipientFingerprint(pub.Kyber, pub.ECDH)
+	if err != nil {
+		return nil, err
+	}
+	entry := &KeyringEntry{Name: name, Fingerprint: fingerprint, Public: pub}
+
+	if path := base + keyringPrivateSuffix; fileExists(path) {
+		if entry.Private, err = LoadPrivateKey(path); err != nil {
+			return nil, fmt.Errorf("load %s: %w", name, err)
+		}
+	}
+	if path := base + keyringDilithiumPublicSuffix; fileExists(path) {
+		if entry.DilithiumPub, err = LoadDilithiumPublicKey(path); err != nil {
+			return nil, fmt.Errorf("load %s: %w", name, err)
+		}
+	}
+	if path := base + keyringDilithiumPrivateSuffix; fileExists(path) {
+		if entry.DilithiumPriv, err = LoadDilithiumPrivateKey(path); err != nil {
+			return nil, fmt.Errorf("load %s: %w", name, err)
+		}
+	}
+	return entry, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Entries 返回按名称排序的所有条目.
+func (k *Keyring) Entries() []*KeyringEntry {
+	return k.entries
+}
+
+// Lookup 按名称或指纹前缀（至少 MinFingerprintPrefix 个字符）查找条目.
+// ref 为空且密钥环只有一个条目时返回该条目.
+func (k *Keyring) Lookup(ref string) (*KeyringEntry, error) {
+	if ref == "" {
+		if len(k.entries) == 1 {
+			return k.entries[0], nil
+		}
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter,
+			"Keyring has multiple keys, a key must be selected")
+	}
+
+	for _, entry := range k.entries {
+		if entry.Name == ref {
+			return entry, nil
+		}
+	}
+
+	ref = strings.ToLower(ref)
+	var found *KeyringEntry
+	if len(ref) >= MinFingerprintPrefix {
+		for _, entry := range k.entries {
+			if !strings.HasPrefix(entry.Fingerprint, ref) {
+				continue
+			}
+			if found != nil {
+				return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Ambiguous key fingerprint: "+ref)
+			}
+			found = entry
+		}
+	}
+	if found == nil {
+		return nil, utils.NewCryptoError(utils.ErrFileNotFound, "Key not found in keyring: "+ref)
+	}
+	return found, nil
+}
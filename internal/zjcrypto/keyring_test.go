@@ -0,0 +1,82 @@
+package zjcrypto
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeKeyringEntry 以 keygen 的命名方式保存一组测试密钥.
+func writeKeyringEntry(t *testing.T, dir, name string, keys testKeys, withPrivate bool) {
+	t.Helper()
+	base := filepath.Join(dir, name)
+	if err := SaveKeyFiles(keys.kyberPub, keys.ecdhPub, keys.kyberPriv, keys.ecdhPriv,
+		base+"_public.pem", base+"_private.pem"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveDilithiumKeys(keys.dilithiumPub, keys.dilithiumPriv,
+		base+"_dilithium_public.pem", base+"_dilithium_private.pem"); err != nil {
+		t.Fatal(err)
+	}
+	if !withPrivate {
+		_ = os.Remove(base + "_private.pem")
+		_ = os.Remove(base + "_dilithium_private.pem")
+	}
+}
+
+func TestKeyring(t *testing.T) {
+	dir := t.TempDir()
+	alice, bob := generateTestKeys(t), generateTestKeys(t)
+	writeKeyringEntry(t, dir, "alice", alice, true)
+	writeKeyringEntry(t, dir, "bob", bob, false)
+
+	keyring, err := LoadKeyring(dir)
+	if err != nil {
+		t.Fatalf("LoadKeyring 失败: %v", err)
+	}
+	entries := keyring.Entries()
+	if len(entries) != 2 || entries[0].Name != "alice" || entries[1].Name != "bob" {
+		t.Fatalf("条目 = %+v", entries)
+	}
+	if entries[0].Private == nil || entries[0].DilithiumPriv == nil {
+		t.Error("alice 的私钥未加载")
+	}
+	if entries[1].Private != nil || entries[1].DilithiumPriv != nil || entries[1].DilithiumPub == nil {
+		t.Error("bob 只应加载公钥")
+	}
+	aliceFP, _ := RecipientFingerprint(alice.kyberPub, alice.ecdhPub)
+	if entries[0].Fingerprint != aliceFP {
+		t.Errorf("指纹 = %s, 期望 %s", entries[0].Fingerprint, aliceFP)
+	}
+
+	for _, ref := range []string{"alice", aliceFP, aliceFP[:MinFingerprintPrefix]} {
+		if entry, err := keyring.Lookup(ref); err != nil || entry.Name != "alice" {
+			t.Errorf("Lookup(%q) = %v, %v", ref, entry, err)
+		}
+	}
+	for _, ref := range []string{"", "carol", aliceFP[:MinFingerprintPrefix-1]} {
+		if _, err := keyring.Lookup(ref); err == nil {
+			t.Errorf("Lookup(%q) 期望失败", ref)
+		}
+	}
+
+	// 单个条目时可省略选择
+	single := t.TempDir()
+	writeKeyringEntry(t, single, "alice", alice, true)
+	keyring, err = LoadKeyring(single)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := keyring.Lookup(""); err != nil || entry.Name != "alice" {
+		t.Errorf("Lookup(\"\") = %v, %v", entry, err)
+	}
+
+	// 重复的公钥和空目录被拒绝
+	writeKeyringEntry(t, single, "alias", alice, false)
+	if _, err := LoadKeyring(single); err == nil {
+		t.Error("期望重复密钥报错")
+	}
+	if _, err := LoadKeyring(t.TempDir()); err == nil {
+		t.Error("期望空密钥环报错")
+	}
+}
@@ -0,0 +1,75 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"fmt"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+// 私钥文件可在原有 PEM 块之后追加生成该私钥的种子：混合私钥为 64 字节 Kyber768 种子，
+// Dilithium3 私钥为 32 字节种子. 种子让纸质备份只需抄写几行（见 PaperKey）；
+// 加载时由种子重建密钥并与展开的密钥比对. 旧版本会忽略这些块.
+//
+// circl 从展开的字节还原的私钥不含种子，因此没有种子块的旧密钥文件只能备份展开的密钥.
+const (
+	pemTypeKyberSeed     = "KYBER SEED"
+	pemTypeDilithiumSeed = "DILITHIUM3 SEED"
+)
+
+// kyberFromSeed 由 64 字节种子重建 Kyber768 密钥对.
+func kyberFromSeed(seed []byte) (kem.PublicKey, kem.PrivateKey, error) {
+	if len(seed) != kyber768.KeySeedSize {
+		return nil, nil, utils.NewCryptoError(utils.ErrInvalidKey,
+			fmt.Sprintf("Invalid Kyber seed size: %d", len(seed)))
+	}
+	pub, priv := kyber768.Scheme().DeriveKeyPair(seed)
+	return pub, priv, nil
+}
+
+// dilithiumFromSeed 由 32 字节种子重建 Dilithium3 私钥，返回的私钥保留种子.
+func dilithiumFromSeed(seed []byte) (*mode3.PrivateKey, error) {
+	if len(seed) != mode3.SeedSize {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+			fmt.Sprintf("Invalid Dilithium3 seed size: %d", len(seed)))
+	}
+	_, priv := mode3.NewKeyFromSeed((*[mode3.SeedSize]byte)(seed))
+	return priv, nil
+}
+
+// kyberSeedFromPEM 返回 data 中与 priv 匹配的 Kyber 种子；没有种子块时返回 nil.
+func kyberSeedFromPEM(data []byte, priv kem.PrivateKey) ([]byte, error) {
+	block := findPEMBlock(data, pemTypeKyberSeed)
+	if block == nil {
+		return nil, nil
+	}
+	defer clear(block.Bytes)
+	_, derived, err := kyberFromSeed(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if !derived.Equal(priv) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Kyber seed does not match the private key")
+	}
+	return bytes.Clone(block.Bytes), nil
+}
+
+// dilithiumSeedFromPEM 在 data 含与 priv 匹配的种子块时返回由种子重建、保留种子的私钥，否则返回 priv.
+func dilithiumSeedFromPEM(priv *mode3.PrivateKey, data []byte) (*mode3.PrivateKey, error) {
+	block := findPEMBlock(data, pemTypeDilithiumSeed)
+	if block == nil {
+		return priv, nil
+	}
+	defer clear(block.Bytes)
+	derived, err := dilithiumFromSeed(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if !derived.Equal(priv) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Dilithium3 seed does not match the private key")
+	}
+	return derived, nil
+}
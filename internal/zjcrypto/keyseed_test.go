@@ -0,0 +1,149 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+// TestKeySeedPersistence 私钥文件中的种子在直接加载和经缓存加载后都保留.
+func TestKeySeedPersistence(t *testing.T) {
+	kyberPub, kyberPriv, kyberSeed, err := GenerateSeededKyberKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdhPub, ecdhPriv, err := GenerateECDHKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signPub, signPriv, err := GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	compositePub, compositePriv, err := GenerateCompositeKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	pubPath, privPath := filepath.Join(dir, "public.pem"), filepath.Join(dir, "private.pem")
+	if err := SaveHybridKeyFiles(
+		&HybridPublicKey{Kyber: kyberPub, ECDH: ecdhPub},
+		&HybridPrivateKey{Kyber: kyberPriv, ECDH: ecdhPriv, KyberSeed: kyberSeed},
+		pubPath, privPath,
+	); err != nil {
+		t.Fatal(err)
+	}
+	signPubPath, signPrivPath := filepath.Join(dir, "sign_public.pem"), filepath.Join(dir, "sign_private.pem")
+	if err := SaveDilithiumKeys(signPub, signPriv, signPubPath, signPrivPath); err != nil {
+		t.Fatal(err)
+	}
+	compPubPath, compPrivPath := filepath.Join(dir, "comp_public.pem"), filepath.Join(dir, "comp_private.pem")
+	if err := SaveDilithiumKeys(compositePub, compositePriv, compPubPath, compPrivPath); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, _ := newTestKeyCache(t, KeyCacheConfig{})
+	for name, load := range map[string]func(string) (*HybridPrivateKey, error){
+		"direct": LoadPrivateKey, "cached": cache.LoadPrivateKey,
+	} {
+		priv, err := load(privPath)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(priv.KyberSeed, kyberSeed) || !priv.Kyber.Equal(kyberPriv) {
+			t.Fatalf("%s: Kyber 种子未保留", name)
+		}
+	}
+	for name, load := range map[string]func(string) (SigningKey, error){
+		"direct": LoadDilithiumPrivateKey, "cached": cache.LoadDilithiumPrivateKey,
+	} {
+		priv, err := load(signPrivPath)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		k, ok := priv.(*mode3.PrivateKey)
+		if !ok || !bytes.Equal(k.Seed(), signPriv.Seed()) || !k.Equal(signPriv) {
+			t.Fatalf("%s: Dilithium3 种子未保留", name)
+		}
+		priv, err = load(compPrivPath)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		c, ok := priv.(*CompositePrivateKey)
+		if !ok || !bytes.Equal(c.Dilithium.Seed(), compositePriv.Dilithium.Seed()) || !c.Equal(compositePriv) {
+			t.Fatalf("%s: 复合私钥的 Dilithium3 种子未保留", name)
+		}
+	}
+}
+
+// TestKeySeedWithoutSeedBlock 不含种子块的旧私钥文件照常加载，种子为空.
+func TestKeySeedWithoutSeedBlock(t *testing.T) {
+	kyberPub, kyberPriv, _ := GenerateKyberKeys()
+	ecdhPub, ecdhPriv, _ := GenerateECDHKeys()
+	dir := t.TempDir()
+	pubPath, privPath := filepath.Join(dir, "public.pem"), filepath.Join(dir, "private.pem")
+	if err := SaveKeyFiles(kyberPub, ecdhPub, kyberPriv, ecdhPriv, pubPath, privPath); err != nil {
+		t.Fatal(err)
+	}
+	priv, err := LoadPrivateKey(privPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.KyberSeed != nil {
+		t.Fatal("没有种子块时 KyberSeed 应为 nil")
+	}
+
+	_, signPriv, _ := GenerateDilithiumKeys()
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "DILITHIUM3 PRIVATE KEY", Bytes: signPriv.Bytes()})
+	loaded, err := parseDilithiumPrivateKey(pemData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.(*mode3.PrivateKey).Seed() != nil || !loaded.Equal(signPriv) {
+		t.Fatal("没有种子块时应返回不含种子的私钥")
+	}
+}
+
+// TestKeySeedMismatch 与展开的私钥不一致的种子块被拒绝.
+func TestKeySeedMismatch(t *testing.T) {
+	_, kyberPriv, _, err := GenerateSeededKyberKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ecdhPriv, _ := GenerateECDHKeys()
+	_, _, otherSeed, err := GenerateSeededKyberKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ExportHybridPrivateKey(&HybridPrivateKey{Kyber: kyberPriv, ECDH: ecdhPriv, KyberSeed: otherSeed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseHybridPrivateKey(data); err == nil {
+		t.Fatal("不匹配的 Kyber 种子应被拒绝")
+	}
+
+	_, signPriv, _ := GenerateDilithiumKeys()
+	_, other, _ := GenerateDilithiumKeys()
+	data = pem.EncodeToMemory(&pem.Block{Type: "DILITHIUM3 PRIVATE KEY", Bytes: signPriv.Bytes()})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: pemTypeDilithiumSeed, Bytes: other.Seed()})...)
+	if _, err := parseDilithiumPrivateKey(data); err == nil {
+		t.Fatal("不匹配的 Dilithium3 种子应被拒绝")
+	}
+
+	// 截断的种子块
+	path := filepath.Join(t.TempDir(), "private.pem")
+	data = pem.EncodeToMemory(&pem.Block{Type: "DILITHIUM3 PRIVATE KEY", Bytes: signPriv.Bytes()})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: pemTypeDilithiumSeed, Bytes: signPriv.Seed()[:16]})...)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDilithiumPrivateKey(path); err == nil {
+		t.Fatal("长度错误的种子块应被拒绝")
+	}
+}
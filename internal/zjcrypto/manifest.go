@@ -0,0 +1,96 @@
+package zjcrypto
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// SignManifest 序列化清单并附加 Dilithium 签名.
+func SignManifest(manifest *format.Manifest, key SigningKey) ([]byte, error) {
+	body, err := manifest.MarshalText()
+	if err != nil {
+		return nil, fmt.Errorf("marshal manifest: %w", err)
+	}
+	signature, err := SignDataWithKey(body, key)
+	if err != nil {
+		return nil, err
+	}
+	return format.EncodeSignedManifest(body, signature), nil
+}
+
+// ParseSignedManifest 解析清单文件内容，pubKey 非空时验证签名.
+func ParseSignedManifest(data []byte, pubKey VerifyingKey) (*format.Manifest, error) {
+	body, signature, err := format.DecodeSignedManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	if pubKey != nil {
+		valid, err := VerifySignatureWithKey(body, signature, pubKey)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			return nil, utils.NewCryptoError(utils.ErrSignatureVerification, "Manifest signature verification failed")
+		}
+	}
+
+	var manifest format.Manifest
+	if err := manifest.UnmarshalText(body); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// ReadTreeManifest 读取解压目录根部保存的清单，pubKey 非空时验证签名.
+// 目录中没有清单时返回 os.ErrNotExist.
+func ReadTreeManifest(dir string, pubKey VerifyingKey) (*format.Manifest, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open directory root: %w", err)
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+
+	file, err := root.Open(format.ManifestName)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	// 清单每个条目只有一行，16MB 足以容纳数十万条目
+	data, err := io.ReadAll(io.LimitReader(file, 16<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	return ParseSignedManifest(data, pubKey)
+}
+
+// CheckTreeAgainstManifest 按清单重新计算目录中每个文件的哈希并比较.
+// 仅匹配 only 模式的清单条目和目录路径参与比较；清单未记录修改时间，只比较内容和权限.
+func CheckTreeAgainstManifest(manifest *format.Manifest, dir string, only []string) (*TreeDiffReport, error) {
+	if err := ValidateZipPatterns(only); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*verifyEntry, len(manifest.Entries))
+	for i := range manifest.Entries {
+		e := &manifest.Entries[i]
+		if !MatchZipPatterns(only, e.Name) {
+			continue
+		}
+		entries[e.Name] = &verifyEntry{
+			isDir:   e.IsDir,
+			mode:    e.Mode,
+			hasMode: true,
+			hash:    e.SHA256,
+		}
+	}
+	return compareWithDirectory(entries, dir, only)
+}
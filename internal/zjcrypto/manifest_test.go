@@ -0,0 +1,142 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+)
+
+func TestZipManifestRoundTrip(t *testing.T) {
+	sourceDir, files := createIndexedTestDir(t)
+	keys := generateTestKeys(t)
+
+	opts := DefaultArchiveOptions
+	opts.ManifestKey = keys.dilithiumPriv
+	var buf bytes.Buffer
+	if err := CreateZipFromDirectory(sourceDir, &buf, opts); err != nil {
+		t.Fatalf("创建ZIP失败: %v", err)
+	}
+
+	// 只解压部分条目，清单仍应被解压
+	targetDir := t.TempDir()
+	extractOpts := DefaultExtractOptions
+	extractOpts.Only = []string{"docs/**"}
+	if err := ExtractZipToDirectoryWithOptions(buf.Bytes(), targetDir, extractOpts); err != nil {
+		t.Fatalf("解压失败: %v", err)
+	}
+
+	manifest, err := ReadTreeManifest(targetDir, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("读取清单失败: %v", err)
+	}
+	var fileCount int
+	for _, e := range manifest.Entries {
+		if !e.IsDir {
+			fileCount++
+		}
+	}
+	if fileCount != len(files) {
+		t.Errorf("清单应包含 %d 个文件，实际 %d", len(files), fileCount)
+	}
+
+	report, err := CheckTreeAgainstManifest(manifest, targetDir, extractOpts.Only)
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if report.HasDifferences() || report.Verified != 2 {
+		t.Fatalf("选中的条目应全部一致: verified=%d diffs=%+v", report.Verified, report.Diffs)
+	}
+
+	// 修改文件后应报告内容差异
+	if err := os.WriteFile(filepath.Join(targetDir, "docs", "intro.txt"), []byte("tampered"), 0600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	report, err = CheckTreeAgainstManifest(manifest, targetDir, extractOpts.Only)
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	assertTreeDiffs(t, report, map[string]DiffKind{"docs/intro.txt": DiffModified})
+
+	// 清单与源目录比较时不应被视为差异
+	report, err = VerifyZipAgainstDirectory(buf.Bytes(), sourceDir, DefaultExtractOptions)
+	if err != nil {
+		t.Fatalf("比较失败: %v", err)
+	}
+	if report.HasDifferences() {
+		t.Errorf("清单不应产生差异: %+v", report.Diffs)
+	}
+}
+
+func TestCheckTreeAgainstManifestOnly(t *testing.T) {
+	sourceDir, _ := createIndexedTestDir(t)
+	keys := generateTestKeys(t)
+
+	opts := DefaultArchiveOptions
+	opts.ManifestKey = keys.dilithiumPriv
+	var buf bytes.Buffer
+	if err := CreateZipFromDirectory(sourceDir, &buf, opts); err != nil {
+		t.Fatalf("创建ZIP失败: %v", err)
+	}
+	targetDir := t.TempDir()
+	if err := ExtractZipToDirectoryWithOptions(buf.Bytes(), targetDir, DefaultExtractOptions); err != nil {
+		t.Fatalf("解压失败: %v", err)
+	}
+	manifest, err := ReadTreeManifest(targetDir, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("读取清单失败: %v", err)
+	}
+
+	// 完整目录中不匹配 --only 的文件不应被报告为新增
+	only := []string{"docs/**"}
+	report, err := CheckTreeAgainstManifest(manifest, targetDir, only)
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if report.HasDifferences() || report.Verified != 2 {
+		t.Fatalf("匹配的条目应全部一致: verified=%d diffs=%+v", report.Verified, report.Diffs)
+	}
+
+	// 匹配范围内的新增文件仍应报告，范围外的修改不报告
+	if err := os.WriteFile(filepath.Join(targetDir, "docs", "new.txt"), []byte("new"), 0600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(targetDir, "README.md"), []byte("# changed"), 0600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	report, err = CheckTreeAgainstManifest(manifest, targetDir, only)
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	assertTreeDiffs(t, report, map[string]DiffKind{"docs/new.txt": DiffAdded})
+}
+
+func TestManifestSignatureVerification(t *testing.T) {
+	keys := generateTestKeys(t)
+	other := generateTestKeys(t)
+
+	manifest := &format.Manifest{Entries: []format.ManifestEntry{{Name: "a.txt", Size: 1, Mode: 0600}}}
+	data, err := SignManifest(manifest, keys.dilithiumPriv)
+	if err != nil {
+		t.Fatalf("签名清单失败: %v", err)
+	}
+
+	if _, err := ParseSignedManifest(data, keys.dilithiumPub); err != nil {
+		t.Errorf("正确的公钥应验证通过: %v", err)
+	}
+	if _, err := ParseSignedManifest(data, other.dilithiumPub); err == nil {
+		t.Error("错误的公钥应验证失败")
+	}
+
+	tampered := bytes.Replace(data, []byte(" 1 0600 "), []byte(" 2 0600 "), 1)
+	if _, err := ParseSignedManifest(tampered, keys.dilithiumPub); err == nil {
+		t.Error("被篡改的清单应验证失败")
+	}
+
+	if _, err := ReadTreeManifest(t.TempDir(), keys.dilithiumPub); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("目录中没有清单时应返回 os.ErrNotExist: %v", err)
+	}
+}
@@ -0,0 +1,667 @@
+package zjcrypto
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem"
+)
+
+// 镜像模式：源目录中的每个文件加密为输出目录中相同相对位置的独立 .fzj 文件.
+//
+// 名称加密使用每个镜像目录随机生成的名称密钥，密钥加密给接收方后以 MirrorNameKeyName 文件随密文分发.
+// 加密方把名称密钥保存在状态文件中，后续运行沿用同一密钥，因此未变化的文件名称保持不变.
+// 状态文件含有名称密钥，默认保存在用户配置目录而不是输出目录中.
+//
+// 每个密文头部记录其在镜像中的相对路径（不含 MirrorFileExt），并作为 AES-GCM 附加认证数据
+// （FlagFilenameBound）. 解密时路径必须与密文所在位置一致，交换或移动密文会被发现.
+const (
+	// MirrorNameKeyName 加密给接收方的名称密钥文件名（位于输出目录根部）.
+	MirrorNameKeyName = ".fzjjyz-mirror-names.fzj"
+	// MirrorFileExt 镜像密文文件扩展名.
+	MirrorFileExt = ".fzj"
+
+	mirrorReservedPrefix = ".fzjjyz-mirror"
+	mirrorStateVersion   = 3
+	mirrorNameKeySize    = 32
+	// maxMirrorNameLen 可加密的名称长度上限，保证加密后的名称不超过 255 字节.
+	maxMirrorNameLen = 128
+
+	mirrorNameEncLabel = "fzjjyz mirror name enc v1"
+	mirrorNameSIVLabel = "fzjjyz mirror name siv v1"
+)
+
+// mirrorNameEncoding 小写 base32，兼容大小写不敏感的文件系统.
+var mirrorNameEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// MirrorOptions 镜像加密选项.
+type MirrorOptions struct {
+	EncryptNames bool   // 确定性加密文件和目录名称
+	StatePath    string // 状态文件路径，为空时使用 DefaultMirrorStatePath；不能位于输出目录中
+}
+
+// DefaultMirrorOptions 默认镜像加密选项.
+var DefaultMirrorOptions = MirrorOptions{}
+
+// MirrorResult 镜像加密/解密的统计结果.
+type MirrorResult struct {
+	Processed int // 加密或解密的文件数
+	Skipped   int // 大小和修改时间未变化而跳过的文件数
+	Removed   int // 源文件已删除而移除的密文数
+}
+
+// mirrorState 上次镜像加密的记录，用于跳过未变化的文件.
+type mirrorState struct {
+	Version      int    `json:"version"`
+	Recipient    string `json:"recipient"`
+	EncryptNames bool   `json:"encrypt_names"`
+	// NameKey 名称密钥，未加密名称时为空
+	NameKey []byte                      `json:"name_key,omitempty"`
+	Files   map[string]mirrorStateEntry `json:"files"`
+}
+
+// mirrorStateEntry 单个源文件的记录.
+type mirrorStateEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Output  string `json:"output"`
+}
+
+// mirrorNameCipher 确定性名称加密（SIV 构造：Nonce 由名称的 HMAC 派生）.
+type mirrorNameCipher struct {
+	aead   cipher.AEAD
+	sivKey []byte
+}
+
+// newMirrorNameCipher 由名称主密钥派生加密和 SIV 子密钥.
+func newMirrorNameCipher(nameKey []byte) (*mirrorNameCipher, error) {
+	aead, err := deriveArchiveKey(nameKey, nil, mirrorNameEncLabel)
+	if err != nil {
+		return nil, err
+	}
+	sivKey, err := hkdf.Key(sha256.New, nameKey, nil, mirrorNameSIVLabel, 32)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrKeyGenerationFailed, "Key derivation failed: "+err.Error())
+	}
+	return &mirrorNameCipher{aead: aead, sivKey: sivKey}, nil
+}
+
+// siv 计算名称的确定性 Nonce.
+func (c *mirrorNameCipher) siv(name string) []byte {
+	mac := hmac.New(sha256.New, c.sivKey)
+	mac.Write([]byte(name))
+	return mac.Sum(nil)[:c.aead.NonceSize()]
+}
+
+// encryptName 加密单个路径段.
+func (c *mirrorNameCipher) encryptName(name string) (string, error) {
+	if len(name) > maxMirrorNameLen {
+		return "", utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Name too long for encryption: %s (max %d bytes)", name, maxMirrorNameLen),
+		)
+	}
+	nonce := c.siv(name)
+	sealed := c.aead.Seal(nonce, nonce, []byte(name), nil)
+	return mirrorNameEncoding.EncodeToString(sealed), nil
+}
+
+// decryptName 解密单个路径段并校验 Nonce 与名称匹配.
+func (c *mirrorNameCipher) decryptName(encoded string) (string, error) {
+	data, err := mirrorNameEncoding.DecodeString(encoded)
+	nonceSize := c.aead.NonceSize()
+	if err != nil || len(data) < nonceSize+c.aead.Overhead() {
+		return "", utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid encrypted name: "+encoded)
+	}
+	plain, err := c.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil || !hmac.Equal(c.siv(string(plain)), data[:nonceSize]) {
+		return "", utils.NewCryptoError(utils.ErrDecryptionFailed, "Encrypted name authentication failed: "+encoded)
+	}
+	return string(plain), nil
+}
+
+// mirrorPathMapper 在源路径与镜像路径之间转换.
+type mirrorPathMapper struct {
+	names *mirrorNameCipher // 为 nil 时不加密名称
+}
+
+// toMirror 将源相对路径转换为镜像相对路径，文件追加 MirrorFileExt.
+func (m *mirrorPathMapper) toMirror(rel string, isDir bool) (string, error) {
+	segments := strings.Split(rel, "/")
+	if m.names != nil {
+		for i, segment := range segments {
+			encrypted, err := m.names.encryptName(segment)
+			if err != nil {
+				return "", err
+			}
+			segments[i] = encrypted
+		}
+	}
+	out := strings.Join(segments, "/")
+	if !isDir {
+		out += MirrorFileExt
+	}
+	return out, nil
+}
+
+// fromMirror 将镜像相对路径转换回源相对路径，文件需去掉 MirrorFileExt 后传入.
+func (m *mirrorPathMapper) fromMirror(rel string) (string, error) {
+	segments := strings.Split(rel, "/")
+	for i, segment := range segments {
+		if m.names != nil {
+			plain, err := m.names.decryptName(segment)
+			if err != nil {
+				return "", err
+			}
+			segment = plain
+		}
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, "/\\") {
+			return "", utils.NewCryptoError(utils.ErrInvalidFormat, "Invalid path segment in mirror: "+rel)
+		}
+		segments[i] = segment
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// DefaultMirrorStatePath 返回 outputDir 的默认状态文件路径：
+// <用户配置目录>/fzjjyz/mirror/<输出目录绝对路径的 SHA256 前缀>.json.
+func DefaultMirrorStatePath(outputDir string) (string, error) {
+	absOutput, err := filepath.Abs(outputDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", utils.NewCryptoError(utils.ErrIOError, "No user config directory for mirror state: "+err.Error())
+	}
+	sum := sha256.Sum256([]byte(absOutput))
+	return filepath.Join(configDir, "fzjjyz", "mirror", hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// loadMirrorState 读取状态文件，文件不存在或格式无效时返回 nil.
+func loadMirrorState(statePath string) *mirrorState {
+	data, err := os.ReadFile(statePath) // #nosec G304 - 状态文件路径来自调用方
+	if err != nil {
+		return nil
+	}
+	var state mirrorState
+	if err := json.Unmarshal(data, &state); err != nil || state.Version != mirrorStateVersion {
+		return nil
+	}
+	if state.EncryptNames && len(state.NameKey) != mirrorNameKeySize {
+		return nil
+	}
+	return &state
+}
+
+// saveMirrorState 以 0600 权限原子写入状态文件（含名称密钥）.
+func saveMirrorState(statePath string, state *mirrorState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshal mirror state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(statePath), dirPerm); err != nil {
+		return fmt.Errorf("create mirror state directory: %w", err)
+	}
+
+	tmpPath := statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("write mirror state: %w", err)
+	}
+	if err := os.Rename(tmpPath, statePath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("replace mirror state: %w", err)
+	}
+	return nil
+}
+
+// isWithinDir 判断 path 是否为 dir 或位于 dir 之下（均为绝对路径）.
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// writeRootFileAtomic 先写临时文件再重命名，避免中断时留下不完整的密文.
+func writeRootFileAtomic(root *os.Root, name string, data []byte, perm os.FileMode) error {
+	tmpName := name + ".tmp"
+	if err := root.WriteFile(tmpName, data, perm); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := root.Rename(tmpName, name); err != nil {
+		_ = root.Remove(tmpName)
+		return fmt.Errorf("rename %s: %w", name, err)
+	}
+	return nil
+}
+
+// EncryptDirectoryMirror 将目录逐文件加密到 outputDir，保持相同的相对布局.
+// 大小和修改时间与状态文件记录一致的文件会被跳过；源目录中已删除的文件对应的密文会被移除.
+//
+//nolint:funlen,gocognit,gocyclo // 遍历、增量判断、写入和清理需要完整处理
+func EncryptDirectoryMirror(
+	sourceDir, outputDir string,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	opts MirrorOptions,
+) (*MirrorResult, error) {
+	if isNilSigningKey(dilithiumPriv) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Dilithium private key is required for mirror mode")
+	}
+	info, err := os.Stat(sourceDir)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Source directory not found: "+err.Error())
+	}
+	if !info.IsDir() {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Source path is not a directory")
+	}
+
+	absSource, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	absOutput, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	statePath := opts.StatePath
+	if statePath == "" {
+		if statePath, err = DefaultMirrorStatePath(absOutput); err != nil {
+			return nil, err
+		}
+	}
+	absState, err := filepath.Abs(statePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	// 状态文件含有名称密钥，不能随密文一起分发
+	if isWithinDir(absState, absOutput) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Mirror state file must not be inside the output directory")
+	}
+
+	if err := os.MkdirAll(absOutput, dirPerm); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+	srcRoot, err := os.OpenRoot(absSource)
+	if err != nil {
+		return nil, fmt.Errorf("open source root: %w", err)
+	}
+	defer func() {
+		_ = srcRoot.Close()
+	}()
+	outRoot, err := os.OpenRoot(absOutput)
+	if err != nil {
+		return nil, fmt.Errorf("open output root: %w", err)
+	}
+	defer func() {
+		_ = outRoot.Close()
+	}()
+
+	recipient, err := RecipientFingerprint(kyberPub, ecdhPub)
+	if err != nil {
+		return nil, err
+	}
+
+	// 接收方或名称模式变化时旧状态失效，全部重新加密并更换名称密钥
+	oldState := loadMirrorState(absState)
+	_, nameKeyErr := outRoot.Stat(MirrorNameKeyName)
+	if oldState == nil && opts.EncryptNames && nameKeyErr == nil {
+		// 没有状态文件就无法得到原名称密钥，新密钥会使已有的加密名称无法解密
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter,
+			"Mirror output already has encrypted names but the state file is missing: use the original --state or an empty output directory")
+	}
+	result := &MirrorResult{}
+	if oldState != nil && (oldState.Recipient != recipient || oldState.EncryptNames != opts.EncryptNames) {
+		// 旧密文加密给原接收方或使用旧名称密钥，不能留在新镜像中
+		removed, err := removeMirrorOutputs(outRoot, absOutput, oldState)
+		result.Removed += removed
+		if err != nil {
+			return nil, err
+		}
+		oldState = nil
+	}
+	if oldState == nil {
+		oldState = &mirrorState{Files: map[string]mirrorStateEntry{}}
+	}
+	newState := &mirrorState{
+		Version:      mirrorStateVersion,
+		Recipient:    recipient,
+		EncryptNames: opts.EncryptNames,
+		NameKey:      oldState.NameKey,
+		Files:        make(map[string]mirrorStateEntry, len(oldState.Files)),
+	}
+
+	mapper := &mirrorPathMapper{}
+	if opts.EncryptNames {
+		if newState.NameKey == nil {
+			newState.NameKey = make([]byte, mirrorNameKeySize)
+			if _, err := rand.Read(newState.NameKey); err != nil {
+				return nil, utils.NewCryptoError(utils.ErrKeyGenerationFailed, "Failed to generate name key: "+err.Error())
+			}
+		}
+		if mapper.names, err = newMirrorNameCipher(newState.NameKey); err != nil {
+			return nil, err
+		}
+		if nameKeyErr != nil || len(oldState.Files) == 0 {
+			if err := writeMirrorNameKey(outRoot, newState.NameKey, kyberPub, ecdhPub, dilithiumPriv); err != nil {
+				return nil, err
+			}
+		}
+	} else if nameKeyErr == nil {
+		// 不再加密名称时移除旧的名称密钥，否则解密方会按加密名称处理
+		if err := outRoot.Remove(MirrorNameKeyName); err != nil {
+			return nil, fmt.Errorf("remove name key: %w", err)
+		}
+	}
+
+	walkErr := filepath.Walk(absSource, func(p string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return fmt.Errorf("walk error at %s: %w", p, walkErr)
+		}
+		if p == absSource {
+			return nil
+		}
+		// 输出目录位于源目录内时跳过
+		if p == absOutput {
+			return filepath.SkipDir
+		}
+		if p == absState || info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(absSource, p)
+		if err != nil {
+			return fmt.Errorf("get relative path: %w", err)
+		}
+		rel := filepath.ToSlash(relPath)
+		out, err := mapper.toMirror(rel, info.IsDir())
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if err := outRoot.MkdirAll(out, dirPerm); err != nil {
+				return fmt.Errorf("create directory %s: %w", out, err)
+			}
+			return nil
+		}
+
+		entry := mirrorStateEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Output: out}
+		if prev, ok := oldState.Files[rel]; ok && prev == entry {
+			if _, err := outRoot.Stat(out); err == nil {
+				newState.Files[rel] = entry
+				result.Skipped++
+				return nil
+			}
+		}
+
+		plaintext, err := srcRoot.ReadFile(relPath)
+		if err != nil {
+			return fmt.Errorf("read file %s: %w", rel, err)
+		}
+		data, err := encryptMirrorFile(plaintext, out, kyberPub, ecdhPub, dilithiumPriv)
+		if err != nil {
+			return err
+		}
+		if err := outRoot.MkdirAll(path.Dir(out), dirPerm); err != nil {
+			return fmt.Errorf("create directory for %s: %w", out, err)
+		}
+		if err := writeRootFileAtomic(outRoot, out, data, encryptedFilePerm); err != nil {
+			return err
+		}
+
+		newState.Files[rel] = entry
+		result.Processed++
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr //nolint:wrapcheck // 回调中已包装
+	}
+
+	// 移除源文件已删除（或镜像路径已变化）的旧密文
+	for rel, prev := range oldState.Files {
+		if cur, ok := newState.Files[rel]; ok && cur.Output == prev.Output {
+			continue
+		}
+		if err := outRoot.Remove(prev.Output); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("remove stale file %s: %w", prev.Output, err)
+		}
+		result.Removed++
+	}
+
+	if err := saveMirrorState(absState, newState); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// removeMirrorOutputs 删除 state 记录的所有密文，并移除由此变空的目录，返回删除的文件数.
+func removeMirrorOutputs(outRoot *os.Root, absOutput string, state *mirrorState) (int, error) {
+	removed := 0
+	for _, entry := range state.Files {
+		if err := outRoot.Remove(entry.Output); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return removed, fmt.Errorf("remove stale file %s: %w", entry.Output, err)
+		}
+		removed++
+	}
+
+	// 逆序遍历保证子目录先于父目录处理；非空目录删除失败时保留
+	var dirs []string
+	err := filepath.WalkDir(absOutput, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk error at %s: %w", p, err)
+		}
+		if d.IsDir() && p != absOutput {
+			rel, err := filepath.Rel(absOutput, p)
+			if err != nil {
+				return fmt.Errorf("get relative path: %w", err)
+			}
+			dirs = append(dirs, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = outRoot.Remove(dirs[i])
+	}
+	return removed, nil
+}
+
+// writeMirrorNameKey 将名称密钥加密给接收方并写入输出目录.
+func writeMirrorNameKey(
+	outRoot *os.Root,
+	nameKey []byte,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+) error {
+	data, err := encryptMirrorFile(nameKey, MirrorNameKeyName, kyberPub, ecdhPub, dilithiumPriv)
+	if err != nil {
+		return err
+	}
+	return writeRootFileAtomic(outRoot, MirrorNameKeyName, data, encryptedFilePerm)
+}
+
+// encryptMirrorFile 加密镜像中位于 name 的文件，name 去掉 MirrorFileExt 后记录在头部并受认证.
+func encryptMirrorFile(
+	plaintext []byte,
+	name string,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+) ([]byte, error) {
+	header, ciphertext, err := encryptDataCore(
+		plaintext,
+		strings.TrimSuffix(name, MirrorFileExt),
+		kyberPub, ecdhPub, dilithiumPriv,
+		NoCompression,
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return encodeEncryptedData(header, ciphertext)
+}
+
+// decryptMirrorFile 解密镜像中位于 name 的文件，头部记录的认证路径必须与 name 一致.
+func decryptMirrorFile(
+	data []byte,
+	name string,
+	kyberPriv kem.PrivateKey,
+	ecdhPriv *ecdh.PrivateKey,
+	dilithiumPub VerifyingKey,
+) ([]byte, error) {
+	header, err := format.ParseFileHeaderFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse file header: %w", err)
+	}
+	want := strings.TrimSuffix(name, MirrorFileExt)
+	if !header.IsFilenameBound() || header.Filename != want {
+		return nil, utils.NewCryptoError(
+			utils.ErrAuthFailed,
+			fmt.Sprintf("Mirror file was moved or swapped: header path %q, expected %q", header.Filename, want),
+		)
+	}
+	return DecryptDataCore(data, kyberPriv, ecdhPriv, dilithiumPub)
+}
+
+// DecryptDirectoryMirror 将镜像目录中的每个 .fzj 文件解密到 outputDir 的对应位置.
+// 单个文件失败不会中断其余文件，所有失败在最后一并返回.
+//
+//nolint:funlen,gocognit // 遍历、名称解密和逐文件错误收集需要完整处理
+func DecryptDirectoryMirror(
+	inputDir, outputDir string,
+	kyberPriv kem.PrivateKey,
+	ecdhPriv *ecdh.PrivateKey,
+	dilithiumPub VerifyingKey,
+) (*MirrorResult, error) {
+	absInput, err := filepath.Abs(inputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	inRoot, err := os.OpenRoot(absInput)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Mirror directory not found: "+err.Error())
+	}
+	defer func() {
+		_ = inRoot.Close()
+	}()
+
+	if err := os.MkdirAll(outputDir, dirPerm); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+	outRoot, err := os.OpenRoot(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("open output root: %w", err)
+	}
+	defer func() {
+		_ = outRoot.Close()
+	}()
+
+	// 存在名称密钥文件时说明名称已加密
+	mapper := &mirrorPathMapper{}
+	if keyData, err := inRoot.ReadFile(MirrorNameKeyName); err == nil {
+		nameKey, err := decryptMirrorFile(keyData, MirrorNameKeyName, kyberPriv, ecdhPriv, dilithiumPub)
+		if err != nil {
+			return nil, err
+		}
+		if mapper.names, err = newMirrorNameCipher(nameKey); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read name key: %w", err)
+	}
+
+	result := &MirrorResult{}
+	var failures []error
+	walkErr := filepath.Walk(absInput, func(p string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return fmt.Errorf("walk error at %s: %w", p, walkErr)
+		}
+		if p == absInput || info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(absInput, p)
+		if err != nil {
+			return fmt.Errorf("get relative path: %w", err)
+		}
+		rel := filepath.ToSlash(relPath)
+		if !strings.Contains(rel, "/") && strings.HasPrefix(rel, mirrorReservedPrefix) {
+			return nil
+		}
+
+		if info.IsDir() {
+			target, err := mapper.fromMirror(rel)
+			if err != nil {
+				return err
+			}
+			if err := outRoot.MkdirAll(target, dirPerm); err != nil {
+				return fmt.Errorf("create directory %s: %w", target, err)
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || !strings.HasSuffix(rel, MirrorFileExt) {
+			return nil
+		}
+
+		target, err := mapper.fromMirror(strings.TrimSuffix(rel, MirrorFileExt))
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", rel, err))
+			return nil
+		}
+		data, err := inRoot.ReadFile(relPath)
+		if err != nil {
+			return fmt.Errorf("read file %s: %w", rel, err)
+		}
+		plaintext, err := decryptMirrorFile(data, rel, kyberPriv, ecdhPriv, dilithiumPub)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", target, err))
+			return nil
+		}
+		if err := outRoot.MkdirAll(path.Dir(target), dirPerm); err != nil {
+			return fmt.Errorf("create directory for %s: %w", target, err)
+		}
+		err = outRoot.WriteFile(target, plaintext, decryptedFilePerm)
+		wipePlaintext(plaintext)
+		if err != nil {
+			return fmt.Errorf("write file %s: %w", target, err)
+		}
+		result.Processed++
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr //nolint:wrapcheck // 回调中已包装
+	}
+	if len(failures) > 0 {
+		return result, utils.NewCryptoError(
+			utils.ErrDecryptionFailed,
+			fmt.Sprintf("%d file(s) failed to decrypt: %v", len(failures), errors.Join(failures...)),
+		)
+	}
+	return result, nil
+}
@@ -0,0 +1,369 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readTree 读取目录中所有普通文件的内容，键为使用 / 分隔的相对路径.
+func readTree(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	files := map[string][]byte{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		data, err := os.ReadFile(path) // #nosec G304 - 测试环境使用临时文件路径
+		files[filepath.ToSlash(rel)] = data
+		return err
+	})
+	if err != nil {
+		t.Fatalf("读取目录失败: %v", err)
+	}
+	return files
+}
+
+// useTempConfigDir 让默认状态文件写入临时目录而不是用户配置目录.
+func useTempConfigDir(t *testing.T) string {
+	t.Helper()
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("HOME", configDir)
+	t.Setenv("AppData", configDir)
+	return configDir
+}
+
+func TestMirrorRoundTrip(t *testing.T) {
+	useTempConfigDir(t)
+	keys := generateTestKeys(t)
+
+	for _, encryptNames := range []bool{false, true} {
+		sourceDir, files := createIndexedTestDir(t)
+		mirrorDir := filepath.Join(t.TempDir(), "mirror")
+		opts := MirrorOptions{EncryptNames: encryptNames}
+
+		result, err := EncryptDirectoryMirror(sourceDir, mirrorDir, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, opts)
+		if err != nil {
+			t.Fatalf("镜像加密失败: %v", err)
+		}
+		if result.Processed != len(files) {
+			t.Errorf("期望加密 %d 个文件，实际 %d", len(files), result.Processed)
+		}
+
+		mirrored := readTree(t, mirrorDir)
+		for name := range mirrored {
+			if strings.HasPrefix(name, mirrorReservedPrefix) {
+				continue
+			}
+			leaks := strings.Contains(name, "README") || strings.Contains(name, "docs")
+			if encryptNames && leaks {
+				t.Errorf("加密名称后不应出现原始名称: %s", name)
+			}
+		}
+		if !encryptNames {
+			if _, ok := mirrored["docs/guide/usage.md"+MirrorFileExt]; !ok {
+				t.Errorf("未加密名称时应保持相对布局: %v", mirrored)
+			}
+		}
+
+		restoreDir := filepath.Join(t.TempDir(), "restore")
+		result, err = DecryptDirectoryMirror(mirrorDir, restoreDir, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+		if err != nil {
+			t.Fatalf("镜像解密失败: %v", err)
+		}
+		if result.Processed != len(files) {
+			t.Errorf("期望解密 %d 个文件，实际 %d", len(files), result.Processed)
+		}
+		restored := readTree(t, restoreDir)
+		for name, data := range files {
+			if !bytes.Equal(restored[name], data) {
+				t.Errorf("%s 解密内容不一致", name)
+			}
+		}
+	}
+}
+
+func TestMirrorIncremental(t *testing.T) {
+	useTempConfigDir(t)
+	keys := generateTestKeys(t)
+	sourceDir, files := createIndexedTestDir(t)
+	mirrorDir := filepath.Join(t.TempDir(), "mirror")
+	opts := MirrorOptions{EncryptNames: true}
+
+	if _, err := EncryptDirectoryMirror(sourceDir, mirrorDir, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, opts); err != nil {
+		t.Fatalf("镜像加密失败: %v", err)
+	}
+	before := readTree(t, mirrorDir)
+
+	// 未变化时全部跳过，名称保持一致
+	result, err := EncryptDirectoryMirror(sourceDir, mirrorDir, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, opts)
+	if err != nil {
+		t.Fatalf("镜像加密失败: %v", err)
+	}
+	if result.Processed != 0 || result.Skipped != len(files) {
+		t.Errorf("未变化的文件应全部跳过: %+v", result)
+	}
+	after := readTree(t, mirrorDir)
+	for name, data := range before {
+		if !bytes.Equal(after[name], data) {
+			t.Errorf("未变化的密文不应被重写: %s", name)
+		}
+	}
+
+	// 修改一个文件、删除一个文件
+	future := time.Now().Add(time.Hour)
+	if err := os.WriteFile(filepath.Join(sourceDir, "README.md"), []byte("# changed"), 0600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	if err := os.Chtimes(filepath.Join(sourceDir, "README.md"), future, future); err != nil {
+		t.Fatalf("修改时间失败: %v", err)
+	}
+	if err := os.Remove(filepath.Join(sourceDir, "docs", "intro.txt")); err != nil {
+		t.Fatalf("删除文件失败: %v", err)
+	}
+	result, err = EncryptDirectoryMirror(sourceDir, mirrorDir, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, opts)
+	if err != nil {
+		t.Fatalf("镜像加密失败: %v", err)
+	}
+	if result.Processed != 1 || result.Removed != 1 || result.Skipped != len(files)-2 {
+		t.Errorf("增量结果不正确: %+v", result)
+	}
+
+	restoreDir := filepath.Join(t.TempDir(), "restore")
+	if _, err := DecryptDirectoryMirror(mirrorDir, restoreDir, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub); err != nil {
+		t.Fatalf("镜像解密失败: %v", err)
+	}
+	restored := readTree(t, restoreDir)
+	if string(restored["README.md"]) != "# changed" {
+		t.Error("修改后的文件应被重新加密")
+	}
+	if _, ok := restored["docs/intro.txt"]; ok {
+		t.Error("已删除的文件不应出现在解密结果中")
+	}
+
+	// 切换名称模式时状态失效，旧密文被移除
+	result, err = EncryptDirectoryMirror(sourceDir, mirrorDir, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, MirrorOptions{})
+	if err != nil {
+		t.Fatalf("镜像加密失败: %v", err)
+	}
+	if result.Processed != len(files)-1 || result.Skipped != 0 {
+		t.Errorf("切换名称模式后应全部重新加密: %+v", result)
+	}
+}
+
+func TestMirrorRecipientChange(t *testing.T) {
+	useTempConfigDir(t)
+	oldKeys := generateTestKeys(t)
+	newKeys := generateTestKeys(t)
+
+	for _, encryptNames := range []bool{false, true} {
+		sourceDir, files := createIndexedTestDir(t)
+		mirrorDir := filepath.Join(t.TempDir(), "mirror")
+		opts := MirrorOptions{EncryptNames: encryptNames}
+		if _, err := EncryptDirectoryMirror(sourceDir, mirrorDir, oldKeys.kyberPub, oldKeys.ecdhPub, oldKeys.dilithiumPriv, opts); err != nil {
+			t.Fatalf("镜像加密失败: %v", err)
+		}
+
+		// 删除源文件后换接收方重新镜像，加密给原接收方的密文应全部移除
+		if err := os.Remove(filepath.Join(sourceDir, "docs", "intro.txt")); err != nil {
+			t.Fatalf("删除文件失败: %v", err)
+		}
+		delete(files, "docs/intro.txt")
+		result, err := EncryptDirectoryMirror(sourceDir, mirrorDir, newKeys.kyberPub, newKeys.ecdhPub, newKeys.dilithiumPriv, opts)
+		if err != nil {
+			t.Fatalf("镜像加密失败: %v", err)
+		}
+		if result.Processed != len(files) || result.Removed != len(files)+1 {
+			t.Errorf("更换接收方后应删除全部旧密文并重新加密: %+v", result)
+		}
+
+		var mirrored int
+		for name := range readTree(t, mirrorDir) {
+			if !strings.HasPrefix(name, mirrorReservedPrefix) {
+				mirrored++
+			}
+		}
+		if mirrored != len(files) {
+			t.Errorf("镜像中有 %d 个密文，期望 %d", mirrored, len(files))
+		}
+
+		restoreDir := filepath.Join(t.TempDir(), "restore")
+		if _, err := DecryptDirectoryMirror(mirrorDir, restoreDir, newKeys.kyberPriv, newKeys.ecdhPriv, newKeys.dilithiumPub); err != nil {
+			t.Fatalf("新接收方解密失败: %v", err)
+		}
+		if restored := readTree(t, restoreDir); len(restored) != len(files) {
+			t.Errorf("解密出 %d 个文件，期望 %d", len(restored), len(files))
+		}
+	}
+}
+
+func TestMirrorStateAndNameKey(t *testing.T) {
+	configDir := useTempConfigDir(t)
+	keys := generateTestKeys(t)
+	sourceDir, files := createIndexedTestDir(t)
+	opts := MirrorOptions{EncryptNames: true}
+
+	// 默认状态文件位于用户配置目录，权限为 0600，不随密文分发
+	mirrorA := filepath.Join(t.TempDir(), "a")
+	if _, err := EncryptDirectoryMirror(sourceDir, mirrorA, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, opts); err != nil {
+		t.Fatalf("镜像加密失败: %v", err)
+	}
+	statePath, err := DefaultMirrorStatePath(mirrorA)
+	if err != nil {
+		t.Fatalf("获取默认状态文件路径失败: %v", err)
+	}
+	if !strings.HasPrefix(statePath, configDir) {
+		t.Errorf("默认状态文件应位于配置目录: %s", statePath)
+	}
+	info, err := os.Stat(statePath)
+	if err != nil {
+		t.Fatalf("状态文件不存在: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("状态文件权限 = %o，期望 0600", info.Mode().Perm())
+	}
+
+	// 名称密钥随机生成，与签名私钥无关：同一密钥加密的两个镜像名称不同
+	mirrorB := filepath.Join(t.TempDir(), "b")
+	if _, err := EncryptDirectoryMirror(sourceDir, mirrorB, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, opts); err != nil {
+		t.Fatalf("镜像加密失败: %v", err)
+	}
+	namesA, namesB := readTree(t, mirrorA), readTree(t, mirrorB)
+	for name := range namesA {
+		if _, ok := namesB[name]; ok && !strings.HasPrefix(name, mirrorReservedPrefix) {
+			t.Errorf("不同镜像的加密名称不应相同: %s", name)
+		}
+	}
+
+	// 状态文件不能位于输出目录中
+	inside := MirrorOptions{EncryptNames: true, StatePath: filepath.Join(mirrorA, "state.json")}
+	if _, err := EncryptDirectoryMirror(sourceDir, mirrorA, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, inside); err == nil {
+		t.Error("期望状态文件位于输出目录时失败")
+	}
+
+	// 状态文件丢失时拒绝生成新名称密钥，避免已有名称无法解密
+	if err := os.Remove(statePath); err != nil {
+		t.Fatalf("删除状态文件失败: %v", err)
+	}
+	if _, err := EncryptDirectoryMirror(sourceDir, mirrorA, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, opts); err == nil {
+		t.Error("期望状态文件丢失时失败")
+	}
+
+	// 远程签名密钥同样可用于名称加密
+	remote := &localRemote{sign: keys.dilithiumPriv}
+	signKey := NewRemoteSigningKey(keys.dilithiumPub, remote)
+	mirrorC := filepath.Join(t.TempDir(), "c")
+	if _, err := EncryptDirectoryMirror(sourceDir, mirrorC, keys.kyberPub, keys.ecdhPub, signKey, opts); err != nil {
+		t.Fatalf("远程签名镜像加密失败: %v", err)
+	}
+	restoreDir := filepath.Join(t.TempDir(), "restore")
+	if _, err := DecryptDirectoryMirror(mirrorC, restoreDir, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub); err != nil {
+		t.Fatalf("镜像解密失败: %v", err)
+	}
+	restored := readTree(t, restoreDir)
+	for name, data := range files {
+		if !bytes.Equal(restored[name], data) {
+			t.Errorf("%s 解密内容不一致", name)
+		}
+	}
+}
+
+func TestMirrorNameCipher(t *testing.T) {
+	names, err := newMirrorNameCipher(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("创建名称加密器失败: %v", err)
+	}
+
+	a, _ := names.encryptName("secret.txt")
+	b, _ := names.encryptName("secret.txt")
+	if a != b {
+		t.Error("名称加密应是确定性的")
+	}
+	if plain, err := names.decryptName(a); err != nil || plain != "secret.txt" {
+		t.Errorf("名称解密失败: %q %v", plain, err)
+	}
+
+	tampered := []byte(a)
+	if tampered[0] == 'a' {
+		tampered[0] = 'b'
+	} else {
+		tampered[0] = 'a'
+	}
+	if _, err := names.decryptName(string(tampered)); err == nil {
+		t.Error("被篡改的名称应解密失败")
+	}
+	if _, err := names.encryptName(strings.Repeat("x", maxMirrorNameLen+1)); err == nil {
+		t.Error("过长的名称应返回错误")
+	}
+}
+
+func TestMirrorSwappedFiles(t *testing.T) {
+	useTempConfigDir(t)
+	keys := generateTestKeys(t)
+	sourceDir, files := createIndexedTestDir(t)
+	mirrorDir := filepath.Join(t.TempDir(), "mirror")
+	if _, err := EncryptDirectoryMirror(sourceDir, mirrorDir, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv,
+		DefaultMirrorOptions); err != nil {
+		t.Fatalf("镜像加密失败: %v", err)
+	}
+
+	a := filepath.Join(mirrorDir, "README.md"+MirrorFileExt)
+	b := filepath.Join(mirrorDir, "docs", "guide", "usage.md"+MirrorFileExt)
+	dataA, errA := os.ReadFile(a) // #nosec G304 - 测试环境使用临时文件路径
+	dataB, errB := os.ReadFile(b) // #nosec G304 - 测试环境使用临时文件路径
+	if errA != nil || errB != nil {
+		t.Fatalf("读取密文失败: %v %v", errA, errB)
+	}
+
+	// 交换两个签名有效的密文
+	if err := os.WriteFile(a, dataB, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, dataA, 0600); err != nil {
+		t.Fatal(err)
+	}
+	restoreDir := filepath.Join(t.TempDir(), "restore")
+	if _, err := DecryptDirectoryMirror(mirrorDir, restoreDir, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub); err == nil {
+		t.Fatal("交换后的密文应解密失败")
+	}
+	restored := readTree(t, restoreDir)
+	for _, name := range []string{"README.md", "docs/guide/usage.md"} {
+		if _, ok := restored[name]; ok {
+			t.Errorf("%s 不应被写入", name)
+		}
+	}
+
+	// 同时改写头部中的路径：路径受 AES-GCM 认证，解密同样失败
+	header, ciphertext, err := parseEncryptedData(dataB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.Filename = "README.md"
+	header.FilenameLen = uint16(len(header.Filename))
+	forged, err := encodeEncryptedData(header, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(a, forged, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, dataB, 0600); err != nil {
+		t.Fatal(err)
+	}
+	restoreDir = filepath.Join(t.TempDir(), "restore")
+	if _, err := DecryptDirectoryMirror(mirrorDir, restoreDir, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub); err == nil {
+		t.Fatal("改写头部路径的密文应解密失败")
+	}
+	restored = readTree(t, restoreDir)
+	if _, ok := restored["README.md"]; ok {
+		t.Error("README.md 不应被写入")
+	}
+	if !bytes.Equal(restored["docs/guide/usage.md"], files["docs/guide/usage.md"]) {
+		t.Error("未被篡改的文件应正常解密")
+	}
+}
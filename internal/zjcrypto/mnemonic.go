@@ -0,0 +1,130 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// 助记词派生:
+//
+//	seed      = BIP39 种子（PBKDF2-HMAC-SHA512，2048 次，口令为空），64 字节
+//	kyber     = HKDF-SHA256(seed, salt, "fzjjyz/v1/kyber768")   → 64 字节 → kyber768.DeriveKeyPair
+//	x25519    = HKDF-SHA256(seed, salt, "fzjjyz/v1/x25519")     → 32 字节私钥
+//	dilithium = HKDF-SHA256(seed, salt, "fzjjyz/v1/dilithium3") → 32 字节 → mode3.NewKeyFromSeed
+//	ed25519   = HKDF-SHA256(seed, salt, "fzjjyz/v1/ed25519")    → 32 字节种子（复合签名密钥使用）
+//
+// 各组密钥的派生输入互相独立；更改任何一步都会改变已有助记词对应的密钥，
+// 因此 mnemonic_test.go 中的测试向量必须保持不变，新的派生方式只能使用新的版本标签.
+
+// mnemonicEntropyBits 生成 24 个词的助记词.
+const mnemonicEntropyBits = 256
+
+const (
+	mnemonicSalt           = "fzjjyz mnemonic keygen"
+	mnemonicInfoKyber      = "fzjjyz/v1/kyber768"
+	mnemonicInfoX25519     = "fzjjyz/v1/x25519"
+	mnemonicInfoDilithium3 = "fzjjyz/v1/dilithium3"
+	mnemonicInfoEd25519    = "fzjjyz/v1/ed25519"
+)
+
+// DerivedKeys 由助记词派生的全部密钥.
+type DerivedKeys struct {
+	KyberPub  kem.PublicKey
+	KyberPriv kem.PrivateKey
+	// KyberSeed 派生 KyberPriv 的 64 字节种子，保存到私钥文件后可用于纸质种子备份.
+	KyberSeed     []byte
+	ECDHPub       *ecdh.PublicKey
+	ECDHPriv      *ecdh.PrivateKey
+	DilithiumPub  *mode3.PublicKey
+	DilithiumPriv *mode3.PrivateKey
+	// Ed25519Priv 与 DilithiumPriv 组成复合签名密钥，见 NewCompositeSigningKey.
+	Ed25519Priv ed25519.PrivateKey
+}
+
+// NewMnemonic 生成 24 个词的 BIP39 英文助记词.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropyBits)
+	if err != nil {
+		return "", utils.NewCryptoError(utils.ErrKeyGenerationFailed,
+			fmt.Sprintf("Failed to generate mnemonic entropy: %v", err))
+	}
+	defer clear(entropy)
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", utils.NewCryptoError(utils.ErrKeyGenerationFailed, fmt.Sprintf("Failed to encode mnemonic: %v", err))
+	}
+	return mnemonic, nil
+}
+
+// NormalizeMnemonic 统一大小写和空白并检查单词与校验和.
+func NormalizeMnemonic(mnemonic string) (string, error) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+	for i, word := range strings.Fields(normalized) {
+		if _, ok := bip39.GetWordIndex(word); !ok {
+			return "", utils.NewCryptoError(utils.ErrInvalidParameter,
+				fmt.Sprintf("Word %d (%q) is not in the BIP39 English word list", i+1, word))
+		}
+	}
+	if _, err := bip39.EntropyFromMnemonic(normalized); err != nil {
+		return "", utils.NewCryptoError(utils.ErrInvalidParameter, fmt.Sprintf("Invalid mnemonic: %v", err))
+	}
+	return normalized, nil
+}
+
+// DeriveKeysFromMnemonic 由助记词确定性地派生 Kyber768、X25519、Dilithium3 与 Ed25519 密钥对.
+// 相同的助记词在任何版本上都得到逐字节相同的密钥.
+func DeriveKeysFromMnemonic(mnemonic string) (*DerivedKeys, error) {
+	normalized, err := NormalizeMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	seed := bip39.NewSeed(normalized, "")
+	defer clear(seed)
+
+	kyberSeed, err := hkdf.Key(sha256.New, seed, []byte(mnemonicSalt), mnemonicInfoKyber, kyber768.Scheme().SeedSize())
+	if err != nil {
+		return nil, derivationError(err)
+	}
+	defer clear(kyberSeed)
+	ecdhSeed, err := hkdf.Key(sha256.New, seed, []byte(mnemonicSalt), mnemonicInfoX25519, 32)
+	if err != nil {
+		return nil, derivationError(err)
+	}
+	defer clear(ecdhSeed)
+	dilithiumSeed, err := hkdf.Key(sha256.New, seed, []byte(mnemonicSalt), mnemonicInfoDilithium3, mode3.SeedSize)
+	if err != nil {
+		return nil, derivationError(err)
+	}
+	defer clear(dilithiumSeed)
+	ed25519Seed, err := hkdf.Key(sha256.New, seed, []byte(mnemonicSalt), mnemonicInfoEd25519, ed25519.SeedSize)
+	if err != nil {
+		return nil, derivationError(err)
+	}
+	defer clear(ed25519Seed)
+
+	keys := &DerivedKeys{}
+	keys.KyberPub, keys.KyberPriv = kyber768.Scheme().DeriveKeyPair(kyberSeed)
+	keys.KyberSeed = bytes.Clone(kyberSeed)
+	if keys.ECDHPriv, err = ecdh.X25519().NewPrivateKey(ecdhSeed); err != nil {
+		return nil, derivationError(err)
+	}
+	keys.ECDHPub = keys.ECDHPriv.PublicKey()
+	keys.DilithiumPub, keys.DilithiumPriv = mode3.NewKeyFromSeed((*[mode3.SeedSize]byte)(dilithiumSeed))
+	keys.Ed25519Priv = ed25519.NewKeyFromSeed(ed25519Seed)
+	return keys, nil
+}
+
+func derivationError(err error) error {
+	return utils.NewCryptoError(utils.ErrKeyGenerationFailed, fmt.Sprintf("Key derivation failed: %v", err))
+}
@@ -0,0 +1,127 @@
+package zjcrypto
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// 助记词派生测试向量. 这些值一旦发布就不能改变，否则用户无法用已有助记词恢复密钥.
+// Kyber 与 Dilithium 密钥较长，记录其编码的 SHA-256；X25519 直接记录密钥.
+var mnemonicVectors = []struct {
+	mnemonic      string
+	kyberPub      string
+	kyberPriv     string
+	x25519Priv    string
+	x25519Pub     string
+	dilithiumPub  string
+	dilithiumPriv string
+	ed25519Pub    string
+}{
+	{
+		mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon " +
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		kyberPub:      "18be1e6a390db8d17769cfb80bf76ba9aa81c66183988966bbe4c11b421938d6",
+		kyberPriv:     "e8ccf83732c641569c9f5a7480930fba6371acc5cd718f04d9353f8f7f2833f3",
+		x25519Priv:    "84162bd47b91276039aff20117476063e2d02e857a8b7271287ff43d8db3f0fc",
+		x25519Pub:     "bf7399debc6cc28a5df46215b7ab32f3f36bec878b010a899ef9d1bb9f2e1c22",
+		dilithiumPub:  "790fe4618d7bdc9aaa78e283044bd57afd507516e3b696ef07849faca0a090f8",
+		dilithiumPriv: "7f6189f242470939dfc17b1a20f4382701cd774936a9d3f46adf4560427b0e50",
+		ed25519Pub:    "7e8e59f088d6201b745ef6137a340b9f7537881602b81b54c131fe1b5e104c3a",
+	},
+	{
+		mnemonic:      "legal winner thank year wave sausage worth useful legal winner thank yellow",
+		kyberPub:      "ff8755d3199cb1e4c6c7d9ed0f87c9eba9462c38f00892afc95ce636bc540c16",
+		kyberPriv:     "8fab108953f3027cd5a10d6973e7596ef5cb3197c9d1b2bd9c880a8b57d5ce26",
+		x25519Priv:    "9abfd4e1ad4ac9e900989a4bebc0d24869111e6b57f7d939364e2ae7abdb242a",
+		x25519Pub:     "f04b51ef30b4bce7e0f58321e454d42ab32c5e0131d269e5f5a535e320ad2c45",
+		dilithiumPub:  "cc0910471eb8252155935191d186f30eab1361481445ab17a71fad6e41b7610c",
+		dilithiumPriv: "c3f116a6765bbea66a09c3400ee4ca2ee94b71436084aac38b993779778327e2",
+		ed25519Pub:    "789447a7502112646eec6ec4f9a56ac76c0df5b97bc1efa06da33f351ae136c0",
+	},
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDeriveKeysFromMnemonicVectors(t *testing.T) {
+	for _, v := range mnemonicVectors {
+		keys, err := DeriveKeysFromMnemonic(v.mnemonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kyberPub, _ := keys.KyberPub.MarshalBinary()
+		kyberPriv, _ := keys.KyberPriv.MarshalBinary()
+		got := map[string][2]string{
+			"kyber public":      {sha256Hex(kyberPub), v.kyberPub},
+			"kyber private":     {sha256Hex(kyberPriv), v.kyberPriv},
+			"x25519 private":    {hex.EncodeToString(keys.ECDHPriv.Bytes()), v.x25519Priv},
+			"x25519 public":     {hex.EncodeToString(keys.ECDHPub.Bytes()), v.x25519Pub},
+			"dilithium public":  {sha256Hex(keys.DilithiumPub.Bytes()), v.dilithiumPub},
+			"dilithium private": {sha256Hex(keys.DilithiumPriv.Bytes()), v.dilithiumPriv},
+			"ed25519 public":    {hex.EncodeToString(keys.Ed25519Priv[ed25519.SeedSize:]), v.ed25519Pub},
+		}
+		for name, pair := range got {
+			if pair[0] != pair[1] {
+				t.Errorf("%.20s... %s = %s, 期望 %s", v.mnemonic, name, pair[0], pair[1])
+			}
+		}
+	}
+}
+
+// TestMnemonicSeedVector 固定 BIP39 种子一步，依赖升级改变种子时在此处报告.
+func TestMnemonicSeedVector(t *testing.T) {
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	const want = "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc1" +
+		"9a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4"
+	if got := hex.EncodeToString(seed); got != want {
+		t.Fatalf("BIP39 种子 = %s, 期望 %s", got, want)
+	}
+}
+
+func TestMnemonicRoundTrip(t *testing.T) {
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(strings.Fields(mnemonic)); n != 24 {
+		t.Fatalf("助记词应为 24 个词，实际 %d", n)
+	}
+	first, err := DeriveKeysFromMnemonic(mnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 大小写与空白不影响结果
+	second, err := DeriveKeysFromMnemonic("  " + strings.ToUpper(strings.ReplaceAll(mnemonic, " ", "\n ")) + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.KyberPriv.Equal(second.KyberPriv) || !first.ECDHPriv.Equal(second.ECDHPriv) ||
+		!first.DilithiumPriv.Equal(second.DilithiumPriv) {
+		t.Fatal("同一助记词派生的密钥不同")
+	}
+}
+
+func TestNormalizeMnemonicErrors(t *testing.T) {
+	words := strings.Fields(mnemonicVectors[1].mnemonic)
+	cases := map[string]string{
+		"未知单词":  strings.Join(append([]string{"notaword"}, words[1:]...), " "),
+		"校验和错误": strings.Join(append(append([]string{}, words[:11]...), "winner"), " "),
+		"词数错误":  strings.Join(words[:11], " "),
+	}
+	for name, mnemonic := range cases {
+		if _, err := NormalizeMnemonic(mnemonic); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+	_, err := NormalizeMnemonic(cases["未知单词"])
+	if err == nil || !strings.Contains(err.Error(), "Word 1") {
+		t.Errorf("错误应指出第 1 个词: %v", err)
+	}
+}
@@ -2,10 +2,11 @@ package zjcrypto
 
 import (
 	"crypto/ecdh"
+	"fmt"
+	"io"
 
 	"codeberg.org/jiangfire/fzjjyz/internal/utils"
 	"github.com/cloudflare/circl/kem"
-	"github.com/cloudflare/circl/sign/dilithium/mode3"
 )
 
 // EncryptFile 加密文件
@@ -24,10 +25,22 @@ func EncryptFile(
 	inputPath, outputPath string,
 	kyberPub kem.PublicKey,
 	ecdhPub *ecdh.PublicKey,
-	dilithiumPriv *mode3.PrivateKey,
+	dilithiumPriv SigningKey,
+) error {
+	return EncryptFileWithCompression(inputPath, outputPath, kyberPub, ecdhPub, dilithiumPriv, NoCompression)
+}
+
+// EncryptFileWithCompression 加密文件，并在加密前按选项压缩明文
+// 压缩算法记录在头部 Flags 中，DecryptFile 会自动解压.
+func EncryptFileWithCompression(
+	inputPath, outputPath string,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	compression CompressionOptions,
 ) error {
 	// 调用核心加密逻辑
-	header, ciphertext, err := EncryptFileCore(inputPath, kyberPub, ecdhPub, dilithiumPriv)
+	header, ciphertext, err := EncryptFileCoreWithCompression(inputPath, kyberPub, ecdhPub, dilithiumPriv, compression)
 	if err != nil {
 		return err
 	}
@@ -45,6 +58,36 @@ func EncryptFile(
 	return writeEncryptedFile(outputPath, headerBytes, ciphertext)
 }
 
+// EncryptFileTo 与 EncryptFileWithCompression 相同，但将 [头部] + [密文] 写入 w（如分卷写入器）.
+func EncryptFileTo(
+	inputPath string,
+	w io.Writer,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	compression CompressionOptions,
+) error {
+	header, ciphertext, err := EncryptFileCoreWithCompression(inputPath, kyberPub, ecdhPub, dilithiumPriv, compression)
+	if err != nil {
+		return err
+	}
+	headerBytes, err := serializeHeader(header)
+	if err != nil {
+		return utils.NewCryptoError(
+			utils.ErrSerializationFailed,
+			"Header serialization failed: "+err.Error(),
+		)
+	}
+
+	if _, err := w.Write(headerBytes); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	if _, err := w.Write(ciphertext); err != nil {
+		return fmt.Errorf("write ciphertext: %w", err)
+	}
+	return nil
+}
+
 // DecryptFile 解密文件
 // 输入: 加密文件路径, 输出文件路径, Kyber私钥, ECDH私钥, Dilithium公钥
 // 返回: 错误
@@ -53,7 +96,7 @@ func EncryptFile(
 // 1. 读取并解析文件头
 // 2. 验证文件头
 // 3. 混合密钥解封装
-// 4. AES-256-GCM 解密
+// 4. AES-256-GCM 解密（如有压缩则自动解压）
 // 5. 验证 SHA256 哈希
 // 6. 验证 Dilithium3 签名
 // 7. 写入解密文件.
@@ -61,7 +104,7 @@ func DecryptFile(
 	inputPath, outputPath string,
 	kyberPriv kem.PrivateKey,
 	ecdhPriv *ecdh.PrivateKey,
-	dilithiumPub *mode3.PublicKey,
+	dilithiumPub VerifyingKey,
 ) error {
 	// 调用核心解密逻辑
 	plaintext, err := DecryptFileCore(inputPath, kyberPriv, ecdhPriv, dilithiumPub)
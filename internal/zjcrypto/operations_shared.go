@@ -1,9 +1,11 @@
 package zjcrypto
 
 import (
+	"bytes"
 	"crypto/ecdh"
 	"crypto/sha256"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
@@ -27,7 +29,7 @@ type EncryptionData struct {
 	FileSize     uint64
 	Encapsulated []byte
 	ECDHTempPub  []byte
-	SharedSecret []byte
+	SharedSecret *SecretBuffer
 	IV           []byte
 	Hash         [32]byte
 	Signature    []byte
@@ -37,22 +39,22 @@ type EncryptionData struct {
 type DecryptionData struct {
 	Ciphertext   []byte
 	Header       *format.FileHeader
-	SharedSecret []byte
+	SharedSecret *SecretBuffer
 	Plaintext    []byte
 	Hash         [32]byte
 }
 
 // prepareEncryptionKeys 执行混合密钥封装
-// 返回: Kyber密文, 临时ECDH公钥, 组合共享密钥.
-func prepareEncryptionKeys(kyberPub kem.PublicKey, ecdhPub *ecdh.PublicKey) ([]byte, []byte, []byte, error) {
+// 返回: Kyber密文, 临时ECDH公钥, 组合共享密钥（调用方 Release）.
+func prepareEncryptionKeys(kyberPub kem.PublicKey, ecdhPub *ecdh.PublicKey) ([]byte, []byte, *SecretBuffer, error) {
 	encryptor := NewHybridEncryptor(kyberPub, ecdhPub)
-	return encryptor.Encapsulate()
+	return encryptor.EncapsulateSecret()
 }
 
-// encryptAESGCM 使用AES-256-GCM加密数据.
-func encryptAESGCM(sharedSecret []byte, plaintext []byte) (ciphertext []byte, iv []byte, err error) {
-	// AESGCMEncrypt 已经生成随机 nonce
-	ciphertext, iv, err = AESGCMEncrypt(sharedSecret, plaintext)
+// encryptAESGCM 使用AES-256-GCM加密数据，aad 为附加认证数据.
+func encryptAESGCM(sharedSecret []byte, plaintext []byte, aad []byte) (ciphertext []byte, iv []byte, err error) {
+	// aesGCMSeal 已经生成随机 nonce
+	ciphertext, iv, err = aesGCMSeal(sharedSecret, plaintext, aad)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -60,9 +62,9 @@ func encryptAESGCM(sharedSecret []byte, plaintext []byte) (ciphertext []byte, iv
 	return ciphertext, iv, nil
 }
 
-// decryptAESGCM 使用AES-256-GCM解密数据.
-func decryptAESGCM(sharedSecret []byte, ciphertext []byte, iv []byte) ([]byte, error) {
-	return AESGCMDecrypt(sharedSecret, ciphertext, iv)
+// decryptAESGCM 使用AES-256-GCM解密数据并验证 aad.
+func decryptAESGCM(sharedSecret []byte, ciphertext []byte, iv []byte, aad []byte) ([]byte, error) {
+	return aesGCMOpen(sharedSecret, ciphertext, iv, aad)
 }
 
 // calculateHash 计算数据的SHA256哈希.
@@ -71,12 +73,12 @@ func calculateHash(data []byte) [32]byte {
 }
 
 // signHash 对哈希进行Dilithium签名.
-func signHash(hash []byte, dilithiumPriv *mode3.PrivateKey) ([]byte, error) {
+func signHash(hash []byte, dilithiumPriv SigningKey) ([]byte, error) {
 	return SignHashWithKey(hash, dilithiumPriv)
 }
 
 // verifyHashSignature 验证哈希签名.
-func verifyHashSignature(hash []byte, signature []byte, dilithiumPub *mode3.PublicKey) (bool, error) {
+func verifyHashSignature(hash []byte, signature []byte, dilithiumPub VerifyingKey) (bool, error) {
 	return VerifyHashSignatureWithKey(hash, signature, dilithiumPub)
 }
 
@@ -97,7 +99,7 @@ func buildFileHeader(
 	var ivArray [12]byte
 	copy(ivArray[:], iv)
 
-	return format.NewFileHeader(
+	header := format.NewFileHeader(
 		filename,
 		fileSize,
 		encapsulated,
@@ -105,7 +107,12 @@ func buildFileHeader(
 		ivArray,
 		signature,
 		hash,
-	), nil
+	)
+	// 两种签名长度不同，签名类型由长度确定
+	if len(signature) == CompositeSignatureSize {
+		header.SetSignatureType(format.SignatureComposite)
+	}
+	return header, nil
 }
 
 // serializeHeader 序列化文件头.
@@ -118,6 +125,17 @@ func serializeHeader(header *format.FileHeader) ([]byte, error) {
 	return data, nil
 }
 
+// encodeEncryptedData 序列化头部并与密文拼接.
+func encodeEncryptedData(header *format.FileHeader, ciphertext []byte) ([]byte, error) {
+	headerBytes, err := serializeHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	outputData := make([]byte, 0, len(headerBytes)+len(ciphertext))
+	outputData = append(outputData, headerBytes...)
+	return append(outputData, ciphertext...), nil
+}
+
 // writeEncryptedFile 写入加密文件（头部 + 密文）.
 func writeEncryptedFile(outputPath string, headerBytes []byte, ciphertext []byte) error {
 	// 预分配内存
@@ -131,14 +149,8 @@ func writeEncryptedFile(outputPath string, headerBytes []byte, ciphertext []byte
 	return nil
 }
 
-// parseEncryptedFile 读取并解析加密文件.
-func parseEncryptedFile(inputPath string) (header *format.FileHeader, ciphertext []byte, err error) {
-	// #nosec G304 - inputPath 应由调用方验证
-	encryptedData, err := os.ReadFile(inputPath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("read encrypted file: %w", err)
-	}
-
+// parseEncryptedData 解析内存中的加密数据，返回头部和密文.
+func parseEncryptedData(encryptedData []byte) (header *format.FileHeader, ciphertext []byte, err error) {
 	// 解析文件头
 	header, err = format.ParseFileHeaderFromBytes(encryptedData)
 	if err != nil {
@@ -163,31 +175,38 @@ func parseEncryptedFile(inputPath string) (header *format.FileHeader, ciphertext
 	return header, ciphertext, nil
 }
 
-// decapsulateKeys 解封装密钥.
+// decapsulateKeys 解封装密钥，返回的共享密钥由调用方 Release.
 func decapsulateKeys(
 	kyberPriv kem.PrivateKey,
 	ecdhPriv *ecdh.PrivateKey,
 	encapsulated []byte,
 	ecdhPub []byte,
-) ([]byte, error) {
+) (*SecretBuffer, error) {
 	decryptor := NewHybridDecryptor(kyberPriv, ecdhPriv)
-	sharedSecret, err := decryptor.Decapsulate(encapsulated, ecdhPub)
+	sharedSecret, err := decryptor.DecapsulateSecret(encapsulated, ecdhPub)
 	if err != nil {
 		return nil, fmt.Errorf("decapsulate: %w", err)
 	}
 	return sharedSecret, nil
 }
 
-// writeDecryptedFile 写入解密文件.
+// writeDecryptedFile 写入解密文件，返回前清零 plaintext.
 func writeDecryptedFile(outputPath string, plaintext []byte) error {
+	// 明文为调用方独占的解密结果，写入成功与否都不再使用
+	defer wipePlaintext(plaintext)
 	if err := os.WriteFile(outputPath, plaintext, decryptedFilePerm); err != nil {
 		return fmt.Errorf("write decrypted file: %w", err)
 	}
 	return nil
 }
 
+// wipePlaintext 清零不再使用的解密明文，测试可替换以检查清零的缓冲区.
+var wipePlaintext = func(plaintext []byte) {
+	clear(plaintext)
+}
+
 // verifyDecryptionIntegrity 验证解密数据的完整性和签名.
-func verifyDecryptionIntegrity(plaintext []byte, header *format.FileHeader, dilithiumPub *mode3.PublicKey) error {
+func verifyDecryptionIntegrity(plaintext []byte, header *format.FileHeader, dilithiumPub VerifyingKey) error {
 	// 验证哈希
 	hash := calculateHash(plaintext)
 	if hash != header.SHA256Hash {
@@ -211,7 +230,20 @@ func verifyDecryptionIntegrity(plaintext []byte, header *format.FileHeader, dili
 				"Signature length mismatch",
 			)
 		}
-		if len(header.Signature) != mode3.SignatureSize {
+		// 签名类型必须与验签公钥一致：复合公钥不接受单一 Dilithium3 签名（降级），
+		// 普通公钥也不能验证复合签名
+		wantSize, wantType := mode3.SignatureSize, format.SignatureDilithium3
+		if IsCompositeKey(dilithiumPub) {
+			wantSize, wantType = CompositeSignatureSize, format.SignatureComposite
+		}
+		if header.SignatureType() != wantType {
+			return utils.NewCryptoError(
+				utils.ErrVerificationFailed,
+				fmt.Sprintf("Signature type mismatch: file has %s, verification key expects %s",
+					format.SignatureTypeName(header.SignatureType()), format.SignatureTypeName(wantType)),
+			)
+		}
+		if len(header.Signature) != wantSize {
 			return utils.NewCryptoError(
 				utils.ErrVerificationFailed,
 				"Invalid signature size",
@@ -242,7 +274,19 @@ func EncryptFileCore(
 	inputPath string,
 	kyberPub kem.PublicKey,
 	ecdhPub *ecdh.PublicKey,
-	dilithiumPriv *mode3.PrivateKey,
+	dilithiumPriv SigningKey,
+) (header *format.FileHeader, ciphertext []byte, err error) {
+	return EncryptFileCoreWithCompression(inputPath, kyberPub, ecdhPub, dilithiumPriv, NoCompression)
+}
+
+// EncryptFileCoreWithCompression 加密文件的核心逻辑（支持加密前压缩）
+// 哈希和签名始终针对原始明文，压缩算法记录在头部 Flags 中.
+func EncryptFileCoreWithCompression(
+	inputPath string,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	compression CompressionOptions,
 ) (header *format.FileHeader, ciphertext []byte, err error) {
 	// #nosec G304 - inputPath 应由调用方验证
 	plaintext, err := os.ReadFile(inputPath)
@@ -253,6 +297,34 @@ func EncryptFileCore(
 		)
 	}
 
+	return EncryptDataCore(plaintext, filepath.Base(inputPath), kyberPub, ecdhPub, dilithiumPriv, compression)
+}
+
+// EncryptDataCore 加密内存中的数据，filename 记录在头部（可为任意展示名称）.
+func EncryptDataCore(
+	plaintext []byte,
+	filename string,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	compression CompressionOptions,
+) (header *format.FileHeader, ciphertext []byte, err error) {
+	return encryptDataCore(plaintext, filename, kyberPub, ecdhPub, dilithiumPriv, compression, false)
+}
+
+// encryptDataCore 同 EncryptDataCore；bindFilename 为 true 时文件名作为 AES-GCM 附加认证数据，
+// 头部设置 FlagFilenameBound，篡改文件名或清除标志都会导致解密失败.
+//
+//nolint:funlen
+func encryptDataCore(
+	plaintext []byte,
+	filename string,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	compression CompressionOptions,
+	bindFilename bool,
+) (header *format.FileHeader, ciphertext []byte, err error) {
 	// 1. 混合密钥封装
 	encapsulated, ecdhTempPub, sharedSecret, err := prepareEncryptionKeys(kyberPub, ecdhPub)
 	if err != nil {
@@ -261,9 +333,27 @@ func EncryptFileCore(
 			"Hybrid encapsulation failed: "+err.Error(),
 		)
 	}
+	defer sharedSecret.Release()
+
+	// 2. 可选压缩
+	payload, codec, err := compressPayload(plaintext, compression)
+	if err != nil {
+		return nil, nil, utils.NewCryptoError(
+			utils.ErrEncryptionFailed,
+			"Compression failed: "+err.Error(),
+		)
+	}
 
-	// 2. AES-GCM 加密
-	ciphertext, iv, err := encryptAESGCM(sharedSecret, plaintext)
+	// 3. AES-GCM 加密
+	var aad []byte
+	if bindFilename {
+		aad = []byte(filename)
+	}
+	ciphertext, iv, err := encryptAESGCM(sharedSecret.Bytes(), payload, aad)
+	if codec != format.CompressionNone {
+		// 压缩后的中间数据由本函数持有
+		clear(payload)
+	}
 	if err != nil {
 		return nil, nil, utils.NewCryptoError(
 			utils.ErrEncryptionFailed,
@@ -271,10 +361,10 @@ func EncryptFileCore(
 		)
 	}
 
-	// 3. 计算哈希
+	// 4. 计算哈希
 	hash := calculateHash(plaintext)
 
-	// 4. 签名
+	// 5. 签名
 	signature, err := signHash(hash[:], dilithiumPriv)
 	if err != nil {
 		return nil, nil, utils.NewCryptoError(
@@ -283,8 +373,7 @@ func EncryptFileCore(
 		)
 	}
 
-	// 5. 构建头部
-	filename := filepath.Base(inputPath)
+	// 6. 构建头部
 	header, err = buildFileHeader(
 		filename,
 		uint64(len(plaintext)),
@@ -297,23 +386,75 @@ func EncryptFileCore(
 	if err != nil {
 		return nil, nil, err
 	}
+	header.SetCompression(codec)
+	if bindFilename {
+		header.Flags |= format.FlagFilenameBound
+	}
 
 	return header, ciphertext, nil
 }
 
+// EncryptData 加密内存中的数据并返回完整的加密文件内容（头部 + 密文）.
+func EncryptData(
+	plaintext []byte,
+	filename string,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	compression CompressionOptions,
+) ([]byte, error) {
+	header, ciphertext, err := EncryptDataCore(plaintext, filename, kyberPub, ecdhPub, dilithiumPriv, compression)
+	if err != nil {
+		return nil, err
+	}
+	return encodeEncryptedData(header, ciphertext)
+}
+
 // DecryptFileCore 解密文件的核心逻辑
 // 这个函数被 DecryptFile 和 StreamingDecryptor 共用.
 func DecryptFileCore(
 	inputPath string,
 	kyberPriv kem.PrivateKey,
 	ecdhPriv *ecdh.PrivateKey,
-	dilithiumPub *mode3.PublicKey,
+	dilithiumPub VerifyingKey,
 ) (plaintext []byte, err error) {
-	// 1. 读取并解析文件
-	header, ciphertext, err := parseEncryptedFile(inputPath)
+	// #nosec G304 - inputPath 应由调用方验证
+	encryptedData, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("read encrypted file: %w", err)
+	}
+	return DecryptDataCore(encryptedData, kyberPriv, ecdhPriv, dilithiumPub)
+}
+
+// DecryptDataCore 解密内存中的完整加密数据（头部 + 密文）.
+func DecryptDataCore(
+	encryptedData []byte,
+	kyberPriv kem.PrivateKey,
+	ecdhPriv *ecdh.PrivateKey,
+	dilithiumPub VerifyingKey,
+) (plaintext []byte, err error) {
+	// 1. 解析文件
+	header, ciphertext, err := parseEncryptedData(encryptedData)
 	if err != nil {
 		return nil, err
 	}
+	if header.IsIndexedArchive() {
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidFormat,
+			"Indexed directory archive - use OpenIndexedArchive (decrypt-dir) instead",
+		)
+	}
+	if header.IsChunked() {
+		reader, err := OpenReaderAt(bytes.NewReader(encryptedData), int64(len(encryptedData)), kyberPriv, ecdhPriv, dilithiumPub)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("read chunked file: %w", err)
+		}
+		return plaintext, nil
+	}
 
 	// 2. 密钥解封装
 	sharedSecret, err := decapsulateKeys(kyberPriv, ecdhPriv, header.KyberEnc, header.ECDHPub[:])
@@ -325,7 +466,12 @@ func DecryptFileCore(
 	}
 
 	// 3. AES-GCM 解密
-	plaintext, err = decryptAESGCM(sharedSecret, ciphertext, header.IV[:])
+	var aad []byte
+	if header.IsFilenameBound() {
+		aad = []byte(header.Filename)
+	}
+	payload, err := decryptAESGCM(sharedSecret.Bytes(), ciphertext, header.IV[:], aad)
+	sharedSecret.Release()
 	if err != nil {
 		return nil, utils.NewCryptoError(
 			utils.ErrDecryptionFailed,
@@ -333,8 +479,18 @@ func DecryptFileCore(
 		)
 	}
 
-	// 4. 验证完整性和签名
+	// 4. 按头部标志解压，解压前的中间数据随即清零
+	plaintext, err = decompressData(payload, header.Compression(), header.FileSize)
+	if header.Compression() != format.CompressionNone {
+		clear(payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. 验证完整性和签名，未通过验证的明文不返回也不保留
 	if err := verifyDecryptionIntegrity(plaintext, header, dilithiumPub); err != nil {
+		clear(plaintext)
 		return nil, err
 	}
 
@@ -7,7 +7,6 @@ import (
 
 	"codeberg.org/jiangfire/fzjjyz/internal/utils"
 	"github.com/cloudflare/circl/kem"
-	"github.com/cloudflare/circl/sign/dilithium/mode3"
 )
 
 // EncryptFileStreaming 流式加密文件
@@ -32,7 +31,7 @@ func EncryptFileStreaming(
 	inputPath, outputPath string,
 	kyberPub kem.PublicKey,
 	ecdhPub *ecdh.PublicKey,
-	dilithiumPriv *mode3.PrivateKey,
+	dilithiumPriv SigningKey,
 	bufferSize int,
 ) error {
 	encryptor, err := NewStreamingEncryptor(kyberPub, ecdhPub, dilithiumPriv, bufferSize)
@@ -64,7 +63,7 @@ func DecryptFileStreaming(
 	inputPath, outputPath string,
 	kyberPriv kem.PrivateKey,
 	ecdhPriv *ecdh.PrivateKey,
-	dilithiumPub *mode3.PublicKey,
+	dilithiumPub VerifyingKey,
 	bufferSize int,
 ) error {
 	decryptor, err := NewStreamingDecryptor(kyberPriv, ecdhPriv, dilithiumPub, bufferSize)
@@ -80,7 +79,7 @@ func EncryptFileStreamingAuto(
 	inputPath, outputPath string,
 	kyberPub kem.PublicKey,
 	ecdhPub *ecdh.PublicKey,
-	dilithiumPriv *mode3.PrivateKey,
+	dilithiumPriv SigningKey,
 ) error {
 	// 获取文件大小
 	info, err := os.Stat(inputPath)
@@ -101,7 +100,7 @@ func DecryptFileStreamingAuto(
 	inputPath, outputPath string,
 	kyberPriv kem.PrivateKey,
 	ecdhPriv *ecdh.PrivateKey,
-	dilithiumPub *mode3.PublicKey,
+	dilithiumPub VerifyingKey,
 ) error {
 	// 获取文件大小
 	info, err := os.Stat(inputPath)
@@ -0,0 +1,449 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+// 纸质备份文本格式:
+//
+//	# fzjjyz paper key v1
+//	# type: hybrid-private
+//	# size: 2432
+//	# sha256: <密钥字节的 SHA-256>
+//	001 ABCD EFGH IJKL MNOP QRST UVWX YZ23 4567 1A2B3C4D
+//
+// 每行携带 20 字节密钥（32 个 Base32 字符，分为 4 字符一组），行尾为
+// CRC32(行号 || 本行数据)，抄写错误可以定位到具体行；头部的 SHA-256 覆盖全部密钥字节.
+//
+// 私钥保留种子时（keygen 生成或由助记词派生，种子随私钥文件保存，见 keyseed.go）
+// 只备份种子：hybrid-seed 为 64 字节 Kyber 种子 || 32 字节 X25519 私钥，共 5 行；
+// dilithium-seed 为 32 字节种子，共 2 行；composite-seed 为 32 字节 Dilithium3 种子 ||
+// 32 字节 Ed25519 种子，共 4 行；恢复时用 DeriveKeyPair / NewKeyFromSeed 重建.
+// circl 无法从展开的私钥反推种子，因此旧版本生成、不含种子块的私钥文件只能备份
+// 展开的私钥字节（hybrid-private 约 122 行、dilithium-private 200 行）.
+
+const (
+	paperKeyTitle        = "fzjjyz paper key v1"
+	paperKeyBytesPerLine = 20
+	paperKeyGroupSize    = 4
+)
+
+var paperKeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// 手抄时容易混淆的字符，Base32 字母表中不含 0、1、8.
+var paperKeyConfusables = strings.NewReplacer("0", "O", "1", "I", "8", "B")
+
+// PaperKeyKind 纸质备份中的密钥类型.
+type PaperKeyKind int
+
+// 纸质备份密钥类型.
+const (
+	PaperHybridPrivate PaperKeyKind = iota + 1
+	PaperHybridPublic
+	PaperDilithiumPrivate
+	PaperDilithiumPublic
+	PaperHybridSeed
+	PaperDilithiumSeed
+	PaperCompositeSeed
+)
+
+var paperKeyKindNames = map[PaperKeyKind]string{
+	PaperHybridPrivate:    "hybrid-private",
+	PaperHybridPublic:     "hybrid-public",
+	PaperDilithiumPrivate: "dilithium-private",
+	PaperDilithiumPublic:  "dilithium-public",
+	PaperHybridSeed:       "hybrid-seed",
+	PaperDilithiumSeed:    "dilithium-seed",
+	PaperCompositeSeed:    "composite-seed",
+}
+
+func (k PaperKeyKind) String() string {
+	if name, ok := paperKeyKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("PaperKeyKind(%d)", int(k))
+}
+
+// IsPrivate 是否为私钥.
+func (k PaperKeyKind) IsPrivate() bool {
+	switch k {
+	case PaperHybridPrivate, PaperDilithiumPrivate, PaperHybridSeed, PaperDilithiumSeed, PaperCompositeSeed:
+		return true
+	default:
+		return false
+	}
+}
+
+// size 返回该类型密钥字节的固定长度.
+func (k PaperKeyKind) size() int {
+	scheme := kyber768.Scheme()
+	switch k {
+	case PaperHybridPrivate:
+		return scheme.PrivateKeySize() + 32
+	case PaperHybridPublic:
+		return scheme.PublicKeySize() + 32
+	case PaperDilithiumPrivate:
+		return mode3.PrivateKeySize
+	case PaperDilithiumPublic:
+		return mode3.PublicKeySize
+	case PaperHybridSeed:
+		return kyber768.KeySeedSize + 32
+	case PaperDilithiumSeed:
+		return mode3.SeedSize
+	case PaperCompositeSeed:
+		return mode3.SeedSize + ed25519.SeedSize
+	default:
+		return 0
+	}
+}
+
+// PaperKey 纸质备份的内容：密钥类型和原始密钥字节.
+type PaperKey struct {
+	Kind PaperKeyKind
+	Data []byte
+}
+
+// NewPaperKey 从密钥创建纸质备份.
+// key 可以是 *HybridPrivateKey、*HybridPublicKey、*mode3.PrivateKey、*mode3.PublicKey
+// 或 *CompositePrivateKey；私钥保留种子时备份种子，复合私钥必须保留 Dilithium3 种子.
+func NewPaperKey(key any) (*PaperKey, error) {
+	switch k := key.(type) {
+	case *HybridPrivateKey:
+		if k.KyberSeed != nil {
+			if len(k.KyberSeed) != kyber768.KeySeedSize {
+				return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+					fmt.Sprintf("Invalid Kyber seed size: %d", len(k.KyberSeed)))
+			}
+			return &PaperKey{Kind: PaperHybridSeed, Data: append(bytes.Clone(k.KyberSeed), k.ECDH.Bytes()...)}, nil
+		}
+		kyberBytes, err := k.Kyber.MarshalBinary()
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrSerializationFailed,
+				fmt.Sprintf("Failed to marshal Kyber private key: %v", err))
+		}
+		defer clear(kyberBytes)
+		return &PaperKey{Kind: PaperHybridPrivate, Data: append(bytes.Clone(kyberBytes), k.ECDH.Bytes()...)}, nil
+	case *HybridPublicKey:
+		kyberBytes, err := k.Kyber.MarshalBinary()
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrSerializationFailed,
+				fmt.Sprintf("Failed to marshal Kyber public key: %v", err))
+		}
+		return &PaperKey{Kind: PaperHybridPublic, Data: append(kyberBytes, k.ECDH.Bytes()...)}, nil
+	case *mode3.PrivateKey:
+		if seed := k.Seed(); seed != nil {
+			return &PaperKey{Kind: PaperDilithiumSeed, Data: seed}, nil
+		}
+		return &PaperKey{Kind: PaperDilithiumPrivate, Data: k.Bytes()}, nil
+	case *mode3.PublicKey:
+		return &PaperKey{Kind: PaperDilithiumPublic, Data: k.Bytes()}, nil
+	case *CompositePrivateKey:
+		seed := k.Dilithium.Seed()
+		if seed == nil {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+				"Composite key without a Dilithium3 seed cannot be backed up on paper")
+		}
+		return &PaperKey{Kind: PaperCompositeSeed, Data: append(seed, k.Ed25519.Seed()...)}, nil
+	case *CompositePublicKey:
+		return nil, errCompositeUnsupported()
+	default:
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, fmt.Sprintf("Unsupported key type %T", key))
+	}
+}
+
+// Key 还原密钥，返回类型与 NewPaperKey 接受的类型对应.
+func (p *PaperKey) Key() (any, error) {
+	if len(p.Data) != p.Kind.size() {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+			fmt.Sprintf("Invalid %s key size: %d", p.Kind, len(p.Data)))
+	}
+	scheme := kyber768.Scheme()
+	switch p.Kind {
+	case PaperHybridPrivate:
+		kyberPriv, err := scheme.UnmarshalBinaryPrivateKey(p.Data[:scheme.PrivateKeySize()])
+		if err != nil {
+			return nil, paperKeyInvalid(err)
+		}
+		ecdhPriv, err := ecdh.X25519().NewPrivateKey(p.Data[scheme.PrivateKeySize():])
+		if err != nil {
+			return nil, paperKeyInvalid(err)
+		}
+		return &HybridPrivateKey{Kyber: kyberPriv, ECDH: ecdhPriv}, nil
+	case PaperHybridSeed:
+		seed := p.Data[:kyber768.KeySeedSize]
+		_, kyberPriv, err := kyberFromSeed(seed)
+		if err != nil {
+			return nil, err
+		}
+		ecdhPriv, err := ecdh.X25519().NewPrivateKey(p.Data[kyber768.KeySeedSize:])
+		if err != nil {
+			return nil, paperKeyInvalid(err)
+		}
+		return &HybridPrivateKey{Kyber: kyberPriv, ECDH: ecdhPriv, KyberSeed: bytes.Clone(seed)}, nil
+	case PaperHybridPublic:
+		kyberPub, err := scheme.UnmarshalBinaryPublicKey(p.Data[:scheme.PublicKeySize()])
+		if err != nil {
+			return nil, paperKeyInvalid(err)
+		}
+		ecdhPub, err := ecdh.X25519().NewPublicKey(p.Data[scheme.PublicKeySize():])
+		if err != nil {
+			return nil, paperKeyInvalid(err)
+		}
+		return &HybridPublicKey{Kyber: kyberPub, ECDH: ecdhPub}, nil
+	case PaperDilithiumPrivate:
+		var priv mode3.PrivateKey
+		if err := priv.UnmarshalBinary(p.Data); err != nil {
+			return nil, paperKeyInvalid(err)
+		}
+		return &priv, nil
+	case PaperDilithiumSeed:
+		return dilithiumFromSeed(p.Data)
+	case PaperCompositeSeed:
+		dilithium, err := dilithiumFromSeed(p.Data[:mode3.SeedSize])
+		if err != nil {
+			return nil, err
+		}
+		return NewCompositeSigningKey(dilithium, ed25519.NewKeyFromSeed(p.Data[mode3.SeedSize:]))
+	case PaperDilithiumPublic:
+		var pub mode3.PublicKey
+		if err := pub.UnmarshalBinary(p.Data); err != nil {
+			return nil, paperKeyInvalid(err)
+		}
+		return &pub, nil
+	default:
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, fmt.Sprintf("Unknown paper key type %v", p.Kind))
+	}
+}
+
+// PEM 还原密钥并编码为原生 PEM 格式，与 keygen 生成的密钥文件相同.
+func (p *PaperKey) PEM() ([]byte, error) {
+	key, err := p.Key()
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *HybridPrivateKey:
+		return ExportHybridPrivateKey(k)
+	case *HybridPublicKey:
+		return ExportPublicKey(k.Kyber, k.ECDH)
+	case *mode3.PrivateKey:
+		pair, err := ExportDilithiumKeys(DilithiumPublicFromPrivate(k), k)
+		if err != nil {
+			return nil, err
+		}
+		return pair.Private, nil
+	case *CompositePrivateKey:
+		pair, err := ExportDilithiumKeys(k.PublicKey(), k)
+		if err != nil {
+			return nil, err
+		}
+		return pair.Private, nil
+	default:
+		return pem.EncodeToMemory(&pem.Block{Type: "DILITHIUM3 PUBLIC KEY", Bytes: p.Data}), nil
+	}
+}
+
+func paperKeyInvalid(err error) error {
+	return utils.NewCryptoError(utils.ErrInvalidKey, fmt.Sprintf("Invalid key in paper backup: %v", err))
+}
+
+// Wipe 清零密钥字节.
+func (p *PaperKey) Wipe() {
+	clear(p.Data)
+}
+
+// Header 返回文本形式的头部行.
+func (p *PaperKey) Header() []string {
+	sum := sha256.Sum256(p.Data)
+	return []string{
+		"# " + paperKeyTitle,
+		"# type: " + p.Kind.String(),
+		"# size: " + strconv.Itoa(len(p.Data)),
+		"# sha256: " + hex.EncodeToString(sum[:]),
+	}
+}
+
+// Lines 返回带行号和校验和的数据行.
+func (p *PaperKey) Lines() []string {
+	var lines []string
+	for i, number := 0, 1; i < len(p.Data); i, number = i+paperKeyBytesPerLine, number+1 {
+		chunk := p.Data[i:min(i+paperKeyBytesPerLine, len(p.Data))]
+		encoded := paperKeyEncoding.EncodeToString(chunk)
+		var b strings.Builder
+		fmt.Fprintf(&b, "%03d", number)
+		for j := 0; j < len(encoded); j += paperKeyGroupSize {
+			b.WriteByte(' ')
+			b.WriteString(encoded[j:min(j+paperKeyGroupSize, len(encoded))])
+		}
+		fmt.Fprintf(&b, " %08X", paperLineChecksum(number, chunk))
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// Text 返回完整的文本形式.
+func (p *PaperKey) Text() []byte {
+	return []byte(strings.Join(append(p.Header(), p.Lines()...), "\n") + "\n")
+}
+
+// Parts 将文本形式拆分为若干份，每份包含完整头部和最多 linesPerPart 行数据，
+// 用于生成多个二维码. 各份按任意顺序拼接后都能由 ParsePaperKey 解析.
+func (p *PaperKey) Parts(linesPerPart int) [][]byte {
+	header := p.Header()
+	lines := p.Lines()
+	var parts [][]byte
+	for i := 0; i < len(lines); i += linesPerPart {
+		part := append(append([]string{}, header...), lines[i:min(i+linesPerPart, len(lines))]...)
+		parts = append(parts, []byte(strings.Join(part, "\n")+"\n"))
+	}
+	return parts
+}
+
+func paperLineChecksum(number int, chunk []byte) uint32 {
+	var prefix [2]byte
+	binary.BigEndian.PutUint16(prefix[:], uint16(number))
+	crc := crc32.Update(0, crc32.IEEETable, prefix[:])
+	return crc32.Update(crc, crc32.IEEETable, chunk)
+}
+
+// ParsePaperKey 解析纸质备份的文本形式.
+// 大小写、多余空白以及 0/1/8 与 O/I/B 的混淆都会被容忍；
+// 校验和不符、缺失或格式错误的行会在错误信息中逐行列出.
+func ParsePaperKey(text []byte) (*PaperKey, error) {
+	header := map[string]string{}
+	chunks := map[int][]byte{}
+	reported := map[int]bool{} // 已报告错误的行不再报告缺失
+	var problems []string
+	maxLine := 0
+
+	for raw := range strings.SplitSeq(string(text), "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if comment, ok := strings.CutPrefix(line, "#"); ok {
+			key, value, found := strings.Cut(comment, ":")
+			if !found {
+				continue
+			}
+			key, value = strings.TrimSpace(strings.ToLower(key)), strings.TrimSpace(value)
+			if prev, seen := header[key]; seen && prev != value {
+				return nil, utils.NewCryptoError(utils.ErrInvalidFormat,
+					fmt.Sprintf("Conflicting %q header: %q and %q", key, prev, value))
+			}
+			header[key] = value
+			continue
+		}
+
+		number, chunk, err := parsePaperLine(line)
+		if err != nil {
+			problems = append(problems, err.Error())
+			reported[number] = true
+			continue
+		}
+		if prev, seen := chunks[number]; seen && !bytes.Equal(prev, chunk) {
+			problems = append(problems, fmt.Sprintf("line %d: appears twice with different data", number))
+			reported[number] = true
+			continue
+		}
+		chunks[number] = chunk
+		maxLine = max(maxLine, number)
+	}
+
+	kind, size, sum, err := parsePaperHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	wantLines := (size + paperKeyBytesPerLine - 1) / paperKeyBytesPerLine
+	for number := 1; number <= max(wantLines, maxLine); number++ {
+		chunk, ok := chunks[number]
+		switch {
+		case number > wantLines:
+			problems = append(problems, fmt.Sprintf("line %d: unexpected, the key has %d lines", number, wantLines))
+		case !ok && reported[number]:
+		case !ok:
+			problems = append(problems, fmt.Sprintf("line %d: missing", number))
+		case len(chunk) != min(paperKeyBytesPerLine, size-(number-1)*paperKeyBytesPerLine):
+			problems = append(problems, fmt.Sprintf("line %d: wrong length", number))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidData, strings.Join(problems, "; "))
+	}
+
+	data := make([]byte, 0, size)
+	for number := 1; number <= wantLines; number++ {
+		data = append(data, chunks[number]...)
+		clear(chunks[number])
+	}
+	got := sha256.Sum256(data)
+	if subtle.ConstantTimeCompare(got[:], sum) != 1 {
+		clear(data)
+		return nil, utils.NewCryptoError(utils.ErrHashMismatch, "Paper key SHA-256 does not match the header")
+	}
+	return &PaperKey{Kind: kind, Data: data}, nil
+}
+
+// parsePaperLine 解析一行数据，返回行号和解码后的字节.
+func parsePaperLine(line string) (int, []byte, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, nil, fmt.Errorf("line %q: too few fields", line)
+	}
+	number, err := strconv.Atoi(strings.TrimSuffix(fields[0], ":"))
+	if err != nil || number <= 0 || number > 0xffff {
+		return 0, nil, fmt.Errorf("line %q: invalid line number", fields[0])
+	}
+	checksum, err := strconv.ParseUint(fields[len(fields)-1], 16, 32)
+	if err != nil {
+		return number, nil, fmt.Errorf("line %d: invalid checksum %q", number, fields[len(fields)-1])
+	}
+	encoded := paperKeyConfusables.Replace(strings.ToUpper(strings.Join(fields[1:len(fields)-1], "")))
+	chunk, err := paperKeyEncoding.DecodeString(encoded)
+	if err != nil {
+		return number, nil, fmt.Errorf("line %d: invalid characters", number)
+	}
+	if paperLineChecksum(number, chunk) != uint32(checksum) {
+		return number, nil, fmt.Errorf("line %d: checksum mismatch", number)
+	}
+	return number, chunk, nil
+}
+
+func parsePaperHeader(header map[string]string) (PaperKeyKind, int, []byte, error) {
+	var kind PaperKeyKind
+	for k, name := range paperKeyKindNames {
+		if header["type"] == name {
+			kind = k
+		}
+	}
+	if kind == 0 {
+		return 0, 0, nil, utils.NewCryptoError(utils.ErrInvalidFormat,
+			fmt.Sprintf("Missing or unknown paper key type %q", header["type"]))
+	}
+	size, err := strconv.Atoi(header["size"])
+	if err != nil || size != kind.size() {
+		return 0, 0, nil, utils.NewCryptoError(utils.ErrInvalidFormat,
+			fmt.Sprintf("Invalid size %q for %s key", header["size"], kind))
+	}
+	sum, err := hex.DecodeString(header["sha256"])
+	if err != nil || len(sum) != sha256.Size {
+		return 0, 0, nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Missing or invalid sha256 header")
+	}
+	return kind, size, sum, nil
+}
@@ -0,0 +1,255 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+func TestPaperKeyRoundTrip(t *testing.T) {
+	kyberPub, kyberPriv, ecdhPub, ecdhPriv, err := GenerateHybridKeysParallel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signPub, signPriv, err := GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, seededKyber, kyberSeed, err := GenerateSeededKyberKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 从展开的字节还原的私钥不含种子，与旧版本的私钥文件相同
+	var expanded mode3.PrivateKey
+	if err := expanded.UnmarshalBinary(signPriv.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	keys := map[PaperKeyKind]any{
+		PaperHybridPrivate:    &HybridPrivateKey{Kyber: kyberPriv, ECDH: ecdhPriv},
+		PaperHybridSeed:       &HybridPrivateKey{Kyber: seededKyber, ECDH: ecdhPriv, KyberSeed: kyberSeed},
+		PaperHybridPublic:     &HybridPublicKey{Kyber: kyberPub, ECDH: ecdhPub},
+		PaperDilithiumPrivate: &expanded,
+		PaperDilithiumSeed:    signPriv,
+		PaperDilithiumPublic:  signPub,
+	}
+	for kind, key := range keys {
+		paper, err := NewPaperKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if paper.Kind != kind || len(paper.Data) != kind.size() {
+			t.Fatalf("%s: 类型或长度不正确 %s/%d", kind, paper.Kind, len(paper.Data))
+		}
+		parsed, err := ParsePaperKey(paper.Text())
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if parsed.Kind != kind || !bytes.Equal(parsed.Data, paper.Data) {
+			t.Fatalf("%s: 解析结果与原始密钥不同", kind)
+		}
+		restored, err := parsed.Key()
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		again, err := NewPaperKey(restored)
+		if err != nil || !bytes.Equal(again.Data, paper.Data) {
+			t.Fatalf("%s: 还原的密钥不同", kind)
+		}
+	}
+
+	// 种子备份只需几行，还原出与原私钥相同的展开密钥
+	paper, _ := NewPaperKey(keys[PaperHybridSeed])
+	if n := len(paper.Lines()); n != 5 {
+		t.Fatalf("hybrid-seed 应为 5 行，实际 %d", n)
+	}
+	restoredHybrid, err := paper.Key()
+	if err != nil || !restoredHybrid.(*HybridPrivateKey).Kyber.Equal(seededKyber) {
+		t.Fatalf("由种子还原的 Kyber 私钥不同: %v", err)
+	}
+	paper, _ = NewPaperKey(signPriv)
+	if n := len(paper.Lines()); n != 2 {
+		t.Fatalf("dilithium-seed 应为 2 行，实际 %d", n)
+	}
+
+	// 还原的 Dilithium 私钥能够签名
+	paper, _ = NewPaperKey(signPriv)
+	parsed, err := ParsePaperKey(paper.Text())
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, _ := parsed.Key()
+	sig, err := SignData([]byte("paper"), restored.(*mode3.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifySignature([]byte("paper"), sig, signPub); err != nil || !ok {
+		t.Fatalf("还原的私钥签名无法验证: %v", err)
+	}
+}
+
+func TestPaperKeyComposite(t *testing.T) {
+	pub, priv, err := GenerateCompositeKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	paper, err := NewPaperKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paper.Kind != PaperCompositeSeed || !paper.Kind.IsPrivate() || len(paper.Lines()) != 4 {
+		t.Fatalf("复合私钥应备份为 4 行 composite-seed，实际 %s/%d 行", paper.Kind, len(paper.Lines()))
+	}
+	parsed, err := ParsePaperKey(paper.Text())
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := parsed.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	composite, ok := restored.(*CompositePrivateKey)
+	if !ok || !composite.Equal(priv) {
+		t.Fatalf("还原的复合私钥不同: %T", restored)
+	}
+	sig, err := SignDataWithKey([]byte("paper"), composite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifySignatureWithKey([]byte("paper"), sig, pub); err != nil || !ok {
+		t.Fatalf("还原的复合私钥签名无法验证: %v", err)
+	}
+
+	// 还原的 PEM 与 keygen --composite 生成的私钥文件相同
+	out, err := parsed.PEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := parseDilithiumPrivateKey(out)
+	if err != nil || !loaded.Equal(priv) {
+		t.Fatalf("复合私钥 PEM 还原失败: %v", err)
+	}
+}
+
+func TestPaperKeyTolerance(t *testing.T) {
+	_, signPriv, err := GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	paper, err := NewPaperKey(DilithiumPublicFromPrivate(signPriv))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 小写、缩进、O→0 的混淆和 Windows 换行都可以解析
+	text := strings.ToLower(string(paper.Text()))
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "o", "0"), "\n", "\r\n  ")
+	text = strings.ReplaceAll(text, "# sha256", "# SHA256")
+	if _, err := ParsePaperKey([]byte(text)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 二维码分片按任意顺序拼接
+	parts := paper.Parts(30)
+	if len(parts) < 2 {
+		t.Fatalf("应拆分为多份，实际 %d", len(parts))
+	}
+	var joined []byte
+	for i := len(parts) - 1; i >= 0; i-- {
+		joined = append(joined, parts[i]...)
+	}
+	parsed, err := ParsePaperKey(joined)
+	if err != nil || !bytes.Equal(parsed.Data, paper.Data) {
+		t.Fatalf("分片拼接后解析失败: %v", err)
+	}
+}
+
+func TestPaperKeyErrors(t *testing.T) {
+	_, signPriv, err := GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	paper, err := NewPaperKey(DilithiumPublicFromPrivate(signPriv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, lines := paper.Header(), paper.Lines()
+	build := func(lines []string) []byte {
+		return []byte(strings.Join(append(append([]string{}, header...), lines...), "\n"))
+	}
+
+	// 抄错第 17 行和第 42 行的一个字符
+	typo := append([]string{}, lines...)
+	for _, i := range []int{16, 41} {
+		r := []byte(typo[i])
+		if r[4] == 'A' {
+			r[4] = 'B'
+		} else {
+			r[4] = 'A'
+		}
+		typo[i] = string(r)
+	}
+	_, err = ParsePaperKey(build(typo))
+	if err == nil || !strings.Contains(err.Error(), "line 17: checksum mismatch") ||
+		!strings.Contains(err.Error(), "line 42: checksum mismatch") {
+		t.Fatalf("应指出第 17 和 42 行: %v", err)
+	}
+	if strings.Contains(err.Error(), "missing") {
+		t.Fatalf("校验失败的行不应再报告缺失: %v", err)
+	}
+
+	// 漏抄一行、行号调换
+	missing := append(append([]string{}, lines[:4]...), lines[5:]...)
+	if _, err := ParsePaperKey(build(missing)); err == nil || !strings.Contains(err.Error(), "line 5: missing") {
+		t.Fatalf("应指出第 5 行缺失: %v", err)
+	}
+	swapped := append([]string{}, lines...)
+	swapped[2] = "004" + lines[2][3:]
+	swapped[3] = "003" + lines[3][3:]
+	if _, err := ParsePaperKey(build(swapped)); err == nil || !strings.Contains(err.Error(), "line 3: checksum mismatch") {
+		t.Fatalf("行号调换应导致校验失败: %v", err)
+	}
+
+	// 头部缺失或不匹配
+	if _, err := ParsePaperKey([]byte(strings.Join(lines, "\n"))); err == nil {
+		t.Fatal("缺少头部时应失败")
+	}
+	other, _ := NewPaperKey(DilithiumPublicFromPrivate(signPriv))
+	other.Data[0] ^= 1
+	mixed := append(build(lines), []byte("\n"+strings.Join(other.Header(), "\n"))...)
+	if _, err := ParsePaperKey(mixed); err == nil {
+		t.Fatal("头部冲突时应失败")
+	}
+	if _, err := NewPaperKey("key"); err == nil {
+		t.Fatal("不支持的密钥类型应失败")
+	}
+}
+
+func TestPaperKeyPEM(t *testing.T) {
+	signPub, signPriv, err := GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []any{signPriv, signPub} {
+		paper, err := NewPaperKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := paper.PEM()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if paper.Kind.IsPrivate() {
+			priv, err := parseDilithiumPrivateKey(out)
+			if err != nil || !priv.Equal(signPriv) {
+				t.Fatalf("私钥 PEM 还原失败: %v", err)
+			}
+		} else {
+			pub, err := parseDilithiumPublicKey(out)
+			if err != nil || !pub.Equal(signPub) {
+				t.Fatalf("公钥 PEM 还原失败: %v", err)
+			}
+		}
+	}
+}
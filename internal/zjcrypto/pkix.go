@@ -0,0 +1,291 @@
+package zjcrypto
+
+import (
+	"crypto/ecdh"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+	"github.com/cloudflare/circl/sign/dilithium/mode3"
+)
+
+// 标准 PEM 类型.
+const (
+	pemTypePublicKey  = "PUBLIC KEY"
+	pemTypePrivateKey = "PRIVATE KEY"
+)
+
+var (
+	// oidX25519 RFC 8410.
+	oidX25519 = asn1.ObjectIdentifier{1, 3, 101, 110}
+
+	// oidKyber768 与 oidDilithium3 是 Open Quantum Safe 为第三轮 Kyber768 与 Dilithium3 分配的私有 OID.
+	// 本工具使用的是第三轮算法而非 FIPS 203/204，不能使用 ML-KEM / ML-DSA 的 OID，
+	// 因此这两种分量只能由本工具读取，不与 OpenSSL 3.5 等实现的 ML-KEM / ML-DSA 密钥互通.
+	oidKyber768   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 22554, 5, 6, 2}
+	oidDilithium3 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 2, 267, 7, 6, 5}
+
+	// oidNISTKEM 与 oidNISTSig 是 NIST 算法 OID 的前缀，仅用于识别 ML-KEM / ML-DSA 密钥并给出明确的错误.
+	oidNISTKEM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4}
+	oidNISTSig = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3}
+)
+
+// subjectPublicKeyInfo RFC 5280.
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// oneAsymmetricKey RFC 5958 中本工具使用的字段.
+type oneAsymmetricKey struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// MarshalPKIXPublicKey 把 Kyber768、X25519 或 Dilithium3 公钥编码为 DER 格式的 SubjectPublicKeyInfo.
+func MarshalPKIXPublicKey(pub any) ([]byte, error) {
+	var (
+		oid asn1.ObjectIdentifier
+		raw []byte
+		err error
+	)
+	switch k := pub.(type) {
+	case *ecdh.PublicKey:
+		if k.Curve() != ecdh.X25519() {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Only X25519 ECDH keys are supported")
+		}
+		//nolint:wrapcheck
+		return x509.MarshalPKIXPublicKey(k)
+	case *mode3.PublicKey:
+		oid, raw = oidDilithium3, k.Bytes()
+	case *CompositePublicKey:
+		return nil, errCompositeUnsupported()
+	case kem.PublicKey:
+		if k.Scheme() != kyber768.Scheme() {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Only Kyber768 KEM keys are supported")
+		}
+		oid = oidKyber768
+		raw, err = k.MarshalBinary()
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrSerializationFailed,
+				fmt.Sprintf("Failed to marshal Kyber public key: %v", err))
+		}
+	default:
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, fmt.Sprintf("Unsupported public key type %T", pub))
+	}
+	//nolint:wrapcheck
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oid},
+		PublicKey: asn1.BitString{Bytes: raw, BitLength: 8 * len(raw)},
+	})
+}
+
+// ParsePKIXPublicKey 解析 DER 格式的 SubjectPublicKeyInfo，
+// 返回 kem.PublicKey（Kyber768）、*ecdh.PublicKey（X25519）或 *mode3.PublicKey.
+func ParsePKIXPublicKey(der []byte) (any, error) {
+	var info subjectPublicKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil || len(rest) != 0 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Invalid SubjectPublicKeyInfo")
+	}
+	if err := checkKeyAlgorithm(info.Algorithm); err != nil {
+		return nil, err
+	}
+	raw := info.PublicKey.RightAlign()
+	switch {
+	case info.Algorithm.Algorithm.Equal(oidX25519):
+		//nolint:wrapcheck
+		return x509.ParsePKIXPublicKey(der)
+	case info.Algorithm.Algorithm.Equal(oidKyber768):
+		pub, err := kyber768.Scheme().UnmarshalBinaryPublicKey(raw)
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+				fmt.Sprintf("Failed to parse Kyber public key: %v", err))
+		}
+		return pub, nil
+	default:
+		var pub mode3.PublicKey
+		if err := pub.UnmarshalBinary(raw); err != nil {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+				fmt.Sprintf("Failed to parse Dilithium3 public key: %v", err))
+		}
+		return &pub, nil
+	}
+}
+
+// MarshalPKCS8PrivateKey 把 Kyber768、X25519 或 Dilithium3 私钥编码为 DER 格式的 PKCS#8.
+// 私钥字段与 RFC 8410 相同，为包含原始私钥的 OCTET STRING；返回值含私钥，调用方用完后应清零.
+func MarshalPKCS8PrivateKey(priv any) ([]byte, error) {
+	var (
+		oid asn1.ObjectIdentifier
+		raw []byte
+		err error
+	)
+	switch k := priv.(type) {
+	case *ecdh.PrivateKey:
+		if k.Curve() != ecdh.X25519() {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Only X25519 ECDH keys are supported")
+		}
+		//nolint:wrapcheck
+		return x509.MarshalPKCS8PrivateKey(k)
+	case *mode3.PrivateKey:
+		oid, raw = oidDilithium3, k.Bytes()
+	case *CompositePrivateKey:
+		return nil, errCompositeUnsupported()
+	case kem.PrivateKey:
+		if k.Scheme() != kyber768.Scheme() {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Only Kyber768 KEM keys are supported")
+		}
+		oid = oidKyber768
+		raw, err = k.MarshalBinary()
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrSerializationFailed,
+				fmt.Sprintf("Failed to marshal Kyber private key: %v", err))
+		}
+	default:
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, fmt.Sprintf("Unsupported private key type %T", priv))
+	}
+	defer clear(raw)
+
+	inner, err := asn1.Marshal(raw)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	defer clear(inner)
+	//nolint:wrapcheck
+	return asn1.Marshal(oneAsymmetricKey{
+		Algorithm:  pkix.AlgorithmIdentifier{Algorithm: oid},
+		PrivateKey: inner,
+	})
+}
+
+// ParsePKCS8PrivateKey 解析 DER 格式的 PKCS#8 私钥，
+// 返回 kem.PrivateKey（Kyber768）、*ecdh.PrivateKey（X25519）或 *mode3.PrivateKey.
+func ParsePKCS8PrivateKey(der []byte) (any, error) {
+	var key oneAsymmetricKey
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Invalid PKCS#8 private key")
+	}
+	defer clear(key.PrivateKey)
+	if err := checkKeyAlgorithm(key.Algorithm); err != nil {
+		return nil, err
+	}
+	if key.Algorithm.Algorithm.Equal(oidX25519) {
+		//nolint:wrapcheck
+		return x509.ParsePKCS8PrivateKey(der)
+	}
+
+	var raw []byte
+	if rest, err := asn1.Unmarshal(key.PrivateKey, &raw); err != nil || len(rest) != 0 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Invalid PKCS#8 private key field")
+	}
+	defer clear(raw)
+	if key.Algorithm.Algorithm.Equal(oidKyber768) {
+		priv, err := kyber768.Scheme().UnmarshalBinaryPrivateKey(raw)
+		if err != nil {
+			return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+				fmt.Sprintf("Failed to parse Kyber private key: %v", err))
+		}
+		return priv, nil
+	}
+	var priv mode3.PrivateKey
+	if err := priv.UnmarshalBinary(raw); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+			fmt.Sprintf("Failed to parse Dilithium3 private key: %v", err))
+	}
+	return &priv, nil
+}
+
+// checkKeyAlgorithm 只接受本工具支持的算法，对 ML-KEM / ML-DSA 给出不兼容的说明.
+func checkKeyAlgorithm(alg pkix.AlgorithmIdentifier) error {
+	oid := alg.Algorithm
+	switch {
+	case oid.Equal(oidX25519), oid.Equal(oidKyber768), oid.Equal(oidDilithium3):
+		if len(alg.Parameters.FullBytes) != 0 {
+			return utils.NewCryptoError(utils.ErrInvalidKey, "Unexpected algorithm parameters")
+		}
+		return nil
+	case hasOIDPrefix(oid, oidNISTKEM):
+		return utils.NewCryptoError(utils.ErrInvalidAlgorithm,
+			fmt.Sprintf("ML-KEM key (%s) is not supported: fzjjyz uses round-3 Kyber768, which is not interoperable with ML-KEM", oid))
+	case hasOIDPrefix(oid, oidNISTSig) && len(oid) == len(oidNISTSig)+1 && oid[len(oidNISTSig)] >= 17 && oid[len(oidNISTSig)] <= 19:
+		return utils.NewCryptoError(utils.ErrInvalidAlgorithm,
+			fmt.Sprintf("ML-DSA key (%s) is not supported: fzjjyz uses round-3 Dilithium3, which is not interoperable with ML-DSA", oid))
+	default:
+		return utils.NewCryptoError(utils.ErrInvalidAlgorithm, fmt.Sprintf("Unsupported key algorithm %s", oid))
+	}
+}
+
+func hasOIDPrefix(oid, prefix asn1.ObjectIdentifier) bool {
+	return len(oid) > len(prefix) && oid[:len(prefix)].Equal(prefix)
+}
+
+// ExportPublicKeyPKIX 以两个 "PUBLIC KEY" PEM 块导出混合公钥.
+// X25519 在前，OpenSSL 等只读取第一个块的工具可以直接使用.
+func ExportPublicKeyPKIX(kyberPub kem.PublicKey, ecdhPub *ecdh.PublicKey) ([]byte, error) {
+	var out []byte
+	for _, pub := range []any{ecdhPub, kyberPub} {
+		der, err := MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: pemTypePublicKey, Bytes: der})...)
+	}
+	return out, nil
+}
+
+// ExportPrivateKeyPKCS8 以两个 "PRIVATE KEY" PEM 块导出混合私钥，顺序与 ExportPublicKeyPKIX 相同.
+func ExportPrivateKeyPKCS8(kyberPriv kem.PrivateKey, ecdhPriv *ecdh.PrivateKey) ([]byte, error) {
+	var out []byte
+	for _, priv := range []any{ecdhPriv, kyberPriv} {
+		der, err := MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: pemTypePrivateKey, Bytes: der})...)
+		clear(der)
+	}
+	return out, nil
+}
+
+// ExportDilithiumKeysPKCS8 以 SubjectPublicKeyInfo / PKCS#8 PEM 导出 Dilithium3 密钥对；复合密钥无法用这两种格式表示.
+func ExportDilithiumKeysPKCS8(pub VerifyingKey, priv SigningKey) (*DilithiumKeyPair, error) {
+	pubDER, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	privDER, err := MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(privDER)
+	return &DilithiumKeyPair{
+		Public:  pem.EncodeToMemory(&pem.Block{Type: pemTypePublicKey, Bytes: pubDER}),
+		Private: pem.EncodeToMemory(&pem.Block{Type: pemTypePrivateKey, Bytes: privDER}),
+	}, nil
+}
+
+// decodeSingleKey 取出单个密钥的 DER：nativeType 块返回原始字节，标准类型块或未经 PEM 编码的 DER 返回 der.
+func decodeSingleKey(data []byte, nativeType, standardType string) (raw, der []byte, ok bool) {
+	block, _ := pem.Decode(data)
+	switch {
+	case block == nil:
+		// 未经 PEM 编码的 DER 以 SEQUENCE 开头
+		if len(data) > 0 && data[0] == 0x30 {
+			return nil, data, true
+		}
+		return nil, nil, false
+	case block.Type == nativeType:
+		return block.Bytes, nil, true
+	case block.Type == standardType:
+		return nil, block.Bytes, true
+	default:
+		return nil, nil, false
+	}
+}
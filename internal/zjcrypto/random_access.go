@@ -0,0 +1,555 @@
+package zjcrypto
+
+import (
+	"container/list"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem"
+)
+
+// 分块文件的密钥派生标签，与索引存档一样由混合 KEM 共享密钥经 HKDF 派生.
+const (
+	chunkDataKeyLabel  = "fzjjyz chunked data v1"
+	chunkTableKeyLabel = "fzjjyz chunked table v1"
+)
+
+// DefaultChunkCacheSize 随机访问读取器默认缓存的已解密块数量.
+const DefaultChunkCacheSize = 8
+
+// chunkTableAAD 块表密文的附加认证数据.
+var chunkTableAAD = []byte("FZJC")
+
+// EncryptFileChunked 将文件分块加密为支持随机访问的格式，chunkSize 为 0 时使用默认块大小.
+// 加密过程流式进行，内存占用与块大小相关.
+func EncryptFileChunked(
+	inputPath, outputPath string,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	chunkSize uint32,
+) (err error) {
+	// #nosec G304 - outputPath 应由调用方验证
+	out, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, encryptedFilePerm)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+	defer func() {
+		if closeErr := out.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close output file: %w", closeErr)
+		}
+		if err != nil {
+			_ = os.Remove(outputPath)
+		}
+	}()
+	return EncryptFileChunkedTo(inputPath, out, kyberPub, ecdhPub, dilithiumPriv, chunkSize)
+}
+
+// EncryptFileChunkedTo 与 EncryptFileChunked 相同，但写入 out（如分卷写入器），出错时由调用方清理输出.
+func EncryptFileChunkedTo(
+	inputPath string,
+	out BackfillWriter,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	chunkSize uint32,
+) error {
+	// #nosec G304 - inputPath 应由调用方验证
+	in, err := os.Open(inputPath)
+	if err != nil {
+		return utils.NewCryptoError(utils.ErrIOError, "Failed to read input file: "+err.Error())
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	info, err := in.Stat()
+	if err != nil {
+		return utils.NewCryptoError(utils.ErrIOError, "Failed to get file info: "+err.Error())
+	}
+	size, err := EncryptReaderChunked(in, filepath.Base(inputPath), out, kyberPub, ecdhPub, dilithiumPriv, chunkSize)
+	if err != nil {
+		return err
+	}
+	if size != info.Size() {
+		return utils.NewCryptoError(utils.ErrIOError, "Input file changed while encrypting")
+	}
+	return nil
+}
+
+// EncryptReaderChunked 将 in 的全部内容分块加密写入 out，filename 记录在头部，返回明文长度.
+// 明文长度无需预先知道：每块读入后立即加密写出，文件大小、签名和哈希在写完块表后回填头部，
+// 因此内存占用只与块大小相关，明文不会落盘. 出错时由调用方清理输出.
+//
+//nolint:funlen
+func EncryptReaderChunked(
+	in io.Reader,
+	filename string,
+	out BackfillWriter,
+	kyberPub kem.PublicKey,
+	ecdhPub *ecdh.PublicKey,
+	dilithiumPriv SigningKey,
+	chunkSize uint32,
+) (int64, error) {
+	if chunkSize == 0 {
+		chunkSize = format.DefaultChunkSize
+	}
+	if chunkSize > format.MaxIndexChunkSize {
+		return 0, utils.NewCryptoError(utils.ErrInvalidParameter, fmt.Sprintf("Invalid chunk size: %d", chunkSize))
+	}
+	if isNilSigningKey(dilithiumPriv) {
+		return 0, utils.NewCryptoError(utils.ErrInvalidKey, "Signing key is required")
+	}
+
+	// 1. 混合密钥封装，IV 字段保存 HKDF 盐
+	encapsulated, ecdhTempPub, secret, err := prepareEncryptionKeys(kyberPub, ecdhPub)
+	if err != nil {
+		return 0, utils.NewCryptoError(
+			utils.ErrKeyGenerationFailed,
+			"Hybrid encapsulation failed: "+err.Error(),
+		)
+	}
+	defer secret.Release()
+	sharedSecret := secret.Bytes()
+	salt := make([]byte, 12)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return 0, utils.NewCryptoError(utils.ErrKeyGenerationFailed, "Salt generation failed")
+	}
+	dataGCM, err := deriveArchiveKey(sharedSecret, salt, chunkDataKeyLabel)
+	if err != nil {
+		return 0, err
+	}
+
+	// 2. 写入占位头部（文件大小、签名和哈希在写完块表后回填，长度不变）
+	header, err := buildFileHeader(
+		filename,
+		0,
+		encapsulated,
+		ecdhTempPub,
+		salt,
+		make([]byte, SignatureSizeFor(dilithiumPriv)),
+		[32]byte{},
+	)
+	if err != nil {
+		return 0, err
+	}
+	header.Flags |= format.FlagChunked
+	headerBytes, err := serializeHeader(header)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := out.Write(headerBytes); err != nil {
+		return 0, fmt.Errorf("write header: %w", err)
+	}
+
+	// 3. 逐块加密并记录明文哈希；预读下一块以确定最后一块（其 AAD 带结束标记）
+	table := format.ChunkTable{ChunkSize: chunkSize}
+	cur, next := make([]byte, chunkSize), make([]byte, chunkSize)
+	defer clear(cur)
+	defer clear(next)
+	sealBuf := make([]byte, 0, int(chunkSize)+format.IndexChunkOverhead)
+	n, err := readChunk(in, cur)
+	if err != nil {
+		return 0, err
+	}
+	for i := uint64(0); n > 0; i++ {
+		following := 0
+		if n == len(cur) {
+			if following, err = readChunk(in, next); err != nil {
+				return 0, err
+			}
+		}
+		plain := cur[:n]
+		table.Hashes = append(table.Hashes, sha256.Sum256(plain))
+		sealed := dataGCM.Seal(sealBuf[:0], chunkNonce(i), plain, chunkAAD(i, following == 0))
+		if _, err := out.Write(sealed); err != nil {
+			return 0, fmt.Errorf("write chunk: %w", err)
+		}
+		table.FileSize += uint64(n) // #nosec G115 - n 非负
+		cur, next, n = next, cur, following
+	}
+
+	// 4. 加密块表并写入尾部
+	tablePlain, err := table.MarshalBinary()
+	if err != nil {
+		return 0, fmt.Errorf("marshal chunk table: %w", err)
+	}
+	tableGCM, err := deriveArchiveKey(sharedSecret, salt, chunkTableKeyLabel)
+	if err != nil {
+		return 0, err
+	}
+	tableCipher := tableGCM.Seal(nil, chunkNonce(0), tablePlain, chunkTableAAD)
+	if _, err := out.Write(tableCipher); err != nil {
+		return 0, fmt.Errorf("write chunk table: %w", err)
+	}
+	trailer := format.IndexTrailer{
+		IndexOffset: uint64(len(headerBytes)) + format.ChunkedDataSize(table.FileSize, chunkSize),
+		IndexLen:    uint64(len(tableCipher)),
+	}
+	trailerBytes, _ := trailer.MarshalBinary()
+	if _, err := out.Write(trailerBytes); err != nil {
+		return 0, fmt.Errorf("write trailer: %w", err)
+	}
+
+	// 5. 对块表哈希签名并回填头部
+	hash := calculateHash(tablePlain)
+	signature, err := signHash(hash[:], dilithiumPriv)
+	if err != nil {
+		return 0, utils.NewCryptoError(
+			utils.ErrSigningFailed,
+			"Hash signing failed: "+err.Error(),
+		)
+	}
+	header.FileSize = table.FileSize
+	header.Signature = signature
+	header.SHA256Hash = hash
+	finalHeader, err := serializeHeader(header)
+	if err != nil {
+		return 0, err
+	}
+	if len(finalHeader) != len(headerBytes) {
+		return 0, utils.NewCryptoError(utils.ErrSerializationFailed, "Header size changed while finalizing")
+	}
+	if _, err := out.WriteAt(finalHeader, 0); err != nil {
+		return 0, fmt.Errorf("finalize header: %w", err)
+	}
+	return int64(table.FileSize), nil // #nosec G115 - 由读取的字节数累加
+}
+
+// readChunk 读满 buf 或读到输入结尾，返回读取的字节数.
+func readChunk(in io.Reader, buf []byte) (int, error) {
+	n, err := io.ReadFull(in, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return n, nil
+	}
+	if err != nil {
+		return n, fmt.Errorf("read input: %w", err)
+	}
+	return n, nil
+}
+
+// ChunkedReader 分块文件的随机访问读取器，实现 io.ReaderAt 和 io.ReadSeeker.
+// 只解密并认证覆盖读取范围的块，最近使用的块保存在 LRU 缓存中.
+// ReadAt 可并发调用；Read 和 Seek 共享读取位置，不可并发调用.
+type ChunkedReader struct {
+	r          io.ReaderAt
+	header     *format.FileHeader
+	table      format.ChunkTable
+	dataOffset uint64
+	gcm        cipher.AEAD
+
+	mu    sync.Mutex
+	cache *chunkCache
+	pos   int64
+}
+
+// OpenReaderAt 打开分块加密的密文，size 为密文总长度：解封装密钥、解密并验证块表.
+// dilithiumPub 为 nil 时跳过签名验证（块表和每块数据仍受 AEAD 保护）.
+func OpenReaderAt(
+	ciphertext io.ReaderAt,
+	size int64,
+	kyberPriv kem.PrivateKey,
+	ecdhPriv *ecdh.PrivateKey,
+	dilithiumPub VerifyingKey,
+) (*ChunkedReader, error) {
+	header, err := format.ParseFileHeader(io.NewSectionReader(ciphertext, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("parse file header: %w", err)
+	}
+	if err := header.Validate(); err != nil {
+		return nil, fmt.Errorf("header validation failed: %w", err)
+	}
+	if !header.IsChunked() {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Not a chunked file - encrypt with random access enabled")
+	}
+
+	// 读取尾部定位块表
+	fileSize := uint64(size) // #nosec G115 - 调用方提供的长度
+	headerSize := uint64(header.GetHeaderSize())
+	if size < 0 || fileSize < headerSize+format.IndexTrailerSize {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Chunked file too short")
+	}
+	trailerBytes := make([]byte, format.IndexTrailerSize)
+	// #nosec G115 - 已检查文件长度
+	if _, err := ciphertext.ReadAt(trailerBytes, int64(fileSize-format.IndexTrailerSize)); err != nil {
+		return nil, fmt.Errorf("read trailer: %w", err)
+	}
+	var trailer format.IndexTrailer
+	if err := trailer.UnmarshalBinary(trailerBytes); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	tableEnd := fileSize - format.IndexTrailerSize
+	if trailer.IndexOffset < headerSize || trailer.IndexOffset > tableEnd ||
+		trailer.IndexLen != tableEnd-trailer.IndexOffset {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Chunk table location out of range")
+	}
+
+	secret, err := decapsulateKeys(kyberPriv, ecdhPriv, header.KyberEnc, header.ECDHPub[:])
+	if err != nil {
+		return nil, utils.NewCryptoError(
+			utils.ErrDecryptionFailed,
+			"Key decapsulation failed: "+err.Error(),
+		)
+	}
+	defer secret.Release()
+	sharedSecret := secret.Bytes()
+
+	// 解密块表并验证哈希和签名
+	tableCipher := make([]byte, trailer.IndexLen)
+	// #nosec G115 - 已检查偏移范围
+	if _, err := ciphertext.ReadAt(tableCipher, int64(trailer.IndexOffset)); err != nil {
+		return nil, fmt.Errorf("read chunk table: %w", err)
+	}
+	tableGCM, err := deriveArchiveKey(sharedSecret, header.IV[:], chunkTableKeyLabel)
+	if err != nil {
+		return nil, err
+	}
+	tablePlain, err := tableGCM.Open(nil, chunkNonce(0), tableCipher, chunkTableAAD)
+	if err != nil {
+		return nil, utils.NewCryptoError(
+			utils.ErrAuthFailed,
+			"Chunk table authentication failed - wrong key or corrupted file",
+		)
+	}
+	if err := verifyDecryptionIntegrity(tablePlain, header, dilithiumPub); err != nil {
+		return nil, err
+	}
+
+	cr := &ChunkedReader{
+		r:          ciphertext,
+		header:     header,
+		dataOffset: headerSize,
+		cache:      newChunkCache(DefaultChunkCacheSize),
+	}
+	if err := cr.table.UnmarshalBinary(tablePlain); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if cr.table.FileSize != header.FileSize ||
+		headerSize+format.ChunkedDataSize(cr.table.FileSize, cr.table.ChunkSize) != trailer.IndexOffset {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Chunk table does not match file layout")
+	}
+	if cr.gcm, err = deriveArchiveKey(sharedSecret, header.IV[:], chunkDataKeyLabel); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// Header 返回文件头.
+func (cr *ChunkedReader) Header() *format.FileHeader {
+	return cr.header
+}
+
+// Size 返回明文大小.
+func (cr *ChunkedReader) Size() int64 {
+	return int64(cr.table.FileSize) // #nosec G115 - 已与密文长度核对
+}
+
+// SetCacheSize 设置缓存的已解密块数量，n 小于 1 时按 1 处理.
+func (cr *ChunkedReader) SetCacheSize(n int) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.cache = newChunkCache(n)
+}
+
+// ReadAt 实现 io.ReaderAt.
+func (cr *ChunkedReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, utils.NewCryptoError(utils.ErrInvalidParameter, "Negative offset")
+	}
+	chunkSize := int64(cr.table.ChunkSize)
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= cr.Size() {
+			return n, io.EOF
+		}
+		plain, err := cr.chunk(uint64(pos / chunkSize)) // #nosec G115 - pos 非负
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], plain[pos%chunkSize:])
+	}
+	return n, nil
+}
+
+// Read 实现 io.Reader.
+func (cr *ChunkedReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadAt(p, cr.pos)
+	cr.pos += int64(n)
+	if errors.Is(err, io.EOF) && n > 0 {
+		return n, nil
+	}
+	return n, err
+}
+
+// Seek 实现 io.Seeker.
+func (cr *ChunkedReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += cr.pos
+	case io.SeekEnd:
+		offset += cr.Size()
+	default:
+		return 0, utils.NewCryptoError(utils.ErrInvalidParameter, "Invalid whence")
+	}
+	if offset < 0 {
+		return 0, utils.NewCryptoError(utils.ErrInvalidParameter, "Negative position")
+	}
+	cr.pos = offset
+	return offset, nil
+}
+
+// chunk 返回第 i 块明文，优先从缓存读取；新解密的块校验 GCM 标签和块表哈希.
+func (cr *ChunkedReader) chunk(i uint64) ([]byte, error) {
+	cr.mu.Lock()
+	plain, ok := cr.cache.get(i)
+	cr.mu.Unlock()
+	if ok {
+		return plain, nil
+	}
+
+	count := uint64(len(cr.table.Hashes))
+	chunkSize := uint64(cr.table.ChunkSize)
+	plainLen := min(chunkSize, cr.table.FileSize-i*chunkSize)
+	buf := make([]byte, plainLen+format.IndexChunkOverhead)
+	offset := cr.dataOffset + i*(chunkSize+format.IndexChunkOverhead)
+	// #nosec G115 - 偏移已通过块表与文件布局核对
+	if _, err := cr.r.ReadAt(buf, int64(offset)); err != nil {
+		return nil, fmt.Errorf("read chunk %d: %w", i, err)
+	}
+	plain, err := cr.gcm.Open(buf[:0], chunkNonce(i), buf, chunkAAD(i, i == count-1))
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrAuthFailed, fmt.Sprintf("Chunk %d is corrupted", i))
+	}
+	if sha256.Sum256(plain) != cr.table.Hashes[i] {
+		return nil, utils.NewCryptoError(utils.ErrHashMismatch, fmt.Sprintf("SHA256 hash mismatch for chunk %d", i))
+	}
+
+	cr.mu.Lock()
+	cr.cache.put(i, plain)
+	cr.mu.Unlock()
+	return plain, nil
+}
+
+// DecryptFileChunked 流式解密整个分块文件，逐块校验后写入 outputPath.
+func DecryptFileChunked(
+	inputPath, outputPath string,
+	kyberPriv kem.PrivateKey,
+	ecdhPriv *ecdh.PrivateKey,
+	dilithiumPub VerifyingKey,
+) error {
+	// #nosec G304 - inputPath 应由调用方验证
+	in, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("read encrypted file: %w", err)
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("stat encrypted file: %w", err)
+	}
+	return decryptChunkedTo(in, info.Size(), outputPath, kyberPriv, ecdhPriv, dilithiumPub)
+}
+
+// decryptChunkedTo 逐块解密 r 中长度为 size 的分块文件并写入 outputPath，失败时删除输出文件.
+func decryptChunkedTo(
+	r io.ReaderAt,
+	size int64,
+	outputPath string,
+	kyberPriv kem.PrivateKey,
+	ecdhPriv *ecdh.PrivateKey,
+	dilithiumPub VerifyingKey,
+) (err error) {
+	reader, err := OpenReaderAt(r, size, kyberPriv, ecdhPriv, dilithiumPub)
+	if err != nil {
+		return err
+	}
+	reader.SetCacheSize(1)
+
+	// #nosec G304 - outputPath 应由调用方验证
+	out, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, decryptedFilePerm)
+	if err != nil {
+		return fmt.Errorf("write decrypted file: %w", err)
+	}
+	defer func() {
+		if closeErr := out.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("write decrypted file: %w", closeErr)
+		}
+		if err != nil {
+			_ = os.Remove(outputPath)
+		}
+	}()
+	if _, err := io.Copy(out, reader); err != nil {
+		return fmt.Errorf("write decrypted file: %w", err)
+	}
+	return nil
+}
+
+// isChunkedFile 判断文件头是否带有分块标志.
+func isChunkedFile(path string) bool {
+	// #nosec G304 - path 应由调用方验证
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	header, err := format.ParseFileHeader(f)
+	return err == nil && header.IsChunked()
+}
+
+// chunkCache 已解密块的 LRU 缓存.
+type chunkCache struct {
+	capacity int
+	order    *list.List // 最近使用的在前
+	items    map[uint64]*list.Element
+}
+
+type chunkCacheItem struct {
+	index uint64
+	plain []byte
+}
+
+func newChunkCache(capacity int) *chunkCache {
+	return &chunkCache{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		items:    make(map[uint64]*list.Element),
+	}
+}
+
+func (c *chunkCache) get(i uint64) ([]byte, bool) {
+	elem, ok := c.items[i]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*chunkCacheItem).plain, true //nolint:forcetypeassert
+}
+
+func (c *chunkCache) put(i uint64, plain []byte) {
+	if elem, ok := c.items[i]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[i] = c.order.PushFront(&chunkCacheItem{index: i, plain: plain})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*chunkCacheItem).index) //nolint:forcetypeassert
+	}
+}
@@ -0,0 +1,231 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+)
+
+// countingReaderAt 统计底层读取次数.
+type countingReaderAt struct {
+	r     io.ReaderAt
+	reads atomic.Int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.reads.Add(1)
+	return c.r.ReadAt(p, off)
+}
+
+// encryptChunkedTestFile 生成随机明文并分块加密，返回明文和密文.
+func encryptChunkedTestFile(t *testing.T, keys testKeys, size int, chunkSize uint32) ([]byte, []byte) {
+	t.Helper()
+	plain := make([]byte, size)
+	_, _ = rand.Read(plain)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "media.bin")
+	output := filepath.Join(dir, "media.bin.fzj")
+	if err := os.WriteFile(input, plain, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptFileChunked(input, output, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, chunkSize); err != nil {
+		t.Fatalf("分块加密失败: %v", err)
+	}
+	data, err := os.ReadFile(output) // #nosec G304 - 测试环境使用临时文件路径
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plain, data
+}
+
+func TestChunkedReaderAt(t *testing.T) {
+	keys := generateTestKeys(t)
+	plain, data := encryptChunkedTestFile(t, keys, 10*1024+123, 1024)
+
+	src := &countingReaderAt{r: bytes.NewReader(data)}
+	reader, err := OpenReaderAt(src, int64(len(data)), keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("OpenReaderAt 失败: %v", err)
+	}
+	if reader.Size() != int64(len(plain)) || !reader.Header().IsChunked() {
+		t.Fatalf("Size = %d", reader.Size())
+	}
+
+	// 随机范围读取
+	for range 50 {
+		off, _ := rand.Int(rand.Reader, big.NewInt(int64(len(plain))))
+		n, _ := rand.Int(rand.Reader, big.NewInt(3000))
+		start := off.Int64()
+		end := min(start+n.Int64(), int64(len(plain)))
+		buf := make([]byte, end-start)
+		if _, err := reader.ReadAt(buf, start); err != nil {
+			t.Fatalf("ReadAt(%d, %d) 失败: %v", start, len(buf), err)
+		}
+		if !bytes.Equal(buf, plain[start:end]) {
+			t.Fatalf("ReadAt(%d, %d) 内容不一致", start, len(buf))
+		}
+	}
+
+	// 只读取覆盖范围的块，缓存命中时不再读取
+	reader.SetCacheSize(2)
+	before := src.reads.Load()
+	buf := make([]byte, 100)
+	if _, err := reader.ReadAt(buf, 5*1024+1000); err != nil {
+		t.Fatal(err)
+	}
+	if got := src.reads.Load() - before; got != 2 {
+		t.Errorf("跨两块读取了 %d 次", got)
+	}
+	if _, err := reader.ReadAt(buf, 5*1024+900); err != nil {
+		t.Fatal(err)
+	}
+	if got := src.reads.Load() - before; got != 2 {
+		t.Errorf("缓存命中后仍读取了 %d 次", got)
+	}
+
+	// 越过末尾返回 io.EOF
+	n, err := reader.ReadAt(make([]byte, 200), int64(len(plain))-100)
+	if n != 100 || err != io.EOF {
+		t.Errorf("ReadAt 末尾 = %d, %v", n, err)
+	}
+
+	// Seek + Read
+	if _, err := reader.Seek(-500, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	tail, err := io.ReadAll(reader)
+	if err != nil || !bytes.Equal(tail, plain[len(plain)-500:]) {
+		t.Errorf("Seek 后读取不一致: %v", err)
+	}
+	if _, err := reader.Seek(-1, io.SeekStart); err == nil {
+		t.Error("期望负位置报错")
+	}
+}
+
+func TestEncryptReaderChunked(t *testing.T) {
+	keys := generateTestKeys(t)
+
+	// 长度未知且每次只返回一个字节的输入，长度恰为块大小整数倍时最后一块仍带结束标记
+	for _, size := range []int{0, 1024, 3*1024 + 5} {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+		output := filepath.Join(t.TempDir(), "body.fzj")
+		out, err := os.Create(output) // #nosec G304 - 测试环境使用临时文件路径
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := EncryptReaderChunked(iotest.OneByteReader(bytes.NewReader(plain)), "body.bin", out,
+			keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, 1024)
+		_ = out.Close()
+		if err != nil || n != int64(size) {
+			t.Fatalf("size %d: 加密失败: %d, %v", size, n, err)
+		}
+
+		data, _ := os.ReadFile(output) // #nosec G304 - 测试环境使用临时文件路径
+		reader, err := OpenReaderAt(bytes.NewReader(data), int64(len(data)), keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+		if err != nil {
+			t.Fatalf("size %d: 打开失败: %v", size, err)
+		}
+		got, err := io.ReadAll(reader)
+		if err != nil || !bytes.Equal(got, plain) || reader.Header().Filename != "body.bin" {
+			t.Fatalf("size %d: 内容不一致: %v", size, err)
+		}
+	}
+
+	// 读取错误原样包装返回
+	output := filepath.Join(t.TempDir(), "err.fzj")
+	out, err := os.Create(output) // #nosec G304 - 测试环境使用临时文件路径
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+	_, err = EncryptReaderChunked(iotest.ErrReader(iotest.ErrTimeout), "x", out,
+		keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, 1024)
+	if !errors.Is(err, iotest.ErrTimeout) {
+		t.Errorf("期望读取错误，得到 %v", err)
+	}
+}
+
+func TestChunkedReaderDetectsTampering(t *testing.T) {
+	keys := generateTestKeys(t)
+	other := generateTestKeys(t)
+	plain, data := encryptChunkedTestFile(t, keys, 4096, 1024)
+
+	if _, err := OpenReaderAt(bytes.NewReader(data), int64(len(data)), keys.kyberPriv, keys.ecdhPriv, other.dilithiumPub); err == nil {
+		t.Error("期望使用错误公钥时验证失败")
+	}
+	if _, err := OpenReaderAt(bytes.NewReader(data), int64(len(data)), other.kyberPriv, other.ecdhPriv, nil); err == nil {
+		t.Error("期望使用错误私钥时解密失败")
+	}
+
+	// 修改第 2 块：其他块仍可读取，覆盖该块的读取失败
+	header, err := format.ParseFileHeaderFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	damaged := bytes.Clone(data)
+	damaged[header.GetHeaderSize()+2*(1024+format.IndexChunkOverhead)+10] ^= 0x01
+	reader, err := OpenReaderAt(bytes.NewReader(damaged), int64(len(damaged)), keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("数据块损坏不应影响打开: %v", err)
+	}
+	buf := make([]byte, 1024)
+	if _, err := reader.ReadAt(buf, 0); err != nil || !bytes.Equal(buf, plain[:1024]) {
+		t.Errorf("未损坏的块读取失败: %v", err)
+	}
+	if _, err := reader.ReadAt(buf, 2048); err == nil {
+		t.Error("期望损坏的块读取失败")
+	}
+
+	// 截断文件无法打开
+	if _, err := OpenReaderAt(bytes.NewReader(data[:len(data)-1]), int64(len(data)-1), keys.kyberPriv, keys.ecdhPriv, nil); err == nil {
+		t.Error("期望截断的文件打开失败")
+	}
+}
+
+func TestChunkedFileDecryptTransparent(t *testing.T) {
+	keys := generateTestKeys(t)
+
+	for _, size := range []int{0, 1, int(format.DefaultChunkSize), 3*int(format.DefaultChunkSize) + 7} {
+		plain, data := encryptChunkedTestFile(t, keys, size, 0)
+		dir := t.TempDir()
+		input := filepath.Join(dir, "in.fzj")
+		if err := os.WriteFile(input, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		streamed := filepath.Join(dir, "streamed")
+		if err := DecryptFileStreaming(input, streamed, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub, MinBufferSize); err != nil {
+			t.Fatalf("size %d: 流式解密失败: %v", size, err)
+		}
+		got, _ := os.ReadFile(streamed) // #nosec G304 - 测试环境使用临时文件路径
+		if !bytes.Equal(got, plain) {
+			t.Fatalf("size %d: 流式解密内容不一致", size)
+		}
+
+		got, err := DecryptDataCore(data, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Fatalf("size %d: 内存解密失败: %v", size, err)
+		}
+	}
+
+	// 普通加密文件不能按分块格式打开
+	normal, err := EncryptData([]byte("plain"), "x.txt", keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, NoCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenReaderAt(bytes.NewReader(normal), int64(len(normal)), keys.kyberPriv, keys.ecdhPriv, nil); err == nil {
+		t.Error("期望非分块文件打开失败")
+	}
+}
@@ -0,0 +1,74 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"fmt"
+	"strings"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+)
+
+// 收件人字符串: Bech32("fzj", 类型 || 密钥字节)，例如 fzj1q...
+// 类型字节区分密钥算法，目前只有 Kyber768 + X25519；不认识的类型会被拒绝而不是按长度猜测.
+
+const (
+	// RecipientPrefix 收件人字符串的 Bech32 前缀（HRP）.
+	RecipientPrefix = "fzj"
+
+	recipientTypeKyber768X25519 byte = 0x01
+)
+
+// IsRecipient 判断 s 是否形如收件人字符串（不检查校验和）.
+func IsRecipient(s string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(s)), RecipientPrefix+"1")
+}
+
+// EncodeRecipient 将混合公钥编码为收件人字符串.
+func EncodeRecipient(pub *HybridPublicKey) (string, error) {
+	if pub == nil || pub.Kyber == nil || pub.ECDH == nil {
+		return "", utils.NewCryptoError(utils.ErrInvalidKey, "Public key cannot be nil")
+	}
+	kyberBytes, err := pub.Kyber.MarshalBinary()
+	if err != nil {
+		return "", utils.NewCryptoError(utils.ErrSerializationFailed,
+			fmt.Sprintf("Failed to marshal Kyber public key: %v", err))
+	}
+	data := append([]byte{recipientTypeKyber768X25519}, kyberBytes...)
+	data = append(data, pub.ECDH.Bytes()...)
+	s, err := bech32Encode(RecipientPrefix, data)
+	if err != nil {
+		return "", utils.NewCryptoError(utils.ErrSerializationFailed, fmt.Sprintf("Failed to encode recipient: %v", err))
+	}
+	return s, nil
+}
+
+// ParseRecipient 解析收件人字符串，首尾空白以及中间的换行（聊天软件自动折行）会被忽略.
+func ParseRecipient(s string) (*HybridPublicKey, error) {
+	hrp, data, err := bech32Decode(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, fmt.Sprintf("Invalid recipient: %v", err))
+	}
+	if hrp != RecipientPrefix {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat,
+			fmt.Sprintf("Invalid recipient prefix %q, expected %q", hrp, RecipientPrefix))
+	}
+	if len(data) == 0 || data[0] != recipientTypeKyber768X25519 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidAlgorithm, "Unsupported recipient key type")
+	}
+	scheme := kyber768.Scheme()
+	key := data[1:]
+	if len(key) != scheme.PublicKeySize()+32 {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, fmt.Sprintf("Invalid recipient key size: %d", len(key)))
+	}
+	kyberPub, err := scheme.UnmarshalBinaryPublicKey(key[:scheme.PublicKeySize()])
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, fmt.Sprintf("Invalid Kyber public key: %v", err))
+	}
+	ecdhPub, err := ecdh.X25519().NewPublicKey(bytes.Clone(key[scheme.PublicKeySize():]))
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, fmt.Sprintf("Invalid X25519 public key: %v", err))
+	}
+	return &HybridPublicKey{Kyber: kyberPub, ECDH: ecdhPub}, nil
+}
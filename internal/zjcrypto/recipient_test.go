@@ -0,0 +1,98 @@
+package zjcrypto
+
+import (
+	"strings"
+	"testing"
+)
+
+// BIP173 的有效与无效测试向量.
+func TestBech32Vectors(t *testing.T) {
+	valid := []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+		"?1ezyfcl",
+	}
+	for _, s := range valid {
+		hrp, data, err := bech32Decode(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		if encoded, err := bech32Encode(hrp, data); err != nil || encoded != strings.ToLower(s) {
+			t.Errorf("%s: 重新编码得到 %s", s, encoded)
+		}
+	}
+	invalid := []string{
+		"pzry9x0s0muk",  // 没有分隔符
+		"1pzry9x0s0muk", // HRP 为空
+		"x1b4n0q5v",     // 非法数据字符
+		"li1dgmt3",      // 校验和过短
+		"A1G7SGD8",      // 校验和以大写 HRP 计算
+		"10a06t8",       // HRP 为空
+		"1qzzfhee",      // HRP 为空
+		"a12UEL5L",      // 大小写混用
+	}
+	for _, s := range invalid {
+		if _, _, err := bech32Decode(s); err == nil {
+			t.Errorf("%s: 应解析失败", s)
+		}
+	}
+}
+
+func TestRecipientRoundTrip(t *testing.T) {
+	kyberPub, _, ecdhPub, _, err := GenerateHybridKeysParallel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &HybridPublicKey{Kyber: kyberPub, ECDH: ecdhPub}
+	s, err := EncodeRecipient(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s, "fzj1") || !IsRecipient(s) || strings.ToLower(s) != s {
+		t.Fatalf("收件人字符串格式不正确: %.20s...", s)
+	}
+
+	// 大写与聊天软件折行都可以解析
+	wrapped := strings.ToUpper(s[:100]) + "\n  " + strings.ToUpper(s[100:])
+	for _, in := range []string{s, " " + s + "\n", wrapped} {
+		got, err := ParseRecipient(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Kyber.Equal(pub.Kyber) || !got.ECDH.Equal(pub.ECDH) {
+			t.Fatal("解析出的公钥不同")
+		}
+	}
+
+	// 单个字符错误被校验和检出
+	for _, i := range []int{5, len(s) / 2, len(s) - 1} {
+		b := []byte(s)
+		if b[i] == 'q' {
+			b[i] = 'p'
+		} else {
+			b[i] = 'q'
+		}
+		if _, err := ParseRecipient(string(b)); err == nil {
+			t.Errorf("第 %d 个字符错误应被检出", i)
+		}
+	}
+
+	// 截断、错误的前缀或类型
+	if _, err := ParseRecipient(s[:len(s)-10]); err == nil {
+		t.Error("截断的收件人应解析失败")
+	}
+	wrongHRP, _ := bech32Encode("age", []byte{recipientTypeKyber768X25519})
+	wrongType, _ := bech32Encode(RecipientPrefix, []byte{0x7f, 1, 2, 3})
+	for _, bad := range []string{wrongHRP, wrongType, "fzj1"} {
+		if _, err := ParseRecipient(bad); err == nil {
+			t.Errorf("%s: 应解析失败", bad)
+		}
+	}
+	if IsRecipient("keys/public.pem") {
+		t.Error("文件路径不应被识别为收件人")
+	}
+}
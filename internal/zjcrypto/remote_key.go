@@ -0,0 +1,103 @@
+package zjcrypto
+
+import (
+	"crypto"
+	"io"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+)
+
+// RemoteDecapsulator 在私钥持有方（如密钥代理）执行混合密钥解封装，私钥不离开持有方.
+type RemoteDecapsulator interface {
+	Decapsulate(encapsulated, ecdhPub []byte) ([]byte, error)
+}
+
+// RemoteSigner 在私钥持有方执行 Dilithium3 签名.
+type RemoteSigner interface {
+	Sign(message []byte) ([]byte, error)
+}
+
+// remoteKyberKey 代表远程混合私钥的 kem.PrivateKey，只能用于解封装.
+type remoteKyberKey struct {
+	pub    *HybridPublicKey
+	remote RemoteDecapsulator
+}
+
+func (k *remoteKyberKey) Scheme() kem.Scheme { return kyber768.Scheme() }
+
+func (k *remoteKyberKey) MarshalBinary() ([]byte, error) {
+	return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Remote private key cannot be exported")
+}
+
+func (k *remoteKyberKey) Equal(other kem.PrivateKey) bool {
+	o, ok := other.(*remoteKyberKey)
+	return ok && o == k
+}
+
+func (k *remoteKyberKey) Public() kem.PublicKey { return k.pub.Kyber }
+
+// NewRemotePrivateKey 返回由远程持有方解封装的混合私钥.
+// 返回值可传给所有接受 HybridPrivateKey 或 kem.PrivateKey 的解密函数，其 ECDH 字段为 nil.
+func NewRemotePrivateKey(pub *HybridPublicKey, remote RemoteDecapsulator) *HybridPrivateKey {
+	return &HybridPrivateKey{Kyber: &remoteKyberKey{pub: pub, remote: remote}}
+}
+
+// HybridPublicFromPrivate 由混合私钥（本地或远程）得到对应的公钥.
+func HybridPublicFromPrivate(priv *HybridPrivateKey) (*HybridPublicKey, error) {
+	if priv != nil {
+		if remote, ok := priv.Kyber.(*remoteKyberKey); ok {
+			return remote.pub, nil
+		}
+	}
+	if priv == nil || priv.Kyber == nil || priv.ECDH == nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Incomplete private key")
+	}
+	return &HybridPublicKey{Kyber: priv.Kyber.Public(), ECDH: priv.ECDH.PublicKey()}, nil
+}
+
+// remoteSigningKey 由远程签名方签名的 SigningKey，只能用于签名，不能导出.
+type remoteSigningKey struct {
+	pub    VerifyingKey
+	remote RemoteSigner
+}
+
+// NewRemoteSigningKey 返回由 remote 签名、公钥为 pub 的签名私钥.
+// 返回值可传给所有接受 SigningKey 的函数；pub 为复合公钥时 remote 必须返回复合签名.
+// 导出、序列化等需要原始私钥的函数只接受具体的私钥类型，不会接受远程签名密钥.
+func NewRemoteSigningKey(pub VerifyingKey, remote RemoteSigner) SigningKey {
+	return &remoteSigningKey{pub: pub, remote: remote}
+}
+
+// Public 实现 crypto.Signer.
+func (k *remoteSigningKey) Public() crypto.PublicKey { return k.pub }
+
+// Sign 实现 crypto.Signer，把 message 转发给远程签名方并检查签名长度.
+func (k *remoteSigningKey) Sign(_ io.Reader, message []byte, _ crypto.SignerOpts) ([]byte, error) {
+	signature, err := k.remote.Sign(message)
+	if err != nil {
+		return nil, utils.NewCryptoError(utils.ErrSigningFailed, "Remote signing failed: "+err.Error())
+	}
+	if len(signature) != SignatureSizeFor(k) {
+		return nil, utils.NewCryptoError(utils.ErrSigningFailed, "Remote signature has invalid length")
+	}
+	return signature, nil
+}
+
+// Equal 只有同一个远程签名密钥对象才相等.
+func (k *remoteSigningKey) Equal(other crypto.PrivateKey) bool {
+	o, ok := other.(*remoteSigningKey)
+	return ok && o == k
+}
+
+// IsRemoteSigningKey 判断 key 是否为 NewRemoteSigningKey 返回的远程签名密钥.
+func IsRemoteSigningKey(key SigningKey) bool {
+	_, ok := key.(*remoteSigningKey)
+	return ok
+}
+
+// SigningFingerprint 计算签名公钥的 SHA256 指纹（十六进制），见 DilithiumFingerprint.
+func SigningFingerprint(pub VerifyingKey) string {
+	return DilithiumFingerprint(pub).String()
+}
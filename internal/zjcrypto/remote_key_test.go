@@ -0,0 +1,96 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// localRemote 在进程内模拟私钥持有方.
+type localRemote struct {
+	priv  *HybridPrivateKey
+	sign  SigningKey
+	calls int
+	fail  bool
+}
+
+func (r *localRemote) Decapsulate(encapsulated, ecdhPub []byte) ([]byte, error) {
+	r.calls++
+	if r.fail {
+		return nil, errors.New("remote unavailable")
+	}
+	return NewHybridDecryptor(r.priv.Kyber, r.priv.ECDH).Decapsulate(encapsulated, ecdhPub)
+}
+
+func (r *localRemote) Sign(message []byte) ([]byte, error) {
+	r.calls++
+	if r.fail {
+		return nil, errors.New("remote unavailable")
+	}
+	return SignDataWithKey(message, r.sign)
+}
+
+func TestRemoteKeys(t *testing.T) {
+	kyberPub, kyberPriv, _ := GenerateKyberKeys()
+	ecdhPub, ecdhPriv, _ := GenerateECDHKeys()
+	dilithiumPub, dilithiumPriv, _ := GenerateDilithiumKeys()
+	pub := &HybridPublicKey{Kyber: kyberPub, ECDH: ecdhPub}
+	remote := &localRemote{priv: &HybridPrivateKey{Kyber: kyberPriv, ECDH: ecdhPriv}, sign: dilithiumPriv}
+
+	priv := NewRemotePrivateKey(pub, remote)
+	signKey := NewRemoteSigningKey(dilithiumPub, remote)
+
+	if got, err := HybridPublicFromPrivate(priv); err != nil || got != pub {
+		t.Errorf("HybridPublicFromPrivate = %v, %v", got, err)
+	}
+	if !DilithiumPublicFromPrivate(signKey).Equal(dilithiumPub) {
+		t.Error("DilithiumPublicFromPrivate 应返回远程公钥")
+	}
+	if _, err := priv.Kyber.MarshalBinary(); err == nil {
+		t.Error("远程私钥不应可导出")
+	}
+
+	plain := []byte("remote key round trip")
+	data, err := EncryptData(plain, "r.txt", kyberPub, ecdhPub, signKey, NoCompression)
+	if err != nil {
+		t.Fatalf("远程签名加密失败: %v", err)
+	}
+	got, err := DecryptDataCore(data, priv.Kyber, priv.ECDH, dilithiumPub)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("远程解密失败: %v", err)
+	}
+	if remote.calls != 2 {
+		t.Errorf("远程调用次数 = %d，期望 2", remote.calls)
+	}
+
+	remote.fail = true
+	if _, err := DecryptDataCore(data, priv.Kyber, priv.ECDH, nil); err == nil {
+		t.Error("期望远程解封装失败时解密失败")
+	}
+	if _, err := SignDataWithKey(plain, signKey); err == nil {
+		t.Error("期望远程签名失败时返回错误")
+	}
+}
+
+func TestRemoteSigningKeyNotExportable(t *testing.T) {
+	dilithiumPub, dilithiumPriv, _ := GenerateDilithiumKeys()
+	remote := &localRemote{sign: dilithiumPriv}
+	a := NewRemoteSigningKey(dilithiumPub, remote)
+	b := NewRemoteSigningKey(dilithiumPub, remote)
+
+	if !IsRemoteSigningKey(a) || IsRemoteSigningKey(dilithiumPriv) {
+		t.Error("IsRemoteSigningKey 判断错误")
+	}
+	if !a.Equal(a) || a.Equal(b) || a.Equal(dilithiumPriv) {
+		t.Error("远程签名密钥只应与自身相等")
+	}
+	if _, err := ExportDilithiumKeys(dilithiumPub, a); err == nil {
+		t.Error("ExportDilithiumKeys 应拒绝远程签名密钥")
+	}
+	if _, err := MarshalPKCS8PrivateKey(a); err == nil {
+		t.Error("MarshalPKCS8PrivateKey 应拒绝远程签名密钥")
+	}
+	if _, err := NewPaperKey(a); err == nil {
+		t.Error("NewPaperKey 应拒绝远程签名密钥")
+	}
+}
@@ -0,0 +1,157 @@
+package zjcrypto
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+)
+
+// maxSecretFileSize 读入 SecretBuffer 的密钥文件大小上限.
+const maxSecretFileSize = 1 << 20
+
+// SecretBuffer 保存密钥材料的缓冲区.
+//
+// 在支持的平台上内存通过 mmap 单独分配、尽量 mlock 以免换出到磁盘，并在 Linux 上排除出核心转储；
+// 其余平台退化为普通堆内存. Release 清零并释放内存，调用方在用完后必须调用；
+// 遗漏的 Release 由 GC 清理时补做.
+type SecretBuffer struct {
+	mu       sync.Mutex
+	data     []byte
+	mem      *secretMemory
+	cleanup  runtime.Cleanup
+	released bool
+}
+
+// secretMemory 底层内存区域；mapped 表示由 mmap 分配，locked 表示 mlock 是否成功.
+type secretMemory struct {
+	region []byte
+	mapped bool
+	locked bool
+}
+
+// freeSecretMemory 平台相关的释放函数，测试可替换以检查释放前的内容.
+var freeSecretMemory = freeSecret
+
+// NewSecretBuffer 分配 size 字节的零值密钥缓冲区.
+func NewSecretBuffer(size int) *SecretBuffer {
+	mem := allocSecret(size)
+	b := &SecretBuffer{data: mem.region[:size:size], mem: mem}
+	b.cleanup = runtime.AddCleanup(b, releaseSecretMemory, mem)
+	return b
+}
+
+// NewSecretBufferFrom 把 src 复制到新的密钥缓冲区并清零 src.
+func NewSecretBufferFrom(src []byte) *SecretBuffer {
+	b := NewSecretBuffer(len(src))
+	copy(b.data, src)
+	clear(src)
+	return b
+}
+
+// Bytes 返回缓冲区内容；Release 之后不得再使用返回的切片.
+func (b *SecretBuffer) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.released {
+		return nil
+	}
+	return b.data
+}
+
+// Len 返回缓冲区长度.
+func (b *SecretBuffer) Len() int {
+	return len(b.Bytes())
+}
+
+// Locked 报告内存是否已被 mlock.
+func (b *SecretBuffer) Locked() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.released && b.mem.locked
+}
+
+// Release 清零并释放缓冲区，可重复调用，nil 缓冲区上调用无副作用.
+func (b *SecretBuffer) Release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.released {
+		return
+	}
+	b.released = true
+	b.cleanup.Stop()
+	releaseSecretMemory(b.mem)
+	b.data = nil
+}
+
+func releaseSecretMemory(mem *secretMemory) {
+	clear(mem.region[:cap(mem.region)])
+	freeSecretMemory(mem)
+}
+
+// readSecretFile 把文件内容读入密钥缓冲区，避免在堆上留下副本.
+func readSecretFile(path string) (*SecretBuffer, error) {
+	f, err := os.Open(path) // #nosec G304 - 调用方应验证路径安全性
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+	if info.Size() > maxSecretFileSize {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+			fmt.Sprintf("Key file too large: %d bytes", info.Size()))
+	}
+
+	// 多分配一个字节以发现读取期间变长的文件
+	b := NewSecretBuffer(int(info.Size()) + 1)
+	n, err := io.ReadFull(f, b.data)
+	switch {
+	case err == nil:
+		b.Release()
+		return nil, utils.NewCryptoError(utils.ErrIOError, "Key file changed while reading")
+	case err == io.ErrUnexpectedEOF || err == io.EOF: //nolint:errorlint // io.ReadFull 直接返回这两个哨兵错误
+		b.data = b.data[:n:n]
+		return b, nil
+	default:
+		b.Release()
+		//nolint:wrapcheck
+		return nil, err
+	}
+}
+
+// readSecretStream 把长度未知的流读到 EOF 并放入大小合适的密钥缓冲区，上限为 maxSecretFileSize.
+func readSecretStream(r io.Reader) (*SecretBuffer, error) {
+	b := NewSecretBuffer(maxSecretFileSize + 1)
+	defer b.Release()
+	n, err := io.ReadFull(r, b.data)
+	switch {
+	case err == nil:
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey,
+			fmt.Sprintf("Key data exceeds %d bytes", maxSecretFileSize))
+	case err == io.ErrUnexpectedEOF || err == io.EOF: //nolint:errorlint // io.ReadFull 直接返回这两个哨兵错误
+		return NewSecretBufferFrom(b.data[:n]), nil
+	default:
+		//nolint:wrapcheck
+		return nil, err
+	}
+}
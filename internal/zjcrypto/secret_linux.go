@@ -0,0 +1,10 @@
+package zjcrypto
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// excludeFromCoreDump 标记内存页不写入核心转储.
+func excludeFromCoreDump(region []byte) {
+	_ = unix.Madvise(region, unix.MADV_DONTDUMP)
+}
@@ -0,0 +1,6 @@
+//go:build unix && !linux
+
+package zjcrypto
+
+// excludeFromCoreDump 在没有 MADV_DONTDUMP 的平台上为空操作.
+func excludeFromCoreDump([]byte) {}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package zjcrypto
+
+// allocSecret 在不支持 mmap/mlock 的平台上使用堆内存.
+func allocSecret(size int) *secretMemory {
+	return &secretMemory{region: make([]byte, size)}
+}
+
+// freeSecret 堆内存交给 GC.
+func freeSecret(mem *secretMemory) {
+	*mem = secretMemory{}
+}
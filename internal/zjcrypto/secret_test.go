@@ -0,0 +1,222 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+)
+
+// releaseRecorder 记录释放时的缓冲区大小以及是否已清零.
+type releaseRecorder struct {
+	mu      sync.Mutex
+	dirty   int
+	total   int
+	largest int
+}
+
+// recordReleases 在测试期间拦截 freeSecretMemory.
+func recordReleases(t *testing.T) *releaseRecorder {
+	t.Helper()
+	r := &releaseRecorder{}
+	orig := freeSecretMemory
+	freeSecretMemory = func(mem *secretMemory) {
+		region := mem.region[:cap(mem.region)]
+		r.mu.Lock()
+		r.total++
+		r.largest = max(r.largest, len(mem.region))
+		if !bytes.Equal(region, make([]byte, len(region))) {
+			r.dirty++
+		}
+		r.mu.Unlock()
+		orig(mem)
+	}
+	t.Cleanup(func() {
+		freeSecretMemory = orig
+	})
+	return r
+}
+
+func (r *releaseRecorder) snapshot() (total, dirty, largest int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.total, r.dirty, r.largest
+}
+
+func TestSecretBuffer(t *testing.T) {
+	rec := recordReleases(t)
+
+	src := []byte("top secret material")
+	b := NewSecretBufferFrom(src)
+	if !bytes.Equal(src, make([]byte, len(src))) {
+		t.Error("NewSecretBufferFrom 应清零源数据")
+	}
+	if string(b.Bytes()) != "top secret material" || b.Len() != len(src) {
+		t.Errorf("Bytes = %q", b.Bytes())
+	}
+	t.Logf("mlock: %v", b.Locked())
+
+	b.Release()
+	b.Release()
+	if b.Bytes() != nil || b.Locked() {
+		t.Error("Release 后不应再返回内容")
+	}
+	if total, dirty, _ := rec.snapshot(); total != 1 || dirty != 0 {
+		t.Errorf("释放 %d 次，其中 %d 次未清零", total, dirty)
+	}
+
+	var nilBuf *SecretBuffer
+	nilBuf.Release()
+	if nilBuf.Bytes() != nil || nilBuf.Len() != 0 {
+		t.Error("nil 缓冲区应为空")
+	}
+
+	empty := NewSecretBuffer(0)
+	if empty.Len() != 0 {
+		t.Error("零长度缓冲区长度应为 0")
+	}
+	empty.Release()
+}
+
+func TestSecretBufferCleanup(t *testing.T) {
+	rec := recordReleases(t)
+	func() {
+		b := NewSecretBuffer(64)
+		copy(b.Bytes(), "forgotten")
+	}()
+	// 遗漏的 Release 由 GC 清理时补做
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		runtime.GC()
+		if total, dirty, _ := rec.snapshot(); total == 1 {
+			if dirty != 0 {
+				t.Error("GC 清理时应清零")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("GC 未释放缓冲区")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHybridSecretsWiped(t *testing.T) {
+	kyberPub, kyberPriv, ecdhPub, ecdhPriv, err := GenerateHybridKeysParallel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, dilithiumPriv, err := GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := recordReleases(t)
+
+	encapsulated, tempPub, secret, err := NewHybridEncryptor(kyberPub, ecdhPub).EncapsulateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := NewHybridDecryptor(kyberPriv, ecdhPriv).DecapsulateSecret(encapsulated, tempPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(secret.Bytes(), decrypted.Bytes()) || secret.Len() != 32 {
+		t.Fatal("封装与解封装的共享密钥不一致")
+	}
+	secret.Release()
+	decrypted.Release()
+	if total, dirty, _ := rec.snapshot(); total != 2 || dirty != 0 {
+		t.Errorf("共享密钥释放 %d 次，其中 %d 次未清零", total, dirty)
+	}
+
+	// 加解密路径上的共享密钥在返回前全部释放并清零
+	plain := []byte("wipe me after use")
+	data, err := EncryptData(plain, "w.txt", kyberPub, ecdhPub, dilithiumPriv, CompressionOptions{Codec: format.CompressionGzip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecryptDataCore(data, kyberPriv, ecdhPriv, nil)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if total, dirty, _ := rec.snapshot(); total != 4 || dirty != 0 {
+		t.Errorf("共享密钥释放 %d 次，其中 %d 次未清零", total, dirty)
+	}
+}
+
+func TestDecryptedPlaintextWiped(t *testing.T) {
+	kyberPub, kyberPriv, ecdhPub, ecdhPriv, err := GenerateHybridKeysParallel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dilithiumPub, dilithiumPriv, err := GenerateDilithiumKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wiped [][]byte
+	orig := wipePlaintext
+	wipePlaintext = func(plaintext []byte) {
+		orig(plaintext)
+		wiped = append(wiped, plaintext)
+	}
+	t.Cleanup(func() {
+		wipePlaintext = orig
+	})
+
+	dir := t.TempDir()
+	plain := []byte("plaintext that must not linger in memory")
+	data, err := EncryptData(plain, "p.txt", kyberPub, ecdhPub, dilithiumPriv, NoCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(dir, "p.txt.fzj")
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "p.txt")
+	if err := DecryptFile(input, output, kyberPriv, ecdhPriv, dilithiumPub); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(output); !bytes.Equal(got, plain) { // #nosec G304 - 测试环境使用临时文件路径
+		t.Fatal("解密文件内容不一致")
+	}
+	if len(wiped) != 1 || len(wiped[0]) != len(plain) || !bytes.Equal(wiped[0], make([]byte, len(plain))) {
+		t.Errorf("DecryptFile 写入后应清零明文缓冲区，实际清零 %d 个", len(wiped))
+	}
+}
+
+func TestPrivateKeyFileWiped(t *testing.T) {
+	dir := t.TempDir()
+	_, privPath, _, dilPrivPath := writeCacheTestKeys(t, dir, "wipe")
+	rec := recordReleases(t)
+
+	if _, err := LoadPrivateKey(privPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDilithiumPrivateKey(dilPrivPath); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(dilPrivPath)
+	total, dirty, largest := rec.snapshot()
+	if total != 2 || dirty != 0 {
+		t.Errorf("PEM 缓冲区释放 %d 次，其中 %d 次未清零", total, dirty)
+	}
+	// readSecretFile 多分配一个字节用于发现变长的文件
+	if int64(largest) != info.Size()+1 {
+		t.Errorf("最大缓冲区 %d 字节，期望为私钥文件大小 %d", largest, info.Size())
+	}
+
+	// 过大的文件被拒绝
+	big := filepath.Join(dir, "big.pem")
+	if err := os.WriteFile(big, make([]byte, maxSecretFileSize+1), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPrivateKey(big); err == nil {
+		t.Error("期望拒绝过大的私钥文件")
+	}
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package zjcrypto
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// allocSecret 用匿名 mmap 分配独立的内存页并尝试 mlock；mmap 失败时退化为堆内存.
+func allocSecret(size int) *secretMemory {
+	region, err := unix.Mmap(-1, 0, max(size, 1), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return &secretMemory{region: make([]byte, size)}
+	}
+	excludeFromCoreDump(region)
+	// RLIMIT_MEMLOCK 不足时 mlock 失败，内存仍可用，只是可能被换出
+	return &secretMemory{region: region[:size], mapped: true, locked: unix.Mlock(region) == nil}
+}
+
+// freeSecret 解锁并解除映射；堆内存交给 GC.
+func freeSecret(mem *secretMemory) {
+	if mem.mapped {
+		region := mem.region[:cap(mem.region)]
+		if mem.locked {
+			_ = unix.Munlock(region)
+		}
+		_ = unix.Munmap(region)
+	}
+	*mem = secretMemory{}
+}
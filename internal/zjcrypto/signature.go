@@ -1,6 +1,7 @@
 package zjcrypto
 
 import (
+	"crypto"
 	"crypto/rand"
 	"fmt"
 	"os"
@@ -9,17 +10,19 @@ import (
 	"github.com/cloudflare/circl/sign/dilithium/mode3"
 )
 
-// SignDataWithKey 使用强类型 Dilithium3 私钥对数据进行签名.
-func SignDataWithKey(data []byte, privKey *mode3.PrivateKey) (signature []byte, err error) {
-	if privKey == nil {
+// SignDataWithKey 使用签名私钥（Dilithium3、复合或远程）对数据进行签名.
+func SignDataWithKey(data []byte, privKey SigningKey) (signature []byte, err error) {
+	if isNilSigningKey(privKey) {
 		return nil, utils.NewCryptoError(
 			utils.ErrInvalidKey,
 			"Dilithium3 private key cannot be nil",
 		)
 	}
 
-	signature = make([]byte, mode3.SignatureSize)
-	mode3.SignTo(privKey, data, signature)
+	signature, err = privKey.Sign(rand.Reader, data, crypto.Hash(0))
+	if err != nil {
+		return nil, err //nolint:wrapcheck // 复合与远程签名密钥返回的已是 CryptoError
+	}
 	return signature, nil
 }
 
@@ -28,7 +31,7 @@ func SignDataWithKey(data []byte, privKey *mode3.PrivateKey) (signature []byte,
 // 返回: 签名 (3293B), 错误.
 func SignData(data []byte, privKey interface{}) (signature []byte, err error) {
 	// 确保私钥是 Dilithium3 类型
-	priv, ok := privKey.(*mode3.PrivateKey)
+	priv, ok := privKey.(SigningKey)
 	if !ok {
 		return nil, utils.NewCryptoError(
 			utils.ErrInvalidKey,
@@ -39,16 +42,26 @@ func SignData(data []byte, privKey interface{}) (signature []byte, err error) {
 	return SignDataWithKey(data, priv)
 }
 
-// VerifySignatureWithKey 使用强类型 Dilithium3 公钥验证签名.
-func VerifySignatureWithKey(data []byte, signature []byte, pubKey *mode3.PublicKey) (bool, error) {
-	if pubKey == nil {
-		return false, utils.NewCryptoError(
-			utils.ErrInvalidKey,
-			"Dilithium3 public key cannot be nil",
-		)
-	}
-	valid := mode3.Verify(pubKey, data, signature)
-	return valid, nil
+// VerifySignatureWithKey 使用验签公钥验证签名.
+// pubKey 为复合公钥时只接受 Ed25519 与 Dilithium3 分量都有效的复合签名.
+func VerifySignatureWithKey(data []byte, signature []byte, pubKey VerifyingKey) (bool, error) {
+	switch pub := pubKey.(type) {
+	case *mode3.PublicKey:
+		if pub != nil {
+			return mode3.Verify(pub, data, signature), nil
+		}
+	case *CompositePublicKey:
+		if pub != nil {
+			return verifyComposite(pub, data, signature), nil
+		}
+	case nil:
+	default:
+		return false, utils.NewCryptoError(utils.ErrInvalidKey, "Invalid Dilithium3 public key type")
+	}
+	return false, utils.NewCryptoError(
+		utils.ErrInvalidKey,
+		"Dilithium3 public key cannot be nil",
+	)
 }
 
 // VerifySignature 验证 Dilithium3 签名
@@ -56,7 +69,7 @@ func VerifySignatureWithKey(data []byte, signature []byte, pubKey *mode3.PublicK
 // 返回: bool (true = 验证通过), 错误.
 func VerifySignature(data []byte, signature []byte, pubKey interface{}) (bool, error) {
 	// 确保公钥是 Dilithium3 类型
-	pub, ok := pubKey.(*mode3.PublicKey)
+	pub, ok := pubKey.(VerifyingKey)
 	if !ok {
 		return false, utils.NewCryptoError(
 			utils.ErrInvalidKey,
@@ -68,7 +81,7 @@ func VerifySignature(data []byte, signature []byte, pubKey interface{}) (bool, e
 }
 
 // SignFileWithKey 对文件数据进行签名（强类型私钥）.
-func SignFileWithKey(filePath string, privKey *mode3.PrivateKey) (signature []byte, err error) {
+func SignFileWithKey(filePath string, privKey SigningKey) (signature []byte, err error) {
 	data, err := readFileData(filePath)
 	if err != nil {
 		return nil, err
@@ -90,7 +103,7 @@ func SignFile(filePath string, privKey interface{}) (signature []byte, err error
 }
 
 // VerifyFileSignatureWithKey 验证文件签名（强类型公钥）.
-func VerifyFileSignatureWithKey(filePath string, signature []byte, pubKey *mode3.PublicKey) (bool, error) {
+func VerifyFileSignatureWithKey(filePath string, signature []byte, pubKey VerifyingKey) (bool, error) {
 	data, err := readFileData(filePath)
 	if err != nil {
 		return false, err
@@ -112,7 +125,7 @@ func VerifyFileSignature(filePath string, signature []byte, pubKey interface{})
 }
 
 // SignHashWithKey 对哈希值进行签名（强类型私钥）.
-func SignHashWithKey(hash []byte, privKey *mode3.PrivateKey) (signature []byte, err error) {
+func SignHashWithKey(hash []byte, privKey SigningKey) (signature []byte, err error) {
 	if len(hash) != 32 {
 		return nil, utils.NewCryptoError(
 			utils.ErrInvalidParameter,
@@ -138,7 +151,7 @@ func SignHash(hash []byte, privKey interface{}) (signature []byte, err error) {
 }
 
 // VerifyHashSignatureWithKey 验证哈希签名（强类型公钥）.
-func VerifyHashSignatureWithKey(hash []byte, signature []byte, pubKey *mode3.PublicKey) (bool, error) {
+func VerifyHashSignatureWithKey(hash []byte, signature []byte, pubKey VerifyingKey) (bool, error) {
 	if len(hash) != 32 {
 		return false, utils.NewCryptoError(
 			utils.ErrInvalidParameter,
@@ -205,12 +218,12 @@ func DilithiumPrivateKeySize() int {
 	return mode3.PrivateKeySize
 }
 
-// DilithiumPublicFromPrivate 从私钥获取公钥（强类型）.
-func DilithiumPublicFromPrivate(privKey *mode3.PrivateKey) *mode3.PublicKey {
-	if privKey == nil {
+// DilithiumPublicFromPrivate 从签名私钥（Dilithium3、复合或远程）获取验签公钥.
+func DilithiumPublicFromPrivate(privKey SigningKey) VerifyingKey {
+	if isNilSigningKey(privKey) {
 		return nil
 	}
-	pub, ok := privKey.Public().(*mode3.PublicKey)
+	pub, ok := privKey.Public().(VerifyingKey)
 	if !ok {
 		return nil
 	}
@@ -218,6 +231,6 @@ func DilithiumPublicFromPrivate(privKey *mode3.PrivateKey) *mode3.PublicKey {
 }
 
 // DilithiumGetPublicKey 从私钥获取公钥.
-func DilithiumGetPublicKey(privKey *mode3.PrivateKey) *mode3.PublicKey {
+func DilithiumGetPublicKey(privKey SigningKey) VerifyingKey {
 	return DilithiumPublicFromPrivate(privKey)
 }
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
 	"github.com/cloudflare/circl/sign/dilithium/mode3"
 )
 
@@ -306,6 +307,20 @@ func TestSignDataWithInvalidKey(t *testing.T) {
 	}
 }
 
+// TestSignDataWithTypedNilKey 测试装在接口中的 nil 私钥返回错误而不是 panic.
+func TestSignDataWithTypedNilKey(t *testing.T) {
+	for _, key := range []SigningKey{(*mode3.PrivateKey)(nil), (*CompositePrivateKey)(nil)} {
+		_, err := SignData([]byte("test"), key)
+		if code, _ := utils.CodeOf(err); code != utils.ErrInvalidKey {
+			t.Errorf("%T: 期望 ErrInvalidKey，实际 %v", key, err)
+		}
+	}
+	if _, err := EncryptDirectoryMirror(t.TempDir(), t.TempDir(), nil, nil, (*mode3.PrivateKey)(nil),
+		MirrorOptions{}); err == nil {
+		t.Error("镜像模式应拒绝 nil 签名私钥")
+	}
+}
+
 // TestVerifyDataWithInvalidKey 测试无效密钥验证.
 func TestVerifyDataWithInvalidKey(t *testing.T) {
 	_, priv, _ := GenerateDilithiumKeys()
@@ -5,7 +5,6 @@ import (
 
 	"codeberg.org/jiangfire/fzjjyz/internal/utils"
 	"github.com/cloudflare/circl/kem"
-	"github.com/cloudflare/circl/sign/dilithium/mode3"
 )
 
 // StreamingDecryptor 流式解密器
@@ -17,7 +16,7 @@ import (
 type StreamingDecryptor struct {
 	kyberPriv    kem.PrivateKey
 	ecdhPriv     *ecdh.PrivateKey
-	dilithiumPub *mode3.PublicKey
+	dilithiumPub VerifyingKey
 	bufferSize   int
 	pool         *BufferPool
 }
@@ -26,7 +25,7 @@ type StreamingDecryptor struct {
 func NewStreamingDecryptor(
 	kyberPriv kem.PrivateKey,
 	ecdhPriv *ecdh.PrivateKey,
-	dilithiumPub *mode3.PublicKey,
+	dilithiumPub VerifyingKey,
 	bufferSize int,
 ) (*StreamingDecryptor, error) {
 	if bufferSize < MinBufferSize || bufferSize > MaxBufferSize {
@@ -48,6 +47,11 @@ func NewStreamingDecryptor(
 // DecryptFile 流式解密文件
 // 使用核心解密逻辑，支持缓冲区池优化.
 func (sd *StreamingDecryptor) DecryptFile(inputPath, outputPath string) error {
+	// 分块文件可以逐块解密，无需整体读入内存
+	if isChunkedFile(inputPath) {
+		return DecryptFileChunked(inputPath, outputPath, sd.kyberPriv, sd.ecdhPriv, sd.dilithiumPub)
+	}
+
 	// 调用核心解密逻辑
 	plaintext, err := DecryptFileCore(inputPath, sd.kyberPriv, sd.ecdhPriv, sd.dilithiumPub)
 	if err != nil {
@@ -5,7 +5,6 @@ import (
 
 	"codeberg.org/jiangfire/fzjjyz/internal/utils"
 	"github.com/cloudflare/circl/kem"
-	"github.com/cloudflare/circl/sign/dilithium/mode3"
 )
 
 // StreamingEncryptor 流式加密器
@@ -17,16 +16,17 @@ import (
 type StreamingEncryptor struct {
 	kyberPub      kem.PublicKey
 	ecdhPub       *ecdh.PublicKey
-	dilithiumPriv *mode3.PrivateKey
+	dilithiumPriv SigningKey
 	bufferSize    int
 	pool          *BufferPool
+	compression   CompressionOptions
 }
 
 // NewStreamingEncryptor 创建流式加密器.
 func NewStreamingEncryptor(
 	kyberPub kem.PublicKey,
 	ecdhPub *ecdh.PublicKey,
-	dilithiumPriv *mode3.PrivateKey,
+	dilithiumPriv SigningKey,
 	bufferSize int,
 ) (*StreamingEncryptor, error) {
 	if bufferSize < MinBufferSize || bufferSize > MaxBufferSize {
@@ -42,14 +42,22 @@ func NewStreamingEncryptor(
 		dilithiumPriv: dilithiumPriv,
 		bufferSize:    bufferSize,
 		pool:          NewBufferPool(bufferSize),
+		compression:   NoCompression,
 	}, nil
 }
 
+// SetCompression 设置加密前的压缩选项.
+func (se *StreamingEncryptor) SetCompression(opts CompressionOptions) {
+	se.compression = opts
+}
+
 // EncryptFile 流式加密文件
 // 使用核心加密逻辑，支持缓冲区池优化.
 func (se *StreamingEncryptor) EncryptFile(inputPath, outputPath string) error {
 	// 调用核心加密逻辑
-	header, ciphertext, err := EncryptFileCore(inputPath, se.kyberPub, se.ecdhPub, se.dilithiumPriv)
+	header, ciphertext, err := EncryptFileCoreWithCompression(
+		inputPath, se.kyberPub, se.ecdhPub, se.dilithiumPriv, se.compression,
+	)
 	if err != nil {
 		return err
 	}
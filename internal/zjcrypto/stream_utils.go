@@ -11,6 +11,13 @@ type StreamProcessor interface {
 	Process(input io.Reader, output io.Writer) error
 }
 
+// BackfillWriter 顺序写入、并可通过 WriteAt 回填已写入部分（如占位文件头）的输出.
+// *os.File 和 *VolumeWriter 都实现该接口.
+type BackfillWriter interface {
+	io.Writer
+	io.WriterAt
+}
+
 // MultiWriter 支持多目标写入的包装器.
 type MultiWriter struct {
 	writers []io.Writer
@@ -0,0 +1,648 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+	"codeberg.org/jiangfire/fzjjyz/internal/utils"
+	"github.com/cloudflare/circl/kem"
+)
+
+// MinVolumeSize 分卷大小下限（含分卷头）.
+const MinVolumeSize = 64 * 1024
+
+// SplitVolumes 将加密文件切分为不超过 volumeSize 字节的分卷 base.001、base.002 ...
+// 每个分卷头由 signKey（普通、复合或远程密钥）签名；失败时删除已写入的分卷.
+func SplitVolumes(inputPath, base string, volumeSize int64, signKey SigningKey) ([]string, error) {
+	in, err := os.Open(inputPath) // #nosec G304 - 路径由调用方验证
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", inputPath, err)
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	w, err := CreateVolumes(base, volumeSize, signKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		w.Abort()
+		return nil, fmt.Errorf("split %s: %w", inputPath, err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return w.Paths(), nil
+}
+
+// VolumeWriter 将写入的字节流直接切分为分卷 base.001、base.002 ...，无需先写出完整的加密文件.
+// 分卷总数和原文件大小在 Close 时才确定，因此每个分卷先写入占位头部，Close 时统一签名并回填.
+// WriteAt 只能改写已写入的范围（用于回填加密文件头），被改写的分卷在 Close 时重新计算载荷哈希.
+type VolumeWriter struct {
+	base       string
+	headerSize int
+	payloadMax uint64
+	signKey    SigningKey
+	setID      [format.VolumeSetIDSize]byte
+	volumes    []writtenVolume
+	file       *os.File // 当前分卷
+	hasher     hash.Hash
+	total      uint64
+	closed     bool
+}
+
+// writtenVolume 已创建分卷的载荷信息.
+type writtenVolume struct {
+	path  string
+	size  uint64
+	hash  [32]byte
+	dirty bool // 载荷写入后被 WriteAt 改写，哈希需要重新计算
+}
+
+// CreateVolumes 创建分卷写入器并立即创建第一个分卷，分卷头由 signKey 签名.
+func CreateVolumes(base string, volumeSize int64, signKey SigningKey) (*VolumeWriter, error) {
+	if volumeSize < MinVolumeSize {
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Volume size must be at least %d bytes", MinVolumeSize),
+		)
+	}
+	if isNilSigningKey(signKey) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidKey, "Dilithium3 private key cannot be nil")
+	}
+	headerSize := format.VolumeHeaderSizeFor(SignatureSizeFor(signKey))
+
+	w := &VolumeWriter{
+		base:       base,
+		headerSize: headerSize,
+		payloadMax: uint64(volumeSize - int64(headerSize)), // #nosec G115 - volumeSize 不小于 MinVolumeSize
+		signKey:    signKey,
+		hasher:     sha256.New(),
+	}
+	if _, err := rand.Read(w.setID[:]); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrSystem, "Failed to generate volume set ID: "+err.Error())
+	}
+	if err := w.nextVolume(); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	return w, nil
+}
+
+// nextVolume 创建下一个分卷并写入占位头部.
+func (w *VolumeWriter) nextVolume() error {
+	if len(w.volumes) >= format.MaxVolumeCount {
+		return utils.NewCryptoError(
+			utils.ErrInvalidParameter,
+			fmt.Sprintf("Volume size too small: more than %d volumes", format.MaxVolumeCount),
+		)
+	}
+	path := format.VolumeName(w.base, len(w.volumes)+1)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, encryptedFilePerm) // #nosec G302,G304 - 与加密文件权限一致
+	if err != nil {
+		return fmt.Errorf("create volume %s: %w", path, err)
+	}
+	w.volumes = append(w.volumes, writtenVolume{path: path})
+	w.file = f
+	w.hasher.Reset()
+	if _, err := f.Write(make([]byte, w.headerSize)); err != nil {
+		return fmt.Errorf("write volume %s: %w", path, err)
+	}
+	return nil
+}
+
+// finishVolume 记录当前分卷的载荷哈希并关闭文件.
+func (w *VolumeWriter) finishVolume() error {
+	cur := &w.volumes[len(w.volumes)-1]
+	copy(cur.hash[:], w.hasher.Sum(nil))
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return fmt.Errorf("close volume %s: %w", cur.path, err)
+	}
+	return nil
+}
+
+// Write 实现 io.Writer，当前分卷写满时自动创建下一个分卷.
+func (w *VolumeWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	written := 0
+	for len(p) > 0 {
+		if w.volumes[len(w.volumes)-1].size == w.payloadMax {
+			if err := w.finishVolume(); err != nil {
+				return written, err
+			}
+			if err := w.nextVolume(); err != nil {
+				return written, err
+			}
+		}
+		cur := &w.volumes[len(w.volumes)-1]
+		n, err := w.file.Write(p[:min(uint64(len(p)), w.payloadMax-cur.size)])
+		w.hasher.Write(p[:n])
+		cur.size += uint64(n) // #nosec G115 - n 非负
+		w.total += uint64(n)  // #nosec G115 - n 非负
+		written += n
+		if err != nil {
+			return written, fmt.Errorf("write volume %s: %w", cur.path, err)
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// WriteAt 实现 io.WriterAt，只能改写已经写入的范围.
+func (w *VolumeWriter) WriteAt(p []byte, off int64) (int, error) {
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	// #nosec G115 - off 已检查非负
+	if off < 0 || uint64(off)+uint64(len(p)) > w.total {
+		return 0, utils.NewCryptoError(utils.ErrInvalidParameter, "Volume WriteAt beyond written data")
+	}
+	written := 0
+	for len(p) > 0 {
+		pos := uint64(off) + uint64(written) // #nosec G115 - 均非负
+		i := int(pos / w.payloadMax)         // #nosec G115 - 不超过分卷数量
+		inner := pos % w.payloadMax
+		n := int(min(uint64(len(p)), w.payloadMax-inner)) // #nosec G115 - 不超过 len(p)
+		if err := w.writeVolumeAt(i, p[:n], inner); err != nil {
+			return written, err
+		}
+		w.volumes[i].dirty = true
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// writeVolumeAt 将 p 写入第 i 个分卷载荷的 inner 偏移处.
+func (w *VolumeWriter) writeVolumeAt(i int, p []byte, inner uint64) (err error) {
+	path := w.volumes[i].path
+	off := int64(w.headerSize) + int64(inner) // #nosec G115 - 不超过分卷大小
+	f := w.file
+	if i < len(w.volumes)-1 || f == nil {
+		f, err = os.OpenFile(path, os.O_WRONLY, 0) // #nosec G304 - 分卷路径由写入器生成
+		if err != nil {
+			return fmt.Errorf("open volume %s: %w", path, err)
+		}
+		defer func() {
+			if closeErr := f.Close(); err == nil && closeErr != nil {
+				err = fmt.Errorf("close volume %s: %w", path, closeErr)
+			}
+		}()
+	}
+	if _, err := f.WriteAt(p, off); err != nil {
+		return fmt.Errorf("write volume %s: %w", path, err)
+	}
+	return nil
+}
+
+// Close 结束最后一个分卷，签名并回填所有分卷头；失败时删除已写入的分卷.
+func (w *VolumeWriter) Close() (err error) {
+	if w.closed {
+		return nil
+	}
+	defer func() {
+		if err != nil {
+			w.Abort()
+		}
+		w.closed = true
+	}()
+	if err := w.finishVolume(); err != nil {
+		return err
+	}
+
+	header := format.VolumeHeader{
+		SetID:     w.setID,
+		Count:     uint32(len(w.volumes)), // #nosec G115 - 不超过 MaxVolumeCount
+		TotalSize: w.total,
+	}
+	for i := range w.volumes {
+		v := &w.volumes[i]
+		if v.dirty {
+			if v.hash, err = hashVolumePayload(v.path, w.headerSize, v.size); err != nil {
+				return err
+			}
+		}
+		header.Index = uint32(i + 1) // #nosec G115 - 不超过 MaxVolumeCount
+		header.PayloadSize = v.size
+		header.PayloadHash = v.hash
+		if err := w.writeHeader(v.path, &header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHeader 签名分卷头并写入分卷开头的占位区域.
+func (w *VolumeWriter) writeHeader(path string, header *format.VolumeHeader) (err error) {
+	header.Signature, err = SignDataWithKey(header.SignedBytes(), w.signKey)
+	if err != nil {
+		return err
+	}
+	headerBytes, err := header.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if len(headerBytes) != w.headerSize {
+		return utils.NewCryptoError(utils.ErrSigningFailed, "Unexpected volume signature length")
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0) // #nosec G304 - 分卷路径由写入器生成
+	if err != nil {
+		return fmt.Errorf("open volume %s: %w", path, err)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("close volume %s: %w", path, closeErr)
+		}
+	}()
+	if _, err := f.WriteAt(headerBytes, 0); err != nil {
+		return fmt.Errorf("write volume %s: %w", path, err)
+	}
+	return nil
+}
+
+// hashVolumePayload 重新计算分卷载荷的 SHA-256.
+func hashVolumePayload(path string, headerSize int, size uint64) ([32]byte, error) {
+	f, err := os.Open(path) // #nosec G304 - 分卷路径由写入器生成
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("open volume %s: %w", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	// #nosec G115 - 不超过分卷大小
+	hash, err := HashReader(io.NewSectionReader(f, int64(headerSize), int64(size)))
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("read volume %s: %w", path, err)
+	}
+	return hash, nil
+}
+
+// Abort 放弃写入并删除已创建的分卷，用于上游加密失败时清理.
+func (w *VolumeWriter) Abort() {
+	if w.file != nil {
+		_ = w.file.Close()
+		w.file = nil
+	}
+	w.closed = true
+	for _, v := range w.volumes {
+		_ = os.Remove(v.path)
+	}
+}
+
+// Paths 返回已创建的分卷路径.
+func (w *VolumeWriter) Paths() []string {
+	paths := make([]string, len(w.volumes))
+	for i, v := range w.volumes {
+		paths[i] = v.path
+	}
+	return paths
+}
+
+// Size 返回已写入的字节数，即原加密文件大小.
+func (w *VolumeWriter) Size() int64 {
+	return int64(w.total) // #nosec G115 - 由写入长度累加而来
+}
+
+// IsVolumeFile 判断文件是否为分卷.
+func IsVolumeFile(path string) bool {
+	f, err := os.Open(path) // #nosec G304 - 路径由调用方提供
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false
+	}
+	return format.IsVolumeHeader(magic)
+}
+
+// VolumeReader 读取分卷集中的载荷，还原原加密文件的字节流.
+// 打开时校验所有分卷头的序号、总数和分卷集 ID（提供验证公钥时同时验证签名），
+// 因此缺失、乱序或混入其他分卷集的分卷在读取前即被发现.
+// Read 顺序读取并在读完每个分卷时校验其载荷哈希，被修改的分卷会导致读取失败；
+// ReadAt 不校验载荷哈希，只用于自带认证的格式（分块文件和索引存档的每个数据段都受 AEAD 保护）.
+// ReadAt 可并发调用；Read 共享读取位置，不可并发调用.
+type VolumeReader struct {
+	volumes   []openedVolume
+	total     uint64
+	index     int // 当前顺序读取的分卷下标
+	file      *os.File
+	remaining uint64
+	hasher    hash.Hash
+}
+
+// openedVolume 已校验头部的分卷.
+type openedVolume struct {
+	path   string
+	header format.VolumeHeader
+	offset uint64 // 载荷在原加密文件中的起始位置
+}
+
+// OpenVolumes 打开分卷集，path 可以是任一分卷（通常为 .001），读取总是从第一个分卷开始.
+// verifyKey 为 nil 时不验证分卷头签名.
+func OpenVolumes(path string, verifyKey VerifyingKey) (*VolumeReader, error) {
+	base, _, ok := format.VolumeBase(path)
+	if !ok {
+		return nil, utils.NewCryptoError(utils.ErrInvalidParameter, "Not a volume file name (expected name.001): "+filepath.Base(path))
+	}
+
+	r := &VolumeReader{hasher: sha256.New()}
+	var first *format.VolumeHeader
+	for index := uint32(1); first == nil || index <= first.Count; index++ {
+		volumePath := format.VolumeName(base, int(index))
+		header, err := readVolumeHeader(volumePath, index, first, verifyKey)
+		if err != nil {
+			return nil, err
+		}
+		r.volumes = append(r.volumes, openedVolume{path: volumePath, header: *header, offset: r.total})
+		r.total += header.PayloadSize
+		if first == nil {
+			first = &r.volumes[0].header
+		}
+	}
+	if r.total != first.TotalSize {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Volume set size mismatch")
+	}
+	if err := r.openVolume(0); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// readVolumeHeader 读取并校验第 index 个分卷的头部，first 为第一个分卷的头部（读取第一个分卷时为 nil）.
+func readVolumeHeader(
+	path string,
+	index uint32,
+	first *format.VolumeHeader,
+	verifyKey VerifyingKey,
+) (*format.VolumeHeader, error) {
+	f, err := os.Open(path) // #nosec G304 - 分卷路径由第一个分卷推导
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && first != nil {
+			return nil, utils.NewCryptoError(
+				utils.ErrFileNotFound,
+				fmt.Sprintf("Missing volume %d of %d: %s", index, first.Count, path),
+			)
+		}
+		return nil, fmt.Errorf("open volume %s: %w", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	prefix := make([]byte, format.VolumeHeaderPrefixSize)
+	if _, err := io.ReadFull(f, prefix); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Truncated volume header: "+path)
+	}
+	size, err := format.VolumeHeaderSizeFromPrefix(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	data := append(prefix, make([]byte, size-len(prefix))...)
+	if _, err := io.ReadFull(f, data[len(prefix):]); err != nil {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Truncated volume header: "+path)
+	}
+	var header format.VolumeHeader
+	if err := header.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if verifyKey != nil {
+		valid, err := VerifySignatureWithKey(header.SignedBytes(), header.Signature, verifyKey)
+		if err != nil || !valid {
+			return nil, utils.NewCryptoError(utils.ErrSignatureVerification, "Volume signature verification failed: "+path)
+		}
+	}
+	if first != nil && !bytes.Equal(header.SetID[:], first.SetID[:]) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Volume belongs to a different volume set: "+path)
+	}
+	if header.Index != index {
+		return nil, utils.NewCryptoError(
+			utils.ErrInvalidFormat,
+			fmt.Sprintf("Volume out of order: %s is volume %d, expected %d", path, header.Index, index),
+		)
+	}
+	if first != nil && (header.Count != first.Count || header.TotalSize != first.TotalSize) {
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Volume set size mismatch: "+path)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat volume %s: %w", path, err)
+	}
+	if uint64(info.Size()) != uint64(header.Size())+header.PayloadSize { // #nosec G115 - 文件大小非负
+		return nil, utils.NewCryptoError(utils.ErrInvalidFormat, "Volume size does not match its header: "+path)
+	}
+	return &header, nil
+}
+
+// openVolume 打开第 i 个分卷并定位到载荷开头，用于顺序读取.
+func (r *VolumeReader) openVolume(i int) error {
+	v := &r.volumes[i]
+	f, err := os.Open(v.path) // #nosec G304 - 分卷路径由第一个分卷推导
+	if err != nil {
+		return fmt.Errorf("open volume %s: %w", v.path, err)
+	}
+	if _, err := f.Seek(int64(v.header.Size()), io.SeekStart); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("seek volume %s: %w", v.path, err)
+	}
+	r.index = i
+	r.file = f
+	r.remaining = v.header.PayloadSize
+	r.hasher.Reset()
+	return nil
+}
+
+// Count 返回分卷总数.
+func (r *VolumeReader) Count() int {
+	return len(r.volumes)
+}
+
+// Size 返回原加密文件大小.
+func (r *VolumeReader) Size() int64 {
+	return int64(r.total) // #nosec G115 - 由文件大小累加而来
+}
+
+// Read 实现 io.Reader.
+func (r *VolumeReader) Read(p []byte) (int, error) {
+	for r.remaining == 0 {
+		if r.file == nil {
+			return 0, io.EOF
+		}
+		if err := r.finishVolume(); err != nil {
+			return 0, err
+		}
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	n, err := r.file.Read(p[:min(uint64(len(p)), r.remaining)])
+	r.hasher.Write(p[:n])
+	r.remaining -= uint64(n) // #nosec G115 - n 非负
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, fmt.Errorf("read volume %d: %w", r.index+1, err)
+	}
+	if errors.Is(err, io.EOF) && r.remaining > 0 {
+		return n, utils.NewCryptoError(utils.ErrInvalidFormat, fmt.Sprintf("Volume %d is truncated", r.index+1))
+	}
+	return n, nil
+}
+
+// finishVolume 校验当前分卷的载荷哈希，并打开下一个分卷.
+func (r *VolumeReader) finishVolume() error {
+	if !bytes.Equal(r.hasher.Sum(nil), r.volumes[r.index].header.PayloadHash[:]) {
+		return utils.NewCryptoError(utils.ErrHashMismatch, fmt.Sprintf("Volume %d payload hash mismatch", r.index+1))
+	}
+	_ = r.file.Close()
+	r.file = nil
+
+	if r.index == len(r.volumes)-1 {
+		return nil
+	}
+	return r.openVolume(r.index + 1)
+}
+
+// ReadAt 实现 io.ReaderAt，每次调用按需打开覆盖读取范围的分卷.
+func (r *VolumeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, utils.NewCryptoError(utils.ErrInvalidParameter, "Negative volume offset")
+	}
+	n := 0
+	for n < len(p) {
+		pos := uint64(off) + uint64(n) // #nosec G115 - 均非负
+		if pos >= r.total {
+			return n, io.EOF
+		}
+		i := sort.Search(len(r.volumes), func(i int) bool {
+			return r.volumes[i].offset+r.volumes[i].header.PayloadSize > pos
+		})
+		v := &r.volumes[i]
+		inner := pos - v.offset
+		part := p[n : n+int(min(uint64(len(p)-n), v.header.PayloadSize-inner))] // #nosec G115 - 不超过 len(p)
+		m, err := readVolumeAt(v, part, inner)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// readVolumeAt 从分卷 v 载荷的 inner 偏移处读满 p.
+func readVolumeAt(v *openedVolume, p []byte, inner uint64) (int, error) {
+	f, err := os.Open(v.path) // #nosec G304 - 分卷路径由第一个分卷推导
+	if err != nil {
+		return 0, fmt.Errorf("open volume %s: %w", v.path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	n, err := f.ReadAt(p, int64(v.header.Size())+int64(inner)) // #nosec G115 - 不超过分卷大小
+	if errors.Is(err, io.EOF) {
+		return n, utils.NewCryptoError(utils.ErrInvalidFormat, fmt.Sprintf("Volume %d is truncated", v.header.Index))
+	}
+	if err != nil {
+		return n, fmt.Errorf("read volume %s: %w", v.path, err)
+	}
+	return n, nil
+}
+
+// Close 关闭当前打开的分卷.
+func (r *VolumeReader) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	//nolint:wrapcheck
+	return err
+}
+
+// JoinVolumes 校验并合并分卷集，将原加密文件写入 outputPath.
+func JoinVolumes(path, outputPath string, verifyKey VerifyingKey) (n int64, err error) {
+	r, err := OpenVolumes(path, verifyKey)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	out, err := os.OpenFile(outputPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, encryptedFilePerm) // #nosec G302,G304 - 与加密文件权限一致
+	if err != nil {
+		return 0, fmt.Errorf("create %s: %w", outputPath, err)
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("close %s: %w", outputPath, closeErr)
+		}
+		if err != nil {
+			_ = os.Remove(outputPath)
+		}
+	}()
+
+	n, err = io.Copy(out, r)
+	if err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// DecryptVolumes 直接从分卷集解密到 outputPath，不合并出完整的加密文件.
+// 分块文件经 ReadAt 逐块解密；其他格式顺序读取分卷（同时校验各分卷的载荷哈希）后在内存中解密.
+func DecryptVolumes(
+	r *VolumeReader,
+	outputPath string,
+	kyberPriv kem.PrivateKey,
+	ecdhPriv *ecdh.PrivateKey,
+	dilithiumPub VerifyingKey,
+) error {
+	header, err := format.ParseFileHeader(io.NewSectionReader(r, 0, r.Size()))
+	if err != nil {
+		return fmt.Errorf("parse file header: %w", err)
+	}
+	if header.IsChunked() {
+		return decryptChunkedTo(r, r.Size(), outputPath, kyberPriv, ecdhPriv, dilithiumPub)
+	}
+
+	plaintext, err := DecryptVolumesCore(r, kyberPriv, ecdhPriv, dilithiumPub)
+	if err != nil {
+		return err
+	}
+	return writeDecryptedFile(outputPath, plaintext)
+}
+
+// DecryptVolumesCore 顺序读取分卷集并在内存中解密，对应 DecryptFileCore.
+func DecryptVolumesCore(
+	r *VolumeReader,
+	kyberPriv kem.PrivateKey,
+	ecdhPriv *ecdh.PrivateKey,
+	dilithiumPub VerifyingKey,
+) ([]byte, error) {
+	encryptedData, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read volumes: %w", err)
+	}
+	return DecryptDataCore(encryptedData, kyberPriv, ecdhPriv, dilithiumPub)
+}
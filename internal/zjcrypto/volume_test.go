@@ -0,0 +1,288 @@
+package zjcrypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"codeberg.org/jiangfire/fzjjyz/internal/format"
+)
+
+// splitTestFile 生成随机文件并按 MinVolumeSize 切分，返回原文件内容和分卷路径.
+func splitTestFile(t *testing.T, keys testKeys, size int) ([]byte, []string) {
+	t.Helper()
+	data := make([]byte, size)
+	_, _ = rand.Read(data)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "data.fzj")
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	paths, err := SplitVolumes(input, input, MinVolumeSize, keys.dilithiumPriv)
+	if err != nil {
+		t.Fatalf("切分失败: %v", err)
+	}
+	return data, paths
+}
+
+func joinToBytes(t *testing.T, path string, keys testKeys) ([]byte, error) {
+	t.Helper()
+	output := filepath.Join(t.TempDir(), "joined.fzj")
+	if _, err := JoinVolumes(path, output, keys.dilithiumPub); err != nil {
+		if _, statErr := os.Stat(output); statErr == nil {
+			t.Error("合并失败后残留输出文件")
+		}
+		return nil, err
+	}
+	return os.ReadFile(output) // #nosec G304 - 测试环境使用临时文件路径
+}
+
+func TestVolumeRoundTrip(t *testing.T) {
+	keys := generateTestKeys(t)
+	payload := MinVolumeSize - format.VolumeHeaderSize
+
+	for _, size := range []int{0, 100, payload, payload + 1, 3*payload + 17} {
+		data, paths := splitTestFile(t, keys, size)
+		want := max((size+payload-1)/payload, 1)
+		if len(paths) != want {
+			t.Fatalf("size %d: %d volumes, want %d", size, len(paths), want)
+		}
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil || info.Size() > MinVolumeSize {
+				t.Fatalf("分卷 %s 超出大小限制: %v", path, err)
+			}
+			if !IsVolumeFile(path) {
+				t.Fatalf("IsVolumeFile(%s) = false", path)
+			}
+		}
+
+		got, err := joinToBytes(t, paths[0], keys)
+		if err != nil {
+			t.Fatalf("size %d: 合并失败: %v", size, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("size %d: 合并结果不一致", size)
+		}
+	}
+}
+
+func TestVolumeDetectsDamage(t *testing.T) {
+	keys := generateTestKeys(t)
+	payload := MinVolumeSize - format.VolumeHeaderSize
+	_, paths := splitTestFile(t, keys, 3*payload)
+	_, foreign := splitTestFile(t, keys, 3*payload)
+
+	read := func(path string) []byte {
+		data, err := os.ReadFile(path) // #nosec G304 - 测试环境使用临时文件路径
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	originals := make([][]byte, len(paths))
+	for i, path := range paths {
+		originals[i] = read(path)
+	}
+	restore := func() {
+		for i, path := range paths {
+			if err := os.WriteFile(path, originals[i], 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	cases := map[string]func(){
+		"missing": func() { _ = os.Remove(paths[1]) },
+		"reordered": func() {
+			_ = os.WriteFile(paths[1], originals[2], 0600)
+			_ = os.WriteFile(paths[2], originals[1], 0600)
+		},
+		"foreign":   func() { _ = os.WriteFile(paths[1], read(foreign[1]), 0600) },
+		"truncated": func() { _ = os.WriteFile(paths[2], originals[2][:len(originals[2])-1], 0600) },
+		"tampered payload": func() {
+			damaged := bytes.Clone(originals[1])
+			damaged[len(damaged)-1] ^= 0x01
+			_ = os.WriteFile(paths[1], damaged, 0600)
+		},
+		"tampered header": func() {
+			damaged := bytes.Clone(originals[0])
+			damaged[30] ^= 0x01 // Count 字段
+			_ = os.WriteFile(paths[0], damaged, 0600)
+		},
+	}
+	for name, damage := range cases {
+		restore()
+		damage()
+		if _, err := joinToBytes(t, paths[0], keys); err == nil {
+			t.Errorf("%s: 期望合并失败", name)
+		}
+	}
+
+	restore()
+	if _, err := joinToBytes(t, paths[1], keys); err != nil {
+		t.Errorf("从任一分卷开始合并失败: %v", err)
+	}
+}
+
+func TestVolumeSignatureKey(t *testing.T) {
+	keys := generateTestKeys(t)
+	other := generateTestKeys(t)
+	_, paths := splitTestFile(t, keys, 100)
+
+	if _, err := joinToBytes(t, paths[0], other); err == nil {
+		t.Error("期望使用错误公钥时验证失败")
+	}
+	output := filepath.Join(t.TempDir(), "joined.fzj")
+	if _, err := JoinVolumes(paths[0], output, nil); err != nil {
+		t.Errorf("未提供公钥时合并失败: %v", err)
+	}
+
+	if _, err := SplitVolumes(paths[0], paths[0]+".x", MinVolumeSize-1, keys.dilithiumPriv); err == nil {
+		t.Error("期望分卷大小过小时报错")
+	}
+	if _, err := OpenVolumes(filepath.Join(t.TempDir(), "plain.fzj"), nil); err == nil {
+		t.Error("期望非分卷文件名报错")
+	}
+}
+
+func TestVolumeWriterStreaming(t *testing.T) {
+	keys := generateTestKeys(t)
+	data := make([]byte, 3*MinVolumeSize+123)
+	_, _ = rand.Read(data)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "data.bin")
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	encryptors := map[string]func(w *VolumeWriter) error{
+		"gcm": func(w *VolumeWriter) error {
+			return EncryptFileTo(input, w, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, NoCompression)
+		},
+		// 分块加密最后通过 WriteAt 回填第一个分卷中的文件头
+		"chunked": func(w *VolumeWriter) error {
+			return EncryptFileChunkedTo(input, w, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, 0)
+		},
+	}
+	for name, encrypt := range encryptors {
+		base := filepath.Join(dir, name+".fzj")
+		w, err := CreateVolumes(base, MinVolumeSize, keys.dilithiumPriv)
+		if err != nil {
+			t.Fatalf("%s: 创建分卷失败: %v", name, err)
+		}
+		if err := encrypt(w); err != nil {
+			w.Abort()
+			t.Fatalf("%s: 加密失败: %v", name, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: 写入分卷头失败: %v", name, err)
+		}
+		if len(w.Paths()) < 4 {
+			t.Fatalf("%s: 期望至少 4 个分卷，实际 %d", name, len(w.Paths()))
+		}
+		if _, err := os.Stat(base); !os.IsNotExist(err) {
+			t.Errorf("%s: 不应写出未分卷的密文", name)
+		}
+
+		// 顺序读取校验每个分卷的载荷哈希，包括被 WriteAt 改写过的分卷
+		if _, err := joinToBytes(t, w.Paths()[0], keys); err != nil {
+			t.Fatalf("%s: 分卷载荷校验失败: %v", name, err)
+		}
+
+		r, err := OpenVolumes(w.Paths()[0], keys.dilithiumPub)
+		if err != nil {
+			t.Fatalf("%s: 打开分卷失败: %v", name, err)
+		}
+		output := filepath.Join(dir, name+".out")
+		err = DecryptVolumes(r, output, keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+		_ = r.Close()
+		if err != nil {
+			t.Fatalf("%s: 从分卷解密失败: %v", name, err)
+		}
+		got, _ := os.ReadFile(output) // #nosec G304 - 测试环境使用临时文件路径
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s: 解密结果不一致", name)
+		}
+	}
+}
+
+func TestVolumeWriterIndexedArchive(t *testing.T) {
+	sourceDir, files := createIndexedTestDir(t)
+	keys := generateTestKeys(t)
+
+	base := filepath.Join(t.TempDir(), "project.fzj")
+	w, err := CreateVolumes(base, MinVolumeSize, keys.dilithiumPriv)
+	if err != nil {
+		t.Fatalf("创建分卷失败: %v", err)
+	}
+	if _, err := EncryptDirectoryIndexedTo(sourceDir, w, keys.kyberPub, keys.ecdhPub, keys.dilithiumPriv, DefaultArchiveOptions); err != nil {
+		w.Abort()
+		t.Fatalf("索引存档加密失败: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("写入分卷头失败: %v", err)
+	}
+
+	r, err := OpenVolumes(w.Paths()[0], keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("打开分卷失败: %v", err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	archive, err := OpenIndexedArchiveAt(r, r.Size(), keys.kyberPriv, keys.ecdhPriv, keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("从分卷打开索引存档失败: %v", err)
+	}
+	defer func() {
+		_ = archive.Close()
+	}()
+	for name, want := range files {
+		var buf bytes.Buffer
+		if err := archive.WriteEntry(&buf, name); err != nil {
+			t.Fatalf("读取条目 %s 失败: %v", name, err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("条目 %s 内容不一致", name)
+		}
+	}
+}
+
+func TestVolumeReaderAt(t *testing.T) {
+	keys := generateTestKeys(t)
+	payload := MinVolumeSize - format.VolumeHeaderSize
+	data, paths := splitTestFile(t, keys, 2*payload+100)
+
+	r, err := OpenVolumes(paths[0], keys.dilithiumPub)
+	if err != nil {
+		t.Fatalf("打开分卷失败: %v", err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	// 跨越分卷边界的读取
+	buf := make([]byte, 200)
+	off := int64(payload - 100)
+	if n, err := r.ReadAt(buf, off); err != nil || n != len(buf) || !bytes.Equal(buf, data[off:off+200]) {
+		t.Errorf("跨分卷读取失败: n=%d err=%v", n, err)
+	}
+	if n, err := r.ReadAt(buf, int64(len(data)-50)); n != 50 || err == nil {
+		t.Errorf("读到末尾时应返回 io.EOF: n=%d err=%v", n, err)
+	}
+
+	w, err := CreateVolumes(filepath.Join(t.TempDir(), "x.fzj"), MinVolumeSize, keys.dilithiumPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Abort()
+	if _, err := w.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteAt([]byte("xy"), 2); err == nil {
+		t.Error("WriteAt 超出已写入范围时应报错")
+	}
+}